@@ -1,6 +1,6 @@
 module github.com/grafana/grafana-ci-otel-collector
 
-go 1.25.0
+go 1.26.0
 
 toolchain go1.26.5
 
@@ -8,27 +8,55 @@ replace github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver => .
 
 replace github.com/grafana/grafana-ci-otel-collector/receiver/githubactionsreceiver => ./receiver/githubactionsreceiver
 
+replace github.com/grafana/grafana-ci-otel-collector/receiver/gitlabcireceiver => ./receiver/gitlabcireceiver
+
+replace github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver => ./receiver/jenkinsreceiver
+
+replace github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver => ./receiver/buildkitereceiver
+
+replace github.com/grafana/grafana-ci-otel-collector/receiver/circlecireceiver => ./receiver/circlecireceiver
+
+replace github.com/grafana/grafana-ci-otel-collector/receiver/tektonargoreceiver => ./receiver/tektonargoreceiver
+
+replace github.com/grafana/grafana-ci-otel-collector/receiver/azuredevopsreceiver => ./receiver/azuredevopsreceiver
+
+replace github.com/grafana/grafana-ci-otel-collector/receiver/jsonwebhookreceiver => ./receiver/jsonwebhookreceiver
+
 replace github.com/grafana/grafana-ci-otel-collector/internal/traceutils => ./internal/traceutils
 
 replace github.com/grafana/grafana-ci-otel-collector/internal/semconv => ./internal/semconv
 
 replace github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent => ./internal/sharedcomponent
 
+replace github.com/grafana/grafana-ci-otel-collector/internal/logpolicy => ./internal/logpolicy
+
+replace github.com/grafana/grafana-ci-otel-collector/internal/cimodel => ./internal/cimodel
+
 require (
+	github.com/grafana/grafana-ci-otel-collector/receiver/azuredevopsreceiver v0.0.0-00010101000000-000000000000
+	github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver v0.0.0-00010101000000-000000000000
+	github.com/grafana/grafana-ci-otel-collector/receiver/circlecireceiver v0.0.0-00010101000000-000000000000
 	github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver v0.0.0-20250724144144-eaa9d8fde20a
 	github.com/grafana/grafana-ci-otel-collector/receiver/githubactionsreceiver v0.0.0-20250709143647-9e225ee7fe9b
+	github.com/grafana/grafana-ci-otel-collector/receiver/gitlabcireceiver v0.0.0-00010101000000-000000000000
+	github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver v0.0.0-00010101000000-000000000000
+	github.com/grafana/grafana-ci-otel-collector/receiver/jsonwebhookreceiver v0.0.0-00010101000000-000000000000
+	github.com/grafana/grafana-ci-otel-collector/receiver/tektonargoreceiver v0.0.0-00010101000000-000000000000
 )
 
 require (
+	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/99designs/httpsignatures-go v0.0.0-20170731043157-88528bf4ca7e // indirect
 	github.com/bradleyfalzon/ghinstallation/v2 v2.19.0 // indirect
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/drone/drone-go v1.7.1 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/felixge/httpsnoop v1.0.4 // indirect
 	github.com/foxboron/go-tpm-keyfiles v0.0.0-20260427185012-515ba073c4c1 // indirect
 	github.com/fsnotify/fsnotify v1.9.0 // indirect
 	github.com/go-logr/logr v1.4.3 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/go-sql-driver/mysql v1.9.3 // indirect
 	github.com/go-viper/mapstructure/v2 v2.5.0 // indirect
 	github.com/gobwas/glob v0.2.3 // indirect
 	github.com/golang-jwt/jwt/v4 v4.5.2 // indirect
@@ -37,9 +65,13 @@ require (
 	github.com/google/go-querystring v1.2.0 // indirect
 	github.com/google/go-tpm v0.9.9-0.20260124013517-8f8f42cba0de // indirect
 	github.com/google/uuid v1.6.0 // indirect
+	github.com/grafana/grafana-ci-otel-collector/internal/cimodel v0.0.0-00010101000000-000000000000 // indirect
+	github.com/grafana/grafana-ci-otel-collector/internal/logpolicy v0.0.0-00010101000000-000000000000 // indirect
 	github.com/grafana/grafana-ci-otel-collector/internal/semconv v0.0.0-20250724144144-eaa9d8fde20a // indirect
 	github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent v0.0.0-20250724144144-eaa9d8fde20a // indirect
 	github.com/grafana/grafana-ci-otel-collector/internal/traceutils v0.0.0-20250728232919-9f7e4a6957de // indirect
+	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
+	github.com/hashicorp/go-retryablehttp v0.7.8 // indirect
 	github.com/hashicorp/go-version v1.9.0 // indirect
 	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
 	github.com/jackc/pgpassfile v1.0.0 // indirect
@@ -48,16 +80,20 @@ require (
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
 	github.com/klauspost/compress v1.18.7 // indirect
-	github.com/knadh/koanf/maps v0.1.2 // indirect
-	github.com/knadh/koanf/providers/confmap v1.0.0 // indirect
+	github.com/knadh/koanf/maps v0.1.3 // indirect
+	github.com/knadh/koanf/providers/confmap v1.0.1 // indirect
 	github.com/knadh/koanf/v2 v2.3.4 // indirect
+	github.com/mattn/go-isatty v0.0.24 // indirect
 	github.com/mitchellh/copystructure v1.2.0 // indirect
 	github.com/mitchellh/reflectwalk v1.0.2 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.3-0.20250322232337-35a7c28c31ee // indirect
+	github.com/ncruces/go-strftime v1.0.0 // indirect
 	github.com/pierrec/lz4/v4 v4.1.26 // indirect
 	github.com/prometheus/common v0.67.5 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	github.com/rs/cors v1.11.1 // indirect
+	gitlab.com/gitlab-org/api/client-go v1.46.0 // indirect
 	go.opentelemetry.io/auto/sdk v1.2.1 // indirect
 	go.opentelemetry.io/collector/client v1.56.0 // indirect
 	go.opentelemetry.io/collector/component v1.56.0 // indirect
@@ -96,10 +132,15 @@ require (
 	golang.org/x/crypto v0.53.0 // indirect
 	golang.org/x/net v0.56.0 // indirect
 	golang.org/x/oauth2 v0.36.0 // indirect
-	golang.org/x/sync v0.21.0 // indirect
-	golang.org/x/sys v0.46.0 // indirect
+	golang.org/x/sync v0.23.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
 	golang.org/x/text v0.39.0 // indirect
+	golang.org/x/time v0.14.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20260715232425-e75dac1f907d // indirect
 	google.golang.org/grpc v1.82.1 // indirect
 	google.golang.org/protobuf v1.36.11 // indirect
+	modernc.org/libc v1.77.1 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.12.1 // indirect
+	modernc.org/sqlite v1.60.1 // indirect
 )
@@ -0,0 +1,33 @@
+module github.com/grafana/grafana-ci-otel-collector/internal/cimodel
+
+go 1.25.0
+
+toolchain go1.26.5
+
+replace github.com/grafana/grafana-ci-otel-collector/internal/logpolicy => ../logpolicy
+
+replace github.com/grafana/grafana-ci-otel-collector/internal/semconv => ../semconv
+
+replace github.com/grafana/grafana-ci-otel-collector/internal/traceutils => ../traceutils
+
+require (
+	github.com/grafana/grafana-ci-otel-collector/internal/logpolicy v0.0.0-00010101000000-000000000000
+	github.com/grafana/grafana-ci-otel-collector/internal/semconv v0.0.0-20250724144144-eaa9d8fde20a
+	github.com/grafana/grafana-ci-otel-collector/internal/traceutils v0.0.0-00010101000000-000000000000
+	github.com/hashicorp/golang-lru/v2 v2.0.7
+	github.com/stretchr/testify v1.11.1
+	go.opentelemetry.io/collector/pdata v1.56.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/hashicorp/go-version v1.9.0 // indirect
+	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
+	github.com/modern-go/reflect2 v1.0.3-0.20250322232337-35a7c28c31ee // indirect
+	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
+	go.opentelemetry.io/collector/featuregate v1.56.0 // indirect
+	go.uber.org/multierr v1.11.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
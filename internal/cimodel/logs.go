@@ -0,0 +1,59 @@
+package cimodel
+
+import (
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// ToLogs translates the pipeline, task and step logs of a pipeline to log records
+// correlated with their spans, applying the log policy to every task and
+// step. Missing IDs are filled in on p, so that ToTraces and ToLogs can be
+// called in any order.
+func ToLogs(p *Pipeline, policy *logpolicy.Policy, opts Options) plog.Logs {
+	fillIDs(p)
+
+	logs := plog.NewLogs()
+	var records plog.LogRecordSlice
+	now := pcommon.NewTimestampFromTime(time.Now())
+
+	appendEntries := func(entries []LogEntry, result Result, spanID pcommon.SpanID, attrs map[string]any) {
+		for _, entry := range logpolicy.Tail(policy, entries, result.Failed()) {
+			body, keep := policy.Apply(entry.Body)
+			if !keep {
+				continue
+			}
+
+			for _, chunk := range policy.Fit(body) {
+				if logs.ResourceLogs().Len() == 0 {
+					resourceLogs := logs.ResourceLogs().AppendEmpty()
+					SetResourceAttributes(resourceLogs.Resource().Attributes(), p, opts)
+					scopeLogs := resourceLogs.ScopeLogs().AppendEmpty()
+					scopeLogs.Scope().SetName(opts.ScopeName)
+					scopeLogs.Scope().SetVersion(opts.ScopeVersion)
+					records = scopeLogs.LogRecords()
+				}
+
+				record := records.AppendEmpty()
+				record.SetTraceID(p.TraceID)
+				record.SetSpanID(spanID)
+				record.SetObservedTimestamp(now)
+				record.SetTimestamp(pcommon.NewTimestampFromTime(entry.Timestamp))
+				putAttributes(record.Attributes(), attrs)
+				record.Body().SetStr(chunk)
+			}
+		}
+	}
+
+	appendEntries(p.Logs, p.Result, p.SpanID, p.LogAttributes)
+	for _, task := range p.Tasks {
+		appendEntries(task.Logs, task.Result, task.SpanID, task.LogAttributes)
+		for _, step := range task.Steps {
+			appendEntries(step.Logs, step.Result, step.SpanID, step.LogAttributes)
+		}
+	}
+
+	return logs
+}
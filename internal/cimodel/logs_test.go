@@ -0,0 +1,130 @@
+package cimodel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToLogs(t *testing.T) {
+	started := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	entries := []LogEntry{
+		{Timestamp: started, Body: "go test ./..."},
+		{Timestamp: started.Add(time.Second), Body: "DEBUG cache miss"},
+		{Timestamp: started.Add(2 * time.Second), Body: "FAIL"},
+	}
+
+	tests := map[string]struct {
+		policy         logpolicy.Config
+		result         Result
+		expectedBodies []string
+	}{
+		"all lines": {
+			result:         ResultSuccess,
+			expectedBodies: []string{"go test ./...", "DEBUG cache miss", "FAIL"},
+		},
+		"drop patterns": {
+			policy:         logpolicy.Config{DropPatterns: []string{"^DEBUG"}},
+			result:         ResultSuccess,
+			expectedBodies: []string{"go test ./...", "FAIL"},
+		},
+		"tail of successful steps": {
+			policy:         logpolicy.Config{SuccessTailLines: 1},
+			result:         ResultSuccess,
+			expectedBodies: []string{"FAIL"},
+		},
+		"failed steps are not tailed": {
+			policy:         logpolicy.Config{SuccessTailLines: 1},
+			result:         ResultFailure,
+			expectedBodies: []string{"go test ./...", "DEBUG cache miss", "FAIL"},
+		},
+		"split oversized entries": {
+			policy:         logpolicy.Config{MaxEntryBytes: 8, Oversize: logpolicy.OversizeSplit},
+			result:         ResultSuccess,
+			expectedBodies: []string{"go test ", "./...", "DEBUG ca", "che miss", "FAIL"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			policy, err := logpolicy.New(test.policy, nil)
+			require.NoError(t, err)
+
+			p := &Pipeline{
+				ResourceAttributes: map[string]any{"service.name": "app"},
+				Tasks: []Task{{Steps: []Step{{
+					Result:        test.result,
+					Logs:          entries,
+					LogAttributes: map[string]any{"ci.step.name": "test"},
+				}}}},
+			}
+			logs := ToLogs(p, policy, Options{ScopeName: "testreceiver"})
+			traces := ToTraces(p, Options{})
+			stepSpanID := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(2).SpanID()
+
+			require.Equal(t, 1, logs.ResourceLogs().Len())
+			require.Equal(t, "app", logs.ResourceLogs().At(0).Resource().Attributes().AsRaw()["service.name"])
+			records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+
+			var bodies []string
+			for i := range records.Len() {
+				record := records.At(i)
+				bodies = append(bodies, record.Body().Str())
+				require.Equal(t, p.TraceID, record.TraceID())
+				require.Equal(t, stepSpanID, record.SpanID())
+				require.Equal(t, "test", record.Attributes().AsRaw()["ci.step.name"])
+			}
+			require.Equal(t, test.expectedBodies, bodies)
+		})
+	}
+}
+
+func TestToLogsEmpty(t *testing.T) {
+	policy, err := logpolicy.New(logpolicy.Config{}, nil)
+	require.NoError(t, err)
+
+	logs := ToLogs(&Pipeline{Tasks: []Task{{Steps: []Step{{}}}}}, policy, Options{})
+	require.Equal(t, 0, logs.ResourceLogs().Len())
+}
+
+func TestToLogsTask(t *testing.T) {
+	policy, err := logpolicy.New(logpolicy.Config{SuccessTailLines: 1}, nil)
+	require.NoError(t, err)
+
+	p := &Pipeline{Tasks: []Task{{
+		Result:        ResultSuccess,
+		Logs:          []LogEntry{{Body: "Running with gitlab-runner"}, {Body: "Job succeeded"}},
+		LogAttributes: map[string]any{"ci.job.name": "test"},
+		Steps:         []Step{{Logs: []LogEntry{{Body: "step"}}}},
+	}}}
+	logs := ToLogs(p, policy, Options{})
+
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 2, records.Len())
+	require.Equal(t, "Job succeeded", records.At(0).Body().Str())
+	require.Equal(t, p.Tasks[0].SpanID, records.At(0).SpanID())
+	require.Equal(t, "test", records.At(0).Attributes().AsRaw()["ci.job.name"])
+	require.Equal(t, "step", records.At(1).Body().Str())
+	require.Equal(t, p.Tasks[0].Steps[0].SpanID, records.At(1).SpanID())
+}
+
+func TestToLogsPipeline(t *testing.T) {
+	policy, err := logpolicy.New(logpolicy.Config{}, nil)
+	require.NoError(t, err)
+
+	p := &Pipeline{
+		Logs:          []LogEntry{{Body: "Started by user admin"}},
+		LogAttributes: map[string]any{"ci.build.number": 18},
+		Tasks:         []Task{{Logs: []LogEntry{{Body: "make"}}}},
+	}
+	logs := ToLogs(p, policy, Options{})
+
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 2, records.Len())
+	require.Equal(t, "Started by user admin", records.At(0).Body().Str())
+	require.Equal(t, p.SpanID, records.At(0).SpanID())
+	require.EqualValues(t, 18, records.At(0).Attributes().AsRaw()["ci.build.number"])
+	require.Equal(t, p.Tasks[0].SpanID, records.At(1).SpanID())
+}
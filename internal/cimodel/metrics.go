@@ -0,0 +1,145 @@
+package cimodel
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// DurationBuckets are the explicit bounds, in seconds, of duration histograms
+var DurationBuckets = []float64{5, 15, 30, 60, 300, 600, 1800}
+
+// Histogram is the cumulative state of a duration histogram. Histograms are
+// emitted with cumulative temporality, so every emission carries the totals
+// of all observations.
+type Histogram struct {
+	Count        uint64
+	Sum          float64
+	BucketCounts []uint64
+	LastSeen     time.Time
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{
+		BucketCounts: make([]uint64, len(DurationBuckets)+1),
+	}
+}
+
+// Observe records a duration in seconds.
+func (h *Histogram) Observe(seconds float64) {
+	h.Count++
+	h.Sum += seconds
+	h.LastSeen = time.Now()
+	for i, b := range DurationBuckets {
+		if seconds <= b {
+			h.BucketCounts[i]++
+			return
+		}
+	}
+	h.BucketCounts[len(DurationBuckets)]++
+}
+
+// AppendHistogram appends a cumulative duration histogram with a single data point.
+func AppendHistogram(ms pmetric.MetricSlice, name string, attrs map[string]any, h *Histogram) {
+	m := ms.AppendEmpty()
+	m.SetName(name)
+	m.SetUnit("s")
+	m.SetEmptyHistogram()
+	m.Histogram().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+
+	dp := m.Histogram().DataPoints().AppendEmpty()
+	dp.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	dp.SetCount(h.Count)
+	dp.SetSum(h.Sum)
+	dp.ExplicitBounds().FromRaw(DurationBuckets)
+	dp.BucketCounts().FromRaw(h.BucketCounts)
+	putAttributes(dp.Attributes(), attrs)
+}
+
+// Durations accumulates the durations of finished pipelines and tasks in the
+// cicd.pipeline.run.duration and cicd.pipeline.task.run.duration histograms.
+// Histograms not observed for longer than the TTL start over.
+type Durations struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	histograms *lru.Cache[string, *Histogram]
+}
+
+func NewDurations(size int, ttl time.Duration) (*Durations, error) {
+	histograms, err := lru.New[string, *Histogram](size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Durations{
+		ttl:        ttl,
+		histograms: histograms,
+	}, nil
+}
+
+// AppendPipeline observes the duration of a pipeline run and appends its
+// histogram. The time runs waited before starting is observed in the pending
+// state when Queued is known.
+func (d *Durations) AppendPipeline(ms pmetric.MetricSlice, p *Pipeline) {
+	if p.Started.IsZero() || p.Finished.IsZero() {
+		return
+	}
+
+	if !p.Queued.IsZero() && !p.Started.Before(p.Queued) {
+		attrs := map[string]any{
+			semconv.AttributeCICDPipelineName:     p.Name,
+			semconv.AttributeCICDPipelineResult:   string(p.Result),
+			semconv.AttributeCICDPipelineRunState: semconv.AttributeCICDPipelineRunStatePending,
+			semconv.AttributeVCSOwnerName:         p.Repository.Owner,
+			semconv.AttributeVCSRepositoryName:    p.Repository.Name,
+		}
+		key := fmt.Sprintf("pending:%s:%s:%s:%s", p.Repository.Owner, p.Repository.Name, p.Name, p.Result)
+		d.append(ms, semconv.MetricCICDPipelineRunDuration, key, attrs, p.Started.Sub(p.Queued))
+	}
+
+	attrs := map[string]any{
+		semconv.AttributeCICDPipelineName:     p.Name,
+		semconv.AttributeCICDPipelineResult:   string(p.Result),
+		semconv.AttributeCICDPipelineRunState: semconv.AttributeCICDPipelineRunStateExecuting,
+		semconv.AttributeVCSOwnerName:         p.Repository.Owner,
+		semconv.AttributeVCSRepositoryName:    p.Repository.Name,
+	}
+	key := fmt.Sprintf("pipeline:%s:%s:%s:%s", p.Repository.Owner, p.Repository.Name, p.Name, p.Result)
+	d.append(ms, semconv.MetricCICDPipelineRunDuration, key, attrs, p.Finished.Sub(p.Started))
+}
+
+// AppendTask observes the duration of a task run and appends its histogram.
+func (d *Durations) AppendTask(ms pmetric.MetricSlice, p *Pipeline, task *Task) {
+	if task.Started.IsZero() || task.Finished.IsZero() {
+		return
+	}
+
+	attrs := map[string]any{
+		semconv.AttributeCICDPipelineName:          p.Name,
+		semconv.AttributeCICDPipelineTaskName:      task.Name,
+		semconv.AttributeCICDPipelineTaskRunResult: string(task.Result),
+		semconv.AttributeVCSOwnerName:              p.Repository.Owner,
+		semconv.AttributeVCSRepositoryName:         p.Repository.Name,
+	}
+	key := fmt.Sprintf("task:%s:%s:%s:%s:%s", p.Repository.Owner, p.Repository.Name, p.Name, task.Name, task.Result)
+	d.append(ms, semconv.MetricCICDPipelineTaskRunDuration, key, attrs, task.Finished.Sub(task.Started))
+}
+
+func (d *Durations) append(ms pmetric.MetricSlice, name, key string, attrs map[string]any, duration time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Stale histograms start over, the LRU evicts those never observed again
+	h, ok := d.histograms.Get(key)
+	if !ok || time.Since(h.LastSeen) >= d.ttl {
+		h = NewHistogram()
+	}
+	h.Observe(duration.Seconds())
+	d.histograms.Add(key, h)
+	AppendHistogram(ms, name, attrs, h)
+}
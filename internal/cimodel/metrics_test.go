@@ -0,0 +1,155 @@
+package cimodel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestHistogramObserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    float64
+		expected []uint64
+	}{
+		{
+			name:     "zero value falls in first bucket",
+			value:    0,
+			expected: []uint64{1, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:     "on boundary falls in that bucket",
+			value:    5,
+			expected: []uint64{1, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:     "between boundaries",
+			value:    10,
+			expected: []uint64{0, 1, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:     "on second boundary",
+			value:    15,
+			expected: []uint64{0, 1, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:     "between 30 and 60",
+			value:    45,
+			expected: []uint64{0, 0, 0, 1, 0, 0, 0, 0},
+		},
+		{
+			name:     "overflow beyond last boundary",
+			value:    5000,
+			expected: []uint64{0, 0, 0, 0, 0, 0, 0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHistogram()
+			h.Observe(tt.value)
+			require.Equal(t, tt.expected, h.BucketCounts)
+			require.Equal(t, uint64(1), h.Count)
+			require.Equal(t, tt.value, h.Sum)
+		})
+	}
+}
+
+func TestDurations(t *testing.T) {
+	started := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pipeline := &Pipeline{
+		Name:       "ci",
+		Result:     ResultFailure,
+		Started:    started,
+		Finished:   started.Add(45 * time.Second),
+		Repository: Repository{Owner: "grafana", Name: "app"},
+		Tasks: []Task{
+			{Name: "test", Result: ResultSuccess, Started: started, Finished: started.Add(10 * time.Second)},
+			{Name: "queued"},
+		},
+	}
+
+	durations, err := NewDurations(10, time.Hour)
+	require.NoError(t, err)
+
+	ms := pmetric.NewMetricSlice()
+	durations.AppendPipeline(ms, pipeline)
+	durations.AppendPipeline(ms, pipeline)
+	for i := range pipeline.Tasks {
+		durations.AppendTask(ms, pipeline, &pipeline.Tasks[i])
+	}
+	require.Equal(t, 3, ms.Len())
+
+	run := ms.At(1)
+	require.Equal(t, "cicd.pipeline.run.duration", run.Name())
+	dp := run.Histogram().DataPoints().At(0)
+	require.Equal(t, uint64(2), dp.Count())
+	require.Equal(t, 90.0, dp.Sum())
+	require.Equal(t, map[string]any{
+		"cicd.pipeline.name":      "ci",
+		"cicd.pipeline.result":    "failure",
+		"cicd.pipeline.run.state": "executing",
+		"vcs.owner.name":          "grafana",
+		"vcs.repository.name":     "app",
+	}, dp.Attributes().AsRaw())
+
+	task := ms.At(2)
+	require.Equal(t, "cicd.pipeline.task.run.duration", task.Name())
+	dp = task.Histogram().DataPoints().At(0)
+	require.Equal(t, uint64(1), dp.Count())
+	require.Equal(t, "test", dp.Attributes().AsRaw()["cicd.pipeline.task.name"])
+	require.Equal(t, "success", dp.Attributes().AsRaw()["cicd.pipeline.task.run.result"])
+}
+
+func TestDurationsQueued(t *testing.T) {
+	started := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pipeline := &Pipeline{
+		Name:       "ci",
+		Result:     ResultSuccess,
+		Queued:     started.Add(-30 * time.Second),
+		Started:    started,
+		Finished:   started.Add(45 * time.Second),
+		Repository: Repository{Owner: "grafana", Name: "app"},
+	}
+
+	durations, err := NewDurations(10, time.Hour)
+	require.NoError(t, err)
+
+	ms := pmetric.NewMetricSlice()
+	durations.AppendPipeline(ms, pipeline)
+	require.Equal(t, 2, ms.Len())
+
+	dp := ms.At(0).Histogram().DataPoints().At(0)
+	require.Equal(t, "cicd.pipeline.run.duration", ms.At(0).Name())
+	require.Equal(t, 30.0, dp.Sum())
+	require.Equal(t, "pending", dp.Attributes().AsRaw()["cicd.pipeline.run.state"])
+
+	dp = ms.At(1).Histogram().DataPoints().At(0)
+	require.Equal(t, 45.0, dp.Sum())
+	require.Equal(t, "executing", dp.Attributes().AsRaw()["cicd.pipeline.run.state"])
+
+	// Clock skew can't report negative waits
+	pipeline.Queued = started.Add(time.Second)
+	ms = pmetric.NewMetricSlice()
+	durations.AppendPipeline(ms, pipeline)
+	require.Equal(t, 1, ms.Len())
+}
+
+func TestDurationsTTL(t *testing.T) {
+	durations, err := NewDurations(10, time.Hour)
+	require.NoError(t, err)
+
+	started := time.Now()
+	pipeline := &Pipeline{Name: "ci", Started: started, Finished: started.Add(time.Second)}
+	durations.AppendPipeline(pmetric.NewMetricSlice(), pipeline)
+	for _, h := range durations.histograms.Values() {
+		h.LastSeen = time.Now().Add(-2 * time.Hour)
+	}
+
+	// Histograms not observed within the TTL start over
+	ms := pmetric.NewMetricSlice()
+	durations.AppendPipeline(ms, pipeline)
+	require.Equal(t, uint64(1), ms.At(0).Histogram().DataPoints().At(0).Count())
+}
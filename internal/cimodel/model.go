@@ -0,0 +1,153 @@
+// Package cimodel is a vendor-neutral model of CI pipeline runs and its
+// translation to traces, logs and metrics. Receivers map their webhook
+// payloads into a Pipeline, leaving the layout of the telemetry to this
+// package so that every CI system gets the same spans, logs and metrics.
+package cimodel
+
+import (
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// Result is the normalized outcome of a pipeline, task or step
+type Result string
+
+const (
+	ResultUnknown      Result = ""
+	ResultSuccess      Result = semconv.AttributeCICDResultSuccess
+	ResultFailure      Result = semconv.AttributeCICDResultFailure
+	ResultError        Result = semconv.AttributeCICDResultError
+	ResultTimeout      Result = semconv.AttributeCICDResultTimeout
+	ResultCancellation Result = semconv.AttributeCICDResultCancellation
+	ResultSkip         Result = semconv.AttributeCICDResultSkip
+)
+
+// Failed reports whether the result is an unsuccessful completion: a failure,
+// an error or a timeout. Cancellations, like skips, are not failures.
+func (r Result) Failed() bool {
+	switch r {
+	case ResultFailure, ResultError, ResultTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// Repository is the repository a pipeline runs for
+type Repository struct {
+	Provider string // e.g. github or gitlab
+	Owner    string
+	Name     string // name of the repository, without its owner
+	URL      string
+}
+
+// Ref is the revision a pipeline runs for
+type Ref struct {
+	Head     string // branch or tag
+	HeadType string // semconv.AttributeVCSRefTypeBranch or semconv.AttributeVCSRefTypeTag
+	Revision string
+	Base     string // target branch of a change
+	ChangeID string // pull or merge request number
+}
+
+// Pipeline is a run of a pipeline, the root of the model. Its span is the
+// parent of its tasks.
+type Pipeline struct {
+	ID       string
+	Name     string
+	URL      string
+	Result   Result
+	Status   string    // vendor status, used as span status message
+	Queued   time.Time // when the run was created, zero when unknown
+	Started  time.Time
+	Finished time.Time
+
+	Repository Repository
+	Ref        Ref
+
+	// TraceID and SpanID identify the run. Random IDs are generated when they are empty.
+	TraceID pcommon.TraceID
+	SpanID  pcommon.SpanID
+	// OmitSpan skips the pipeline span, for events only describing some of
+	// its tasks. The tasks keep SpanID as their parent.
+	OmitSpan bool
+	// ParentSpanID is the parent of the pipeline span, for runs grouped
+	// under a span of their own, such as the workflows of a CircleCI
+	// pipeline. Pipeline spans are root spans when it is empty.
+	ParentSpanID pcommon.SpanID
+	// Links are the traces of previous attempts of the run
+	Links []pcommon.TraceID
+
+	ResourceAttributes map[string]any
+	Attributes         map[string]any
+
+	// Logs of the run not attributed to any of its tasks, in order.
+	// LogAttributes are added to every record.
+	Logs          []LogEntry
+	LogAttributes map[string]any
+
+	Stages []Stage
+	Tasks  []Task
+}
+
+// Stage groups tasks of a pipeline, such as GitLab stages. Its span is the
+// parent of the tasks naming it.
+type Stage struct {
+	Name     string
+	Result   Result
+	Status   string // vendor status, used as span status message
+	Started  time.Time
+	Finished time.Time
+
+	SpanID     pcommon.SpanID
+	Attributes map[string]any
+}
+
+// Task is a unit of work of a pipeline, such as a job or a stage
+type Task struct {
+	ID       string
+	Name     string
+	URL      string
+	Stage    string   // name of the stage the task belongs to, if any
+	Parent   string   // ID of the task this one runs in, such as the stage of a parallel branch
+	Needs    []string // IDs of the tasks this one waits for, linked from its span
+	Worker   string   // runner or machine the task ran on
+	Result   Result
+	Status   string // vendor status, used as span status message
+	Started  time.Time
+	Finished time.Time
+
+	SpanID     pcommon.SpanID
+	Attributes map[string]any
+
+	// Logs of the task not attributed to any of its steps, in order.
+	// LogAttributes are added to every record.
+	Logs          []LogEntry
+	LogAttributes map[string]any
+
+	Steps []Step
+}
+
+// Step is a command or action run by a task
+type Step struct {
+	Name     string
+	Result   Result
+	Status   string // vendor status, used as span status message
+	Started  time.Time
+	Finished time.Time
+
+	SpanID     pcommon.SpanID
+	Attributes map[string]any
+
+	// Logs of the step, in order. LogAttributes are added to every record.
+	Logs          []LogEntry
+	LogAttributes map[string]any
+}
+
+// LogEntry is a line or multi-line entry of a step log
+type LogEntry struct {
+	Timestamp time.Time
+	Body      string
+}
@@ -0,0 +1,227 @@
+package cimodel
+
+import (
+	"slices"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/internal/traceutils"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Options configure the translation of pipelines
+type Options struct {
+	ScopeName    string
+	ScopeVersion string
+	Semconv      semconv.Config
+}
+
+// ToTraces translates a pipeline to a trace with a span per pipeline, stage,
+// task and step. Missing IDs are filled in on p.
+func ToTraces(p *Pipeline, opts Options) ptrace.Traces {
+	fillIDs(p)
+
+	traces := ptrace.NewTraces()
+	resourceSpans := traces.ResourceSpans().AppendEmpty()
+	SetResourceAttributes(resourceSpans.Resource().Attributes(), p, opts)
+
+	scopeSpans := resourceSpans.ScopeSpans().AppendEmpty()
+	scopeSpans.Scope().SetName(opts.ScopeName)
+	scopeSpans.Scope().SetVersion(opts.ScopeVersion)
+	spans := scopeSpans.Spans()
+
+	if !p.OmitSpan {
+		span := spans.AppendEmpty()
+		span.SetTraceID(p.TraceID)
+		span.SetParentSpanID(p.ParentSpanID)
+		span.SetSpanID(p.SpanID)
+		span.SetName(p.Name)
+		setSpan(span, p.Result, p.Status, p.Started, p.Finished)
+		for _, link := range p.Links {
+			span.Links().AppendEmpty().SetTraceID(link)
+		}
+
+		putAttributes(span.Attributes(), p.Attributes)
+		if opts.Semconv.Enabled {
+			setPipelineSemconvAttributes(span.Attributes(), p)
+		}
+	}
+
+	stageSpanIDs := make(map[string]pcommon.SpanID, len(p.Stages))
+	for i := range p.Stages {
+		stage := &p.Stages[i]
+		stageSpanIDs[stage.Name] = stage.SpanID
+
+		span := spans.AppendEmpty()
+		span.SetTraceID(p.TraceID)
+		span.SetParentSpanID(p.SpanID)
+		span.SetSpanID(stage.SpanID)
+		span.SetName(stage.Name)
+		setSpan(span, stage.Result, stage.Status, stage.Started, stage.Finished)
+
+		putAttributes(span.Attributes(), stage.Attributes)
+	}
+
+	taskSpanIDs := make(map[string]pcommon.SpanID, len(p.Tasks))
+	for _, task := range p.Tasks {
+		taskSpanIDs[task.ID] = task.SpanID
+	}
+
+	for i := range p.Tasks {
+		task := &p.Tasks[i]
+
+		parentSpanID, ok := taskSpanIDs[task.Parent]
+		if !ok || task.Parent == "" {
+			parentSpanID, ok = stageSpanIDs[task.Stage]
+		}
+		if !ok {
+			parentSpanID = p.SpanID
+		}
+
+		span := spans.AppendEmpty()
+		span.SetTraceID(p.TraceID)
+		span.SetParentSpanID(parentSpanID)
+		span.SetSpanID(task.SpanID)
+		span.SetName(task.Name)
+		setSpan(span, task.Result, task.Status, task.Started, task.Finished)
+		for _, need := range task.Needs {
+			if spanID, ok := taskSpanIDs[need]; ok {
+				link := span.Links().AppendEmpty()
+				link.SetTraceID(p.TraceID)
+				link.SetSpanID(spanID)
+			}
+		}
+
+		putAttributes(span.Attributes(), task.Attributes)
+		if opts.Semconv.Enabled {
+			setTaskSemconvAttributes(span.Attributes(), p, task)
+		}
+
+		for j := range task.Steps {
+			step := &task.Steps[j]
+
+			span := spans.AppendEmpty()
+			span.SetTraceID(p.TraceID)
+			span.SetParentSpanID(task.SpanID)
+			span.SetSpanID(step.SpanID)
+			span.SetName(step.Name)
+			setSpan(span, step.Result, step.Status, step.Started, step.Finished)
+
+			putAttributes(span.Attributes(), step.Attributes)
+		}
+	}
+
+	return traces
+}
+
+// SpanStatus returns the span status code of a result. Failed results are
+// reported as errors.
+func SpanStatus(result Result) ptrace.StatusCode {
+	switch {
+	case result == ResultSuccess:
+		return ptrace.StatusCodeOk
+	case result.Failed():
+		return ptrace.StatusCodeError
+	default:
+		return ptrace.StatusCodeUnset
+	}
+}
+
+func fillIDs(p *Pipeline) {
+	if p.TraceID.IsEmpty() {
+		p.TraceID = traceutils.NewTraceID()
+	}
+	if p.SpanID.IsEmpty() {
+		p.SpanID = traceutils.NewSpanID()
+	}
+	for i := range p.Stages {
+		if p.Stages[i].SpanID.IsEmpty() {
+			p.Stages[i].SpanID = traceutils.NewSpanID()
+		}
+	}
+	for i := range p.Tasks {
+		task := &p.Tasks[i]
+		if task.SpanID.IsEmpty() {
+			task.SpanID = traceutils.NewSpanID()
+		}
+		for j := range task.Steps {
+			if task.Steps[j].SpanID.IsEmpty() {
+				task.Steps[j].SpanID = traceutils.NewSpanID()
+			}
+		}
+	}
+}
+
+func setSpan(span ptrace.Span, result Result, status string, started, finished time.Time) {
+	span.SetKind(ptrace.SpanKindServer)
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(started))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(finished))
+	span.Status().SetCode(SpanStatus(result))
+	span.Status().SetMessage(status)
+}
+
+// SetResourceAttributes sets the resource attributes of a pipeline, with its
+// repository when semantic conventions are enabled, for receivers building
+// their telemetry outside of ToTraces and ToLogs.
+func SetResourceAttributes(attrs pcommon.Map, p *Pipeline, opts Options) {
+	putAttributes(attrs, p.ResourceAttributes)
+	if !opts.Semconv.Enabled {
+		return
+	}
+
+	putStr(attrs, semconv.AttributeVCSProviderName, p.Repository.Provider)
+	putStr(attrs, semconv.AttributeVCSOwnerName, p.Repository.Owner)
+	putStr(attrs, semconv.AttributeVCSRepositoryName, p.Repository.Name)
+	putStr(attrs, semconv.AttributeVCSRepositoryURLFull, p.Repository.URL)
+}
+
+func setPipelineSemconvAttributes(attrs pcommon.Map, p *Pipeline) {
+	putStr(attrs, semconv.AttributeCICDPipelineName, p.Name)
+	putStr(attrs, semconv.AttributeCICDPipelineRunID, p.ID)
+	putStr(attrs, semconv.AttributeCICDPipelineRunURLFull, p.URL)
+	putStr(attrs, semconv.AttributeCICDPipelineResult, string(p.Result))
+	setRefSemconvAttributes(attrs, p.Ref)
+}
+
+func setRefSemconvAttributes(attrs pcommon.Map, ref Ref) {
+	putStr(attrs, semconv.AttributeVCSRefHeadName, ref.Head)
+	putStr(attrs, semconv.AttributeVCSRefHeadType, ref.HeadType)
+	putStr(attrs, semconv.AttributeVCSRefHeadRevision, ref.Revision)
+	putStr(attrs, semconv.AttributeVCSRefBaseName, ref.Base)
+	putStr(attrs, semconv.AttributeVCSChangeID, ref.ChangeID)
+}
+
+func setTaskSemconvAttributes(attrs pcommon.Map, p *Pipeline, task *Task) {
+	putStr(attrs, semconv.AttributeCICDPipelineName, p.Name)
+	putStr(attrs, semconv.AttributeCICDPipelineRunID, p.ID)
+	putStr(attrs, semconv.AttributeCICDPipelineTaskName, task.Name)
+	putStr(attrs, semconv.AttributeCICDPipelineTaskRunID, task.ID)
+	putStr(attrs, semconv.AttributeCICDPipelineTaskRunURLFull, task.URL)
+	putStr(attrs, semconv.AttributeCICDPipelineTaskRunResult, string(task.Result))
+	putStr(attrs, semconv.AttributeCICDWorkerName, task.Worker)
+	// Tasks reported without their pipeline span carry its ref
+	if p.OmitSpan {
+		setRefSemconvAttributes(attrs, p.Ref)
+	}
+}
+
+// putAttributes copies raw attributes, in key order.
+func putAttributes(dst pcommon.Map, src map[string]any) {
+	keys := make([]string, 0, len(src))
+	for k := range src {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		_ = dst.PutEmpty(k).FromRaw(src[k])
+	}
+}
+
+// putStr sets non-empty string attributes.
+func putStr(dst pcommon.Map, key, value string) {
+	if value != "" {
+		dst.PutStr(key, value)
+	}
+}
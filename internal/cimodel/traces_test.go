@@ -0,0 +1,203 @@
+package cimodel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func testPipeline() *Pipeline {
+	started := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	return &Pipeline{
+		ID:       "42",
+		Name:     "ci",
+		URL:      "https://ci.example.com/42",
+		Result:   ResultFailure,
+		Status:   "failed",
+		Started:  started,
+		Finished: started.Add(time.Minute),
+		Repository: Repository{
+			Provider: semconv.AttributeVCSProviderNameGitlab,
+			Owner:    "grafana",
+			Name:     "app",
+		},
+		Ref:                Ref{Head: "main", HeadType: semconv.AttributeVCSRefTypeBranch, Revision: "abc123"},
+		Links:              []pcommon.TraceID{{1}},
+		ResourceAttributes: map[string]any{"service.name": "app"},
+		Attributes:         map[string]any{"ci.vendor.number": 42},
+		Tasks: []Task{{
+			ID:       "7",
+			Name:     "test",
+			Worker:   "runner-1",
+			Result:   ResultFailure,
+			Status:   "failed",
+			Started:  started,
+			Finished: started.Add(time.Minute),
+			Steps: []Step{
+				{Name: "checkout", Result: ResultSuccess, Started: started, Finished: started.Add(time.Second)},
+				{Name: "go test", Result: ResultFailure, Started: started.Add(time.Second), Finished: started.Add(time.Minute)},
+			},
+		}},
+	}
+}
+
+func TestToTraces(t *testing.T) {
+	p := testPipeline()
+	traces := ToTraces(p, Options{ScopeName: "testreceiver", ScopeVersion: "0.1.0"})
+
+	require.Equal(t, 4, traces.SpanCount())
+	rs := traces.ResourceSpans().At(0)
+	require.Equal(t, map[string]any{"service.name": "app"}, rs.Resource().Attributes().AsRaw())
+	require.Equal(t, "testreceiver", rs.ScopeSpans().At(0).Scope().Name())
+
+	spans := rs.ScopeSpans().At(0).Spans()
+	pipeline, task, checkout, test := spans.At(0), spans.At(1), spans.At(2), spans.At(3)
+
+	require.False(t, p.TraceID.IsEmpty())
+	for i := range spans.Len() {
+		require.Equal(t, p.TraceID, spans.At(i).TraceID())
+		require.Equal(t, ptrace.SpanKindServer, spans.At(i).Kind())
+	}
+
+	require.Equal(t, "ci", pipeline.Name())
+	require.True(t, pipeline.ParentSpanID().IsEmpty())
+	require.Equal(t, ptrace.StatusCodeError, pipeline.Status().Code())
+	require.Equal(t, "failed", pipeline.Status().Message())
+	require.Equal(t, map[string]any{"ci.vendor.number": int64(42)}, pipeline.Attributes().AsRaw())
+	require.Equal(t, 1, pipeline.Links().Len())
+
+	require.Equal(t, pipeline.SpanID(), task.ParentSpanID())
+	require.Equal(t, task.SpanID(), checkout.ParentSpanID())
+	require.Equal(t, task.SpanID(), test.ParentSpanID())
+	require.Equal(t, ptrace.StatusCodeOk, checkout.Status().Code())
+	require.Equal(t, time.Minute, test.EndTimestamp().AsTime().Sub(task.StartTimestamp().AsTime()))
+}
+
+func TestToTracesOmitSpan(t *testing.T) {
+	p := testPipeline()
+	p.SpanID = pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8}
+	p.OmitSpan = true
+
+	traces := ToTraces(p, Options{})
+	require.Equal(t, 3, traces.SpanCount())
+	require.Equal(t, p.SpanID, traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).ParentSpanID())
+}
+
+func TestToTracesParentSpan(t *testing.T) {
+	p := testPipeline()
+	p.SpanID = pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8}
+	p.ParentSpanID = pcommon.SpanID{8, 7, 6, 5, 4, 3, 2, 1}
+
+	spans := ToTraces(p, Options{}).ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	require.Equal(t, p.ParentSpanID, spans.At(0).ParentSpanID())
+	// Tasks keep the pipeline span as their parent.
+	require.Equal(t, p.SpanID, spans.At(1).ParentSpanID())
+}
+
+func TestToTracesStages(t *testing.T) {
+	p := testPipeline()
+	p.Stages = []Stage{{Name: "build", Result: ResultSuccess}, {Name: "test", Result: ResultFailure}}
+	p.Tasks[0].Stage = "test"
+	p.Tasks = append(p.Tasks, Task{Name: "lint"})
+
+	traces := ToTraces(p, Options{})
+	require.Equal(t, 7, traces.SpanCount())
+
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	build, test, task, lint := spans.At(1), spans.At(2), spans.At(3), spans.At(6)
+	require.Equal(t, "build", build.Name())
+	require.Equal(t, p.SpanID, build.ParentSpanID())
+	require.Equal(t, p.SpanID, test.ParentSpanID())
+	require.Equal(t, ptrace.StatusCodeError, test.Status().Code())
+	require.Equal(t, test.SpanID(), task.ParentSpanID())
+	require.Equal(t, "lint", lint.Name())
+	require.Equal(t, p.SpanID, lint.ParentSpanID())
+}
+
+func TestToTracesNestedTasks(t *testing.T) {
+	p := testPipeline()
+	p.Tasks[0].ID = "6"
+	p.Tasks = append(p.Tasks,
+		Task{ID: "9", Name: "unit", Parent: "6"},
+		Task{ID: "10", Name: "e2e", Parent: "unknown"},
+	)
+
+	traces := ToTraces(p, Options{})
+
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	stage, unit, e2e := spans.At(1), spans.At(4), spans.At(5)
+	require.Equal(t, "unit", unit.Name())
+	require.Equal(t, stage.SpanID(), unit.ParentSpanID())
+	require.Equal(t, "e2e", e2e.Name())
+	require.Equal(t, p.SpanID, e2e.ParentSpanID())
+}
+
+func TestToTracesNeeds(t *testing.T) {
+	p := testPipeline()
+	p.Tasks = append(p.Tasks, Task{ID: "8", Name: "deploy", Needs: []string{"7", "unknown"}})
+
+	traces := ToTraces(p, Options{})
+
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	test, deploy := spans.At(1), spans.At(4)
+	require.Equal(t, "deploy", deploy.Name())
+	require.Equal(t, p.SpanID, deploy.ParentSpanID())
+	require.Equal(t, 1, deploy.Links().Len())
+	require.Equal(t, p.TraceID, deploy.Links().At(0).TraceID())
+	require.Equal(t, test.SpanID(), deploy.Links().At(0).SpanID())
+}
+
+func TestToTracesSemconv(t *testing.T) {
+	traces := ToTraces(testPipeline(), Options{Semconv: semconv.Config{Enabled: true}})
+	rs := traces.ResourceSpans().At(0)
+
+	require.Equal(t, map[string]any{
+		"service.name":        "app",
+		"vcs.provider.name":   "gitlab",
+		"vcs.owner.name":      "grafana",
+		"vcs.repository.name": "app",
+	}, rs.Resource().Attributes().AsRaw())
+
+	spans := rs.ScopeSpans().At(0).Spans()
+	pipeline := spans.At(0).Attributes().AsRaw()
+	require.Equal(t, "ci", pipeline["cicd.pipeline.name"])
+	require.Equal(t, "42", pipeline["cicd.pipeline.run.id"])
+	require.Equal(t, "https://ci.example.com/42", pipeline["cicd.pipeline.run.url.full"])
+	require.Equal(t, "failure", pipeline["cicd.pipeline.result"])
+	require.Equal(t, "main", pipeline["vcs.ref.head.name"])
+	require.Equal(t, "branch", pipeline["vcs.ref.head.type"])
+	require.Equal(t, "abc123", pipeline["vcs.ref.head.revision"])
+	require.NotContains(t, pipeline, "vcs.ref.base.name")
+
+	task := spans.At(1).Attributes().AsRaw()
+	require.Equal(t, "ci", task["cicd.pipeline.name"])
+	require.Equal(t, "test", task["cicd.pipeline.task.name"])
+	require.Equal(t, "7", task["cicd.pipeline.task.run.id"])
+	require.Equal(t, "failure", task["cicd.pipeline.task.run.result"])
+	require.Equal(t, "runner-1", task["cicd.worker.name"])
+
+	require.Empty(t, spans.At(2).Attributes().AsRaw())
+}
+
+func TestSpanStatus(t *testing.T) {
+	tests := map[Result]ptrace.StatusCode{
+		ResultSuccess:      ptrace.StatusCodeOk,
+		ResultFailure:      ptrace.StatusCodeError,
+		ResultError:        ptrace.StatusCodeError,
+		ResultTimeout:      ptrace.StatusCodeError,
+		ResultCancellation: ptrace.StatusCodeUnset,
+		ResultSkip:         ptrace.StatusCodeUnset,
+		ResultUnknown:      ptrace.StatusCodeUnset,
+	}
+
+	for result, expected := range tests {
+		t.Run(string(result), func(t *testing.T) {
+			require.Equal(t, expected, SpanStatus(result))
+			require.Equal(t, expected == ptrace.StatusCodeError, result.Failed())
+		})
+	}
+}
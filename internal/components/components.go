@@ -1,6 +1,13 @@
 package components
 
 import (
+	_ "github.com/grafana/grafana-ci-otel-collector/receiver/azuredevopsreceiver"
+	_ "github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver"
+	_ "github.com/grafana/grafana-ci-otel-collector/receiver/circlecireceiver"
 	_ "github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver"
 	_ "github.com/grafana/grafana-ci-otel-collector/receiver/githubactionsreceiver"
+	_ "github.com/grafana/grafana-ci-otel-collector/receiver/gitlabcireceiver"
+	_ "github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver"
+	_ "github.com/grafana/grafana-ci-otel-collector/receiver/jsonwebhookreceiver"
+	_ "github.com/grafana/grafana-ci-otel-collector/receiver/tektonargoreceiver"
 )
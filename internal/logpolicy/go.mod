@@ -0,0 +1,13 @@
+module github.com/grafana/grafana-ci-otel-collector/internal/logpolicy
+
+go 1.25.0
+
+toolchain go1.26.5
+
+require github.com/stretchr/testify v1.11.1
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,268 @@
+// Package logpolicy implements sampling, truncation and redaction policies
+// applied to CI logs before they are exported.
+package logpolicy
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// Reasons reported to a Recorder when log lines are dropped.
+const (
+	DropReasonPattern = "pattern"
+	DropReasonTail    = "tail"
+)
+
+// RedactedPlaceholder replaces every redacted secret.
+const RedactedPlaceholder = "[REDACTED]"
+
+// Behaviours for entries larger than the maximum entry size.
+const (
+	OversizeTruncate = "truncate"
+	OversizeSplit    = "split"
+	OversizeDrop     = "drop"
+)
+
+const (
+	// DefaultMaxEntryBytes is the maximum entry size used when none is configured.
+	DefaultMaxEntryBytes = 1 * 1024 * 1024 // 1 MB
+	// TruncatedMarker is appended to truncated entries.
+	TruncatedMarker = "... [truncated]"
+)
+
+var errNegativeTailLines = errors.New("success_tail_lines must not be negative")
+var errMaxEntryBytes = fmt.Errorf("max_entry_bytes must be 0 or at least %d", len(TruncatedMarker)+1)
+var errOversize = fmt.Errorf("oversize must be one of %q, %q or %q", OversizeTruncate, OversizeSplit, OversizeDrop)
+
+// defaultSecretPatterns match commonly leaked credentials.
+var defaultSecretPatterns = []string{
+	// GitHub personal access, OAuth, user-to-server, server-to-server and refresh tokens
+	`\bgh[pousr]_[A-Za-z0-9]{36,255}\b`,
+	// GitHub fine-grained personal access tokens
+	`\bgithub_pat_[A-Za-z0-9_]{22,255}\b`,
+	// GitLab personal, project, group and CI job tokens
+	`\bgl(?:pat|cbt)-[A-Za-z0-9_.-]{20,}`,
+	// AWS access key IDs
+	`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`,
+	// AWS secret access keys assigned to a well-known variable name
+	`(?i)aws_secret_access_key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`,
+	// JSON Web Tokens
+	`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+	// Slack tokens
+	`\bxox[abposr]-[A-Za-z0-9-]{10,}`,
+	// Bearer tokens in authorization headers
+	`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`,
+}
+
+// Config defines which CI log lines are exported.
+type Config struct {
+	FailedOnly       bool     `mapstructure:"failed_only"`        // only export logs of failed jobs and steps. Default is false
+	SuccessTailLines int      `mapstructure:"success_tail_lines"` // only export the last N lines of successful steps, 0 exports all lines. Default is 0
+	DropPatterns     []string `mapstructure:"drop_patterns"`      // regular expressions, lines matching any of them are dropped. Default is empty
+	RedactSecrets    bool     `mapstructure:"redact_secrets"`     // redact well-known secrets such as tokens, AWS keys and JWTs. Default is false
+	RedactPatterns   []string `mapstructure:"redact_patterns"`    // additional regular expressions whose matches are redacted. Default is empty
+	MaxEntryBytes    int      `mapstructure:"max_entry_bytes"`    // maximum size of a log record body. Default is 1 MB
+	Oversize         string   `mapstructure:"oversize"`           // what to do with larger entries: truncate, split or drop. Default is truncate
+}
+
+// Validate checks the policy configuration is valid
+func (cfg *Config) Validate() error {
+	if cfg.SuccessTailLines < 0 {
+		return errNegativeTailLines
+	}
+	if cfg.MaxEntryBytes != 0 && cfg.MaxEntryBytes <= len(TruncatedMarker) {
+		return errMaxEntryBytes
+	}
+	switch cfg.Oversize {
+	case "", OversizeTruncate, OversizeSplit, OversizeDrop:
+	default:
+		return errOversize
+	}
+	if _, err := compile(cfg.DropPatterns); err != nil {
+		return fmt.Errorf("invalid drop_patterns: %w", err)
+	}
+	if _, err := compile(cfg.RedactPatterns); err != nil {
+		return fmt.Errorf("invalid redact_patterns: %w", err)
+	}
+	return nil
+}
+
+// Recorder is notified about log lines affected by a Policy.
+type Recorder interface {
+	RecordDroppedLines(n int64, reason string)
+	RecordRedactedLines(n int64)
+	RecordOversizedEntries(n int64, behaviour string)
+}
+
+type nopRecorder struct{}
+
+func (nopRecorder) RecordDroppedLines(int64, string)     {}
+func (nopRecorder) RecordRedactedLines(int64)            {}
+func (nopRecorder) RecordOversizedEntries(int64, string) {}
+
+// Policy applies a compiled Config to log lines.
+type Policy struct {
+	failedOnly bool
+	tailLines  int
+	maxBytes   int
+	oversize   string
+	drop       []*regexp.Regexp
+	redact     []*regexp.Regexp
+	recorder   Recorder
+}
+
+// New compiles cfg into a Policy reporting to recorder, which may be nil.
+func New(cfg Config, recorder Recorder) (*Policy, error) {
+	drop, err := compile(cfg.DropPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid drop_patterns: %w", err)
+	}
+
+	redactPatterns := cfg.RedactPatterns
+	if cfg.RedactSecrets {
+		redactPatterns = append(append([]string{}, defaultSecretPatterns...), redactPatterns...)
+	}
+	redact, err := compile(redactPatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redact_patterns: %w", err)
+	}
+
+	if recorder == nil {
+		recorder = nopRecorder{}
+	}
+
+	maxBytes := cfg.MaxEntryBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxEntryBytes
+	}
+	oversize := cfg.Oversize
+	if oversize == "" {
+		oversize = OversizeTruncate
+	}
+
+	return &Policy{
+		failedOnly: cfg.FailedOnly,
+		tailLines:  cfg.SuccessTailLines,
+		maxBytes:   maxBytes,
+		oversize:   oversize,
+		drop:       drop,
+		redact:     redact,
+		recorder:   recorder,
+	}, nil
+}
+
+// NeedsOutcome reports whether the policy depends on the outcome of a job or step.
+func (p *Policy) NeedsOutcome() bool {
+	return p.failedOnly || p.tailLines > 0
+}
+
+// KeepLogs reports whether the logs of a job or step with the given outcome are exported at all.
+func (p *Policy) KeepLogs(failed bool) bool {
+	return failed || !p.failedOnly
+}
+
+// TailLines returns how many trailing lines of a step with the given outcome
+// are exported, 0 meaning all of them.
+func (p *Policy) TailLines(failed bool) int {
+	if failed {
+		return 0
+	}
+	return p.tailLines
+}
+
+// Tail returns the trailing lines of a step with the given outcome that are
+// exported, reporting the dropped ones.
+func Tail[T any](p *Policy, lines []T, failed bool) []T {
+	n := p.TailLines(failed)
+	if n == 0 || len(lines) <= n {
+		return lines
+	}
+	p.recorder.RecordDroppedLines(int64(len(lines)-n), DropReasonTail)
+	return lines[len(lines)-n:]
+}
+
+// Apply drops the line if it matches a drop pattern and redacts secrets
+// from it otherwise. It returns false if the line must be dropped.
+func (p *Policy) Apply(line string) (string, bool) {
+	for _, re := range p.drop {
+		if re.MatchString(line) {
+			p.recorder.RecordDroppedLines(1, DropReasonPattern)
+			return "", false
+		}
+	}
+
+	redacted := false
+	for _, re := range p.redact {
+		if re.MatchString(line) {
+			line = re.ReplaceAllLiteralString(line, RedactedPlaceholder)
+			redacted = true
+		}
+	}
+	if redacted {
+		p.recorder.RecordRedactedLines(1)
+	}
+
+	return line, true
+}
+
+// RecordTailDropped reports n lines dropped because they were not part of a step's tail.
+func (p *Policy) RecordTailDropped(n int) {
+	if n > 0 {
+		p.recorder.RecordDroppedLines(int64(n), DropReasonTail)
+	}
+}
+
+// MaxEntryBytes returns the maximum size of a log record body.
+func (p *Policy) MaxEntryBytes() int {
+	return p.maxBytes
+}
+
+// Fit applies the oversize behaviour to an entry, returning the record bodies
+// to export. Entries within the maximum size are returned as is.
+func (p *Policy) Fit(entry string) []string {
+	if len(entry) <= p.maxBytes {
+		return []string{entry}
+	}
+
+	p.recorder.RecordOversizedEntries(1, p.oversize)
+
+	switch p.oversize {
+	case OversizeDrop:
+		return nil
+	case OversizeSplit:
+		var chunks []string
+		for len(entry) > p.maxBytes {
+			cut := runeBoundary(entry, p.maxBytes)
+			chunks = append(chunks, entry[:cut])
+			entry = entry[cut:]
+		}
+		return append(chunks, entry)
+	default:
+		return []string{entry[:runeBoundary(entry, p.maxBytes-len(TruncatedMarker))] + TruncatedMarker}
+	}
+}
+
+// runeBoundary returns the largest index not greater than n at which s can be
+// cut without splitting a UTF-8 encoded rune.
+func runeBoundary(s string, n int) int {
+	for i := n; i > n-utf8.UTFMax && i > 0; i-- {
+		if utf8.RuneStart(s[i]) {
+			return i
+		}
+	}
+	return n
+}
+
+func compile(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
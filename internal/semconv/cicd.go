@@ -0,0 +1,208 @@
+package semconv
+
+// Config selects the attribute vocabulary emitted by the receivers
+type Config struct {
+	Enabled    bool `mapstructure:"enabled"`     // emit the OpenTelemetry CICD and VCS semantic conventions. Default is false
+	EmitLegacy bool `mapstructure:"emit_legacy"` // keep emitting the legacy attributes alongside the semantic conventions. Default is false
+}
+
+// EmitsLegacy reports whether the legacy attributes are emitted.
+func (c Config) EmitsLegacy() bool {
+	return !c.Enabled || c.EmitLegacy
+}
+
+// The following attributes are defined by the OpenTelemetry CICD and VCS
+// semantic conventions. See
+// https://opentelemetry.io/docs/specs/semconv/registry/attributes/cicd/ and
+// https://opentelemetry.io/docs/specs/semconv/registry/attributes/vcs/
+
+// CICD pipeline info
+const (
+	// AttributeCICDPipelineName
+	// The human readable name of the pipeline within a CI/CD system.
+	//
+	// Type: string
+	// Stability: development
+	AttributeCICDPipelineName = "cicd.pipeline.name"
+	// AttributeCICDPipelineResult
+	// The result of a pipeline run.
+	//
+	// Type: Enum
+	// Stability: development
+	AttributeCICDPipelineResult = "cicd.pipeline.result"
+	// AttributeCICDPipelineRunID
+	// The unique identifier of a pipeline run within a CI/CD system.
+	//
+	// Type: string
+	// Stability: development
+	AttributeCICDPipelineRunID = "cicd.pipeline.run.id"
+	// AttributeCICDPipelineRunState
+	// The pipeline run goes through these states during its lifecycle.
+	//
+	// Type: Enum
+	// Stability: development
+	AttributeCICDPipelineRunState = "cicd.pipeline.run.state"
+	// AttributeCICDPipelineRunURLFull
+	// The URL of the pipeline run.
+	//
+	// Type: string
+	// Stability: development
+	AttributeCICDPipelineRunURLFull = "cicd.pipeline.run.url.full"
+)
+
+// CICD pipeline task info
+const (
+	// AttributeCICDPipelineTaskName
+	// The human readable name of a task within a pipeline.
+	//
+	// Type: string
+	// Stability: development
+	AttributeCICDPipelineTaskName = "cicd.pipeline.task.name"
+	// AttributeCICDPipelineTaskRunID
+	// The unique identifier of a task run within a pipeline.
+	//
+	// Type: string
+	// Stability: development
+	AttributeCICDPipelineTaskRunID = "cicd.pipeline.task.run.id"
+	// AttributeCICDPipelineTaskRunResult
+	// The result of a task run.
+	//
+	// Type: Enum
+	// Stability: development
+	AttributeCICDPipelineTaskRunResult = "cicd.pipeline.task.run.result"
+	// AttributeCICDPipelineTaskRunURLFull
+	// The URL of the pipeline task run.
+	//
+	// Type: string
+	// Stability: development
+	AttributeCICDPipelineTaskRunURLFull = "cicd.pipeline.task.run.url.full"
+	// AttributeCICDWorkerName
+	// The name of a worker within a CI/CD system.
+	//
+	// Type: string
+	// Stability: development
+	AttributeCICDWorkerName = "cicd.worker.name"
+)
+
+// Pipeline and task run result enum
+const (
+	AttributeCICDResultSuccess      = "success"
+	AttributeCICDResultFailure      = "failure"
+	AttributeCICDResultError        = "error"
+	AttributeCICDResultTimeout      = "timeout"
+	AttributeCICDResultCancellation = "cancellation"
+	AttributeCICDResultSkip         = "skip"
+)
+
+// Pipeline run state enum
+const (
+	AttributeCICDPipelineRunStatePending    = "pending"
+	AttributeCICDPipelineRunStateExecuting  = "executing"
+	AttributeCICDPipelineRunStateFinalizing = "finalizing"
+)
+
+// VCS repository info
+const (
+	// AttributeVCSOwnerName
+	// The group owner within the version control system.
+	//
+	// Type: string
+	// Stability: development
+	AttributeVCSOwnerName = "vcs.owner.name"
+	// AttributeVCSProviderName
+	// The name of the version control system provider.
+	//
+	// Type: Enum
+	// Stability: development
+	AttributeVCSProviderName = "vcs.provider.name"
+	// AttributeVCSRepositoryName
+	// The human readable name of the repository.
+	//
+	// Type: string
+	// Stability: development
+	AttributeVCSRepositoryName = "vcs.repository.name"
+	// AttributeVCSRepositoryURLFull
+	// The canonical URL of the repository.
+	//
+	// Type: string
+	// Stability: development
+	AttributeVCSRepositoryURLFull = "vcs.repository.url.full"
+)
+
+// VCS reference info
+const (
+	// AttributeVCSChangeID
+	// The ID of the change (pull request/merge request) if applicable.
+	//
+	// Type: string
+	// Stability: development
+	AttributeVCSChangeID = "vcs.change.id"
+	// AttributeVCSRefBaseName
+	// The name of the reference the head reference is compared against.
+	//
+	// Type: string
+	// Stability: development
+	AttributeVCSRefBaseName = "vcs.ref.base.name"
+	// AttributeVCSRefHeadName
+	// The name of the reference such as branch or tag.
+	//
+	// Type: string
+	// Stability: development
+	AttributeVCSRefHeadName = "vcs.ref.head.name"
+	// AttributeVCSRefHeadRevision
+	// The revision, literally revised version, of the head reference.
+	//
+	// Type: string
+	// Stability: development
+	AttributeVCSRefHeadRevision = "vcs.ref.head.revision"
+	// AttributeVCSRefHeadType
+	// The type of the head reference.
+	//
+	// Type: Enum
+	// Stability: development
+	AttributeVCSRefHeadType = "vcs.ref.head.type"
+)
+
+// VCS reference type enum
+const (
+	AttributeVCSRefTypeBranch = "branch"
+	AttributeVCSRefTypeTag    = "tag"
+)
+
+// VCS provider name enum
+const (
+	AttributeVCSProviderNameGithub    = "github"
+	AttributeVCSProviderNameGitlab    = "gitlab"
+	AttributeVCSProviderNameGitea     = "gitea"
+	AttributeVCSProviderNameBitbucket = "bitbucket"
+)
+
+// Deployment info, see
+// https://opentelemetry.io/docs/specs/semconv/registry/attributes/deployment/
+const (
+	// AttributeDeploymentEnvironmentName
+	// Name of the deployment environment, such as staging or production.
+	//
+	// Type: string
+	// Stability: development
+	AttributeDeploymentEnvironmentName = "deployment.environment.name"
+)
+
+// CICD metrics
+const (
+	// MetricCICDPipelineRunDuration
+	// Duration of a pipeline run grouped by pipeline, state and result.
+	//
+	// Type: Histogram
+	// Unit: s
+	// Stability: development
+	MetricCICDPipelineRunDuration = "cicd.pipeline.run.duration"
+	// MetricCICDPipelineTaskRunDuration
+	// Duration of a pipeline task run grouped by pipeline, task and result.
+	// Not part of the upstream conventions, named after cicd.pipeline.run.duration.
+	//
+	// Type: Histogram
+	// Unit: s
+	// Stability: development
+	MetricCICDPipelineTaskRunDuration = "cicd.pipeline.task.run.duration"
+)
@@ -22,7 +22,8 @@ const (
 // AttributeCIVendorDrone
 // CI/CD system vendor enum
 const (
-	AttributeCIVendorDrone = "drone"
+	AttributeCIVendorDrone      = "drone"
+	AttributeCIVendorWoodpecker = "woodpecker"
 )
 
 const (
@@ -43,7 +44,7 @@ const (
 	// Type: Enum
 	// Required: No
 	// Stability: alpha
-	AttributeDroneWorkflowItemKind = "ci.drone.workflow_item.kind" // build | stage | step
+	AttributeDroneWorkflowItemKind = "ci.drone.workflow_item.kind" // build | stage | step | approval
 	// AttributeDroneWorkflowEvent
 	// Drone workflow event Indicatates which event triggeed the workflow.
 	//
@@ -64,9 +65,10 @@ const (
 
 // Drone workflow item kind enum.
 const (
-	AttributeDroneWorkflowItemKindBuild = "build"
-	AttributeDroneWorkflowItemKindStage = "stage"
-	AttributeDroneWorkflowItemKindStep  = "step"
+	AttributeDroneWorkflowItemKindBuild    = "build"
+	AttributeDroneWorkflowItemKindStage    = "stage"
+	AttributeDroneWorkflowItemKindStep     = "step"
+	AttributeDroneWorkflowItemKindApproval = "approval"
 )
 
 // Drone build info
@@ -101,6 +103,12 @@ const (
 	AttributesDroneBuildRef    = "ci.drone.build.ref"
 	AttributesDroneBuildLink   = "ci.drone.build.link"
 	AttributesDroneBuildParent = "ci.drone.build.parent"
+
+	// AttributesDroneBuildDeployTo is the target of promotions and rollbacks
+	AttributesDroneBuildDeployTo = "ci.drone.build.deploy_to"
+	AttributesDroneBuildDeployID = "ci.drone.build.deploy_id"
+	// AttributesDroneBuildCron is the name of the cron job of cron builds
+	AttributesDroneBuildCron = "ci.drone.build.cron"
 )
 
 // Drone stage info
@@ -126,6 +134,24 @@ const (
 	// Required: No
 	// Stability: alpha
 	AttributeDroneStageName = "ci.drone.stage.name"
+	// AttributeDroneStageDependsOn
+	// Names of the stages the stage waits for.
+	//
+	// Type: string[]
+	// Required: No
+	// Stability: alpha
+	AttributeDroneStageDependsOn = "ci.drone.stage.depends_on"
+)
+
+// Drone approval info
+const (
+	// AttributeDroneApprovalApprover
+	// User who approved or declined the build or stage, when the CI records it.
+	//
+	// Type: string
+	// Required: No
+	// Stability: alpha
+	AttributeDroneApprovalApprover = "ci.drone.approval.approver"
 )
 
 // Drone step info
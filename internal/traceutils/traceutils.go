@@ -7,9 +7,7 @@ import (
 	"math/rand"
 
 	"github.com/google/uuid"
-	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
 	"go.opentelemetry.io/collector/pdata/pcommon"
-	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
 func NewTraceID() pcommon.TraceID {
@@ -27,19 +25,3 @@ func NewSpanID() pcommon.SpanID {
 
 	return spanID
 }
-
-func getOtelExitCode(code string) ptrace.StatusCode {
-	switch code {
-	case "failure", "error":
-		return ptrace.StatusCodeError
-	case "success":
-		return ptrace.StatusCodeOk
-	default:
-		return ptrace.StatusCodeUnset
-	}
-}
-
-func SetStatus(status string, span ptrace.Span) {
-	span.Attributes().PutStr(semconv.AttributeCIWorkflowItemStatus, status)
-	span.Status().SetCode(getOtelExitCode(status))
-}
@@ -0,0 +1,156 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// apiVersion is the version of the REST API requested.
+const apiVersion = "7.1"
+
+// maxLogLineBytes bounds the lines of the logs of tasks.
+const maxLogLineBytes = 1024 * 1024
+
+// errNotFound is returned for missing resources, such as the timelines of
+// projects the token cannot read.
+var errNotFound = errors.New("not found")
+
+// Types of timeline records. Phases group the jobs of a stage, and
+// checkpoints the approvals and checks of a stage.
+const (
+	recordStage      = "Stage"
+	recordPhase      = "Phase"
+	recordJob        = "Job"
+	recordTask       = "Task"
+	recordCheckpoint = "Checkpoint"
+)
+
+// timelineRecord is a record of the timeline of a build: a stage, phase,
+// job or task.
+type timelineRecord struct {
+	ID           string `json:"id"`
+	ParentID     string `json:"parentId"`
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	Identifier   string `json:"identifier"`
+	Order        int64  `json:"order"`
+	State        string `json:"state"`
+	Result       string `json:"result"`
+	StartTime    string `json:"startTime"`
+	FinishTime   string `json:"finishTime"`
+	WorkerName   string `json:"workerName"`
+	Attempt      int64  `json:"attempt"`
+	ErrorCount   int64  `json:"errorCount"`
+	WarningCount int64  `json:"warningCount"`
+	Log          *struct {
+		ID int64 `json:"id"`
+	} `json:"log"`
+}
+
+// azureDevOpsClient reads the timelines and logs of builds from the REST
+// API of an organization or collection.
+type azureDevOpsClient struct {
+	baseURL *url.URL
+	token   string
+	client  *http.Client
+}
+
+func newAzureDevOpsClient(cfg AzureDevOpsAPIConfig) (*azureDevOpsClient, error) {
+	baseURL, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+
+	return &azureDevOpsClient{
+		baseURL: baseURL,
+		token:   cfg.Token,
+		client:  &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// timeline returns the records of the timeline of a build.
+func (c *azureDevOpsClient) timeline(ctx context.Context, projectID string, buildID int64) ([]timelineRecord, error) {
+	var timeline struct {
+		Records []timelineRecord `json:"records"`
+	}
+
+	resp, err := c.get(ctx, buildRef(projectID, buildID, "timeline"), "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&timeline); err != nil {
+		return nil, err
+	}
+	return timeline.Records, nil
+}
+
+// log returns the lines of a log of a build.
+func (c *azureDevOpsClient) log(ctx context.Context, projectID string, buildID, logID int64) ([]string, error) {
+	resp, err := c.get(ctx, buildRef(projectID, buildID, "logs/"+strconv.FormatInt(logID, 10)), "text/plain")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, maxLogLineBytes)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// buildRef returns the reference of a resource of a build.
+func buildRef(projectID string, buildID int64, resource string) string {
+	return url.PathEscape(projectID) + "/_apis/build/builds/" + strconv.FormatInt(buildID, 10) + "/" + resource + "?api-version=" + apiVersion
+}
+
+func (c *azureDevOpsClient) get(ctx context.Context, ref, accept string) (*http.Response, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+	u = c.baseURL.ResolveReference(u)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	// Personal access tokens are sent as the password of any user
+	req.SetBasicAuth("", c.token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", u.Path, errNotFound)
+	case resp.StatusCode >= http.StatusBadRequest:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: unexpected status %s", u.Path, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,100 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testRunID = 1235
+
+// newAzureDevOpsTestServer serves the timeline and logs of run 1235 of the
+// apps/web-app CI pipeline, as recorded from the REST API.
+func newAzureDevOpsTestServer(t *testing.T) *azureDevOpsClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Personal access tokens are the password of any user.
+		_, token, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "api-token", token)
+		require.Equal(t, apiVersion, r.URL.Query().Get("api-version"))
+
+		prefix := fmt.Sprintf("/acme/%s/_apis/build/builds/%d/", testProjectID, testRunID)
+		var name string
+		var logID int
+		switch {
+		case r.URL.Path == prefix+"timeline":
+			name = "timeline.json"
+		case sscanf(r.URL.Path, prefix+"logs/%d", &logID):
+			name = fmt.Sprintf("log_%d.txt", logID)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", name))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := newAzureDevOpsClient(AzureDevOpsAPIConfig{
+		BaseURL: server.URL + "/acme",
+		Token:   "api-token",
+		Timeout: time.Second,
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func sscanf(s, format string, args ...any) bool {
+	n, err := fmt.Sscanf(s, format, args...)
+	return err == nil && n == len(args)
+}
+
+func TestTimeline(t *testing.T) {
+	client := newAzureDevOpsTestServer(t)
+
+	records, err := client.timeline(t.Context(), testProjectID, testRunID)
+	require.NoError(t, err)
+	require.Len(t, records, 8)
+	require.Equal(t, recordStage, records[0].Type)
+	require.Equal(t, "Build", records[0].Identifier)
+	require.Equal(t, "Hosted Agent", records[2].WorkerName)
+	require.EqualValues(t, 5, records[2].Log.ID)
+	require.Nil(t, records[5].Log)
+
+	_, err = client.timeline(t.Context(), testProjectID, 1)
+	require.ErrorIs(t, err, errNotFound)
+}
+
+func TestLog(t *testing.T) {
+	client := newAzureDevOpsTestServer(t)
+
+	lines, err := client.log(t.Context(), testProjectID, testRunID, 3)
+	require.NoError(t, err)
+	require.Len(t, lines, 7)
+	require.Equal(t, "2024-05-12T11:00:31.2000000Z npm test", lines[2])
+
+	_, err = client.log(t.Context(), testProjectID, testRunID, 9)
+	require.ErrorIs(t, err, errNotFound)
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := newAzureDevOpsClient(AzureDevOpsAPIConfig{BaseURL: server.URL, Token: "expired", Timeout: time.Second})
+	require.NoError(t, err)
+
+	_, err = client.timeline(t.Context(), testProjectID, testRunID)
+	require.ErrorContains(t, err, "401")
+}
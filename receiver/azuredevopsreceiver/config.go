@@ -0,0 +1,96 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/azuredevopsreceiver"
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/azuredevopsreceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.uber.org/multierr"
+)
+
+var errMissingEndpointFromConfig = errors.New("missing receiver server endpoint from config")
+var errMissingUsername = errors.New("basic_auth username is required with a password")
+var errBaseURL = errors.New("azuredevops_api base_url must be an absolute http or https URL")
+var errMissingBaseURL = errors.New("azuredevops_api base_url is required with a token")
+var errTimeout = errors.New("azuredevops_api timeout must not be negative")
+var errQueueSize = errors.New("azuredevops_api queue_size must be positive")
+
+// BasicAuthConfig defines the credentials of the basic authentication of
+// service hooks
+type BasicAuthConfig struct {
+	Username string `mapstructure:"username"` // user name of the service hook. Default is empty, disabling basic authentication
+	Password string `mapstructure:"password"` // password of the service hook. Default is empty
+}
+
+// AzureDevOpsAPIConfig defines configuration for the Azure DevOps REST API
+type AzureDevOpsAPIConfig struct {
+	BaseURL   string        `mapstructure:"base_url"`   // URL of the organization or collection, such as https://dev.azure.com/acme/. Default is empty
+	Token     string        `mapstructure:"token"`      // personal access token, with the Build (Read) scope. Default is empty, disabling timelines and logs
+	Timeout   time.Duration `mapstructure:"timeout"`    // timeout of each API request. Default is 30s
+	QueueSize int           `mapstructure:"queue_size"` // completed runs waiting for their timeline and logs, more are rejected. Default is 100
+}
+
+// enabled reports whether the API can be used.
+func (cfg AzureDevOpsAPIConfig) enabled() bool {
+	return cfg.Token != ""
+}
+
+// Config defines configuration for Azure DevOps receiver
+type Config struct {
+	metadata.MetricsBuilderConfig `mapstructure:",squash"`
+	confighttp.ServerConfig       `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct
+	Path                          string                   `mapstructure:"path"`                // path for data collection. Default is <host>:<port>/azuredevopsevents
+	Secret                        string                   `mapstructure:"secret"`              // secret expected in the X-Azure-DevOps-Secret header of service hooks. Default is empty
+	BasicAuth                     BasicAuthConfig          `mapstructure:"basic_auth"`          // basic authentication of service hooks
+	CustomServiceName             string                   `mapstructure:"custom_service_name"` // custom service name. Default is empty
+	ServiceNamePrefix             string                   `mapstructure:"service_name_prefix"` // service name prefix. Default is empty
+	ServiceNameSuffix             string                   `mapstructure:"service_name_suffix"` // service name suffix. Default is empty
+	AzureDevOpsAPIConfig          AzureDevOpsAPIConfig     `mapstructure:"azuredevops_api"`     // azure devops api configuration
+	Logs                          logpolicy.Config         `mapstructure:"logs"`                // sampling, truncation and redaction policies applied to task logs. failed_only applies to runs
+	Semconv                       semconv.Config           `mapstructure:"semconv"`             // OpenTelemetry CICD and VCS semantic conventions
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the receiver configuration is valid
+func (cfg *Config) Validate() error {
+	var errs error
+
+	if cfg.NetAddr.Endpoint == "" {
+		errs = multierr.Append(errs, errMissingEndpointFromConfig)
+	}
+
+	if cfg.BasicAuth.Password != "" && cfg.BasicAuth.Username == "" {
+		errs = multierr.Append(errs, errMissingUsername)
+	}
+
+	api := cfg.AzureDevOpsAPIConfig
+	if api.BaseURL != "" {
+		if u, err := url.Parse(api.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, errBaseURL)
+		}
+	} else if api.Token != "" {
+		errs = multierr.Append(errs, errMissingBaseURL)
+	}
+	if api.Timeout < 0 {
+		errs = multierr.Append(errs, errTimeout)
+	}
+	if api.QueueSize < 1 {
+		errs = multierr.Append(errs, errQueueSize)
+	}
+
+	if err := cfg.Logs.Validate(); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("invalid logs configuration: %w", err))
+	}
+
+	return errs
+}
@@ -0,0 +1,359 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// secretHeader is the header service hooks are configured to send the
+// secret of the receiver in.
+const secretHeader = "X-Azure-DevOps-Secret"
+
+// Events handled by the receiver.
+const (
+	eventBuildComplete     = "build.complete"
+	eventRunStateChanged   = "ms.vss-pipelines.run-state-changed-event"
+	eventStageStateChanged = "ms.vss-pipelines.stage-state-changed-event"
+	eventJobStateChanged   = "ms.vss-pipelines.job-state-changed-event"
+)
+
+const stateCompleted = "completed"
+
+var (
+	errMissingBuild       = errors.New("build event has no build")
+	errMissingRun         = errors.New("pipelines event has no run or pipeline")
+	errMissingStage       = errors.New("stage event has no stage")
+	errMissingJob         = errors.New("job event has no job")
+	errMissingProject     = errors.New("event has no project")
+	errInvalidSecret      = errors.New("invalid secret")
+	errInvalidCredentials = errors.New("invalid basic authentication credentials")
+)
+
+// serviceHookEvent is an Azure DevOps service hook event. Its resource is
+// the build of build events, or the run of pipelines events, with the stage
+// or job of their state change.
+type serviceHookEvent struct {
+	ID                 string          `json:"id"`
+	EventType          string          `json:"eventType"`
+	PublisherID        string          `json:"publisherId"`
+	CreatedDate        string          `json:"createdDate"`
+	Resource           json.RawMessage `json:"resource"`
+	ResourceContainers struct {
+		Collection resourceContainer `json:"collection"`
+		Account    resourceContainer `json:"account"`
+		Project    resourceContainer `json:"project"`
+	} `json:"resourceContainers"`
+
+	Build     *build             `json:"-"`
+	Pipelines *pipelinesResource `json:"-"`
+}
+
+type resourceContainer struct {
+	ID      string `json:"id"`
+	BaseURL string `json:"baseUrl"`
+}
+
+type links struct {
+	Web struct {
+		Href string `json:"href"`
+	} `json:"web"`
+}
+
+// build is a build of the Build API, the resource of build events.
+type build struct {
+	ID          int64  `json:"id"`
+	BuildNumber string `json:"buildNumber"`
+	Status      string `json:"status"`
+	Result      string `json:"result"`
+	Reason      string `json:"reason"`
+	QueueTime   string `json:"queueTime"`
+	StartTime   string `json:"startTime"`
+	FinishTime  string `json:"finishTime"`
+	URL         string `json:"url"`
+	Links       links  `json:"_links"`
+	Definition  struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+		Path string `json:"path"`
+	} `json:"definition"`
+	Project struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"project"`
+	SourceBranch  string `json:"sourceBranch"`
+	SourceVersion string `json:"sourceVersion"`
+	Repository    *struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"repository"`
+	RequestedFor *identity `json:"requestedFor"`
+}
+
+type identity struct {
+	DisplayName string `json:"displayName"`
+}
+
+// pipelinesResource is the resource of pipelines events.
+type pipelinesResource struct {
+	Run      *pipelineRun `json:"run"`
+	Pipeline *pipeline    `json:"pipeline"`
+	Stage    *stage       `json:"stage"`
+	Job      *job         `json:"job"`
+}
+
+type pipeline struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Folder string `json:"folder"`
+}
+
+type pipelineRun struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	State        string `json:"state"`
+	Result       string `json:"result"`
+	CreatedDate  string `json:"createdDate"`
+	FinishedDate string `json:"finishedDate"`
+	URL          string `json:"url"`
+	Links        links  `json:"_links"`
+	Resources    struct {
+		Repositories map[string]struct {
+			RefName    string `json:"refName"`
+			Version    string `json:"version"`
+			Repository struct {
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				FullName string `json:"fullName"`
+			} `json:"repository"`
+		} `json:"repositories"`
+	} `json:"resources"`
+}
+
+type stage struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Attempt     int64  `json:"attempt"`
+	State       string `json:"state"`
+	Result      string `json:"result"`
+	StartTime   string `json:"startTime"`
+	FinishTime  string `json:"finishTime"`
+}
+
+type job struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Attempt    int64  `json:"attempt"`
+	State      string `json:"state"`
+	Result     string `json:"result"`
+	StartTime  string `json:"startTime"`
+	FinishTime string `json:"finishTime"`
+}
+
+// parseServiceHookEvent parses the payload of an event, and its resource
+// when it is of a type handled by the receiver.
+func parseServiceHookEvent(payload []byte) (*serviceHookEvent, error) {
+	var e serviceHookEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+
+	// Events without a resource are reported as missing their build or run
+	if len(e.Resource) == 0 {
+		e.Resource = json.RawMessage("null")
+	}
+
+	switch e.EventType {
+	case eventBuildComplete:
+		if err := json.Unmarshal(e.Resource, &e.Build); err != nil {
+			return nil, err
+		}
+		if e.Build == nil {
+			return nil, errMissingBuild
+		}
+	case eventRunStateChanged, eventStageStateChanged, eventJobStateChanged:
+		if err := json.Unmarshal(e.Resource, &e.Pipelines); err != nil {
+			return nil, err
+		}
+		switch {
+		case e.Pipelines == nil || e.Pipelines.Run == nil || e.Pipelines.Pipeline == nil:
+			return nil, errMissingRun
+		case e.EventType == eventStageStateChanged && e.Pipelines.Stage == nil:
+			return nil, errMissingStage
+		case e.EventType == eventJobStateChanged && e.Pipelines.Job == nil:
+			return nil, errMissingJob
+		}
+	default:
+		return &e, nil
+	}
+
+	if e.projectID() == "" {
+		return nil, errMissingProject
+	}
+	return &e, nil
+}
+
+// validateRequest checks the secret header and basic authentication
+// credentials of a request, when they are configured.
+func validateRequest(r *http.Request, config *Config) error {
+	if config.Secret != "" {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretHeader)), []byte(config.Secret)) != 1 {
+			return errInvalidSecret
+		}
+	}
+
+	if config.BasicAuth.Username != "" {
+		username, password, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(username), []byte(config.BasicAuth.Username)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(password), []byte(config.BasicAuth.Password)) != 1 {
+			return errInvalidCredentials
+		}
+	}
+
+	return nil
+}
+
+// completed reports whether the run, stage or job of an event completed.
+func (e *serviceHookEvent) completed() bool {
+	switch e.EventType {
+	case eventBuildComplete:
+		return true
+	case eventRunStateChanged:
+		return e.Pipelines.Run.State == stateCompleted
+	case eventStageStateChanged:
+		return e.Pipelines.Stage.State == stateCompleted
+	case eventJobStateChanged:
+		return e.Pipelines.Job.State == stateCompleted
+	default:
+		return false
+	}
+}
+
+// projectID returns the ID of the project of the event.
+func (e *serviceHookEvent) projectID() string {
+	if e.ResourceContainers.Project.ID != "" {
+		return e.ResourceContainers.Project.ID
+	}
+	if e.Build != nil {
+		return e.Build.Project.ID
+	}
+	return ""
+}
+
+// run is a run of a pipeline, as described by build and pipelines events.
+type run struct {
+	ProjectID    string
+	ProjectName  string
+	PipelineID   int64
+	PipelineName string
+	ID           int64
+	Name         string
+	Result       string
+	Reason       string
+	URL          string
+	Queued       time.Time
+	Started      time.Time
+	Finished     time.Time
+	RefName      string
+	Revision     string
+	Repository   struct{ Type, Name, URL string }
+	RequestedFor string
+}
+
+// key identifies the run in its organization.
+func (r *run) key() string {
+	return r.ProjectID + "/" + strconv.FormatInt(r.ID, 10)
+}
+
+// run returns the run of an event. Pipelines events do not tell when runs
+// started, so they are reported from their creation.
+func (e *serviceHookEvent) run() *run {
+	r := &run{ProjectID: e.projectID()}
+
+	if b := e.Build; b != nil {
+		r.ProjectName = b.Project.Name
+		r.PipelineID = b.Definition.ID
+		r.PipelineName = pipelineName(b.Definition.Path, b.Definition.Name)
+		r.ID = b.ID
+		r.Name = b.BuildNumber
+		// Builds of version 1.0 of the event have a status and no result
+		r.Result = b.Result
+		if r.Result == "" {
+			r.Result = b.Status
+		}
+		r.Reason = b.Reason
+		r.URL = b.Links.Web.Href
+		r.Queued = parseTime(b.QueueTime)
+		r.Started = parseTime(b.StartTime)
+		r.Finished = parseTime(b.FinishTime)
+		r.RefName = b.SourceBranch
+		r.Revision = b.SourceVersion
+		if repo := b.Repository; repo != nil {
+			r.Repository.Type = repo.Type
+			r.Repository.Name = repo.Name
+			r.Repository.URL = repo.URL
+		}
+		if b.RequestedFor != nil {
+			r.RequestedFor = b.RequestedFor.DisplayName
+		}
+		return r
+	}
+
+	p, pr := e.Pipelines.Pipeline, e.Pipelines.Run
+	r.PipelineID = p.ID
+	r.PipelineName = pipelineName(p.Folder, p.Name)
+	r.ID = pr.ID
+	r.Name = pr.Name
+	r.Result = pr.Result
+	r.URL = pr.Links.Web.Href
+	r.Started = parseTime(pr.CreatedDate)
+	r.Finished = parseTime(pr.FinishedDate)
+	if self, ok := pr.Resources.Repositories["self"]; ok {
+		r.RefName = self.RefName
+		r.Revision = self.Version
+		r.Repository.Type = self.Repository.Type
+		r.Repository.Name = self.Repository.FullName
+	}
+	return r
+}
+
+// pipelineName returns the name of a pipeline, prefixed with the folder it
+// is in, such as infra/deploy for the deploy pipeline of the \infra folder.
+func pipelineName(folder, name string) string {
+	folder = strings.Trim(strings.ReplaceAll(folder, `\`, "/"), "/")
+	if folder == "" {
+		return name
+	}
+	return folder + "/" + name
+}
+
+func parseTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// collectionURL returns the URL of the organization or collection of the
+// event.
+func (e *serviceHookEvent) collectionURL() string {
+	if u := e.ResourceContainers.Account.BaseURL; u != "" {
+		return u
+	}
+	return e.ResourceContainers.Collection.BaseURL
+}
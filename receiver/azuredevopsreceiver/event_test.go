@@ -0,0 +1,150 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testProjectID = "4bc8f1c2-3a0e-4e53-9a1b-7d5e0f3c6a21"
+
+func loadEvent(t *testing.T, name string) *serviceHookEvent {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	e, err := parseServiceHookEvent(payload)
+	require.NoError(t, err)
+	return e
+}
+
+func TestParseServiceHookEvent(t *testing.T) {
+	const (
+		project  = `"resourceContainers": {"project": {"id": "p"}}`
+		pipeline = `"run": {"id": 1}, "pipeline": {"id": 2}`
+	)
+
+	tests := map[string]struct {
+		payload string
+		err     error
+	}{
+		"build":                  {payload: `{"eventType": "build.complete", "resource": {"id": 1}, ` + project + `}`},
+		"build without build":    {payload: `{"eventType": "build.complete", ` + project + `}`, err: errMissingBuild},
+		"run":                    {payload: `{"eventType": "ms.vss-pipelines.run-state-changed-event", "resource": {` + pipeline + `}, ` + project + `}`},
+		"run without pipeline":   {payload: `{"eventType": "ms.vss-pipelines.run-state-changed-event", "resource": {"run": {"id": 1}}, ` + project + `}`, err: errMissingRun},
+		"run without project":    {payload: `{"eventType": "ms.vss-pipelines.run-state-changed-event", "resource": {` + pipeline + `}}`, err: errMissingProject},
+		"stage without stage":    {payload: `{"eventType": "ms.vss-pipelines.stage-state-changed-event", "resource": {` + pipeline + `}, ` + project + `}`, err: errMissingStage},
+		"job without job":        {payload: `{"eventType": "ms.vss-pipelines.job-state-changed-event", "resource": {` + pipeline + `}, ` + project + `}`, err: errMissingJob},
+		"unsupported event":      {payload: `{"eventType": "git.push", "resource": {"commits": []}}`},
+		"build project fallback": {payload: `{"eventType": "build.complete", "resource": {"id": 1, "project": {"id": "p"}}}`},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseServiceHookEvent([]byte(test.payload))
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestEventRun(t *testing.T) {
+	build := loadEvent(t, "build_complete.json").run()
+	require.Equal(t, testProjectID+"/1234", build.key())
+	require.Equal(t, "apps/web-app CI", build.PipelineName)
+	require.Equal(t, "Platform", build.ProjectName)
+	require.Equal(t, "succeeded", build.Result)
+	require.Equal(t, "individualCI", build.Reason)
+	require.Equal(t, "refs/heads/main", build.RefName)
+	require.Equal(t, "Jamie Doe", build.RequestedFor)
+	require.Equal(t, time.Date(2024, 5, 12, 10, 0, 5, 123456700, time.UTC), build.Started)
+
+	run := loadEvent(t, "run_state_changed.json").run()
+	require.Equal(t, testProjectID+"/1235", run.key())
+	require.Equal(t, "apps/web-app CI", run.PipelineName)
+	require.Equal(t, "failed", run.Result)
+	require.Equal(t, "refs/pull/17/merge", run.RefName)
+	require.Equal(t, "acme/web-app", run.Repository.Name)
+	require.Equal(t, time.Date(2024, 5, 12, 11, 0, 0, 500000000, time.UTC), run.Started)
+}
+
+func TestEventCompleted(t *testing.T) {
+	require.True(t, loadEvent(t, "build_complete.json").completed())
+	require.True(t, loadEvent(t, "run_state_changed.json").completed())
+	require.True(t, loadEvent(t, "stage_state_changed.json").completed())
+
+	e := loadEvent(t, "job_state_changed.json")
+	require.True(t, e.completed())
+	e.Pipelines.Job.State = "inProgress"
+	require.False(t, e.completed())
+}
+
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		desc   string
+		config Config
+		secret string
+		user   string
+		pass   string
+		err    error
+	}{
+		{
+			desc: "No authentication",
+		},
+		{
+			desc:   "Valid secret",
+			config: Config{Secret: "mysecret"},
+			secret: "mysecret",
+		},
+		{
+			desc:   "Invalid secret",
+			config: Config{Secret: "mysecret"},
+			secret: "wrong",
+			err:    errInvalidSecret,
+		},
+		{
+			desc:   "Valid credentials",
+			config: Config{BasicAuth: BasicAuthConfig{Username: "azuredevops", Password: "hookpassword"}},
+			user:   "azuredevops",
+			pass:   "hookpassword",
+		},
+		{
+			desc:   "Invalid password",
+			config: Config{BasicAuth: BasicAuthConfig{Username: "azuredevops", Password: "hookpassword"}},
+			user:   "azuredevops",
+			pass:   "wrong",
+			err:    errInvalidCredentials,
+		},
+		{
+			desc:   "Missing credentials",
+			config: Config{BasicAuth: BasicAuthConfig{Username: "azuredevops", Password: "hookpassword"}},
+			err:    errInvalidCredentials,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/azuredevopsevents", nil)
+			if test.secret != "" {
+				r.Header.Set(secretHeader, test.secret)
+			}
+			if test.user != "" {
+				r.SetBasicAuth(test.user, test.pass)
+			}
+			require.ErrorIs(t, validateRequest(r, &test.config), test.err)
+		})
+	}
+}
+
+func TestPipelineName(t *testing.T) {
+	require.Equal(t, "deploy", pipelineName(`\`, "deploy"))
+	require.Equal(t, "infra/deploy", pipelineName(`\infra`, "deploy"))
+	require.Equal(t, "infra/prod/deploy", pipelineName(`\infra\prod\`, "deploy"))
+}
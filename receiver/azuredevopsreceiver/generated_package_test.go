@@ -0,0 +1,12 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package azuredevopsreceiver
+
+import (
+	"go.uber.org/goleak"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
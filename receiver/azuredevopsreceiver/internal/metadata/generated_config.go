@@ -0,0 +1,59 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"go.opentelemetry.io/collector/confmap"
+)
+
+// MetricConfig provides common config for a particular metric.
+type MetricConfig struct {
+	Enabled          bool `mapstructure:"enabled"`
+	enabledSetByUser bool
+}
+
+func (ms *MetricConfig) Unmarshal(parser *confmap.Conf) error {
+	if parser == nil {
+		return nil
+	}
+
+	err := parser.Unmarshal(ms)
+	if err != nil {
+		return err
+	}
+
+	ms.enabledSetByUser = parser.IsSet("enabled")
+	return nil
+}
+
+// MetricsConfig provides config for azuredevops metrics.
+type MetricsConfig struct {
+	JobsCount   MetricConfig `mapstructure:"jobs.count"`
+	RunsCount   MetricConfig `mapstructure:"runs.count"`
+	StagesCount MetricConfig `mapstructure:"stages.count"`
+}
+
+func DefaultMetricsConfig() MetricsConfig {
+	return MetricsConfig{
+		JobsCount: MetricConfig{
+			Enabled: true,
+		},
+		RunsCount: MetricConfig{
+			Enabled: true,
+		},
+		StagesCount: MetricConfig{
+			Enabled: true,
+		},
+	}
+}
+
+// MetricsBuilderConfig is a configuration for azuredevops metrics builder.
+type MetricsBuilderConfig struct {
+	Metrics MetricsConfig `mapstructure:"metrics"`
+}
+
+func DefaultMetricsBuilderConfig() MetricsBuilderConfig {
+	return MetricsBuilderConfig{
+		Metrics: DefaultMetricsConfig(),
+	}
+}
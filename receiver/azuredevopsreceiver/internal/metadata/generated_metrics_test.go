@@ -0,0 +1,183 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type testDataSet int
+
+const (
+	testDataSetDefault testDataSet = iota
+	testDataSetAll
+	testDataSetNone
+)
+
+func TestMetricsBuilder(t *testing.T) {
+	tests := []struct {
+		name        string
+		metricsSet  testDataSet
+		resAttrsSet testDataSet
+		expectEmpty bool
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:        "all_set",
+			metricsSet:  testDataSetAll,
+			resAttrsSet: testDataSetAll,
+		},
+		{
+			name:        "none_set",
+			metricsSet:  testDataSetNone,
+			resAttrsSet: testDataSetNone,
+			expectEmpty: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := pcommon.Timestamp(1_000_000_000)
+			ts := pcommon.Timestamp(1_000_001_000)
+			observedZapCore, observedLogs := observer.New(zap.WarnLevel)
+			settings := receivertest.NewNopSettings(receivertest.NopType)
+			settings.Logger = zap.New(observedZapCore)
+			mb := NewMetricsBuilder(loadMetricsBuilderConfig(t, tt.name), settings, WithStartTime(start))
+
+			expectedWarnings := 0
+			assert.Equal(t, expectedWarnings, observedLogs.Len())
+
+			defaultMetricsCount := 0
+			allMetricsCount := 0
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordJobsCountDataPoint(ts, 1, "ci.azuredevops.project.id-val", "ci.azuredevops.pipeline.name-val", "ci.azuredevops.stage.name-val", "ci.azuredevops.job.name-val", AttributeCiAzuredevopsResultSucceeded)
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordRunsCountDataPoint(ts, 1, "ci.azuredevops.project.id-val", "ci.azuredevops.pipeline.name-val", AttributeCiAzuredevopsRunResultSucceeded)
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordStagesCountDataPoint(ts, 1, "ci.azuredevops.project.id-val", "ci.azuredevops.pipeline.name-val", "ci.azuredevops.stage.name-val", AttributeCiAzuredevopsResultSucceeded)
+
+			res := pcommon.NewResource()
+			metrics := mb.Emit(WithResource(res))
+
+			if tt.expectEmpty {
+				assert.Equal(t, 0, metrics.ResourceMetrics().Len())
+				return
+			}
+
+			var allMetricsList []pmetric.Metric
+			totalMetricsCount := 0
+			for ri := 0; ri < metrics.ResourceMetrics().Len(); ri++ {
+				rm := metrics.ResourceMetrics().At(ri)
+				assert.Equal(t, 1, rm.ScopeMetrics().Len())
+				ms := rm.ScopeMetrics().At(0).Metrics()
+				totalMetricsCount += ms.Len()
+				for mi := 0; mi < ms.Len(); mi++ {
+					allMetricsList = append(allMetricsList, ms.At(mi))
+				}
+			}
+			if tt.metricsSet == testDataSetDefault {
+				assert.Equal(t, defaultMetricsCount, totalMetricsCount)
+			}
+			if tt.metricsSet == testDataSetAll {
+				assert.Equal(t, allMetricsCount, totalMetricsCount)
+			}
+			validatedMetrics := make(map[string]bool)
+			for _, mi := range allMetricsList {
+				switch mi.Name() {
+				case "jobs.count":
+					assert.False(t, validatedMetrics["jobs.count"], "Found a duplicate in the metrics slice: jobs.count")
+					validatedMetrics["jobs.count"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Number of completed jobs, by result.", mi.Description())
+					assert.Equal(t, "{job}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciAzuredevopsProjectIDAttrVal, ok := dp.Attributes().Get("ci.azuredevops.project.id")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.azuredevops.project.id-val", ciAzuredevopsProjectIDAttrVal.Str())
+					ciAzuredevopsPipelineNameAttrVal, ok := dp.Attributes().Get("ci.azuredevops.pipeline.name")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.azuredevops.pipeline.name-val", ciAzuredevopsPipelineNameAttrVal.Str())
+					ciAzuredevopsStageNameAttrVal, ok := dp.Attributes().Get("ci.azuredevops.stage.name")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.azuredevops.stage.name-val", ciAzuredevopsStageNameAttrVal.Str())
+					ciAzuredevopsJobNameAttrVal, ok := dp.Attributes().Get("ci.azuredevops.job.name")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.azuredevops.job.name-val", ciAzuredevopsJobNameAttrVal.Str())
+					ciAzuredevopsResultAttrVal, ok := dp.Attributes().Get("ci.azuredevops.result")
+					assert.True(t, ok)
+					assert.Equal(t, "succeeded", ciAzuredevopsResultAttrVal.Str())
+				case "runs.count":
+					assert.False(t, validatedMetrics["runs.count"], "Found a duplicate in the metrics slice: runs.count")
+					validatedMetrics["runs.count"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Number of completed runs, by result.", mi.Description())
+					assert.Equal(t, "{run}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciAzuredevopsProjectIDAttrVal, ok := dp.Attributes().Get("ci.azuredevops.project.id")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.azuredevops.project.id-val", ciAzuredevopsProjectIDAttrVal.Str())
+					ciAzuredevopsPipelineNameAttrVal, ok := dp.Attributes().Get("ci.azuredevops.pipeline.name")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.azuredevops.pipeline.name-val", ciAzuredevopsPipelineNameAttrVal.Str())
+					ciAzuredevopsRunResultAttrVal, ok := dp.Attributes().Get("ci.azuredevops.run.result")
+					assert.True(t, ok)
+					assert.Equal(t, "succeeded", ciAzuredevopsRunResultAttrVal.Str())
+				case "stages.count":
+					assert.False(t, validatedMetrics["stages.count"], "Found a duplicate in the metrics slice: stages.count")
+					validatedMetrics["stages.count"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Number of completed stages, by result.", mi.Description())
+					assert.Equal(t, "{stage}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciAzuredevopsProjectIDAttrVal, ok := dp.Attributes().Get("ci.azuredevops.project.id")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.azuredevops.project.id-val", ciAzuredevopsProjectIDAttrVal.Str())
+					ciAzuredevopsPipelineNameAttrVal, ok := dp.Attributes().Get("ci.azuredevops.pipeline.name")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.azuredevops.pipeline.name-val", ciAzuredevopsPipelineNameAttrVal.Str())
+					ciAzuredevopsStageNameAttrVal, ok := dp.Attributes().Get("ci.azuredevops.stage.name")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.azuredevops.stage.name-val", ciAzuredevopsStageNameAttrVal.Str())
+					ciAzuredevopsResultAttrVal, ok := dp.Attributes().Get("ci.azuredevops.result")
+					assert.True(t, ok)
+					assert.Equal(t, "succeeded", ciAzuredevopsResultAttrVal.Str())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// runEventToLogs fetches the logs of the tasks of a completed run and
+// translates them to log records correlated with the spans of the tasks.
+// Only the tasks of jobs are fetched, the logs of the run and of its jobs
+// repeat them.
+func runEventToLogs(ctx context.Context, e *serviceHookEvent, records []timelineRecord, config *Config, client *azureDevOpsClient, policy *logpolicy.Policy, logger *zap.Logger) (*plog.Logs, error) {
+	r := e.run()
+	pipeline := timelinePipeline(r, records)
+	finishPipeline(&pipeline, e, config)
+
+	if !policy.KeepLogs(pipeline.Result.Failed()) {
+		logger.Debug("Skipping logs of run", zap.String("project", r.ProjectID), zap.String("pipeline", r.PipelineName), zap.Int64("id", r.ID), zap.String("result", r.Result))
+		return nil, nil
+	}
+
+	logIDs := make(map[pcommon.SpanID]int64, len(records))
+	for _, record := range records {
+		if record.Type == recordTask && record.Log != nil {
+			logIDs[generateStepSpanID(r.key(), record.ID)] = record.Log.ID
+		}
+	}
+
+	for i := range pipeline.Tasks {
+		task := &pipeline.Tasks[i]
+		for j := range task.Steps {
+			step := &task.Steps[j]
+
+			logID, ok := logIDs[step.SpanID]
+			if !ok {
+				continue
+			}
+
+			lines, err := client.log(ctx, r.ProjectID, r.ID, logID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get log of task %q of %s #%d: %w", step.Name, r.PipelineName, r.ID, err)
+			}
+			step.Logs = parseLog(lines, step.Started)
+			step.LogAttributes = map[string]any{
+				"ci.azuredevops.pipeline.name": r.PipelineName,
+				"ci.azuredevops.run.id":        r.ID,
+				"ci.azuredevops.job.name":      task.Name,
+				"ci.azuredevops.task.name":     step.Name,
+			}
+		}
+	}
+
+	logs := cimodel.ToLogs(&pipeline, policy, traceOptions(config))
+	return &logs, nil
+}
+
+// parseLog timestamps the lines of a log with the time agents prefix them
+// with, after the byte order mark logs start with. Lines without one keep
+// the time of the previous line.
+func parseLog(lines []string, started time.Time) []cimodel.LogEntry {
+	entries := make([]cimodel.LogEntry, 0, len(lines))
+	timestamp := started
+	for _, line := range lines {
+		line = strings.TrimPrefix(line, "\ufeff")
+		if prefix, body, _ := strings.Cut(line, " "); prefix != "" {
+			if t := parseTime(prefix); !t.IsZero() {
+				timestamp, line = t, body
+			}
+		}
+		line = strings.TrimRight(line, " ")
+		if line == "" {
+			continue
+		}
+		entries = append(entries, cimodel.LogEntry{Timestamp: timestamp, Body: line})
+	}
+	return entries
+}
@@ -0,0 +1,103 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+func newTestLogPolicy(tb testing.TB, cfg logpolicy.Config) *logpolicy.Policy {
+	tb.Helper()
+	policy, err := logpolicy.New(cfg, nil)
+	require.NoError(tb, err)
+	return policy
+}
+
+// logBodies returns the bodies of the log records, by the task they were
+// attributed to.
+func logBodies(logs *plog.Logs) map[string][]string {
+	bodies := map[string][]string{}
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	for i := range records.Len() {
+		task, _ := records.At(i).Attributes().Get("ci.azuredevops.task.name")
+		bodies[task.Str()] = append(bodies[task.Str()], records.At(i).Body().Str())
+	}
+	return bodies
+}
+
+func TestParseLog(t *testing.T) {
+	started := at(11, 0, 0)
+	entries := parseLog([]string{
+		"\ufeff2024-05-12T11:00:31.1000000Z ##[section]Starting: Run tests",
+		"continued line",
+		"2024-05-12T11:00:32.0000000Z ",
+		"2024-05-12T11:00:33.0000000Z done  ",
+	}, started)
+
+	require.Equal(t, []cimodel.LogEntry{
+		{Timestamp: time.Date(2024, 5, 12, 11, 0, 31, 100000000, time.UTC), Body: "##[section]Starting: Run tests"},
+		{Timestamp: time.Date(2024, 5, 12, 11, 0, 31, 100000000, time.UTC), Body: "continued line"},
+		{Timestamp: at(11, 0, 33), Body: "done"},
+	}, entries)
+
+	// Lines before the first timestamp keep the start of their task.
+	require.Equal(t, started, parseLog([]string{"no timestamp"}, started)[0].Timestamp)
+}
+
+func TestRunEventToLogs(t *testing.T) {
+	client := newAzureDevOpsTestServer(t)
+	e := loadEvent(t, "run_state_changed.json")
+	records, err := client.timeline(t.Context(), testProjectID, testRunID)
+	require.NoError(t, err)
+	cfg := createDefaultConfig().(*Config)
+
+	logs, err := runEventToLogs(t.Context(), e, records, cfg, client, newTestLogPolicy(t, cfg.Logs), zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, logs)
+	require.Equal(t, 8, logs.LogRecordCount())
+
+	require.Equal(t, []string{
+		"##[section]Starting: Run tests",
+		"Script contents:",
+		"npm test",
+		"FAIL src/app.test.ts",
+		"##[error]Bash exited with code '1'.",
+		"##[section]Finishing: Run tests",
+	}, logBodies(logs)["Run tests"])
+
+	logRecords := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	for i := range logRecords.Len() {
+		record := logRecords.At(i)
+		require.EqualValues(t, testRunID, record.Attributes().AsRaw()["ci.azuredevops.run.id"])
+
+		if task, _ := record.Attributes().Get("ci.azuredevops.task.name"); task.Str() == "Run tests" {
+			require.Equal(t, generateStepSpanID(testProjectID+"/1235", "a1b2c3d4-e5f6-5a7b-8c9d-0e1f2a3b4c5d"), record.SpanID())
+		}
+	}
+}
+
+func TestRunEventToLogsFailedOnly(t *testing.T) {
+	client := newAzureDevOpsTestServer(t)
+	e := loadEvent(t, "run_state_changed.json")
+	records, err := client.timeline(t.Context(), testProjectID, testRunID)
+	require.NoError(t, err)
+	cfg := createDefaultConfig().(*Config)
+	cfg.Logs.FailedOnly = true
+
+	logs, err := runEventToLogs(t.Context(), e, records, cfg, client, newTestLogPolicy(t, cfg.Logs), zap.NewNop())
+	require.NoError(t, err)
+	require.Equal(t, 8, logs.LogRecordCount())
+
+	e.Pipelines.Run.Result = "succeeded"
+	logs, err = runEventToLogs(t.Context(), e, records, cfg, client, newTestLogPolicy(t, cfg.Logs), zap.NewNop())
+	require.NoError(t, err)
+	require.Nil(t, logs)
+}
@@ -0,0 +1,269 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/azuredevopsreceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.uber.org/zap"
+)
+
+const metricsMaxCacheSize = 100000
+const histogramCacheSize = 50000
+const histogramTTL = 24 * time.Hour
+
+type metricsHandler struct {
+	mu             sync.Mutex
+	mb             *metadata.MetricsBuilder
+	cfg            *Config
+	logger         *zap.Logger
+	countersCache  *lru.Cache[string, int64]
+	histogramCache *lru.Cache[string, *cimodel.Histogram]
+	durations      *cimodel.Durations
+}
+
+func newMetricsHandler(settings receiver.Settings, cfg *Config, logger *zap.Logger) (*metricsHandler, error) {
+	countersCache, err := lru.New[string, int64](metricsMaxCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize counters cache: %w", err)
+	}
+
+	// histogramCache stores cumulative histogram state per unique dimension set,
+	// as histograms are emitted with cumulative temporality.
+	histogramCache, err := lru.New[string, *cimodel.Histogram](histogramCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize histogram cache: %w", err)
+	}
+
+	durations, err := cimodel.NewDurations(histogramCacheSize, histogramTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize durations cache: %w", err)
+	}
+
+	return &metricsHandler{
+		cfg:            cfg,
+		mb:             metadata.NewMetricsBuilder(cfg.MetricsBuilderConfig, settings),
+		logger:         logger,
+		countersCache:  countersCache,
+		histogramCache: histogramCache,
+		durations:      durations,
+	}, nil
+}
+
+// runEventToMetrics counts completed runs by result, and reports their
+// durations.
+func (m *metricsHandler) runEventToMetrics(e *serviceHookEvent) pmetric.Metrics {
+	r := e.run()
+
+	m.logger.Debug("Processing run event",
+		zap.String("project", r.ProjectID),
+		zap.String("pipeline", r.PipelineName),
+		zap.Int64("id", r.ID),
+		zap.String("result", r.Result),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	if result, ok := metadata.MapAttributeCiAzuredevopsRunResult[r.Result]; ok {
+		dimensions := fmt.Sprintf("run:%s:%s", r.ProjectID, r.PipelineName)
+		val, found := m.countersCache.Get(dimensions + ":" + result.String())
+		if !found {
+			// The counters of the other results start at zero, so that
+			// their increases are visible from their first event.
+			for _, s := range metadata.MapAttributeCiAzuredevopsRunResult {
+				if s != result && m.seedCounter(dimensions+":"+s.String()) {
+					m.mb.RecordRunsCountDataPoint(now, 0, r.ProjectID, r.PipelineName, s)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+result.String(), val+1)
+		m.mb.RecordRunsCountDataPoint(now, val+1, r.ProjectID, r.PipelineName, result)
+	}
+
+	metrics := m.mb.Emit()
+	if r.Started.IsZero() || r.Finished.IsZero() {
+		return metrics
+	}
+
+	ms := scopeMetrics(metrics)
+	pipeline := runPipeline(r)
+
+	if m.cfg.Semconv.EmitsLegacy() {
+		key := fmt.Sprintf("hist:run:%s:%s:%s", r.ProjectID, r.PipelineName, r.Result)
+		cimodel.AppendHistogram(ms, "runs.duration", map[string]any{
+			"ci.azuredevops.project.id":    r.ProjectID,
+			"ci.azuredevops.pipeline.name": r.PipelineName,
+			"ci.azuredevops.run.result":    r.Result,
+		}, m.observeDuration(key, pipeline.Finished.Sub(pipeline.Started).Seconds()))
+	}
+
+	if m.cfg.Semconv.Enabled {
+		m.durations.AppendPipeline(ms, &pipeline)
+	}
+
+	return metrics
+}
+
+// stageEventToMetrics counts completed stages by result, and reports the
+// durations of the stages that ran.
+func (m *metricsHandler) stageEventToMetrics(e *serviceHookEvent) pmetric.Metrics {
+	r, s := e.run(), e.Pipelines.Stage
+	stageName := s.displayName()
+
+	m.logger.Debug("Processing stage event",
+		zap.String("project", r.ProjectID),
+		zap.String("pipeline", r.PipelineName),
+		zap.Int64("run", r.ID),
+		zap.String("stage", stageName),
+		zap.String("result", s.Result),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	if result, ok := metadata.MapAttributeCiAzuredevopsResult[s.Result]; ok {
+		dimensions := fmt.Sprintf("stage:%s:%s:%s", r.ProjectID, r.PipelineName, stageName)
+		val, found := m.countersCache.Get(dimensions + ":" + result.String())
+		if !found {
+			for _, res := range metadata.MapAttributeCiAzuredevopsResult {
+				if res != result && m.seedCounter(dimensions+":"+res.String()) {
+					m.mb.RecordStagesCountDataPoint(now, 0, r.ProjectID, r.PipelineName, stageName, res)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+result.String(), val+1)
+		m.mb.RecordStagesCountDataPoint(now, val+1, r.ProjectID, r.PipelineName, stageName, result)
+	}
+
+	metrics := m.mb.Emit()
+
+	// Stages that never ran have no duration.
+	if s.StartTime == "" || s.FinishTime == "" {
+		return metrics
+	}
+
+	if m.cfg.Semconv.EmitsLegacy() {
+		pipeline := stageEventPipeline(e)
+		stage := &pipeline.Stages[0]
+		key := fmt.Sprintf("hist:stage:%s:%s:%s:%s", r.ProjectID, r.PipelineName, stageName, s.Result)
+		cimodel.AppendHistogram(scopeMetrics(metrics), "stages.duration", map[string]any{
+			"ci.azuredevops.project.id":    r.ProjectID,
+			"ci.azuredevops.pipeline.name": r.PipelineName,
+			"ci.azuredevops.stage.name":    stageName,
+			"ci.azuredevops.result":        s.Result,
+		}, m.observeDuration(key, stage.Finished.Sub(stage.Started).Seconds()))
+	}
+
+	return metrics
+}
+
+// jobEventToMetrics counts completed jobs by result, and reports the
+// durations of the jobs that ran.
+func (m *metricsHandler) jobEventToMetrics(e *serviceHookEvent) pmetric.Metrics {
+	r, j := e.run(), e.Pipelines.Job
+	stageName := ""
+	if s := e.Pipelines.Stage; s != nil {
+		stageName = s.displayName()
+	}
+
+	m.logger.Debug("Processing job event",
+		zap.String("project", r.ProjectID),
+		zap.String("pipeline", r.PipelineName),
+		zap.Int64("run", r.ID),
+		zap.String("stage", stageName),
+		zap.String("job", j.Name),
+		zap.String("result", j.Result),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	if result, ok := metadata.MapAttributeCiAzuredevopsResult[j.Result]; ok {
+		dimensions := fmt.Sprintf("job:%s:%s:%s:%s", r.ProjectID, r.PipelineName, stageName, j.Name)
+		val, found := m.countersCache.Get(dimensions + ":" + result.String())
+		if !found {
+			for _, res := range metadata.MapAttributeCiAzuredevopsResult {
+				if res != result && m.seedCounter(dimensions+":"+res.String()) {
+					m.mb.RecordJobsCountDataPoint(now, 0, r.ProjectID, r.PipelineName, stageName, j.Name, res)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+result.String(), val+1)
+		m.mb.RecordJobsCountDataPoint(now, val+1, r.ProjectID, r.PipelineName, stageName, j.Name, result)
+	}
+
+	metrics := m.mb.Emit()
+
+	// Jobs that never ran have no duration.
+	if j.StartTime == "" || j.FinishTime == "" {
+		return metrics
+	}
+
+	ms := scopeMetrics(metrics)
+	pipeline := jobEventPipeline(e)
+	task := &pipeline.Tasks[0]
+
+	if m.cfg.Semconv.EmitsLegacy() {
+		key := fmt.Sprintf("hist:job:%s:%s:%s:%s:%s", r.ProjectID, r.PipelineName, stageName, j.Name, j.Result)
+		cimodel.AppendHistogram(ms, "jobs.duration", map[string]any{
+			"ci.azuredevops.project.id":    r.ProjectID,
+			"ci.azuredevops.pipeline.name": r.PipelineName,
+			"ci.azuredevops.stage.name":    stageName,
+			"ci.azuredevops.job.name":      j.Name,
+			"ci.azuredevops.result":        j.Result,
+		}, m.observeDuration(key, task.Finished.Sub(task.Started).Seconds()))
+	}
+
+	if m.cfg.Semconv.Enabled {
+		m.durations.AppendTask(ms, &pipeline, task)
+	}
+
+	return metrics
+}
+
+// scopeMetrics returns the metrics emitted by the metrics builder, which
+// emits no resource at all when no counter was recorded.
+func scopeMetrics(metrics pmetric.Metrics) pmetric.MetricSlice {
+	if metrics.ResourceMetrics().Len() == 0 {
+		scope := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+		scope.Scope().SetName(metadata.ScopeName)
+		return scope.Metrics()
+	}
+	return metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+}
+
+// seedCounter starts the counter cached under key at zero, reporting
+// whether it was unknown. Called under m.mu.
+func (m *metricsHandler) seedCounter(key string) bool {
+	if m.countersCache.Contains(key) {
+		return false
+	}
+	m.countersCache.Add(key, 0)
+	return true
+}
+
+// observeDuration records a duration in the histogram cached under key.
+// Called under m.mu.
+func (m *metricsHandler) observeDuration(key string, duration float64) *cimodel.Histogram {
+	// Stale histograms start over, the LRU evicts those never observed again
+	h, ok := m.histogramCache.Get(key)
+	if !ok || time.Since(h.LastSeen) >= histogramTTL {
+		h = cimodel.NewHistogram()
+	}
+	h.Observe(duration)
+	m.histogramCache.Add(key, h)
+	return h
+}
@@ -0,0 +1,187 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/azuredevopsreceiver/internal/metadata"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestMetricsHandler(t *testing.T, cfg *Config) *metricsHandler {
+	t.Helper()
+	cfg.MetricsBuilderConfig = metadata.DefaultMetricsBuilderConfig()
+	mh, err := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return mh
+}
+
+// metricNames returns the names of the metrics, with their data point counts.
+func metricNames(metrics pmetric.Metrics) map[string]int {
+	names := map[string]int{}
+	for i := range metrics.ResourceMetrics().Len() {
+		sms := metrics.ResourceMetrics().At(i).ScopeMetrics()
+		for j := range sms.Len() {
+			ms := sms.At(j).Metrics()
+			for k := range ms.Len() {
+				m := ms.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					names[m.Name()] += m.Sum().DataPoints().Len()
+				case pmetric.MetricTypeHistogram:
+					names[m.Name()] += m.Histogram().DataPoints().Len()
+				}
+			}
+		}
+	}
+	return names
+}
+
+func TestRunEventToMetrics(t *testing.T) {
+	e := loadEvent(t, "build_complete.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	// The first event seeds the counters of the other results.
+	metrics := mh.runEventToMetrics(e)
+	require.Equal(t, map[string]int{
+		"runs.count":    len(metadata.MapAttributeCiAzuredevopsRunResult),
+		"runs.duration": 1,
+	}, metricNames(metrics))
+
+	hist := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(1).Histogram().DataPoints().At(0)
+	require.InDelta(t, 270.642, hist.Sum(), 0.001)
+
+	// The counters keep counting.
+	metrics = mh.runEventToMetrics(e)
+	dp := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, int64(2), dp.IntValue())
+	result, _ := dp.Attributes().Get("ci.azuredevops.run.result")
+	require.Equal(t, "succeeded", result.Str())
+	pipeline, _ := dp.Attributes().Get("ci.azuredevops.pipeline.name")
+	require.Equal(t, "apps/web-app CI", pipeline.Str())
+}
+
+func TestStageEventToMetrics(t *testing.T) {
+	e := loadEvent(t, "stage_state_changed.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	metrics := mh.stageEventToMetrics(e)
+	require.Equal(t, map[string]int{
+		"stages.count":    len(metadata.MapAttributeCiAzuredevopsResult),
+		"stages.duration": 1,
+	}, metricNames(metrics))
+
+	val, ok := mh.countersCache.Get("stage:" + testProjectID + ":apps/web-app CI:Build web app:failed")
+	require.True(t, ok)
+	require.Equal(t, int64(1), val)
+
+	// Stages that never ran are counted, without a duration.
+	e.Pipelines.Stage.Result = "skipped"
+	e.Pipelines.Stage.StartTime = ""
+	require.Equal(t, map[string]int{"stages.count": 1}, metricNames(mh.stageEventToMetrics(e)))
+}
+
+func TestJobEventToMetrics(t *testing.T) {
+	e := loadEvent(t, "job_state_changed.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	metrics := mh.jobEventToMetrics(e)
+	require.Equal(t, map[string]int{
+		"jobs.count":    len(metadata.MapAttributeCiAzuredevopsResult),
+		"jobs.duration": 1,
+	}, metricNames(metrics))
+
+	val, ok := mh.countersCache.Get("job:" + testProjectID + ":apps/web-app CI:Build web app:Test:failed")
+	require.True(t, ok)
+	require.Equal(t, int64(1), val)
+
+	// Jobs that never ran are counted, without a duration.
+	e.Pipelines.Job.Result = "canceled"
+	e.Pipelines.Job.StartTime = ""
+	require.Equal(t, map[string]int{"jobs.count": 1}, metricNames(mh.jobEventToMetrics(e)))
+}
+
+func TestEventToMetricsSemconv(t *testing.T) {
+	run := loadEvent(t, "run_state_changed.json")
+	stage := loadEvent(t, "stage_state_changed.json")
+	job := loadEvent(t, "job_state_changed.json")
+
+	tests := []struct {
+		desc          string
+		semconv       semconv.Config
+		expectMetrics []string
+	}{
+		{
+			desc:          "Legacy metrics",
+			expectMetrics: []string{"runs.count", "stages.count", "jobs.count", "runs.duration", "stages.duration", "jobs.duration"},
+		},
+		{
+			desc:          "Semantic conventions",
+			semconv:       semconv.Config{Enabled: true},
+			expectMetrics: []string{"runs.count", "stages.count", "jobs.count", semconv.MetricCICDPipelineRunDuration, semconv.MetricCICDPipelineTaskRunDuration},
+		},
+		{
+			desc:    "Semantic conventions with legacy metrics",
+			semconv: semconv.Config{Enabled: true, EmitLegacy: true},
+			expectMetrics: []string{
+				"runs.count", "stages.count", "jobs.count", "runs.duration", "stages.duration", "jobs.duration",
+				semconv.MetricCICDPipelineRunDuration, semconv.MetricCICDPipelineTaskRunDuration,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			mh := newTestMetricsHandler(t, &Config{Semconv: test.semconv})
+
+			names := metricNames(mh.runEventToMetrics(run))
+			for name, count := range metricNames(mh.stageEventToMetrics(stage)) {
+				names[name] += count
+			}
+			for name, count := range metricNames(mh.jobEventToMetrics(job)) {
+				names[name] += count
+			}
+			require.Len(t, names, len(test.expectMetrics))
+			for _, name := range test.expectMetrics {
+				require.Contains(t, names, name)
+			}
+		})
+	}
+}
+
+func TestRunEventToMetricsConcurrency(t *testing.T) {
+	e := loadEvent(t, "run_state_changed.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			mh.runEventToMetrics(e)
+		})
+	}
+	wg.Wait()
+
+	val, ok := mh.countersCache.Get("run:" + testProjectID + ":apps/web-app CI:failed")
+	require.True(t, ok)
+	require.Equal(t, int64(50), val)
+}
+
+func TestObserveDurationStartsOver(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+
+	mh.observeDuration("key", 1)
+	require.Equal(t, uint64(2), mh.observeDuration("key", 1).Count)
+
+	// Histograms not observed within the TTL start over
+	h, _ := mh.histogramCache.Get("key")
+	h.LastSeen = time.Now().Add(-histogramTTL)
+	require.Equal(t, uint64(1), mh.observeDuration("key", 1).Count)
+}
@@ -0,0 +1,321 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+)
+
+// runPipeline maps a run to the CI model, without its stages and jobs.
+func runPipeline(r *run) cimodel.Pipeline {
+	started := r.Started
+	if started.IsZero() {
+		started = r.Queued
+	}
+	finished := r.Finished
+	if finished.IsZero() {
+		finished = started
+	}
+
+	return cimodel.Pipeline{
+		ID:         r.Name,
+		Name:       r.PipelineName,
+		URL:        r.URL,
+		Result:     runResult(r.Result),
+		Status:     r.Result,
+		Started:    started,
+		Finished:   finished,
+		Repository: runRepository(r),
+		Ref:        runRef(r),
+		TraceID:    generateTraceID(r.key()),
+		SpanID:     generateRunSpanID(r.key()),
+		Attributes: runAttributes(r),
+	}
+}
+
+func runAttributes(r *run) map[string]any {
+	attrs := map[string]any{
+		"ci.azuredevops.pipeline.id":    r.PipelineID,
+		"ci.azuredevops.pipeline.name":  r.PipelineName,
+		"ci.azuredevops.run.id":         r.ID,
+		"ci.azuredevops.run.name":       r.Name,
+		"ci.azuredevops.run.result":     r.Result,
+		"ci.azuredevops.source_branch":  r.RefName,
+		"ci.azuredevops.source_version": r.Revision,
+	}
+	if r.URL != "" {
+		attrs["ci.azuredevops.run.url"] = r.URL
+	}
+	if r.Reason != "" {
+		attrs["ci.azuredevops.run.reason"] = r.Reason
+	}
+	if r.RequestedFor != "" {
+		attrs["ci.azuredevops.run.requested_for"] = r.RequestedFor
+	}
+	return attrs
+}
+
+// stageEventPipeline maps a stage event to the CI model. The event only
+// describes one stage, so the run has no span of its own and the stage span
+// keeps the run span as its parent.
+func stageEventPipeline(e *serviceHookEvent) cimodel.Pipeline {
+	r := e.run()
+	s := e.Pipelines.Stage
+
+	pipeline := runPipeline(r)
+	pipeline.OmitSpan = true
+
+	started, finished := recordTimes(s.StartTime, s.FinishTime, pipeline.Finished)
+	pipeline.Stages = []cimodel.Stage{{
+		Name:     s.displayName(),
+		Result:   recordResult(s.Result),
+		Status:   s.Result,
+		Started:  started,
+		Finished: finished,
+		SpanID:   generateStageSpanID(r.key(), s.Name),
+		Attributes: map[string]any{
+			"ci.azuredevops.stage.id":      s.Name,
+			"ci.azuredevops.stage.attempt": s.Attempt,
+		},
+	}}
+	return pipeline
+}
+
+// jobEventPipeline maps a job event to the CI model. The event only
+// describes one job, so neither the run nor the stage of the job have a
+// span, and the job span keeps the stage span as its parent.
+func jobEventPipeline(e *serviceHookEvent) cimodel.Pipeline {
+	r := e.run()
+	j := e.Pipelines.Job
+
+	pipeline := runPipeline(r)
+	pipeline.OmitSpan = true
+
+	stageName := ""
+	if s := e.Pipelines.Stage; s != nil {
+		stageName = s.displayName()
+		pipeline.SpanID = generateStageSpanID(r.key(), s.Name)
+	}
+
+	started, finished := recordTimes(j.StartTime, j.FinishTime, pipeline.Finished)
+	pipeline.Tasks = []cimodel.Task{{
+		Stage:    stageName,
+		ID:       j.ID,
+		Name:     j.Name,
+		Result:   recordResult(j.Result),
+		Status:   j.Result,
+		Started:  started,
+		Finished: finished,
+		SpanID:   generateJobSpanID(r.key(), j.ID),
+		Attributes: map[string]any{
+			"ci.azuredevops.job.id":      j.ID,
+			"ci.azuredevops.job.name":    j.Name,
+			"ci.azuredevops.job.result":  j.Result,
+			"ci.azuredevops.job.attempt": j.Attempt,
+		},
+	}}
+	return pipeline
+}
+
+// timelinePipeline maps a run and the records of its timeline to the CI
+// model, with its stages, its jobs and their tasks as steps. Phases only
+// group the jobs of a stage, and checkpoints are not reported.
+func timelinePipeline(r *run, records []timelineRecord) cimodel.Pipeline {
+	pipeline := runPipeline(r)
+
+	byID := make(map[string]*timelineRecord, len(records))
+	for i := range records {
+		byID[records[i].ID] = &records[i]
+	}
+	sorted := slices.Clone(records)
+	slices.SortStableFunc(sorted, func(a, b timelineRecord) int {
+		return cmp.Compare(a.Order, b.Order)
+	})
+
+	for i := range sorted {
+		record := &sorted[i]
+		started, finished := recordTimes(record.StartTime, record.FinishTime, pipeline.Finished)
+
+		switch record.Type {
+		case recordStage:
+			pipeline.Stages = append(pipeline.Stages, cimodel.Stage{
+				Name:     record.Name,
+				Result:   recordResult(record.Result),
+				Status:   record.Result,
+				Started:  started,
+				Finished: finished,
+				SpanID:   generateStageSpanID(r.key(), record.stageID()),
+				Attributes: map[string]any{
+					"ci.azuredevops.stage.id":      record.stageID(),
+					"ci.azuredevops.stage.attempt": record.Attempt,
+				},
+			})
+		case recordJob:
+			task := cimodel.Task{
+				ID:       record.ID,
+				Name:     record.Name,
+				Worker:   record.WorkerName,
+				Result:   recordResult(record.Result),
+				Status:   record.Result,
+				Started:  started,
+				Finished: finished,
+				SpanID:   generateJobSpanID(r.key(), record.ID),
+				Attributes: map[string]any{
+					"ci.azuredevops.job.id":            record.ID,
+					"ci.azuredevops.job.name":          record.Name,
+					"ci.azuredevops.job.result":        record.Result,
+					"ci.azuredevops.job.attempt":       record.Attempt,
+					"ci.azuredevops.job.error_count":   record.ErrorCount,
+					"ci.azuredevops.job.warning_count": record.WarningCount,
+				},
+			}
+			if record.WorkerName != "" {
+				task.Attributes["ci.azuredevops.job.worker"] = record.WorkerName
+			}
+			if s := stageOf(record, byID); s != nil {
+				task.Stage = s.Name
+			}
+			for j := range sorted {
+				if step := &sorted[j]; step.Type == recordTask && step.ParentID == record.ID {
+					task.Steps = append(task.Steps, timelineStep(r, step, finished))
+				}
+			}
+			pipeline.Tasks = append(pipeline.Tasks, task)
+		}
+	}
+
+	return pipeline
+}
+
+func timelineStep(r *run, record *timelineRecord, jobFinished time.Time) cimodel.Step {
+	started, finished := recordTimes(record.StartTime, record.FinishTime, jobFinished)
+	return cimodel.Step{
+		Name:     record.Name,
+		Result:   recordResult(record.Result),
+		Status:   record.Result,
+		Started:  started,
+		Finished: finished,
+		SpanID:   generateStepSpanID(r.key(), record.ID),
+		Attributes: map[string]any{
+			"ci.azuredevops.task.id":            record.ID,
+			"ci.azuredevops.task.error_count":   record.ErrorCount,
+			"ci.azuredevops.task.warning_count": record.WarningCount,
+		},
+	}
+}
+
+// stageOf returns the stage of a job record, the parent of its phase.
+func stageOf(record *timelineRecord, byID map[string]*timelineRecord) *timelineRecord {
+	for parent := byID[record.ParentID]; parent != nil; parent = byID[parent.ParentID] {
+		if parent.Type == recordStage {
+			return parent
+		}
+		if parent.ParentID == parent.ID {
+			break
+		}
+	}
+	return nil
+}
+
+// stageID returns the identifier of a stage record, the name of the stage
+// in stage events.
+func (r *timelineRecord) stageID() string {
+	if r.Identifier != "" {
+		return r.Identifier
+	}
+	return r.Name
+}
+
+// displayName returns the display name of a stage. Pipelines without
+// stages have a single __default stage.
+func (s *stage) displayName() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.Name
+}
+
+// recordTimes returns the start and end of a stage, job or task. Those that
+// never started, such as skipped ones, start and end when their parent
+// ended.
+func recordTimes(startTime, finishTime string, parentFinished time.Time) (time.Time, time.Time) {
+	started, finished := parseTime(startTime), parseTime(finishTime)
+	if started.IsZero() {
+		return parentFinished, parentFinished
+	}
+	if finished.Before(started) {
+		finished = started
+	}
+	return started, finished
+}
+
+// runResult maps the result of a run. Partially succeeded runs had tasks
+// failing with continueOnError set.
+func runResult(result string) cimodel.Result {
+	switch result {
+	case "succeeded":
+		return cimodel.ResultSuccess
+	case "partiallySucceeded", "failed":
+		return cimodel.ResultFailure
+	case "canceled":
+		return cimodel.ResultCancellation
+	default:
+		return cimodel.ResultUnknown
+	}
+}
+
+// recordResult maps the result of a stage, job or task.
+func recordResult(result string) cimodel.Result {
+	switch result {
+	case "succeeded":
+		return cimodel.ResultSuccess
+	case "succeededWithIssues", "failed":
+		return cimodel.ResultFailure
+	case "canceled", "abandoned":
+		return cimodel.ResultCancellation
+	case "skipped":
+		return cimodel.ResultSkip
+	default:
+		return cimodel.ResultUnknown
+	}
+}
+
+// runRepository maps the repository of a run. Repositories of Azure Repos
+// are named after their project, which owns them.
+func runRepository(r *run) cimodel.Repository {
+	repo := cimodel.Repository{Owner: r.ProjectName, Name: r.Repository.Name, URL: r.Repository.URL}
+	switch strings.ToLower(r.Repository.Type) {
+	case "github", "githubenterprise":
+		repo.Provider = semconv.AttributeVCSProviderNameGithub
+	case "bitbucket":
+		repo.Provider = semconv.AttributeVCSProviderNameBitbucket
+	}
+	if owner, name, ok := strings.Cut(r.Repository.Name, "/"); ok {
+		repo.Owner, repo.Name = owner, name
+	}
+	return repo
+}
+
+// runRef maps the source branch of a run, such as refs/heads/main,
+// refs/tags/v1.0.0 or refs/pull/12/merge.
+func runRef(r *run) cimodel.Ref {
+	ref := cimodel.Ref{Revision: r.Revision}
+	switch {
+	case strings.HasPrefix(r.RefName, "refs/heads/"):
+		ref.Head = strings.TrimPrefix(r.RefName, "refs/heads/")
+		ref.HeadType = semconv.AttributeVCSRefTypeBranch
+	case strings.HasPrefix(r.RefName, "refs/tags/"):
+		ref.Head = strings.TrimPrefix(r.RefName, "refs/tags/")
+		ref.HeadType = semconv.AttributeVCSRefTypeTag
+	case strings.HasPrefix(r.RefName, "refs/pull/"):
+		ref.ChangeID, _, _ = strings.Cut(strings.TrimPrefix(r.RefName, "refs/pull/"), "/")
+	}
+	return ref
+}
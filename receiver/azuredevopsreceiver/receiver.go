@@ -0,0 +1,423 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/azuredevopsreceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+var errMissingEndpoint = errors.New("missing a receiver endpoint")
+
+type azureDevOpsReceiver struct {
+	logsConsumer    consumer.Logs
+	tracesConsumer  consumer.Traces
+	metricsConsumer consumer.Metrics
+	metricsHandler  *metricsHandler
+	config          *Config
+	server          *http.Server
+	shutdownWG      sync.WaitGroup
+	createSettings  receiver.Settings
+	logger          *zap.Logger
+	obsrecv         *receiverhelper.ObsReport
+	client          *azureDevOpsClient
+	telemetry       *metadata.TelemetryBuilder
+	logPolicy       *logpolicy.Policy
+	// events are the completed runs whose traces and logs are reported in
+	// the background, once their timeline and logs are read
+	events chan *serviceHookEvent
+	cancel context.CancelFunc
+}
+
+func newReceiver(
+	params receiver.Settings,
+	config *Config,
+) (*azureDevOpsReceiver, error) {
+	if config.NetAddr.Endpoint == "" {
+		return nil, errMissingEndpoint
+	}
+
+	transport := "http"
+	if config.TLS.HasValue() {
+		transport = "https"
+	}
+
+	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             params.ID,
+		Transport:              transport,
+		ReceiverCreateSettings: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Timelines and logs of runs can only be read from the API.
+	var client *azureDevOpsClient
+	if config.AzureDevOpsAPIConfig.enabled() {
+		client, err = newAzureDevOpsClient(config.AzureDevOpsAPIConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure DevOps client: %w", err)
+		}
+	}
+
+	telemetry, err := metadata.NewTelemetryBuilder(params.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+
+	logPolicy, err := logpolicy.New(config.Logs, logPolicyRecorder{telemetry: telemetry})
+	if err != nil {
+		return nil, err
+	}
+
+	metricsHandler, err := newMetricsHandler(params, config, params.Logger.Named("metricsHandler"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &azureDevOpsReceiver{
+		config:         config,
+		createSettings: params,
+		logger:         params.Logger,
+		obsrecv:        obsrecv,
+		client:         client,
+		telemetry:      telemetry,
+		logPolicy:      logPolicy,
+		events:         make(chan *serviceHookEvent, config.AzureDevOpsAPIConfig.QueueSize),
+		metricsHandler: metricsHandler,
+	}, nil
+}
+
+// newTracesReceiver creates a traces receiver based on provided config.
+func newTracesReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Traces,
+) (receiver.Traces, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*azureDevOpsReceiver).tracesConsumer = consumer
+
+	return r, nil
+}
+
+// newLogsReceiver creates a logs receiver based on provided config.
+func newLogsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Logs,
+) (receiver.Logs, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*azureDevOpsReceiver).logsConsumer = consumer
+
+	return r, nil
+}
+
+// newMetricsReceiver creates a metrics receiver based on provided config.
+func newMetricsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*azureDevOpsReceiver).metricsConsumer = consumer
+
+	return r, nil
+}
+
+func getOrAddReceiver(set receiver.Settings, cfg component.Config) (*sharedcomponent.SharedComponent, error) {
+	var err error
+	r := receivers.GetOrAdd(cfg, func() component.Component {
+		var rcv component.Component
+		rcv, err = newReceiver(set, cfg.(*Config))
+		return rcv
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// logPolicyRecorder reports the log lines affected by the log policy as
+// internal telemetry.
+type logPolicyRecorder struct {
+	telemetry *metadata.TelemetryBuilder
+}
+
+func (r logPolicyRecorder) RecordDroppedLines(n int64, reason string) {
+	r.telemetry.ReceiverLogsDroppedLines.Add(context.Background(), n, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+func (r logPolicyRecorder) RecordRedactedLines(n int64) {
+	r.telemetry.ReceiverLogsRedactedLines.Add(context.Background(), n)
+}
+
+func (r logPolicyRecorder) RecordOversizedEntries(n int64, behaviour string) {
+	r.telemetry.ReceiverLogsOversizedEntries.Add(context.Background(), n, metric.WithAttributes(attribute.String("behaviour", behaviour)))
+}
+
+func (ar *azureDevOpsReceiver) Start(_ context.Context, _ component.Host) error {
+	endpoint := fmt.Sprintf("%s%s", ar.config.NetAddr.Endpoint, ar.config.Path)
+	ar.logger.Info("Starting Azure DevOps server", zap.String("endpoint", endpoint))
+	ar.server = &http.Server{
+		Addr:              ar.config.NetAddr.Endpoint,
+		Handler:           ar,
+		ReadHeaderTimeout: 20 * time.Second,
+	}
+
+	ar.shutdownWG.Add(1)
+	go func() {
+		defer ar.shutdownWG.Done()
+
+		if errHTTP := ar.server.ListenAndServe(); !errors.Is(errHTTP, http.ErrServerClosed) && errHTTP != nil {
+			ar.createSettings.Logger.Error("Server closed with error", zap.Error(errHTTP))
+		}
+	}()
+
+	var ctx context.Context
+	ctx, ar.cancel = context.WithCancel(context.Background())
+	ar.shutdownWG.Add(1)
+	go func() {
+		defer ar.shutdownWG.Done()
+		ar.processEvents(ctx)
+	}()
+
+	return nil
+}
+
+// Shutdown stops the server and the requests to the API. Runs waiting for
+// their timeline and logs are dropped.
+func (ar *azureDevOpsReceiver) Shutdown(ctx context.Context) error {
+	var err error
+	if ar.server != nil {
+		err = ar.server.Close()
+	}
+	if ar.cancel != nil {
+		ar.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ar.shutdownWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		ar.logger.Warn("Stopped waiting for the run being reported", zap.Error(ctx.Err()))
+	}
+	if n := len(ar.events); n > 0 {
+		ar.logger.Warn("Dropping runs waiting for their timeline", zap.Int("runs", n))
+	}
+	ar.telemetry.Shutdown()
+	return err
+}
+
+func (ar *azureDevOpsReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Validate request path
+	if r.URL.Path != ar.config.Path {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if err := validateRequest(r, ar.config); err != nil {
+		ar.logger.Debug("Service hook authentication failed", zap.Error(err))
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		ar.logger.Debug("Failed to read payload", zap.Error(err))
+		http.Error(w, "Failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	e, err := parseServiceHookEvent(payload)
+	if err != nil {
+		ar.logger.Debug("Service hook event parsing failed", zap.Error(err))
+		http.Error(w, "Failed to parse service hook event", http.StatusBadRequest)
+		return
+	}
+
+	switch e.EventType {
+	case eventBuildComplete, eventRunStateChanged, eventStageStateChanged, eventJobStateChanged:
+	default:
+		ar.logger.Debug("Skipping unsupported event type", zap.String("event", e.EventType))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Only completed runs, stages and jobs are reported.
+	if !e.completed() {
+		ar.logger.Debug("Skipping event of uncompleted run", zap.String("event", e.EventType))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	switch e.EventType {
+	case eventBuildComplete, eventRunStateChanged:
+		// Runs rejected while the queue is full are not counted either, so
+		// that their redelivery is counted once
+		if ar.tracesConsumer != nil || ar.logsConsumer != nil {
+			select {
+			case ar.events <- e:
+			default:
+				r := e.run()
+				ar.logger.Warn("Too many runs waiting for their timeline, dropping",
+					zap.String("project", r.ProjectID),
+					zap.String("pipeline", r.PipelineName),
+					zap.Int64("id", r.ID),
+				)
+				http.Error(w, "too many runs waiting for their timeline", http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		if ar.metricsConsumer != nil {
+			ar.consumeMetrics(ctx, ar.metricsHandler.runEventToMetrics(e))
+		}
+	case eventStageStateChanged:
+		if ar.metricsConsumer != nil {
+			ar.consumeMetrics(ctx, ar.metricsHandler.stageEventToMetrics(e))
+		}
+
+		// Stages are reported with their run when timelines are read.
+		if ar.tracesConsumer != nil && ar.client == nil {
+			ar.consumeTraces(ctx, stageEventToTraces(e, ar.config, ar.logger.Named("stageEventToTraces")))
+		}
+	case eventJobStateChanged:
+		if ar.metricsConsumer != nil {
+			ar.consumeMetrics(ctx, ar.metricsHandler.jobEventToMetrics(e))
+		}
+
+		// Jobs are reported with their run when timelines are read.
+		if ar.tracesConsumer != nil && ar.client == nil {
+			ar.consumeTraces(ctx, jobEventToTraces(e, ar.config, ar.logger.Named("jobEventToTraces")))
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// processEvents reports the traces and logs of the queued runs, until ctx
+// is canceled.
+func (ar *azureDevOpsReceiver) processEvents(ctx context.Context) {
+	for {
+		select {
+		case e := <-ar.events:
+			ar.processEvent(ctx, e)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// processEvent reads the timeline of a run and reports its traces and logs.
+// Runs whose requests are canceled by shutdown are still reported with what
+// was read.
+func (ar *azureDevOpsReceiver) processEvent(ctx context.Context, e *serviceHookEvent) {
+	records := ar.timeline(ctx, e)
+
+	if ar.tracesConsumer != nil {
+		ar.consumeTraces(context.WithoutCancel(ctx), runEventToTraces(e, records, ar.config, ar.logger.Named("runEventToTraces")))
+	}
+
+	if ar.logsConsumer != nil {
+		ar.consumeRunLogs(ctx, e, records)
+	}
+}
+
+// timeline returns the records of the timeline of the run of an event,
+// returning nil when the API is not configured or fails to read it.
+func (ar *azureDevOpsReceiver) timeline(ctx context.Context, e *serviceHookEvent) []timelineRecord {
+	if ar.client == nil || (ar.tracesConsumer == nil && ar.logsConsumer == nil) {
+		return nil
+	}
+
+	r := e.run()
+	records, err := ar.client.timeline(ctx, r.ProjectID, r.ID)
+	if err != nil {
+		ar.logger.Error("Failed to read timeline of run", zap.String("project", r.ProjectID), zap.String("pipeline", r.PipelineName), zap.Int64("id", r.ID), zap.Error(err))
+		return nil
+	}
+	return records
+}
+
+func (ar *azureDevOpsReceiver) consumeTraces(ctx context.Context, td ptrace.Traces) {
+	tracesCtx := ar.obsrecv.StartTracesOp(ctx)
+	err := ar.tracesConsumer.ConsumeTraces(tracesCtx, td)
+	ar.obsrecv.EndTracesOp(tracesCtx, metadata.Type.String(), td.SpanCount(), err)
+	if err != nil {
+		ar.logger.Error("Failed to consume traces", zap.Error(err))
+	}
+}
+
+func (ar *azureDevOpsReceiver) consumeMetrics(ctx context.Context, md pmetric.Metrics) {
+	metricsCtx := ar.obsrecv.StartMetricsOp(ctx)
+	err := ar.metricsConsumer.ConsumeMetrics(metricsCtx, md)
+	ar.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), md.DataPointCount(), err)
+	if err != nil {
+		ar.logger.Error("Failed to consume metrics", zap.Error(err))
+	}
+}
+
+func (ar *azureDevOpsReceiver) consumeRunLogs(ctx context.Context, e *serviceHookEvent, records []timelineRecord) {
+	// The logs of tasks can only be read from the API.
+	if records == nil {
+		ar.logger.Debug("Azure DevOps API not configured or timeline not read, logs will not be processed.")
+		return
+	}
+
+	ld, err := runEventToLogs(ctx, e, records, ar.config, ar.client, ar.logPolicy, ar.logger.Named("runEventToLogs"))
+	if err != nil {
+		ar.logger.Error("Failed to process logs", zap.Error(err))
+		return
+	}
+	if ld == nil {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	logsCtx := ar.obsrecv.StartLogsOp(ctx)
+	err = ar.logsConsumer.ConsumeLogs(logsCtx, *ld)
+	ar.obsrecv.EndLogsOp(logsCtx, metadata.Type.String(), ld.LogRecordCount(), err)
+	if err != nil {
+		ar.logger.Error("Failed to consume logs", zap.Error(err))
+	}
+}
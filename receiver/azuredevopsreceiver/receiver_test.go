@@ -0,0 +1,292 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+func TestNewReceiver(t *testing.T) {
+	defaultConfig := createDefaultConfig().(*Config)
+	withToken := createDefaultConfig().(*Config)
+	withToken.AzureDevOpsAPIConfig.BaseURL = "https://dev.azure.com/acme/"
+	withToken.AzureDevOpsAPIConfig.Token = "api-token"
+
+	tests := []struct {
+		desc         string
+		config       Config
+		expectClient bool
+		err          error
+	}{
+		{
+			desc:   "Default config succeeds",
+			config: *defaultConfig,
+		},
+		{
+			desc:         "Token creates an Azure DevOps client",
+			config:       *withToken,
+			expectClient: true,
+		},
+		{
+			desc:   "Missing endpoint fails",
+			config: Config{},
+			err:    errMissingEndpoint,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), &test.config)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectClient, rec.client != nil)
+			require.NoError(t, rec.Shutdown(context.Background()))
+		})
+	}
+}
+
+// newTestReceiver returns a receiver authenticating service hooks with
+// basic authentication, reading timelines from the test server when
+// withAPI is set.
+func newTestReceiver(t *testing.T, withAPI bool) (*azureDevOpsReceiver, *consumertest.TracesSink, *consumertest.LogsSink, *consumertest.MetricsSink) {
+	t.Helper()
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.BasicAuth = BasicAuthConfig{Username: "azuredevops", Password: "hookpassword"}
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, rec.Shutdown(context.Background())) })
+
+	tracesSink := new(consumertest.TracesSink)
+	logsSink := new(consumertest.LogsSink)
+	metricsSink := new(consumertest.MetricsSink)
+	rec.tracesConsumer = tracesSink
+	rec.logsConsumer = logsSink
+	rec.metricsConsumer = metricsSink
+	if withAPI {
+		rec.client = newAzureDevOpsTestServer(t)
+	}
+	return rec, tracesSink, logsSink, metricsSink
+}
+
+func serveEvent(rec *azureDevOpsReceiver, path, password string, payload []byte) int {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	if password != "" {
+		req.SetBasicAuth("azuredevops", password)
+	}
+	w := httptest.NewRecorder()
+
+	rec.ServeHTTP(w, req)
+	// Traces and logs of runs are reported in the background
+	for len(rec.events) > 0 {
+		rec.processEvent(context.Background(), <-rec.events)
+	}
+	return w.Code
+}
+
+func TestServeHTTP(t *testing.T) {
+	payload := func(name string) []byte {
+		payload, err := os.ReadFile(filepath.Join("testdata", name))
+		require.NoError(t, err)
+		return payload
+	}
+	build := payload("build_complete.json")
+	run := payload("run_state_changed.json")
+	stage := payload("stage_state_changed.json")
+	job := payload("job_state_changed.json")
+	runningJob := bytes.ReplaceAll(job, []byte(`"state": "completed"`), []byte(`"state": "inProgress"`))
+
+	tests := []struct {
+		desc          string
+		path          string
+		password      string
+		payload       []byte
+		withAPI       bool
+		expectStatus  int
+		expectSpans   int
+		expectLogs    int
+		expectMetrics bool
+	}{
+		{
+			desc:         "Unknown path",
+			path:         "/other",
+			payload:      build,
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			desc:         "Invalid credentials",
+			password:     "wrong",
+			payload:      build,
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:         "Unsupported event",
+			payload:      []byte(`{"eventType": "git.push", "resource": {}}`),
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			desc:         "Invalid payload",
+			payload:      []byte(`{`),
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			desc:         "Running job",
+			payload:      runningJob,
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			desc:          "Completed build",
+			payload:       build,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   1,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Completed run with timeline",
+			payload:       run,
+			withAPI:       true,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   7,
+			expectLogs:    8,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Completed stage",
+			payload:       stage,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   1,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Completed job",
+			payload:       job,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   1,
+			expectMetrics: true,
+		},
+		{
+			// Jobs are reported with the timeline of their run.
+			desc:          "Completed job with timeline",
+			payload:       job,
+			withAPI:       true,
+			expectStatus:  http.StatusAccepted,
+			expectMetrics: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			rec, tracesSink, logsSink, metricsSink := newTestReceiver(t, test.withAPI)
+
+			path := test.path
+			if path == "" {
+				path = rec.config.Path
+			}
+			password := test.password
+			if password == "" {
+				password = "hookpassword"
+			}
+
+			require.Equal(t, test.expectStatus, serveEvent(rec, path, password, test.payload))
+			require.Equal(t, test.expectSpans, tracesSink.SpanCount())
+			require.Equal(t, test.expectLogs, logsSink.LogRecordCount())
+			require.Equal(t, test.expectMetrics, len(metricsSink.AllMetrics()) > 0)
+		})
+	}
+}
+
+func TestServeHTTPQueue(t *testing.T) {
+	payload, err := os.ReadFile(filepath.Join("testdata", "run_state_changed.json"))
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.AzureDevOpsAPIConfig.QueueSize = 1
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	rec.tracesConsumer = new(consumertest.TracesSink)
+	metricsSink := new(consumertest.MetricsSink)
+	rec.metricsConsumer = metricsSink
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, cfg.Path, bytes.NewReader(payload))
+		w := httptest.NewRecorder()
+		rec.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusAccepted, send())
+	// Runs are rejected while the queue is full
+	require.Equal(t, http.StatusServiceUnavailable, send())
+	require.Len(t, rec.events, 1)
+
+	// Rejected runs are counted once redelivered
+	require.Len(t, metricsSink.AllMetrics(), 1)
+	<-rec.events
+	require.Equal(t, http.StatusAccepted, send())
+	require.Len(t, metricsSink.AllMetrics(), 2)
+}
+
+func TestShutdownCancelsAPIRequests(t *testing.T) {
+	// The API never answers
+	requested := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	payload, err := os.ReadFile(filepath.Join("testdata", "run_state_changed.json"))
+	require.NoError(t, err)
+	e, err := parseServiceHookEvent(payload)
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.NetAddr.Endpoint = "127.0.0.1:0"
+	cfg.AzureDevOpsAPIConfig.BaseURL = server.URL + "/acme"
+	cfg.AzureDevOpsAPIConfig.Token = "api-token"
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	tracesSink := new(consumertest.TracesSink)
+	rec.tracesConsumer = tracesSink
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	rec.events <- e
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timeline was not requested")
+	}
+
+	// Shutdown doesn't wait for the timeout of the request
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	started := time.Now()
+	require.NoError(t, rec.Shutdown(ctx))
+	require.Less(t, time.Since(started), time.Second)
+
+	// The run is reported without its timeline
+	require.Positive(t, tracesSink.SpanCount())
+}
@@ -0,0 +1,186 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeName    = "azuredevopsreceiver"
+	scopeVersion = "0.1.0"
+)
+
+// legacyResourceAttributes, legacyRunAttributes and legacyJobAttributes are
+// the attributes replaced by the semantic conventions. Attributes without
+// an equivalent keep their names.
+var legacyResourceAttributes = []string{
+	"ci.azuredevops.repository.url",
+}
+
+var legacyRunAttributes = []string{
+	"ci.azuredevops.pipeline.name",
+	"ci.azuredevops.run.id",
+	"ci.azuredevops.run.url",
+	"ci.azuredevops.run.result",
+	"ci.azuredevops.source_branch",
+	"ci.azuredevops.source_version",
+}
+
+var legacyJobAttributes = []string{
+	"ci.azuredevops.job.id",
+	"ci.azuredevops.job.name",
+	"ci.azuredevops.job.result",
+	"ci.azuredevops.job.worker",
+}
+
+// runEventToTraces translates a completed run to its span. The spans of its
+// stages, jobs and tasks are added from the timeline of the run, records is
+// nil when the API is not configured.
+func runEventToTraces(e *serviceHookEvent, records []timelineRecord, config *Config, logger *zap.Logger) ptrace.Traces {
+	r := e.run()
+	logger.Debug("Processing run",
+		zap.String("project", r.ProjectID),
+		zap.String("pipeline", r.PipelineName),
+		zap.Int64("id", r.ID),
+		zap.String("result", r.Result),
+		zap.Int("records", len(records)),
+	)
+
+	pipeline := runPipeline(r)
+	if records != nil {
+		pipeline = timelinePipeline(r, records)
+	}
+	finishPipeline(&pipeline, e, config)
+	return cimodel.ToTraces(&pipeline, traceOptions(config))
+}
+
+// stageEventToTraces translates a completed stage to its span.
+func stageEventToTraces(e *serviceHookEvent, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing stage",
+		zap.String("project", e.projectID()),
+		zap.Int64("run", e.Pipelines.Run.ID),
+		zap.String("stage", e.Pipelines.Stage.Name),
+		zap.String("result", e.Pipelines.Stage.Result),
+	)
+
+	pipeline := stageEventPipeline(e)
+	finishPipeline(&pipeline, e, config)
+	return cimodel.ToTraces(&pipeline, traceOptions(config))
+}
+
+// jobEventToTraces translates a completed job to its span.
+func jobEventToTraces(e *serviceHookEvent, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing job",
+		zap.String("project", e.projectID()),
+		zap.Int64("run", e.Pipelines.Run.ID),
+		zap.String("job", e.Pipelines.Job.Name),
+		zap.String("result", e.Pipelines.Job.Result),
+	)
+
+	pipeline := jobEventPipeline(e)
+	finishPipeline(&pipeline, e, config)
+	return cimodel.ToTraces(&pipeline, traceOptions(config))
+}
+
+// finishPipeline sets the resource attributes of a run, and removes its
+// legacy attributes.
+func finishPipeline(p *cimodel.Pipeline, e *serviceHookEvent, config *Config) {
+	p.ResourceAttributes = resourceAttributes(e, p, config)
+	removeLegacyAttributes(p, config.Semconv)
+}
+
+func resourceAttributes(e *serviceHookEvent, p *cimodel.Pipeline, config *Config) map[string]any {
+	attrs := map[string]any{
+		"service.name":              generateServiceName(config, p.Name),
+		"ci.azuredevops.project.id": e.projectID(),
+	}
+	if e.Build != nil && e.Build.Project.Name != "" {
+		attrs["ci.azuredevops.project.name"] = e.Build.Project.Name
+	}
+	if u := e.collectionURL(); u != "" {
+		attrs["ci.azuredevops.collection.url"] = u
+	}
+	if p.Repository.URL != "" {
+		attrs["ci.azuredevops.repository.url"] = p.Repository.URL
+	}
+	return attrs
+}
+
+// removeLegacyAttributes removes the attributes replaced by the semantic
+// conventions, unless the legacy ones are emitted.
+func removeLegacyAttributes(p *cimodel.Pipeline, cfg semconv.Config) {
+	if cfg.EmitsLegacy() {
+		return
+	}
+
+	for _, key := range legacyResourceAttributes {
+		delete(p.ResourceAttributes, key)
+	}
+	for _, key := range legacyRunAttributes {
+		delete(p.Attributes, key)
+	}
+	for i := range p.Tasks {
+		for _, key := range legacyJobAttributes {
+			delete(p.Tasks[i].Attributes, key)
+		}
+	}
+}
+
+func traceOptions(config *Config) cimodel.Options {
+	return cimodel.Options{
+		ScopeName:    scopeName,
+		ScopeVersion: scopeVersion,
+		Semconv:      config.Semconv,
+	}
+}
+
+// generateServiceName derives the service name from the name of a
+// pipeline, with its folder, such as infra-deploy for \infra\deploy.
+func generateServiceName(config *Config, pipelineName string) string {
+	if config.CustomServiceName != "" {
+		return config.CustomServiceName
+	}
+	formattedName := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(pipelineName, "/", "-"), "_", "-"), " ", "-"))
+	return fmt.Sprintf("%s%s%s", config.ServiceNamePrefix, formattedName, config.ServiceNameSuffix)
+}
+
+// generateTraceID returns the trace ID of a run, from the key of the run.
+// Builds and runs share their IDs, so build and pipelines events of a run
+// have the same trace.
+func generateTraceID(runKey string) pcommon.TraceID {
+	hash := sha256.Sum256([]byte(runKey + "t"))
+	return pcommon.TraceID(hash[:16])
+}
+
+func generateRunSpanID(runKey string) pcommon.SpanID {
+	return generateSpanID(runKey + "s")
+}
+
+// generateStageSpanID returns the span ID of a stage, from its identifier:
+// the name of the stage in the pipeline YAML.
+func generateStageSpanID(runKey, stageID string) pcommon.SpanID {
+	return generateSpanID(runKey + "g" + stageID)
+}
+
+func generateJobSpanID(runKey, jobID string) pcommon.SpanID {
+	return generateSpanID(runKey + "j" + jobID)
+}
+
+func generateStepSpanID(runKey, recordID string) pcommon.SpanID {
+	return generateSpanID(runKey + "p" + recordID)
+}
+
+func generateSpanID(input string) pcommon.SpanID {
+	hash := sha256.Sum256([]byte(input))
+	return pcommon.SpanID(hash[:8])
+}
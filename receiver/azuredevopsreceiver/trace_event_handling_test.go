@@ -0,0 +1,229 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package azuredevopsreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap/zaptest"
+)
+
+func spansByName(traces ptrace.Traces) map[string]ptrace.Span {
+	byName := map[string]ptrace.Span{}
+	for i := range traces.ResourceSpans().Len() {
+		spans := traces.ResourceSpans().At(i).ScopeSpans().At(0).Spans()
+		for j := range spans.Len() {
+			byName[spans.At(j).Name()] = spans.At(j)
+		}
+	}
+	return byName
+}
+
+func at(hour, minute, second int) time.Time {
+	return time.Date(2024, 5, 12, hour, minute, second, 0, time.UTC)
+}
+
+// testAPIConfig is the configuration of a receiver using the API.
+func testAPIConfig() *Config {
+	return &Config{AzureDevOpsAPIConfig: AzureDevOpsAPIConfig{BaseURL: "https://dev.azure.com/acme/", Token: "api-token"}}
+}
+
+func TestRunEventToTraces(t *testing.T) {
+	e := loadEvent(t, "build_complete.json")
+
+	traces := runEventToTraces(e, nil, &Config{}, zaptest.NewLogger(t))
+	require.Equal(t, 1, traces.SpanCount())
+
+	resource := traces.ResourceSpans().At(0).Resource().Attributes()
+	serviceName, _ := resource.Get("service.name")
+	require.Equal(t, "apps-web-app-ci", serviceName.Str())
+	project, _ := resource.Get("ci.azuredevops.project.name")
+	require.Equal(t, "Platform", project.Str())
+	collection, _ := resource.Get("ci.azuredevops.collection.url")
+	require.Equal(t, "https://dev.azure.com/acme/", collection.Str())
+
+	run := spansByName(traces)["apps/web-app CI"]
+	require.Equal(t, "55ba7c0eed580cfd50bb3938e200f51f", run.TraceID().String())
+	require.Equal(t, "79105b471606fc7b", run.SpanID().String())
+	require.True(t, run.ParentSpanID().IsEmpty())
+	require.Equal(t, ptrace.StatusCodeOk, run.Status().Code())
+	require.Equal(t, time.Date(2024, 5, 12, 10, 0, 5, 123456700, time.UTC), run.StartTimestamp().AsTime())
+	require.Equal(t, time.Date(2024, 5, 12, 10, 4, 35, 765432100, time.UTC), run.EndTimestamp().AsTime())
+	number, _ := run.Attributes().Get("ci.azuredevops.run.name")
+	require.Equal(t, "20240512.3", number.Str())
+	reason, _ := run.Attributes().Get("ci.azuredevops.run.reason")
+	require.Equal(t, "individualCI", reason.Str())
+	branch, _ := run.Attributes().Get("ci.azuredevops.source_branch")
+	require.Equal(t, "refs/heads/main", branch.Str())
+}
+
+func TestRunEventToTracesTimeline(t *testing.T) {
+	e := loadEvent(t, "run_state_changed.json")
+	records, err := newAzureDevOpsTestServer(t).timeline(t.Context(), testProjectID, testRunID)
+	require.NoError(t, err)
+
+	traces := runEventToTraces(e, records, testAPIConfig(), zaptest.NewLogger(t))
+	// The run, its 2 stages, its job and 3 tasks. Phases and checkpoints
+	// have no span.
+	require.Equal(t, 7, traces.SpanCount())
+
+	spans := spansByName(traces)
+	run := spans["apps/web-app CI"]
+	require.Equal(t, ptrace.StatusCodeError, run.Status().Code())
+	require.Equal(t, "failed", run.Status().Message())
+
+	build := spans["Build web app"]
+	require.Equal(t, run.SpanID(), build.ParentSpanID())
+	require.Equal(t, ptrace.StatusCodeError, build.Status().Code())
+	stageID, _ := build.Attributes().Get("ci.azuredevops.stage.id")
+	require.Equal(t, "Build", stageID.Str())
+
+	// Skipped stages start and end with the run.
+	deploy := spans["Deploy"]
+	require.Equal(t, run.SpanID(), deploy.ParentSpanID())
+	require.Equal(t, run.EndTimestamp(), deploy.StartTimestamp())
+	require.Equal(t, run.EndTimestamp(), deploy.EndTimestamp())
+
+	// Jobs are under the stage of their phase.
+	job := spans["Test"]
+	require.Equal(t, build.SpanID(), job.ParentSpanID())
+	require.Equal(t, at(11, 0, 20), job.StartTimestamp().AsTime())
+	require.Equal(t, at(11, 2, 35), job.EndTimestamp().AsTime())
+	worker, _ := job.Attributes().Get("ci.azuredevops.job.worker")
+	require.Equal(t, "Hosted Agent", worker.Str())
+	errorCount, _ := job.Attributes().Get("ci.azuredevops.job.error_count")
+	require.EqualValues(t, 1, errorCount.Int())
+
+	tasks := map[string]struct {
+		start, end time.Time
+		code       ptrace.StatusCode
+	}{
+		"Checkout acme/web-app@refs/pull/17/merge to s": {at(11, 0, 22), at(11, 0, 31), ptrace.StatusCodeOk},
+		"Run tests":            {at(11, 0, 31), at(11, 2, 30), ptrace.StatusCodeError},
+		"Publish test results": {at(11, 2, 35), at(11, 2, 35), ptrace.StatusCodeUnset},
+	}
+	for name, expected := range tasks {
+		task := spans[name]
+		require.Equal(t, job.SpanID(), task.ParentSpanID(), name)
+		require.Equal(t, expected.start, task.StartTimestamp().AsTime(), name)
+		require.Equal(t, expected.end, task.EndTimestamp().AsTime(), name)
+		require.Equal(t, expected.code, task.Status().Code(), name)
+	}
+}
+
+func TestStageAndJobEventsToTraces(t *testing.T) {
+	run := spansByName(runEventToTraces(loadEvent(t, "run_state_changed.json"), nil, &Config{}, zaptest.NewLogger(t)))["apps/web-app CI"]
+
+	traces := stageEventToTraces(loadEvent(t, "stage_state_changed.json"), &Config{}, zaptest.NewLogger(t))
+	require.Equal(t, 1, traces.SpanCount())
+	stage := spansByName(traces)["Build web app"]
+	require.Equal(t, run.TraceID(), stage.TraceID())
+	require.Equal(t, run.SpanID(), stage.ParentSpanID())
+	require.Equal(t, at(11, 0, 10), stage.StartTimestamp().AsTime())
+	require.Equal(t, at(11, 2, 40), stage.EndTimestamp().AsTime())
+
+	traces = jobEventToTraces(loadEvent(t, "job_state_changed.json"), &Config{}, zaptest.NewLogger(t))
+	require.Equal(t, 1, traces.SpanCount())
+	job := spansByName(traces)["Test"]
+	require.Equal(t, run.TraceID(), job.TraceID())
+	require.Equal(t, stage.SpanID(), job.ParentSpanID())
+	require.Equal(t, ptrace.StatusCodeError, job.Status().Code())
+
+	// Events and timelines identify stages and jobs alike, so that their
+	// spans are the same.
+	records, err := newAzureDevOpsTestServer(t).timeline(t.Context(), testProjectID, testRunID)
+	require.NoError(t, err)
+	spans := spansByName(runEventToTraces(loadEvent(t, "run_state_changed.json"), records, testAPIConfig(), zaptest.NewLogger(t)))
+	require.Equal(t, stage.SpanID(), spans["Build web app"].SpanID())
+	require.Equal(t, job.SpanID(), spans["Test"].SpanID())
+}
+
+func TestRunEventToTracesSemconv(t *testing.T) {
+	e := loadEvent(t, "build_complete.json")
+
+	tests := []struct {
+		desc         string
+		semconv      semconv.Config
+		expectLegacy bool
+	}{
+		{
+			desc:         "Semantic conventions with legacy attributes",
+			semconv:      semconv.Config{Enabled: true, EmitLegacy: true},
+			expectLegacy: true,
+		},
+		{
+			desc:         "Semantic conventions only",
+			semconv:      semconv.Config{Enabled: true},
+			expectLegacy: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			traces := runEventToTraces(e, nil, &Config{Semconv: test.semconv}, zaptest.NewLogger(t))
+
+			resource := traces.ResourceSpans().At(0).Resource().Attributes()
+			provider, _ := resource.Get(semconv.AttributeVCSProviderName)
+			require.Equal(t, semconv.AttributeVCSProviderNameGithub, provider.Str())
+			repoURL, _ := resource.Get(semconv.AttributeVCSRepositoryURLFull)
+			require.Equal(t, "https://github.com/acme/web-app", repoURL.Str())
+			_, ok := resource.Get("ci.azuredevops.repository.url")
+			require.Equal(t, test.expectLegacy, ok)
+
+			run := spansByName(traces)["apps/web-app CI"].Attributes()
+			pipelineName, _ := run.Get(semconv.AttributeCICDPipelineName)
+			require.Equal(t, "apps/web-app CI", pipelineName.Str())
+			result, _ := run.Get(semconv.AttributeCICDPipelineResult)
+			require.Equal(t, "success", result.Str())
+			_, ok = run.Get("ci.azuredevops.run.result")
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = run.Get("ci.azuredevops.run.reason")
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestRunRef(t *testing.T) {
+	tests := map[string]struct {
+		refName  string
+		head     string
+		headType string
+		changeID string
+	}{
+		"branch":       {refName: "refs/heads/feature/login", head: "feature/login", headType: semconv.AttributeVCSRefTypeBranch},
+		"tag":          {refName: "refs/tags/v1.2.0", head: "v1.2.0", headType: semconv.AttributeVCSRefTypeTag},
+		"pull request": {refName: "refs/pull/17/merge", changeID: "17"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ref := runRef(&run{RefName: test.refName})
+			require.Equal(t, test.head, ref.Head)
+			require.Equal(t, test.headType, ref.HeadType)
+			require.Equal(t, test.changeID, ref.ChangeID)
+		})
+	}
+}
+
+func TestGenerateServiceName(t *testing.T) {
+	tests := map[string]struct {
+		config   *Config
+		pipeline string
+		expect   string
+	}{
+		"folder":  {config: &Config{}, pipeline: "apps/web_app CI", expect: "apps-web-app-ci"},
+		"custom":  {config: &Config{CustomServiceName: "ci"}, pipeline: "web-app", expect: "ci"},
+		"affixes": {config: &Config{ServiceNamePrefix: "foo-", ServiceNameSuffix: "-bar"}, pipeline: "web-app", expect: "foo-web-app-bar"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expect, generateServiceName(test.config, test.pipeline))
+		})
+	}
+}
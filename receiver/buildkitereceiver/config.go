@@ -0,0 +1,47 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver"
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.uber.org/multierr"
+)
+
+var errMissingEndpointFromConfig = errors.New("missing receiver server endpoint from config")
+var errSignatureTolerance = errors.New("signature_tolerance must not be negative")
+
+// Config defines configuration for Buildkite receiver
+type Config struct {
+	metadata.MetricsBuilderConfig `mapstructure:",squash"`
+	confighttp.ServerConfig       `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct
+	Path                          string                   `mapstructure:"path"`                // path for data collection. Default is <host>:<port>/bkevents
+	Secret                        string                   `mapstructure:"secret"`              // webhook token, or signature secret. Default is empty
+	SignatureTolerance            time.Duration            `mapstructure:"signature_tolerance"` // maximum age of signed requests. Default is 5m
+	CustomServiceName             string                   `mapstructure:"custom_service_name"` // custom service name. Default is empty
+	ServiceNamePrefix             string                   `mapstructure:"service_name_prefix"` // service name prefix. Default is empty
+	ServiceNameSuffix             string                   `mapstructure:"service_name_suffix"` // service name suffix. Default is empty
+	Semconv                       semconv.Config           `mapstructure:"semconv"`             // OpenTelemetry CICD and VCS semantic conventions
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the receiver configuration is valid
+func (cfg *Config) Validate() error {
+	var errs error
+
+	if cfg.NetAddr.Endpoint == "" {
+		errs = multierr.Append(errs, errMissingEndpointFromConfig)
+	}
+	if cfg.SignatureTolerance < 0 {
+		errs = multierr.Append(errs, errSignatureTolerance)
+	}
+
+	return errs
+}
@@ -0,0 +1,253 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	eventHeader     = "X-Buildkite-Event"
+	tokenHeader     = "X-Buildkite-Token"
+	signatureHeader = "X-Buildkite-Signature"
+)
+
+// Events handled by the receiver. Other build and job events are counted.
+const (
+	eventPing          = "ping"
+	eventBuildFinished = "build.finished"
+	eventJobStarted    = "job.started"
+	eventJobFinished   = "job.finished"
+)
+
+// defaultQueue is the queue of agents and jobs without a queue tag.
+const defaultQueue = "default"
+
+var (
+	errMissingBuild     = errors.New("build event has no build or pipeline")
+	errMissingJob       = errors.New("job event has no job, build or pipeline")
+	errMissingAgent     = errors.New("agent event has no agent")
+	errInvalidToken     = errors.New("invalid webhook token")
+	errInvalidSignature = errors.New("invalid webhook signature")
+	errExpiredSignature = errors.New("expired webhook signature")
+)
+
+// webhookEvent is a Buildkite webhook event. Build and job events carry the
+// build and its pipeline, agent events the agent.
+type webhookEvent struct {
+	Event    string    `json:"event"`
+	Build    *build    `json:"build"`
+	Job      *job      `json:"job"`
+	Pipeline *pipeline `json:"pipeline"`
+	Agent    *agent    `json:"agent"`
+}
+
+type pipeline struct {
+	ID            string `json:"id"`
+	WebURL        string `json:"web_url"`
+	Name          string `json:"name"`
+	Slug          string `json:"slug"`
+	Repository    string `json:"repository"`
+	DefaultBranch string `json:"default_branch"`
+	Provider      struct {
+		ID string `json:"id"`
+	} `json:"provider"`
+}
+
+type build struct {
+	ID          string       `json:"id"`
+	WebURL      string       `json:"web_url"`
+	Number      int64        `json:"number"`
+	State       string       `json:"state"`
+	Blocked     bool         `json:"blocked"`
+	Commit      string       `json:"commit"`
+	Branch      string       `json:"branch"`
+	Tag         string       `json:"tag"`
+	Source      string       `json:"source"`
+	CreatedAt   string       `json:"created_at"`
+	ScheduledAt string       `json:"scheduled_at"`
+	StartedAt   string       `json:"started_at"`
+	FinishedAt  string       `json:"finished_at"`
+	PullRequest *pullRequest `json:"pull_request"`
+	Jobs        []job        `json:"jobs"`
+}
+
+type pullRequest struct {
+	ID         string `json:"id"`
+	Base       string `json:"base"`
+	Repository string `json:"repository"`
+}
+
+// job is a step of a build: a command (script), a wait step (waiter), a
+// block or input step (manual) or a trigger step (trigger).
+type job struct {
+	ID              string   `json:"id"`
+	Type            string   `json:"type"`
+	Name            string   `json:"name"`
+	Label           string   `json:"label"`
+	StepKey         string   `json:"step_key"`
+	State           string   `json:"state"`
+	WebURL          string   `json:"web_url"`
+	Command         string   `json:"command"`
+	SoftFailed      bool     `json:"soft_failed"`
+	ExitStatus      *int     `json:"exit_status"`
+	AgentQueryRules []string `json:"agent_query_rules"`
+	Agent           *agent   `json:"agent"`
+	CreatedAt       string   `json:"created_at"`
+	ScheduledAt     string   `json:"scheduled_at"`
+	RunnableAt      string   `json:"runnable_at"`
+	StartedAt       string   `json:"started_at"`
+	FinishedAt      string   `json:"finished_at"`
+	Retried         bool     `json:"retried"`
+	RetriesCount    int64    `json:"retries_count"`
+	UnblockedBy     *struct {
+		Name string `json:"name"`
+	} `json:"unblocked_by"`
+	UnblockedAt    string `json:"unblocked_at"`
+	TriggeredBuild *struct {
+		ID     string `json:"id"`
+		Number int64  `json:"number"`
+		WebURL string `json:"web_url"`
+	} `json:"triggered_build"`
+}
+
+type agent struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	ConnectionState string   `json:"connection_state"`
+	Hostname        string   `json:"hostname"`
+	Version         string   `json:"version"`
+	MetaData        []string `json:"meta_data"`
+}
+
+func parseWebhookEvent(payload []byte) (*webhookEvent, error) {
+	var e webhookEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasPrefix(e.Event, "build.") && (e.Build == nil || e.Pipeline == nil):
+		return nil, errMissingBuild
+	case strings.HasPrefix(e.Event, "job.") && (e.Job == nil || e.Build == nil || e.Pipeline == nil):
+		return nil, errMissingJob
+	case strings.HasPrefix(e.Event, "agent.") && e.Agent == nil:
+		return nil, errMissingAgent
+	}
+	return &e, nil
+}
+
+// validateRequest checks the token or the signature of a webhook request.
+// Signatures are HMAC-SHA256 digests of the timestamp and the payload.
+func validateRequest(r *http.Request, payload []byte, secret string, tolerance time.Duration, now time.Time) error {
+	header := r.Header.Get(signatureHeader)
+	if header == "" {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(tokenHeader)), []byte(secret)) != 1 {
+			return errInvalidToken
+		}
+		return nil
+	}
+
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "timestamp":
+			timestamp = value
+		case "signature":
+			signature = value
+		}
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil || timestamp == "" {
+		return errInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return errInvalidSignature
+	}
+
+	// Signed requests are only accepted for a while, so that they cannot
+	// be replayed.
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); tolerance > 0 && (age > tolerance || age < -tolerance) {
+		return errExpiredSignature
+	}
+	return nil
+}
+
+// pipelineSlug returns the slug of the pipeline, including its
+// organization, such as acme/web-app.
+func (p *pipeline) pipelineSlug() string {
+	if p == nil {
+		return ""
+	}
+	if u, err := url.Parse(p.WebURL); err == nil && strings.Count(strings.Trim(u.Path, "/"), "/") == 1 {
+		return strings.Trim(u.Path, "/")
+	}
+	return p.Slug
+}
+
+// queue returns the queue of a job, from its agent targeting rules.
+func (j *job) queue() string {
+	return queueTag(j.AgentQueryRules)
+}
+
+// queue returns the queue of an agent, from its tags.
+func (a *agent) queue() string {
+	return queueTag(a.MetaData)
+}
+
+func queueTag(tags []string) string {
+	for _, tag := range tags {
+		if queue, ok := strings.CutPrefix(tag, "queue="); ok && queue != "" {
+			return queue
+		}
+	}
+	return defaultQueue
+}
+
+// displayName returns the name of a job as shown on the build page.
+func (j *job) displayName() string {
+	switch {
+	case j.Name != "":
+		return j.Name
+	case j.Label != "":
+		return j.Label
+	case j.Type == "waiter":
+		return "wait"
+	case j.StepKey != "":
+		return j.StepKey
+	default:
+		return j.Command
+	}
+}
+
+func parseTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
@@ -0,0 +1,161 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func loadEvent(t *testing.T, name string) *webhookEvent {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	e, err := parseWebhookEvent(payload)
+	require.NoError(t, err)
+	return e
+}
+
+// sign returns the signature header of a payload sent at timestamp.
+func sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(payload)
+	return fmt.Sprintf("timestamp=%d,signature=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func TestParseWebhookEvent(t *testing.T) {
+	tests := map[string]struct {
+		payload string
+		err     error
+	}{
+		"build":               {payload: `{"event": "build.finished", "build": {}, "pipeline": {}}`},
+		"build without build": {payload: `{"event": "build.finished", "pipeline": {}}`, err: errMissingBuild},
+		"job":                 {payload: `{"event": "job.finished", "job": {}, "build": {}, "pipeline": {}}`},
+		"job without job":     {payload: `{"event": "job.finished", "build": {}, "pipeline": {}}`, err: errMissingJob},
+		"agent":               {payload: `{"event": "agent.lost", "agent": {}}`},
+		"agent without agent": {payload: `{"event": "agent.lost"}`, err: errMissingAgent},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseWebhookEvent([]byte(test.payload))
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	payload := []byte(`{"event": "ping"}`)
+	now := time.Unix(1709632800, 0)
+
+	tests := []struct {
+		desc      string
+		token     string
+		signature string
+		err       error
+	}{
+		{
+			desc:  "Valid token",
+			token: "mysecret",
+		},
+		{
+			desc:  "Invalid token",
+			token: "wrong",
+			err:   errInvalidToken,
+		},
+		{
+			desc: "Missing token",
+			err:  errInvalidToken,
+		},
+		{
+			desc:      "Valid signature",
+			signature: sign("mysecret", now.Unix()-10, payload),
+		},
+		{
+			desc:      "Signature with another secret",
+			signature: sign("wrong", now.Unix(), payload),
+			err:       errInvalidSignature,
+		},
+		{
+			desc:      "Malformed signature",
+			signature: "timestamp=1709632800,signature=zz",
+			err:       errInvalidSignature,
+		},
+		{
+			desc:      "Expired signature",
+			signature: sign("mysecret", now.Unix()-600, payload),
+			err:       errExpiredSignature,
+		},
+		{
+			// The token is ignored when the request is signed.
+			desc:      "Signature takes precedence over token",
+			token:     "mysecret",
+			signature: sign("wrong", now.Unix(), payload),
+			err:       errInvalidSignature,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/bkevents", nil)
+			if test.token != "" {
+				r.Header.Set(tokenHeader, test.token)
+			}
+			if test.signature != "" {
+				r.Header.Set(signatureHeader, test.signature)
+			}
+
+			err := validateRequest(r, payload, "mysecret", 5*time.Minute, now)
+			require.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestPipelineSlug(t *testing.T) {
+	tests := map[string]struct {
+		pipeline *pipeline
+		expect   string
+	}{
+		"web URL":         {pipeline: &pipeline{WebURL: "https://buildkite.com/acme/web-app", Slug: "web-app"}, expect: "acme/web-app"},
+		"missing web URL": {pipeline: &pipeline{Slug: "web-app"}, expect: "web-app"},
+		"missing":         {expect: ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expect, test.pipeline.pipelineSlug())
+		})
+	}
+}
+
+func TestQueueTag(t *testing.T) {
+	tests := map[string]struct {
+		tags   []string
+		expect string
+	}{
+		"queue":       {tags: []string{"os=linux", "queue=test"}, expect: "test"},
+		"no queue":    {tags: []string{"os=linux"}, expect: defaultQueue},
+		"empty queue": {tags: []string{"queue="}, expect: defaultQueue},
+		"no tags":     {expect: defaultQueue},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expect, queueTag(test.tags))
+		})
+	}
+}
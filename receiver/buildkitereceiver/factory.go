@@ -0,0 +1,55 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver"
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/confignet"
+	"go.opentelemetry.io/collector/receiver"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver/internal/metadata"
+)
+
+// This file implements factory for Buildkite receiver.
+
+const (
+	defaultBindEndpoint       = "0.0.0.0:19421"
+	defaultPath               = "/bkevents"
+	defaultSignatureTolerance = 5 * time.Minute
+)
+
+// NewFactory creates a new Buildkite receiver factory
+func NewFactory() receiver.Factory {
+	return receiver.NewFactory(
+		metadata.Type,
+		createDefaultConfig,
+		receiver.WithTraces(newTracesReceiver, metadata.TracesStability),
+		receiver.WithMetrics(newMetricsReceiver, metadata.MetricsStability),
+	)
+}
+
+// createDefaultConfig creates the default configuration for Buildkite receiver.
+func createDefaultConfig() component.Config {
+	return &Config{
+		MetricsBuilderConfig: metadata.DefaultMetricsBuilderConfig(),
+		ServerConfig: confighttp.ServerConfig{
+			NetAddr: confignet.AddrConfig{
+				Transport: confignet.TransportTypeTCP,
+				Endpoint:  defaultBindEndpoint,
+			},
+		},
+		Path:               defaultPath,
+		SignatureTolerance: defaultSignatureTolerance,
+	}
+}
+
+// This is the map of already created buildkite receivers for particular configurations.
+// We maintain this map because the Factory is asked trace and metric receivers
+// separately but they must not create separate objects, they must use one receiver
+// object per configuration.
+var receivers = sharedcomponent.NewSharedComponents()
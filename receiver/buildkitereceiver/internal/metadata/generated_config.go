@@ -0,0 +1,63 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"go.opentelemetry.io/collector/confmap"
+)
+
+// MetricConfig provides common config for a particular metric.
+type MetricConfig struct {
+	Enabled          bool `mapstructure:"enabled"`
+	enabledSetByUser bool
+}
+
+func (ms *MetricConfig) Unmarshal(parser *confmap.Conf) error {
+	if parser == nil {
+		return nil
+	}
+
+	err := parser.Unmarshal(ms)
+	if err != nil {
+		return err
+	}
+
+	ms.enabledSetByUser = parser.IsSet("enabled")
+	return nil
+}
+
+// MetricsConfig provides config for buildkite metrics.
+type MetricsConfig struct {
+	AgentsConnected   MetricConfig `mapstructure:"agents.connected"`
+	AgentsEventsCount MetricConfig `mapstructure:"agents.events.count"`
+	BuildsCount       MetricConfig `mapstructure:"builds.count"`
+	JobsCount         MetricConfig `mapstructure:"jobs.count"`
+}
+
+func DefaultMetricsConfig() MetricsConfig {
+	return MetricsConfig{
+		AgentsConnected: MetricConfig{
+			Enabled: true,
+		},
+		AgentsEventsCount: MetricConfig{
+			Enabled: true,
+		},
+		BuildsCount: MetricConfig{
+			Enabled: true,
+		},
+		JobsCount: MetricConfig{
+			Enabled: true,
+		},
+	}
+}
+
+// MetricsBuilderConfig is a configuration for buildkite metrics builder.
+type MetricsBuilderConfig struct {
+	Metrics MetricsConfig `mapstructure:"metrics"`
+}
+
+func DefaultMetricsBuilderConfig() MetricsBuilderConfig {
+	return MetricsBuilderConfig{
+		Metrics: DefaultMetricsConfig(),
+	}
+}
@@ -0,0 +1,562 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+)
+
+// AttributeCiBuildkiteAgentEvent specifies the value ci.buildkite.agent.event attribute.
+type AttributeCiBuildkiteAgentEvent int
+
+const (
+	_ AttributeCiBuildkiteAgentEvent = iota
+	AttributeCiBuildkiteAgentEventConnected
+	AttributeCiBuildkiteAgentEventDisconnected
+	AttributeCiBuildkiteAgentEventLost
+	AttributeCiBuildkiteAgentEventStopping
+	AttributeCiBuildkiteAgentEventStopped
+	AttributeCiBuildkiteAgentEventBlocked
+)
+
+// String returns the string representation of the AttributeCiBuildkiteAgentEvent.
+func (av AttributeCiBuildkiteAgentEvent) String() string {
+	switch av {
+	case AttributeCiBuildkiteAgentEventConnected:
+		return "connected"
+	case AttributeCiBuildkiteAgentEventDisconnected:
+		return "disconnected"
+	case AttributeCiBuildkiteAgentEventLost:
+		return "lost"
+	case AttributeCiBuildkiteAgentEventStopping:
+		return "stopping"
+	case AttributeCiBuildkiteAgentEventStopped:
+		return "stopped"
+	case AttributeCiBuildkiteAgentEventBlocked:
+		return "blocked"
+	}
+	return ""
+}
+
+// MapAttributeCiBuildkiteAgentEvent is a helper map of string to AttributeCiBuildkiteAgentEvent attribute value.
+var MapAttributeCiBuildkiteAgentEvent = map[string]AttributeCiBuildkiteAgentEvent{
+	"connected":    AttributeCiBuildkiteAgentEventConnected,
+	"disconnected": AttributeCiBuildkiteAgentEventDisconnected,
+	"lost":         AttributeCiBuildkiteAgentEventLost,
+	"stopping":     AttributeCiBuildkiteAgentEventStopping,
+	"stopped":      AttributeCiBuildkiteAgentEventStopped,
+	"blocked":      AttributeCiBuildkiteAgentEventBlocked,
+}
+
+// AttributeCiBuildkiteBuildState specifies the value ci.buildkite.build.state attribute.
+type AttributeCiBuildkiteBuildState int
+
+const (
+	_ AttributeCiBuildkiteBuildState = iota
+	AttributeCiBuildkiteBuildStateScheduled
+	AttributeCiBuildkiteBuildStateRunning
+	AttributeCiBuildkiteBuildStatePassed
+	AttributeCiBuildkiteBuildStateFailing
+	AttributeCiBuildkiteBuildStateFailed
+	AttributeCiBuildkiteBuildStateBlocked
+	AttributeCiBuildkiteBuildStateCanceling
+	AttributeCiBuildkiteBuildStateCanceled
+	AttributeCiBuildkiteBuildStateSkipped
+	AttributeCiBuildkiteBuildStateNotRun
+)
+
+// String returns the string representation of the AttributeCiBuildkiteBuildState.
+func (av AttributeCiBuildkiteBuildState) String() string {
+	switch av {
+	case AttributeCiBuildkiteBuildStateScheduled:
+		return "scheduled"
+	case AttributeCiBuildkiteBuildStateRunning:
+		return "running"
+	case AttributeCiBuildkiteBuildStatePassed:
+		return "passed"
+	case AttributeCiBuildkiteBuildStateFailing:
+		return "failing"
+	case AttributeCiBuildkiteBuildStateFailed:
+		return "failed"
+	case AttributeCiBuildkiteBuildStateBlocked:
+		return "blocked"
+	case AttributeCiBuildkiteBuildStateCanceling:
+		return "canceling"
+	case AttributeCiBuildkiteBuildStateCanceled:
+		return "canceled"
+	case AttributeCiBuildkiteBuildStateSkipped:
+		return "skipped"
+	case AttributeCiBuildkiteBuildStateNotRun:
+		return "not_run"
+	}
+	return ""
+}
+
+// MapAttributeCiBuildkiteBuildState is a helper map of string to AttributeCiBuildkiteBuildState attribute value.
+var MapAttributeCiBuildkiteBuildState = map[string]AttributeCiBuildkiteBuildState{
+	"scheduled": AttributeCiBuildkiteBuildStateScheduled,
+	"running":   AttributeCiBuildkiteBuildStateRunning,
+	"passed":    AttributeCiBuildkiteBuildStatePassed,
+	"failing":   AttributeCiBuildkiteBuildStateFailing,
+	"failed":    AttributeCiBuildkiteBuildStateFailed,
+	"blocked":   AttributeCiBuildkiteBuildStateBlocked,
+	"canceling": AttributeCiBuildkiteBuildStateCanceling,
+	"canceled":  AttributeCiBuildkiteBuildStateCanceled,
+	"skipped":   AttributeCiBuildkiteBuildStateSkipped,
+	"not_run":   AttributeCiBuildkiteBuildStateNotRun,
+}
+
+// AttributeCiBuildkiteJobState specifies the value ci.buildkite.job.state attribute.
+type AttributeCiBuildkiteJobState int
+
+const (
+	_ AttributeCiBuildkiteJobState = iota
+	AttributeCiBuildkiteJobStatePassed
+	AttributeCiBuildkiteJobStateFailed
+	AttributeCiBuildkiteJobStateCanceled
+	AttributeCiBuildkiteJobStateTimedOut
+	AttributeCiBuildkiteJobStateSkipped
+	AttributeCiBuildkiteJobStateBroken
+	AttributeCiBuildkiteJobStateExpired
+)
+
+// String returns the string representation of the AttributeCiBuildkiteJobState.
+func (av AttributeCiBuildkiteJobState) String() string {
+	switch av {
+	case AttributeCiBuildkiteJobStatePassed:
+		return "passed"
+	case AttributeCiBuildkiteJobStateFailed:
+		return "failed"
+	case AttributeCiBuildkiteJobStateCanceled:
+		return "canceled"
+	case AttributeCiBuildkiteJobStateTimedOut:
+		return "timed_out"
+	case AttributeCiBuildkiteJobStateSkipped:
+		return "skipped"
+	case AttributeCiBuildkiteJobStateBroken:
+		return "broken"
+	case AttributeCiBuildkiteJobStateExpired:
+		return "expired"
+	}
+	return ""
+}
+
+// MapAttributeCiBuildkiteJobState is a helper map of string to AttributeCiBuildkiteJobState attribute value.
+var MapAttributeCiBuildkiteJobState = map[string]AttributeCiBuildkiteJobState{
+	"passed":    AttributeCiBuildkiteJobStatePassed,
+	"failed":    AttributeCiBuildkiteJobStateFailed,
+	"canceled":  AttributeCiBuildkiteJobStateCanceled,
+	"timed_out": AttributeCiBuildkiteJobStateTimedOut,
+	"skipped":   AttributeCiBuildkiteJobStateSkipped,
+	"broken":    AttributeCiBuildkiteJobStateBroken,
+	"expired":   AttributeCiBuildkiteJobStateExpired,
+}
+
+var MetricsInfo = metricsInfo{
+	AgentsConnected: metricInfo{
+		Name: "agents.connected",
+	},
+	AgentsEventsCount: metricInfo{
+		Name: "agents.events.count",
+	},
+	BuildsCount: metricInfo{
+		Name: "builds.count",
+	},
+	JobsCount: metricInfo{
+		Name: "jobs.count",
+	},
+}
+
+type metricsInfo struct {
+	AgentsConnected   metricInfo
+	AgentsEventsCount metricInfo
+	BuildsCount       metricInfo
+	JobsCount         metricInfo
+}
+
+type metricInfo struct {
+	Name string
+}
+
+type metricAgentsConnected struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills agents.connected metric with initial data.
+func (m *metricAgentsConnected) init() {
+	m.data.SetName("agents.connected")
+	m.data.SetDescription("Number of agents connected, by queue. Agents are tracked from the agent events received since the receiver started.")
+	m.data.SetUnit("{agent}")
+	m.data.SetEmptyGauge()
+	m.data.Gauge().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricAgentsConnected) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciBuildkiteAgentQueueAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Gauge().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.buildkite.agent.queue", ciBuildkiteAgentQueueAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricAgentsConnected) updateCapacity() {
+	if m.data.Gauge().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Gauge().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricAgentsConnected) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Gauge().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricAgentsConnected(cfg MetricConfig) metricAgentsConnected {
+	m := metricAgentsConnected{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricAgentsEventsCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills agents.events.count metric with initial data.
+func (m *metricAgentsEventsCount) init() {
+	m.data.SetName("agents.events.count")
+	m.data.SetDescription("Number of agent connection events, by queue and event.")
+	m.data.SetUnit("{event}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricAgentsEventsCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciBuildkiteAgentQueueAttributeValue string, ciBuildkiteAgentEventAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.buildkite.agent.queue", ciBuildkiteAgentQueueAttributeValue)
+	dp.Attributes().PutStr("ci.buildkite.agent.event", ciBuildkiteAgentEventAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricAgentsEventsCount) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricAgentsEventsCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricAgentsEventsCount(cfg MetricConfig) metricAgentsEventsCount {
+	m := metricAgentsEventsCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricBuildsCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills builds.count metric with initial data.
+func (m *metricBuildsCount) init() {
+	m.data.SetName("builds.count")
+	m.data.SetDescription("Number of build events, by state.")
+	m.data.SetUnit("{build}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricBuildsCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciBuildkitePipelineSlugAttributeValue string, ciBuildkiteBuildStateAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.buildkite.pipeline.slug", ciBuildkitePipelineSlugAttributeValue)
+	dp.Attributes().PutStr("ci.buildkite.build.state", ciBuildkiteBuildStateAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricBuildsCount) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricBuildsCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricBuildsCount(cfg MetricConfig) metricBuildsCount {
+	m := metricBuildsCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricJobsCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills jobs.count metric with initial data.
+func (m *metricJobsCount) init() {
+	m.data.SetName("jobs.count")
+	m.data.SetDescription("Number of finished jobs, by queue and state.")
+	m.data.SetUnit("{job}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricJobsCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciBuildkitePipelineSlugAttributeValue string, ciBuildkiteAgentQueueAttributeValue string, ciBuildkiteJobStateAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.buildkite.pipeline.slug", ciBuildkitePipelineSlugAttributeValue)
+	dp.Attributes().PutStr("ci.buildkite.agent.queue", ciBuildkiteAgentQueueAttributeValue)
+	dp.Attributes().PutStr("ci.buildkite.job.state", ciBuildkiteJobStateAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricJobsCount) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricJobsCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricJobsCount(cfg MetricConfig) metricJobsCount {
+	m := metricJobsCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+// MetricsBuilder provides an interface for scrapers to report metrics while taking care of all the transformations
+// required to produce metric representation defined in metadata and user config.
+type MetricsBuilder struct {
+	config                  MetricsBuilderConfig // config of the metrics builder.
+	startTime               pcommon.Timestamp    // start time that will be applied to all recorded data points.
+	metricsCapacity         int                  // maximum observed number of metrics per resource.
+	metricsBuffer           pmetric.Metrics      // accumulates metrics data before emitting.
+	buildInfo               component.BuildInfo  // contains version information.
+	metricAgentsConnected   metricAgentsConnected
+	metricAgentsEventsCount metricAgentsEventsCount
+	metricBuildsCount       metricBuildsCount
+	metricJobsCount         metricJobsCount
+}
+
+// MetricBuilderOption applies changes to default metrics builder.
+type MetricBuilderOption interface {
+	apply(*MetricsBuilder)
+}
+
+type metricBuilderOptionFunc func(mb *MetricsBuilder)
+
+func (mbof metricBuilderOptionFunc) apply(mb *MetricsBuilder) {
+	mbof(mb)
+}
+
+// WithStartTime sets startTime on the metrics builder.
+func WithStartTime(startTime pcommon.Timestamp) MetricBuilderOption {
+	return metricBuilderOptionFunc(func(mb *MetricsBuilder) {
+		mb.startTime = startTime
+	})
+}
+func NewMetricsBuilder(mbc MetricsBuilderConfig, settings receiver.Settings, options ...MetricBuilderOption) *MetricsBuilder {
+	mb := &MetricsBuilder{
+		config:                  mbc,
+		startTime:               pcommon.NewTimestampFromTime(time.Now()),
+		metricsBuffer:           pmetric.NewMetrics(),
+		buildInfo:               settings.BuildInfo,
+		metricAgentsConnected:   newMetricAgentsConnected(mbc.Metrics.AgentsConnected),
+		metricAgentsEventsCount: newMetricAgentsEventsCount(mbc.Metrics.AgentsEventsCount),
+		metricBuildsCount:       newMetricBuildsCount(mbc.Metrics.BuildsCount),
+		metricJobsCount:         newMetricJobsCount(mbc.Metrics.JobsCount),
+	}
+
+	for _, op := range options {
+		op.apply(mb)
+	}
+	return mb
+}
+
+// updateCapacity updates max length of metrics and resource attributes that will be used for the slice capacity.
+func (mb *MetricsBuilder) updateCapacity(rm pmetric.ResourceMetrics) {
+	if mb.metricsCapacity < rm.ScopeMetrics().At(0).Metrics().Len() {
+		mb.metricsCapacity = rm.ScopeMetrics().At(0).Metrics().Len()
+	}
+}
+
+// ResourceMetricsOption applies changes to provided resource metrics.
+type ResourceMetricsOption interface {
+	apply(pmetric.ResourceMetrics)
+}
+
+type resourceMetricsOptionFunc func(pmetric.ResourceMetrics)
+
+func (rmof resourceMetricsOptionFunc) apply(rm pmetric.ResourceMetrics) {
+	rmof(rm)
+}
+
+// WithResource sets the provided resource on the emitted ResourceMetrics.
+// It's recommended to use ResourceBuilder to create the resource.
+func WithResource(res pcommon.Resource) ResourceMetricsOption {
+	return resourceMetricsOptionFunc(func(rm pmetric.ResourceMetrics) {
+		res.CopyTo(rm.Resource())
+	})
+}
+
+// WithStartTimeOverride overrides start time for all the resource metrics data points.
+// This option should be only used if different start time has to be set on metrics coming from different resources.
+func WithStartTimeOverride(start pcommon.Timestamp) ResourceMetricsOption {
+	return resourceMetricsOptionFunc(func(rm pmetric.ResourceMetrics) {
+		var dps pmetric.NumberDataPointSlice
+		metrics := rm.ScopeMetrics().At(0).Metrics()
+		for i := 0; i < metrics.Len(); i++ {
+			switch metrics.At(i).Type() {
+			case pmetric.MetricTypeGauge:
+				dps = metrics.At(i).Gauge().DataPoints()
+			case pmetric.MetricTypeSum:
+				dps = metrics.At(i).Sum().DataPoints()
+			}
+			for j := 0; j < dps.Len(); j++ {
+				dps.At(j).SetStartTimestamp(start)
+			}
+		}
+	})
+}
+
+// EmitForResource saves all the generated metrics under a new resource and updates the internal state to be ready for
+// recording another set of data points as part of another resource. This function can be helpful when one scraper
+// needs to emit metrics from several resources. Otherwise calling this function is not required,
+// just `Emit` function can be called instead.
+// Resource attributes should be provided as ResourceMetricsOption arguments.
+func (mb *MetricsBuilder) EmitForResource(options ...ResourceMetricsOption) {
+	rm := pmetric.NewResourceMetrics()
+	ils := rm.ScopeMetrics().AppendEmpty()
+	ils.Scope().SetName(ScopeName)
+	ils.Scope().SetVersion(mb.buildInfo.Version)
+	ils.Metrics().EnsureCapacity(mb.metricsCapacity)
+	mb.metricAgentsConnected.emit(ils.Metrics())
+	mb.metricAgentsEventsCount.emit(ils.Metrics())
+	mb.metricBuildsCount.emit(ils.Metrics())
+	mb.metricJobsCount.emit(ils.Metrics())
+
+	for _, op := range options {
+		op.apply(rm)
+	}
+
+	if ils.Metrics().Len() > 0 {
+		mb.updateCapacity(rm)
+		rm.MoveTo(mb.metricsBuffer.ResourceMetrics().AppendEmpty())
+	}
+}
+
+// Emit returns all the metrics accumulated by the metrics builder and updates the internal state to be ready for
+// recording another set of metrics. This function will be responsible for applying all the transformations required to
+// produce metric representation defined in metadata and user config, e.g. delta or cumulative.
+func (mb *MetricsBuilder) Emit(options ...ResourceMetricsOption) pmetric.Metrics {
+	mb.EmitForResource(options...)
+	metrics := mb.metricsBuffer
+	mb.metricsBuffer = pmetric.NewMetrics()
+	return metrics
+}
+
+// RecordAgentsConnectedDataPoint adds a data point to agents.connected metric.
+func (mb *MetricsBuilder) RecordAgentsConnectedDataPoint(ts pcommon.Timestamp, val int64, ciBuildkiteAgentQueueAttributeValue string) {
+	mb.metricAgentsConnected.recordDataPoint(mb.startTime, ts, val, ciBuildkiteAgentQueueAttributeValue)
+}
+
+// RecordAgentsEventsCountDataPoint adds a data point to agents.events.count metric.
+func (mb *MetricsBuilder) RecordAgentsEventsCountDataPoint(ts pcommon.Timestamp, val int64, ciBuildkiteAgentQueueAttributeValue string, ciBuildkiteAgentEventAttributeValue AttributeCiBuildkiteAgentEvent) {
+	mb.metricAgentsEventsCount.recordDataPoint(mb.startTime, ts, val, ciBuildkiteAgentQueueAttributeValue, ciBuildkiteAgentEventAttributeValue.String())
+}
+
+// RecordBuildsCountDataPoint adds a data point to builds.count metric.
+func (mb *MetricsBuilder) RecordBuildsCountDataPoint(ts pcommon.Timestamp, val int64, ciBuildkitePipelineSlugAttributeValue string, ciBuildkiteBuildStateAttributeValue AttributeCiBuildkiteBuildState) {
+	mb.metricBuildsCount.recordDataPoint(mb.startTime, ts, val, ciBuildkitePipelineSlugAttributeValue, ciBuildkiteBuildStateAttributeValue.String())
+}
+
+// RecordJobsCountDataPoint adds a data point to jobs.count metric.
+func (mb *MetricsBuilder) RecordJobsCountDataPoint(ts pcommon.Timestamp, val int64, ciBuildkitePipelineSlugAttributeValue string, ciBuildkiteAgentQueueAttributeValue string, ciBuildkiteJobStateAttributeValue AttributeCiBuildkiteJobState) {
+	mb.metricJobsCount.recordDataPoint(mb.startTime, ts, val, ciBuildkitePipelineSlugAttributeValue, ciBuildkiteAgentQueueAttributeValue, ciBuildkiteJobStateAttributeValue.String())
+}
+
+// Reset resets metrics builder to its initial state. It should be used when external metrics source is restarted,
+// and metrics builder should update its startTime and reset it's internal state accordingly.
+func (mb *MetricsBuilder) Reset(options ...MetricBuilderOption) {
+	mb.startTime = pcommon.NewTimestampFromTime(time.Now())
+	for _, op := range options {
+		op.apply(mb)
+	}
+}
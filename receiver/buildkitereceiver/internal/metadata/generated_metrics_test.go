@@ -0,0 +1,187 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type testDataSet int
+
+const (
+	testDataSetDefault testDataSet = iota
+	testDataSetAll
+	testDataSetNone
+)
+
+func TestMetricsBuilder(t *testing.T) {
+	tests := []struct {
+		name        string
+		metricsSet  testDataSet
+		resAttrsSet testDataSet
+		expectEmpty bool
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:        "all_set",
+			metricsSet:  testDataSetAll,
+			resAttrsSet: testDataSetAll,
+		},
+		{
+			name:        "none_set",
+			metricsSet:  testDataSetNone,
+			resAttrsSet: testDataSetNone,
+			expectEmpty: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := pcommon.Timestamp(1_000_000_000)
+			ts := pcommon.Timestamp(1_000_001_000)
+			observedZapCore, observedLogs := observer.New(zap.WarnLevel)
+			settings := receivertest.NewNopSettings(receivertest.NopType)
+			settings.Logger = zap.New(observedZapCore)
+			mb := NewMetricsBuilder(loadMetricsBuilderConfig(t, tt.name), settings, WithStartTime(start))
+
+			expectedWarnings := 0
+			assert.Equal(t, expectedWarnings, observedLogs.Len())
+
+			defaultMetricsCount := 0
+			allMetricsCount := 0
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordAgentsConnectedDataPoint(ts, 1, "ci.buildkite.agent.queue-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordAgentsEventsCountDataPoint(ts, 1, "ci.buildkite.agent.queue-val", AttributeCiBuildkiteAgentEventConnected)
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordBuildsCountDataPoint(ts, 1, "ci.buildkite.pipeline.slug-val", AttributeCiBuildkiteBuildStateScheduled)
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordJobsCountDataPoint(ts, 1, "ci.buildkite.pipeline.slug-val", "ci.buildkite.agent.queue-val", AttributeCiBuildkiteJobStatePassed)
+
+			res := pcommon.NewResource()
+			metrics := mb.Emit(WithResource(res))
+
+			if tt.expectEmpty {
+				assert.Equal(t, 0, metrics.ResourceMetrics().Len())
+				return
+			}
+
+			var allMetricsList []pmetric.Metric
+			totalMetricsCount := 0
+			for ri := 0; ri < metrics.ResourceMetrics().Len(); ri++ {
+				rm := metrics.ResourceMetrics().At(ri)
+				assert.Equal(t, 1, rm.ScopeMetrics().Len())
+				ms := rm.ScopeMetrics().At(0).Metrics()
+				totalMetricsCount += ms.Len()
+				for mi := 0; mi < ms.Len(); mi++ {
+					allMetricsList = append(allMetricsList, ms.At(mi))
+				}
+			}
+			if tt.metricsSet == testDataSetDefault {
+				assert.Equal(t, defaultMetricsCount, totalMetricsCount)
+			}
+			if tt.metricsSet == testDataSetAll {
+				assert.Equal(t, allMetricsCount, totalMetricsCount)
+			}
+			validatedMetrics := make(map[string]bool)
+			for _, mi := range allMetricsList {
+				switch mi.Name() {
+				case "agents.connected":
+					assert.False(t, validatedMetrics["agents.connected"], "Found a duplicate in the metrics slice: agents.connected")
+					validatedMetrics["agents.connected"] = true
+					assert.Equal(t, pmetric.MetricTypeGauge, mi.Type())
+					assert.Equal(t, 1, mi.Gauge().DataPoints().Len())
+					assert.Equal(t, "Number of agents connected, by queue. Agents are tracked from the agent events received since the receiver started.", mi.Description())
+					assert.Equal(t, "{agent}", mi.Unit())
+					dp := mi.Gauge().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciBuildkiteAgentQueueAttrVal, ok := dp.Attributes().Get("ci.buildkite.agent.queue")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.buildkite.agent.queue-val", ciBuildkiteAgentQueueAttrVal.Str())
+				case "agents.events.count":
+					assert.False(t, validatedMetrics["agents.events.count"], "Found a duplicate in the metrics slice: agents.events.count")
+					validatedMetrics["agents.events.count"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Number of agent connection events, by queue and event.", mi.Description())
+					assert.Equal(t, "{event}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciBuildkiteAgentQueueAttrVal, ok := dp.Attributes().Get("ci.buildkite.agent.queue")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.buildkite.agent.queue-val", ciBuildkiteAgentQueueAttrVal.Str())
+					ciBuildkiteAgentEventAttrVal, ok := dp.Attributes().Get("ci.buildkite.agent.event")
+					assert.True(t, ok)
+					assert.Equal(t, "connected", ciBuildkiteAgentEventAttrVal.Str())
+				case "builds.count":
+					assert.False(t, validatedMetrics["builds.count"], "Found a duplicate in the metrics slice: builds.count")
+					validatedMetrics["builds.count"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Number of build events, by state.", mi.Description())
+					assert.Equal(t, "{build}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciBuildkitePipelineSlugAttrVal, ok := dp.Attributes().Get("ci.buildkite.pipeline.slug")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.buildkite.pipeline.slug-val", ciBuildkitePipelineSlugAttrVal.Str())
+					ciBuildkiteBuildStateAttrVal, ok := dp.Attributes().Get("ci.buildkite.build.state")
+					assert.True(t, ok)
+					assert.Equal(t, "scheduled", ciBuildkiteBuildStateAttrVal.Str())
+				case "jobs.count":
+					assert.False(t, validatedMetrics["jobs.count"], "Found a duplicate in the metrics slice: jobs.count")
+					validatedMetrics["jobs.count"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Number of finished jobs, by queue and state.", mi.Description())
+					assert.Equal(t, "{job}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciBuildkitePipelineSlugAttrVal, ok := dp.Attributes().Get("ci.buildkite.pipeline.slug")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.buildkite.pipeline.slug-val", ciBuildkitePipelineSlugAttrVal.Str())
+					ciBuildkiteAgentQueueAttrVal, ok := dp.Attributes().Get("ci.buildkite.agent.queue")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.buildkite.agent.queue-val", ciBuildkiteAgentQueueAttrVal.Str())
+					ciBuildkiteJobStateAttrVal, ok := dp.Attributes().Get("ci.buildkite.job.state")
+					assert.True(t, ok)
+					assert.Equal(t, "passed", ciBuildkiteJobStateAttrVal.Str())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"go.opentelemetry.io/collector/component"
+)
+
+var (
+	Type      = component.MustNewType("buildkite")
+	ScopeName = "github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver"
+)
+
+const (
+	TracesStability  = component.StabilityLevelAlpha
+	MetricsStability = component.StabilityLevelAlpha
+)
@@ -0,0 +1,300 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.uber.org/zap"
+)
+
+const metricsMaxCacheSize = 100000
+const histogramCacheSize = 50000
+const histogramTTL = 24 * time.Hour
+
+type metricsHandler struct {
+	mu             sync.Mutex
+	mb             *metadata.MetricsBuilder
+	cfg            *Config
+	logger         *zap.Logger
+	countersCache  *lru.Cache[string, int64]
+	histogramCache *lru.Cache[string, *cimodel.Histogram]
+	durations      *cimodel.Durations
+	// agentQueues are the queues of the connected agents, by agent ID.
+	agentQueues map[string]string
+}
+
+func newMetricsHandler(settings receiver.Settings, cfg *Config, logger *zap.Logger) (*metricsHandler, error) {
+	countersCache, err := lru.New[string, int64](metricsMaxCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize counters cache: %w", err)
+	}
+
+	// histogramCache stores cumulative histogram state per unique dimension set,
+	// as histograms are emitted with cumulative temporality.
+	histogramCache, err := lru.New[string, *cimodel.Histogram](histogramCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize histogram cache: %w", err)
+	}
+
+	durations, err := cimodel.NewDurations(histogramCacheSize, histogramTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize durations cache: %w", err)
+	}
+
+	return &metricsHandler{
+		cfg:            cfg,
+		mb:             metadata.NewMetricsBuilder(cfg.MetricsBuilderConfig, settings),
+		logger:         logger,
+		countersCache:  countersCache,
+		histogramCache: histogramCache,
+		durations:      durations,
+		agentQueues:    map[string]string{},
+	}, nil
+}
+
+// buildEventToMetrics counts builds by state, and reports the duration of
+// finished builds.
+func (m *metricsHandler) buildEventToMetrics(e *webhookEvent) pmetric.Metrics {
+	slug := e.Pipeline.pipelineSlug()
+
+	m.logger.Debug("Processing build event",
+		zap.String("event", e.Event),
+		zap.String("pipeline", slug),
+		zap.Int64("number", e.Build.Number),
+		zap.String("state", e.Build.State),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	state, ok := metadata.MapAttributeCiBuildkiteBuildState[e.Build.State]
+	if ok && slug != "" {
+		dimensions := "build:" + slug
+		val, found := m.countersCache.Get(dimensions + ":" + state.String())
+		if !found {
+			// The counters of the other states start at zero, so that
+			// their increases are visible from their first event.
+			for _, s := range metadata.MapAttributeCiBuildkiteBuildState {
+				if s != state && m.seedCounter(dimensions+":"+s.String()) {
+					m.mb.RecordBuildsCountDataPoint(now, 0, slug, s)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+state.String(), val+1)
+		m.mb.RecordBuildsCountDataPoint(now, val+1, slug, state)
+	}
+
+	metrics := m.mb.Emit()
+	if e.Event != eventBuildFinished || e.Build.StartedAt == "" {
+		return metrics
+	}
+
+	ms := scopeMetrics(metrics)
+	pipeline := buildRun(e)
+
+	if m.cfg.Semconv.EmitsLegacy() {
+		key := fmt.Sprintf("hist:build:%s:%s", slug, e.Build.State)
+		cimodel.AppendHistogram(ms, "builds.duration", map[string]any{
+			"ci.buildkite.pipeline.slug": slug,
+			"ci.buildkite.build.state":   e.Build.State,
+		}, m.observeDuration(key, pipeline.Finished.Sub(pipeline.Started).Seconds()))
+	}
+
+	if m.cfg.Semconv.Enabled {
+		m.durations.AppendPipeline(ms, &pipeline)
+	}
+
+	return metrics
+}
+
+// jobEventToMetrics reports the time jobs waited for an agent when they
+// start, and counts finished jobs by queue and state with their durations.
+// Wait, block and trigger steps are not run by agents and are ignored.
+func (m *metricsHandler) jobEventToMetrics(e *webhookEvent) pmetric.Metrics {
+	j := e.Job
+	slug := e.Pipeline.pipelineSlug()
+	queue := j.queue()
+
+	m.logger.Debug("Processing job event",
+		zap.String("event", e.Event),
+		zap.String("pipeline", slug),
+		zap.Int64("number", e.Build.Number),
+		zap.String("id", j.ID),
+		zap.String("state", j.State),
+		zap.String("queue", queue),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case j.Type == jobTypeWaiter || j.Type == jobTypeManual || j.Type == jobTypeTrigger:
+		return pmetric.NewMetrics()
+	case e.Event == eventJobStarted:
+		runnable, started := parseTime(j.RunnableAt), parseTime(j.StartedAt)
+		if runnable.IsZero() || started.Before(runnable) {
+			return pmetric.NewMetrics()
+		}
+
+		// The queued duration has no semantic convention.
+		metrics := pmetric.NewMetrics()
+		key := fmt.Sprintf("hist:queued:%s:%s", slug, queue)
+		cimodel.AppendHistogram(scopeMetrics(metrics), "jobs.queued_duration", map[string]any{
+			"ci.buildkite.pipeline.slug": slug,
+			"ci.buildkite.agent.queue":   queue,
+		}, m.observeDuration(key, started.Sub(runnable).Seconds()))
+		return metrics
+	case e.Event != eventJobFinished:
+		return pmetric.NewMetrics()
+	}
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	state, ok := metadata.MapAttributeCiBuildkiteJobState[j.State]
+	if ok && slug != "" {
+		dimensions := fmt.Sprintf("job:%s:%s", slug, queue)
+		val, found := m.countersCache.Get(dimensions + ":" + state.String())
+		if !found {
+			for _, s := range metadata.MapAttributeCiBuildkiteJobState {
+				if s != state && m.seedCounter(dimensions+":"+s.String()) {
+					m.mb.RecordJobsCountDataPoint(now, 0, slug, queue, s)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+state.String(), val+1)
+		m.mb.RecordJobsCountDataPoint(now, val+1, slug, queue, state)
+	}
+
+	metrics := m.mb.Emit()
+
+	// Jobs that never ran have no duration.
+	if j.StartedAt == "" || j.FinishedAt == "" {
+		return metrics
+	}
+
+	ms := scopeMetrics(metrics)
+	pipeline := jobEventPipeline(e)
+	task := &pipeline.Tasks[0]
+
+	if m.cfg.Semconv.EmitsLegacy() {
+		key := fmt.Sprintf("hist:job:%s:%s:%s:%s", slug, task.Name, queue, j.State)
+		cimodel.AppendHistogram(ms, "jobs.duration", map[string]any{
+			"ci.buildkite.pipeline.slug": slug,
+			"ci.buildkite.job.name":      task.Name,
+			"ci.buildkite.agent.queue":   queue,
+			"ci.buildkite.job.state":     j.State,
+		}, m.observeDuration(key, task.Finished.Sub(task.Started).Seconds()))
+	}
+
+	if m.cfg.Semconv.Enabled {
+		m.durations.AppendTask(ms, &pipeline, task)
+	}
+
+	return metrics
+}
+
+// agentEventToMetrics counts agent connection events, and the agents
+// connected to the queue of the agent.
+func (m *metricsHandler) agentEventToMetrics(e *webhookEvent) pmetric.Metrics {
+	a := e.Agent
+	queue := a.queue()
+	name := strings.TrimPrefix(e.Event, "agent.")
+
+	m.logger.Debug("Processing agent event",
+		zap.String("event", e.Event),
+		zap.String("id", a.ID),
+		zap.String("name", a.Name),
+		zap.String("queue", queue),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	event, ok := metadata.MapAttributeCiBuildkiteAgentEvent[name]
+	if !ok {
+		return pmetric.NewMetrics()
+	}
+
+	key := fmt.Sprintf("agent:%s:%s", queue, event.String())
+	val, _ := m.countersCache.Get(key)
+	m.countersCache.Add(key, val+1)
+	m.mb.RecordAgentsEventsCountDataPoint(now, val+1, queue, event)
+
+	// Agents are tracked from the events received since the receiver
+	// started, so agents connected before are only counted once they
+	// reconnect.
+	previous, known := m.agentQueues[a.ID]
+	switch event {
+	case metadata.AttributeCiBuildkiteAgentEventConnected:
+		m.agentQueues[a.ID] = queue
+	case metadata.AttributeCiBuildkiteAgentEventDisconnected,
+		metadata.AttributeCiBuildkiteAgentEventLost,
+		metadata.AttributeCiBuildkiteAgentEventStopped:
+		delete(m.agentQueues, a.ID)
+	}
+
+	m.mb.RecordAgentsConnectedDataPoint(now, m.connectedAgents(queue), queue)
+	if known && previous != queue {
+		m.mb.RecordAgentsConnectedDataPoint(now, m.connectedAgents(previous), previous)
+	}
+
+	return m.mb.Emit()
+}
+
+// connectedAgents returns the number of agents connected to a queue.
+// Called under m.mu.
+func (m *metricsHandler) connectedAgents(queue string) int64 {
+	var n int64
+	for _, q := range m.agentQueues {
+		if q == queue {
+			n++
+		}
+	}
+	return n
+}
+
+// scopeMetrics returns the metrics emitted by the metrics builder, which
+// emits no resource at all when no counter was recorded.
+func scopeMetrics(metrics pmetric.Metrics) pmetric.MetricSlice {
+	if metrics.ResourceMetrics().Len() == 0 {
+		scope := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+		scope.Scope().SetName(metadata.ScopeName)
+		return scope.Metrics()
+	}
+	return metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+}
+
+// seedCounter starts the counter cached under key at zero, reporting
+// whether it was unknown. Called under m.mu.
+func (m *metricsHandler) seedCounter(key string) bool {
+	if m.countersCache.Contains(key) {
+		return false
+	}
+	m.countersCache.Add(key, 0)
+	return true
+}
+
+// observeDuration records a duration in the histogram cached under key.
+// Called under m.mu.
+func (m *metricsHandler) observeDuration(key string, duration float64) *cimodel.Histogram {
+	// Stale histograms start over, the LRU evicts those never observed again
+	h, ok := m.histogramCache.Get(key)
+	if !ok || time.Since(h.LastSeen) >= histogramTTL {
+		h = cimodel.NewHistogram()
+	}
+	h.Observe(duration)
+	m.histogramCache.Add(key, h)
+	return h
+}
@@ -0,0 +1,232 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver/internal/metadata"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestMetricsHandler(t *testing.T, cfg *Config) *metricsHandler {
+	t.Helper()
+	cfg.MetricsBuilderConfig = metadata.DefaultMetricsBuilderConfig()
+	mh, err := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return mh
+}
+
+// metricNames returns the names of the metrics, with their data point counts.
+func metricNames(metrics pmetric.Metrics) map[string]int {
+	names := map[string]int{}
+	for i := range metrics.ResourceMetrics().Len() {
+		sms := metrics.ResourceMetrics().At(i).ScopeMetrics()
+		for j := range sms.Len() {
+			ms := sms.At(j).Metrics()
+			for k := range ms.Len() {
+				m := ms.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeGauge:
+					names[m.Name()] += m.Gauge().DataPoints().Len()
+				case pmetric.MetricTypeSum:
+					names[m.Name()] += m.Sum().DataPoints().Len()
+				case pmetric.MetricTypeHistogram:
+					names[m.Name()] += m.Histogram().DataPoints().Len()
+				}
+			}
+		}
+	}
+	return names
+}
+
+// connectedAgentsByQueue returns the values of the agents.connected gauge.
+func connectedAgentsByQueue(metrics pmetric.Metrics) map[string]int64 {
+	values := map[string]int64{}
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := range ms.Len() {
+		if ms.At(i).Name() != "agents.connected" {
+			continue
+		}
+		dps := ms.At(i).Gauge().DataPoints()
+		for j := range dps.Len() {
+			queue, _ := dps.At(j).Attributes().Get("ci.buildkite.agent.queue")
+			values[queue.Str()] = dps.At(j).IntValue()
+		}
+	}
+	return values
+}
+
+func TestBuildEventToMetrics(t *testing.T) {
+	e := loadEvent(t, "build_finished.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	running := *e
+	runningBuild := *e.Build
+	runningBuild.State = "running"
+	running.Event = "build.running"
+	running.Build = &runningBuild
+
+	// The first event seeds the counters of the other states.
+	metrics := mh.buildEventToMetrics(&running)
+	require.Equal(t, map[string]int{"builds.count": len(metadata.MapAttributeCiBuildkiteBuildState)}, metricNames(metrics))
+
+	e.Event = eventBuildFinished
+	metrics = mh.buildEventToMetrics(e)
+	require.Equal(t, map[string]int{"builds.count": 1, "builds.duration": 1}, metricNames(metrics))
+
+	dp := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, int64(1), dp.IntValue())
+	state, _ := dp.Attributes().Get("ci.buildkite.build.state")
+	require.Equal(t, "passed", state.Str())
+	slug, _ := dp.Attributes().Get("ci.buildkite.pipeline.slug")
+	require.Equal(t, "acme/web-app", slug.Str())
+
+	// The counters keep counting.
+	metrics = mh.buildEventToMetrics(e)
+	dp = metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, int64(2), dp.IntValue())
+}
+
+func TestJobEventToMetrics(t *testing.T) {
+	e := loadEvent(t, "job_finished.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	started := *e
+	started.Event = eventJobStarted
+	metrics := mh.jobEventToMetrics(&started)
+	require.Equal(t, map[string]int{"jobs.queued_duration": 1}, metricNames(metrics))
+	hist := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Histogram().DataPoints().At(0)
+	require.Equal(t, 20.0, hist.Sum())
+
+	e.Event = eventJobFinished
+	metrics = mh.jobEventToMetrics(e)
+	require.Equal(t, map[string]int{
+		"jobs.count":    len(metadata.MapAttributeCiBuildkiteJobState),
+		"jobs.duration": 1,
+	}, metricNames(metrics))
+
+	val, ok := mh.countersCache.Get("job:acme/web-app:test:failed")
+	require.True(t, ok)
+	require.Equal(t, int64(1), val)
+
+	// Steps that do not run on agents are not counted.
+	waiter := *e
+	waiter.Job = &job{ID: "waiter", Type: jobTypeWaiter, State: "finished"}
+	require.Equal(t, 0, mh.jobEventToMetrics(&waiter).DataPointCount())
+}
+
+func TestEventToMetricsSemconv(t *testing.T) {
+	build := loadEvent(t, "build_finished.json")
+	build.Event = eventBuildFinished
+	job := loadEvent(t, "job_finished.json")
+	job.Event = eventJobFinished
+
+	tests := []struct {
+		desc          string
+		semconv       semconv.Config
+		expectMetrics []string
+	}{
+		{
+			desc:          "Legacy metrics",
+			expectMetrics: []string{"builds.count", "jobs.count", "builds.duration", "jobs.duration"},
+		},
+		{
+			desc:          "Semantic conventions",
+			semconv:       semconv.Config{Enabled: true},
+			expectMetrics: []string{"builds.count", "jobs.count", semconv.MetricCICDPipelineRunDuration, semconv.MetricCICDPipelineTaskRunDuration},
+		},
+		{
+			desc:    "Semantic conventions with legacy metrics",
+			semconv: semconv.Config{Enabled: true, EmitLegacy: true},
+			expectMetrics: []string{
+				"builds.count", "jobs.count", "builds.duration", "jobs.duration",
+				semconv.MetricCICDPipelineRunDuration, semconv.MetricCICDPipelineTaskRunDuration,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			mh := newTestMetricsHandler(t, &Config{Semconv: test.semconv})
+
+			names := metricNames(mh.buildEventToMetrics(build))
+			for name, count := range metricNames(mh.jobEventToMetrics(job)) {
+				names[name] += count
+			}
+			require.Len(t, names, len(test.expectMetrics))
+			for _, name := range test.expectMetrics {
+				require.Contains(t, names, name)
+			}
+		})
+	}
+}
+
+func TestAgentEventToMetrics(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+
+	agentEvent := func(event, id string, tags ...string) *webhookEvent {
+		return &webhookEvent{Event: event, Agent: &agent{ID: id, MetaData: tags}}
+	}
+
+	metrics := mh.agentEventToMetrics(agentEvent("agent.connected", "a1", "queue=test"))
+	require.Equal(t, map[string]int{"agents.connected": 1, "agents.events.count": 1}, metricNames(metrics))
+	require.Equal(t, map[string]int64{"test": 1}, connectedAgentsByQueue(metrics))
+
+	metrics = mh.agentEventToMetrics(agentEvent("agent.connected", "a2", "queue=test"))
+	require.Equal(t, map[string]int64{"test": 2}, connectedAgentsByQueue(metrics))
+
+	// Agents that reconnect to another queue leave their previous one.
+	metrics = mh.agentEventToMetrics(agentEvent("agent.connected", "a2"))
+	require.Equal(t, map[string]int64{"test": 1, defaultQueue: 1}, connectedAgentsByQueue(metrics))
+
+	metrics = mh.agentEventToMetrics(agentEvent("agent.lost", "a1", "queue=test"))
+	require.Equal(t, map[string]int64{"test": 0}, connectedAgentsByQueue(metrics))
+
+	// Agents connected before the receiver started are unknown.
+	metrics = mh.agentEventToMetrics(agentEvent("agent.disconnected", "a3", "queue=test"))
+	require.Equal(t, map[string]int64{"test": 0}, connectedAgentsByQueue(metrics))
+
+	val, ok := mh.countersCache.Get("agent:test:connected")
+	require.True(t, ok)
+	require.Equal(t, int64(2), val)
+
+	require.Equal(t, 0, mh.agentEventToMetrics(agentEvent("agent.heartbeat", "a2")).DataPointCount())
+}
+
+func TestBuildEventToMetricsConcurrency(t *testing.T) {
+	e := loadEvent(t, "build_finished.json")
+	e.Event = eventBuildFinished
+	mh := newTestMetricsHandler(t, &Config{})
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			mh.buildEventToMetrics(e)
+		})
+	}
+	wg.Wait()
+
+	val, ok := mh.countersCache.Get("build:acme/web-app:passed")
+	require.True(t, ok)
+	require.Equal(t, int64(50), val)
+}
+
+func TestObserveDurationStartsOver(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+
+	mh.observeDuration("key", 1)
+	require.Equal(t, uint64(2), mh.observeDuration("key", 1).Count)
+
+	// Histograms not observed within the TTL start over
+	h, _ := mh.histogramCache.Get("key")
+	h.LastSeen = time.Now().Add(-histogramTTL)
+	require.Equal(t, uint64(1), mh.observeDuration("key", 1).Count)
+}
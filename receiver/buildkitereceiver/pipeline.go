@@ -0,0 +1,259 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+)
+
+// Types of the jobs of a build.
+const (
+	jobTypeWaiter  = "waiter"
+	jobTypeManual  = "manual"
+	jobTypeTrigger = "trigger"
+)
+
+// buildPipeline maps a build event to the CI model, with a task per job of
+// the build and the deterministic IDs of their spans.
+func buildPipeline(e *webhookEvent) cimodel.Pipeline {
+	b := e.Build
+
+	pipeline := buildRun(e)
+	pipeline.Tasks = make([]cimodel.Task, 0, len(b.Jobs))
+	for i := range b.Jobs {
+		pipeline.Tasks = append(pipeline.Tasks, jobTask(b.ID, &b.Jobs[i]))
+	}
+	setBarrierTimes(pipeline.Tasks, b.Jobs, pipeline.Started)
+
+	return pipeline
+}
+
+// jobEventPipeline maps a job event to the CI model. The event only
+// describes one job, so the build has no span of its own and the job span
+// keeps the build span as its parent.
+func jobEventPipeline(e *webhookEvent) cimodel.Pipeline {
+	pipeline := buildRun(e)
+	pipeline.OmitSpan = true
+	pipeline.Tasks = []cimodel.Task{jobTask(e.Build.ID, e.Job)}
+	return pipeline
+}
+
+// buildRun maps the build of an event, without its jobs.
+func buildRun(e *webhookEvent) cimodel.Pipeline {
+	b := e.Build
+
+	started := parseTime(b.StartedAt)
+	if started.IsZero() {
+		started = parseTime(b.CreatedAt)
+	}
+	finished := parseTime(b.FinishedAt)
+	if finished.IsZero() {
+		finished = started
+	}
+
+	pipeline := cimodel.Pipeline{
+		ID:         strconv.FormatInt(b.Number, 10),
+		Name:       e.Pipeline.pipelineSlug(),
+		URL:        b.WebURL,
+		Result:     buildResult(b.State),
+		Status:     b.State,
+		Started:    started,
+		Finished:   finished,
+		Repository: pipelineRepository(e.Pipeline),
+		Ref:        buildRef(b),
+		TraceID:    generateTraceID(b.ID),
+		SpanID:     generateBuildSpanID(b.ID),
+	}
+
+	return pipeline
+}
+
+func jobTask(buildID string, j *job) cimodel.Task {
+	started, finished := jobTimes(j.CreatedAt, j.StartedAt, j.FinishedAt)
+
+	task := cimodel.Task{
+		ID:       j.ID,
+		Name:     j.displayName(),
+		URL:      j.WebURL,
+		Result:   jobResult(j.State),
+		Status:   j.State,
+		Started:  started,
+		Finished: finished,
+		SpanID:   generateJobSpanID(buildID, j.ID),
+	}
+	if j.Agent != nil {
+		task.Worker = j.Agent.Name
+	}
+
+	return task
+}
+
+// setBarrierTimes times the wait and block steps of a build, which do not
+// run on agents. They start when the jobs before them finished, and finish
+// when the first job after them started or, for block steps, when they were
+// unblocked.
+func setBarrierTimes(tasks []cimodel.Task, jobs []job, buildStarted time.Time) {
+	groupFinished := buildStarted
+
+	for i := range jobs {
+		task := &tasks[i]
+
+		switch jobs[i].Type {
+		case jobTypeWaiter:
+			task.Started = groupFinished
+			task.Finished = firstStarted(tasks[i+1:], jobs[i+1:], groupFinished)
+		case jobTypeManual:
+			task.Started = groupFinished
+			task.Finished = groupFinished
+			if unblocked := parseTime(jobs[i].UnblockedAt); !unblocked.IsZero() {
+				task.Finished = unblocked
+				groupFinished = unblocked
+			}
+		default:
+			if jobs[i].StartedAt != "" && task.Finished.After(groupFinished) {
+				groupFinished = task.Finished
+			}
+		}
+	}
+}
+
+// firstStarted returns the time the first of the jobs before the next wait
+// or block step started at, or fallback when none of them started.
+func firstStarted(tasks []cimodel.Task, jobs []job, fallback time.Time) time.Time {
+	var first time.Time
+	for i := range jobs {
+		if jobs[i].Type == jobTypeWaiter || jobs[i].Type == jobTypeManual {
+			break
+		}
+		if jobs[i].StartedAt != "" && (first.IsZero() || tasks[i].Started.Before(first)) {
+			first = tasks[i].Started
+		}
+	}
+	if first.IsZero() || first.Before(fallback) {
+		return fallback
+	}
+	return first
+}
+
+// jobTimes returns the times a job started and finished at. Jobs that never
+// ran start and finish when they were created.
+func jobTimes(createdAt, startedAt, finishedAt string) (time.Time, time.Time) {
+	started := parseTime(startedAt)
+	if started.IsZero() {
+		started = parseTime(createdAt)
+	}
+
+	finished := parseTime(finishedAt)
+	if finished.IsZero() {
+		finished = started
+	}
+
+	return started, finished
+}
+
+// pipelineRepository maps the repository of a pipeline, in its HTTP, SSH or
+// scp-like form.
+func pipelineRepository(p *pipeline) cimodel.Repository {
+	if p == nil || p.Repository == "" {
+		return cimodel.Repository{}
+	}
+
+	var host, repoPath string
+	if u, err := url.Parse(p.Repository); err == nil && u.Host != "" {
+		host, repoPath = u.Hostname(), u.Path
+	} else if at := strings.Index(p.Repository, "@"); at >= 0 {
+		// scp-like syntax, such as git@github.com:acme/web-app.git
+		host, repoPath, _ = strings.Cut(p.Repository[at+1:], ":")
+	}
+
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	if host == "" || repoPath == "" {
+		return cimodel.Repository{URL: p.Repository}
+	}
+
+	return cimodel.Repository{
+		Provider: repositoryProvider(p.Provider.ID),
+		Owner:    path.Dir(repoPath),
+		Name:     path.Base(repoPath),
+		URL:      "https://" + host + "/" + repoPath,
+	}
+}
+
+// repositoryProvider maps the ID of the provider of a pipeline, such as
+// github_enterprise or bitbucket_server.
+func repositoryProvider(id string) string {
+	switch {
+	case strings.HasPrefix(id, "github"):
+		return semconv.AttributeVCSProviderNameGithub
+	case strings.HasPrefix(id, "gitlab"):
+		return semconv.AttributeVCSProviderNameGitlab
+	case strings.HasPrefix(id, "bitbucket"):
+		return semconv.AttributeVCSProviderNameBitbucket
+	default:
+		return ""
+	}
+}
+
+func buildRef(b *build) cimodel.Ref {
+	ref := cimodel.Ref{Revision: b.Commit}
+
+	if b.Tag != "" {
+		ref.Head = b.Tag
+		ref.HeadType = semconv.AttributeVCSRefTypeTag
+	} else if b.Branch != "" {
+		ref.Head = b.Branch
+		ref.HeadType = semconv.AttributeVCSRefTypeBranch
+	}
+
+	if pr := b.PullRequest; pr != nil {
+		ref.ChangeID = pr.ID
+		ref.Base = pr.Base
+	}
+
+	return ref
+}
+
+// buildResult maps the state of a finished build.
+func buildResult(state string) cimodel.Result {
+	switch state {
+	case "passed":
+		return cimodel.ResultSuccess
+	case "failed":
+		return cimodel.ResultFailure
+	case "canceled":
+		return cimodel.ResultCancellation
+	case "skipped", "not_run":
+		return cimodel.ResultSkip
+	default:
+		return cimodel.ResultUnknown
+	}
+}
+
+// jobResult maps the state of a finished job. Block steps are unblocked,
+// wait steps are finished or broken when the jobs before them failed.
+func jobResult(state string) cimodel.Result {
+	switch state {
+	case "passed", "unblocked", "finished":
+		return cimodel.ResultSuccess
+	case "failed":
+		return cimodel.ResultFailure
+	case "timed_out":
+		return cimodel.ResultTimeout
+	case "canceled":
+		return cimodel.ResultCancellation
+	case "expired":
+		return cimodel.ResultError
+	case "skipped", "broken", "not_run":
+		return cimodel.ResultSkip
+	default:
+		return cimodel.ResultUnknown
+	}
+}
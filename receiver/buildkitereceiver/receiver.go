@@ -0,0 +1,243 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/buildkitereceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.uber.org/zap"
+)
+
+var errMissingEndpoint = errors.New("missing a receiver endpoint")
+
+type buildkiteReceiver struct {
+	tracesConsumer  consumer.Traces
+	metricsConsumer consumer.Metrics
+	metricsHandler  *metricsHandler
+	config          *Config
+	server          *http.Server
+	shutdownWG      sync.WaitGroup
+	createSettings  receiver.Settings
+	logger          *zap.Logger
+	obsrecv         *receiverhelper.ObsReport
+	now             func() time.Time
+}
+
+func newReceiver(
+	params receiver.Settings,
+	config *Config,
+) (*buildkiteReceiver, error) {
+	if config.NetAddr.Endpoint == "" {
+		return nil, errMissingEndpoint
+	}
+
+	transport := "http"
+	if config.TLS.HasValue() {
+		transport = "https"
+	}
+
+	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             params.ID,
+		Transport:              transport,
+		ReceiverCreateSettings: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metricsHandler, err := newMetricsHandler(params, config, params.Logger.Named("metricsHandler"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &buildkiteReceiver{
+		config:         config,
+		createSettings: params,
+		logger:         params.Logger,
+		obsrecv:        obsrecv,
+		metricsHandler: metricsHandler,
+		now:            time.Now,
+	}, nil
+}
+
+// newTracesReceiver creates a traces receiver based on provided config.
+func newTracesReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Traces,
+) (receiver.Traces, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*buildkiteReceiver).tracesConsumer = consumer
+
+	return r, nil
+}
+
+// newMetricsReceiver creates a metrics receiver based on provided config.
+func newMetricsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*buildkiteReceiver).metricsConsumer = consumer
+
+	return r, nil
+}
+
+func getOrAddReceiver(set receiver.Settings, cfg component.Config) (*sharedcomponent.SharedComponent, error) {
+	var err error
+	r := receivers.GetOrAdd(cfg, func() component.Component {
+		var rcv component.Component
+		rcv, err = newReceiver(set, cfg.(*Config))
+		return rcv
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (bkr *buildkiteReceiver) Start(_ context.Context, _ component.Host) error {
+	endpoint := fmt.Sprintf("%s%s", bkr.config.NetAddr.Endpoint, bkr.config.Path)
+	bkr.logger.Info("Starting Buildkite server", zap.String("endpoint", endpoint))
+	bkr.server = &http.Server{
+		Addr:              bkr.config.NetAddr.Endpoint,
+		Handler:           bkr,
+		ReadHeaderTimeout: 20 * time.Second,
+	}
+
+	bkr.shutdownWG.Add(1)
+	go func() {
+		defer bkr.shutdownWG.Done()
+
+		if errHTTP := bkr.server.ListenAndServe(); !errors.Is(errHTTP, http.ErrServerClosed) && errHTTP != nil {
+			bkr.createSettings.Logger.Error("Server closed with error", zap.Error(errHTTP))
+		}
+	}()
+
+	return nil
+}
+
+func (bkr *buildkiteReceiver) Shutdown(_ context.Context) error {
+	var err error
+	if bkr.server != nil {
+		err = bkr.server.Close()
+	}
+	bkr.shutdownWG.Wait()
+	return err
+}
+
+func (bkr *buildkiteReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Validate request path
+	if r.URL.Path != bkr.config.Path {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		bkr.logger.Debug("Failed to read payload", zap.Error(err))
+		http.Error(w, "Failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	// Validate the token or signature configured on the webhook
+	if bkr.config.Secret != "" {
+		if err := validateRequest(r, payload, bkr.config.Secret, bkr.config.SignatureTolerance, bkr.now()); err != nil {
+			bkr.logger.Debug("Webhook validation failed", zap.Error(err))
+			http.Error(w, "Invalid token or signature", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	// Determine the type of Buildkite webhook event and ensure it's one we handle
+	eventType := r.Header.Get(eventHeader)
+	switch {
+	case strings.HasPrefix(eventType, "build."), strings.HasPrefix(eventType, "job."), strings.HasPrefix(eventType, "agent."):
+	default:
+		bkr.logger.Debug("Skipping unsupported event type", zap.String("event", eventType))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	e, err := parseWebhookEvent(payload)
+	if err != nil {
+		bkr.logger.Debug("Webhook parsing failed", zap.Error(err))
+		http.Error(w, "Failed to parse webhook", http.StatusBadRequest)
+		return
+	}
+	e.Event = eventType
+
+	switch {
+	case strings.HasPrefix(eventType, "build."):
+		if bkr.metricsConsumer != nil {
+			bkr.consumeMetrics(ctx, bkr.metricsHandler.buildEventToMetrics(e))
+		}
+
+		if eventType != eventBuildFinished {
+			bkr.logger.Debug("Skipping unfinished build", zap.String("event", eventType))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if bkr.tracesConsumer != nil {
+			td := buildEventToTraces(e, bkr.config, bkr.logger.Named("buildEventToTraces"))
+			tracesCtx := bkr.obsrecv.StartTracesOp(ctx)
+			err := bkr.tracesConsumer.ConsumeTraces(tracesCtx, td)
+			bkr.obsrecv.EndTracesOp(tracesCtx, metadata.Type.String(), td.SpanCount(), err)
+			if err != nil {
+				bkr.logger.Error("Failed to consume traces", zap.Error(err))
+			}
+		}
+	case strings.HasPrefix(eventType, "job."):
+		if bkr.metricsConsumer != nil {
+			bkr.consumeMetrics(ctx, bkr.metricsHandler.jobEventToMetrics(e))
+		}
+	case strings.HasPrefix(eventType, "agent."):
+		if bkr.metricsConsumer != nil {
+			bkr.consumeMetrics(ctx, bkr.metricsHandler.agentEventToMetrics(e))
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (bkr *buildkiteReceiver) consumeMetrics(ctx context.Context, md pmetric.Metrics) {
+	if md.DataPointCount() == 0 {
+		return
+	}
+
+	metricsCtx := bkr.obsrecv.StartMetricsOp(ctx)
+	err := bkr.metricsConsumer.ConsumeMetrics(metricsCtx, md)
+	bkr.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), md.DataPointCount(), err)
+	if err != nil {
+		bkr.logger.Error("Failed to consume metrics", zap.Error(err))
+	}
+}
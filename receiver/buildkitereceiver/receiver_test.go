@@ -0,0 +1,182 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+func TestNewReceiver(t *testing.T) {
+	defaultConfig := createDefaultConfig().(*Config)
+
+	tests := []struct {
+		desc   string
+		config Config
+		err    error
+	}{
+		{
+			desc:   "Default config succeeds",
+			config: *defaultConfig,
+		},
+		{
+			desc:   "Missing endpoint fails",
+			config: Config{},
+			err:    errMissingEndpoint,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), &test.config)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, rec.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	buildPayload, err := os.ReadFile(filepath.Join("testdata", "build_finished.json"))
+	require.NoError(t, err)
+	jobPayload, err := os.ReadFile(filepath.Join("testdata", "job_finished.json"))
+	require.NoError(t, err)
+	agentPayload, err := os.ReadFile(filepath.Join("testdata", "agent_connected.json"))
+	require.NoError(t, err)
+	now := time.Date(2024, 3, 5, 10, 6, 0, 0, time.UTC)
+
+	tests := []struct {
+		desc          string
+		path          string
+		event         string
+		token         string
+		signature     string
+		payload       []byte
+		expectStatus  int
+		expectSpans   int
+		expectMetrics bool
+	}{
+		{
+			desc:         "Unknown path",
+			path:         "/other",
+			event:        eventBuildFinished,
+			token:        "mysecret",
+			payload:      buildPayload,
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			desc:         "Invalid token",
+			event:        eventBuildFinished,
+			token:        "wrong",
+			payload:      buildPayload,
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:         "Ping",
+			event:        eventPing,
+			token:        "mysecret",
+			payload:      []byte(`{"event": "ping"}`),
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			desc:         "Invalid payload",
+			event:        eventBuildFinished,
+			token:        "mysecret",
+			payload:      []byte(`{`),
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			desc:          "Running build",
+			event:         "build.running",
+			token:         "mysecret",
+			payload:       buildPayload,
+			expectStatus:  http.StatusNoContent,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Finished build",
+			event:         eventBuildFinished,
+			token:         "mysecret",
+			payload:       buildPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   7,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Signed finished build",
+			event:         eventBuildFinished,
+			signature:     sign("mysecret", now.Unix(), buildPayload),
+			payload:       buildPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   7,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Finished job",
+			event:         eventJobFinished,
+			token:         "mysecret",
+			payload:       jobPayload,
+			expectStatus:  http.StatusAccepted,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Connected agent",
+			event:         "agent.connected",
+			token:         "mysecret",
+			payload:       agentPayload,
+			expectStatus:  http.StatusAccepted,
+			expectMetrics: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Secret = "mysecret"
+
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, rec.Shutdown(context.Background())) })
+
+			tracesSink := new(consumertest.TracesSink)
+			metricsSink := new(consumertest.MetricsSink)
+			rec.tracesConsumer = tracesSink
+			rec.metricsConsumer = metricsSink
+			rec.now = func() time.Time { return now }
+
+			path := test.path
+			if path == "" {
+				path = cfg.Path
+			}
+			req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(test.payload))
+			req.Header.Set(eventHeader, test.event)
+			if test.token != "" {
+				req.Header.Set(tokenHeader, test.token)
+			}
+			if test.signature != "" {
+				req.Header.Set(signatureHeader, test.signature)
+			}
+			w := httptest.NewRecorder()
+
+			rec.ServeHTTP(w, req)
+
+			require.Equal(t, test.expectStatus, w.Code)
+			require.Equal(t, test.expectSpans, tracesSink.SpanCount())
+			require.Equal(t, test.expectMetrics, len(metricsSink.AllMetrics()) > 0)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeName    = "buildkitereceiver"
+	scopeVersion = "0.1.0"
+)
+
+// legacyResourceAttributes, legacyBuildAttributes and legacyJobAttributes
+// are the attributes replaced by the semantic conventions. Attributes
+// without an equivalent keep their names.
+var legacyResourceAttributes = []string{
+	"ci.buildkite.pipeline.slug",
+	"ci.buildkite.pipeline.repository",
+}
+
+var legacyBuildAttributes = []string{
+	"ci.buildkite.build.number",
+	"ci.buildkite.build.url",
+	"ci.buildkite.build.state",
+	"ci.buildkite.build.branch",
+	"ci.buildkite.build.tag",
+	"ci.buildkite.build.commit",
+	"ci.buildkite.build.pull_request.id",
+	"ci.buildkite.build.pull_request.base",
+}
+
+var legacyJobAttributes = []string{
+	"ci.buildkite.job.id",
+	"ci.buildkite.job.name",
+	"ci.buildkite.job.url",
+	"ci.buildkite.job.state",
+	"ci.buildkite.job.agent.name",
+}
+
+func buildEventToTraces(e *webhookEvent, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing build",
+		zap.String("pipeline", e.Pipeline.pipelineSlug()),
+		zap.Int64("number", e.Build.Number),
+		zap.String("state", e.Build.State),
+		zap.Int("jobs", len(e.Build.Jobs)),
+	)
+
+	pipeline := buildEventPipeline(e, config)
+	return cimodel.ToTraces(&pipeline, traceOptions(config))
+}
+
+// buildEventPipeline maps a build event to the CI model, with the attributes
+// of its resource and spans.
+func buildEventPipeline(e *webhookEvent, config *Config) cimodel.Pipeline {
+	b := e.Build
+
+	pipeline := buildPipeline(e)
+	pipeline.ResourceAttributes = resourceAttributes(e, config, pipeline.Name)
+	pipeline.Attributes = map[string]any{
+		"ci.buildkite.build.id":      b.ID,
+		"ci.buildkite.build.number":  b.Number,
+		"ci.buildkite.build.url":     b.WebURL,
+		"ci.buildkite.build.state":   b.State,
+		"ci.buildkite.build.source":  b.Source,
+		"ci.buildkite.build.commit":  b.Commit,
+		"ci.buildkite.build.blocked": b.Blocked,
+	}
+	if b.Tag != "" {
+		pipeline.Attributes["ci.buildkite.build.tag"] = b.Tag
+	} else {
+		pipeline.Attributes["ci.buildkite.build.branch"] = b.Branch
+	}
+	if pr := b.PullRequest; pr != nil {
+		pipeline.Attributes["ci.buildkite.build.pull_request.id"] = pr.ID
+		pipeline.Attributes["ci.buildkite.build.pull_request.base"] = pr.Base
+	}
+	if scheduled := parseTime(b.ScheduledAt); !scheduled.IsZero() && !pipeline.Started.Before(scheduled) {
+		pipeline.Attributes["ci.buildkite.build.queued_duration"] = pipeline.Started.Sub(scheduled).Seconds()
+	}
+
+	for i := range pipeline.Tasks {
+		pipeline.Tasks[i].Attributes = jobAttributes(&b.Jobs[i])
+	}
+
+	removeLegacyAttributes(&pipeline, config.Semconv)
+	return pipeline
+}
+
+func resourceAttributes(e *webhookEvent, config *Config, slug string) map[string]any {
+	attrs := map[string]any{
+		"service.name":               generateServiceName(config, slug),
+		"ci.buildkite.pipeline.slug": slug,
+	}
+	if e.Pipeline != nil && e.Pipeline.Repository != "" {
+		attrs["ci.buildkite.pipeline.repository"] = e.Pipeline.Repository
+	}
+	return attrs
+}
+
+func jobAttributes(j *job) map[string]any {
+	attrs := map[string]any{
+		"ci.buildkite.job.id":    j.ID,
+		"ci.buildkite.job.name":  j.displayName(),
+		"ci.buildkite.job.type":  j.Type,
+		"ci.buildkite.job.state": j.State,
+	}
+	if j.WebURL != "" {
+		attrs["ci.buildkite.job.url"] = j.WebURL
+	}
+	if j.StepKey != "" {
+		attrs["ci.buildkite.job.step_key"] = j.StepKey
+	}
+
+	switch j.Type {
+	case jobTypeWaiter:
+	case jobTypeManual:
+		if j.UnblockedBy != nil {
+			attrs["ci.buildkite.job.unblocked_by"] = j.UnblockedBy.Name
+		}
+	case jobTypeTrigger:
+		if tb := j.TriggeredBuild; tb != nil {
+			attrs["ci.buildkite.job.triggered_build.id"] = tb.ID
+			attrs["ci.buildkite.job.triggered_build.url"] = tb.WebURL
+		}
+	default:
+		attrs["ci.buildkite.job.agent.queue"] = j.queue()
+		attrs["ci.buildkite.job.soft_failed"] = j.SoftFailed
+		attrs["ci.buildkite.job.retried"] = j.Retried
+		if j.ExitStatus != nil {
+			attrs["ci.buildkite.job.exit_status"] = int64(*j.ExitStatus)
+		}
+		if j.Agent != nil {
+			attrs["ci.buildkite.job.agent.name"] = j.Agent.Name
+		}
+		runnable, started := parseTime(j.RunnableAt), parseTime(j.StartedAt)
+		if !runnable.IsZero() && !started.IsZero() && !started.Before(runnable) {
+			attrs["ci.buildkite.job.queued_duration"] = started.Sub(runnable).Seconds()
+		}
+	}
+
+	return attrs
+}
+
+// removeLegacyAttributes removes the attributes replaced by the semantic
+// conventions, unless the legacy ones are emitted.
+func removeLegacyAttributes(p *cimodel.Pipeline, cfg semconv.Config) {
+	if cfg.EmitsLegacy() {
+		return
+	}
+
+	for _, key := range legacyResourceAttributes {
+		delete(p.ResourceAttributes, key)
+	}
+	for _, key := range legacyBuildAttributes {
+		delete(p.Attributes, key)
+	}
+	for i := range p.Tasks {
+		for _, key := range legacyJobAttributes {
+			delete(p.Tasks[i].Attributes, key)
+		}
+	}
+}
+
+func traceOptions(config *Config) cimodel.Options {
+	return cimodel.Options{
+		ScopeName:    scopeName,
+		ScopeVersion: scopeVersion,
+		Semconv:      config.Semconv,
+	}
+}
+
+func generateServiceName(config *Config, slug string) string {
+	if config.CustomServiceName != "" {
+		return config.CustomServiceName
+	}
+	formattedName := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(slug, "/", "-"), "_", "-"))
+	return fmt.Sprintf("%s%s%s", config.ServiceNamePrefix, formattedName, config.ServiceNameSuffix)
+}
+
+func generateTraceID(buildID string) pcommon.TraceID {
+	hash := sha256.Sum256([]byte(buildID + "t"))
+	return pcommon.TraceID(hash[:16])
+}
+
+func generateBuildSpanID(buildID string) pcommon.SpanID {
+	return generateSpanID(buildID + "s")
+}
+
+func generateJobSpanID(buildID, jobID string) pcommon.SpanID {
+	return generateSpanID(buildID + "j" + jobID)
+}
+
+func generateSpanID(input string) pcommon.SpanID {
+	hash := sha256.Sum256([]byte(input))
+	return pcommon.SpanID(hash[:8])
+}
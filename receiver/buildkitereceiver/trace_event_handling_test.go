@@ -0,0 +1,213 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package buildkitereceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap/zaptest"
+)
+
+func spansByName(traces ptrace.Traces) map[string]ptrace.Span {
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	byName := make(map[string]ptrace.Span, spans.Len())
+	for i := 0; i < spans.Len(); i++ {
+		byName[spans.At(i).Name()] = spans.At(i)
+	}
+	return byName
+}
+
+func at(minute, second int) time.Time {
+	return time.Date(2024, 3, 5, 10, minute, second, 0, time.UTC)
+}
+
+func TestBuildEventToTraces(t *testing.T) {
+	e := loadEvent(t, "build_finished.json")
+
+	traces := buildEventToTraces(e, &Config{}, zaptest.NewLogger(t))
+
+	// The build and its 6 jobs, including the wait, block and trigger steps.
+	require.Equal(t, 7, traces.SpanCount())
+
+	resource := traces.ResourceSpans().At(0).Resource().Attributes()
+	serviceName, _ := resource.Get("service.name")
+	require.Equal(t, "acme-web-app", serviceName.Str())
+	slug, _ := resource.Get("ci.buildkite.pipeline.slug")
+	require.Equal(t, "acme/web-app", slug.Str())
+
+	spans := spansByName(traces)
+	build := spans["acme/web-app"]
+	require.Equal(t, "c198f1f14e90b9883942f1c33385af69", build.TraceID().String())
+	require.Equal(t, "94084287721a0dd2", build.SpanID().String())
+	require.True(t, build.ParentSpanID().IsEmpty())
+	require.Equal(t, ptrace.StatusCodeOk, build.Status().Code())
+	// The build span excludes the time the build was scheduled for.
+	require.Equal(t, at(0, 5), build.StartTimestamp().AsTime())
+	require.Equal(t, at(6, 0), build.EndTimestamp().AsTime())
+	queued, _ := build.Attributes().Get("ci.buildkite.build.queued_duration")
+	require.Equal(t, 5.0, queued.Double())
+	changeID, _ := build.Attributes().Get("ci.buildkite.build.pull_request.id")
+	require.Equal(t, "12", changeID.Str())
+
+	jobs := map[string]struct {
+		start, end time.Time
+		code       ptrace.StatusCode
+	}{
+		":eslint: Lint": {at(0, 10), at(1, 0), ptrace.StatusCodeOk},
+		// The wait step lasts until the first job after it started.
+		"wait":        {at(1, 0), at(1, 20), ptrace.StatusCodeOk},
+		":go: Test":   {at(1, 30), at(3, 0), ptrace.StatusCodeOk},
+		"Integration": {at(1, 20), at(2, 40), ptrace.StatusCodeError},
+		// The block step lasts until it was unblocked.
+		":rocket: Deploy?": {at(3, 0), at(4, 0), ptrace.StatusCodeOk},
+		"Deploy":           {at(4, 5), at(6, 0), ptrace.StatusCodeOk},
+	}
+	for name, expected := range jobs {
+		job, ok := spans[name]
+		require.True(t, ok, name)
+		require.Equal(t, build.SpanID(), job.ParentSpanID(), name)
+		require.Equal(t, expected.start, job.StartTimestamp().AsTime(), name)
+		require.Equal(t, expected.end, job.EndTimestamp().AsTime(), name)
+		require.Equal(t, expected.code, job.Status().Code(), name)
+	}
+
+	test := spans[":go: Test"]
+	require.Equal(t, "6beb1651e0a9d880", test.SpanID().String())
+	queue, _ := test.Attributes().Get("ci.buildkite.job.agent.queue")
+	require.Equal(t, "test", queue.Str())
+	agentName, _ := test.Attributes().Get("ci.buildkite.job.agent.name")
+	require.Equal(t, "agent-2", agentName.Str())
+	jobQueued, _ := test.Attributes().Get("ci.buildkite.job.queued_duration")
+	require.Equal(t, 30.0, jobQueued.Double())
+
+	integration := spans["Integration"].Attributes()
+	softFailed, _ := integration.Get("ci.buildkite.job.soft_failed")
+	require.True(t, softFailed.Bool())
+	exitStatus, _ := integration.Get("ci.buildkite.job.exit_status")
+	require.EqualValues(t, 1, exitStatus.Int())
+
+	unblockedBy, _ := spans[":rocket: Deploy?"].Attributes().Get("ci.buildkite.job.unblocked_by")
+	require.Equal(t, "Jane Doe", unblockedBy.Str())
+	triggered, _ := spans["Deploy"].Attributes().Get("ci.buildkite.job.triggered_build.url")
+	require.Equal(t, "https://buildkite.com/acme/deploy/builds/7", triggered.Str())
+}
+
+func TestBuildEventToTracesSemconv(t *testing.T) {
+	e := loadEvent(t, "build_finished.json")
+
+	tests := []struct {
+		desc         string
+		semconv      semconv.Config
+		expectLegacy bool
+	}{
+		{
+			desc:         "Semantic conventions with legacy attributes",
+			semconv:      semconv.Config{Enabled: true, EmitLegacy: true},
+			expectLegacy: true,
+		},
+		{
+			desc:         "Semantic conventions only",
+			semconv:      semconv.Config{Enabled: true},
+			expectLegacy: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			traces := buildEventToTraces(e, &Config{Semconv: test.semconv}, zaptest.NewLogger(t))
+
+			resource := traces.ResourceSpans().At(0).Resource().Attributes()
+			provider, _ := resource.Get(semconv.AttributeVCSProviderName)
+			require.Equal(t, semconv.AttributeVCSProviderNameGithub, provider.Str())
+			repoURL, _ := resource.Get(semconv.AttributeVCSRepositoryURLFull)
+			require.Equal(t, "https://github.com/acme/web-app", repoURL.Str())
+			_, ok := resource.Get("ci.buildkite.pipeline.repository")
+			require.Equal(t, test.expectLegacy, ok)
+
+			spans := spansByName(traces)
+			build := spans["acme/web-app"].Attributes()
+			changeID, _ := build.Get(semconv.AttributeVCSChangeID)
+			require.Equal(t, "12", changeID.Str())
+			head, _ := build.Get(semconv.AttributeVCSRefHeadName)
+			require.Equal(t, "feature/login", head.Str())
+			result, _ := build.Get(semconv.AttributeCICDPipelineResult)
+			require.Equal(t, "success", result.Str())
+			_, ok = build.Get("ci.buildkite.build.state")
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = build.Get("ci.buildkite.build.source")
+			require.True(t, ok)
+
+			job := spans["Integration"].Attributes()
+			taskID, _ := job.Get(semconv.AttributeCICDPipelineTaskRunID)
+			require.Equal(t, "0190046e-e1a2-4e6a-8f5c-000000000004", taskID.Str())
+			worker, _ := job.Get(semconv.AttributeCICDWorkerName)
+			require.Equal(t, "agent-3", worker.Str())
+			taskResult, _ := job.Get(semconv.AttributeCICDPipelineTaskRunResult)
+			require.Equal(t, "failure", taskResult.Str())
+			_, ok = job.Get("ci.buildkite.job.name")
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = job.Get("ci.buildkite.job.agent.queue")
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestSetBarrierTimes(t *testing.T) {
+	// Wait steps after jobs that never ran, and block steps that were not
+	// unblocked, last no time.
+	e := loadEvent(t, "build_finished.json")
+	jobs := e.Build.Jobs
+	jobs[2].StartedAt, jobs[2].FinishedAt = "", ""
+	jobs[3].StartedAt, jobs[3].FinishedAt = "", ""
+	jobs[4].UnblockedAt = ""
+
+	pipeline := buildPipeline(e)
+	require.Equal(t, at(1, 0), pipeline.Tasks[1].Started)
+	require.Equal(t, at(1, 0), pipeline.Tasks[1].Finished)
+	require.Equal(t, at(1, 0), pipeline.Tasks[4].Started)
+	require.Equal(t, at(1, 0), pipeline.Tasks[4].Finished)
+}
+
+func TestPipelineRepository(t *testing.T) {
+	tests := map[string]struct {
+		pipeline pipeline
+		expect   string
+		owner    string
+		provider string
+	}{
+		"scp-like": {
+			pipeline: pipeline{Repository: "git@github.com:acme/web-app.git", Provider: struct {
+				ID string `json:"id"`
+			}{ID: "github"}},
+			expect: "https://github.com/acme/web-app", owner: "acme", provider: semconv.AttributeVCSProviderNameGithub,
+		},
+		"https": {
+			pipeline: pipeline{Repository: "https://gitlab.example.com/acme/group/web-app.git", Provider: struct {
+				ID string `json:"id"`
+			}{ID: "gitlab_ee"}},
+			expect: "https://gitlab.example.com/acme/group/web-app", owner: "acme/group", provider: semconv.AttributeVCSProviderNameGitlab,
+		},
+		"unknown provider": {
+			pipeline: pipeline{Repository: "ssh://git@git.example.com/acme/web-app.git"},
+			expect:   "https://git.example.com/acme/web-app", owner: "acme",
+		},
+		"local path": {
+			pipeline: pipeline{Repository: "/srv/git/web-app"},
+			expect:   "/srv/git/web-app",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo := pipelineRepository(&test.pipeline)
+			require.Equal(t, test.expect, repo.URL)
+			require.Equal(t, test.owner, repo.Owner)
+			require.Equal(t, test.provider, repo.Provider)
+		})
+	}
+}
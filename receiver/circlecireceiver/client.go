@@ -0,0 +1,180 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// errNotFound is returned for missing resources, such as the jobs of
+// projects the token cannot read.
+var errNotFound = errors.New("not found")
+
+// maxWorkflowPages bounds the pages of workflows listed for a pipeline.
+const maxWorkflowPages = 10
+
+// jobDetails is a job as described by the v1.1 API, the only one listing
+// the steps of jobs.
+type jobDetails struct {
+	QueuedAt  string `json:"queued_at"`
+	StartTime string `json:"start_time"`
+	StopTime  string `json:"stop_time"`
+	Parallel  int64  `json:"parallel"`
+	Picard    *struct {
+		Executor      string `json:"executor"`
+		ResourceClass struct {
+			Class string `json:"class"`
+		} `json:"resource_class"`
+	} `json:"picard"`
+	Steps []stepDetails `json:"steps"`
+}
+
+// stepDetails is a step of a job, with an action per parallel run of the
+// job.
+type stepDetails struct {
+	Name    string          `json:"name"`
+	Actions []actionDetails `json:"actions"`
+}
+
+type actionDetails struct {
+	Index     int64  `json:"index"`
+	Step      int64  `json:"step"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+	ExitCode  *int   `json:"exit_code"`
+	HasOutput bool   `json:"has_output"`
+	OutputURL string `json:"output_url"`
+}
+
+// outputMessage is a chunk of the output of an action.
+type outputMessage struct {
+	Message string `json:"message"`
+	Time    string `json:"time"`
+	Type    string `json:"type"`
+}
+
+// workflowItem is a workflow of a pipeline, as listed by the v2 API.
+type workflowItem struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+	StoppedAt string `json:"stopped_at"`
+}
+
+// circleciClient reads pipelines and jobs from the CircleCI API. The token
+// is only sent to the configured API, not to the pre-signed URLs the output
+// of steps is stored at.
+type circleciClient struct {
+	baseURL *url.URL
+	token   string
+	client  *http.Client
+}
+
+func newCircleCIClient(cfg CircleCIAPIConfig) (*circleciClient, error) {
+	baseURL, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+
+	return &circleciClient{
+		baseURL: baseURL,
+		token:   cfg.Token,
+		client:  &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// jobDetails describes a job and its steps, given the slug of its project
+// and its number.
+func (c *circleciClient) jobDetails(ctx context.Context, projectSlug string, number int64) (*jobDetails, error) {
+	var details jobDetails
+	ref := "api/v1.1/project/" + projectSlug + "/" + strconv.FormatInt(number, 10)
+	if err := c.getJSON(ctx, ref, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// stepOutput returns the output of an action.
+func (c *circleciClient) stepOutput(ctx context.Context, outputURL string) ([]outputMessage, error) {
+	var output []outputMessage
+	if err := c.getJSON(ctx, outputURL, &output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// pipelineWorkflows lists the workflows of a pipeline.
+func (c *circleciClient) pipelineWorkflows(ctx context.Context, pipelineID string) ([]workflowItem, error) {
+	var workflows []workflowItem
+
+	ref := "api/v2/pipeline/" + url.PathEscape(pipelineID) + "/workflow"
+	pageToken := ""
+	for range maxWorkflowPages {
+		var page struct {
+			Items         []workflowItem `json:"items"`
+			NextPageToken string         `json:"next_page_token"`
+		}
+
+		pageRef := ref
+		if pageToken != "" {
+			pageRef += "?page-token=" + url.QueryEscape(pageToken)
+		}
+		if err := c.getJSON(ctx, pageRef, &page); err != nil {
+			return nil, err
+		}
+
+		workflows = append(workflows, page.Items...)
+		if page.NextPageToken == "" {
+			return workflows, nil
+		}
+		pageToken = page.NextPageToken
+	}
+
+	return nil, fmt.Errorf("pipeline %s has more than %d pages of workflows", pipelineID, maxWorkflowPages)
+}
+
+func (c *circleciClient) getJSON(ctx context.Context, ref string, v any) error {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return err
+	}
+	u = c.baseURL.ResolveReference(u)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if u.Scheme == c.baseURL.Scheme && u.Host == c.baseURL.Host {
+		req.Header.Set("Circle-Token", c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%s: %w", u.Path, errNotFound)
+	case resp.StatusCode >= http.StatusBadRequest:
+		return fmt.Errorf("%s: unexpected status %s", u.Path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
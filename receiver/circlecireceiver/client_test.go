@@ -0,0 +1,126 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPipelineID = "5034460f-c7c4-4c43-9457-de07e2029e7b"
+	testJobNumber  = 131
+)
+
+// newCircleCITestServer serves the workflows of pipeline 130 and job 131 of
+// gh/acme/web-app, whose step output is served by a second server standing
+// for the storage of outputs.
+func newCircleCITestServer(t *testing.T) *circleciClient {
+	t.Helper()
+
+	outputServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The token must not leak to the storage of outputs.
+		require.Empty(t, r.Header.Get("Circle-Token"))
+
+		var step, index int
+		if _, err := fmt.Sscanf(r.URL.Path, "/output/%d/%d", &step, &index); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		name := filepath.Join("testdata", fmt.Sprintf("output_%d_%d.json", step, index))
+		if _, err := os.Stat(name); err != nil {
+			fmt.Fprintf(w, `[{"message": "step %d\r\nrun %d\r\n", "time": "2024-03-05T10:01:00Z", "type": "out"}]`, step, index)
+			return
+		}
+		http.ServeFile(w, r, name)
+	}))
+	t.Cleanup(outputServer.Close)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "api-token", r.Header.Get("Circle-Token"))
+
+		var name string
+		switch r.URL.Path {
+		case "/api/v2/pipeline/" + testPipelineID + "/workflow":
+			name = "pipeline_workflows.json"
+		case fmt.Sprintf("/api/v1.1/project/gh/acme/web-app/%d", testJobNumber):
+			name = fmt.Sprintf("job_%d.json", testJobNumber)
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		payload, err := os.ReadFile(filepath.Join("testdata", name))
+		require.NoError(t, err)
+		_, _ = w.Write(bytes.ReplaceAll(payload, []byte("{{output}}"), []byte(outputServer.URL)))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := newCircleCIClient(CircleCIAPIConfig{
+		BaseURL: server.URL,
+		Token:   "api-token",
+		Timeout: time.Second,
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestJobDetails(t *testing.T) {
+	client := newCircleCITestServer(t)
+
+	details, err := client.jobDetails(t.Context(), "gh/acme/web-app", testJobNumber)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, details.Parallel)
+	require.Len(t, details.Steps, 3)
+	require.Len(t, details.Steps[2].Actions, 2)
+	require.Equal(t, "failed", details.Steps[2].Actions[1].Status)
+
+	output, err := client.stepOutput(t.Context(), details.Steps[2].Actions[1].OutputURL)
+	require.NoError(t, err)
+	require.Len(t, output, 3)
+	require.True(t, strings.HasPrefix(output[0].Message, "#!/bin/bash"))
+
+	_, err = client.jobDetails(t.Context(), "gh/acme/web-app", 1)
+	require.ErrorIs(t, err, errNotFound)
+}
+
+func TestPipelineWorkflows(t *testing.T) {
+	client := newCircleCITestServer(t)
+
+	workflows, err := client.pipelineWorkflows(t.Context(), testPipelineID)
+	require.NoError(t, err)
+	require.Len(t, workflows, 2)
+	require.Equal(t, "lint", workflows[1].Name)
+	require.True(t, pipelineComplete(workflows))
+}
+
+func TestPipelineWorkflowsPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page-token") {
+		case "":
+			_, _ = w.Write([]byte(`{"items": [{"id": "1", "stopped_at": "2024-03-05T10:05:00Z"}], "next_page_token": "next"}`))
+		case "next":
+			_, _ = w.Write([]byte(`{"items": [{"id": "2"}], "next_page_token": null}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := newCircleCIClient(CircleCIAPIConfig{BaseURL: server.URL, Token: "api-token", Timeout: time.Second})
+	require.NoError(t, err)
+
+	workflows, err := client.pipelineWorkflows(t.Context(), testPipelineID)
+	require.NoError(t, err)
+	require.Len(t, workflows, 2)
+	// The second workflow is still running.
+	require.False(t, pipelineComplete(workflows))
+}
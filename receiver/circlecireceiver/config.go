@@ -0,0 +1,78 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/circlecireceiver"
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/circlecireceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.uber.org/multierr"
+)
+
+var errMissingEndpointFromConfig = errors.New("missing receiver server endpoint from config")
+var errBaseURL = errors.New("circleci_api base_url must be an absolute http or https URL")
+var errTimeout = errors.New("circleci_api timeout must not be negative")
+var errQueueSize = errors.New("circleci_api queue_size must be positive")
+
+// CircleCIAPIConfig defines configuration for the CircleCI API
+type CircleCIAPIConfig struct {
+	BaseURL   string        `mapstructure:"base_url"`   // root URL of the CircleCI API, such as https://circleci.example.com/ for CircleCI server. Default is https://circleci.com/
+	Token     string        `mapstructure:"token"`      // personal API token. Default is empty, disabling pipeline spans, steps and logs
+	Timeout   time.Duration `mapstructure:"timeout"`    // timeout of each API request. Default is 30s
+	QueueSize int           `mapstructure:"queue_size"` // completed workflows and jobs waiting to be described by the API, more are rejected. Default is 100
+}
+
+// enabled reports whether the API can be used.
+func (cfg CircleCIAPIConfig) enabled() bool {
+	return cfg.Token != ""
+}
+
+// Config defines configuration for CircleCI receiver
+type Config struct {
+	metadata.MetricsBuilderConfig `mapstructure:",squash"`
+	confighttp.ServerConfig       `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct
+	Path                          string                   `mapstructure:"path"`                // path for data collection. Default is <host>:<port>/circlecievents
+	Secret                        string                   `mapstructure:"secret"`              // secret the circleci-signature header is computed with. Default is empty
+	CustomServiceName             string                   `mapstructure:"custom_service_name"` // custom service name. Default is empty
+	ServiceNamePrefix             string                   `mapstructure:"service_name_prefix"` // service name prefix. Default is empty
+	ServiceNameSuffix             string                   `mapstructure:"service_name_suffix"` // service name suffix. Default is empty
+	CircleCIAPIConfig             CircleCIAPIConfig        `mapstructure:"circleci_api"`        // circleci api configuration
+	Logs                          logpolicy.Config         `mapstructure:"logs"`                // sampling, truncation and redaction policies applied to step output. failed_only applies to jobs
+	Semconv                       semconv.Config           `mapstructure:"semconv"`             // OpenTelemetry CICD and VCS semantic conventions
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the receiver configuration is valid
+func (cfg *Config) Validate() error {
+	var errs error
+
+	if cfg.NetAddr.Endpoint == "" {
+		errs = multierr.Append(errs, errMissingEndpointFromConfig)
+	}
+
+	api := cfg.CircleCIAPIConfig
+	if u, err := url.Parse(api.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = multierr.Append(errs, errBaseURL)
+	}
+	if api.Timeout < 0 {
+		errs = multierr.Append(errs, errTimeout)
+	}
+	if api.QueueSize < 1 {
+		errs = multierr.Append(errs, errQueueSize)
+	}
+
+	if err := cfg.Logs.Validate(); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("invalid logs configuration: %w", err))
+	}
+
+	return errs
+}
@@ -0,0 +1,148 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	eventTypeHeader = "Circleci-Event-Type"
+	signatureHeader = "Circleci-Signature"
+)
+
+// Events handled by the receiver.
+const (
+	eventWorkflowCompleted = "workflow-completed"
+	eventJobCompleted      = "job-completed"
+)
+
+var (
+	errMissingWorkflow  = errors.New("webhook has no workflow or pipeline")
+	errMissingJob       = errors.New("job webhook has no job")
+	errInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// webhookEvent is a CircleCI webhook event. Job events describe the workflow
+// and pipeline of the job as well.
+type webhookEvent struct {
+	Type         string  `json:"type"`
+	ID           string  `json:"id"`
+	HappenedAt   string  `json:"happened_at"`
+	Project      project `json:"project"`
+	Organization struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"organization"`
+	Workflow *workflow `json:"workflow"`
+	Pipeline *pipeline `json:"pipeline"`
+	Job      *job      `json:"job"`
+}
+
+type project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type workflow struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	StoppedAt string `json:"stopped_at"`
+	URL       string `json:"url"`
+	Status    string `json:"status"`
+}
+
+type pipeline struct {
+	ID        string `json:"id"`
+	Number    int64  `json:"number"`
+	CreatedAt string `json:"created_at"`
+	Trigger   struct {
+		Type string `json:"type"`
+	} `json:"trigger"`
+	VCS *vcs `json:"vcs"`
+}
+
+type vcs struct {
+	ProviderName        string `json:"provider_name"`
+	OriginRepositoryURL string `json:"origin_repository_url"`
+	TargetRepositoryURL string `json:"target_repository_url"`
+	Revision            string `json:"revision"`
+	Branch              string `json:"branch"`
+	Tag                 string `json:"tag"`
+}
+
+type job struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Number    int64  `json:"number"`
+	StartedAt string `json:"started_at"`
+	StoppedAt string `json:"stopped_at"`
+	Status    string `json:"status"`
+}
+
+// parseWebhookEvent parses the payload of an event, of the type sent in the
+// circleci-event-type header.
+func parseWebhookEvent(eventType string, payload []byte) (*webhookEvent, error) {
+	var e webhookEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+	e.Type = eventType
+
+	switch {
+	case e.Workflow == nil || e.Pipeline == nil:
+		return nil, errMissingWorkflow
+	case e.Type == eventJobCompleted && e.Job == nil:
+		return nil, errMissingJob
+	}
+	return &e, nil
+}
+
+// validateSignature checks the circleci-signature header of a webhook
+// request, a list of versioned HMAC-SHA256 digests of the payload such as
+// v1=<hex>.
+func validateSignature(header string, payload []byte, secret string) error {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	for _, part := range strings.Split(header, ",") {
+		version, signature, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if version != "v1" {
+			continue
+		}
+		if actual, err := hex.DecodeString(signature); err == nil && hmac.Equal(actual, expected) {
+			return nil
+		}
+	}
+	return errInvalidSignature
+}
+
+// jobURL returns the URL of a job, under the URL of its workflow.
+func (e *webhookEvent) jobURL() string {
+	if e.Workflow.URL == "" || e.Job == nil {
+		return ""
+	}
+	return strings.TrimSuffix(e.Workflow.URL, "/") + "/jobs/" + strconv.FormatInt(e.Job.Number, 10)
+}
+
+func parseTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
@@ -0,0 +1,108 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func loadEvent(t *testing.T, name string) *webhookEvent {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	eventType := eventWorkflowCompleted
+	if name == "job_completed.json" {
+		eventType = eventJobCompleted
+	}
+	e, err := parseWebhookEvent(eventType, payload)
+	require.NoError(t, err)
+	return e
+}
+
+// sign returns the signature header of a payload.
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParseWebhookEvent(t *testing.T) {
+	tests := map[string]struct {
+		eventType string
+		payload   string
+		err       error
+	}{
+		"workflow":                  {eventType: eventWorkflowCompleted, payload: `{"workflow": {}, "pipeline": {}}`},
+		"workflow without pipeline": {eventType: eventWorkflowCompleted, payload: `{"workflow": {}}`, err: errMissingWorkflow},
+		"job":                       {eventType: eventJobCompleted, payload: `{"job": {}, "workflow": {}, "pipeline": {}}`},
+		"job without job":           {eventType: eventJobCompleted, payload: `{"workflow": {}, "pipeline": {}}`, err: errMissingJob},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e, err := parseWebhookEvent(test.eventType, []byte(test.payload))
+			require.ErrorIs(t, err, test.err)
+			if err == nil {
+				require.Equal(t, test.eventType, e.Type)
+			}
+		})
+	}
+}
+
+func TestValidateSignature(t *testing.T) {
+	payload := []byte(`{"type": "workflow-completed"}`)
+
+	tests := []struct {
+		desc   string
+		header string
+		err    error
+	}{
+		{
+			desc:   "Valid signature",
+			header: sign("mysecret", payload),
+		},
+		{
+			// Signatures of future versions are ignored.
+			desc:   "Several versions",
+			header: "v2=abcdef," + sign("mysecret", payload),
+		},
+		{
+			desc:   "Signature with another secret",
+			header: sign("wrong", payload),
+			err:    errInvalidSignature,
+		},
+		{
+			desc:   "Malformed signature",
+			header: "v1=zz",
+			err:    errInvalidSignature,
+		},
+		{
+			desc: "Missing signature",
+			err:  errInvalidSignature,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			require.ErrorIs(t, validateSignature(test.header, payload, "mysecret"), test.err)
+		})
+	}
+}
+
+func TestJobURL(t *testing.T) {
+	e := loadEvent(t, "job_completed.json")
+	require.Equal(t, "https://app.circleci.com/pipelines/github/acme/web-app/130/workflows/fda08377-fe7e-46b1-8992-3a7aaecac9c3/jobs/131", e.jobURL())
+
+	e.Workflow.URL = ""
+	require.Empty(t, e.jobURL())
+}
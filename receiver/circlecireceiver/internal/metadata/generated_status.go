@@ -0,0 +1,18 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"go.opentelemetry.io/collector/component"
+)
+
+var (
+	Type      = component.MustNewType("circleci")
+	ScopeName = "github.com/grafana/grafana-ci-otel-collector/receiver/circlecireceiver"
+)
+
+const (
+	TracesStability  = component.StabilityLevelAlpha
+	LogsStability    = component.StabilityLevelAlpha
+	MetricsStability = component.StabilityLevelAlpha
+)
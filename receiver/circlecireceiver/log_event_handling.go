@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+// ansiEscape matches the colour sequences of step output.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// jobEventToLogs fetches the output of the steps of a completed job and
+// translates it to log records correlated with the spans of its steps. The
+// output of the parallel runs of a step follow each other, in the order of
+// their index.
+func jobEventToLogs(ctx context.Context, e *webhookEvent, details *jobDetails, config *Config, client *circleciClient, policy *logpolicy.Policy, logger *zap.Logger) (*plog.Logs, error) {
+	pipeline := jobEventToPipeline(e, details, config)
+	task := &pipeline.Tasks[0]
+
+	if !policy.KeepLogs(task.Result.Failed()) {
+		logger.Debug("Skipping logs of job", zap.String("project", e.Project.Slug), zap.String("job", e.Job.Name), zap.Int64("number", e.Job.Number), zap.String("status", e.Job.Status))
+		return nil, nil
+	}
+
+	for i := range task.Steps {
+		step := &task.Steps[i]
+
+		for _, action := range details.Steps[i].Actions {
+			if !action.HasOutput || action.OutputURL == "" {
+				continue
+			}
+
+			output, err := client.stepOutput(ctx, action.OutputURL)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get output of step %q of %s #%d: %w", step.Name, e.Project.Slug, e.Job.Number, err)
+			}
+			step.Logs = append(step.Logs, parseOutput(output, step.Started)...)
+		}
+
+		step.LogAttributes = map[string]any{
+			"ci.circleci.project.slug":  e.Project.Slug,
+			"ci.circleci.workflow.name": e.Workflow.Name,
+			"ci.circleci.job.name":      e.Job.Name,
+			"ci.circleci.job.number":    e.Job.Number,
+			"ci.circleci.step.name":     step.Name,
+		}
+	}
+
+	logs := cimodel.ToLogs(&pipeline, policy, traceOptions(config))
+	return &logs, nil
+}
+
+// parseOutput splits the output of an action into lines, timestamped with
+// the time of the message they were written in.
+func parseOutput(output []outputMessage, started time.Time) []cimodel.LogEntry {
+	var entries []cimodel.LogEntry
+	for _, message := range output {
+		timestamp := parseTime(message.Time)
+		if timestamp.IsZero() {
+			timestamp = started
+		}
+
+		for _, line := range strings.Split(message.Message, "\n") {
+			if body, ok := parseOutputLine(line); ok {
+				entries = append(entries, cimodel.LogEntry{Timestamp: timestamp, Body: body})
+			}
+		}
+	}
+	return entries
+}
+
+// parseOutputLine strips the escape sequences from a line, keeping the text
+// written after the last carriage return of progress output.
+func parseOutputLine(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r")
+	if line == "" {
+		return "", false
+	}
+
+	line = ansiEscape.ReplaceAllString(line, "")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+
+	line = strings.TrimRight(line, " ")
+	return line, line != ""
+}
@@ -0,0 +1,122 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+func newTestLogPolicy(tb testing.TB, cfg logpolicy.Config) *logpolicy.Policy {
+	tb.Helper()
+	policy, err := logpolicy.New(cfg, nil)
+	require.NoError(tb, err)
+	return policy
+}
+
+// logBodies returns the bodies of the log records, by the step they were
+// attributed to.
+func logBodies(logs *plog.Logs) map[string][]string {
+	bodies := map[string][]string{}
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	for i := range records.Len() {
+		step, _ := records.At(i).Attributes().Get("ci.circleci.step.name")
+		bodies[step.Str()] = append(bodies[step.Str()], records.At(i).Body().Str())
+	}
+	return bodies
+}
+
+func TestParseOutputLine(t *testing.T) {
+	tests := map[string]struct {
+		line         string
+		expectBody   string
+		expectParsed bool
+	}{
+		"empty line": {
+			line: "\r",
+		},
+		"plain line": {
+			line:         "make test\r",
+			expectBody:   "make test",
+			expectParsed: true,
+		},
+		"colours": {
+			line:         "\x1b[31mFAIL\x1b[0m\tgithub.com/acme/web-app/login\r",
+			expectBody:   "FAIL\tgithub.com/acme/web-app/login",
+			expectParsed: true,
+		},
+		"progress": {
+			line:         "Downloading 10%\rDownloading 100%\r",
+			expectBody:   "Downloading 100%",
+			expectParsed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			body, ok := parseOutputLine(test.line)
+			require.Equal(t, test.expectParsed, ok)
+			require.Equal(t, test.expectBody, body)
+		})
+	}
+}
+
+func TestJobEventToLogs(t *testing.T) {
+	client := newCircleCITestServer(t)
+	e := loadEvent(t, "job_completed.json")
+	details, err := client.jobDetails(t.Context(), e.Project.Slug, e.Job.Number)
+	require.NoError(t, err)
+	cfg := createDefaultConfig().(*Config)
+
+	logs, err := jobEventToLogs(t.Context(), e, details, cfg, client, newTestLogPolicy(t, cfg.Logs), zap.NewNop())
+	require.NoError(t, err)
+	require.NotNil(t, logs)
+	require.Equal(t, 17, logs.LogRecordCount())
+
+	// The output of the parallel runs of a step follow each other.
+	require.Equal(t, []string{
+		"step 2",
+		"run 0",
+		"#!/bin/bash -eo pipefail",
+		"make test",
+		"ok  \tgithub.com/acme/web-app/auth\t0.012s",
+		"Downloading 100%",
+		"--- FAIL: TestLogin (0.00s)",
+		"FAIL\tgithub.com/acme/web-app/login\t0.020s",
+		"Exited with code exit status 1",
+	}, logBodies(logs)["Run tests"])
+
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	for i := range records.Len() {
+		record := records.At(i)
+		require.Equal(t, "96925fc48b0608b307e6b2031f5cd72c", record.TraceID().String())
+		require.EqualValues(t, testJobNumber, record.Attributes().AsRaw()["ci.circleci.job.number"])
+
+		if step, _ := record.Attributes().Get("ci.circleci.step.name"); step.Str() == "Run tests" {
+			require.Equal(t, "98b2ea540818884c", record.SpanID().String())
+		}
+	}
+}
+
+func TestJobEventToLogsFailedOnly(t *testing.T) {
+	client := newCircleCITestServer(t)
+	e := loadEvent(t, "job_completed.json")
+	details, err := client.jobDetails(t.Context(), e.Project.Slug, e.Job.Number)
+	require.NoError(t, err)
+	cfg := createDefaultConfig().(*Config)
+	cfg.Logs.FailedOnly = true
+
+	logs, err := jobEventToLogs(t.Context(), e, details, cfg, client, newTestLogPolicy(t, cfg.Logs), zap.NewNop())
+	require.NoError(t, err)
+	require.Equal(t, 17, logs.LogRecordCount())
+
+	e.Job.Status = "success"
+	logs, err = jobEventToLogs(t.Context(), e, details, cfg, client, newTestLogPolicy(t, cfg.Logs), zap.NewNop())
+	require.NoError(t, err)
+	require.Nil(t, logs)
+}
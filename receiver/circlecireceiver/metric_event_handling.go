@@ -0,0 +1,212 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/circlecireceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.uber.org/zap"
+)
+
+const metricsMaxCacheSize = 100000
+const histogramCacheSize = 50000
+const histogramTTL = 24 * time.Hour
+
+type metricsHandler struct {
+	mu             sync.Mutex
+	mb             *metadata.MetricsBuilder
+	cfg            *Config
+	logger         *zap.Logger
+	countersCache  *lru.Cache[string, int64]
+	histogramCache *lru.Cache[string, *cimodel.Histogram]
+	durations      *cimodel.Durations
+}
+
+func newMetricsHandler(settings receiver.Settings, cfg *Config, logger *zap.Logger) (*metricsHandler, error) {
+	countersCache, err := lru.New[string, int64](metricsMaxCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize counters cache: %w", err)
+	}
+
+	// histogramCache stores cumulative histogram state per unique dimension set,
+	// as histograms are emitted with cumulative temporality.
+	histogramCache, err := lru.New[string, *cimodel.Histogram](histogramCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize histogram cache: %w", err)
+	}
+
+	durations, err := cimodel.NewDurations(histogramCacheSize, histogramTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize durations cache: %w", err)
+	}
+
+	return &metricsHandler{
+		cfg:            cfg,
+		mb:             metadata.NewMetricsBuilder(cfg.MetricsBuilderConfig, settings),
+		logger:         logger,
+		countersCache:  countersCache,
+		histogramCache: histogramCache,
+		durations:      durations,
+	}, nil
+}
+
+// workflowEventToMetrics counts completed workflows by status, and reports
+// their durations.
+func (m *metricsHandler) workflowEventToMetrics(e *webhookEvent) pmetric.Metrics {
+	slug, name := e.Project.Slug, e.Workflow.Name
+
+	m.logger.Debug("Processing workflow event",
+		zap.String("project", slug),
+		zap.Int64("pipeline", e.Pipeline.Number),
+		zap.String("workflow", name),
+		zap.String("status", e.Workflow.Status),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	status, ok := metadata.MapAttributeCiCircleciWorkflowStatus[e.Workflow.Status]
+	if ok && slug != "" {
+		dimensions := fmt.Sprintf("workflow:%s:%s", slug, name)
+		val, found := m.countersCache.Get(dimensions + ":" + status.String())
+		if !found {
+			// The counters of the other statuses start at zero, so that
+			// their increases are visible from their first event.
+			for _, s := range metadata.MapAttributeCiCircleciWorkflowStatus {
+				if s != status && m.seedCounter(dimensions+":"+s.String()) {
+					m.mb.RecordWorkflowsCountDataPoint(now, 0, slug, name, s)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+status.String(), val+1)
+		m.mb.RecordWorkflowsCountDataPoint(now, val+1, slug, name, status)
+	}
+
+	metrics := m.mb.Emit()
+	if e.Workflow.CreatedAt == "" || e.Workflow.StoppedAt == "" {
+		return metrics
+	}
+
+	ms := scopeMetrics(metrics)
+	pipeline := workflowRun(e)
+
+	if m.cfg.Semconv.EmitsLegacy() {
+		key := fmt.Sprintf("hist:workflow:%s:%s:%s", slug, name, e.Workflow.Status)
+		cimodel.AppendHistogram(ms, "workflows.duration", map[string]any{
+			"ci.circleci.project.slug":    slug,
+			"ci.circleci.workflow.name":   name,
+			"ci.circleci.workflow.status": e.Workflow.Status,
+		}, m.observeDuration(key, pipeline.Finished.Sub(pipeline.Started).Seconds()))
+	}
+
+	if m.cfg.Semconv.Enabled {
+		m.durations.AppendPipeline(ms, &pipeline)
+	}
+
+	return metrics
+}
+
+// jobEventToMetrics counts completed jobs by status, and reports the
+// durations of the jobs that ran.
+func (m *metricsHandler) jobEventToMetrics(e *webhookEvent) pmetric.Metrics {
+	j := e.Job
+	slug, name := e.Project.Slug, e.Workflow.Name
+
+	m.logger.Debug("Processing job event",
+		zap.String("project", slug),
+		zap.String("workflow", name),
+		zap.String("job", j.Name),
+		zap.Int64("number", j.Number),
+		zap.String("status", j.Status),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	status, ok := metadata.MapAttributeCiCircleciJobStatus[j.Status]
+	if ok && slug != "" {
+		dimensions := fmt.Sprintf("job:%s:%s", slug, name)
+		val, found := m.countersCache.Get(dimensions + ":" + status.String())
+		if !found {
+			for _, s := range metadata.MapAttributeCiCircleciJobStatus {
+				if s != status && m.seedCounter(dimensions+":"+s.String()) {
+					m.mb.RecordJobsCountDataPoint(now, 0, slug, name, s)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+status.String(), val+1)
+		m.mb.RecordJobsCountDataPoint(now, val+1, slug, name, status)
+	}
+
+	metrics := m.mb.Emit()
+
+	// Jobs that never ran have no duration.
+	if j.StartedAt == "" || j.StoppedAt == "" {
+		return metrics
+	}
+
+	ms := scopeMetrics(metrics)
+	pipeline := jobEventPipeline(e, nil)
+	task := &pipeline.Tasks[0]
+
+	if m.cfg.Semconv.EmitsLegacy() {
+		key := fmt.Sprintf("hist:job:%s:%s:%s:%s", slug, name, j.Name, j.Status)
+		cimodel.AppendHistogram(ms, "jobs.duration", map[string]any{
+			"ci.circleci.project.slug":  slug,
+			"ci.circleci.workflow.name": name,
+			"ci.circleci.job.name":      j.Name,
+			"ci.circleci.job.status":    j.Status,
+		}, m.observeDuration(key, task.Finished.Sub(task.Started).Seconds()))
+	}
+
+	if m.cfg.Semconv.Enabled {
+		m.durations.AppendTask(ms, &pipeline, task)
+	}
+
+	return metrics
+}
+
+// scopeMetrics returns the metrics emitted by the metrics builder, which
+// emits no resource at all when no counter was recorded.
+func scopeMetrics(metrics pmetric.Metrics) pmetric.MetricSlice {
+	if metrics.ResourceMetrics().Len() == 0 {
+		scope := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+		scope.Scope().SetName(metadata.ScopeName)
+		return scope.Metrics()
+	}
+	return metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+}
+
+// seedCounter starts the counter cached under key at zero, reporting
+// whether it was unknown. Called under m.mu.
+func (m *metricsHandler) seedCounter(key string) bool {
+	if m.countersCache.Contains(key) {
+		return false
+	}
+	m.countersCache.Add(key, 0)
+	return true
+}
+
+// observeDuration records a duration in the histogram cached under key.
+// Called under m.mu.
+func (m *metricsHandler) observeDuration(key string, duration float64) *cimodel.Histogram {
+	// Stale histograms start over, the LRU evicts those never observed again
+	h, ok := m.histogramCache.Get(key)
+	if !ok || time.Since(h.LastSeen) >= histogramTTL {
+		h = cimodel.NewHistogram()
+	}
+	h.Observe(duration)
+	m.histogramCache.Add(key, h)
+	return h
+}
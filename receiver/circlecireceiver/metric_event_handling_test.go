@@ -0,0 +1,163 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/circlecireceiver/internal/metadata"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestMetricsHandler(t *testing.T, cfg *Config) *metricsHandler {
+	t.Helper()
+	cfg.MetricsBuilderConfig = metadata.DefaultMetricsBuilderConfig()
+	mh, err := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return mh
+}
+
+// metricNames returns the names of the metrics, with their data point counts.
+func metricNames(metrics pmetric.Metrics) map[string]int {
+	names := map[string]int{}
+	for i := range metrics.ResourceMetrics().Len() {
+		sms := metrics.ResourceMetrics().At(i).ScopeMetrics()
+		for j := range sms.Len() {
+			ms := sms.At(j).Metrics()
+			for k := range ms.Len() {
+				m := ms.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					names[m.Name()] += m.Sum().DataPoints().Len()
+				case pmetric.MetricTypeHistogram:
+					names[m.Name()] += m.Histogram().DataPoints().Len()
+				}
+			}
+		}
+	}
+	return names
+}
+
+func TestWorkflowEventToMetrics(t *testing.T) {
+	e := loadEvent(t, "workflow_completed.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	// The first event seeds the counters of the other statuses.
+	metrics := mh.workflowEventToMetrics(e)
+	require.Equal(t, map[string]int{
+		"workflows.count":    len(metadata.MapAttributeCiCircleciWorkflowStatus),
+		"workflows.duration": 1,
+	}, metricNames(metrics))
+
+	hist := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(1).Histogram().DataPoints().At(0)
+	require.Equal(t, 299.0, hist.Sum())
+
+	// The counters keep counting.
+	metrics = mh.workflowEventToMetrics(e)
+	dp := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, int64(2), dp.IntValue())
+	status, _ := dp.Attributes().Get("ci.circleci.workflow.status")
+	require.Equal(t, "failed", status.Str())
+	slug, _ := dp.Attributes().Get("ci.circleci.project.slug")
+	require.Equal(t, "gh/acme/web-app", slug.Str())
+}
+
+func TestJobEventToMetrics(t *testing.T) {
+	e := loadEvent(t, "job_completed.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	metrics := mh.jobEventToMetrics(e)
+	require.Equal(t, map[string]int{
+		"jobs.count":    len(metadata.MapAttributeCiCircleciJobStatus),
+		"jobs.duration": 1,
+	}, metricNames(metrics))
+
+	val, ok := mh.countersCache.Get("job:gh/acme/web-app:build-and-test:failed")
+	require.True(t, ok)
+	require.Equal(t, int64(1), val)
+
+	// Jobs that never ran are counted, without a duration.
+	e.Job.Status = "canceled"
+	e.Job.StartedAt = ""
+	require.Equal(t, map[string]int{"jobs.count": 1}, metricNames(mh.jobEventToMetrics(e)))
+}
+
+func TestEventToMetricsSemconv(t *testing.T) {
+	workflow := loadEvent(t, "workflow_completed.json")
+	job := loadEvent(t, "job_completed.json")
+
+	tests := []struct {
+		desc          string
+		semconv       semconv.Config
+		expectMetrics []string
+	}{
+		{
+			desc:          "Legacy metrics",
+			expectMetrics: []string{"workflows.count", "jobs.count", "workflows.duration", "jobs.duration"},
+		},
+		{
+			desc:          "Semantic conventions",
+			semconv:       semconv.Config{Enabled: true},
+			expectMetrics: []string{"workflows.count", "jobs.count", semconv.MetricCICDPipelineRunDuration, semconv.MetricCICDPipelineTaskRunDuration},
+		},
+		{
+			desc:    "Semantic conventions with legacy metrics",
+			semconv: semconv.Config{Enabled: true, EmitLegacy: true},
+			expectMetrics: []string{
+				"workflows.count", "jobs.count", "workflows.duration", "jobs.duration",
+				semconv.MetricCICDPipelineRunDuration, semconv.MetricCICDPipelineTaskRunDuration,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			mh := newTestMetricsHandler(t, &Config{Semconv: test.semconv})
+
+			names := metricNames(mh.workflowEventToMetrics(workflow))
+			for name, count := range metricNames(mh.jobEventToMetrics(job)) {
+				names[name] += count
+			}
+			require.Len(t, names, len(test.expectMetrics))
+			for _, name := range test.expectMetrics {
+				require.Contains(t, names, name)
+			}
+		})
+	}
+}
+
+func TestWorkflowEventToMetricsConcurrency(t *testing.T) {
+	e := loadEvent(t, "workflow_completed.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			mh.workflowEventToMetrics(e)
+		})
+	}
+	wg.Wait()
+
+	val, ok := mh.countersCache.Get("workflow:gh/acme/web-app:build-and-test:failed")
+	require.True(t, ok)
+	require.Equal(t, int64(50), val)
+}
+
+func TestObserveDurationStartsOver(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+
+	mh.observeDuration("key", 1)
+	require.Equal(t, uint64(2), mh.observeDuration("key", 1).Count)
+
+	// Histograms not observed within the TTL start over
+	h, _ := mh.histogramCache.Get("key")
+	h.LastSeen = time.Now().Add(-histogramTTL)
+	require.Equal(t, uint64(1), mh.observeDuration("key", 1).Count)
+}
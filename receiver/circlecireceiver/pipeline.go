@@ -0,0 +1,273 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+)
+
+// workflowRun maps the workflow of an event to the CI model. Workflows are
+// the runs of the model, as they are what CircleCI reports the status of;
+// the pipeline they belong to only groups them.
+func workflowRun(e *webhookEvent) cimodel.Pipeline {
+	w := e.Workflow
+
+	started := parseTime(w.CreatedAt)
+	finished := parseTime(w.StoppedAt)
+	if finished.IsZero() {
+		finished = started
+	}
+
+	return cimodel.Pipeline{
+		ID:         w.ID,
+		Name:       w.Name,
+		URL:        w.URL,
+		Result:     workflowResult(w.Status),
+		Status:     w.Status,
+		Started:    started,
+		Finished:   finished,
+		Repository: vcsRepository(e.Pipeline.VCS),
+		Ref:        vcsRef(e.Pipeline.VCS),
+		TraceID:    generateTraceID(e.Pipeline.ID),
+		SpanID:     generateWorkflowSpanID(w.ID),
+	}
+}
+
+// jobEventPipeline maps a job event to the CI model. The event only
+// describes one job, so the workflow has no span of its own and the job span
+// keeps the workflow span as its parent. The steps of the job are only known
+// from the API, details is nil without it.
+func jobEventPipeline(e *webhookEvent, details *jobDetails) cimodel.Pipeline {
+	pipeline := workflowRun(e)
+	pipeline.OmitSpan = true
+	pipeline.Tasks = []cimodel.Task{jobTask(e, details)}
+	return pipeline
+}
+
+func jobTask(e *webhookEvent, details *jobDetails) cimodel.Task {
+	j := e.Job
+
+	// Jobs canceled before they started have no start time.
+	started := parseTime(j.StartedAt)
+	if started.IsZero() {
+		started = parseTime(j.StoppedAt)
+	}
+	if started.IsZero() {
+		started = parseTime(e.HappenedAt)
+	}
+	finished := parseTime(j.StoppedAt)
+	if finished.IsZero() {
+		finished = started
+	}
+
+	task := cimodel.Task{
+		ID:       j.ID,
+		Name:     j.Name,
+		URL:      e.jobURL(),
+		Result:   jobResult(j.Status),
+		Status:   j.Status,
+		Started:  started,
+		Finished: finished,
+		SpanID:   generateJobSpanID(j.ID),
+	}
+	if details != nil {
+		task.Steps = jobSteps(j.ID, details, started)
+	}
+
+	return task
+}
+
+// jobSteps maps the steps of a job. Parallel jobs run each step once per
+// parallel run, so steps span all of their actions and fail when any of
+// them failed.
+func jobSteps(jobID string, details *jobDetails, jobStarted time.Time) []cimodel.Step {
+	steps := make([]cimodel.Step, 0, len(details.Steps))
+	for i := range details.Steps {
+		s := &details.Steps[i]
+
+		step := cimodel.Step{
+			Name:     s.Name,
+			Result:   cimodel.ResultUnknown,
+			Started:  jobStarted,
+			Finished: jobStarted,
+			SpanID:   generateStepSpanID(jobID, i),
+		}
+
+		var first, last time.Time
+		for _, action := range s.Actions {
+			if started := parseTime(action.StartTime); !started.IsZero() && (first.IsZero() || started.Before(first)) {
+				first = started
+			}
+			if ended := parseTime(action.EndTime); ended.After(last) {
+				last = ended
+			}
+			if step.Status == "" || step.Result == cimodel.ResultSuccess {
+				step.Result = jobResult(action.Status)
+				step.Status = action.Status
+			}
+		}
+		if !first.IsZero() {
+			step.Started = first
+			step.Finished = first
+		}
+		if last.After(step.Started) {
+			step.Finished = last
+		}
+
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+// pipelineRun maps the pipeline of a workflow event, given all of its
+// workflows, to a run without tasks whose span is the parent of the spans of
+// the workflows.
+func pipelineRun(e *webhookEvent, workflows []workflowItem) cimodel.Pipeline {
+	p := e.Pipeline
+
+	started := parseTime(p.CreatedAt)
+	finished := started
+	result, status := cimodel.ResultSuccess, "success"
+	for _, w := range workflows {
+		if stopped := parseTime(w.StoppedAt); stopped.After(finished) {
+			finished = stopped
+		}
+		// Failed workflows take precedence over canceled ones
+		if r := workflowResult(w.Status); (r.Failed() && !result.Failed()) || (r == cimodel.ResultCancellation && result == cimodel.ResultSuccess) {
+			result, status = r, w.Status
+		}
+	}
+
+	return cimodel.Pipeline{
+		ID:         strconv.FormatInt(p.Number, 10),
+		Name:       e.Project.Slug,
+		URL:        pipelineURL(e.Workflow.URL),
+		Result:     result,
+		Status:     status,
+		Started:    started,
+		Finished:   finished,
+		Repository: vcsRepository(p.VCS),
+		Ref:        vcsRef(p.VCS),
+		TraceID:    generateTraceID(p.ID),
+		SpanID:     generatePipelineSpanID(p.ID),
+	}
+}
+
+// pipelineComplete reports whether all the workflows of a pipeline stopped.
+func pipelineComplete(workflows []workflowItem) bool {
+	for _, w := range workflows {
+		if w.StoppedAt == "" {
+			return false
+		}
+	}
+	return len(workflows) > 0
+}
+
+// pipelineURL returns the URL of a pipeline, from the URL of one of its
+// workflows.
+func pipelineURL(workflowURL string) string {
+	if i := strings.Index(workflowURL, "/workflows/"); i >= 0 {
+		return workflowURL[:i]
+	}
+	return ""
+}
+
+// vcsRepository maps the repository a pipeline was triggered for.
+func vcsRepository(v *vcs) cimodel.Repository {
+	if v == nil || v.OriginRepositoryURL == "" {
+		return cimodel.Repository{}
+	}
+
+	u, err := url.Parse(v.OriginRepositoryURL)
+	repoPath := ""
+	if err == nil {
+		repoPath = strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	}
+	if err != nil || u.Host == "" || repoPath == "" {
+		return cimodel.Repository{URL: v.OriginRepositoryURL}
+	}
+
+	return cimodel.Repository{
+		Provider: vcsProvider(v.ProviderName),
+		Owner:    path.Dir(repoPath),
+		Name:     path.Base(repoPath),
+		URL:      u.Scheme + "://" + u.Host + "/" + repoPath,
+	}
+}
+
+// vcsProvider maps the name of the provider of a pipeline, such as GitHub.
+func vcsProvider(name string) string {
+	switch strings.ToLower(name) {
+	case "github":
+		return semconv.AttributeVCSProviderNameGithub
+	case "gitlab":
+		return semconv.AttributeVCSProviderNameGitlab
+	case "bitbucket":
+		return semconv.AttributeVCSProviderNameBitbucket
+	default:
+		return ""
+	}
+}
+
+func vcsRef(v *vcs) cimodel.Ref {
+	if v == nil {
+		return cimodel.Ref{}
+	}
+
+	ref := cimodel.Ref{Revision: v.Revision}
+	if v.Tag != "" {
+		ref.Head = v.Tag
+		ref.HeadType = semconv.AttributeVCSRefTypeTag
+	} else if v.Branch != "" {
+		ref.Head = v.Branch
+		ref.HeadType = semconv.AttributeVCSRefTypeBranch
+	}
+	return ref
+}
+
+// workflowResult maps the status of a completed workflow. Unauthorized
+// workflows used a restricted context.
+func workflowResult(status string) cimodel.Result {
+	switch status {
+	case "success":
+		return cimodel.ResultSuccess
+	case "failed":
+		return cimodel.ResultFailure
+	case "error", "unauthorized":
+		return cimodel.ResultError
+	case "canceled":
+		return cimodel.ResultCancellation
+	case "not_run":
+		return cimodel.ResultSkip
+	default:
+		return cimodel.ResultUnknown
+	}
+}
+
+// jobResult maps the status of a completed job or step.
+func jobResult(status string) cimodel.Result {
+	switch status {
+	case "success":
+		return cimodel.ResultSuccess
+	case "failed":
+		return cimodel.ResultFailure
+	case "timedout":
+		return cimodel.ResultTimeout
+	case "canceled":
+		return cimodel.ResultCancellation
+	case "unauthorized", "infrastructure_fail", "terminated-unknown":
+		return cimodel.ResultError
+	case "not_run":
+		return cimodel.ResultSkip
+	default:
+		return cimodel.ResultUnknown
+	}
+}
@@ -0,0 +1,455 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/circlecireceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+var errMissingEndpoint = errors.New("missing a receiver endpoint")
+
+// reportedPipelinesCacheSize bounds the pipelines whose span was reported,
+// so that it is reported once when workflows complete concurrently.
+const reportedPipelinesCacheSize = 10000
+
+type circleciReceiver struct {
+	logsConsumer      consumer.Logs
+	tracesConsumer    consumer.Traces
+	metricsConsumer   consumer.Metrics
+	metricsHandler    *metricsHandler
+	config            *Config
+	server            *http.Server
+	shutdownWG        sync.WaitGroup
+	createSettings    receiver.Settings
+	logger            *zap.Logger
+	obsrecv           *receiverhelper.ObsReport
+	client            *circleciClient
+	reportedPipelines *lru.Cache[string, struct{}]
+	telemetry         *metadata.TelemetryBuilder
+	logPolicy         *logpolicy.Policy
+	// events are the completed workflows and jobs whose traces and logs are
+	// reported in the background, as they are described by the API
+	events chan *webhookEvent
+	cancel context.CancelFunc
+}
+
+func newReceiver(
+	params receiver.Settings,
+	config *Config,
+) (*circleciReceiver, error) {
+	if config.NetAddr.Endpoint == "" {
+		return nil, errMissingEndpoint
+	}
+
+	transport := "http"
+	if config.TLS.HasValue() {
+		transport = "https"
+	}
+
+	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             params.ID,
+		Transport:              transport,
+		ReceiverCreateSettings: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Pipeline spans, steps and their output can only be read from the API.
+	var client *circleciClient
+	if config.CircleCIAPIConfig.enabled() {
+		client, err = newCircleCIClient(config.CircleCIAPIConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CircleCI client: %w", err)
+		}
+	}
+
+	reportedPipelines, err := lru.New[string, struct{}](reportedPipelinesCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize reported pipelines cache: %w", err)
+	}
+
+	telemetry, err := metadata.NewTelemetryBuilder(params.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+
+	logPolicy, err := logpolicy.New(config.Logs, logPolicyRecorder{telemetry: telemetry})
+	if err != nil {
+		return nil, err
+	}
+
+	metricsHandler, err := newMetricsHandler(params, config, params.Logger.Named("metricsHandler"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &circleciReceiver{
+		config:            config,
+		createSettings:    params,
+		logger:            params.Logger,
+		obsrecv:           obsrecv,
+		client:            client,
+		reportedPipelines: reportedPipelines,
+		telemetry:         telemetry,
+		logPolicy:         logPolicy,
+		metricsHandler:    metricsHandler,
+		events:            make(chan *webhookEvent, config.CircleCIAPIConfig.QueueSize),
+	}, nil
+}
+
+// newTracesReceiver creates a traces receiver based on provided config.
+func newTracesReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Traces,
+) (receiver.Traces, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*circleciReceiver).tracesConsumer = consumer
+
+	return r, nil
+}
+
+// newLogsReceiver creates a logs receiver based on provided config.
+func newLogsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Logs,
+) (receiver.Logs, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*circleciReceiver).logsConsumer = consumer
+
+	return r, nil
+}
+
+// newMetricsReceiver creates a metrics receiver based on provided config.
+func newMetricsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*circleciReceiver).metricsConsumer = consumer
+
+	return r, nil
+}
+
+func getOrAddReceiver(set receiver.Settings, cfg component.Config) (*sharedcomponent.SharedComponent, error) {
+	var err error
+	r := receivers.GetOrAdd(cfg, func() component.Component {
+		var rcv component.Component
+		rcv, err = newReceiver(set, cfg.(*Config))
+		return rcv
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// logPolicyRecorder reports the log lines affected by the log policy as
+// internal telemetry.
+type logPolicyRecorder struct {
+	telemetry *metadata.TelemetryBuilder
+}
+
+func (r logPolicyRecorder) RecordDroppedLines(n int64, reason string) {
+	r.telemetry.ReceiverLogsDroppedLines.Add(context.Background(), n, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+func (r logPolicyRecorder) RecordRedactedLines(n int64) {
+	r.telemetry.ReceiverLogsRedactedLines.Add(context.Background(), n)
+}
+
+func (r logPolicyRecorder) RecordOversizedEntries(n int64, behaviour string) {
+	r.telemetry.ReceiverLogsOversizedEntries.Add(context.Background(), n, metric.WithAttributes(attribute.String("behaviour", behaviour)))
+}
+
+func (cr *circleciReceiver) Start(_ context.Context, _ component.Host) error {
+	endpoint := fmt.Sprintf("%s%s", cr.config.NetAddr.Endpoint, cr.config.Path)
+	cr.logger.Info("Starting CircleCI server", zap.String("endpoint", endpoint))
+	cr.server = &http.Server{
+		Addr:              cr.config.NetAddr.Endpoint,
+		Handler:           cr,
+		ReadHeaderTimeout: 20 * time.Second,
+	}
+
+	cr.shutdownWG.Add(1)
+	go func() {
+		defer cr.shutdownWG.Done()
+
+		if errHTTP := cr.server.ListenAndServe(); !errors.Is(errHTTP, http.ErrServerClosed) && errHTTP != nil {
+			cr.createSettings.Logger.Error("Server closed with error", zap.Error(errHTTP))
+		}
+	}()
+
+	var ctx context.Context
+	ctx, cr.cancel = context.WithCancel(context.Background())
+	cr.shutdownWG.Add(1)
+	go func() {
+		defer cr.shutdownWG.Done()
+		cr.processEvents(ctx)
+	}()
+
+	return nil
+}
+
+// Shutdown stops the server and the requests to the API. Workflows and jobs
+// waiting to be described are dropped.
+func (cr *circleciReceiver) Shutdown(ctx context.Context) error {
+	var err error
+	if cr.server != nil {
+		err = cr.server.Close()
+	}
+	if cr.cancel != nil {
+		cr.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		cr.shutdownWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		cr.logger.Warn("Stopped waiting for the event being reported", zap.Error(ctx.Err()))
+	}
+	if n := len(cr.events); n > 0 {
+		cr.logger.Warn("Dropping workflows and jobs waiting to be described", zap.Int("events", n))
+	}
+	cr.telemetry.Shutdown()
+	return err
+}
+
+func (cr *circleciReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Validate request path
+	if r.URL.Path != cr.config.Path {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		cr.logger.Debug("Failed to read payload", zap.Error(err))
+		http.Error(w, "Failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	// Validate the signature computed with the secret of the webhook
+	if cr.config.Secret != "" {
+		if err := validateSignature(r.Header.Get(signatureHeader), payload, cr.config.Secret); err != nil {
+			cr.logger.Debug("Webhook signature validation failed", zap.Error(err))
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	// Determine the type of CircleCI webhook event and ensure it's one we handle
+	eventType := r.Header.Get(eventTypeHeader)
+	if eventType != eventWorkflowCompleted && eventType != eventJobCompleted {
+		cr.logger.Debug("Skipping unsupported event type", zap.String("event", eventType))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	e, err := parseWebhookEvent(eventType, payload)
+	if err != nil {
+		cr.logger.Debug("Webhook parsing failed", zap.Error(err))
+		http.Error(w, "Failed to parse webhook", http.StatusBadRequest)
+		return
+	}
+
+	// Metrics don't need the API. CircleCI doesn't redeliver rejected
+	// webhooks, so they are counted even when the queue is full.
+	if cr.metricsConsumer != nil {
+		switch eventType {
+		case eventWorkflowCompleted:
+			cr.consumeMetrics(ctx, cr.metricsHandler.workflowEventToMetrics(e))
+		case eventJobCompleted:
+			cr.consumeMetrics(ctx, cr.metricsHandler.jobEventToMetrics(e))
+		}
+	}
+
+	if cr.tracesConsumer != nil || (eventType == eventJobCompleted && cr.logsConsumer != nil) {
+		select {
+		case cr.events <- e:
+		default:
+			cr.logger.Warn("Too many workflows and jobs waiting to be described, dropping",
+				zap.String("event", eventType),
+				zap.String("project", e.Project.Slug),
+				zap.Int64("pipeline", e.Pipeline.Number),
+			)
+			http.Error(w, "too many workflows and jobs waiting to be described", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// processEvents reports the traces and logs of the queued events, until ctx
+// is canceled.
+func (cr *circleciReceiver) processEvents(ctx context.Context) {
+	for {
+		select {
+		case e := <-cr.events:
+			cr.processEvent(ctx, e)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// processEvent describes the workflow or job of an event with the API, and
+// reports its traces and logs. Events whose requests are canceled by
+// shutdown are still reported with what was read.
+func (cr *circleciReceiver) processEvent(ctx context.Context, e *webhookEvent) {
+	switch e.Type {
+	case eventWorkflowCompleted:
+		if cr.tracesConsumer != nil {
+			td := workflowEventToTraces(e, cr.completedPipelineWorkflows(ctx, e), cr.config, cr.logger.Named("workflowEventToTraces"))
+			cr.consumeTraces(context.WithoutCancel(ctx), td)
+		}
+	case eventJobCompleted:
+		details := cr.jobDetails(ctx, e)
+
+		if cr.tracesConsumer != nil {
+			td := jobEventToTraces(e, details, cr.config, cr.logger.Named("jobEventToTraces"))
+			cr.consumeTraces(context.WithoutCancel(ctx), td)
+		}
+
+		if cr.logsConsumer != nil {
+			cr.consumeJobLogs(ctx, e, details)
+		}
+	}
+}
+
+// completedPipelineWorkflows returns the workflows of the pipeline of a
+// workflow event once all of them completed, so that the span of the
+// pipeline is reported with its last workflow. It returns nil when the
+// pipeline is still running, was already reported, or when the API is not
+// configured.
+func (cr *circleciReceiver) completedPipelineWorkflows(ctx context.Context, e *webhookEvent) []workflowItem {
+	if cr.client == nil {
+		return nil
+	}
+
+	workflows, err := cr.client.pipelineWorkflows(ctx, e.Pipeline.ID)
+	if err != nil {
+		cr.logger.Error("Failed to list workflows of pipeline", zap.String("project", e.Project.Slug), zap.Int64("pipeline", e.Pipeline.Number), zap.Error(err))
+		return nil
+	}
+
+	// The API may not list the workflow of the event as stopped yet.
+	for i := range workflows {
+		if workflows[i].ID == e.Workflow.ID {
+			workflows[i].Status = e.Workflow.Status
+			workflows[i].StoppedAt = e.Workflow.StoppedAt
+		}
+	}
+
+	if !pipelineComplete(workflows) {
+		return nil
+	}
+	if found, _ := cr.reportedPipelines.ContainsOrAdd(e.Pipeline.ID, struct{}{}); found {
+		return nil
+	}
+	return workflows
+}
+
+// jobDetails describes the steps of the job of an event, returning nil when
+// the API is not configured or fails to describe it.
+func (cr *circleciReceiver) jobDetails(ctx context.Context, e *webhookEvent) *jobDetails {
+	if cr.client == nil || (cr.tracesConsumer == nil && cr.logsConsumer == nil) {
+		return nil
+	}
+
+	details, err := cr.client.jobDetails(ctx, e.Project.Slug, e.Job.Number)
+	if err != nil {
+		cr.logger.Error("Failed to describe job", zap.String("project", e.Project.Slug), zap.Int64("number", e.Job.Number), zap.Error(err))
+		return nil
+	}
+	return details
+}
+
+func (cr *circleciReceiver) consumeTraces(ctx context.Context, td ptrace.Traces) {
+	tracesCtx := cr.obsrecv.StartTracesOp(ctx)
+	err := cr.tracesConsumer.ConsumeTraces(tracesCtx, td)
+	cr.obsrecv.EndTracesOp(tracesCtx, metadata.Type.String(), td.SpanCount(), err)
+	if err != nil {
+		cr.logger.Error("Failed to consume traces", zap.Error(err))
+	}
+}
+
+func (cr *circleciReceiver) consumeMetrics(ctx context.Context, md pmetric.Metrics) {
+	metricsCtx := cr.obsrecv.StartMetricsOp(ctx)
+	err := cr.metricsConsumer.ConsumeMetrics(metricsCtx, md)
+	cr.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), md.DataPointCount(), err)
+	if err != nil {
+		cr.logger.Error("Failed to consume metrics", zap.Error(err))
+	}
+}
+
+func (cr *circleciReceiver) consumeJobLogs(ctx context.Context, e *webhookEvent, details *jobDetails) {
+	// The output of steps can only be read from the API.
+	if details == nil {
+		cr.logger.Debug("CircleCI API not configured or job not described, logs will not be processed.")
+		return
+	}
+
+	ld, err := jobEventToLogs(ctx, e, details, cr.config, cr.client, cr.logPolicy, cr.logger.Named("jobEventToLogs"))
+	if err != nil {
+		cr.logger.Error("Failed to process logs", zap.Error(err))
+		return
+	}
+	if ld == nil {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	logsCtx := cr.obsrecv.StartLogsOp(ctx)
+	err = cr.logsConsumer.ConsumeLogs(logsCtx, *ld)
+	cr.obsrecv.EndLogsOp(logsCtx, metadata.Type.String(), ld.LogRecordCount(), err)
+	if err != nil {
+		cr.logger.Error("Failed to consume logs", zap.Error(err))
+	}
+}
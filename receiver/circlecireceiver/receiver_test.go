@@ -0,0 +1,269 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+func TestNewReceiver(t *testing.T) {
+	defaultConfig := createDefaultConfig().(*Config)
+	withToken := createDefaultConfig().(*Config)
+	withToken.CircleCIAPIConfig.Token = "api-token"
+
+	tests := []struct {
+		desc         string
+		config       Config
+		expectClient bool
+		err          error
+	}{
+		{
+			desc:   "Default config succeeds",
+			config: *defaultConfig,
+		},
+		{
+			desc:         "Token creates a CircleCI client",
+			config:       *withToken,
+			expectClient: true,
+		},
+		{
+			desc:   "Missing endpoint fails",
+			config: Config{},
+			err:    errMissingEndpoint,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), &test.config)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectClient, rec.client != nil)
+			require.NoError(t, rec.Shutdown(context.Background()))
+		})
+	}
+}
+
+func newTestReceiver(t *testing.T) (*circleciReceiver, *consumertest.TracesSink, *consumertest.LogsSink, *consumertest.MetricsSink) {
+	t.Helper()
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.Secret = "mysecret"
+	cfg.CircleCIAPIConfig.Token = "api-token"
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, rec.Shutdown(context.Background())) })
+
+	tracesSink := new(consumertest.TracesSink)
+	logsSink := new(consumertest.LogsSink)
+	metricsSink := new(consumertest.MetricsSink)
+	rec.tracesConsumer = tracesSink
+	rec.logsConsumer = logsSink
+	rec.metricsConsumer = metricsSink
+	rec.client = newCircleCITestServer(t)
+	return rec, tracesSink, logsSink, metricsSink
+}
+
+func serveEvent(rec *circleciReceiver, path, eventType, signature string, payload []byte) int {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set(eventTypeHeader, eventType)
+	if signature != "" {
+		req.Header.Set(signatureHeader, signature)
+	}
+	w := httptest.NewRecorder()
+
+	rec.ServeHTTP(w, req)
+	// Traces and logs are reported in the background
+	for len(rec.events) > 0 {
+		rec.processEvent(context.Background(), <-rec.events)
+	}
+	return w.Code
+}
+
+func TestServeHTTP(t *testing.T) {
+	workflowPayload, err := os.ReadFile(filepath.Join("testdata", "workflow_completed.json"))
+	require.NoError(t, err)
+	jobPayload, err := os.ReadFile(filepath.Join("testdata", "job_completed.json"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc          string
+		path          string
+		event         string
+		signature     string
+		payload       []byte
+		expectStatus  int
+		expectSpans   int
+		expectLogs    int
+		expectMetrics bool
+	}{
+		{
+			desc:         "Unknown path",
+			path:         "/other",
+			event:        eventWorkflowCompleted,
+			signature:    sign("mysecret", workflowPayload),
+			payload:      workflowPayload,
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			desc:         "Invalid signature",
+			event:        eventWorkflowCompleted,
+			signature:    sign("wrong", workflowPayload),
+			payload:      workflowPayload,
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:         "Unsupported event",
+			event:        "ping",
+			signature:    sign("mysecret", []byte(`{}`)),
+			payload:      []byte(`{}`),
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			desc:         "Invalid payload",
+			event:        eventWorkflowCompleted,
+			signature:    sign("mysecret", []byte(`{`)),
+			payload:      []byte(`{`),
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			desc:          "Completed workflow",
+			event:         eventWorkflowCompleted,
+			signature:     sign("mysecret", workflowPayload),
+			payload:       workflowPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   2,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Completed job",
+			event:         eventJobCompleted,
+			signature:     sign("mysecret", jobPayload),
+			payload:       jobPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   4,
+			expectLogs:    17,
+			expectMetrics: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			rec, tracesSink, logsSink, metricsSink := newTestReceiver(t)
+
+			path := test.path
+			if path == "" {
+				path = rec.config.Path
+			}
+
+			require.Equal(t, test.expectStatus, serveEvent(rec, path, test.event, test.signature, test.payload))
+			require.Equal(t, test.expectSpans, tracesSink.SpanCount())
+			require.Equal(t, test.expectLogs, logsSink.LogRecordCount())
+			require.Equal(t, test.expectMetrics, len(metricsSink.AllMetrics()) > 0)
+		})
+	}
+}
+
+func TestServeHTTPPipelineReportedOnce(t *testing.T) {
+	payload, err := os.ReadFile(filepath.Join("testdata", "workflow_completed.json"))
+	require.NoError(t, err)
+	rec, tracesSink, _, _ := newTestReceiver(t)
+
+	// Redelivered webhooks report the workflow again, but not its pipeline.
+	for range 2 {
+		require.Equal(t, http.StatusAccepted, serveEvent(rec, rec.config.Path, eventWorkflowCompleted, sign("mysecret", payload), payload))
+	}
+	require.Equal(t, 3, tracesSink.SpanCount())
+}
+
+func TestServeHTTPQueue(t *testing.T) {
+	payload, err := os.ReadFile(filepath.Join("testdata", "job_completed.json"))
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.CircleCIAPIConfig.QueueSize = 1
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	rec.tracesConsumer = new(consumertest.TracesSink)
+	metricsSink := new(consumertest.MetricsSink)
+	rec.metricsConsumer = metricsSink
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, cfg.Path, bytes.NewReader(payload))
+		req.Header.Set(eventTypeHeader, eventJobCompleted)
+		w := httptest.NewRecorder()
+		rec.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusAccepted, send())
+	// Events are rejected while the queue is full, but still counted as
+	// CircleCI doesn't redeliver them
+	require.Equal(t, http.StatusServiceUnavailable, send())
+	require.Len(t, rec.events, 1)
+	require.Len(t, metricsSink.AllMetrics(), 2)
+}
+
+func TestShutdownCancelsAPIRequests(t *testing.T) {
+	// The API never answers
+	requested := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	payload, err := os.ReadFile(filepath.Join("testdata", "job_completed.json"))
+	require.NoError(t, err)
+	e, err := parseWebhookEvent(eventJobCompleted, payload)
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.NetAddr.Endpoint = "127.0.0.1:0"
+	cfg.CircleCIAPIConfig.BaseURL = server.URL
+	cfg.CircleCIAPIConfig.Token = "api-token"
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	tracesSink := new(consumertest.TracesSink)
+	rec.tracesConsumer = tracesSink
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	rec.events <- e
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "job was not described")
+	}
+
+	// Shutdown doesn't wait for the timeout of the request
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	started := time.Now()
+	require.NoError(t, rec.Shutdown(ctx))
+	require.Less(t, time.Since(started), time.Second)
+
+	// The job is reported without its steps
+	require.Equal(t, 1, tracesSink.SpanCount())
+}
@@ -0,0 +1,280 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeName    = "circlecireceiver"
+	scopeVersion = "0.1.0"
+)
+
+// legacyResourceAttributes, legacyWorkflowAttributes and legacyJobAttributes
+// are the attributes replaced by the semantic conventions. Attributes
+// without an equivalent keep their names.
+var legacyResourceAttributes = []string{
+	"ci.circleci.vcs.origin_repository_url",
+}
+
+var legacyWorkflowAttributes = []string{
+	"ci.circleci.workflow.id",
+	"ci.circleci.workflow.name",
+	"ci.circleci.workflow.url",
+	"ci.circleci.workflow.status",
+	"ci.circleci.vcs.branch",
+	"ci.circleci.vcs.tag",
+	"ci.circleci.vcs.revision",
+}
+
+var legacyJobAttributes = []string{
+	"ci.circleci.job.id",
+	"ci.circleci.job.name",
+	"ci.circleci.job.url",
+	"ci.circleci.job.status",
+	"ci.circleci.workflow.id",
+	"ci.circleci.workflow.name",
+}
+
+// workflowEventToTraces translates a completed workflow to its span. The
+// span of the pipeline of the workflow is added once all of its workflows
+// completed, workflows is nil when it is not reported.
+func workflowEventToTraces(e *webhookEvent, workflows []workflowItem, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing workflow",
+		zap.String("project", e.Project.Slug),
+		zap.Int64("pipeline", e.Pipeline.Number),
+		zap.String("workflow", e.Workflow.Name),
+		zap.String("status", e.Workflow.Status),
+	)
+
+	pipeline := workflowEventToPipeline(e, config)
+	traces := cimodel.ToTraces(&pipeline, traceOptions(config))
+
+	if pipelineComplete(workflows) {
+		run := pipelineRun(e, workflows)
+		run.ResourceAttributes = resourceAttributes(e, config)
+		run.Attributes = pipelineAttributes(e)
+		removeLegacyAttributes(&run, config.Semconv)
+		cimodel.ToTraces(&run, traceOptions(config)).ResourceSpans().MoveAndAppendTo(traces.ResourceSpans())
+	}
+
+	return traces
+}
+
+// jobEventToTraces translates a completed job to its span, with a span per
+// step when the job was described by the API.
+func jobEventToTraces(e *webhookEvent, details *jobDetails, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing job",
+		zap.String("project", e.Project.Slug),
+		zap.String("workflow", e.Workflow.Name),
+		zap.String("job", e.Job.Name),
+		zap.Int64("number", e.Job.Number),
+		zap.String("status", e.Job.Status),
+	)
+
+	pipeline := jobEventToPipeline(e, details, config)
+	return cimodel.ToTraces(&pipeline, traceOptions(config))
+}
+
+// workflowEventToPipeline maps a workflow event to the CI model, with the
+// attributes of its resource and span. The span of the workflow is under
+// the span of its pipeline when the API is used to report it.
+func workflowEventToPipeline(e *webhookEvent, config *Config) cimodel.Pipeline {
+	pipeline := workflowRun(e)
+	if config.CircleCIAPIConfig.enabled() {
+		pipeline.ParentSpanID = generatePipelineSpanID(e.Pipeline.ID)
+	}
+	pipeline.ResourceAttributes = resourceAttributes(e, config)
+	pipeline.Attributes = workflowAttributes(e)
+
+	removeLegacyAttributes(&pipeline, config.Semconv)
+	return pipeline
+}
+
+// jobEventToPipeline maps a job event to the CI model, with the attributes
+// of its resource, job and steps.
+func jobEventToPipeline(e *webhookEvent, details *jobDetails, config *Config) cimodel.Pipeline {
+	pipeline := jobEventPipeline(e, details)
+	pipeline.ResourceAttributes = resourceAttributes(e, config)
+
+	task := &pipeline.Tasks[0]
+	task.Attributes = jobAttributes(e, details)
+	for i := range task.Steps {
+		task.Steps[i].Attributes = stepAttributes(&details.Steps[i])
+	}
+
+	removeLegacyAttributes(&pipeline, config.Semconv)
+	return pipeline
+}
+
+func resourceAttributes(e *webhookEvent, config *Config) map[string]any {
+	attrs := map[string]any{
+		"service.name":             generateServiceName(config, e.Project.Slug),
+		"ci.circleci.project.slug": e.Project.Slug,
+		"ci.circleci.project.name": e.Project.Name,
+	}
+	if e.Organization.Name != "" {
+		attrs["ci.circleci.organization.name"] = e.Organization.Name
+	}
+	if v := e.Pipeline.VCS; v != nil && v.OriginRepositoryURL != "" {
+		attrs["ci.circleci.vcs.origin_repository_url"] = v.OriginRepositoryURL
+	}
+	return attrs
+}
+
+func pipelineAttributes(e *webhookEvent) map[string]any {
+	attrs := map[string]any{
+		"ci.circleci.pipeline.id":           e.Pipeline.ID,
+		"ci.circleci.pipeline.number":       e.Pipeline.Number,
+		"ci.circleci.pipeline.trigger.type": e.Pipeline.Trigger.Type,
+	}
+	if v := e.Pipeline.VCS; v != nil {
+		attrs["ci.circleci.vcs.revision"] = v.Revision
+		if v.Tag != "" {
+			attrs["ci.circleci.vcs.tag"] = v.Tag
+		} else if v.Branch != "" {
+			attrs["ci.circleci.vcs.branch"] = v.Branch
+		}
+	}
+	return attrs
+}
+
+func workflowAttributes(e *webhookEvent) map[string]any {
+	w := e.Workflow
+
+	attrs := pipelineAttributes(e)
+	attrs["ci.circleci.workflow.id"] = w.ID
+	attrs["ci.circleci.workflow.name"] = w.Name
+	attrs["ci.circleci.workflow.url"] = w.URL
+	attrs["ci.circleci.workflow.status"] = w.Status
+	return attrs
+}
+
+func jobAttributes(e *webhookEvent, details *jobDetails) map[string]any {
+	j := e.Job
+
+	attrs := map[string]any{
+		"ci.circleci.job.id":        j.ID,
+		"ci.circleci.job.name":      j.Name,
+		"ci.circleci.job.number":    j.Number,
+		"ci.circleci.job.status":    j.Status,
+		"ci.circleci.workflow.id":   e.Workflow.ID,
+		"ci.circleci.workflow.name": e.Workflow.Name,
+	}
+	if url := e.jobURL(); url != "" {
+		attrs["ci.circleci.job.url"] = url
+	}
+
+	if details == nil {
+		return attrs
+	}
+	if details.Parallel > 1 {
+		attrs["ci.circleci.job.parallelism"] = details.Parallel
+	}
+	if p := details.Picard; p != nil {
+		if p.Executor != "" {
+			attrs["ci.circleci.job.executor"] = p.Executor
+		}
+		if p.ResourceClass.Class != "" {
+			attrs["ci.circleci.job.resource_class"] = p.ResourceClass.Class
+		}
+	}
+	queued, started := parseTime(details.QueuedAt), parseTime(details.StartTime)
+	if !queued.IsZero() && !started.IsZero() && !started.Before(queued) {
+		attrs["ci.circleci.job.queued_duration"] = started.Sub(queued).Seconds()
+	}
+	return attrs
+}
+
+func stepAttributes(s *stepDetails) map[string]any {
+	attrs := map[string]any{}
+	// The exit code of parallel steps is the one of their first failed run.
+	for _, action := range s.Actions {
+		if action.ExitCode != nil {
+			attrs["ci.circleci.step.exit_code"] = int64(*action.ExitCode)
+			if *action.ExitCode != 0 {
+				break
+			}
+		}
+	}
+	return attrs
+}
+
+// removeLegacyAttributes removes the attributes replaced by the semantic
+// conventions, unless the legacy ones are emitted.
+func removeLegacyAttributes(p *cimodel.Pipeline, cfg semconv.Config) {
+	if cfg.EmitsLegacy() {
+		return
+	}
+
+	for _, key := range legacyResourceAttributes {
+		delete(p.ResourceAttributes, key)
+	}
+	for _, key := range legacyWorkflowAttributes {
+		delete(p.Attributes, key)
+	}
+	for i := range p.Tasks {
+		for _, key := range legacyJobAttributes {
+			delete(p.Tasks[i].Attributes, key)
+		}
+	}
+}
+
+func traceOptions(config *Config) cimodel.Options {
+	return cimodel.Options{
+		ScopeName:    scopeName,
+		ScopeVersion: scopeVersion,
+		Semconv:      config.Semconv,
+	}
+}
+
+// generateServiceName derives the service name from the slug of a project,
+// without its VCS prefix, such as acme-web-app for gh/acme/web-app.
+func generateServiceName(config *Config, projectSlug string) string {
+	if config.CustomServiceName != "" {
+		return config.CustomServiceName
+	}
+	name := projectSlug
+	if _, rest, ok := strings.Cut(projectSlug, "/"); ok {
+		name = rest
+	}
+	formattedName := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(name, "/", "-"), "_", "-"))
+	return fmt.Sprintf("%s%s%s", config.ServiceNamePrefix, formattedName, config.ServiceNameSuffix)
+}
+
+func generateTraceID(pipelineID string) pcommon.TraceID {
+	hash := sha256.Sum256([]byte(pipelineID + "t"))
+	return pcommon.TraceID(hash[:16])
+}
+
+func generatePipelineSpanID(pipelineID string) pcommon.SpanID {
+	return generateSpanID(pipelineID + "p")
+}
+
+func generateWorkflowSpanID(workflowID string) pcommon.SpanID {
+	return generateSpanID(workflowID + "w")
+}
+
+func generateJobSpanID(jobID string) pcommon.SpanID {
+	return generateSpanID(jobID + "j")
+}
+
+func generateStepSpanID(jobID string, step int) pcommon.SpanID {
+	return generateSpanID(jobID + "s" + strconv.Itoa(step))
+}
+
+func generateSpanID(input string) pcommon.SpanID {
+	hash := sha256.Sum256([]byte(input))
+	return pcommon.SpanID(hash[:8])
+}
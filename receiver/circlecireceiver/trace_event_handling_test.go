@@ -0,0 +1,247 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package circlecireceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap/zaptest"
+)
+
+func spansByName(traces ptrace.Traces) map[string]ptrace.Span {
+	byName := map[string]ptrace.Span{}
+	for i := range traces.ResourceSpans().Len() {
+		spans := traces.ResourceSpans().At(i).ScopeSpans().At(0).Spans()
+		for j := range spans.Len() {
+			byName[spans.At(j).Name()] = spans.At(j)
+		}
+	}
+	return byName
+}
+
+func at(minute, second int) time.Time {
+	return time.Date(2024, 3, 5, 10, minute, second, 0, time.UTC)
+}
+
+// testAPIConfig is the configuration of a receiver using the API.
+func testAPIConfig() *Config {
+	return &Config{CircleCIAPIConfig: CircleCIAPIConfig{BaseURL: defaultBaseURL, Token: "api-token"}}
+}
+
+func TestWorkflowEventToTraces(t *testing.T) {
+	e := loadEvent(t, "workflow_completed.json")
+
+	traces := workflowEventToTraces(e, nil, &Config{}, zaptest.NewLogger(t))
+	require.Equal(t, 1, traces.SpanCount())
+
+	resource := traces.ResourceSpans().At(0).Resource().Attributes()
+	serviceName, _ := resource.Get("service.name")
+	require.Equal(t, "acme-web-app", serviceName.Str())
+	slug, _ := resource.Get("ci.circleci.project.slug")
+	require.Equal(t, "gh/acme/web-app", slug.Str())
+
+	workflow := spansByName(traces)["build-and-test"]
+	require.Equal(t, "96925fc48b0608b307e6b2031f5cd72c", workflow.TraceID().String())
+	require.Equal(t, "8dded4b5ad797f46", workflow.SpanID().String())
+	// Without the API, workflows are root spans.
+	require.True(t, workflow.ParentSpanID().IsEmpty())
+	require.Equal(t, ptrace.StatusCodeError, workflow.Status().Code())
+	require.Equal(t, at(0, 1), workflow.StartTimestamp().AsTime())
+	require.Equal(t, at(5, 0), workflow.EndTimestamp().AsTime())
+	number, _ := workflow.Attributes().Get("ci.circleci.pipeline.number")
+	require.EqualValues(t, 130, number.Int())
+	branch, _ := workflow.Attributes().Get("ci.circleci.vcs.branch")
+	require.Equal(t, "main", branch.Str())
+}
+
+func TestWorkflowEventToTracesPipeline(t *testing.T) {
+	e := loadEvent(t, "workflow_completed.json")
+	workflows, err := newCircleCITestServer(t).pipelineWorkflows(t.Context(), testPipelineID)
+	require.NoError(t, err)
+
+	traces := workflowEventToTraces(e, workflows, testAPIConfig(), zaptest.NewLogger(t))
+	require.Equal(t, 2, traces.SpanCount())
+
+	spans := spansByName(traces)
+	pipeline := spans["gh/acme/web-app"]
+	require.Equal(t, "38597f74e17510f0", pipeline.SpanID().String())
+	require.True(t, pipeline.ParentSpanID().IsEmpty())
+	// The pipeline failed with its build-and-test workflow, and lasted
+	// until its last workflow stopped.
+	require.Equal(t, ptrace.StatusCodeError, pipeline.Status().Code())
+	require.Equal(t, "failed", pipeline.Status().Message())
+	require.Equal(t, at(0, 0), pipeline.StartTimestamp().AsTime())
+	require.Equal(t, at(5, 0), pipeline.EndTimestamp().AsTime())
+
+	workflow := spans["build-and-test"]
+	require.Equal(t, pipeline.TraceID(), workflow.TraceID())
+	require.Equal(t, pipeline.SpanID(), workflow.ParentSpanID())
+
+	// Workflows keep the pipeline span as their parent while it runs.
+	traces = workflowEventToTraces(e, nil, testAPIConfig(), zaptest.NewLogger(t))
+	require.Equal(t, 1, traces.SpanCount())
+	require.Equal(t, pipeline.SpanID(), spansByName(traces)["build-and-test"].ParentSpanID())
+}
+
+func TestPipelineRunResult(t *testing.T) {
+	e := loadEvent(t, "workflow_completed.json")
+
+	// Canceled workflows cancel the pipeline, unless another workflow failed
+	p := pipelineRun(e, []workflowItem{{Status: "success"}, {Status: "canceled"}})
+	require.Equal(t, cimodel.ResultCancellation, p.Result)
+	p = pipelineRun(e, []workflowItem{{Status: "canceled"}, {Status: "failed"}})
+	require.Equal(t, cimodel.ResultFailure, p.Result)
+}
+
+func TestJobEventToTraces(t *testing.T) {
+	e := loadEvent(t, "job_completed.json")
+
+	traces := jobEventToTraces(e, nil, &Config{}, zaptest.NewLogger(t))
+	require.Equal(t, 1, traces.SpanCount())
+
+	job := spansByName(traces)["test"]
+	require.Equal(t, "96925fc48b0608b307e6b2031f5cd72c", job.TraceID().String())
+	require.Equal(t, "2d1dd9f44e7e7068", job.SpanID().String())
+	require.Equal(t, "8dded4b5ad797f46", job.ParentSpanID().String())
+	require.Equal(t, ptrace.StatusCodeError, job.Status().Code())
+	require.Equal(t, at(1, 0), job.StartTimestamp().AsTime())
+	require.Equal(t, at(4, 0), job.EndTimestamp().AsTime())
+	url, _ := job.Attributes().Get("ci.circleci.job.url")
+	require.Equal(t, "https://app.circleci.com/pipelines/github/acme/web-app/130/workflows/fda08377-fe7e-46b1-8992-3a7aaecac9c3/jobs/131", url.Str())
+}
+
+func TestJobEventToTracesSteps(t *testing.T) {
+	e := loadEvent(t, "job_completed.json")
+	details, err := newCircleCITestServer(t).jobDetails(t.Context(), e.Project.Slug, e.Job.Number)
+	require.NoError(t, err)
+
+	traces := jobEventToTraces(e, details, testAPIConfig(), zaptest.NewLogger(t))
+	// The job and its 3 steps.
+	require.Equal(t, 4, traces.SpanCount())
+
+	spans := spansByName(traces)
+	job := spans["test"]
+	queued, _ := job.Attributes().Get("ci.circleci.job.queued_duration")
+	require.Equal(t, 10.0, queued.Double())
+	resourceClass, _ := job.Attributes().Get("ci.circleci.job.resource_class")
+	require.Equal(t, "medium", resourceClass.Str())
+	parallelism, _ := job.Attributes().Get("ci.circleci.job.parallelism")
+	require.EqualValues(t, 2, parallelism.Int())
+
+	steps := map[string]struct {
+		start, end time.Time
+		code       ptrace.StatusCode
+	}{
+		"Spin up environment": {at(1, 0), at(1, 22), ptrace.StatusCodeOk},
+		"Checkout code":       {at(1, 22), at(1, 25), ptrace.StatusCodeOk},
+		// Parallel steps span all of their runs, and fail with any of them.
+		"Run tests": {at(1, 25), at(4, 0), ptrace.StatusCodeError},
+	}
+	for name, expected := range steps {
+		step := spans[name]
+		require.Equal(t, job.SpanID(), step.ParentSpanID(), name)
+		require.Equal(t, expected.start, step.StartTimestamp().AsTime(), name)
+		require.Equal(t, expected.end, step.EndTimestamp().AsTime(), name)
+		require.Equal(t, expected.code, step.Status().Code(), name)
+	}
+
+	tests := spans["Run tests"]
+	require.Equal(t, "98b2ea540818884c", tests.SpanID().String())
+	exitCode, _ := tests.Attributes().Get("ci.circleci.step.exit_code")
+	require.EqualValues(t, 1, exitCode.Int())
+}
+
+func TestJobEventToTracesSemconv(t *testing.T) {
+	e := loadEvent(t, "job_completed.json")
+
+	tests := []struct {
+		desc         string
+		semconv      semconv.Config
+		expectLegacy bool
+	}{
+		{
+			desc:         "Semantic conventions with legacy attributes",
+			semconv:      semconv.Config{Enabled: true, EmitLegacy: true},
+			expectLegacy: true,
+		},
+		{
+			desc:         "Semantic conventions only",
+			semconv:      semconv.Config{Enabled: true},
+			expectLegacy: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			traces := jobEventToTraces(e, nil, &Config{Semconv: test.semconv}, zaptest.NewLogger(t))
+
+			resource := traces.ResourceSpans().At(0).Resource().Attributes()
+			provider, _ := resource.Get(semconv.AttributeVCSProviderName)
+			require.Equal(t, semconv.AttributeVCSProviderNameGithub, provider.Str())
+			repoURL, _ := resource.Get(semconv.AttributeVCSRepositoryURLFull)
+			require.Equal(t, "https://github.com/acme/web-app", repoURL.Str())
+			_, ok := resource.Get("ci.circleci.vcs.origin_repository_url")
+			require.Equal(t, test.expectLegacy, ok)
+
+			job := spansByName(traces)["test"].Attributes()
+			pipelineName, _ := job.Get(semconv.AttributeCICDPipelineName)
+			require.Equal(t, "build-and-test", pipelineName.Str())
+			taskID, _ := job.Get(semconv.AttributeCICDPipelineTaskRunID)
+			require.Equal(t, "8bd26bc8-1b55-4fbd-8a8f-0e4bf7e1a0a2", taskID.Str())
+			result, _ := job.Get(semconv.AttributeCICDPipelineTaskRunResult)
+			require.Equal(t, "failure", result.Str())
+			_, ok = job.Get("ci.circleci.job.status")
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = job.Get("ci.circleci.job.number")
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestVCSRepository(t *testing.T) {
+	tests := map[string]struct {
+		vcs      *vcs
+		expect   string
+		owner    string
+		provider string
+	}{
+		"github":    {vcs: &vcs{ProviderName: "GitHub", OriginRepositoryURL: "https://github.com/acme/web-app"}, expect: "https://github.com/acme/web-app", owner: "acme", provider: semconv.AttributeVCSProviderNameGithub},
+		"gitlab":    {vcs: &vcs{ProviderName: "GitLab", OriginRepositoryURL: "https://gitlab.com/acme/group/web-app.git"}, expect: "https://gitlab.com/acme/group/web-app", owner: "acme/group", provider: semconv.AttributeVCSProviderNameGitlab},
+		"not a URL": {vcs: &vcs{OriginRepositoryURL: "web-app"}, expect: "web-app"},
+		"no VCS":    {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo := vcsRepository(test.vcs)
+			require.Equal(t, test.expect, repo.URL)
+			require.Equal(t, test.owner, repo.Owner)
+			require.Equal(t, test.provider, repo.Provider)
+		})
+	}
+}
+
+func TestGenerateServiceName(t *testing.T) {
+	tests := map[string]struct {
+		config *Config
+		slug   string
+		expect string
+	}{
+		"vcs slug": {config: &Config{}, slug: "gh/acme/web_app", expect: "acme-web-app"},
+		"custom":   {config: &Config{CustomServiceName: "ci"}, slug: "gh/acme/web-app", expect: "ci"},
+		"affixes":  {config: &Config{ServiceNamePrefix: "foo-", ServiceNameSuffix: "-bar"}, slug: "bb/acme/web-app", expect: "foo-acme-web-app-bar"},
+		"no vcs":   {config: &Config{}, slug: "web-app", expect: "web-app"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expect, generateServiceName(test.config, test.slug))
+		})
+	}
+}
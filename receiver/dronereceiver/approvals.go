@@ -0,0 +1,105 @@
+package dronereceiver
+
+import (
+	"fmt"
+
+	"github.com/drone/drone-go/drone"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// blockedStagesCacheSize bounds the stages awaiting approval remembered
+// until their build finishes
+const blockedStagesCacheSize = 1000
+
+// approval is the time a build, or one of its stages, waited for approval.
+// Woodpecker records who approved or declined, Drone doesn't.
+type approval struct {
+	since, until int64
+	approver     string
+	declined     bool
+}
+
+// approvalTracker remembers the stages of Drone builds blocked awaiting
+// approval. Finished builds only report the status of approved stages after
+// their approval, so the earlier webhooks of their builds are needed to know
+// they waited.
+type approvalTracker struct {
+	blocked *lru.Cache[string, int64]
+}
+
+func newApprovalTracker() (*approvalTracker, error) {
+	blocked, err := lru.New[string, int64](blockedStagesCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize blocked stages cache: %w", err)
+	}
+	return &approvalTracker{blocked: blocked}, nil
+}
+
+// observe records the blocked stages of unfinished builds, and sets the
+// approvals of the stages of finished builds seen blocked.
+func (t *approvalTracker) observe(evt *WebhookEvent) {
+	if evt.Repo == nil || evt.Repo.Build == nil {
+		return
+	}
+	build := evt.Repo.Build
+
+	for _, stage := range build.Stages {
+		key := fmt.Sprintf("%s:%d:%d:%d", systemHost(evt.Host), evt.Repo.ID, build.Number, stage.Number)
+		if build.Finished == 0 {
+			if stage.Status == drone.StatusBlocked && !t.blocked.Contains(key) {
+				t.blocked.Add(key, createdAt(build, stage))
+			}
+			continue
+		}
+
+		// Stages are kept for redeliveries of the finished build
+		since, ok := t.blocked.Get(key)
+		if !ok {
+			continue
+		}
+		if evt.approvals == nil {
+			evt.approvals = map[int]approval{}
+		}
+		evt.approvals[stage.Number] = stageApproval(build, stage, since)
+	}
+}
+
+// buildApprovals returns the approvals of a build by stage number, 0 for
+// the build itself. Declined stages waited for approval even when their
+// blocked webhook wasn't seen.
+func buildApprovals(evt WebhookEvent) map[int]approval {
+	approvals := make(map[int]approval, len(evt.approvals))
+	for number, a := range evt.approvals {
+		approvals[number] = a
+	}
+	for _, stage := range evt.Repo.Build.Stages {
+		if _, ok := approvals[stage.Number]; !ok && stage.Status == drone.StatusDeclined {
+			approvals[stage.Number] = stageApproval(evt.Repo.Build, stage, createdAt(evt.Repo.Build, stage))
+		}
+	}
+	return approvals
+}
+
+// stageApproval returns the wait of a stage blocked since since. Approved
+// stages waited until they started, as Drone doesn't record approvals,
+// declined stages until they stopped.
+func stageApproval(build *drone.Build, stage *drone.Stage, since int64) approval {
+	a := approval{since: since, declined: stage.Status == drone.StatusDeclined}
+	switch {
+	case !a.declined && stage.Started != 0:
+		a.until = stage.Started
+	case stage.Stopped != 0:
+		a.until = stage.Stopped
+	default:
+		a.until = build.Finished
+	}
+	return a
+}
+
+// createdAt returns when a stage was created, or its build when unknown
+func createdAt(build *drone.Build, stage *drone.Stage) int64 {
+	if stage.Created != 0 {
+		return stage.Created
+	}
+	return build.Created
+}
@@ -0,0 +1,77 @@
+package dronereceiver
+
+import (
+	"testing"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newApprovalEvent returns a build whose deploy stage required an approval,
+// blocked when finished is false.
+func newApprovalEvent(finished bool) WebhookEvent {
+	evt := WebhookEvent{
+		Repo: &RepoEvt{
+			Repo: drone.Repo{ID: 42, Slug: "grafana/app", Branch: "main"},
+			Build: &drone.Build{
+				Number:  7,
+				Status:  drone.StatusBlocked,
+				Created: 1000,
+				Stages: []*drone.Stage{
+					{Number: 1, Name: "build", Status: drone.StatusPassing, Created: 1000, Started: 1010, Stopped: 1100},
+					{Number: 2, Name: "deploy", Status: drone.StatusBlocked, Created: 1000},
+				},
+			},
+		},
+		System: drone.System{Host: "drone.example.com"},
+	}
+	if finished {
+		build := evt.Repo.Build
+		build.Status, build.Finished = drone.StatusPassing, 1700
+		build.Stages[1].Status, build.Stages[1].Started, build.Stages[1].Stopped = drone.StatusPassing, 1500, 1700
+	}
+	return evt
+}
+
+func TestApprovalTracker(t *testing.T) {
+	tracker, err := newApprovalTracker()
+	require.NoError(t, err)
+
+	blocked := newApprovalEvent(false)
+	tracker.observe(&blocked)
+	assert.Empty(t, blocked.approvals)
+
+	// Approved stages waited until they started
+	finished := newApprovalEvent(true)
+	tracker.observe(&finished)
+	assert.Equal(t, map[int]approval{2: {since: 1000, until: 1500}}, finished.approvals)
+
+	// Redelivered webhooks report the same wait
+	redelivered := newApprovalEvent(true)
+	tracker.observe(&redelivered)
+	assert.Equal(t, finished.approvals, redelivered.approvals)
+
+	// Builds never seen blocked didn't wait
+	other := newApprovalEvent(true)
+	other.Repo.Build.Number = 8
+	tracker.observe(&other)
+	assert.Empty(t, other.approvals)
+}
+
+func TestBuildApprovals(t *testing.T) {
+	t.Run("Declined stages waited until they stopped", func(t *testing.T) {
+		evt := newApprovalEvent(true)
+		deploy := evt.Repo.Build.Stages[1]
+		deploy.Status, deploy.Started = drone.StatusDeclined, 0
+
+		assert.Equal(t, map[int]approval{2: {since: 1000, until: 1700, declined: true}}, buildApprovals(evt))
+	})
+
+	t.Run("Keep the tracked approvals", func(t *testing.T) {
+		evt := newApprovalEvent(true)
+		evt.approvals = map[int]approval{0: {since: 1000, until: 1200, approver: "octocat"}}
+
+		assert.Equal(t, evt.approvals, buildApprovals(evt))
+	})
+}
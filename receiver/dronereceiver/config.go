@@ -1,26 +1,346 @@
 package dronereceiver
 
 import (
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
 	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/collector/config/confighttp"
 	"go.opentelemetry.io/collector/scraper/scraperhelper"
 )
 
+// DBConfig configures the connection to the database of Drone
 type DBConfig struct {
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
-	DB       string `mapstructure:"db"`
-	Host     string `mapstructure:"host"`
+	Driver   string      `mapstructure:"driver"` // postgres, mysql or sqlite, as configured on the Drone server. Default is postgres
+	DSN      string      `mapstructure:"dsn"`    // connection string, such as postgres://drone:secret@db:5432/drone?sslmode=verify-full. Replaces the other settings
+	Username string      `mapstructure:"username"`
+	Password string      `mapstructure:"password"`
+	DB       string      `mapstructure:"db"` // database name, or path of the SQLite database
+	Host     string      `mapstructure:"host"`
+	Port     int         `mapstructure:"port"`      // Default is 5432 for Postgres, and 3306 for MySQL
+	SSLMode  string      `mapstructure:"sslmode"`   // disable, allow, prefer, require, verify-ca or verify-full. Default is disable
+	TLS      DBTLSConfig `mapstructure:"tls"`       // certificates of verify-ca and verify-full, and client certificates
+	MaxConns int32       `mapstructure:"max_conns"` // maximum size of the connection pool. Default is the pgx default, the number of CPUs but at least 4
+}
+
+// DBTLSConfig locates the certificates of TLS connections to the database
+type DBTLSConfig struct {
+	CAFile   string `mapstructure:"ca_file"`   // CA certificates verifying the server
+	CertFile string `mapstructure:"cert_file"` // client certificate
+	KeyFile  string `mapstructure:"key_file"`  // key of the client certificate
+}
+
+const (
+	driverPostgres = "postgres"
+	driverMySQL    = "mysql"
+	driverSQLite   = "sqlite"
+)
+
+const (
+	defaultDBPort    = 5432
+	defaultMySQLPort = 3306
+	defaultDBSSLMode = "disable"
+	// sqliteBusyTimeout is the pragma making queries wait 5 seconds for the
+	// writes of Drone to the SQLite database
+	sqliteBusyTimeout = "busy_timeout(5000)"
+)
+
+var dbSSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// connString returns the connection string of a Postgres database, the DSN
+// when set. Credentials are escaped, as passwords may contain any character.
+func (cfg DBConfig) connString() string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = defaultDBPort
+	}
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = defaultDBSSLMode
+	}
+
+	query := url.Values{}
+	query.Set("sslmode", sslMode)
+	for key, value := range map[string]string{
+		"sslrootcert": cfg.TLS.CAFile,
+		"sslcert":     cfg.TLS.CertFile,
+		"sslkey":      cfg.TLS.KeyFile,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if cfg.MaxConns > 0 {
+		query.Set("pool_max_conns", strconv.Itoa(int(cfg.MaxConns)))
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.Username, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		Path:     "/" + cfg.DB,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// mysqlConfig returns the connection settings of a MySQL database, parsed
+// from the DSN when set. The sslmode is mapped to the TLS settings of the
+// MySQL driver.
+func (cfg DBConfig) mysqlConfig() (*mysql.Config, error) {
+	if cfg.DSN != "" {
+		return mysql.ParseDSN(cfg.DSN)
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = defaultMySQLPort
+	}
+
+	mcfg := mysql.NewConfig()
+	mcfg.User = cfg.Username
+	mcfg.Passwd = cfg.Password
+	mcfg.Net = "tcp"
+	mcfg.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	mcfg.DBName = cfg.DB
+	mcfg.Timeout = connectTimeout
+
+	switch cfg.SSLMode {
+	case "", "disable":
+		return mcfg, nil
+	case "allow", "prefer":
+		mcfg.TLS = &tls.Config{InsecureSkipVerify: true}
+		mcfg.AllowFallbackToPlaintext = true
+		return mcfg, nil
+	}
+
+	tlsCfg, err := cfg.TLS.load()
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.SSLMode {
+	case "require":
+		tlsCfg.InsecureSkipVerify = true
+	case "verify-ca":
+		// The certificate chain is verified, but not the host name
+		tlsCfg.InsecureSkipVerify = true
+		tlsCfg.VerifyConnection = func(state tls.ConnectionState) error {
+			opts := x509.VerifyOptions{Roots: tlsCfg.RootCAs, Intermediates: x509.NewCertPool()}
+			for _, cert := range state.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := state.PeerCertificates[0].Verify(opts)
+			return err
+		}
+	case "verify-full":
+		tlsCfg.ServerName = cfg.Host
+	}
+	mcfg.TLS = tlsCfg
+	return mcfg, nil
+}
+
+// sqliteDSN returns the connection string of a SQLite database, the DSN
+// when set. The database is opened read-only, as Drone writes to it.
+func (cfg DBConfig) sqliteDSN() string {
+	if cfg.DSN != "" {
+		return cfg.DSN
+	}
+
+	query := url.Values{}
+	query.Set("mode", "ro")
+	query.Set("_pragma", sqliteBusyTimeout)
+	u := url.URL{
+		Scheme:   "file",
+		Opaque:   url.PathEscape(cfg.DB),
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// load reads the certificates of TLS connections to a MySQL database
+func (cfg DBTLSConfig) load() (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificates: %w", err)
+		}
+		tlsCfg.RootCAs = x509.NewCertPool()
+		if !tlsCfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no CA certificates found in %s", cfg.CAFile)
+		}
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+// configured reports whether a database is configured, the scraper only
+// runs then
+func (cfg DBConfig) configured() bool {
+	if cfg.Driver == driverSQLite {
+		return cfg.DSN != "" || cfg.DB != ""
+	}
+	return cfg.DSN != "" || cfg.Host != ""
+}
+
+// Validate checks the settings of the database, without connecting to it
+func (cfg DBConfig) Validate() error {
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		return fmt.Errorf("invalid port %d", cfg.Port)
+	}
+	if cfg.SSLMode != "" && !slices.Contains(dbSSLModes, cfg.SSLMode) {
+		return fmt.Errorf("unknown sslmode %q, must be one of %s", cfg.SSLMode, strings.Join(dbSSLModes, ", "))
+	}
+	if cfg.MaxConns < 0 {
+		return fmt.Errorf("max_conns must not be negative")
+	}
+
+	switch cfg.Driver {
+	case "", driverPostgres:
+		if _, err := pgxpool.ParseConfig(cfg.connString()); err != nil {
+			return fmt.Errorf("invalid connection settings: %w", err)
+		}
+	case driverMySQL:
+		if _, err := cfg.mysqlConfig(); err != nil {
+			return fmt.Errorf("invalid connection settings: %w", err)
+		}
+	case driverSQLite:
+		if cfg.DSN == "" && cfg.DB == "" {
+			return fmt.Errorf("db must be set to the path of the SQLite database")
+		}
+	default:
+		return fmt.Errorf("unknown driver %q, must be %s, %s or %s", cfg.Driver, driverPostgres, driverMySQL, driverSQLite)
+	}
+	return nil
 }
 
 type DroneConfig struct {
 	Token    string   `mapstructure:"token"`
 	Host     string   `mapstructure:"host"`
 	Database DBConfig `mapstructure:"database"`
+	// Runners scrapes the queue and the runners from the Drone API, which
+	// requires an admin token
+	Runners      bool               `mapstructure:"runners"`
+	LogRetrieval LogRetrievalConfig `mapstructure:"log_retrieval"`
+}
+
+// LogRetrievalConfig bounds the retrieval of step logs from the API, which
+// runs in the background of webhooks
+type LogRetrievalConfig struct {
+	Workers     int           `mapstructure:"workers"`      // steps of a build whose logs are retrieved at once. Default is 8
+	Timeout     time.Duration `mapstructure:"timeout"`      // bounds each request to the API. Default is 30s
+	MaxAttempts int           `mapstructure:"max_attempts"` // attempts of requests failing with 429 or 5xx statuses, 1 disables retries. Default is 4
+	QueueSize   int           `mapstructure:"queue_size"`   // finished builds waiting for their logs, more are rejected. Default is 100
 }
 
+const (
+	defaultLogWorkers     = 8
+	defaultLogTimeout     = 30 * time.Second
+	defaultLogMaxAttempts = 4
+	defaultLogQueueSize   = 100
+)
+
+// workers returns the number of steps whose logs are retrieved at once
+func (cfg LogRetrievalConfig) workers() int {
+	if cfg.Workers == 0 {
+		return defaultLogWorkers
+	}
+	return cfg.Workers
+}
+
+// timeout returns the bound of each request to the API
+func (cfg LogRetrievalConfig) timeout() time.Duration {
+	if cfg.Timeout == 0 {
+		return defaultLogTimeout
+	}
+	return cfg.Timeout
+}
+
+// maxAttempts returns the number of attempts of failed requests
+func (cfg LogRetrievalConfig) maxAttempts() int {
+	if cfg.MaxAttempts == 0 {
+		return defaultLogMaxAttempts
+	}
+	return cfg.MaxAttempts
+}
+
+// queueSize returns the number of builds waiting for their logs
+func (cfg LogRetrievalConfig) queueSize() int {
+	if cfg.QueueSize == 0 {
+		return defaultLogQueueSize
+	}
+	return cfg.QueueSize
+}
+
+// Validate checks the bounds of log retrieval. Zero values are defaults.
+func (cfg LogRetrievalConfig) Validate() error {
+	if cfg.Workers < 0 {
+		return fmt.Errorf("workers must not be negative")
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if cfg.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts must not be negative")
+	}
+	if cfg.QueueSize < 0 {
+		return fmt.Errorf("queue_size must not be negative")
+	}
+	return nil
+}
+
+// WoodpeckerConfig configures the verification of Woodpecker webhooks
+type WoodpeckerConfig struct {
+	PublicKey          string        `mapstructure:"public_key"`          // PEM encoded ed25519 key webhooks are signed with, as served by /api/signature/public-key
+	SignatureTolerance time.Duration `mapstructure:"signature_tolerance"` // maximum age of signed webhooks. Default is 5m
+}
+
+const defaultSignatureTolerance = 5 * time.Minute
+
+// signatureTolerance returns the maximum age of signed webhooks
+func (cfg WoodpeckerConfig) signatureTolerance() time.Duration {
+	if cfg.SignatureTolerance == 0 {
+		return defaultSignatureTolerance
+	}
+	return cfg.SignatureTolerance
+}
+
+// TracesConfig configures the spans of builds
+type TracesConfig struct {
+	SkippedSteps bool `mapstructure:"skipped_steps"` // reports skipped steps as spans without duration. Default is false
+}
+
+const (
+	flavorDrone      = "drone"
+	flavorWoodpecker = "woodpecker"
+)
+
 // Config defines configuration for dronereceiver receiver.
 type Config struct {
 	scraperhelper.ControllerConfig `mapstructure:",squash"`
@@ -28,8 +348,13 @@ type Config struct {
 	confighttp.ServerConfig        `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct
 	Path                           string                   `mapstructure:"path"`   // path for data collection. Default is <host>:<port>/events
 	Secret                         string                   `mapstructure:"secret"` // webhook hash signature. Default is empty
+	Flavor                         string                   `mapstructure:"flavor"` // CI server sending the webhooks, drone or woodpecker. Default is drone
+	Woodpecker                     WoodpeckerConfig         `mapstructure:"woodpecker"`
 	DroneConfig                    DroneConfig              `mapstructure:"drone"`
 	ReposConfig                    map[string][]string      `mapstructure:"repos"`
+	Traces                         TracesConfig             `mapstructure:"traces"`
+	Logs                           logpolicy.Config         `mapstructure:"logs"`    // sampling, truncation and redaction policies applied to step logs. failed_only applies to stages
+	Semconv                        semconv.Config           `mapstructure:"semconv"` // OpenTelemetry CICD and VCS semantic conventions
 }
 
 // Validate checks if the receiver configuration is valid
@@ -40,8 +365,21 @@ func (cfg *Config) Validate() error {
 	if cfg.DroneConfig.Token == "" {
 		return fmt.Errorf("token must be defined")
 	}
-	if cfg.Secret == "" {
-		return fmt.Errorf("webhook secret must be defined")
+
+	switch cfg.Flavor {
+	case "", flavorDrone:
+		if cfg.Secret == "" {
+			return fmt.Errorf("webhook secret must be defined")
+		}
+	case flavorWoodpecker:
+		if _, err := cfg.Woodpecker.publicKey(); err != nil {
+			return fmt.Errorf("invalid woodpecker public key: %w", err)
+		}
+		if cfg.Woodpecker.SignatureTolerance < 0 {
+			return fmt.Errorf("woodpecker signature_tolerance must not be negative")
+		}
+	default:
+		return fmt.Errorf("unknown flavor %q, must be %s or %s", cfg.Flavor, flavorDrone, flavorWoodpecker)
 	}
 
 	// Validates that the repos and branches are defined.
@@ -65,5 +403,48 @@ func (cfg *Config) Validate() error {
 		}
 	}
 
+	// The database is only read by metrics pipelines, which are not
+	// supported for Woodpecker.
+	if cfg.Flavor != flavorWoodpecker {
+		if err := cfg.DroneConfig.Database.Validate(); err != nil {
+			return fmt.Errorf("invalid database configuration: %w", err)
+		}
+	}
+
+	if err := cfg.DroneConfig.LogRetrieval.Validate(); err != nil {
+		return fmt.Errorf("invalid log retrieval configuration: %w", err)
+	}
+
+	if err := cfg.Logs.Validate(); err != nil {
+		return fmt.Errorf("invalid logs configuration: %w", err)
+	}
+
 	return nil
 }
+
+// vendor returns the CI vendor of the configured flavor
+func (cfg *Config) vendor() string {
+	if cfg.Flavor == flavorWoodpecker {
+		return semconv.AttributeCIVendorWoodpecker
+	}
+	return semconv.AttributeCIVendorDrone
+}
+
+// publicKey parses the PEM encoded ed25519 public key
+func (cfg WoodpeckerConfig) publicKey() (ed25519.PublicKey, error) {
+	block, _ := pem.Decode([]byte(cfg.PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("public key must be PEM encoded")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key must be an ed25519 key")
+	}
+	return publicKey, nil
+}
@@ -0,0 +1,219 @@
+package dronereceiver
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// dbBackend runs the queries of the scraper against the database of Drone,
+// whichever its driver. The schema of Drone is the same for every driver.
+type dbBackend interface {
+	// builds counts the builds by status, repository and source. Builds of
+	// repositories and sources missing from repos are counted as other.
+	// Rows that can't be read are reported along with the others.
+	builds(ctx context.Context, repos map[string][]string) ([]buildCount, error)
+	// restartedBuilds counts the builds run again for the same commit and
+	// source.
+	restartedBuilds(ctx context.Context) (int64, error)
+	// lastBuilds returns the status of the last finished build of each
+	// repository and source of repos.
+	lastBuilds(ctx context.Context, repos map[string][]string) ([]buildStatus, error)
+	ping(ctx context.Context) error
+	close()
+}
+
+type buildCount struct {
+	count  int64
+	status string
+	slug   string
+	source string
+}
+
+type buildStatus struct {
+	status string
+	slug   string
+	source string
+}
+
+// openBackend connects to the database of the configured driver, and checks
+// it can be reached.
+func openBackend(ctx context.Context, cfg DBConfig) (dbBackend, error) {
+	switch cfg.Driver {
+	case driverMySQL:
+		return openMySQL(ctx, cfg)
+	case driverSQLite:
+		return openSQLite(ctx, cfg)
+	default:
+		return openPostgres(ctx, cfg)
+	}
+}
+
+// dbQuerier is the subset of pgxpool.Pool queried by the Postgres backend
+type dbQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Ping(ctx context.Context) error
+}
+
+// postgresBackend queries a Postgres database through a pgx pool. Names
+// are bound as arrays.
+type postgresBackend struct {
+	pool      dbQuerier
+	closePool func()
+}
+
+func openPostgres(ctx context.Context, cfg DBConfig) (dbBackend, error) {
+	pool, err := pgxpool.New(ctx, cfg.connString())
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return &postgresBackend{pool: pool, closePool: pool.Close}, nil
+}
+
+// buildsQuery counts the builds by status, repository and source. Builds of
+// repositories and sources missing from the configuration are counted as
+// other. $1 is the configured repositories, and $2 and $3 the configured
+// pairs of repository and source.
+const buildsQuery = `
+	SELECT
+		count(*),
+		build_status,
+		CASE
+			WHEN r.repo_slug = ANY($1) THEN r.repo_slug
+			ELSE 'other'
+		END AS slug,
+		CASE
+			WHEN (r.repo_slug, build_source) IN (SELECT * FROM unnest($2::text[], $3::text[])) THEN build_source
+			ELSE 'other'
+		END AS source
+	FROM
+		builds
+	LEFT JOIN
+		repos r
+	ON
+		build_repo_id = r.repo_id
+	GROUP BY
+		build_status,
+		slug,
+		source
+`
+
+// restartedBuildsQuery counts the builds run again for the same commit and
+// source.
+const restartedBuildsQuery = `
+	SELECT COALESCE(SUM(occurrence_count - 1), 0) AS total_occurrence_count
+	FROM (
+		SELECT count(*) AS occurrence_count
+		FROM builds
+		GROUP BY build_after, build_source
+		HAVING COUNT(*) > 1
+	) subquery
+`
+
+// infoQuery returns the status of the last finished build of each
+// configured pair of repository and source, given as $1 and $2.
+const infoQuery = `
+	SELECT build_status, r.repo_slug, build_source FROM builds
+	LEFT JOIN
+		repos r
+	ON
+		build_repo_id = r.repo_id
+	WHERE build_id IN (
+		SELECT MAX(build_id)
+		FROM
+			builds
+		JOIN
+			repos r
+		ON
+			build_repo_id = r.repo_id
+		WHERE
+			build_status NOT IN ('running','waiting_on_dependencies','pending')
+			AND (r.repo_slug, build_source) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+		GROUP BY build_repo_id, build_source
+	)
+`
+
+// repoSources returns the configured repositories, and their pairs of
+// repository and source as parallel arrays, in order. They are bound to the
+// queries rather than formatted into them, as names may contain quotes.
+func repoSources(repos map[string][]string) (slugs, pairSlugs, pairSources []string) {
+	slugs = make([]string, 0, len(repos))
+	for slug := range repos {
+		slugs = append(slugs, slug)
+	}
+	slices.Sort(slugs)
+
+	for _, slug := range slugs {
+		for _, source := range repos[slug] {
+			pairSlugs = append(pairSlugs, slug)
+			pairSources = append(pairSources, source)
+		}
+	}
+	return slugs, pairSlugs, pairSources
+}
+
+func (b *postgresBackend) builds(ctx context.Context, repos map[string][]string) ([]buildCount, error) {
+	slugs, pairSlugs, pairSources := repoSources(repos)
+	rows, err := b.pool.Query(ctx, buildsQuery, slugs, pairSlugs, pairSources)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []buildCount
+	var errs []error
+	for rows.Next() {
+		var c buildCount
+		if err := rows.Scan(&c.count, &c.status, &c.slug, &c.source); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		counts = append(counts, c)
+	}
+	return counts, errors.Join(append(errs, rows.Err())...)
+}
+
+func (b *postgresBackend) restartedBuilds(ctx context.Context) (int64, error) {
+	var count int64
+	err := b.pool.QueryRow(ctx, restartedBuildsQuery).Scan(&count)
+	return count, err
+}
+
+func (b *postgresBackend) lastBuilds(ctx context.Context, repos map[string][]string) ([]buildStatus, error) {
+	_, pairSlugs, pairSources := repoSources(repos)
+	rows, err := b.pool.Query(ctx, infoQuery, pairSlugs, pairSources)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []buildStatus
+	var errs []error
+	for rows.Next() {
+		var s buildStatus
+		if err := rows.Scan(&s.status, &s.slug, &s.source); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, errors.Join(append(errs, rows.Err())...)
+}
+
+func (b *postgresBackend) ping(ctx context.Context) error {
+	return b.pool.Ping(ctx)
+}
+
+func (b *postgresBackend) close() {
+	if b.closePool != nil {
+		b.closePool()
+	}
+}
@@ -0,0 +1,200 @@
+package dronereceiver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	// Pure Go SQLite driver, as the collector is built without cgo
+	_ "modernc.org/sqlite"
+)
+
+// sqlBackend queries a MySQL or SQLite database through database/sql. Both
+// lack array parameters, so each configured name is bound to its own
+// placeholder.
+type sqlBackend struct {
+	db *sql.DB
+}
+
+func openMySQL(ctx context.Context, cfg DBConfig) (dbBackend, error) {
+	mcfg, err := cfg.mysqlConfig()
+	if err != nil {
+		return nil, err
+	}
+	connector, err := mysql.NewConnector(mcfg)
+	if err != nil {
+		return nil, err
+	}
+	return openSQL(ctx, sql.OpenDB(connector), cfg)
+}
+
+func openSQLite(ctx context.Context, cfg DBConfig) (dbBackend, error) {
+	db, err := sql.Open("sqlite", cfg.sqliteDSN())
+	if err != nil {
+		return nil, err
+	}
+	return openSQL(ctx, db, cfg)
+}
+
+func openSQL(ctx context.Context, db *sql.DB, cfg DBConfig) (dbBackend, error) {
+	if cfg.MaxConns > 0 {
+		db.SetMaxOpenConns(int(cfg.MaxConns))
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqlBackend{db: db}, nil
+}
+
+// sqlBuildsQuery returns the query counting the builds by status,
+// repository and source, and its arguments. See buildsQuery.
+func sqlBuildsQuery(repos map[string][]string) (string, []any) {
+	slugs, pairSlugs, pairSources := repoSources(repos)
+	slugsCond, slugsArgs := inCondition("r.repo_slug", slugs)
+	pairsCond, pairsArgs := pairsCondition(pairSlugs, pairSources)
+
+	query := `
+	SELECT
+		count(*),
+		build_status,
+		CASE
+			WHEN ` + slugsCond + ` THEN r.repo_slug
+			ELSE 'other'
+		END AS slug,
+		CASE
+			WHEN ` + pairsCond + ` THEN build_source
+			ELSE 'other'
+		END AS source
+	FROM
+		builds
+	LEFT JOIN
+		repos r
+	ON
+		build_repo_id = r.repo_id
+	GROUP BY
+		build_status,
+		slug,
+		source
+`
+	return query, append(slugsArgs, pairsArgs...)
+}
+
+// sqlInfoQuery returns the query of the status of the last finished build of
+// each configured pair of repository and source, and its arguments. See
+// infoQuery.
+func sqlInfoQuery(repos map[string][]string) (string, []any) {
+	_, pairSlugs, pairSources := repoSources(repos)
+	pairsCond, pairsArgs := pairsCondition(pairSlugs, pairSources)
+
+	query := `
+	SELECT build_status, r.repo_slug, build_source FROM builds
+	LEFT JOIN
+		repos r
+	ON
+		build_repo_id = r.repo_id
+	WHERE build_id IN (
+		SELECT MAX(build_id)
+		FROM
+			builds
+		JOIN
+			repos r
+		ON
+			build_repo_id = r.repo_id
+		WHERE
+			build_status NOT IN ('running','waiting_on_dependencies','pending')
+			AND ` + pairsCond + `
+		GROUP BY build_repo_id, build_source
+	)
+`
+	return query, pairsArgs
+}
+
+// inCondition returns a condition holding when column is one of values,
+// with a placeholder per value. Empty lists never hold.
+func inCondition(column string, values []string) (string, []any) {
+	if len(values) == 0 {
+		return "1 = 0", nil
+	}
+
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return column + " IN (" + strings.Repeat("?, ", len(values)-1) + "?)", args
+}
+
+// pairsCondition returns a condition holding when the repository and source
+// of a build are one of the given pairs, with placeholders for both. Empty
+// lists never hold.
+func pairsCondition(slugs, sources []string) (string, []any) {
+	if len(slugs) == 0 {
+		return "1 = 0", nil
+	}
+
+	conds := make([]string, len(slugs))
+	args := make([]any, 0, 2*len(slugs))
+	for i := range slugs {
+		conds[i] = "(r.repo_slug = ? AND build_source = ?)"
+		args = append(args, slugs[i], sources[i])
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", args
+}
+
+func (b *sqlBackend) builds(ctx context.Context, repos map[string][]string) ([]buildCount, error) {
+	query, args := sqlBuildsQuery(repos)
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []buildCount
+	var errs []error
+	for rows.Next() {
+		var c buildCount
+		if err := rows.Scan(&c.count, &c.status, &c.slug, &c.source); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		counts = append(counts, c)
+	}
+	return counts, errors.Join(append(errs, rows.Err())...)
+}
+
+func (b *sqlBackend) restartedBuilds(ctx context.Context) (int64, error) {
+	var count int64
+	err := b.db.QueryRowContext(ctx, restartedBuildsQuery).Scan(&count)
+	return count, err
+}
+
+func (b *sqlBackend) lastBuilds(ctx context.Context, repos map[string][]string) ([]buildStatus, error) {
+	query, args := sqlInfoQuery(repos)
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []buildStatus
+	var errs []error
+	for rows.Next() {
+		var s buildStatus
+		if err := rows.Scan(&s.status, &s.slug, &s.source); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, errors.Join(append(errs, rows.Err())...)
+}
+
+func (b *sqlBackend) ping(ctx context.Context) error {
+	return b.db.PingContext(ctx)
+}
+
+func (b *sqlBackend) close() {
+	b.db.Close()
+}
@@ -0,0 +1,36 @@
+package dronereceiver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLQueries(t *testing.T) {
+	repos := map[string][]string{
+		"grafana/loki": {"main"},
+		hostileRepo:    {hostileBranch, "release"},
+	}
+
+	t.Run("Binds every name", func(t *testing.T) {
+		query, args := sqlBuildsQuery(repos)
+		assert.Contains(t, query, "r.repo_slug IN (?, ?)")
+		assert.Equal(t, 3, strings.Count(query, "(r.repo_slug = ? AND build_source = ?)"))
+		assert.NotContains(t, query, "o'brien")
+		assert.Equal(t, []any{
+			hostileRepo, "grafana/loki",
+			hostileRepo, hostileBranch, hostileRepo, "release", "grafana/loki", "main",
+		}, args)
+
+		query, args = sqlInfoQuery(repos)
+		assert.Equal(t, 3, strings.Count(query, "(r.repo_slug = ? AND build_source = ?)"))
+		assert.Equal(t, []any{hostileRepo, hostileBranch, hostileRepo, "release", "grafana/loki", "main"}, args)
+	})
+
+	t.Run("Matches nothing without repositories", func(t *testing.T) {
+		query, args := sqlBuildsQuery(nil)
+		assert.Equal(t, 2, strings.Count(query, "WHEN 1 = 0"))
+		assert.Empty(t, args)
+	})
+}
@@ -0,0 +1,84 @@
+package dronereceiver
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+// newTestSQLite creates a SQLite database seeded with the Drone schema
+// fixtures, and returns its path.
+func newTestSQLite(t *testing.T) string {
+	t.Helper()
+
+	fixtures, err := os.ReadFile(filepath.Join("testdata", "drone_sqlite.sql"))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "database.sqlite")
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec(string(fixtures))
+	require.NoError(t, err)
+	return path
+}
+
+func TestSQLiteScrape(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.ReposConfig = map[string][]string{
+		"grafana/loki": {"main"},
+		hostileRepo:    {hostileBranch},
+	}
+	cfg.DroneConfig.Database = DBConfig{Driver: driverSQLite, DB: newTestSQLite(t)}
+	require.NoError(t, cfg.DroneConfig.Database.Validate())
+	require.True(t, cfg.DroneConfig.Database.configured())
+
+	scraper := newDroneScraper(receivertest.NewNopSettings(metadata.Type), cfg)
+	t.Cleanup(func() { require.NoError(t, scraper.Shutdown(t.Context())) })
+
+	metrics, err := scraper.scrape(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), databaseUp(metrics))
+
+	builds := dataPoints(metrics, "builds_number")
+	// Every status is reported for each repository and source.
+	require.Len(t, builds, 4*len(metadata.MapAttributeCiWorkflowItemStatus))
+	require.Equal(t, int64(2), builds[[3]string{"success", "grafana/loki", "main"}])
+	require.Equal(t, int64(1), builds[[3]string{"failure", "grafana/loki", "main"}])
+	require.Equal(t, int64(1), builds[[3]string{"running", "grafana/loki", "main"}])
+	require.Equal(t, int64(1), builds[[3]string{"success", "grafana/loki", "other"}])
+	require.Equal(t, int64(1), builds[[3]string{"failure", hostileRepo, hostileBranch}])
+	require.Equal(t, int64(2), builds[[3]string{"success", "other", "other"}])
+
+	require.Equal(t, map[[3]string]int64{
+		{"success", "grafana/loki", "main"}:     1,
+		{"failure", hostileRepo, hostileBranch}: 1,
+	}, dataPoints(metrics, "repo_info"))
+
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := range ms.Len() {
+		if ms.At(i).Name() == "restarts_total" {
+			require.Equal(t, int64(2), ms.At(i).Sum().DataPoints().At(0).IntValue())
+		}
+	}
+}
+
+func TestSQLiteReadOnly(t *testing.T) {
+	backend, err := openSQLite(t.Context(), DBConfig{Driver: driverSQLite, DB: newTestSQLite(t)})
+	require.NoError(t, err)
+	defer backend.close()
+
+	_, err = backend.(*sqlBackend).db.Exec("DELETE FROM builds")
+	require.Error(t, err)
+}
+
+func TestSQLiteMissingDatabase(t *testing.T) {
+	// Read-only databases are not created when missing.
+	_, err := openSQLite(t.Context(), DBConfig{Driver: driverSQLite, DB: filepath.Join(t.TempDir(), "missing.sqlite")})
+	require.Error(t, err)
+}
@@ -2,6 +2,7 @@ package dronereceiver
 
 import (
 	"context"
+	"errors"
 
 	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
 	"go.opentelemetry.io/collector/component"
@@ -18,6 +19,11 @@ const (
 	defaultPath         = "/drone/webhook"
 )
 
+// errWoodpeckerMetrics is returned for metrics pipelines of Woodpecker
+// receivers scraping the database or the runners, the scrapers only read
+// the database and the API of Drone. Webhooks report builds on their own.
+var errWoodpeckerMetrics = errors.New("database and runners metrics are not supported for woodpecker")
+
 func createDefaultConfig() component.Config {
 	cfg := scraperhelper.NewDefaultControllerConfig()
 
@@ -32,6 +38,7 @@ func createDefaultConfig() component.Config {
 		},
 		Path:   defaultPath,
 		Secret: "",
+		Flavor: flavorDrone,
 	}
 }
 
@@ -63,18 +70,69 @@ func newTracesReceiver(_ context.Context, set receiver.Settings, cfg component.C
 
 func newMetricsReceiver(_ context.Context, set receiver.Settings, rConf component.Config, consumer consumer.Metrics) (receiver.Metrics, error) {
 	cfg := rConf.(*Config)
+	if cfg.Flavor == flavorWoodpecker && (cfg.DroneConfig.Database.configured() || cfg.DroneConfig.Runners) {
+		return nil, errWoodpeckerMetrics
+	}
 
-	ns := newDroneScraper(set, cfg)
-	scraper, err := scraper.NewMetrics(ns.scrape)
+	var err error
+	r := receivers.GetOrAdd(cfg, func() component.Component {
+		var rcv component.Component
+		rcv, err = newReceiver(set, cfg)
+		return rcv
+	})
+	if err != nil {
+		return nil, err
+	}
+	rcv := r.Unwrap().(*droneReceiver)
+	rcv.metricsConsumer = consumer
+
+	// Webhooks report builds on their own, the database and the API are
+	// only scraped when configured
+	var scrapers []scraperhelper.ControllerOption
+	if cfg.DroneConfig.Database.configured() {
+		ns := newDroneScraper(set, cfg)
+		s, err := scraper.NewMetrics(ns.scrape, scraper.WithStart(ns.Start), scraper.WithShutdown(ns.Shutdown))
+		if err != nil {
+			return nil, err
+		}
+		scrapers = append(scrapers, scraperhelper.AddMetricsScraper(metadata.Type, s))
+	}
+	if cfg.DroneConfig.Runners {
+		rs := newRunnersScraper(set, cfg, rcv.runnersAPI)
+		s, err := scraper.NewMetrics(rs.scrape)
+		if err != nil {
+			return nil, err
+		}
+		scrapers = append(scrapers, scraperhelper.AddMetricsScraper(runnersScraperType, s))
+	}
+	if len(scrapers) == 0 {
+		return r, nil
+	}
 
+	controller, err := scraperhelper.NewMetricsController(&cfg.ControllerConfig, set, consumer, scrapers...)
 	if err != nil {
 		return nil, err
 	}
 
-	return scraperhelper.NewMetricsController(
-		&cfg.ControllerConfig, set, consumer,
-		scraperhelper.AddMetricsScraper(metadata.Type, scraper),
-	)
+	return &metricsReceiver{webhooks: r, scraper: controller}, nil
+}
+
+// metricsReceiver runs the webhook server along with the scrapers of the
+// database and the API
+type metricsReceiver struct {
+	webhooks component.Component
+	scraper  component.Component
+}
+
+func (r *metricsReceiver) Start(ctx context.Context, host component.Host) error {
+	if err := r.webhooks.Start(ctx, host); err != nil {
+		return err
+	}
+	return r.scraper.Start(ctx, host)
+}
+
+func (r *metricsReceiver) Shutdown(ctx context.Context) error {
+	return errors.Join(r.scraper.Shutdown(ctx), r.webhooks.Shutdown(ctx))
 }
 
 func newLogsReceiver(_ context.Context, set receiver.Settings, cfg component.Config, consumer consumer.Logs) (receiver.Logs, error) {
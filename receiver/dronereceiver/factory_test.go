@@ -20,3 +20,65 @@ func TestCreateMetricsReceiver(t *testing.T) {
 	)
 	require.NoError(t, err)
 }
+
+func TestCreateWoodpeckerMetricsReceiver(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Flavor = flavorWoodpecker
+	// Webhooks report builds on their own
+	_, err := NewFactory().CreateMetrics(
+		context.Background(),
+		receivertest.NewNopSettings(component.MustNewType("dronereceiver")),
+		cfg,
+		consumertest.NewNop(),
+	)
+	require.NoError(t, err)
+
+	// The scrapers only read Drone
+	cfg = createDefaultConfig().(*Config)
+	cfg.Flavor = flavorWoodpecker
+	cfg.DroneConfig.Runners = true
+	_, err = NewFactory().CreateMetrics(
+		context.Background(),
+		receivertest.NewNopSettings(component.MustNewType("dronereceiver")),
+		cfg,
+		consumertest.NewNop(),
+	)
+	require.ErrorIs(t, err, errWoodpeckerMetrics)
+}
+
+func TestCreateMetricsReceiverDatabase(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	rcv, err := NewFactory().CreateMetrics(
+		context.Background(),
+		receivertest.NewNopSettings(component.MustNewType("dronereceiver")),
+		cfg,
+		consumertest.NewNop(),
+	)
+	require.NoError(t, err)
+	// Without database, metrics are only reported by webhooks
+	require.IsType(t, &SharedComponent{}, rcv)
+
+	cfg = createDefaultConfig().(*Config)
+	cfg.DroneConfig.Database.Host = "db"
+	rcv, err = NewFactory().CreateMetrics(
+		context.Background(),
+		receivertest.NewNopSettings(component.MustNewType("dronereceiver")),
+		cfg,
+		consumertest.NewNop(),
+	)
+	require.NoError(t, err)
+	require.IsType(t, &metricsReceiver{}, rcv)
+}
+
+func TestCreateMetricsReceiverRunners(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.DroneConfig.Runners = true
+	rcv, err := NewFactory().CreateMetrics(
+		context.Background(),
+		receivertest.NewNopSettings(component.MustNewType("dronereceiver")),
+		cfg,
+		consumertest.NewNop(),
+	)
+	require.NoError(t, err)
+	require.IsType(t, &metricsReceiver{}, rcv)
+}
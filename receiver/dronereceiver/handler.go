@@ -1,13 +1,17 @@
 package dronereceiver
 
 import (
+	"context"
+	"fmt"
+	"net/url"
 	"slices"
+	"strconv"
 	"time"
 
 	"github.com/drone/drone-go/drone"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
 	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
-	"github.com/grafana/grafana-ci-otel-collector/internal/traceutils"
-	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	conventions "go.opentelemetry.io/collector/semconv/v1.9.0"
@@ -23,12 +27,63 @@ type WebhookEvent struct {
 	Action       string   `json:"action"`
 	Repo         *RepoEvt `json:"repo"`
 	drone.System `json:"system"`
+
+	// approvals are the waits of the build, and of its stages, for their
+	// approval by stage number, 0 being the build. They aren't part of Drone
+	// webhooks.
+	approvals map[int]approval
+}
+
+// logSource retrieves the log lines of a step
+type logSource interface {
+	StepLogs(ctx context.Context, repo drone.Repo, build drone.Build, stage drone.Stage, step drone.Step) ([]*drone.Line, error)
+}
+
+// droneLogs retrieves step logs through the Drone API. The Drone client
+// takes no context, so logs are requested directly, to stop with ctx.
+type droneLogs struct {
+	apiClient
 }
 
-func handleEvent(evt WebhookEvent, config *Config, droneClient drone.Client, logger *zap.Logger) (*ptrace.Traces, *plog.Logs) {
+// StepLogs retrieves the log lines of a step.
+func (l droneLogs) StepLogs(ctx context.Context, repo drone.Repo, build drone.Build, stage drone.Stage, step drone.Step) ([]*drone.Line, error) {
+	ref := fmt.Sprintf("api/repos/%s/%s/builds/%d/logs/%d/%d",
+		url.PathEscape(repo.Namespace), url.PathEscape(repo.Name), build.Number, stage.Number, step.Number)
+
+	var lines []*drone.Line
+	if err := l.getJSON(ctx, ref, &lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func handleEvent(ctx context.Context, evt WebhookEvent, config *Config, logs logSource, logPolicy *logpolicy.Policy, logger *zap.Logger) (*ptrace.Traces, *plog.Logs) {
+	if !acceptEvent(evt, config, logger) {
+		return nil, nil
+	}
+
+	pipeline := buildToPipeline(ctx, evt, config, logs, logPolicy, logger)
+	opts := cimodel.Options{
+		ScopeName:    "dronereceiver",
+		ScopeVersion: "0.1.0",
+		Semconv:      config.Semconv,
+	}
+
+	traces := cimodel.ToTraces(&pipeline, opts)
+	records := cimodel.ToLogs(&pipeline, logPolicy, opts)
+	return &traces, &records
+}
+
+// acceptEvent reports whether an event is a finished build of the enabled
+// repos and branches, the only builds reported.
+func acceptEvent(evt WebhookEvent, config *Config, logger *zap.Logger) bool {
 	repo := evt.Repo
-	build := evt.Repo.Build
 	logger.Debug("Got request")
+	if repo == nil {
+		logger.Warn("no repo info provided from the webhook event")
+		return false
+	}
+	build := repo.Build
 
 	// Skip unfinished builds (i.e. builds that are still running)
 	// In theory, according to the docs in https://docs.drone.io/webhooks/examples/, build.Action should be "completed" when a build is completed.
@@ -39,67 +94,65 @@ func handleEvent(evt WebhookEvent, config *Config, droneClient drone.Client, log
 	// TODO: Revisit this, we may not need the Finished check.
 	if build == nil {
 		logger.Warn("no build info provided from the webhook event")
-		return nil, nil
+		return false
 	}
 
 	if build.Finished == 0 {
 		logger.Debug("build hasn't finished yet")
-		return nil, nil
+		return false
 	}
 
 	// Skip traces for repos that are not enabled
-	allowedBranches, ok := config.ReposConfig[evt.Repo.Slug]
+	allowedBranches, ok := config.ReposConfig[repo.Slug]
 	if !ok {
-		logger.Warn("repo not enabled, skipping", zap.String("repo", evt.Repo.Slug))
-		return nil, nil
+		logger.Warn("repo not enabled, skipping", zap.String("repo", repo.Slug))
+		return false
 	}
 
 	// Skip traces for branches that are not configured
 	if !slices.Contains(allowedBranches, repo.Branch) {
 		logger.Warn("branch not enabled, skipping", zap.String("branch", repo.Branch))
-		return nil, nil
+		return false
 	}
 
-	traces := ptrace.NewTraces()
-	logs := plog.NewLogs()
-
-	resourceSpans := traces.ResourceSpans().AppendEmpty()
-	scopeSpans := resourceSpans.ScopeSpans().AppendEmpty()
-
-	scopeSpans.Scope().SetName("dronereceiver")
-	scopeSpans.Scope().SetVersion("0.1.0")
-
-	resourceAttrs := resourceSpans.Resource().Attributes()
-	resourceAttrs.PutStr(conventions.AttributeServiceVersion, "0.1.0")
-	resourceAttrs.PutStr(conventions.AttributeServiceName, "drone")
-	resourceAttrs.PutStr(semconv.AttributeGitRepoName, repo.Slug)
-	resourceAttrs.PutStr(semconv.AttributeGitBranchName, repo.Branch)
-
-	buildSpan := scopeSpans.Spans().AppendEmpty()
-	buildSpan.SetTraceID(traceutils.NewTraceID())
-	buildSpan.SetSpanID(traceutils.NewSpanID())
-	buildSpan.SetParentSpanID(pcommon.NewSpanIDEmpty())
-	buildAttributes := buildSpan.Attributes()
-
-	buildAttributes.PutStr(semconv.AttributeDroneWorkflowItemKind, semconv.AttributeDroneWorkflowItemKindBuild)
+	return true
+}
 
-	buildAttributes.PutStr(semconv.AttributeDroneWorkflowEvent, build.Event)
+// buildToPipeline maps a finished build to the CI model, fetching the logs of
+// its steps.
+func buildToPipeline(ctx context.Context, evt WebhookEvent, config *Config, logs logSource, logPolicy *logpolicy.Policy, logger *zap.Logger) cimodel.Pipeline {
+	repo := evt.Repo
+	build := evt.Repo.Build
+	legacy := config.Semconv.EmitsLegacy()
+	vendor := config.vendor()
 
-	buildAttributes.PutInt(semconv.AttributeDroneBuildNumber, build.Number)
-	buildAttributes.PutInt(semconv.AttributeDroneBuildID, build.ID)
+	resourceAttrs := map[string]any{
+		conventions.AttributeServiceVersion: "0.1.0",
+		conventions.AttributeServiceName:    vendor,
+		semconv.AttributeGitBranchName:      repo.Branch,
+	}
+	if legacy {
+		resourceAttrs[semconv.AttributeGitRepoName] = repo.Slug
+	}
 
-	// Set build title and message
-	// The root span name will be the build title if it is set, otherwise it will be the build message.
-	buildAttributes.PutStr(semconv.AttributeDroneWorkflowTitle, build.Title)
-	buildAttributes.PutStr(semconv.AttributeDroneBuildMessage, build.Message)
+	buildAttributes := map[string]any{
+		semconv.AttributeDroneWorkflowItemKind: semconv.AttributeDroneWorkflowItemKindBuild,
+		semconv.AttributeCIWorkflowItemStatus:  build.Status,
+		semconv.AttributeDroneWorkflowEvent:    build.Event,
+		semconv.AttributeDroneBuildNumber:      build.Number,
 
-	traceutils.SetStatus(build.Status, buildSpan)
+		// Set build title and message
+		semconv.AttributeDroneWorkflowTitle: build.Title,
+		semconv.AttributeDroneBuildMessage:  build.Message,
 
-	buildSpan.SetStartTimestamp(pcommon.Timestamp(build.Created * 1000000000))
-	buildSpan.SetEndTimestamp(pcommon.Timestamp(build.Finished * 1000000000))
+		semconv.AttributeCIVendor:  vendor,
+		semconv.AttributeCIVersion: evt.Version,
 
-	buildAttributes.PutStr(semconv.AttributeCIVendor, semconv.AttributeCIVendorDrone)
-	buildAttributes.PutStr(semconv.AttributeCIVersion, evt.Version)
+		// --- Experimental attributes
+		semconv.AttributesDroneBuildBefore: build.Before,
+		semconv.AttributesDroneBuildRef:    build.Ref,
+		semconv.AttributesDroneBuildParent: build.Parent,
+	}
 
 	// --- VCS Info
 	// !FIXME: the scm property seems to always be empty, we fallback to GIT for now
@@ -107,100 +160,217 @@ func handleEvent(evt WebhookEvent, config *Config, droneClient drone.Client, log
 	if repo.SCM != "" {
 		vcsType = repo.SCM
 	}
-	buildAttributes.PutStr(semconv.AttributeVCSType, vcsType)
+	buildAttributes[semconv.AttributeVCSType] = vcsType
 
 	if vcsType == semconv.AttributeVCSTypeGit {
-		buildAttributes.PutStr(semconv.AttributeGitHTTPURL, repo.HTTPURL)
-		buildAttributes.PutStr(semconv.AttributeGitSSHURL, repo.SSHURL)
-		buildAttributes.PutStr(semconv.AttributeGitWWWURL, repo.Link)
+		buildAttributes[semconv.AttributeGitHTTPURL] = repo.HTTPURL
+		buildAttributes[semconv.AttributeGitSSHURL] = repo.SSHURL
+		if legacy {
+			buildAttributes[semconv.AttributeGitWWWURL] = repo.Link
+		}
 	}
 	// --- END VCS Info
 
-	// --- Experimental attributes
-	buildAttributes.PutStr(semconv.AttributesDroneBuildAfter, build.After)
-	buildAttributes.PutStr(semconv.AttributesDroneBuildBefore, build.Before)
-	buildAttributes.PutStr(semconv.AttributesDroneBuildLink, build.Link)
-	buildAttributes.PutStr(semconv.AttributesDroneBuildRef, build.Ref)
-	buildAttributes.PutStr(semconv.AttributesDroneBuildSource, build.Source)
-	buildAttributes.PutStr(semconv.AttributesDroneBuildTarget, build.Target)
-	buildAttributes.PutInt(semconv.AttributesDroneBuildParent, build.Parent)
-	// --- UNKNOWN INFO
+	if build.Cron != "" {
+		buildAttributes[semconv.AttributesDroneBuildCron] = build.Cron
+	}
+	if build.Deploy != "" {
+		if legacy {
+			buildAttributes[semconv.AttributesDroneBuildDeployTo] = build.Deploy
+		}
+		if config.Semconv.Enabled {
+			buildAttributes[semconv.AttributeDeploymentEnvironmentName] = build.Deploy
+		}
+	}
+	if build.DeployID != 0 {
+		buildAttributes[semconv.AttributesDroneBuildDeployID] = build.DeployID
+	}
+
+	if legacy {
+		buildAttributes[semconv.AttributeDroneBuildID] = build.ID
+		buildAttributes[semconv.AttributesDroneBuildAfter] = build.After
+		buildAttributes[semconv.AttributesDroneBuildLink] = build.Link
+		buildAttributes[semconv.AttributesDroneBuildSource] = build.Source
+		buildAttributes[semconv.AttributesDroneBuildTarget] = build.Target
+	}
+
+	pipeline := cimodel.Pipeline{
+		ID:       strconv.FormatInt(build.ID, 10),
+		Name:     repo.Slug,
+		URL:      build.Link,
+		Result:   droneResult(build.Status),
+		Status:   build.Status,
+		Started:  time.Unix(build.Created, 0),
+		Finished: time.Unix(build.Finished, 0),
+		Repository: cimodel.Repository{
+			Owner: repo.Namespace,
+			Name:  repo.Name,
+			URL:   repo.Link,
+		},
+		Ref: cimodel.Ref{
+			Head:     build.Source,
+			Revision: build.After,
+		},
+		TraceID:            generateTraceID(evt.Host, repo.ID, build.Number),
+		SpanID:             generateBuildSpanID(evt.Host, repo.ID, build.Number),
+		ResourceAttributes: resourceAttrs,
+		Attributes:         buildAttributes,
+	}
+	if build.Event == drone.EventPullRequest {
+		pipeline.Ref.Base = build.Target
+	}
+	// Promotions, rollbacks and restarts link the build they were created from
+	if build.Parent != 0 {
+		pipeline.Links = append(pipeline.Links, generateTraceID(evt.Host, repo.ID, build.Parent))
+	}
 
+	// Stages wait for the stages they depend on, by name
+	stageIDs := make(map[string]string, len(build.Stages))
 	for _, stage := range build.Stages {
-		stageSpans := resourceSpans.ScopeSpans().AppendEmpty()
-		stageSpan := stageSpans.Spans().AppendEmpty()
-		stageAttributes := stageSpan.Attributes()
+		stageIDs[stage.Name] = strconv.FormatInt(stage.ID, 10)
+	}
 
-		stageAttributes.PutStr(semconv.AttributeDroneWorkflowItemKind, semconv.AttributeDroneWorkflowItemKindStage)
-		stageSpan.SetTraceID(buildSpan.TraceID())
-		stageSpan.SetSpanID(traceutils.NewSpanID())
-		stageSpan.SetParentSpanID(buildSpan.SpanID())
+	approvals := buildApprovals(evt)
+	if a, ok := approvals[0]; ok {
+		pipeline.Tasks = append(pipeline.Tasks, approvalTask(evt, nil, a))
+	}
 
-		stageSpan.SetName(stage.Name)
-		stageAttributes.PutStr(conventions.AttributeServiceName, stage.Name)
+	var fetches []logFetch
+	for _, stage := range build.Stages {
+		if a, ok := approvals[stage.Number]; ok {
+			pipeline.Tasks = append(pipeline.Tasks, approvalTask(evt, stage, a))
+		}
 
-		traceutils.SetStatus(stage.Status, stageSpan)
+		stageAttributes := map[string]any{
+			semconv.AttributeDroneWorkflowItemKind: semconv.AttributeDroneWorkflowItemKindStage,
+			conventions.AttributeServiceName:       stage.Name,
+			semconv.AttributeCIWorkflowItemStatus:  stage.Status,
+			semconv.AttributeDroneStageNumber:      stage.Number,
+		}
+		if legacy {
+			stageAttributes[semconv.AttributeDroneStageID] = stage.ID
+			stageAttributes[semconv.AttributeDroneStageName] = stage.Name
+		}
 
-		stageAttributes.PutInt(semconv.AttributeDroneStageNumber, int64(stage.Number))
-		stageAttributes.PutInt(semconv.AttributeDroneStageID, stage.ID)
-		stageAttributes.PutStr(semconv.AttributeDroneStageName, stage.Name)
+		task := cimodel.Task{
+			ID:         strconv.FormatInt(stage.ID, 10),
+			Name:       stage.Name,
+			Worker:     stage.Machine,
+			Result:     droneResult(stage.Status),
+			Status:     stage.Status,
+			Started:    time.Unix(stage.Started, 0),
+			Finished:   time.Unix(stage.Stopped, 0),
+			SpanID:     generateStageSpanID(evt.Host, repo.ID, build.Number, stage.Number),
+			Attributes: stageAttributes,
+		}
+		if len(stage.DependsOn) > 0 {
+			dependsOn := make([]any, 0, len(stage.DependsOn))
+			for _, name := range stage.DependsOn {
+				dependsOn = append(dependsOn, name)
+				if id, ok := stageIDs[name]; ok {
+					task.Needs = append(task.Needs, id)
+				}
+			}
+			stageAttributes[semconv.AttributeDroneStageDependsOn] = dependsOn
+		}
 
-		stageSpan.SetStartTimestamp(pcommon.Timestamp(stage.Started * 1000000000))
-		stageSpan.SetEndTimestamp(pcommon.Timestamp(stage.Stopped * 1000000000))
+		keepLogs := logPolicy.KeepLogs(droneResult(stage.Status).Failed())
 
+		// Skipped steps didn't run, their spans are placed when the steps
+		// before them were done
+		last := stage.Started
 		for _, step := range stage.Steps {
-			if step.Status == "skipped" {
-				continue
+			started, stopped := step.Started, step.Stopped
+			if step.Status == drone.StatusSkipped {
+				if !config.Traces.SkippedSteps {
+					continue
+				}
+				started, stopped = last, last
+			}
+			last = max(last, stopped)
+
+			s := cimodel.Step{
+				Name:     step.Name,
+				Result:   droneResult(step.Status),
+				Status:   step.Status,
+				Started:  time.Unix(started, 0),
+				Finished: time.Unix(stopped, 0),
+				SpanID:   generateStepSpanID(evt.Host, repo.ID, build.Number, stage.Number, step.Number),
+				Attributes: map[string]any{
+					semconv.AttributeDroneWorkflowItemKind: semconv.AttributeDroneWorkflowItemKindStep,
+					semconv.AttributeCIWorkflowItemStatus:  step.Status,
+					semconv.AttributeDroneStageName:        stage.Name,
+					semconv.AttributeDroneStageID:          step.StageID,
+					semconv.AttributeDroneStepName:         step.Name,
+					semconv.AttributeDroneStepID:           step.ID,
+					semconv.AttributeDroneStepNumber:       step.Number,
+				},
+				LogAttributes: map[string]any{
+					semconv.AttributeDroneStageName:   stage.Name,
+					semconv.AttributeDroneStepName:    step.Name,
+					semconv.AttributeDroneBuildNumber: build.Number,
+				},
 			}
 
-			stepSpan := stageSpans.Spans().AppendEmpty()
-			stepSpan.SetTraceID(stageSpan.TraceID())
-			stepSpan.SetParentSpanID(stageSpan.SpanID())
-			stepSpan.SetSpanID(traceutils.NewSpanID())
-
-			stepAttributes := stepSpan.Attributes()
-
-			stepAttributes.PutStr(semconv.AttributeDroneWorkflowItemKind, semconv.AttributeDroneWorkflowItemKindStep)
-			stepAttributes.PutStr(semconv.AttributeDroneStageName, stage.Name)
-			stepAttributes.PutInt(semconv.AttributeDroneStageID, int64(step.StageID))
-
-			stepAttributes.PutStr(semconv.AttributeDroneStepName, step.Name)
-			stepAttributes.PutInt(semconv.AttributeDroneStepID, step.ID)
-			stepAttributes.PutInt(semconv.AttributeDroneStepNumber, int64(step.Number))
-
-			traceutils.SetStatus(step.Status, stepSpan)
-			stepSpan.SetName(step.Name)
-
-			stepSpan.SetStartTimestamp(pcommon.Timestamp(step.Started * 1000000000))
-			stepSpan.SetEndTimestamp(pcommon.Timestamp(step.Stopped * 1000000000))
-
-			// TODO: Handle the error in logs retrieval better
-			newLogs, err := generateLogs(droneClient, repo.Repo, *build, *stage, *step, buildSpan.TraceID(), stepSpan.SpanID())
-			if err != nil {
-				logger.Error("error retrieving logs", zap.Error(err))
-				continue
+			if keepLogs && step.Status != drone.StatusSkipped {
+				fetches = append(fetches, logFetch{stage: stage, step: step, task: len(pipeline.Tasks), index: len(task.Steps)})
 			}
 
-			newLogs.ResourceLogs().MoveAndAppendTo(logs.ResourceLogs())
+			task.Steps = append(task.Steps, s)
 		}
+
+		pipeline.Tasks = append(pipeline.Tasks, task)
 	}
 
-	return &traces, &logs
+	fetchStepLogs(ctx, logs, evt, &pipeline, fetches, config.DroneConfig.LogRetrieval.workers(), logger)
+	return pipeline
 }
 
-func generateLogs(drone drone.Client, repo drone.Repo, build drone.Build, stage drone.Stage, step drone.Step, traceId pcommon.TraceID, stepSpanId pcommon.SpanID) (plog.Logs, error) {
-	logs := plog.NewLogs()
+// approvalTask returns the span of the wait of a build for its approval,
+// or of one of its stages when stage isn't nil.
+func approvalTask(evt WebhookEvent, stage *drone.Stage, a approval) cimodel.Task {
+	status, result := "approved", cimodel.ResultSuccess
+	if a.declined {
+		status, result = drone.StatusDeclined, cimodel.ResultCancellation
+	}
 
-	lines, err := drone.Logs(repo.Namespace, repo.Name, int(build.Number), stage.Number, step.Number)
-	if err != nil {
-		return logs, err
+	attributes := map[string]any{
+		semconv.AttributeDroneWorkflowItemKind: semconv.AttributeDroneWorkflowItemKindApproval,
+		semconv.AttributeCIWorkflowItemStatus:  status,
+	}
+	if a.approver != "" {
+		attributes[semconv.AttributeDroneApprovalApprover] = a.approver
 	}
 
-	log := logs.ResourceLogs().AppendEmpty()
-	logScope := log.ScopeLogs().AppendEmpty()
+	task := cimodel.Task{
+		ID:         "approval",
+		Name:       "approval",
+		Result:     result,
+		Status:     status,
+		Started:    time.Unix(a.since, 0),
+		Finished:   time.Unix(a.until, 0),
+		SpanID:     generateApprovalSpanID(evt.Host, evt.Repo.ID, evt.Repo.Build.Number, 0),
+		Attributes: attributes,
+	}
+	if stage != nil {
+		task.ID = strconv.FormatInt(stage.ID, 10) + "/approval"
+		task.Name = stage.Name + " approval"
+		task.SpanID = generateApprovalSpanID(evt.Host, evt.Repo.ID, evt.Repo.Build.Number, stage.Number)
+		attributes[semconv.AttributeDroneStageName] = stage.Name
+		attributes[semconv.AttributeDroneStageNumber] = stage.Number
+	}
+	return task
+}
 
-	now := pcommon.NewTimestampFromTime(time.Now())
+// stepLogs retrieves the log lines of a step. Lines sharing a timestamp are
+// offset by a nanosecond each to keep their order.
+func stepLogs(ctx context.Context, logs logSource, repo drone.Repo, build drone.Build, stage drone.Stage, step drone.Step) ([]cimodel.LogEntry, error) {
+	lines, err := logs.StepLogs(ctx, repo, build, stage, step)
+	if err != nil {
+		return nil, err
+	}
 
+	entries := make([]cimodel.LogEntry, 0, len(lines))
 	prevLineTimestamp := int64(0)
 	delta := int64(0)
 	for _, line := range lines {
@@ -211,17 +381,29 @@ func generateLogs(drone drone.Client, repo drone.Repo, build drone.Build, stage
 			prevLineTimestamp = line.Timestamp
 		}
 
-		record := logScope.LogRecords().AppendEmpty()
-		record.SetTraceID(traceId)
-		record.SetSpanID(stepSpanId)
-
-		record.SetObservedTimestamp(now)
-		record.SetTimestamp(pcommon.Timestamp((step.Started+line.Timestamp)*1000000000 + delta))
-		record.Attributes().PutStr(semconv.AttributeDroneStageName, stage.Name)
-		record.Attributes().PutStr(semconv.AttributeDroneStepName, step.Name)
-		record.Attributes().PutInt(semconv.AttributeDroneBuildNumber, build.Number)
-		record.Body().SetStr(line.Message)
+		entries = append(entries, cimodel.LogEntry{
+			Timestamp: time.Unix(step.Started+line.Timestamp, delta),
+			Body:      line.Message,
+		})
 	}
 
-	return logs, nil
+	return entries, nil
+}
+
+// droneResult maps a Drone status to a CI model result.
+func droneResult(status string) cimodel.Result {
+	switch status {
+	case drone.StatusPassing:
+		return cimodel.ResultSuccess
+	case drone.StatusFailing:
+		return cimodel.ResultFailure
+	case drone.StatusError:
+		return cimodel.ResultError
+	case drone.StatusKilled, drone.StatusDeclined:
+		return cimodel.ResultCancellation
+	case drone.StatusSkipped:
+		return cimodel.ResultSkip
+	default:
+		return cimodel.ResultUnknown
+	}
 }
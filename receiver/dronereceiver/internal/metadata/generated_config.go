@@ -28,9 +28,17 @@ func (ms *MetricConfig) Unmarshal(parser *confmap.Conf) error {
 
 // MetricsConfig provides config for dronereceiver metrics.
 type MetricsConfig struct {
-	BuildsNumber  MetricConfig `mapstructure:"builds_number"`
-	RepoInfo      MetricConfig `mapstructure:"repo_info"`
-	RestartsTotal MetricConfig `mapstructure:"restarts_total"`
+	BuildsNumber     MetricConfig `mapstructure:"builds_number"`
+	BuildsTotal      MetricConfig `mapstructure:"builds_total"`
+	DatabaseUp       MetricConfig `mapstructure:"database_up"`
+	DeploymentsTotal MetricConfig `mapstructure:"deployments_total"`
+	QueueStages      MetricConfig `mapstructure:"queue_stages"`
+	RepoInfo         MetricConfig `mapstructure:"repo_info"`
+	RestartsTotal    MetricConfig `mapstructure:"restarts_total"`
+	Runners          MetricConfig `mapstructure:"runners"`
+	RunnersCapacity  MetricConfig `mapstructure:"runners_capacity"`
+	StagesTotal      MetricConfig `mapstructure:"stages_total"`
+	StepsTotal       MetricConfig `mapstructure:"steps_total"`
 }
 
 func DefaultMetricsConfig() MetricsConfig {
@@ -38,12 +46,36 @@ func DefaultMetricsConfig() MetricsConfig {
 		BuildsNumber: MetricConfig{
 			Enabled: true,
 		},
+		BuildsTotal: MetricConfig{
+			Enabled: true,
+		},
+		DatabaseUp: MetricConfig{
+			Enabled: true,
+		},
+		DeploymentsTotal: MetricConfig{
+			Enabled: true,
+		},
+		QueueStages: MetricConfig{
+			Enabled: true,
+		},
 		RepoInfo: MetricConfig{
 			Enabled: true,
 		},
 		RestartsTotal: MetricConfig{
 			Enabled: true,
 		},
+		Runners: MetricConfig{
+			Enabled: true,
+		},
+		RunnersCapacity: MetricConfig{
+			Enabled: true,
+		},
+		StagesTotal: MetricConfig{
+			Enabled: true,
+		},
+		StepsTotal: MetricConfig{
+			Enabled: true,
+		},
 	}
 }
 
@@ -30,12 +30,36 @@ func TestMetricsBuilderConfig(t *testing.T) {
 					BuildsNumber: MetricConfig{
 						Enabled: true,
 					},
+					BuildsTotal: MetricConfig{
+						Enabled: true,
+					},
+					DatabaseUp: MetricConfig{
+						Enabled: true,
+					},
+					DeploymentsTotal: MetricConfig{
+						Enabled: true,
+					},
+					QueueStages: MetricConfig{
+						Enabled: true,
+					},
 					RepoInfo: MetricConfig{
 						Enabled: true,
 					},
 					RestartsTotal: MetricConfig{
 						Enabled: true,
 					},
+					Runners: MetricConfig{
+						Enabled: true,
+					},
+					RunnersCapacity: MetricConfig{
+						Enabled: true,
+					},
+					StagesTotal: MetricConfig{
+						Enabled: true,
+					},
+					StepsTotal: MetricConfig{
+						Enabled: true,
+					},
 				},
 			},
 		},
@@ -46,12 +70,36 @@ func TestMetricsBuilderConfig(t *testing.T) {
 					BuildsNumber: MetricConfig{
 						Enabled: false,
 					},
+					BuildsTotal: MetricConfig{
+						Enabled: false,
+					},
+					DatabaseUp: MetricConfig{
+						Enabled: false,
+					},
+					DeploymentsTotal: MetricConfig{
+						Enabled: false,
+					},
+					QueueStages: MetricConfig{
+						Enabled: false,
+					},
 					RepoInfo: MetricConfig{
 						Enabled: false,
 					},
 					RestartsTotal: MetricConfig{
 						Enabled: false,
 					},
+					Runners: MetricConfig{
+						Enabled: false,
+					},
+					RunnersCapacity: MetricConfig{
+						Enabled: false,
+					},
+					StagesTotal: MetricConfig{
+						Enabled: false,
+					},
+					StepsTotal: MetricConfig{
+						Enabled: false,
+					},
 				},
 			},
 		},
@@ -74,18 +74,50 @@ var MetricsInfo = metricsInfo{
 	BuildsNumber: metricInfo{
 		Name: "builds_number",
 	},
+	BuildsTotal: metricInfo{
+		Name: "builds_total",
+	},
+	DatabaseUp: metricInfo{
+		Name: "database_up",
+	},
+	DeploymentsTotal: metricInfo{
+		Name: "deployments_total",
+	},
+	QueueStages: metricInfo{
+		Name: "queue_stages",
+	},
 	RepoInfo: metricInfo{
 		Name: "repo_info",
 	},
 	RestartsTotal: metricInfo{
 		Name: "restarts_total",
 	},
+	Runners: metricInfo{
+		Name: "runners",
+	},
+	RunnersCapacity: metricInfo{
+		Name: "runners_capacity",
+	},
+	StagesTotal: metricInfo{
+		Name: "stages_total",
+	},
+	StepsTotal: metricInfo{
+		Name: "steps_total",
+	},
 }
 
 type metricsInfo struct {
-	BuildsNumber  metricInfo
-	RepoInfo      metricInfo
-	RestartsTotal metricInfo
+	BuildsNumber     metricInfo
+	BuildsTotal      metricInfo
+	DatabaseUp       metricInfo
+	DeploymentsTotal metricInfo
+	QueueStages      metricInfo
+	RepoInfo         metricInfo
+	RestartsTotal    metricInfo
+	Runners          metricInfo
+	RunnersCapacity  metricInfo
+	StagesTotal      metricInfo
+	StepsTotal       metricInfo
 }
 
 type metricInfo struct {
@@ -148,6 +180,225 @@ func newMetricBuildsNumber(cfg MetricConfig) metricBuildsNumber {
 	return m
 }
 
+type metricBuildsTotal struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills builds_total metric with initial data.
+func (m *metricBuildsTotal) init() {
+	m.data.SetName("builds_total")
+	m.data.SetDescription("Number of finished builds reported by webhooks.")
+	m.data.SetUnit("{build}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricBuildsTotal) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue string, gitRepoNameAttributeValue string, gitBranchNameAttributeValue string, ciDroneWorkflowEventAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.workflow_item.status", ciWorkflowItemStatusAttributeValue)
+	dp.Attributes().PutStr("git.repo.name", gitRepoNameAttributeValue)
+	dp.Attributes().PutStr("git.branch.name", gitBranchNameAttributeValue)
+	dp.Attributes().PutStr("ci.drone.workflow.event", ciDroneWorkflowEventAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricBuildsTotal) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricBuildsTotal) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricBuildsTotal(cfg MetricConfig) metricBuildsTotal {
+	m := metricBuildsTotal{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricDatabaseUp struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills database_up metric with initial data.
+func (m *metricDatabaseUp) init() {
+	m.data.SetName("database_up")
+	m.data.SetDescription("Whether the database of Drone could be reached at the last scrape, 1 when it could and 0 otherwise.")
+	m.data.SetUnit("1")
+	m.data.SetEmptyGauge()
+}
+
+func (m *metricDatabaseUp) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Gauge().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricDatabaseUp) updateCapacity() {
+	if m.data.Gauge().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Gauge().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricDatabaseUp) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Gauge().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricDatabaseUp(cfg MetricConfig) metricDatabaseUp {
+	m := metricDatabaseUp{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricDeploymentsTotal struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills deployments_total metric with initial data.
+func (m *metricDeploymentsTotal) init() {
+	m.data.SetName("deployments_total")
+	m.data.SetDescription("Number of finished deployments reported by webhooks, promotions and rollbacks, by environment.")
+	m.data.SetUnit("{deployment}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricDeploymentsTotal) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue string, gitRepoNameAttributeValue string, deploymentEnvironmentNameAttributeValue string, ciDroneWorkflowEventAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.workflow_item.status", ciWorkflowItemStatusAttributeValue)
+	dp.Attributes().PutStr("git.repo.name", gitRepoNameAttributeValue)
+	dp.Attributes().PutStr("deployment.environment.name", deploymentEnvironmentNameAttributeValue)
+	dp.Attributes().PutStr("ci.drone.workflow.event", ciDroneWorkflowEventAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricDeploymentsTotal) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricDeploymentsTotal) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricDeploymentsTotal(cfg MetricConfig) metricDeploymentsTotal {
+	m := metricDeploymentsTotal{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricQueueStages struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills queue_stages metric with initial data.
+func (m *metricQueueStages) init() {
+	m.data.SetName("queue_stages")
+	m.data.SetDescription("Number of stages in the queue of Drone, by status, pending or running.")
+	m.data.SetUnit("{stage}")
+	m.data.SetEmptyGauge()
+	m.data.Gauge().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricQueueStages) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue string, ciDroneOsAttributeValue string, ciDroneArchAttributeValue string, ciDroneLabelsAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Gauge().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.workflow_item.status", ciWorkflowItemStatusAttributeValue)
+	dp.Attributes().PutStr("ci.drone.os", ciDroneOsAttributeValue)
+	dp.Attributes().PutStr("ci.drone.arch", ciDroneArchAttributeValue)
+	dp.Attributes().PutStr("ci.drone.labels", ciDroneLabelsAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricQueueStages) updateCapacity() {
+	if m.data.Gauge().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Gauge().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricQueueStages) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Gauge().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricQueueStages(cfg MetricConfig) metricQueueStages {
+	m := metricQueueStages{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
 type metricRepoInfo struct {
 	data     pmetric.Metric // data buffer for generated metric.
 	config   MetricConfig   // metric config provided by user.
@@ -256,17 +507,247 @@ func newMetricRestartsTotal(cfg MetricConfig) metricRestartsTotal {
 	return m
 }
 
+type metricRunners struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills runners metric with initial data.
+func (m *metricRunners) init() {
+	m.data.SetName("runners")
+	m.data.SetDescription("Number of runners registered with Drone and not paused.")
+	m.data.SetUnit("{runner}")
+	m.data.SetEmptyGauge()
+	m.data.Gauge().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricRunners) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciDroneOsAttributeValue string, ciDroneArchAttributeValue string, ciDroneLabelsAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Gauge().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.drone.os", ciDroneOsAttributeValue)
+	dp.Attributes().PutStr("ci.drone.arch", ciDroneArchAttributeValue)
+	dp.Attributes().PutStr("ci.drone.labels", ciDroneLabelsAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricRunners) updateCapacity() {
+	if m.data.Gauge().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Gauge().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricRunners) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Gauge().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricRunners(cfg MetricConfig) metricRunners {
+	m := metricRunners{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricRunnersCapacity struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills runners_capacity metric with initial data.
+func (m *metricRunnersCapacity) init() {
+	m.data.SetName("runners_capacity")
+	m.data.SetDescription("Number of stages the runners registered with Drone and not paused can run at once.")
+	m.data.SetUnit("{stage}")
+	m.data.SetEmptyGauge()
+	m.data.Gauge().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricRunnersCapacity) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciDroneOsAttributeValue string, ciDroneArchAttributeValue string, ciDroneLabelsAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Gauge().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.drone.os", ciDroneOsAttributeValue)
+	dp.Attributes().PutStr("ci.drone.arch", ciDroneArchAttributeValue)
+	dp.Attributes().PutStr("ci.drone.labels", ciDroneLabelsAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricRunnersCapacity) updateCapacity() {
+	if m.data.Gauge().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Gauge().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricRunnersCapacity) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Gauge().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricRunnersCapacity(cfg MetricConfig) metricRunnersCapacity {
+	m := metricRunnersCapacity{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricStagesTotal struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills stages_total metric with initial data.
+func (m *metricStagesTotal) init() {
+	m.data.SetName("stages_total")
+	m.data.SetDescription("Number of stages of the finished builds reported by webhooks.")
+	m.data.SetUnit("{stage}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricStagesTotal) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue string, gitRepoNameAttributeValue string, gitBranchNameAttributeValue string, ciDroneWorkflowEventAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.workflow_item.status", ciWorkflowItemStatusAttributeValue)
+	dp.Attributes().PutStr("git.repo.name", gitRepoNameAttributeValue)
+	dp.Attributes().PutStr("git.branch.name", gitBranchNameAttributeValue)
+	dp.Attributes().PutStr("ci.drone.workflow.event", ciDroneWorkflowEventAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricStagesTotal) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricStagesTotal) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricStagesTotal(cfg MetricConfig) metricStagesTotal {
+	m := metricStagesTotal{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricStepsTotal struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills steps_total metric with initial data.
+func (m *metricStepsTotal) init() {
+	m.data.SetName("steps_total")
+	m.data.SetDescription("Number of steps of the finished builds reported by webhooks.")
+	m.data.SetUnit("{step}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricStepsTotal) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue string, gitRepoNameAttributeValue string, gitBranchNameAttributeValue string, ciDroneWorkflowEventAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.workflow_item.status", ciWorkflowItemStatusAttributeValue)
+	dp.Attributes().PutStr("git.repo.name", gitRepoNameAttributeValue)
+	dp.Attributes().PutStr("git.branch.name", gitBranchNameAttributeValue)
+	dp.Attributes().PutStr("ci.drone.workflow.event", ciDroneWorkflowEventAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricStepsTotal) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricStepsTotal) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricStepsTotal(cfg MetricConfig) metricStepsTotal {
+	m := metricStepsTotal{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
 // MetricsBuilder provides an interface for scrapers to report metrics while taking care of all the transformations
 // required to produce metric representation defined in metadata and user config.
 type MetricsBuilder struct {
-	config              MetricsBuilderConfig // config of the metrics builder.
-	startTime           pcommon.Timestamp    // start time that will be applied to all recorded data points.
-	metricsCapacity     int                  // maximum observed number of metrics per resource.
-	metricsBuffer       pmetric.Metrics      // accumulates metrics data before emitting.
-	buildInfo           component.BuildInfo  // contains version information.
-	metricBuildsNumber  metricBuildsNumber
-	metricRepoInfo      metricRepoInfo
-	metricRestartsTotal metricRestartsTotal
+	config                 MetricsBuilderConfig // config of the metrics builder.
+	startTime              pcommon.Timestamp    // start time that will be applied to all recorded data points.
+	metricsCapacity        int                  // maximum observed number of metrics per resource.
+	metricsBuffer          pmetric.Metrics      // accumulates metrics data before emitting.
+	buildInfo              component.BuildInfo  // contains version information.
+	metricBuildsNumber     metricBuildsNumber
+	metricBuildsTotal      metricBuildsTotal
+	metricDatabaseUp       metricDatabaseUp
+	metricDeploymentsTotal metricDeploymentsTotal
+	metricQueueStages      metricQueueStages
+	metricRepoInfo         metricRepoInfo
+	metricRestartsTotal    metricRestartsTotal
+	metricRunners          metricRunners
+	metricRunnersCapacity  metricRunnersCapacity
+	metricStagesTotal      metricStagesTotal
+	metricStepsTotal       metricStepsTotal
 }
 
 // MetricBuilderOption applies changes to default metrics builder.
@@ -288,13 +769,21 @@ func WithStartTime(startTime pcommon.Timestamp) MetricBuilderOption {
 }
 func NewMetricsBuilder(mbc MetricsBuilderConfig, settings receiver.Settings, options ...MetricBuilderOption) *MetricsBuilder {
 	mb := &MetricsBuilder{
-		config:              mbc,
-		startTime:           pcommon.NewTimestampFromTime(time.Now()),
-		metricsBuffer:       pmetric.NewMetrics(),
-		buildInfo:           settings.BuildInfo,
-		metricBuildsNumber:  newMetricBuildsNumber(mbc.Metrics.BuildsNumber),
-		metricRepoInfo:      newMetricRepoInfo(mbc.Metrics.RepoInfo),
-		metricRestartsTotal: newMetricRestartsTotal(mbc.Metrics.RestartsTotal),
+		config:                 mbc,
+		startTime:              pcommon.NewTimestampFromTime(time.Now()),
+		metricsBuffer:          pmetric.NewMetrics(),
+		buildInfo:              settings.BuildInfo,
+		metricBuildsNumber:     newMetricBuildsNumber(mbc.Metrics.BuildsNumber),
+		metricBuildsTotal:      newMetricBuildsTotal(mbc.Metrics.BuildsTotal),
+		metricDatabaseUp:       newMetricDatabaseUp(mbc.Metrics.DatabaseUp),
+		metricDeploymentsTotal: newMetricDeploymentsTotal(mbc.Metrics.DeploymentsTotal),
+		metricQueueStages:      newMetricQueueStages(mbc.Metrics.QueueStages),
+		metricRepoInfo:         newMetricRepoInfo(mbc.Metrics.RepoInfo),
+		metricRestartsTotal:    newMetricRestartsTotal(mbc.Metrics.RestartsTotal),
+		metricRunners:          newMetricRunners(mbc.Metrics.Runners),
+		metricRunnersCapacity:  newMetricRunnersCapacity(mbc.Metrics.RunnersCapacity),
+		metricStagesTotal:      newMetricStagesTotal(mbc.Metrics.StagesTotal),
+		metricStepsTotal:       newMetricStepsTotal(mbc.Metrics.StepsTotal),
 	}
 
 	for _, op := range options {
@@ -362,8 +851,16 @@ func (mb *MetricsBuilder) EmitForResource(options ...ResourceMetricsOption) {
 	ils.Scope().SetVersion(mb.buildInfo.Version)
 	ils.Metrics().EnsureCapacity(mb.metricsCapacity)
 	mb.metricBuildsNumber.emit(ils.Metrics())
+	mb.metricBuildsTotal.emit(ils.Metrics())
+	mb.metricDatabaseUp.emit(ils.Metrics())
+	mb.metricDeploymentsTotal.emit(ils.Metrics())
+	mb.metricQueueStages.emit(ils.Metrics())
 	mb.metricRepoInfo.emit(ils.Metrics())
 	mb.metricRestartsTotal.emit(ils.Metrics())
+	mb.metricRunners.emit(ils.Metrics())
+	mb.metricRunnersCapacity.emit(ils.Metrics())
+	mb.metricStagesTotal.emit(ils.Metrics())
+	mb.metricStepsTotal.emit(ils.Metrics())
 
 	for _, op := range options {
 		op.apply(rm)
@@ -390,6 +887,26 @@ func (mb *MetricsBuilder) RecordBuildsNumberDataPoint(ts pcommon.Timestamp, val
 	mb.metricBuildsNumber.recordDataPoint(mb.startTime, ts, val, ciWorkflowItemStatusAttributeValue.String(), gitRepoNameAttributeValue, gitBranchNameAttributeValue)
 }
 
+// RecordBuildsTotalDataPoint adds a data point to builds_total metric.
+func (mb *MetricsBuilder) RecordBuildsTotalDataPoint(ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue AttributeCiWorkflowItemStatus, gitRepoNameAttributeValue string, gitBranchNameAttributeValue string, ciDroneWorkflowEventAttributeValue string) {
+	mb.metricBuildsTotal.recordDataPoint(mb.startTime, ts, val, ciWorkflowItemStatusAttributeValue.String(), gitRepoNameAttributeValue, gitBranchNameAttributeValue, ciDroneWorkflowEventAttributeValue)
+}
+
+// RecordDatabaseUpDataPoint adds a data point to database_up metric.
+func (mb *MetricsBuilder) RecordDatabaseUpDataPoint(ts pcommon.Timestamp, val int64) {
+	mb.metricDatabaseUp.recordDataPoint(mb.startTime, ts, val)
+}
+
+// RecordDeploymentsTotalDataPoint adds a data point to deployments_total metric.
+func (mb *MetricsBuilder) RecordDeploymentsTotalDataPoint(ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue AttributeCiWorkflowItemStatus, gitRepoNameAttributeValue string, deploymentEnvironmentNameAttributeValue string, ciDroneWorkflowEventAttributeValue string) {
+	mb.metricDeploymentsTotal.recordDataPoint(mb.startTime, ts, val, ciWorkflowItemStatusAttributeValue.String(), gitRepoNameAttributeValue, deploymentEnvironmentNameAttributeValue, ciDroneWorkflowEventAttributeValue)
+}
+
+// RecordQueueStagesDataPoint adds a data point to queue_stages metric.
+func (mb *MetricsBuilder) RecordQueueStagesDataPoint(ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue AttributeCiWorkflowItemStatus, ciDroneOsAttributeValue string, ciDroneArchAttributeValue string, ciDroneLabelsAttributeValue string) {
+	mb.metricQueueStages.recordDataPoint(mb.startTime, ts, val, ciWorkflowItemStatusAttributeValue.String(), ciDroneOsAttributeValue, ciDroneArchAttributeValue, ciDroneLabelsAttributeValue)
+}
+
 // RecordRepoInfoDataPoint adds a data point to repo_info metric.
 func (mb *MetricsBuilder) RecordRepoInfoDataPoint(ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue AttributeCiWorkflowItemStatus, gitRepoNameAttributeValue string, gitBranchNameAttributeValue string) {
 	mb.metricRepoInfo.recordDataPoint(mb.startTime, ts, val, ciWorkflowItemStatusAttributeValue.String(), gitRepoNameAttributeValue, gitBranchNameAttributeValue)
@@ -400,6 +917,26 @@ func (mb *MetricsBuilder) RecordRestartsTotalDataPoint(ts pcommon.Timestamp, val
 	mb.metricRestartsTotal.recordDataPoint(mb.startTime, ts, val)
 }
 
+// RecordRunnersDataPoint adds a data point to runners metric.
+func (mb *MetricsBuilder) RecordRunnersDataPoint(ts pcommon.Timestamp, val int64, ciDroneOsAttributeValue string, ciDroneArchAttributeValue string, ciDroneLabelsAttributeValue string) {
+	mb.metricRunners.recordDataPoint(mb.startTime, ts, val, ciDroneOsAttributeValue, ciDroneArchAttributeValue, ciDroneLabelsAttributeValue)
+}
+
+// RecordRunnersCapacityDataPoint adds a data point to runners_capacity metric.
+func (mb *MetricsBuilder) RecordRunnersCapacityDataPoint(ts pcommon.Timestamp, val int64, ciDroneOsAttributeValue string, ciDroneArchAttributeValue string, ciDroneLabelsAttributeValue string) {
+	mb.metricRunnersCapacity.recordDataPoint(mb.startTime, ts, val, ciDroneOsAttributeValue, ciDroneArchAttributeValue, ciDroneLabelsAttributeValue)
+}
+
+// RecordStagesTotalDataPoint adds a data point to stages_total metric.
+func (mb *MetricsBuilder) RecordStagesTotalDataPoint(ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue AttributeCiWorkflowItemStatus, gitRepoNameAttributeValue string, gitBranchNameAttributeValue string, ciDroneWorkflowEventAttributeValue string) {
+	mb.metricStagesTotal.recordDataPoint(mb.startTime, ts, val, ciWorkflowItemStatusAttributeValue.String(), gitRepoNameAttributeValue, gitBranchNameAttributeValue, ciDroneWorkflowEventAttributeValue)
+}
+
+// RecordStepsTotalDataPoint adds a data point to steps_total metric.
+func (mb *MetricsBuilder) RecordStepsTotalDataPoint(ts pcommon.Timestamp, val int64, ciWorkflowItemStatusAttributeValue AttributeCiWorkflowItemStatus, gitRepoNameAttributeValue string, gitBranchNameAttributeValue string, ciDroneWorkflowEventAttributeValue string) {
+	mb.metricStepsTotal.recordDataPoint(mb.startTime, ts, val, ciWorkflowItemStatusAttributeValue.String(), gitRepoNameAttributeValue, gitBranchNameAttributeValue, ciDroneWorkflowEventAttributeValue)
+}
+
 // Reset resets metrics builder to its initial state. It should be used when external metrics source is restarted,
 // and metrics builder should update its startTime and reset it's internal state accordingly.
 func (mb *MetricsBuilder) Reset(options ...MetricBuilderOption) {
@@ -62,6 +62,22 @@ func TestMetricsBuilder(t *testing.T) {
 			allMetricsCount++
 			mb.RecordBuildsNumberDataPoint(ts, 1, AttributeCiWorkflowItemStatusSkipped, "git.repo.name-val", "git.branch.name-val")
 
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordBuildsTotalDataPoint(ts, 1, AttributeCiWorkflowItemStatusSkipped, "git.repo.name-val", "git.branch.name-val", "ci.drone.workflow.event-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordDatabaseUpDataPoint(ts, 1)
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordDeploymentsTotalDataPoint(ts, 1, AttributeCiWorkflowItemStatusSkipped, "git.repo.name-val", "deployment.environment.name-val", "ci.drone.workflow.event-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordQueueStagesDataPoint(ts, 1, AttributeCiWorkflowItemStatusSkipped, "ci.drone.os-val", "ci.drone.arch-val", "ci.drone.labels-val")
+
 			defaultMetricsCount++
 			allMetricsCount++
 			mb.RecordRepoInfoDataPoint(ts, 1, AttributeCiWorkflowItemStatusSkipped, "git.repo.name-val", "git.branch.name-val")
@@ -70,6 +86,22 @@ func TestMetricsBuilder(t *testing.T) {
 			allMetricsCount++
 			mb.RecordRestartsTotalDataPoint(ts, 1)
 
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordRunnersDataPoint(ts, 1, "ci.drone.os-val", "ci.drone.arch-val", "ci.drone.labels-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordRunnersCapacityDataPoint(ts, 1, "ci.drone.os-val", "ci.drone.arch-val", "ci.drone.labels-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordStagesTotalDataPoint(ts, 1, AttributeCiWorkflowItemStatusSkipped, "git.repo.name-val", "git.branch.name-val", "ci.drone.workflow.event-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordStepsTotalDataPoint(ts, 1, AttributeCiWorkflowItemStatusSkipped, "git.repo.name-val", "git.branch.name-val", "ci.drone.workflow.event-val")
+
 			res := pcommon.NewResource()
 			metrics := mb.Emit(WithResource(res))
 
@@ -121,6 +153,94 @@ func TestMetricsBuilder(t *testing.T) {
 					gitBranchNameAttrVal, ok := dp.Attributes().Get("git.branch.name")
 					assert.True(t, ok)
 					assert.Equal(t, "git.branch.name-val", gitBranchNameAttrVal.Str())
+				case "builds_total":
+					assert.False(t, validatedMetrics["builds_total"], "Found a duplicate in the metrics slice: builds_total")
+					validatedMetrics["builds_total"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Number of finished builds reported by webhooks.", mi.Description())
+					assert.Equal(t, "{build}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciWorkflowItemStatusAttrVal, ok := dp.Attributes().Get("ci.workflow_item.status")
+					assert.True(t, ok)
+					assert.Equal(t, "skipped", ciWorkflowItemStatusAttrVal.Str())
+					gitRepoNameAttrVal, ok := dp.Attributes().Get("git.repo.name")
+					assert.True(t, ok)
+					assert.Equal(t, "git.repo.name-val", gitRepoNameAttrVal.Str())
+					gitBranchNameAttrVal, ok := dp.Attributes().Get("git.branch.name")
+					assert.True(t, ok)
+					assert.Equal(t, "git.branch.name-val", gitBranchNameAttrVal.Str())
+					ciDroneWorkflowEventAttrVal, ok := dp.Attributes().Get("ci.drone.workflow.event")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.workflow.event-val", ciDroneWorkflowEventAttrVal.Str())
+				case "database_up":
+					assert.False(t, validatedMetrics["database_up"], "Found a duplicate in the metrics slice: database_up")
+					validatedMetrics["database_up"] = true
+					assert.Equal(t, pmetric.MetricTypeGauge, mi.Type())
+					assert.Equal(t, 1, mi.Gauge().DataPoints().Len())
+					assert.Equal(t, "Whether the database of Drone could be reached at the last scrape, 1 when it could and 0 otherwise.", mi.Description())
+					assert.Equal(t, "1", mi.Unit())
+					dp := mi.Gauge().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+				case "deployments_total":
+					assert.False(t, validatedMetrics["deployments_total"], "Found a duplicate in the metrics slice: deployments_total")
+					validatedMetrics["deployments_total"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Number of finished deployments reported by webhooks, promotions and rollbacks, by environment.", mi.Description())
+					assert.Equal(t, "{deployment}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciWorkflowItemStatusAttrVal, ok := dp.Attributes().Get("ci.workflow_item.status")
+					assert.True(t, ok)
+					assert.Equal(t, "skipped", ciWorkflowItemStatusAttrVal.Str())
+					gitRepoNameAttrVal, ok := dp.Attributes().Get("git.repo.name")
+					assert.True(t, ok)
+					assert.Equal(t, "git.repo.name-val", gitRepoNameAttrVal.Str())
+					deploymentEnvironmentNameAttrVal, ok := dp.Attributes().Get("deployment.environment.name")
+					assert.True(t, ok)
+					assert.Equal(t, "deployment.environment.name-val", deploymentEnvironmentNameAttrVal.Str())
+					ciDroneWorkflowEventAttrVal, ok := dp.Attributes().Get("ci.drone.workflow.event")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.workflow.event-val", ciDroneWorkflowEventAttrVal.Str())
+				case "queue_stages":
+					assert.False(t, validatedMetrics["queue_stages"], "Found a duplicate in the metrics slice: queue_stages")
+					validatedMetrics["queue_stages"] = true
+					assert.Equal(t, pmetric.MetricTypeGauge, mi.Type())
+					assert.Equal(t, 1, mi.Gauge().DataPoints().Len())
+					assert.Equal(t, "Number of stages in the queue of Drone, by status, pending or running.", mi.Description())
+					assert.Equal(t, "{stage}", mi.Unit())
+					dp := mi.Gauge().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciWorkflowItemStatusAttrVal, ok := dp.Attributes().Get("ci.workflow_item.status")
+					assert.True(t, ok)
+					assert.Equal(t, "skipped", ciWorkflowItemStatusAttrVal.Str())
+					ciDroneOsAttrVal, ok := dp.Attributes().Get("ci.drone.os")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.os-val", ciDroneOsAttrVal.Str())
+					ciDroneArchAttrVal, ok := dp.Attributes().Get("ci.drone.arch")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.arch-val", ciDroneArchAttrVal.Str())
+					ciDroneLabelsAttrVal, ok := dp.Attributes().Get("ci.drone.labels")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.labels-val", ciDroneLabelsAttrVal.Str())
 				case "repo_info":
 					assert.False(t, validatedMetrics["repo_info"], "Found a duplicate in the metrics slice: repo_info")
 					validatedMetrics["repo_info"] = true
@@ -158,6 +278,100 @@ func TestMetricsBuilder(t *testing.T) {
 					assert.Equal(t, ts, dp.Timestamp())
 					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
 					assert.Equal(t, int64(1), dp.IntValue())
+				case "runners":
+					assert.False(t, validatedMetrics["runners"], "Found a duplicate in the metrics slice: runners")
+					validatedMetrics["runners"] = true
+					assert.Equal(t, pmetric.MetricTypeGauge, mi.Type())
+					assert.Equal(t, 1, mi.Gauge().DataPoints().Len())
+					assert.Equal(t, "Number of runners registered with Drone and not paused.", mi.Description())
+					assert.Equal(t, "{runner}", mi.Unit())
+					dp := mi.Gauge().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciDroneOsAttrVal, ok := dp.Attributes().Get("ci.drone.os")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.os-val", ciDroneOsAttrVal.Str())
+					ciDroneArchAttrVal, ok := dp.Attributes().Get("ci.drone.arch")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.arch-val", ciDroneArchAttrVal.Str())
+					ciDroneLabelsAttrVal, ok := dp.Attributes().Get("ci.drone.labels")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.labels-val", ciDroneLabelsAttrVal.Str())
+				case "runners_capacity":
+					assert.False(t, validatedMetrics["runners_capacity"], "Found a duplicate in the metrics slice: runners_capacity")
+					validatedMetrics["runners_capacity"] = true
+					assert.Equal(t, pmetric.MetricTypeGauge, mi.Type())
+					assert.Equal(t, 1, mi.Gauge().DataPoints().Len())
+					assert.Equal(t, "Number of stages the runners registered with Drone and not paused can run at once.", mi.Description())
+					assert.Equal(t, "{stage}", mi.Unit())
+					dp := mi.Gauge().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciDroneOsAttrVal, ok := dp.Attributes().Get("ci.drone.os")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.os-val", ciDroneOsAttrVal.Str())
+					ciDroneArchAttrVal, ok := dp.Attributes().Get("ci.drone.arch")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.arch-val", ciDroneArchAttrVal.Str())
+					ciDroneLabelsAttrVal, ok := dp.Attributes().Get("ci.drone.labels")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.labels-val", ciDroneLabelsAttrVal.Str())
+				case "stages_total":
+					assert.False(t, validatedMetrics["stages_total"], "Found a duplicate in the metrics slice: stages_total")
+					validatedMetrics["stages_total"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Number of stages of the finished builds reported by webhooks.", mi.Description())
+					assert.Equal(t, "{stage}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciWorkflowItemStatusAttrVal, ok := dp.Attributes().Get("ci.workflow_item.status")
+					assert.True(t, ok)
+					assert.Equal(t, "skipped", ciWorkflowItemStatusAttrVal.Str())
+					gitRepoNameAttrVal, ok := dp.Attributes().Get("git.repo.name")
+					assert.True(t, ok)
+					assert.Equal(t, "git.repo.name-val", gitRepoNameAttrVal.Str())
+					gitBranchNameAttrVal, ok := dp.Attributes().Get("git.branch.name")
+					assert.True(t, ok)
+					assert.Equal(t, "git.branch.name-val", gitBranchNameAttrVal.Str())
+					ciDroneWorkflowEventAttrVal, ok := dp.Attributes().Get("ci.drone.workflow.event")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.workflow.event-val", ciDroneWorkflowEventAttrVal.Str())
+				case "steps_total":
+					assert.False(t, validatedMetrics["steps_total"], "Found a duplicate in the metrics slice: steps_total")
+					validatedMetrics["steps_total"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Number of steps of the finished builds reported by webhooks.", mi.Description())
+					assert.Equal(t, "{step}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					ciWorkflowItemStatusAttrVal, ok := dp.Attributes().Get("ci.workflow_item.status")
+					assert.True(t, ok)
+					assert.Equal(t, "skipped", ciWorkflowItemStatusAttrVal.Str())
+					gitRepoNameAttrVal, ok := dp.Attributes().Get("git.repo.name")
+					assert.True(t, ok)
+					assert.Equal(t, "git.repo.name-val", gitRepoNameAttrVal.Str())
+					gitBranchNameAttrVal, ok := dp.Attributes().Get("git.branch.name")
+					assert.True(t, ok)
+					assert.Equal(t, "git.branch.name-val", gitBranchNameAttrVal.Str())
+					ciDroneWorkflowEventAttrVal, ok := dp.Attributes().Get("ci.drone.workflow.event")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.drone.workflow.event-val", ciDroneWorkflowEventAttrVal.Str())
 				}
 			}
 		})
@@ -0,0 +1,89 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"errors"
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+
+	"go.opentelemetry.io/collector/component"
+)
+
+func Meter(settings component.TelemetrySettings) metric.Meter {
+	return settings.MeterProvider.Meter("github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver")
+}
+
+func Tracer(settings component.TelemetrySettings) trace.Tracer {
+	return settings.TracerProvider.Tracer("github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver")
+}
+
+// TelemetryBuilder provides an interface for components to report telemetry
+// as defined in metadata and user config.
+type TelemetryBuilder struct {
+	meter                        metric.Meter
+	mu                           sync.Mutex
+	registrations                []metric.Registration
+	ReceiverLogsDroppedLines     metric.Int64Counter
+	ReceiverLogsFailedFetches    metric.Int64Counter
+	ReceiverLogsOversizedEntries metric.Int64Counter
+	ReceiverLogsRedactedLines    metric.Int64Counter
+}
+
+// TelemetryBuilderOption applies changes to default builder.
+type TelemetryBuilderOption interface {
+	apply(*TelemetryBuilder)
+}
+
+type telemetryBuilderOptionFunc func(mb *TelemetryBuilder)
+
+func (tbof telemetryBuilderOptionFunc) apply(mb *TelemetryBuilder) {
+	tbof(mb)
+}
+
+// Shutdown unregister all registered callbacks for async instruments.
+func (builder *TelemetryBuilder) Shutdown() {
+	builder.mu.Lock()
+	defer builder.mu.Unlock()
+	for _, reg := range builder.registrations {
+		reg.Unregister()
+	}
+}
+
+// NewTelemetryBuilder provides a struct with methods to update all internal telemetry
+// for a component
+func NewTelemetryBuilder(settings component.TelemetrySettings, options ...TelemetryBuilderOption) (*TelemetryBuilder, error) {
+	builder := TelemetryBuilder{}
+	for _, op := range options {
+		op.apply(&builder)
+	}
+	builder.meter = Meter(settings)
+	var err, errs error
+	builder.ReceiverLogsDroppedLines, err = builder.meter.Int64Counter(
+		"otelcol_receiver_logs_dropped_lines",
+		metric.WithDescription("Number of CI log lines dropped by the log policies. [Development]"),
+		metric.WithUnit("{line}"),
+	)
+	errs = errors.Join(errs, err)
+	builder.ReceiverLogsFailedFetches, err = builder.meter.Int64Counter(
+		"otelcol_receiver_logs_failed_fetches",
+		metric.WithDescription("Number of steps whose logs could not be retrieved from the API, after retries. [Development]"),
+		metric.WithUnit("{step}"),
+	)
+	errs = errors.Join(errs, err)
+	builder.ReceiverLogsOversizedEntries, err = builder.meter.Int64Counter(
+		"otelcol_receiver_logs_oversized_entries",
+		metric.WithDescription("Number of CI log entries larger than the maximum entry size, by the behaviour applied to them. [Development]"),
+		metric.WithUnit("{entry}"),
+	)
+	errs = errors.Join(errs, err)
+	builder.ReceiverLogsRedactedLines, err = builder.meter.Int64Counter(
+		"otelcol_receiver_logs_redacted_lines",
+		metric.WithDescription("Number of CI log lines in which secrets were redacted. [Development]"),
+		metric.WithUnit("{line}"),
+	)
+	errs = errors.Join(errs, err)
+	return &builder, errs
+}
@@ -0,0 +1,214 @@
+package dronereceiver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
+	"go.uber.org/zap"
+)
+
+const (
+	// initialLogBackoff and maxLogBackoff bound the delay between attempts
+	// to retrieve logs, doubled after each failure.
+	initialLogBackoff = time.Second
+	maxLogBackoff     = 30 * time.Second
+)
+
+// newLogsHTTPClient returns a client of the API retrying the requests of
+// logs, on top of the transport of client.
+func newLogsHTTPClient(client *http.Client, cfg LogRetrievalConfig) *http.Client {
+	return &http.Client{
+		Transport: &retryTransport{
+			base:        client.Transport,
+			timeout:     cfg.timeout(),
+			maxAttempts: cfg.maxAttempts(),
+			backoff:     initialLogBackoff,
+		},
+	}
+}
+
+// retryTransport retries GET requests failing with 429 or 5xx statuses, or
+// without response, after a backoff doubling from backoff or the delay set
+// by Retry-After. Each attempt is bounded by timeout.
+type retryTransport struct {
+	base        http.RoundTripper
+	timeout     time.Duration
+	maxAttempts int
+	backoff     time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	backoff := t.backoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+		resp, err := base.RoundTrip(req.Clone(ctx))
+		if attempt >= t.maxAttempts || req.Method != http.MethodGet || !retryable(req.Context(), resp, err) {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			// The attempt lasts until its body is read
+			resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		wait := backoff
+		if resp != nil {
+			if after, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && after >= 0 {
+				wait = min(time.Duration(after)*time.Second, maxLogBackoff)
+			}
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			resp.Body.Close()
+		}
+		cancel()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+		backoff = min(2*backoff, maxLogBackoff)
+	}
+}
+
+// retryable reports whether an attempt failed in a way later attempts may
+// not, unless the request itself was canceled.
+func retryable(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// cancelBody releases the context of a request once its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// apiClient reads from the API of the CI server. Requests are sent with
+// the context of their caller, so that they stop with the retrieval of logs.
+type apiClient struct {
+	baseURL *url.URL
+	client  *http.Client
+}
+
+func newAPIClient(host string, client *http.Client) (apiClient, error) {
+	baseURL, err := url.Parse(host)
+	if err != nil {
+		return apiClient{}, err
+	}
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+	return apiClient{baseURL: baseURL, client: client}, nil
+}
+
+// getJSON decodes the response to a GET request of ref, relative to the
+// server address.
+func (c apiClient) getJSON(ctx context.Context, ref string, v any) error {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return err
+	}
+	u = c.baseURL.ResolveReference(u)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s: unexpected status %s", u.Path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// recordedLogs counts the steps whose logs could not be retrieved
+type recordedLogs struct {
+	logSource
+	telemetry *metadata.TelemetryBuilder
+}
+
+func (l recordedLogs) StepLogs(ctx context.Context, repo drone.Repo, build drone.Build, stage drone.Stage, step drone.Step) ([]*drone.Line, error) {
+	lines, err := l.logSource.StepLogs(ctx, repo, build, stage, step)
+	if err != nil {
+		l.telemetry.ReceiverLogsFailedFetches.Add(context.Background(), 1)
+	}
+	return lines, err
+}
+
+// logFetch is the retrieval of the logs of a step, stored in the step at
+// index of the task at task of the pipeline
+type logFetch struct {
+	stage       *drone.Stage
+	step        *drone.Step
+	task, index int
+}
+
+// fetchStepLogs retrieves the logs of the steps of a build, at most workers
+// at once, and stores them in the pipeline. Steps whose logs could not be
+// retrieved have none.
+func fetchStepLogs(ctx context.Context, logs logSource, evt WebhookEvent, pipeline *cimodel.Pipeline, fetches []logFetch, workers int, logger *zap.Logger) {
+	jobs := make(chan logFetch)
+	var wg sync.WaitGroup
+	for range min(workers, len(fetches)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				entries, err := stepLogs(ctx, logs, evt.Repo.Repo, *evt.Repo.Build, *f.stage, *f.step)
+				if err != nil {
+					logger.Error("error retrieving logs",
+						zap.String("stage", f.stage.Name),
+						zap.String("step", f.step.Name),
+						zap.Error(err),
+					)
+					continue
+				}
+				pipeline.Tasks[f.task].Steps[f.index].Logs = entries
+			}
+		}()
+	}
+
+	for _, f := range fetches {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,231 @@
+package dronereceiver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadatatest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
+	"go.uber.org/zap/zaptest"
+)
+
+// newRetryTestServer returns a server answering with the given statuses,
+// then with 200, and the number of requests it received.
+func newRetryTestServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := int(requests.Add(1))
+		if n <= len(statuses) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		_, _ = w.Write([]byte("[]"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestRetryClient(maxAttempts int, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &retryTransport{
+			base:        http.DefaultTransport,
+			timeout:     timeout,
+			maxAttempts: maxAttempts,
+			backoff:     time.Millisecond,
+		},
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	t.Run("Retries 5xx and 429 statuses", func(t *testing.T) {
+		server, requests := newRetryTestServer(t, http.StatusBadGateway, http.StatusTooManyRequests)
+		resp, err := newTestRetryClient(4, time.Second).Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "[]", string(body))
+		assert.EqualValues(t, 3, requests.Load())
+	})
+
+	t.Run("Returns the last response after max attempts", func(t *testing.T) {
+		server, requests := newRetryTestServer(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+		resp, err := newTestRetryClient(2, time.Second).Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.EqualValues(t, 2, requests.Load())
+	})
+
+	t.Run("Doesn't retry client errors", func(t *testing.T) {
+		server, requests := newRetryTestServer(t, http.StatusNotFound)
+		resp, err := newTestRetryClient(4, time.Second).Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.EqualValues(t, 1, requests.Load())
+	})
+
+	t.Run("Bounds each attempt", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) == 1 {
+				<-r.Context().Done()
+				return
+			}
+			_, _ = w.Write([]byte("[]"))
+		}))
+		t.Cleanup(server.Close)
+
+		resp, err := newTestRetryClient(2, 50*time.Millisecond).Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.EqualValues(t, 2, requests.Load())
+	})
+
+	t.Run("Stops when the request is canceled", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		t.Cleanup(server.Close)
+		client := newTestRetryClient(4, time.Second)
+		client.Transport.(*retryTransport).backoff = time.Hour
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		_, err = client.Do(req)
+		require.ErrorIs(t, err, context.Canceled)
+		assert.EqualValues(t, 1, requests.Load())
+	})
+}
+
+// concurrentLogs is a log source tracking the number of concurrent
+// retrievals, failing for the steps named fail
+type concurrentLogs struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+func (l *concurrentLogs) StepLogs(_ context.Context, _ drone.Repo, _ drone.Build, _ drone.Stage, step drone.Step) ([]*drone.Line, error) {
+	l.mu.Lock()
+	l.current++
+	l.max = max(l.max, l.current)
+	l.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	l.mu.Lock()
+	l.current--
+	l.mu.Unlock()
+
+	if step.Name == "fail" {
+		return nil, errors.New("unavailable")
+	}
+	return []*drone.Line{{Message: step.Name}}, nil
+}
+
+func TestFetchStepLogs(t *testing.T) {
+	stage := &drone.Stage{Name: "build"}
+	evt := newMetricsEvent(drone.StatusPassing)
+
+	var pipeline cimodel.Pipeline
+	var fetches []logFetch
+	for task := range 2 {
+		pipeline.Tasks = append(pipeline.Tasks, cimodel.Task{})
+		for i := range 5 {
+			name := "step"
+			if task == 1 && i == 4 {
+				name = "fail"
+			}
+			pipeline.Tasks[task].Steps = append(pipeline.Tasks[task].Steps, cimodel.Step{})
+			fetches = append(fetches, logFetch{stage: stage, step: &drone.Step{Name: name}, task: task, index: i})
+		}
+	}
+
+	logs := &concurrentLogs{}
+	fetchStepLogs(context.Background(), logs, evt, &pipeline, fetches, 3, zaptest.NewLogger(t))
+
+	assert.LessOrEqual(t, logs.max, 3)
+	for task := range pipeline.Tasks {
+		for i, step := range pipeline.Tasks[task].Steps {
+			if task == 1 && i == 4 {
+				// Failed retrievals leave the step without logs
+				assert.Empty(t, step.Logs)
+				continue
+			}
+			require.Len(t, step.Logs, 1, "task %d step %d", task, i)
+			assert.Equal(t, "step", step.Logs[0].Body)
+		}
+	}
+}
+
+func TestRecordedLogs(t *testing.T) {
+	tt := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+	telemetry, err := metadata.NewTelemetryBuilder(tt.NewTelemetrySettings())
+	require.NoError(t, err)
+
+	logs := recordedLogs{logSource: &concurrentLogs{}, telemetry: telemetry}
+	_, err = logs.StepLogs(context.Background(), drone.Repo{}, drone.Build{}, drone.Stage{}, drone.Step{Name: "step"})
+	require.NoError(t, err)
+	_, err = logs.StepLogs(context.Background(), drone.Repo{}, drone.Build{}, drone.Stage{}, drone.Step{Name: "fail"})
+	require.Error(t, err)
+
+	metadatatest.AssertEqualReceiverLogsFailedFetches(t, tt, []metricdata.DataPoint[int64]{{Value: 1}}, metricdatatest.IgnoreTimestamp())
+}
+
+func TestDroneLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/repos/grafana/app/builds/12/logs/1/2" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"pos":0,"out":"go test ./...","time":3}]`))
+	}))
+	t.Cleanup(server.Close)
+
+	api, err := newAPIClient(server.URL, server.Client())
+	require.NoError(t, err)
+	logs := droneLogs{apiClient: api}
+	repo := drone.Repo{Namespace: "grafana", Name: "app"}
+
+	lines, err := logs.StepLogs(context.Background(), repo, drone.Build{Number: 12}, drone.Stage{Number: 1}, drone.Step{Number: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []*drone.Line{{Number: 0, Message: "go test ./...", Timestamp: 3}}, lines)
+
+	_, err = logs.StepLogs(context.Background(), repo, drone.Build{Number: 12}, drone.Stage{Number: 1}, drone.Step{Number: 3})
+	require.ErrorContains(t, err, "unexpected status 404")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = logs.StepLogs(ctx, repo, drone.Build{Number: 12}, drone.Stage{Number: 1}, drone.Step{Number: 2})
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,268 @@
+package dronereceiver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.uber.org/zap"
+)
+
+const metricsMaxCacheSize = 100000
+const histogramCacheSize = 50000
+const histogramTTL = 24 * time.Hour
+
+// metricsHandler counts the builds, stages and steps reported by webhooks,
+// and reports their durations. It needs no access to the database of Drone.
+type metricsHandler struct {
+	mu             sync.Mutex
+	mb             *metadata.MetricsBuilder
+	cfg            *Config
+	logger         *zap.Logger
+	countersCache  *lru.Cache[string, int64]
+	histogramCache *lru.Cache[string, *cimodel.Histogram]
+	durations      *cimodel.Durations
+}
+
+func newMetricsHandler(settings receiver.Settings, cfg *Config, logger *zap.Logger) (*metricsHandler, error) {
+	countersCache, err := lru.New[string, int64](metricsMaxCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize counters cache: %w", err)
+	}
+
+	// histogramCache stores cumulative histogram state per unique dimension set,
+	// as histograms are emitted with cumulative temporality.
+	histogramCache, err := lru.New[string, *cimodel.Histogram](histogramCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize histogram cache: %w", err)
+	}
+
+	durations, err := cimodel.NewDurations(histogramCacheSize, histogramTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize durations cache: %w", err)
+	}
+
+	return &metricsHandler{
+		cfg:            cfg,
+		mb:             metadata.NewMetricsBuilder(cfg.MetricsBuilderConfig, settings),
+		logger:         logger,
+		countersCache:  countersCache,
+		histogramCache: histogramCache,
+		durations:      durations,
+	}, nil
+}
+
+// recordFunc records a data point of the builds, stages or steps counter.
+// The branch of the deployments counter is the deployment environment.
+type recordFunc func(ts pcommon.Timestamp, val int64, status metadata.AttributeCiWorkflowItemStatus, repo, branch, event string)
+
+// buildToMetrics counts a finished build, its stages and its steps by
+// status, and the deployments by environment, and reports their durations.
+func (m *metricsHandler) buildToMetrics(evt WebhookEvent) pmetric.Metrics {
+	repo := evt.Repo
+	build := evt.Repo.Build
+	m.logger.Debug("Processing build",
+		zap.String("repo", repo.Slug),
+		zap.String("branch", repo.Branch),
+		zap.Int64("build", build.Number),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Stages and steps sharing a status are counted together, as a data
+	// point per counter
+	stages, steps := map[string]int64{}, map[string]int64{}
+	for _, stage := range build.Stages {
+		stages[stage.Status]++
+		for _, step := range stage.Steps {
+			steps[step.Status]++
+		}
+	}
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	m.count("build", m.mb.RecordBuildsTotalDataPoint, now, repo.Slug, repo.Branch, build.Event, map[string]int64{build.Status: 1})
+	m.count("stage", m.mb.RecordStagesTotalDataPoint, now, repo.Slug, repo.Branch, build.Event, stages)
+	m.count("step", m.mb.RecordStepsTotalDataPoint, now, repo.Slug, repo.Branch, build.Event, steps)
+	// Promotions and rollbacks are deployments, Woodpecker deployments too
+	if build.Deploy != "" {
+		m.count("deployment", m.mb.RecordDeploymentsTotalDataPoint, now, repo.Slug, build.Deploy, build.Event, map[string]int64{build.Status: 1})
+	}
+
+	metrics := m.mb.Emit()
+	ms := scopeMetrics(metrics)
+
+	pipeline := buildDurations(evt)
+	if m.cfg.Semconv.EmitsLegacy() {
+		attrs := durationAttributes(repo.Slug, repo.Branch, build.Event, build.Status, "")
+		m.appendDuration(ms, "builds.duration", "build", attrs, pipeline.Started, pipeline.Finished)
+		m.appendDuration(ms, "builds.queue.duration", "queue", attrs, pipeline.Queued, pipeline.Started)
+		for _, stage := range build.Stages {
+			if stage.Status == drone.StatusSkipped {
+				continue
+			}
+			attrs := durationAttributes(repo.Slug, repo.Branch, build.Event, stage.Status, stage.Name)
+			m.appendDuration(ms, "stages.duration", "stage", attrs, time.Unix(stage.Started, 0), time.Unix(stage.Stopped, 0))
+			for _, step := range stage.Steps {
+				if step.Status == drone.StatusSkipped {
+					continue
+				}
+				attrs := durationAttributes(repo.Slug, repo.Branch, build.Event, step.Status, stage.Name)
+				m.appendDuration(ms, "steps.duration", "step", attrs, time.Unix(step.Started, 0), time.Unix(step.Stopped, 0))
+			}
+		}
+	}
+
+	if m.cfg.Semconv.Enabled {
+		m.durations.AppendPipeline(ms, &pipeline)
+		for i := range pipeline.Tasks {
+			m.durations.AppendTask(ms, &pipeline, &pipeline.Tasks[i])
+		}
+	}
+
+	return metrics
+}
+
+// count adds the counts of the builds, stages or steps, by status, to their
+// counters. Statuses unknown to the receiver are not counted. Called under
+// m.mu.
+func (m *metricsHandler) count(kind string, record recordFunc, now pcommon.Timestamp, repo, branch, event string, counts map[string]int64) {
+	for status := range counts {
+		if _, ok := metadata.MapAttributeCiWorkflowItemStatus[status]; !ok {
+			m.logger.Debug("Skipping unknown status", zap.String("kind", kind), zap.String("status", status))
+		}
+	}
+
+	dimensions := fmt.Sprintf("%s:%s:%s:%s", kind, repo, branch, event)
+	for status, attr := range metadata.MapAttributeCiWorkflowItemStatus {
+		key := dimensions + ":" + status
+		n, ok := counts[status]
+		if !ok {
+			// The counters of the other statuses start at zero, so that
+			// their increases are visible from their first build.
+			if m.seedCounter(key) {
+				record(now, 0, attr, repo, branch, event)
+			}
+			continue
+		}
+
+		val, _ := m.countersCache.Get(key)
+		m.countersCache.Add(key, val+n)
+		record(now, val+n, attr, repo, branch, event)
+	}
+}
+
+// appendDuration observes the duration of a build, stage or step, or the
+// time a build was queued, and appends its histogram. Items that never
+// started have no duration. Called under m.mu.
+func (m *metricsHandler) appendDuration(ms pmetric.MetricSlice, name, kind string, attrs map[string]any, started, finished time.Time) {
+	if started.Unix() <= 0 || finished.Before(started) {
+		return
+	}
+
+	key := fmt.Sprintf("hist:%s:%s:%s:%s:%s:%s", kind,
+		attrs[semconv.AttributeGitRepoName],
+		attrs[semconv.AttributeGitBranchName],
+		attrs[semconv.AttributeDroneWorkflowEvent],
+		attrs[semconv.AttributeCIWorkflowItemStatus],
+		attrs[semconv.AttributeDroneStageName],
+	)
+	cimodel.AppendHistogram(ms, name, attrs, m.observeDuration(key, finished.Sub(started).Seconds()))
+}
+
+// durationAttributes returns the attributes of the duration histograms.
+// Builds have no stage.
+func durationAttributes(repo, branch, event, status, stage string) map[string]any {
+	attrs := map[string]any{
+		semconv.AttributeGitRepoName:          repo,
+		semconv.AttributeGitBranchName:        branch,
+		semconv.AttributeDroneWorkflowEvent:   event,
+		semconv.AttributeCIWorkflowItemStatus: status,
+	}
+	if stage != "" {
+		attrs[semconv.AttributeDroneStageName] = stage
+	}
+	return attrs
+}
+
+// buildDurations maps the timings of a build and its stages to the CI model,
+// without fetching the logs of its steps.
+func buildDurations(evt WebhookEvent) cimodel.Pipeline {
+	repo := evt.Repo
+	build := evt.Repo.Build
+
+	// Builds that never started, such as declined ones, have no queue time
+	started := build.Started
+	var queued time.Time
+	if started == 0 {
+		started = build.Created
+	} else if build.Created > 0 {
+		queued = time.Unix(build.Created, 0)
+	}
+
+	pipeline := cimodel.Pipeline{
+		Name:     repo.Slug,
+		Result:   droneResult(build.Status),
+		Queued:   queued,
+		Started:  time.Unix(started, 0),
+		Finished: time.Unix(build.Finished, 0),
+		Repository: cimodel.Repository{
+			Owner: repo.Namespace,
+			Name:  repo.Name,
+		},
+	}
+	for _, stage := range build.Stages {
+		if stage.Status == drone.StatusSkipped || stage.Started == 0 {
+			continue
+		}
+		pipeline.Tasks = append(pipeline.Tasks, cimodel.Task{
+			Name:     stage.Name,
+			Result:   droneResult(stage.Status),
+			Started:  time.Unix(stage.Started, 0),
+			Finished: time.Unix(stage.Stopped, 0),
+		})
+	}
+	return pipeline
+}
+
+// scopeMetrics returns the metrics emitted by the metrics builder, which
+// emits no resource at all when no counter was recorded.
+func scopeMetrics(metrics pmetric.Metrics) pmetric.MetricSlice {
+	if metrics.ResourceMetrics().Len() == 0 {
+		scope := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+		scope.Scope().SetName(metadata.ScopeName)
+		return scope.Metrics()
+	}
+	return metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+}
+
+// seedCounter starts the counter cached under key at zero, reporting
+// whether it was unknown. Called under m.mu.
+func (m *metricsHandler) seedCounter(key string) bool {
+	if m.countersCache.Contains(key) {
+		return false
+	}
+	m.countersCache.Add(key, 0)
+	return true
+}
+
+// observeDuration records a duration in the histogram cached under key.
+// Called under m.mu.
+func (m *metricsHandler) observeDuration(key string, duration float64) *cimodel.Histogram {
+	// Stale histograms start over, the LRU evicts those never observed again
+	h, ok := m.histogramCache.Get(key)
+	if !ok || time.Since(h.LastSeen) >= histogramTTL {
+		h = cimodel.NewHistogram()
+	}
+	h.Observe(duration)
+	m.histogramCache.Add(key, h)
+	return h
+}
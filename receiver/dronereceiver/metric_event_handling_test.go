@@ -0,0 +1,262 @@
+package dronereceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestMetricsHandler(t *testing.T, cfg *Config) *metricsHandler {
+	t.Helper()
+	cfg.MetricsBuilderConfig = metadata.DefaultMetricsBuilderConfig()
+	mh, err := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return mh
+}
+
+func newMetricsEvent(stepStatus string) WebhookEvent {
+	return WebhookEvent{
+		Action: "updated",
+		Repo: &RepoEvt{
+			Repo: drone.Repo{Namespace: "grafana", Name: "app", Slug: "grafana/app", Branch: "main"},
+			Build: &drone.Build{
+				Number:   12,
+				Event:    drone.EventPush,
+				Status:   drone.StatusFailing,
+				Created:  1000,
+				Started:  1010,
+				Finished: 1100,
+				Stages: []*drone.Stage{
+					{
+						Name:    "build",
+						Status:  drone.StatusFailing,
+						Started: 1010,
+						Stopped: 1100,
+						Steps: []*drone.Step{
+							{Name: "clone", Status: drone.StatusPassing, Started: 1010, Stopped: 1020},
+							{Name: "test", Status: stepStatus, Started: 1020, Stopped: 1100},
+							{Name: "publish", Status: drone.StatusSkipped},
+						},
+					},
+					{Name: "deploy", Status: drone.StatusSkipped},
+				},
+			},
+		},
+	}
+}
+
+// metricNames returns the names of the metrics, with their data point counts.
+func metricNames(metrics pmetric.Metrics) map[string]int {
+	names := map[string]int{}
+	for i := range metrics.ResourceMetrics().Len() {
+		sms := metrics.ResourceMetrics().At(i).ScopeMetrics()
+		for j := range sms.Len() {
+			ms := sms.At(j).Metrics()
+			for k := range ms.Len() {
+				m := ms.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					names[m.Name()] += m.Sum().DataPoints().Len()
+				case pmetric.MetricTypeHistogram:
+					names[m.Name()] += m.Histogram().DataPoints().Len()
+				}
+			}
+		}
+	}
+	return names
+}
+
+// counterValues returns the non-zero values of a counter, by status.
+func counterValues(metrics pmetric.Metrics, name string) map[string]int64 {
+	values := map[string]int64{}
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := range ms.Len() {
+		if ms.At(i).Name() != name {
+			continue
+		}
+		dps := ms.At(i).Sum().DataPoints()
+		for j := range dps.Len() {
+			if dps.At(j).IntValue() == 0 {
+				continue
+			}
+			status, _ := dps.At(j).Attributes().Get("ci.workflow_item.status")
+			values[status.Str()] += dps.At(j).IntValue()
+		}
+	}
+	return values
+}
+
+func TestBuildToMetrics(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+	statuses := len(metadata.MapAttributeCiWorkflowItemStatus)
+
+	// The first build seeds the counters of the other statuses.
+	metrics := mh.buildToMetrics(newMetricsEvent(drone.StatusFailing))
+	assert.Equal(t, map[string]int{
+		"builds_total":          statuses,
+		"stages_total":          statuses,
+		"steps_total":           statuses,
+		"builds.duration":       1,
+		"builds.queue.duration": 1,
+		"stages.duration":       1,
+		"steps.duration":        2,
+	}, metricNames(metrics))
+	assert.Equal(t, map[string]int64{"failure": 1}, counterValues(metrics, "builds_total"))
+	assert.Equal(t, map[string]int64{"failure": 1, "skipped": 1}, counterValues(metrics, "stages_total"))
+	assert.Equal(t, map[string]int64{"success": 1, "failure": 1, "skipped": 1}, counterValues(metrics, "steps_total"))
+
+	dp := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	assert.Equal(t, map[string]any{
+		"ci.workflow_item.status": dp.Attributes().AsRaw()["ci.workflow_item.status"],
+		"git.repo.name":           "grafana/app",
+		"git.branch.name":         "main",
+		"ci.drone.workflow.event": "push",
+	}, dp.Attributes().AsRaw())
+
+	// Counters are cumulative, only the counters that changed are reported.
+	metrics = mh.buildToMetrics(newMetricsEvent(drone.StatusPassing))
+	assert.Equal(t, map[string]int64{"failure": 2}, counterValues(metrics, "builds_total"))
+	assert.Equal(t, map[string]int64{"success": 3, "skipped": 2}, counterValues(metrics, "steps_total"))
+	assert.Equal(t, map[string]int{
+		"builds_total":          1,
+		"stages_total":          2,
+		"steps_total":           2,
+		"builds.duration":       1,
+		"builds.queue.duration": 1,
+		"stages.duration":       1,
+		"steps.duration":        2,
+	}, metricNames(metrics))
+}
+
+func TestBuildToMetricsDurations(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+
+	// histogram returns the first data point of a histogram.
+	histogram := func(metrics pmetric.Metrics, name string) pmetric.HistogramDataPoint {
+		ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+		for i := range ms.Len() {
+			if ms.At(i).Name() == name {
+				return ms.At(i).Histogram().DataPoints().At(0)
+			}
+		}
+		t.Fatalf("missing %s", name)
+		return pmetric.HistogramDataPoint{}
+	}
+
+	metrics := mh.buildToMetrics(newMetricsEvent(drone.StatusFailing))
+
+	// Durations start when the build started, not when it was created.
+	dp := histogram(metrics, "builds.duration")
+	assert.Equal(t, 90.0, dp.Sum())
+	assert.Equal(t, uint64(1), dp.Count())
+	assert.NotContains(t, dp.Attributes().AsRaw(), "ci.drone.stage.name")
+
+	dp = histogram(metrics, "builds.queue.duration")
+	assert.Equal(t, 10.0, dp.Sum())
+	assert.Equal(t, "failure", dp.Attributes().AsRaw()["ci.workflow_item.status"])
+
+	dp = histogram(metrics, "stages.duration")
+	assert.Equal(t, "build", dp.Attributes().AsRaw()["ci.drone.stage.name"])
+	dp = histogram(metrics, "steps.duration")
+	assert.Equal(t, "build", dp.Attributes().AsRaw()["ci.drone.stage.name"])
+
+	// Histograms not observed within the TTL start over
+	dp = histogram(mh.buildToMetrics(newMetricsEvent(drone.StatusFailing)), "builds.duration")
+	assert.Equal(t, uint64(2), dp.Count())
+	for _, h := range mh.histogramCache.Values() {
+		h.LastSeen = time.Now().Add(-histogramTTL)
+	}
+	dp = histogram(mh.buildToMetrics(newMetricsEvent(drone.StatusFailing)), "builds.duration")
+	assert.Equal(t, uint64(1), dp.Count())
+
+	// Builds that never started were not queued
+	evt := newMetricsEvent(drone.StatusFailing)
+	evt.Repo.Build.Status = drone.StatusDeclined
+	evt.Repo.Build.Started = 0
+	evt.Repo.Build.Stages = nil
+	assert.NotContains(t, metricNames(mh.buildToMetrics(evt)), "builds.queue.duration")
+}
+
+func TestBuildToMetricsUnknownStatus(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+
+	metrics := mh.buildToMetrics(newMetricsEvent("unknown"))
+	assert.Equal(t, map[string]int64{"success": 1, "skipped": 1}, counterValues(metrics, "steps_total"))
+}
+
+func TestBuildToMetricsDeployments(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+	deploy := func(event, environment, status string) pmetric.Metrics {
+		evt := newMetricsEvent(drone.StatusPassing)
+		evt.Repo.Build.Event, evt.Repo.Build.Deploy, evt.Repo.Build.Status = event, environment, status
+		return mh.buildToMetrics(evt)
+	}
+
+	// Pushes aren't deployments
+	metrics := mh.buildToMetrics(newMetricsEvent(drone.StatusPassing))
+	assert.NotContains(t, metricNames(metrics), "deployments_total")
+
+	metrics = deploy(drone.EventPromote, "production", drone.StatusPassing)
+	assert.Equal(t, len(metadata.MapAttributeCiWorkflowItemStatus), metricNames(metrics)["deployments_total"])
+	assert.Equal(t, map[string]int64{"success": 1}, counterValues(metrics, "deployments_total"))
+
+	// Environments are counted apart, failed deployments too
+	metrics = deploy(drone.EventPromote, "production", drone.StatusFailing)
+	assert.Equal(t, map[string]int64{"failure": 1}, counterValues(metrics, "deployments_total"))
+	metrics = deploy(drone.EventPromote, "staging", drone.StatusPassing)
+	assert.Equal(t, map[string]int64{"success": 1}, counterValues(metrics, "deployments_total"))
+	metrics = deploy(drone.EventRollback, "production", drone.StatusPassing)
+	assert.Equal(t, map[string]int64{"success": 1}, counterValues(metrics, "deployments_total"))
+	metrics = deploy(drone.EventPromote, "production", drone.StatusPassing)
+	assert.Equal(t, map[string]int64{"success": 2}, counterValues(metrics, "deployments_total"))
+
+	var dp pmetric.NumberDataPoint
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := range ms.Len() {
+		if ms.At(i).Name() == "deployments_total" {
+			dp = ms.At(i).Sum().DataPoints().At(0)
+		}
+	}
+	assert.Equal(t, map[string]any{
+		"ci.workflow_item.status":     "success",
+		"git.repo.name":               "grafana/app",
+		"deployment.environment.name": "production",
+		"ci.drone.workflow.event":     "promote",
+	}, dp.Attributes().AsRaw())
+}
+
+func TestBuildToMetricsSemconv(t *testing.T) {
+	tests := map[string]struct {
+		semconv semconv.Config
+		expect  map[string]int
+	}{
+		"legacy": {
+			expect: map[string]int{"builds.duration": 1, "builds.queue.duration": 1, "stages.duration": 1, "steps.duration": 2},
+		},
+		"semconv": {
+			semconv: semconv.Config{Enabled: true},
+			// Queue time is reported in the pending state
+			expect: map[string]int{"cicd.pipeline.run.duration": 2, "cicd.pipeline.task.run.duration": 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mh := newTestMetricsHandler(t, &Config{Semconv: test.semconv})
+
+			names := metricNames(mh.buildToMetrics(newMetricsEvent(drone.StatusFailing)))
+			for _, counter := range []string{"builds_total", "stages_total", "steps_total"} {
+				delete(names, counter)
+			}
+			assert.Equal(t, test.expect, names)
+		})
+	}
+}
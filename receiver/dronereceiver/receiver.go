@@ -11,11 +11,15 @@ import (
 	"time"
 
 	"github.com/99designs/httpsignatures-go"
-	"github.com/drone/drone-go/drone"
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/receiver"
 	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 )
@@ -24,16 +28,27 @@ var errSignatureNotValid = errors.New("signature is not valid")
 var errParsingSignature = errors.New("error parsing signature")
 
 type droneReceiver struct {
-	cfg         *Config
-	set         receiver.Settings
-	httpServer  *http.Server
-	shutdownWG  sync.WaitGroup
-	droneClient drone.Client
-	obsrecv     *receiverhelper.ObsReport
-	logger      *zap.Logger
-
-	logsConsumer   consumer.Logs
-	tracesConsumer consumer.Traces
+	cfg        *Config
+	set        receiver.Settings
+	httpServer *http.Server
+	shutdownWG sync.WaitGroup
+	runnersAPI apiClient // reads the queue and runners
+	logs       logSource
+	obsrecv    *receiverhelper.ObsReport
+	logger     *zap.Logger
+	telemetry  *metadata.TelemetryBuilder
+	logPolicy  *logpolicy.Policy
+	metrics    *metricsHandler
+	approvals  *approvalTracker
+
+	// events are the finished builds whose traces and logs are reported in
+	// the background, as retrieving their logs takes a request per step.
+	events chan WebhookEvent
+	cancel context.CancelFunc
+
+	logsConsumer    consumer.Logs
+	metricsConsumer consumer.Metrics
+	tracesConsumer  consumer.Traces
 }
 
 func newReceiver(params receiver.Settings,
@@ -62,14 +77,59 @@ func newReceiver(params receiver.Settings,
 			AccessToken: config.DroneConfig.Token,
 		},
 	)
-	droneClient := drone.NewClient(config.DroneConfig.Host, httpClient)
+	// The queue and runners are scraped without retries
+	runnersAPI, err := newAPIClient(config.DroneConfig.Host, &http.Client{Transport: httpClient.Transport, Timeout: runnersTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	// Logs are retrieved with retries, other requests fail fast
+	logsHTTPClient := newLogsHTTPClient(httpClient, config.DroneConfig.LogRetrieval)
+	var logs logSource
+	if config.Flavor == flavorWoodpecker {
+		logs, err = newWoodpeckerClient(config.DroneConfig.Host, logsHTTPClient)
+	} else {
+		var api apiClient
+		api, err = newAPIClient(config.DroneConfig.Host, logsHTTPClient)
+		logs = droneLogs{apiClient: api}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	telemetry, err := metadata.NewTelemetryBuilder(params.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+	logs = recordedLogs{logSource: logs, telemetry: telemetry}
+
+	logPolicy, err := logpolicy.New(config.Logs, logPolicyRecorder{telemetry: telemetry})
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := newMetricsHandler(params, config, params.Logger.Named("metricsHandler"))
+	if err != nil {
+		return nil, err
+	}
+
+	approvals, err := newApprovalTracker()
+	if err != nil {
+		return nil, err
+	}
 
 	receiver := &droneReceiver{
-		cfg:         config,
-		set:         params,
-		droneClient: droneClient,
-		obsrecv:     obsrecv,
-		logger:      params.Logger,
+		cfg:        config,
+		set:        params,
+		runnersAPI: runnersAPI,
+		logs:       logs,
+		obsrecv:    obsrecv,
+		logger:     params.Logger,
+		telemetry:  telemetry,
+		logPolicy:  logPolicy,
+		metrics:    metrics,
+		approvals:  approvals,
+		events:     make(chan WebhookEvent, config.DroneConfig.LogRetrieval.queueSize()),
 	}
 
 	return receiver, nil
@@ -90,6 +150,24 @@ func verifySignature(resp http.ResponseWriter, req *http.Request, secret string)
 	return nil
 }
 
+// logPolicyRecorder reports the log lines affected by the log policy as
+// internal telemetry.
+type logPolicyRecorder struct {
+	telemetry *metadata.TelemetryBuilder
+}
+
+func (r logPolicyRecorder) RecordDroppedLines(n int64, reason string) {
+	r.telemetry.ReceiverLogsDroppedLines.Add(context.Background(), n, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+func (r logPolicyRecorder) RecordRedactedLines(n int64) {
+	r.telemetry.ReceiverLogsRedactedLines.Add(context.Background(), n)
+}
+
+func (r logPolicyRecorder) RecordOversizedEntries(n int64, behaviour string) {
+	r.telemetry.ReceiverLogsOversizedEntries.Add(context.Background(), n, metric.WithAttributes(attribute.String("behaviour", behaviour)))
+}
+
 func (r *droneReceiver) Start(_ context.Context, host component.Host) error {
 	endpoint := fmt.Sprintf("%s%s", r.cfg.NetAddr.Endpoint, r.cfg.Path)
 	r.logger.Info("Starting Drone webhook server", zap.String("endpoint", endpoint))
@@ -109,53 +187,197 @@ func (r *droneReceiver) Start(_ context.Context, host component.Host) error {
 		}
 	}()
 
+	var ctx context.Context
+	ctx, r.cancel = context.WithCancel(context.Background())
+	r.shutdownWG.Add(1)
+	go func() {
+		defer r.shutdownWG.Done()
+		r.processEvents(ctx)
+	}()
+
 	return nil
 }
 
-func (r *droneReceiver) Shutdown(_ context.Context) error {
+// Shutdown stops the server and the retrieval of logs. Builds waiting for
+// their logs are dropped.
+func (r *droneReceiver) Shutdown(ctx context.Context) error {
 	var err error
 	if r.httpServer != nil {
 		err = r.httpServer.Close()
 	}
-	r.shutdownWG.Wait()
+	if r.cancel != nil {
+		r.cancel()
+	}
+
+	// Canceled retrievals of logs stop at once, the build being retrieved is
+	// still reported unless ctx expires first
+	done := make(chan struct{})
+	go func() {
+		r.shutdownWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		r.logger.Warn("Stopped waiting for the build being reported", zap.Error(ctx.Err()))
+	}
+	if n := len(r.events); n > 0 {
+		r.logger.Warn("Dropping builds waiting for their logs", zap.Int("builds", n))
+	}
+	r.telemetry.Shutdown()
 	return err
 }
 
 func (r *droneReceiver) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	var evt WebhookEvent
+	var ok bool
+	if r.cfg.Flavor == flavorWoodpecker {
+		evt, ok = r.decodeWoodpeckerEvent(resp, req)
+	} else {
+		evt, ok = r.decodeDroneEvent(resp, req)
+		if ok {
+			r.approvals.observe(&evt)
+		}
+	}
+	if !ok {
+		return
+	}
+
+	logger := r.logger.Named("handler")
+	if !acceptEvent(evt, r.cfg, logger) {
+		return
+	}
+
+	// Builds rejected while the queue is full are not counted either, so
+	// that their redelivery is counted once
+	if r.tracesConsumer != nil || r.logsConsumer != nil {
+		select {
+		case r.events <- evt:
+		default:
+			logger.Warn("Too many builds waiting for their logs, dropping",
+				zap.String("repo", evt.Repo.Slug),
+				zap.Int64("build", evt.Repo.Build.Number),
+			)
+			http.Error(resp, "too many builds waiting for their logs", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	// Metrics don't need the logs of the build
+	if r.metricsConsumer != nil {
+		r.consumeMetrics(req.Context(), r.metrics.buildToMetrics(evt))
+	}
+}
+
+// processEvents reports the traces and logs of the queued builds, until ctx
+// is canceled.
+func (r *droneReceiver) processEvents(ctx context.Context) {
+	for {
+		select {
+		case evt := <-r.events:
+			r.processEvent(ctx, evt)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// processEvent retrieves the logs of a build and reports them with its
+// traces. Builds whose retrieval is canceled by shutdown are still reported.
+func (r *droneReceiver) processEvent(ctx context.Context, evt WebhookEvent) {
+	traces, logs := handleEvent(ctx, evt, r.cfg, r.logs, r.logPolicy, r.logger.Named("handler"))
+	ctx = context.WithoutCancel(ctx)
+
+	if r.tracesConsumer != nil && traces != nil {
+		err := r.tracesConsumer.ConsumeTraces(ctx, *traces)
+		if err != nil {
+			r.logger.Error("Failed to consume traces", zap.Error(err))
+		}
+	}
+	if r.logsConsumer != nil && logs != nil {
+		err := r.logsConsumer.ConsumeLogs(ctx, *logs)
+		if err != nil {
+			r.logger.Error("Failed to consume logs", zap.Error(err))
+		}
+	}
+}
+
+func (r *droneReceiver) consumeMetrics(ctx context.Context, md pmetric.Metrics) {
+	if md.DataPointCount() == 0 {
+		return
+	}
+
+	metricsCtx := r.obsrecv.StartMetricsOp(ctx)
+	err := r.metricsConsumer.ConsumeMetrics(metricsCtx, md)
+	r.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), md.DataPointCount(), err)
+	if err != nil {
+		r.logger.Error("Failed to consume metrics", zap.Error(err))
+	}
+}
+
+func (r *droneReceiver) decodeDroneEvent(resp http.ResponseWriter, req *http.Request) (WebhookEvent, bool) {
+	var evt WebhookEvent
+
 	err := verifySignature(resp, req, r.cfg.Secret)
 	if err != nil {
 		r.logger.Info("couldn't verify request signature", zap.Error(err))
-		return
+		return evt, false
 	}
 
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
 		r.logger.Error("error reading the request body", zap.Error(err))
 		http.Error(resp, err.Error(), http.StatusBadRequest)
-		return
+		return evt, false
 	}
 
-	var evt WebhookEvent
 	err = json.Unmarshal(body, &evt)
 	if err != nil {
 		// TODO: handle this
 		r.logger.Error("error unmarshalling the request body", zap.Error(err))
 		http.Error(resp, err.Error(), http.StatusBadRequest)
-		return
+		return evt, false
 	}
 
-	traces, logs := handleEvent(evt, r.cfg, r.droneClient, r.logger.Named("handler"))
+	return evt, true
+}
 
-	if r.tracesConsumer != nil && traces != nil {
-		err := r.tracesConsumer.ConsumeTraces(req.Context(), *traces)
-		if err != nil {
-			r.logger.Error("Failed to consume traces", zap.Error(err))
-		}
+// decodeWoodpeckerEvent verifies and decodes a Woodpecker webhook, translating
+// it to a Drone event.
+func (r *droneReceiver) decodeWoodpeckerEvent(resp http.ResponseWriter, req *http.Request) (WebhookEvent, bool) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		r.logger.Error("error reading the request body", zap.Error(err))
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return WebhookEvent{}, false
 	}
-	if r.logsConsumer != nil && logs != nil {
-		err := r.logsConsumer.ConsumeLogs(req.Context(), *logs)
-		if err != nil {
-			r.logger.Error("Failed to consume logs", zap.Error(err))
-		}
+
+	// The key is validated with the configuration
+	publicKey, _ := r.cfg.Woodpecker.publicKey()
+	err = verifyWoodpeckerSignature(req, body, publicKey, r.cfg.Woodpecker.signatureTolerance(), time.Now())
+	switch {
+	case errors.Is(err, errParsingSignature):
+		r.logger.Info("couldn't verify request signature", zap.Error(err))
+		resp.WriteHeader(http.StatusBadRequest)
+		return WebhookEvent{}, false
+	case err != nil:
+		r.logger.Info("couldn't verify request signature", zap.Error(err))
+		resp.WriteHeader(http.StatusForbidden)
+		return WebhookEvent{}, false
+	}
+
+	var evt woodpeckerEvent
+	err = json.Unmarshal(body, &evt)
+	if err != nil {
+		r.logger.Error("error unmarshalling the request body", zap.Error(err))
+		http.Error(resp, err.Error(), http.StatusBadRequest)
+		return WebhookEvent{}, false
 	}
+
+	if evt.Repo == nil || evt.Pipeline == nil {
+		r.logger.Warn("no pipeline info provided from the webhook event")
+		return WebhookEvent{}, false
+	}
+
+	return evt.toWebhookEvent(r.cfg.DroneConfig.Host), true
 }
@@ -1,13 +1,19 @@
 package dronereceiver
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/99designs/httpsignatures-go"
+	"github.com/drone/drone-go/drone"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/config/confighttp"
 	"go.opentelemetry.io/collector/config/confignet"
 	"go.opentelemetry.io/collector/consumer"
@@ -115,3 +121,187 @@ func TestNewReceiver(t *testing.T) {
 		})
 	}
 }
+
+// noLogs is a log source of steps without logs
+type noLogs struct{}
+
+func (noLogs) StepLogs(context.Context, drone.Repo, drone.Build, drone.Stage, drone.Step) ([]*drone.Line, error) {
+	return nil, nil
+}
+
+func TestServeHTTPMetrics(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Secret = "mysecret"
+	cfg.ReposConfig = map[string][]string{"grafana/app": {"main"}}
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	rec.logs = noLogs{}
+
+	tracesSink := new(consumertest.TracesSink)
+	metricsSink := new(consumertest.MetricsSink)
+	rec.tracesConsumer = tracesSink
+	rec.metricsConsumer = metricsSink
+
+	send := func(evt WebhookEvent) {
+		body, err := json.Marshal(evt)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, cfg.Path, bytes.NewReader(body))
+		req.Header.Set("Date", "Thu, 08 Dec 2023 10:31:40 GMT")
+		require.NoError(t, httpsignatures.DefaultSha256Signer.SignRequest("keyID", cfg.Secret, req))
+		rec.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Builds of repos that are not enabled are not counted
+	other := newMetricsEvent(drone.StatusPassing)
+	other.Repo.Slug = "grafana/other"
+	send(other)
+	assert.Empty(t, metricsSink.AllMetrics())
+	assert.Empty(t, rec.events)
+
+	// Metrics don't need the database, nor the logs
+	send(newMetricsEvent(drone.StatusPassing))
+	require.Len(t, metricsSink.AllMetrics(), 1)
+	assert.Equal(t, map[string]int64{"failure": 1}, counterValues(metricsSink.AllMetrics()[0], "builds_total"))
+	assert.Zero(t, tracesSink.SpanCount())
+
+	// Traces are reported once the logs are retrieved
+	require.Len(t, rec.events, 1)
+	rec.processEvent(context.Background(), <-rec.events)
+	assert.Equal(t, 5, tracesSink.SpanCount())
+}
+
+func TestServeHTTPQueue(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Secret = "mysecret"
+	cfg.ReposConfig = map[string][]string{"grafana/app": {"main"}}
+	cfg.DroneConfig.LogRetrieval.QueueSize = 1
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	rec.tracesConsumer = new(consumertest.TracesSink)
+	metricsSink := new(consumertest.MetricsSink)
+	rec.metricsConsumer = metricsSink
+
+	send := func() int {
+		body, err := json.Marshal(newMetricsEvent(drone.StatusPassing))
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, cfg.Path, bytes.NewReader(body))
+		req.Header.Set("Date", "Thu, 08 Dec 2023 10:31:40 GMT")
+		require.NoError(t, httpsignatures.DefaultSha256Signer.SignRequest("keyID", cfg.Secret, req))
+		resp := httptest.NewRecorder()
+		rec.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	assert.Equal(t, http.StatusOK, send())
+	// Builds are rejected while the queue is full
+	assert.Equal(t, http.StatusServiceUnavailable, send())
+	assert.Len(t, rec.events, 1)
+
+	// Rejected builds are counted once redelivered
+	require.Len(t, metricsSink.AllMetrics(), 1)
+	<-rec.events
+	assert.Equal(t, http.StatusOK, send())
+	require.Len(t, metricsSink.AllMetrics(), 2)
+	assert.Equal(t, map[string]int64{"failure": 2}, counterValues(metricsSink.AllMetrics()[1], "builds_total"))
+}
+
+func TestProcessEvents(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.ReposConfig = map[string][]string{"grafana/app": {"main"}}
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	rec.logs = noLogs{}
+	tracesSink := new(consumertest.TracesSink)
+	rec.tracesConsumer = tracesSink
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		rec.processEvents(ctx)
+		close(done)
+	}()
+
+	rec.events <- newMetricsEvent(drone.StatusPassing)
+	assert.Eventually(t, func() bool { return tracesSink.SpanCount() == 5 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
+
+func TestShutdownCancelsLogRetrieval(t *testing.T) {
+	// The API never answers the logs of the build
+	requested := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.NetAddr.Endpoint = "127.0.0.1:0"
+	cfg.DroneConfig.Host = server.URL
+	cfg.DroneConfig.Token = "token"
+	cfg.ReposConfig = map[string][]string{"grafana/app": {"main"}}
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	tracesSink := new(consumertest.TracesSink)
+	rec.tracesConsumer = tracesSink
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	rec.events <- newMetricsEvent(drone.StatusPassing)
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "logs were not requested")
+	}
+
+	// Shutdown doesn't wait for the timeouts and retries of the requests
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	started := time.Now()
+	require.NoError(t, rec.Shutdown(ctx))
+	assert.Less(t, time.Since(started), time.Second)
+
+	// The build is reported without its logs
+	assert.Equal(t, 5, tracesSink.SpanCount())
+}
+
+func TestServeHTTPApprovals(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Secret = "mysecret"
+	cfg.ReposConfig = map[string][]string{"grafana/app": {"main"}}
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	rec.logs = noLogs{}
+	tracesSink := new(consumertest.TracesSink)
+	rec.tracesConsumer = tracesSink
+
+	send := func(evt WebhookEvent) {
+		body, err := json.Marshal(evt)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, cfg.Path, bytes.NewReader(body))
+		req.Header.Set("Date", "Thu, 08 Dec 2023 10:31:40 GMT")
+		require.NoError(t, httpsignatures.DefaultSha256Signer.SignRequest("keyID", cfg.Secret, req))
+		rec.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// The blocked build isn't reported, but its blocked stage is remembered
+	send(newApprovalEvent(false))
+	assert.Empty(t, rec.events)
+	send(newApprovalEvent(true))
+	require.Len(t, rec.events, 1)
+	rec.processEvent(context.Background(), <-rec.events)
+
+	// Build, 2 stages and the wait of the deploy stage
+	require.Equal(t, 4, tracesSink.SpanCount())
+	spans := tracesSink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	assert.Equal(t, "deploy approval", spans.At(2).Name())
+}
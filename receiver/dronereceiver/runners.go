@@ -0,0 +1,111 @@
+package dronereceiver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/scraper/scrapererror"
+)
+
+// runnersTimeout bounds each request of a scrape of the queue and runners
+const runnersTimeout = 30 * time.Second
+
+// runnersScraperType identifies the scraper of the queue and runners, next
+// to the scraper of the database
+var runnersScraperType = component.MustNewType("drone_runners")
+
+// platform is the operating system, architecture and labels stages are
+// routed by, and runners accept
+type platform struct {
+	os, arch, labels string
+}
+
+// runnersScraper reports the stages queued in Drone and the capacity of its
+// runners, read from the queue and nodes endpoints of the Drone API. Both
+// need an admin token.
+type runnersScraper struct {
+	settings component.TelemetrySettings
+	mb       *metadata.MetricsBuilder
+	api      apiClient
+}
+
+func newRunnersScraper(settings receiver.Settings, cfg *Config, api apiClient) *runnersScraper {
+	return &runnersScraper{
+		settings: settings.TelemetrySettings,
+		mb:       metadata.NewMetricsBuilder(cfg.MetricsBuilderConfig, settings),
+		api:      api,
+	}
+}
+
+func (r *runnersScraper) scrape(ctx context.Context) (pmetric.Metrics, error) {
+	errs := &scrapererror.ScrapeErrors{}
+	now := pcommon.NewTimestampFromTime(time.Now())
+
+	// The queue of every platform with runners is reported, so that empty
+	// queues are reported as such.
+	queue := map[platform]map[metadata.AttributeCiWorkflowItemStatus]int64{}
+
+	var nodes []*drone.Node
+	if err := r.api.getJSON(ctx, "api/nodes", &nodes); err != nil {
+		errs.AddPartial(2, fmt.Errorf("failed to list the runners: %w", err))
+	}
+	runners, capacity := map[platform]int64{}, map[platform]int64{}
+	for _, node := range nodes {
+		if node.Paused {
+			continue
+		}
+		p := platform{node.OS, node.Arch, sortedLabels(node.Labels)}
+		runners[p]++
+		capacity[p] += int64(node.Capacity)
+		queue[p] = map[metadata.AttributeCiWorkflowItemStatus]int64{}
+	}
+	for p, n := range runners {
+		r.mb.RecordRunnersDataPoint(now, n, p.os, p.arch, p.labels)
+		r.mb.RecordRunnersCapacityDataPoint(now, capacity[p], p.os, p.arch, p.labels)
+	}
+
+	var stages []*drone.Stage
+	if err := r.api.getJSON(ctx, "api/queue", &stages); err != nil {
+		errs.AddPartial(1, fmt.Errorf("failed to read the queue: %w", err))
+	}
+	for _, stage := range stages {
+		status := metadata.AttributeCiWorkflowItemStatusPending
+		if stage.Status == drone.StatusRunning {
+			status = metadata.AttributeCiWorkflowItemStatusRunning
+		}
+		p := platform{stage.OS, stage.Arch, sortedLabels(stage.Labels)}
+		if queue[p] == nil {
+			queue[p] = map[metadata.AttributeCiWorkflowItemStatus]int64{}
+		}
+		queue[p][status]++
+	}
+	for p, counts := range queue {
+		for _, status := range []metadata.AttributeCiWorkflowItemStatus{
+			metadata.AttributeCiWorkflowItemStatusPending,
+			metadata.AttributeCiWorkflowItemStatusRunning,
+		} {
+			r.mb.RecordQueueStagesDataPoint(now, counts[status], status, p.os, p.arch, p.labels)
+		}
+	}
+
+	return r.mb.Emit(), errs.Combine()
+}
+
+// sortedLabels returns labels as sorted key=value pairs separated by commas
+func sortedLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, key+"="+labels[key])
+	}
+	return strings.Join(pairs, ",")
+}
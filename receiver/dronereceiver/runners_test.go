@@ -0,0 +1,139 @@
+package dronereceiver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.opentelemetry.io/collector/scraper/scrapererror"
+)
+
+// gauges returns the values of a gauge by their attributes, joined by
+// slashes.
+func gauges(metrics pmetric.Metrics, name string) map[string]int64 {
+	values := map[string]int64{}
+	if metrics.ResourceMetrics().Len() == 0 {
+		return values
+	}
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := range ms.Len() {
+		if ms.At(i).Name() != name {
+			continue
+		}
+		dps := ms.At(i).Gauge().DataPoints()
+		for j := range dps.Len() {
+			attrs := dps.At(j).Attributes()
+			key := ""
+			for _, attr := range []string{"ci.workflow_item.status", "ci.drone.os", "ci.drone.arch", "ci.drone.labels"} {
+				if v, ok := attrs.Get(attr); ok {
+					key += v.Str() + "/"
+				}
+			}
+			values[key] = dps.At(j).IntValue()
+		}
+	}
+	return values
+}
+
+// newTestRunnersScraper returns a scraper of a Drone server answering the
+// nodes and queue endpoints with the given handlers.
+func newTestRunnersScraper(t *testing.T, nodes, queue http.HandlerFunc) *runnersScraper {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/nodes", nodes)
+	mux.Handle("GET /api/queue", queue)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	api, err := newAPIClient(server.URL, server.Client())
+	require.NoError(t, err)
+	cfg := createDefaultConfig().(*Config)
+	return newRunnersScraper(receivertest.NewNopSettings(metadata.Type), cfg, api)
+}
+
+// respondJSON returns a handler answering with v
+func respondJSON(t *testing.T, v any) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		assert.NoError(t, json.NewEncoder(w).Encode(v))
+	}
+}
+
+func TestRunnersScrape(t *testing.T) {
+	scraper := newTestRunnersScraper(t, respondJSON(t, []*drone.Node{
+		{OS: "linux", Arch: "amd64", Capacity: 2},
+		{OS: "linux", Arch: "amd64", Capacity: 4},
+		{OS: "linux", Arch: "arm64", Capacity: 2, Labels: map[string]string{"size": "large", "gpu": "true"}},
+		{OS: "windows", Arch: "amd64", Capacity: 1, Paused: true},
+	}), respondJSON(t, []*drone.Stage{
+		{OS: "linux", Arch: "amd64", Status: drone.StatusRunning},
+		{OS: "linux", Arch: "amd64", Status: drone.StatusPending},
+		{OS: "linux", Arch: "amd64", Status: drone.StatusPending},
+		{OS: "windows", Arch: "amd64", Status: drone.StatusPending},
+	}))
+
+	metrics, err := scraper.scrape(context.Background())
+	require.NoError(t, err)
+
+	// Paused runners take no stages
+	assert.Equal(t, map[string]int64{
+		"linux/amd64//":                    2,
+		"linux/arm64/gpu=true,size=large/": 1,
+	}, gauges(metrics, "runners"))
+	assert.Equal(t, map[string]int64{
+		"linux/amd64//":                    6,
+		"linux/arm64/gpu=true,size=large/": 2,
+	}, gauges(metrics, "runners_capacity"))
+
+	// Platforms with runners report empty queues, stages waiting for
+	// runners are reported too
+	assert.Equal(t, map[string]int64{
+		"pending/linux/amd64//":                    2,
+		"running/linux/amd64//":                    1,
+		"pending/linux/arm64/gpu=true,size=large/": 0,
+		"running/linux/arm64/gpu=true,size=large/": 0,
+		"pending/windows/amd64//":                  1,
+		"running/windows/amd64//":                  0,
+	}, gauges(metrics, "queue_stages"))
+}
+
+func TestRunnersScrapeErrors(t *testing.T) {
+	forbidden := func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}
+	scraper := newTestRunnersScraper(t, forbidden, respondJSON(t, []*drone.Stage{
+		{OS: "linux", Arch: "amd64", Status: drone.StatusPending},
+	}))
+
+	metrics, err := scraper.scrape(context.Background())
+	require.ErrorContains(t, err, "failed to list the runners: /api/nodes: unexpected status 403 Forbidden")
+	var partial scrapererror.PartialScrapeError
+	require.ErrorAs(t, err, &partial)
+
+	// The queue is reported without the runners
+	assert.Empty(t, gauges(metrics, "runners"))
+	assert.Equal(t, map[string]int64{
+		"pending/linux/amd64//": 1,
+		"running/linux/amd64//": 0,
+	}, gauges(metrics, "queue_stages"))
+}
+
+func TestRunnersScrapeCanceled(t *testing.T) {
+	hang := func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}
+	scraper := newTestRunnersScraper(t, hang, hang)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := scraper.scrape(ctx)
+	require.ErrorContains(t, err, context.DeadlineExceeded.Error())
+}
@@ -2,15 +2,13 @@ package dronereceiver
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
@@ -19,15 +17,31 @@ import (
 )
 
 const (
-	// Timeout is the maximum time to wait for DB connection
-	Timeout = 120
+	// initialConnectBackoff and maxConnectBackoff bound the delay between
+	// attempts to connect to the database, doubled after each failure.
+	initialConnectBackoff = 5 * time.Second
+	maxConnectBackoff     = 5 * time.Minute
+	// connectTimeout bounds each attempt to connect to the database.
+	connectTimeout = 10 * time.Second
 )
 
+// errConnectBackoff is returned by scrapes between failed attempts to
+// connect to the database.
+var errConnectBackoff = errors.New("waiting to reconnect to the database")
+
 type droneScraper struct {
 	settings component.TelemetrySettings
-	dbPool   *pgxpool.Pool
 	mb       *metadata.MetricsBuilder
 	cfg      *Config
+
+	// db is connected by the first scrape, as the database may be
+	// unavailable when the collector starts. Failed attempts are retried
+	// after backoff, from retryAt.
+	db      dbBackend
+	backoff time.Duration
+	retryAt time.Time
+	// connect opens the database and checks it can be reached.
+	connect func(ctx context.Context, cfg DBConfig) (dbBackend, error)
 }
 
 func newDroneScraper(settings receiver.Settings, cfg *Config) *droneScraper {
@@ -35,35 +49,23 @@ func newDroneScraper(settings receiver.Settings, cfg *Config) *droneScraper {
 		cfg:      cfg,
 		settings: settings.TelemetrySettings,
 		mb:       metadata.NewMetricsBuilder(cfg.MetricsBuilderConfig, settings),
+		connect:  openBackend,
 	}
 }
 
-// Start initializes the scraper
-func (r *droneScraper) Start(ctx context.Context, host component.Host) error {
+// Start initializes the scraper. The database is connected to by the first
+// scrape.
+func (r *droneScraper) Start(_ context.Context, _ component.Host) error {
 	r.settings.Logger.Info("Starting the drone scraper")
+	return nil
+}
 
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	timeoutExceeded := time.After(Timeout * time.Second)
-	success := false
-	for !success {
-		select {
-		case <-timeoutExceeded:
-			r.settings.Logger.Error("db connection failed after %d second(s) timeout", zap.Int64("timeout", Timeout))
-			os.Exit(1)
-
-		case <-ticker.C:
-			connString := fmt.Sprintf("postgres://%s:%s@%s:5432/%s?sslmode=disable", r.cfg.DroneConfig.Database.Username, r.cfg.DroneConfig.Database.Password, r.cfg.DroneConfig.Database.Host, r.cfg.DroneConfig.Database.DB)
-			err := r.DBConnect(connString)
-			if err == nil {
-				success = true
-				break
-			}
-			r.settings.Logger.Error("failed attempt to connect to db %w", zap.Error(err))
-		}
+// Shutdown closes the connections to the database.
+func (r *droneScraper) Shutdown(_ context.Context) error {
+	if r.db != nil {
+		r.db.close()
+		r.db = nil
 	}
-
 	return nil
 }
 
@@ -74,6 +76,15 @@ func (r *droneScraper) scrape(ctx context.Context) (pmetric.Metrics, error) {
 
 	now := pcommon.NewTimestampFromTime(time.Now())
 
+	if err := r.ensureConnected(ctx); err != nil {
+		// The other metrics can't be read, only the health of the
+		// connection is reported.
+		r.mb.RecordDatabaseUpDataPoint(now, 0)
+		errs.AddPartial(3, err)
+		return r.mb.Emit(), errs.Combine()
+	}
+	r.mb.RecordDatabaseUpDataPoint(now, 1)
+
 	r.scrapeBuilds(ctx, now, errs)
 	r.scrapeRestartedBuilds(ctx, now, errs)
 	r.scrapeInfo(ctx, now, errs)
@@ -81,70 +92,62 @@ func (r *droneScraper) scrape(ctx context.Context) (pmetric.Metrics, error) {
 	return r.mb.Emit(), errs.Combine()
 }
 
-// repo_slug, build_source, build_status
-type Builds map[string]map[string]map[metadata.AttributeCiWorkflowItemStatus]int64
+// ensureConnected connects to the database on the first scrape, and after
+// the backoff of failed attempts. Once connected, the pool reconnects on
+// its own, and the database is pinged to report its health.
+func (r *droneScraper) ensureConnected(ctx context.Context) error {
+	if r.db != nil {
+		if err := r.db.ping(ctx); err != nil {
+			return fmt.Errorf("database is unreachable: %w", err)
+		}
+		return nil
+	}
 
-func (r *droneScraper) scrapeBuilds(ctx context.Context, now pcommon.Timestamp, errs *scrapererror.ScrapeErrors) {
-	var buildCount int64
+	now := time.Now()
+	if now.Before(r.retryAt) {
+		return fmt.Errorf("%w in %s", errConnectBackoff, r.retryAt.Sub(now).Round(time.Second))
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+	defer cancel()
 
-	conditions := make([]string, 0)
-	repoSlugs := make([]string, 0)
-	for repoSlug, buildSources := range r.cfg.ReposConfig {
-		repoSlugs = append(repoSlugs, repoSlug)
-		conditions = append(conditions, fmt.Sprintf("WHEN repo_slug = '%s' AND build_source IN ('%s') THEN build_source", repoSlug, strings.Join(buildSources, "', '")))
+	db, err := r.connect(connectCtx, r.cfg.DroneConfig.Database)
+	if err != nil {
+		r.backoff = min(max(2*r.backoff, initialConnectBackoff), maxConnectBackoff)
+		r.retryAt = now.Add(r.backoff)
+		r.settings.Logger.Warn("Failed to connect to the database", zap.Duration("retry_in", r.backoff), zap.Error(err))
+		return fmt.Errorf("failed to connect to the database: %w", err)
 	}
 
-	rows, err := r.dbPool.Query(ctx, fmt.Sprintf(`
-		SELECT 
-			count(*),
-			build_status,
-			CASE 
-				WHEN repo_slug IN ('%s') THEN repo_slug
-				ELSE 'other'
-			END AS slug,
-			CASE 
-				%s
-				ELSE 'other'
-			END AS source
-		FROM 
-			builds 
-		LEFT JOIN
-			repos r
-		ON 
-			build_repo_id = r.repo_id  
-		GROUP BY 
-			build_status,
-			slug,
-			source
-	`, strings.Join(repoSlugs, "', '"), strings.Join(conditions, " ")))
+	r.settings.Logger.Info("Connected to the database")
+	r.db = db
+	r.backoff, r.retryAt = 0, time.Time{}
+	return nil
+}
+
+// repo_slug, build_source, build_status
+type Builds map[string]map[string]map[metadata.AttributeCiWorkflowItemStatus]int64
 
+func (r *droneScraper) scrapeBuilds(ctx context.Context, now pcommon.Timestamp, errs *scrapererror.ScrapeErrors) {
+	counts, err := r.db.builds(ctx, r.cfg.ReposConfig)
 	if err != nil {
 		errs.Add(err)
 	}
 
 	values := make(Builds)
-	for rows.Next() {
-		var status string
-		var slug string
-		var source string
-		err := rows.Scan(&buildCount, &status, &slug, &source)
-		if err != nil {
-			errs.Add(err)
-			continue
+	for _, c := range counts {
+		if _, ok := values[c.slug]; !ok {
+			values[c.slug] = make(map[string]map[metadata.AttributeCiWorkflowItemStatus]int64)
 		}
 
-		if _, ok := values[slug]; !ok {
-			values[slug] = make(map[string]map[metadata.AttributeCiWorkflowItemStatus]int64)
+		if _, ok := values[c.slug][c.source]; !ok {
+			values[c.slug][c.source] = make(map[metadata.AttributeCiWorkflowItemStatus]int64)
 		}
 
-		if _, ok := values[slug][source]; !ok {
-			values[slug][source] = make(map[metadata.AttributeCiWorkflowItemStatus]int64)
-		}
-
-		if key, ok := metadata.MapAttributeCiWorkflowItemStatus[status]; ok {
-			values[slug][source][key] = buildCount
+		if key, ok := metadata.MapAttributeCiWorkflowItemStatus[c.status]; ok {
+			values[c.slug][c.source][key] = c.count
 		} else {
-			values[slug][source][key] += buildCount
+			values[c.slug][c.source][key] += c.count
 		}
 	}
 
@@ -163,9 +166,7 @@ func (r *droneScraper) scrapeBuilds(ctx context.Context, now pcommon.Timestamp,
 }
 
 func (r *droneScraper) scrapeRestartedBuilds(ctx context.Context, now pcommon.Timestamp, errs *scrapererror.ScrapeErrors) {
-	var count int64
-	builds := r.dbPool.QueryRow(ctx, "SELECT COALESCE(SUM(occurrence_count - 1), 0) AS total_occurrence_count FROM ( SELECT count(*) AS occurrence_count FROM builds GROUP BY build_after, build_source HAVING COUNT(*) > 1) subquery")
-	err := builds.Scan(&count)
+	count, err := r.db.restartedBuilds(ctx)
 	if err != nil {
 		errs.Add(err)
 	}
@@ -173,58 +174,12 @@ func (r *droneScraper) scrapeRestartedBuilds(ctx context.Context, now pcommon.Ti
 }
 
 func (r *droneScraper) scrapeInfo(ctx context.Context, now pcommon.Timestamp, errs *scrapererror.ScrapeErrors) {
-
-	conditions := make([]string, 0)
-	for repoSlug, buildSources := range r.cfg.ReposConfig {
-		conditions = append(conditions, fmt.Sprintf("repo_slug = '%s' AND build_source IN ('%s')", repoSlug, strings.Join(buildSources, "', '")))
-	}
-
-	rows, err := r.dbPool.Query(ctx, fmt.Sprintf(`
-		SELECT build_status, r.repo_slug, build_source FROM builds
-		LEFT JOIN
-			repos r
-		ON 
-			build_repo_id = r.repo_id 
-		WHERE build_id IN (
-			SELECT MAX(build_id) 
-			FROM 
-				builds 
-			JOIN 
-				repos r 
-			ON 
-				build_repo_id = r.repo_id
-			WHERE 
-				build_status NOT IN ('running','waiting_on_dependencies','pending') 
-				AND (%s)
-			GROUP BY build_repo_id, build_source
-		)
-	`, strings.Join(conditions, " OR ")))
-
+	statuses, err := r.db.lastBuilds(ctx, r.cfg.ReposConfig)
 	if err != nil {
 		errs.Add(err)
 	}
 
-	for rows.Next() {
-		var status string
-		var slug string
-		var source string
-		err := rows.Scan(&status, &slug, &source)
-		if err != nil {
-			errs.Add(err)
-			continue
-		}
-
-		r.mb.RecordRepoInfoDataPoint(now, 1, metadata.MapAttributeCiWorkflowItemStatus[status], slug, source)
+	for _, s := range statuses {
+		r.mb.RecordRepoInfoDataPoint(now, 1, metadata.MapAttributeCiWorkflowItemStatus[s.status], s.slug, s.source)
 	}
 }
-
-// DBConnect establishes connection to the database
-func (r *droneScraper) DBConnect(connString string) error {
-	pool, err := pgxpool.New(context.Background(), connString)
-	if err != nil {
-		return err
-	}
-	r.settings.Logger.Info("successfully connected to db!")
-	r.dbPool = pool
-	return nil
-}
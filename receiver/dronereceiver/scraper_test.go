@@ -0,0 +1,212 @@
+package dronereceiver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/receiver/dronereceiver/internal/metadata"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.opentelemetry.io/collector/scraper/scrapererror"
+)
+
+// Names a query formatted with fmt.Sprintf would choke on, or run.
+const (
+	hostileRepo   = "acme/o'brien"
+	hostileBranch = "main'); DROP TABLE builds; --"
+)
+
+func newTestScraper(t *testing.T, repos map[string][]string) (*droneScraper, pgxmock.PgxPoolIface) {
+	t.Helper()
+
+	// Queries must be sent verbatim: names are bound, never formatted in.
+	mock, err := pgxmock.NewPool(pgxmock.QueryMatcherOption(pgxmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(mock.Close)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.ReposConfig = repos
+	scraper := newDroneScraper(receivertest.NewNopSettings(metadata.Type), cfg)
+	scraper.db = &postgresBackend{pool: mock}
+	return scraper, mock
+}
+
+// dataPoints returns the data points of a metric by their attributes.
+func dataPoints(metrics pmetric.Metrics, name string) map[[3]string]int64 {
+	points := map[[3]string]int64{}
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := range ms.Len() {
+		if ms.At(i).Name() != name {
+			continue
+		}
+		dps := ms.At(i).Sum().DataPoints()
+		for j := range dps.Len() {
+			attrs := dps.At(j).Attributes()
+			status, _ := attrs.Get("ci.workflow_item.status")
+			repo, _ := attrs.Get("git.repo.name")
+			branch, _ := attrs.Get("git.branch.name")
+			points[[3]string{status.Str(), repo.Str(), branch.Str()}] = dps.At(j).IntValue()
+		}
+	}
+	return points
+}
+
+// databaseUp returns the value of the database_up metric, -1 when missing.
+func databaseUp(metrics pmetric.Metrics) int64 {
+	if metrics.ResourceMetrics().Len() == 0 {
+		return -1
+	}
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := range ms.Len() {
+		if ms.At(i).Name() == "database_up" {
+			return ms.At(i).Gauge().DataPoints().At(0).IntValue()
+		}
+	}
+	return -1
+}
+
+func TestRepoSources(t *testing.T) {
+	slugs, pairSlugs, pairSources := repoSources(map[string][]string{
+		"grafana/loki": {"main"},
+		hostileRepo:    {hostileBranch, "release"},
+	})
+
+	require.Equal(t, []string{hostileRepo, "grafana/loki"}, slugs)
+	require.Equal(t, []string{hostileRepo, hostileRepo, "grafana/loki"}, pairSlugs)
+	require.Equal(t, []string{hostileBranch, "release", "main"}, pairSources)
+}
+
+func TestScrape(t *testing.T) {
+	scraper, mock := newTestScraper(t, map[string][]string{hostileRepo: {hostileBranch}})
+
+	mock.ExpectPing()
+	mock.ExpectQuery(buildsQuery).
+		WithArgs([]string{hostileRepo}, []string{hostileRepo}, []string{hostileBranch}).
+		WillReturnRows(mock.NewRows([]string{"count", "build_status", "slug", "source"}).
+			AddRow(int64(3), "success", hostileRepo, hostileBranch).
+			AddRow(int64(2), "failure", hostileRepo, hostileBranch).
+			AddRow(int64(7), "success", "other", "other"))
+	mock.ExpectQuery(restartedBuildsQuery).
+		WillReturnRows(mock.NewRows([]string{"total_occurrence_count"}).AddRow(int64(4)))
+	mock.ExpectQuery(infoQuery).
+		WithArgs([]string{hostileRepo}, []string{hostileBranch}).
+		WillReturnRows(mock.NewRows([]string{"build_status", "repo_slug", "build_source"}).
+			AddRow("failure", hostileRepo, hostileBranch))
+
+	metrics, err := scraper.scrape(t.Context())
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	builds := dataPoints(metrics, "builds_number")
+	// Every status is reported for each repository and source.
+	require.Len(t, builds, 2*len(metadata.MapAttributeCiWorkflowItemStatus))
+	require.Equal(t, int64(3), builds[[3]string{"success", hostileRepo, hostileBranch}])
+	require.Equal(t, int64(2), builds[[3]string{"failure", hostileRepo, hostileBranch}])
+	require.Equal(t, int64(0), builds[[3]string{"running", hostileRepo, hostileBranch}])
+	require.Equal(t, int64(7), builds[[3]string{"success", "other", "other"}])
+
+	require.Equal(t, map[[3]string]int64{{"failure", hostileRepo, hostileBranch}: 1}, dataPoints(metrics, "repo_info"))
+	require.Equal(t, int64(1), databaseUp(metrics))
+}
+
+func TestScrapeQueryErrors(t *testing.T) {
+	scraper, mock := newTestScraper(t, map[string][]string{"grafana/loki": {"main"}})
+
+	errQuery := errors.New("connection reset")
+	mock.ExpectPing()
+	mock.ExpectQuery(buildsQuery).
+		WithArgs([]string{"grafana/loki"}, []string{"grafana/loki"}, []string{"main"}).
+		WillReturnError(errQuery)
+	mock.ExpectQuery(restartedBuildsQuery).
+		WillReturnRows(mock.NewRows([]string{"total_occurrence_count"}).AddRow(int64(1)))
+	mock.ExpectQuery(infoQuery).
+		WithArgs([]string{"grafana/loki"}, []string{"main"}).
+		WillReturnError(errQuery)
+
+	// Failed queries are reported without stopping the other ones.
+	metrics, err := scraper.scrape(t.Context())
+	require.ErrorIs(t, err, errQuery)
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Equal(t, 2, metrics.DataPointCount())
+	require.Equal(t, int64(1), databaseUp(metrics))
+}
+
+func TestScrapeConnection(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	t.Cleanup(mock.Close)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.DroneConfig.Database = DBConfig{Host: "db", Username: "drone", Password: "p@ss/word", DB: "drone", SSLMode: "require"}
+	scraper := newDroneScraper(receivertest.NewNopSettings(metadata.Type), cfg)
+
+	errRefused := errors.New("connection refused")
+	var connStrings []string
+	closed := 0
+	scraper.connect = func(_ context.Context, cfg DBConfig) (dbBackend, error) {
+		connStrings = append(connStrings, cfg.connString())
+		if len(connStrings) < 3 {
+			return nil, errRefused
+		}
+		return &postgresBackend{pool: mock, closePool: func() { closed++ }}, nil
+	}
+
+	// Failed connections are reported as scrape errors, with the health of
+	// the connection.
+	metrics, err := scraper.scrape(t.Context())
+	require.ErrorContains(t, err, errRefused.Error())
+	require.True(t, scrapererror.IsPartialScrapeError(err))
+	require.Equal(t, int64(0), databaseUp(metrics))
+	require.Equal(t, initialConnectBackoff, scraper.backoff)
+
+	// Scrapes within the backoff don't reconnect.
+	metrics, err = scraper.scrape(t.Context())
+	require.ErrorContains(t, err, errConnectBackoff.Error())
+	require.Equal(t, int64(0), databaseUp(metrics))
+	require.Len(t, connStrings, 1)
+
+	// Each failure doubles the backoff.
+	scraper.retryAt = time.Time{}
+	_, err = scraper.scrape(t.Context())
+	require.ErrorContains(t, err, errRefused.Error())
+	require.Equal(t, 2*initialConnectBackoff, scraper.backoff)
+
+	scraper.retryAt = time.Time{}
+	mock.ExpectQuery("SELECT").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(mock.NewRows([]string{"count", "build_status", "slug", "source"}))
+	mock.ExpectQuery("SELECT").WillReturnRows(mock.NewRows([]string{"total_occurrence_count"}).AddRow(int64(0)))
+	mock.ExpectQuery("SELECT").WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).WillReturnRows(mock.NewRows([]string{"build_status", "repo_slug", "build_source"}))
+	metrics, err = scraper.scrape(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), databaseUp(metrics))
+	require.Zero(t, scraper.backoff)
+	require.Equal(t, "postgres://drone:p%40ss%2Fword@db:5432/drone?sslmode=require", connStrings[2])
+
+	// Once connected, pings report the health of the connection.
+	mock.ExpectPing().WillReturnError(errRefused)
+	metrics, err = scraper.scrape(t.Context())
+	require.ErrorContains(t, err, errRefused.Error())
+	require.Equal(t, int64(0), databaseUp(metrics))
+	require.Len(t, connStrings, 3)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	require.NoError(t, scraper.Shutdown(t.Context()))
+	require.Equal(t, 1, closed)
+}
+
+func TestConnectBackoffLimit(t *testing.T) {
+	scraper := newDroneScraper(receivertest.NewNopSettings(metadata.Type), createDefaultConfig().(*Config))
+	scraper.connect = func(context.Context, DBConfig) (dbBackend, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	for range 10 {
+		scraper.retryAt = time.Time{}
+		_, err := scraper.scrape(t.Context())
+		require.Error(t, err)
+	}
+	require.Equal(t, maxConnectBackoff, scraper.backoff)
+}
@@ -0,0 +1,85 @@
+-- Tables of the SQLite schema of Drone read by the scraper, as created by
+-- the migrations of drone/store/shared/migrate/sqlite.
+
+CREATE TABLE IF NOT EXISTS repos (
+ repo_id                    INTEGER PRIMARY KEY AUTOINCREMENT
+,repo_uid                   TEXT
+,repo_user_id               INTEGER
+,repo_namespace             TEXT
+,repo_name                  TEXT
+,repo_slug                  TEXT
+,repo_scm                   TEXT
+,repo_clone_url             TEXT
+,repo_ssh_url               TEXT
+,repo_html_url              TEXT
+,repo_active                BOOLEAN
+,repo_private               BOOLEAN
+,repo_visibility            TEXT
+,repo_branch                TEXT
+,repo_counter               INTEGER
+,repo_config                TEXT
+,repo_timeout               INTEGER
+,repo_trusted               BOOLEAN
+,repo_protected             BOOLEAN
+,repo_synced                INTEGER
+,repo_created               INTEGER
+,repo_updated               INTEGER
+,repo_version               INTEGER
+,repo_signer                TEXT
+,repo_secret                TEXT
+,UNIQUE(repo_slug)
+,UNIQUE(repo_uid)
+);
+
+CREATE TABLE IF NOT EXISTS builds (
+ build_id            INTEGER PRIMARY KEY AUTOINCREMENT
+,build_repo_id       INTEGER
+,build_config_id     INTEGER
+,build_trigger       TEXT
+,build_number        INTEGER
+,build_parent        INTEGER
+,build_status        TEXT
+,build_error         TEXT
+,build_event         TEXT
+,build_action        TEXT
+,build_link          TEXT
+,build_timestamp     INTEGER
+,build_title         TEXT
+,build_message       TEXT
+,build_before        TEXT
+,build_after         TEXT
+,build_ref           TEXT
+,build_source_repo   TEXT
+,build_source        TEXT
+,build_target        TEXT
+,build_author        TEXT
+,build_author_name   TEXT
+,build_author_email  TEXT
+,build_author_avatar TEXT
+,build_sender        TEXT
+,build_deploy        TEXT
+,build_params        TEXT
+,build_started       INTEGER
+,build_finished      INTEGER
+,build_created       INTEGER
+,build_updated       INTEGER
+,build_version       INTEGER
+,UNIQUE(build_repo_id, build_number)
+);
+
+CREATE INDEX IF NOT EXISTS ix_build_repo ON builds (build_repo_id);
+
+INSERT INTO repos (repo_id, repo_uid, repo_namespace, repo_name, repo_slug, repo_branch, repo_active) VALUES
+ (1, '101', 'grafana', 'loki', 'grafana/loki', 'main', 1)
+,(2, '102', 'acme', 'o''brien', 'acme/o''brien', 'main', 1)
+,(3, '103', 'grafana', 'other', 'grafana/other', 'main', 1);
+
+INSERT INTO builds (build_repo_id, build_number, build_status, build_event, build_after, build_source, build_target, build_started, build_finished, build_created) VALUES
+ (1, 1, 'success', 'push', 'a1', 'main', 'main', 1700000010, 1700000100, 1700000000)
+,(1, 2, 'failure', 'push', 'a2', 'main', 'main', 1700001010, 1700001100, 1700001000)
+,(1, 3, 'success', 'push', 'a2', 'main', 'main', 1700002010, 1700002100, 1700002000)
+,(1, 4, 'running', 'push', 'a3', 'main', 'main', 1700003010, 0, 1700003000)
+,(1, 5, 'success', 'push', 'b1', 'dev', 'dev', 1700004010, 1700004100, 1700004000)
+,(2, 1, 'failure', 'push', 'c1', 'main''); DROP TABLE builds; --', 'main', 1700005010, 1700005100, 1700005000)
+,(3, 1, 'success', 'push', 'd1', 'main', 'main', 1700006010, 1700006100, 1700006000)
+,(3, 2, 'success', 'push', 'd1', 'main', 'main', 1700007010, 1700007100, 1700007000);
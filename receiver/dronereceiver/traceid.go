@@ -0,0 +1,81 @@
+package dronereceiver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// The IDs of a build are derived from the Drone server, the repository and
+// the build, stage and step numbers, so that redelivered webhooks report the
+// same trace, and steps can compute the context of their span. They follow
+// the scheme of the GitHub Actions receiver: the trace ID is the first 16
+// bytes of the SHA-256 of its input, and span IDs are bytes 8 to 16.
+//
+//	trace  sha256("<host>:<repo id>:<build>t")
+//	build  sha256("<host>:<repo id>:<build>s")
+//	stage  sha256("<host>:<repo id>:<build>:<stage>")
+//	step   sha256("<host>:<repo id>:<build>:<stage>:<step>")
+//
+// The wait of a stage for its approval has the span
+// sha256("<host>:<repo id>:<build>:<stage>a"), stage being 0 when the whole
+// build waited.
+
+// generateTraceID returns the trace ID of a build.
+func generateTraceID(host string, repoID, build int64) pcommon.TraceID {
+	hash := sha256.Sum256(fmt.Appendf(nil, "%s:%d:%dt", systemHost(host), repoID, build))
+	return pcommon.TraceID(hash[:16])
+}
+
+func generateBuildSpanID(host string, repoID, build int64) pcommon.SpanID {
+	return generateSpanID(fmt.Sprintf("%s:%d:%ds", systemHost(host), repoID, build))
+}
+
+func generateStageSpanID(host string, repoID, build int64, stage int) pcommon.SpanID {
+	return generateSpanID(fmt.Sprintf("%s:%d:%d:%d", systemHost(host), repoID, build, stage))
+}
+
+func generateStepSpanID(host string, repoID, build int64, stage, step int) pcommon.SpanID {
+	return generateSpanID(fmt.Sprintf("%s:%d:%d:%d:%d", systemHost(host), repoID, build, stage, step))
+}
+
+func generateApprovalSpanID(host string, repoID, build int64, stage int) pcommon.SpanID {
+	return generateSpanID(fmt.Sprintf("%s:%d:%d:%da", systemHost(host), repoID, build, stage))
+}
+
+func generateSpanID(input string) pcommon.SpanID {
+	hash := sha256.Sum256([]byte(input))
+	return pcommon.SpanID(hash[8:16])
+}
+
+// systemHost returns the host of a server address, as Drone reports it in
+// webhooks and DRONE_SYSTEM_HOST. Woodpecker events carry the URL of the
+// server.
+func systemHost(host string) string {
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	return strings.TrimSuffix(host, "/")
+}
+
+// TraceParent returns the W3C traceparent of the span of a step, such as
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01, which programs
+// running in the step can use as the parent of their spans. host is
+// DRONE_SYSTEM_HOST, build, stage and step are DRONE_BUILD_NUMBER,
+// DRONE_STAGE_NUMBER and DRONE_STEP_NUMBER, and repoID is the id of the
+// repository in the Drone API. The span of the stage is returned when step
+// is 0, and the span of the build when stage is 0 too.
+func TraceParent(host string, repoID, build int64, stage, step int) string {
+	var spanID pcommon.SpanID
+	switch {
+	case stage == 0:
+		spanID = generateBuildSpanID(host, repoID, build)
+	case step == 0:
+		spanID = generateStageSpanID(host, repoID, build, stage)
+	default:
+		spanID = generateStepSpanID(host, repoID, build, stage, step)
+	}
+	return fmt.Sprintf("00-%s-%s-01", generateTraceID(host, repoID, build), spanID)
+}
@@ -0,0 +1,81 @@
+package dronereceiver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestGenerateIDs(t *testing.T) {
+	t.Run("Are stable", func(t *testing.T) {
+		// The scheme is documented, steps compute the same IDs with sha256sum.
+		assert.Equal(t, "189cdddc9d9b5eed4bc541288e7b2ff3", generateTraceID("drone.example.com", 42, 7).String())
+		assert.Equal(t, "9c7af28b3442e72e", generateBuildSpanID("drone.example.com", 42, 7).String())
+		assert.Equal(t, "2a7aa07fe3bcf67b", generateStepSpanID("drone.example.com", 42, 7, 1, 2).String())
+	})
+
+	t.Run("Differ by build, stage and step", func(t *testing.T) {
+		assert.NotEqual(t, generateTraceID("drone.example.com", 42, 7), generateTraceID("drone.example.com", 42, 8))
+		assert.NotEqual(t, generateTraceID("drone.example.com", 42, 7), generateTraceID("drone.example.com", 43, 7))
+		assert.NotEqual(t, generateTraceID("drone.example.com", 42, 7), generateTraceID("ci.example.com", 42, 7))
+		assert.NotEqual(t, generateStageSpanID("drone.example.com", 42, 7, 1), generateStageSpanID("drone.example.com", 42, 7, 2))
+		assert.NotEqual(t, generateStepSpanID("drone.example.com", 42, 7, 1, 2), generateStepSpanID("drone.example.com", 42, 7, 2, 1))
+		assert.NotEqual(t, generateStepSpanID("drone.example.com", 42, 7, 1, 12), generateStepSpanID("drone.example.com", 42, 7, 11, 2))
+	})
+
+	t.Run("Ignore the scheme of the host", func(t *testing.T) {
+		assert.Equal(t, generateTraceID("drone.example.com", 42, 7), generateTraceID("https://drone.example.com/", 42, 7))
+	})
+}
+
+func TestTraceParent(t *testing.T) {
+	assert.Equal(t, "00-189cdddc9d9b5eed4bc541288e7b2ff3-2a7aa07fe3bcf67b-01", TraceParent("drone.example.com", 42, 7, 1, 2))
+	assert.Equal(t, "00-189cdddc9d9b5eed4bc541288e7b2ff3-9c7af28b3442e72e-01", TraceParent("drone.example.com", 42, 7, 0, 0))
+	assert.Equal(t, "00-189cdddc9d9b5eed4bc541288e7b2ff3-"+generateStageSpanID("drone.example.com", 42, 7, 1).String()+"-01", TraceParent("drone.example.com", 42, 7, 1, 0))
+}
+
+func TestHandleEventIDs(t *testing.T) {
+	config := createDefaultConfig().(*Config)
+	config.ReposConfig = map[string][]string{"grafana/app": {"main"}}
+	evt := newMetricsEvent(drone.StatusPassing)
+	evt.Host = "drone.example.com"
+	evt.Repo.ID = 42
+	evt.Repo.Build.Number = 7
+	for i, stage := range evt.Repo.Build.Stages {
+		stage.Number = i + 1
+		for j, step := range stage.Steps {
+			step.Number = j + 1
+		}
+	}
+
+	spans := func() map[string]ptrace.Span {
+		traces, _ := handleEvent(context.Background(), evt, config, noLogs{}, newTestLogPolicy(t, config.Logs), zaptest.NewLogger(t))
+		require.NotNil(t, traces)
+
+		spans := map[string]ptrace.Span{}
+		ss := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+		for i := range ss.Len() {
+			spans[ss.At(i).Name()] = ss.At(i)
+		}
+		return spans
+	}
+
+	// Redelivered webhooks report the same trace.
+	first, second := spans(), spans()
+	require.Len(t, first, 5)
+	for name, span := range first {
+		assert.Equal(t, span.TraceID(), second[name].TraceID(), name)
+		assert.Equal(t, span.SpanID(), second[name].SpanID(), name)
+	}
+
+	// Steps find their span from their environment.
+	test := first["test"]
+	assert.Equal(t, TraceParent("drone.example.com", 42, 7, 1, 2), "00-"+test.TraceID().String()+"-"+test.SpanID().String()+"-01")
+	assert.Equal(t, first["build"].SpanID(), test.ParentSpanID())
+	assert.Equal(t, generateBuildSpanID("drone.example.com", 42, 7), first["grafana/app"].SpanID())
+}
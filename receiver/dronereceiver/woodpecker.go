@@ -0,0 +1,419 @@
+package dronereceiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/drone/drone-go/drone"
+)
+
+// Woodpecker is a community fork of Drone. Its pipelines, workflows and steps
+// are the builds, stages and steps of Drone, so Woodpecker webhooks are
+// translated to Drone events and handled by handleEvent.
+
+// woodpeckerEvent is the payload of Woodpecker webhooks
+type woodpeckerEvent struct {
+	Repo     *woodpeckerRepo     `json:"repo"`
+	Pipeline *woodpeckerPipeline `json:"pipeline"`
+}
+
+type woodpeckerRepo struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	ForgeURL      string `json:"forge_url"`
+	CloneURL      string `json:"clone_url"`
+	CloneURLSSH   string `json:"clone_url_ssh"`
+	DefaultBranch string `json:"default_branch"`
+	SCM           string `json:"scm"`
+	Private       bool   `json:"private"`
+	Visibility    string `json:"visibility"`
+	Trusted       bool   `json:"trusted"`
+	Timeout       int64  `json:"timeout"`
+}
+
+type woodpeckerPipeline struct {
+	ID          int64                 `json:"id"`
+	Number      int64                 `json:"number"`
+	Parent      int64                 `json:"parent"`
+	Event       string                `json:"event"`
+	Status      string                `json:"status"`
+	Created     int64                 `json:"created"`
+	Updated     int64                 `json:"updated"`
+	Started     int64                 `json:"started"`
+	Finished    int64                 `json:"finished"`
+	Reviewer    string                `json:"reviewed_by"` // user who approved or declined the pipeline
+	Reviewed    int64                 `json:"reviewed"`
+	DeployTo    string                `json:"deploy_to"`
+	Commit      string                `json:"commit"`
+	Branch      string                `json:"branch"`
+	Ref         string                `json:"ref"`
+	Refspec     string                `json:"refspec"`
+	Title       string                `json:"title"`
+	Message     string                `json:"message"`
+	Sender      string                `json:"sender"`
+	Author      string                `json:"author"`
+	AuthorEmail string                `json:"author_email"`
+	Avatar      string                `json:"author_avatar"`
+	ForgeURL    string                `json:"forge_url"`
+	Workflows   []*woodpeckerWorkflow `json:"workflows"`
+}
+
+type woodpeckerWorkflow struct {
+	ID       int64             `json:"id"`
+	PID      int               `json:"pid"`
+	Name     string            `json:"name"`
+	State    string            `json:"state"`
+	Error    string            `json:"error"`
+	Started  int64             `json:"started"`
+	Finished int64             `json:"finished"`
+	AgentID  int64             `json:"agent_id"`
+	Platform string            `json:"platform"`
+	Children []*woodpeckerStep `json:"children"`
+}
+
+type woodpeckerStep struct {
+	ID       int64  `json:"id"`
+	PID      int    `json:"pid"`
+	PPID     int    `json:"ppid"`
+	Name     string `json:"name"`
+	State    string `json:"state"`
+	Error    string `json:"error"`
+	ExitCode int    `json:"exit_code"`
+	Started  int64  `json:"started"`
+	Finished int64  `json:"finished"`
+	Type     string `json:"type"`
+}
+
+// woodpeckerLogEntry is a line of a step log. Time is the offset in seconds
+// from the start of the step.
+type woodpeckerLogEntry struct {
+	ID     int64  `json:"id"`
+	StepID int64  `json:"step_id"`
+	Time   int64  `json:"time"`
+	Line   int    `json:"line"`
+	Data   []byte `json:"data"`
+	Type   int    `json:"type"`
+}
+
+// toWebhookEvent translates a Woodpecker event to a Drone event. server is
+// the address of the Woodpecker server, used to link to the pipeline.
+func (evt woodpeckerEvent) toWebhookEvent(server string) WebhookEvent {
+	repo := evt.Repo
+	pipeline := evt.Pipeline
+
+	droneRepo := drone.Repo{
+		ID:         repo.ID,
+		Namespace:  repo.Owner,
+		Name:       repo.Name,
+		Slug:       repo.FullName,
+		SCM:        repo.SCM,
+		HTTPURL:    repo.CloneURL,
+		SSHURL:     repo.CloneURLSSH,
+		Link:       repo.ForgeURL,
+		Branch:     pipeline.Branch,
+		Private:    repo.Private,
+		Visibility: repo.Visibility,
+		Trusted:    repo.Trusted,
+		Timeout:    repo.Timeout,
+	}
+
+	// Refspecs of pull requests are <source>:<target>
+	source, target := pipeline.Branch, pipeline.Branch
+	if s, t, ok := strings.Cut(pipeline.Refspec, ":"); ok {
+		source, target = s, t
+	}
+
+	build := &drone.Build{
+		ID:           pipeline.ID,
+		RepoID:       repo.ID,
+		Number:       pipeline.Number,
+		Parent:       pipeline.Parent,
+		Status:       pipeline.Status,
+		Event:        pipeline.Event,
+		Link:         fmt.Sprintf("%s/repos/%d/pipeline/%d", strings.TrimSuffix(server, "/"), repo.ID, pipeline.Number),
+		Title:        pipeline.Title,
+		Message:      pipeline.Message,
+		After:        pipeline.Commit,
+		Ref:          pipeline.Ref,
+		Source:       source,
+		Target:       target,
+		Author:       pipeline.Author,
+		AuthorEmail:  pipeline.AuthorEmail,
+		AuthorAvatar: pipeline.Avatar,
+		Sender:       pipeline.Sender,
+		Deploy:       pipeline.DeployTo,
+		Started:      pipeline.Started,
+		Finished:     pipeline.Finished,
+		Created:      pipeline.Created,
+		Updated:      pipeline.Updated,
+	}
+
+	for _, workflow := range pipeline.Workflows {
+		stage := &drone.Stage{
+			ID:      workflow.ID,
+			BuildID: pipeline.ID,
+			Number:  workflow.PID,
+			Name:    workflow.Name,
+			Status:  workflow.State,
+			Error:   workflow.Error,
+			Started: workflow.Started,
+			Stopped: workflow.Finished,
+			Created: pipeline.Created,
+			Updated: pipeline.Updated,
+		}
+		if workflow.AgentID != 0 {
+			stage.Machine = strconv.FormatInt(workflow.AgentID, 10)
+		}
+		stage.OS, stage.Arch, _ = strings.Cut(workflow.Platform, "/")
+
+		for _, child := range workflow.Children {
+			stage.Steps = append(stage.Steps, &drone.Step{
+				ID:       child.ID,
+				StageID:  workflow.ID,
+				Number:   child.PID,
+				Name:     child.Name,
+				Status:   child.State,
+				Error:    child.Error,
+				ExitCode: child.ExitCode,
+				Started:  child.Started,
+				Stopped:  child.Finished,
+			})
+		}
+
+		build.Stages = append(build.Stages, stage)
+	}
+
+	// Blocked pipelines wait for their approval as a whole
+	var approvals map[int]approval
+	if pipeline.Reviewed != 0 {
+		approvals = map[int]approval{0: {
+			since:    pipeline.Created,
+			until:    pipeline.Reviewed,
+			approver: pipeline.Reviewer,
+			declined: pipeline.Status == drone.StatusDeclined,
+		}}
+	}
+
+	return WebhookEvent{
+		Action: pipeline.Status,
+		Repo: &RepoEvt{
+			Repo:  droneRepo,
+			Build: build,
+		},
+		System: drone.System{
+			Host: server,
+		},
+		approvals: approvals,
+	}
+}
+
+// woodpeckerClient reads step logs from the Woodpecker API
+type woodpeckerClient struct {
+	apiClient
+}
+
+func newWoodpeckerClient(host string, client *http.Client) (*woodpeckerClient, error) {
+	api, err := newAPIClient(host, client)
+	if err != nil {
+		return nil, err
+	}
+	return &woodpeckerClient{apiClient: api}, nil
+}
+
+// StepLogs retrieves the log lines of a step. Woodpecker identifies steps by
+// their ID rather than by their number within the stage.
+func (c *woodpeckerClient) StepLogs(ctx context.Context, repo drone.Repo, build drone.Build, _ drone.Stage, step drone.Step) ([]*drone.Line, error) {
+	ref := fmt.Sprintf("api/repos/%d/logs/%d/%d", repo.ID, build.Number, step.ID)
+
+	var entries []woodpeckerLogEntry
+	if err := c.getJSON(ctx, ref, &entries); err != nil {
+		return nil, err
+	}
+
+	lines := make([]*drone.Line, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, &drone.Line{
+			Number:    entry.Line,
+			Message:   string(entry.Data),
+			Timestamp: entry.Time,
+		})
+	}
+	return lines, nil
+}
+
+var errContentDigestNotValid = errors.New("content digest is not valid")
+var errSignatureExpired = errors.New("signature is expired")
+
+// coveredComponents are the components Woodpecker signatures must cover, so
+// that signatures can't be replayed to another path or with another body.
+var coveredComponents = []string{"@request-target", "content-digest"}
+
+// verifyWoodpeckerSignature verifies the HTTP message signature (RFC 9421)
+// Woodpecker signs its requests with, using its ed25519 key. The signature
+// must cover the request target and the Content-Digest header, which is
+// checked against the body, and must have been created within tolerance of
+// now.
+func verifyWoodpeckerSignature(req *http.Request, body []byte, publicKey ed25519.PublicKey, tolerance time.Duration, now time.Time) error {
+	label, params, ok := strings.Cut(req.Header.Get("Signature-Input"), "=")
+	if !ok {
+		return fmt.Errorf("%w: missing Signature-Input header", errParsingSignature)
+	}
+
+	signature, err := parseSignature(req.Header.Get("Signature"), label)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errParsingSignature, err)
+	}
+
+	components, created, expires, err := parseSignatureInput(params)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errParsingSignature, err)
+	}
+	for _, component := range coveredComponents {
+		if !slices.Contains(components, component) {
+			return fmt.Errorf("%w: %s is not covered", errSignatureNotValid, component)
+		}
+	}
+
+	var base strings.Builder
+	for _, component := range components {
+		var value string
+		switch component {
+		case "@request-target":
+			value = req.URL.RequestURI()
+		case "@method":
+			value = req.Method
+		case "@path":
+			value = req.URL.EscapedPath()
+		case "@query":
+			value = "?" + req.URL.RawQuery
+		case "content-digest":
+			value = req.Header.Get("Content-Digest")
+			if err := verifyContentDigest(value, body); err != nil {
+				return err
+			}
+		default:
+			if strings.HasPrefix(component, "@") {
+				return fmt.Errorf("%w: unsupported component %s", errParsingSignature, component)
+			}
+			value = strings.Join(req.Header.Values(component), ", ")
+		}
+		fmt.Fprintf(&base, "%q: %s\n", component, strings.TrimSpace(value))
+	}
+	fmt.Fprintf(&base, "%q: %s", "@signature-params", params)
+
+	if !ed25519.Verify(publicKey, []byte(base.String()), signature) {
+		return errSignatureNotValid
+	}
+
+	// Signed requests are only accepted for a while, so that they cannot
+	// be replayed.
+	if age := now.Sub(time.Unix(created, 0)); age > tolerance || age < -tolerance {
+		return errSignatureExpired
+	}
+	if expires != 0 && now.After(time.Unix(expires, 0)) {
+		return errSignatureExpired
+	}
+	return nil
+}
+
+// parseSignature returns the signature with the given label of a Signature
+// header, e.g. label=:base64:
+func parseSignature(header, label string) ([]byte, error) {
+	for _, member := range strings.Split(header, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if !ok || name != label {
+			continue
+		}
+		value = strings.TrimSuffix(strings.TrimPrefix(value, ":"), ":")
+		return base64.StdEncoding.DecodeString(value)
+	}
+	return nil, fmt.Errorf("no signature labelled %s", label)
+}
+
+// parseSignatureInput returns the components covered by a signature, the
+// inner list of its parameters, and the Unix times it was created at and
+// expires at, e.g. ("@request-target" "content-digest");created=1709633101;alg="ed25519".
+// Signatures must tell when they were created, expires is 0 when not set.
+func parseSignatureInput(params string) ([]string, int64, int64, error) {
+	if !strings.HasPrefix(params, "(") {
+		return nil, 0, 0, fmt.Errorf("malformed signature parameters")
+	}
+	list, rest, ok := strings.Cut(params[1:], ")")
+	if !ok {
+		return nil, 0, 0, fmt.Errorf("malformed signature parameters")
+	}
+
+	var components []string
+	for _, item := range strings.Fields(list) {
+		component, err := strconv.Unquote(item)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("malformed component %s", item)
+		}
+		components = append(components, strings.ToLower(component))
+	}
+
+	var created, expires int64
+	for _, param := range strings.Split(rest, ";") {
+		name, value, _ := strings.Cut(param, "=")
+		var err error
+		switch name {
+		case "created":
+			created, err = strconv.ParseInt(value, 10, 64)
+		case "expires":
+			expires, err = strconv.ParseInt(value, 10, 64)
+		}
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("malformed %s parameter %s", name, value)
+		}
+	}
+	if created == 0 {
+		return nil, 0, 0, fmt.Errorf("missing created parameter")
+	}
+	return components, created, expires, nil
+}
+
+// verifyContentDigest checks the sha-256 or sha-512 digest of a
+// Content-Digest header (RFC 9530) against the body.
+func verifyContentDigest(header string, body []byte) error {
+	for _, member := range strings.Split(header, ",") {
+		alg, value, ok := strings.Cut(strings.TrimSpace(member), "=")
+		if !ok {
+			continue
+		}
+		digest, err := base64.StdEncoding.DecodeString(strings.Trim(value, ":"))
+		if err != nil {
+			return fmt.Errorf("%w: %w", errContentDigestNotValid, err)
+		}
+
+		var expected []byte
+		switch alg {
+		case "sha-256":
+			sum := sha256.Sum256(body)
+			expected = sum[:]
+		case "sha-512":
+			sum := sha512.Sum512(body)
+			expected = sum[:]
+		default:
+			continue
+		}
+
+		if !bytes.Equal(digest, expected) {
+			return errContentDigestNotValid
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: no sha-256 or sha-512 digest", errContentDigestNotValid)
+}
@@ -0,0 +1,317 @@
+package dronereceiver
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/drone/drone-go/drone"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap/zaptest"
+)
+
+func loadWoodpeckerEvent(t *testing.T) ([]byte, woodpeckerEvent) {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", "woodpecker_pipeline.json"))
+	require.NoError(t, err)
+
+	var evt woodpeckerEvent
+	require.NoError(t, json.Unmarshal(body, &evt))
+	return body, evt
+}
+
+// newWoodpeckerTestServer serves the logs of step 1803 of pipeline 58.
+func newWoodpeckerTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var repoID, number, stepID int64
+		if _, err := fmt.Sscanf(r.URL.Path, "/api/repos/%d/logs/%d/%d", &repoID, &number, &stepID); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		if repoID != 7 || number != 58 || stepID != 1803 {
+			_, _ = w.Write([]byte("[]"))
+			return
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", "woodpecker_logs_1803.json"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// signWoodpeckerRequest signs a request the way Woodpecker does.
+func signWoodpeckerRequest(t *testing.T, req *http.Request, body []byte, key ed25519.PrivateKey) {
+	t.Helper()
+	signWoodpeckerComponents(t, req, body, key, time.Now(), "@request-target", "content-digest")
+}
+
+// signWoodpeckerComponents signs the given components of a request, with a
+// signature created at the given time.
+func signWoodpeckerComponents(t *testing.T, req *http.Request, body []byte, key ed25519.PrivateKey, created time.Time, components ...string) {
+	t.Helper()
+
+	digest := sha256.Sum256(body)
+	req.Header.Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(digest[:])+":")
+
+	quoted := make([]string, 0, len(components))
+	var base strings.Builder
+	for _, component := range components {
+		var value string
+		switch component {
+		case "@request-target":
+			value = req.URL.RequestURI()
+		case "@method":
+			value = req.Method
+		case "content-digest":
+			value = req.Header.Get("Content-Digest")
+		}
+		quoted = append(quoted, strconv.Quote(component))
+		fmt.Fprintf(&base, "%q: %s\n", component, value)
+	}
+	params := fmt.Sprintf(`(%s);created=%d;keyid="woodpecker-ci-extensions";alg="ed25519"`, strings.Join(quoted, " "), created.Unix())
+	fmt.Fprintf(&base, "%q: %s", "@signature-params", params)
+	signature := ed25519.Sign(key, []byte(base.String()))
+
+	req.Header.Set("Signature-Input", "woodpecker-ci-extensions="+params)
+	req.Header.Set("Signature", "woodpecker-ci-extensions=:"+base64.StdEncoding.EncodeToString(signature)+":")
+}
+
+func newTestWoodpeckerKey(t *testing.T) (string, ed25519.PrivateKey) {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), privateKey
+}
+
+func TestWoodpeckerToWebhookEvent(t *testing.T) {
+	_, evt := loadWoodpeckerEvent(t)
+
+	webhook := evt.toWebhookEvent("https://ci.example.com/")
+	repo := webhook.Repo
+	build := repo.Build
+
+	assert.Equal(t, "acme/web-app", repo.Slug)
+	assert.Equal(t, "acme", repo.Namespace)
+	assert.Equal(t, "main", repo.Branch)
+	assert.Equal(t, "https://ci.example.com/repos/7/pipeline/58", build.Link)
+	assert.Equal(t, "feature/login", build.Source)
+	assert.Equal(t, "main", build.Target)
+	assert.Equal(t, drone.StatusFailing, build.Status)
+	assert.Equal(t, drone.EventPullRequest, build.Event)
+
+	require.Len(t, build.Stages, 2)
+	test := build.Stages[1]
+	assert.Equal(t, "test", test.Name)
+	assert.Equal(t, 4, test.Number)
+	assert.Equal(t, "5", test.Machine)
+	assert.Equal(t, "linux", test.OS)
+	assert.Equal(t, "arm64", test.Arch)
+	require.Len(t, test.Steps, 2)
+	assert.Equal(t, int64(1803), test.Steps[0].ID)
+	assert.Equal(t, int64(902), test.Steps[0].StageID)
+	assert.Equal(t, 1, test.Steps[0].ExitCode)
+	assert.Empty(t, webhook.approvals)
+
+	// Approved pipelines waited from their creation as a whole
+	evt.Pipeline.Reviewer, evt.Pipeline.Reviewed = "octocat", 1709632830
+	webhook = evt.toWebhookEvent("https://ci.example.com/")
+	assert.Equal(t, map[int]approval{0: {since: 1709632800, until: 1709632830, approver: "octocat"}}, webhook.approvals)
+}
+
+func TestHandleWoodpeckerEvent(t *testing.T) {
+	_, evt := loadWoodpeckerEvent(t)
+	server := newWoodpeckerTestServer(t)
+
+	config := createDefaultConfig().(*Config)
+	config.Flavor = flavorWoodpecker
+	config.ReposConfig = map[string][]string{
+		"acme/web-app": {"main"},
+	}
+
+	client, err := newWoodpeckerClient(server.URL, server.Client())
+	require.NoError(t, err)
+
+	traces, logs := handleEvent(context.Background(), evt.toWebhookEvent(server.URL), config, client, newTestLogPolicy(t, config.Logs), zaptest.NewLogger(t))
+	require.NotNil(t, traces)
+	require.NotNil(t, logs)
+
+	// 1 pipeline, 2 workflows and 3 steps, the skipped step has no span
+	require.Equal(t, 6, traces.SpanCount())
+	resourceSpans := traces.ResourceSpans().At(0)
+	assert.Equal(t, "woodpecker", resourceSpans.Resource().Attributes().AsRaw()["service.name"])
+	pipeline := resourceSpans.ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, semconv.AttributeCIVendorWoodpecker, pipeline.Attributes().AsRaw()[semconv.AttributeCIVendor])
+
+	require.Equal(t, 3, logs.LogRecordCount())
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	assert.Equal(t, "--- FAIL: TestLogin (0.01s)", records.At(1).Body().Str())
+	assert.Equal(t, int64(1709632957), records.At(1).Timestamp().AsTime().Unix())
+	assert.Equal(t, "test", records.At(1).Attributes().AsRaw()[semconv.AttributeDroneStepName])
+}
+
+func TestVerifyWoodpeckerSignature(t *testing.T) {
+	publicKeyPEM, privateKey := newTestWoodpeckerKey(t)
+	_, otherKey := newTestWoodpeckerKey(t)
+	publicKey, err := WoodpeckerConfig{PublicKey: publicKeyPEM}.publicKey()
+	require.NoError(t, err)
+
+	body := []byte(`{"repo": {}, "pipeline": {}}`)
+
+	tests := map[string]struct {
+		sign   func(req *http.Request)
+		body   []byte
+		expErr error
+	}{
+		"valid signature": {
+			sign: func(req *http.Request) { signWoodpeckerRequest(t, req, body, privateKey) },
+			body: body,
+		},
+		"missing signature": {
+			sign:   func(*http.Request) {},
+			body:   body,
+			expErr: errParsingSignature,
+		},
+		"other key": {
+			sign:   func(req *http.Request) { signWoodpeckerRequest(t, req, body, otherKey) },
+			body:   body,
+			expErr: errSignatureNotValid,
+		},
+		"tampered body": {
+			sign:   func(req *http.Request) { signWoodpeckerRequest(t, req, body, privateKey) },
+			body:   []byte(`{"repo": {}, "pipeline": {"status": "success"}}`),
+			expErr: errContentDigestNotValid,
+		},
+		"tampered target": {
+			sign: func(req *http.Request) {
+				signWoodpeckerRequest(t, req, body, privateKey)
+				req.URL.Path = "/other"
+			},
+			body:   body,
+			expErr: errSignatureNotValid,
+		},
+		"body not covered": {
+			sign: func(req *http.Request) {
+				signWoodpeckerComponents(t, req, body, privateKey, time.Now(), "@request-target")
+			},
+			body:   body,
+			expErr: errSignatureNotValid,
+		},
+		"target not covered": {
+			sign: func(req *http.Request) {
+				signWoodpeckerComponents(t, req, body, privateKey, time.Now(), "@method", "content-digest")
+			},
+			body:   body,
+			expErr: errSignatureNotValid,
+		},
+		"replayed signature": {
+			sign: func(req *http.Request) {
+				signWoodpeckerComponents(t, req, body, privateKey, time.Now().Add(-time.Hour), "@request-target", "content-digest")
+			},
+			body:   body,
+			expErr: errSignatureExpired,
+		},
+		"signature from the future": {
+			sign: func(req *http.Request) {
+				signWoodpeckerComponents(t, req, body, privateKey, time.Now().Add(time.Hour), "@request-target", "content-digest")
+			},
+			body:   body,
+			expErr: errSignatureExpired,
+		},
+		"missing creation time": {
+			sign: func(req *http.Request) {
+				signWoodpeckerRequest(t, req, body, privateKey)
+				input := req.Header.Get("Signature-Input")
+				req.Header.Set("Signature-Input", input[:strings.Index(input, ";created=")])
+			},
+			body:   body,
+			expErr: errParsingSignature,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/drone/webhook", bytes.NewReader(test.body))
+			test.sign(req)
+
+			err := verifyWoodpeckerSignature(req, test.body, publicKey, defaultSignatureTolerance, time.Now())
+			if test.expErr != nil {
+				require.ErrorIs(t, err, test.expErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestWoodpeckerServeHTTP(t *testing.T) {
+	publicKeyPEM, privateKey := newTestWoodpeckerKey(t)
+	body, _ := loadWoodpeckerEvent(t)
+	server := newWoodpeckerTestServer(t)
+
+	config := createDefaultConfig().(*Config)
+	config.Flavor = flavorWoodpecker
+	config.Woodpecker.PublicKey = publicKeyPEM
+	config.DroneConfig.Host = server.URL
+	config.DroneConfig.Token = "token"
+	config.ReposConfig = map[string][]string{
+		"acme/web-app": {"main"},
+	}
+	require.NoError(t, config.Validate())
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), config)
+	require.NoError(t, err)
+	tracesSink := new(consumertest.TracesSink)
+	rec.tracesConsumer = tracesSink
+	metricsSink := new(consumertest.MetricsSink)
+	rec.metricsConsumer = metricsSink
+
+	t.Run("rejects unsigned requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, config.Path, bytes.NewReader(body))
+		resp := httptest.NewRecorder()
+		rec.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusBadRequest, resp.Code)
+		assert.Empty(t, tracesSink.AllTraces())
+		assert.Empty(t, metricsSink.AllMetrics())
+	})
+
+	t.Run("handles signed requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, config.Path, bytes.NewReader(body))
+		signWoodpeckerRequest(t, req, body, privateKey)
+		resp := httptest.NewRecorder()
+		rec.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusOK, resp.Code)
+		// Builds are counted from webhooks, without the database
+		require.Len(t, metricsSink.AllMetrics(), 1)
+		require.Len(t, rec.events, 1)
+		rec.processEvent(context.Background(), <-rec.events)
+		require.Len(t, tracesSink.AllTraces(), 1)
+		assert.Equal(t, 6, tracesSink.AllTraces()[0].SpanCount())
+	})
+}
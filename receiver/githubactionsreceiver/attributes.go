@@ -70,4 +70,6 @@ func setWorkflowRunEventAttributes(attrs pcommon.Map, e *github.WorkflowRunEvent
 	}
 
 	attrs.PutStr("scm.git.repo", e.GetRepo().GetFullName())
+
+	removeLegacyAttributes(attrs, config.Semconv, legacyRunAttributes)
 }
@@ -146,11 +146,12 @@ func BenchmarkEventToLogs(b *testing.B) {
 				ghServer.Close()
 			})
 			logger := zap.NewNop()
+			policy := newTestLogPolicy(b, cfg.Logs.Config)
 
 			// Benchmark
 			b.ReportAllocs()
 			for b.Loop() {
-				_, _ = eventToLogs(event, cfg, ghClient, logger, true)
+				_, _ = eventToLogs(b.Context(), event, cfg, ghClient, policy, logger, true)
 			}
 		})
 	}
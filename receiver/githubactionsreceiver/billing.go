@@ -0,0 +1,243 @@
+package githubactionsreceiver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/githubactionsreceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+// GitHub's price per minute of standard Linux runners. Billable minutes of
+// other operating systems are multiples of Linux minutes.
+const defaultMinutePrice = 0.008
+
+// Sources the team owning a repository is read from
+const (
+	teamSourceCodeowners = "codeowners"
+	teamSourceTopics     = "topics"
+)
+
+const (
+	billingCacheSize = 100000
+	teamCacheSize    = 10000
+	teamCacheTTL     = time.Hour
+)
+
+// Locations GitHub looks up CODEOWNERS files at, in order
+var codeownersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// jobUsage is the estimated billing of a completed job
+type jobUsage struct {
+	minutes int64
+	cost    float64
+}
+
+// billingTotals holds the cumulative billing of a set of jobs since start
+type billingTotals struct {
+	start   pcommon.Timestamp
+	minutes int64
+	cost    float64
+}
+
+// estimateJobUsage estimates the billable minutes and cost of a completed job.
+// GitHub rounds every job up to the next whole minute. Jobs running on a
+// runner label with a configured price are billed at that price, other
+// self-hosted jobs and standard GitHub-hosted jobs of public repositories are
+// free, and other GitHub-hosted jobs are billed in Linux minutes using the OS
+// multipliers.
+func estimateJobUsage(job *github.WorkflowJob, repo *github.Repository, cfg BillingConfig) (jobUsage, bool) {
+	if job.GetStatus() != "completed" || job.GetStartedAt().IsZero() || job.GetCompletedAt().IsZero() {
+		return jobUsage{}, false
+	}
+
+	duration := job.GetCompletedAt().Sub(job.GetStartedAt().Time)
+	if duration <= 0 {
+		return jobUsage{}, true
+	}
+	minutes := int64(math.Ceil(duration.Minutes()))
+
+	for _, label := range job.Labels {
+		if price, ok := cfg.Prices[label]; ok {
+			return jobUsage{minutes: minutes, cost: float64(minutes) * price}, true
+		}
+	}
+
+	if slices.Contains(job.Labels, "self-hosted") {
+		return jobUsage{}, true
+	}
+	if isPublic(repo) {
+		return jobUsage{}, true
+	}
+
+	billable := minutes * runnerMultiplier(job.Labels)
+	return jobUsage{minutes: billable, cost: float64(billable) * defaultMinutePrice}, true
+}
+
+// isPublic reports whether a repository is public. Payloads without a
+// visibility only tell whether the repository is private.
+func isPublic(repo *github.Repository) bool {
+	if visibility := repo.GetVisibility(); visibility != "" {
+		return visibility == "public"
+	}
+	return !repo.GetPrivate()
+}
+
+// runnerMultiplier returns the minute multiplier of the OS of a GitHub-hosted runner.
+func runnerMultiplier(labels []string) int64 {
+	for _, label := range labels {
+		switch {
+		case strings.HasPrefix(label, "windows"):
+			return 2
+		case strings.HasPrefix(label, "macos"):
+			return 10
+		}
+	}
+	return 1
+}
+
+// setJobBillingAttributes adds the billing of a job to its span.
+func setJobBillingAttributes(traces ptrace.Traces, job *github.WorkflowJob, usage jobUsage, team string) {
+	jobSpanID, err := generateJobSpanID(job.GetRunID(), int(job.GetRunAttempt()), job.GetName())
+	if err != nil {
+		return
+	}
+
+	span, ok := findSpan(traces, jobSpanID)
+	if !ok {
+		return
+	}
+
+	span.Attributes().PutInt("ci.github.workflow.job.billable_minutes", usage.minutes)
+	span.Attributes().PutDouble("ci.github.workflow.job.cost", usage.cost)
+	if team != "" {
+		span.Attributes().PutStr("ci.github.team", team)
+	}
+}
+
+// jobBillingMetrics adds the billing of a job to the cumulative billable
+// minutes and cost of its repository, runner labels and team.
+func (m *metricsHandler) jobBillingMetrics(event *github.WorkflowJobEvent, usage jobUsage, team string) pmetric.Metrics {
+	repo := event.GetRepo().GetFullName()
+	labels := sortedLabels(event.GetWorkflowJob().Labels)
+	key := fmt.Sprintf("billing:%s:%s:%s", repo, labels, team)
+	now := pcommon.NewTimestampFromTime(time.Now())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Evicted series start over from now, so that backends see a reset
+	totals, ok := m.billingCache.Get(key)
+	if !ok {
+		totals.start = now
+	}
+	totals.minutes += usage.minutes
+	totals.cost += usage.cost
+	m.billingCache.Add(key, totals)
+
+	m.mb.RecordWorkflowJobsBillableMinutesDataPoint(now, totals.minutes, repo, labels, team)
+	m.mb.RecordWorkflowJobsCostDataPoint(now, totals.cost, repo, labels, team)
+	return m.mb.Emit(metadata.WithStartTimeOverride(totals.start))
+}
+
+// cachedTeam is the team owning a repository as of a CODEOWNERS lookup
+type cachedTeam struct {
+	team      string
+	fetchedAt time.Time
+}
+
+// teamResolver attributes repositories to the team owning them
+type teamResolver struct {
+	cfg      BillingConfig
+	ghClient *github.Client
+	logger   *zap.Logger
+	teams    *lru.Cache[string, cachedTeam]
+}
+
+func newTeamResolver(cfg BillingConfig, ghClient *github.Client, logger *zap.Logger) (*teamResolver, error) {
+	teams, err := lru.New[string, cachedTeam](teamCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &teamResolver{
+		cfg:      cfg,
+		ghClient: ghClient,
+		logger:   logger,
+		teams:    teams,
+	}, nil
+}
+
+// resolve returns the team owning the repository, or an empty string if it is unknown.
+func (r *teamResolver) resolve(ctx context.Context, repo *github.Repository) string {
+	switch r.cfg.TeamSource {
+	case teamSourceTopics:
+		for _, topic := range repo.Topics {
+			if team, ok := strings.CutPrefix(topic, r.cfg.TopicPrefix); ok && team != "" {
+				return team
+			}
+		}
+		return ""
+	case teamSourceCodeowners:
+		if cached, ok := r.teams.Get(repo.GetFullName()); ok && time.Since(cached.fetchedAt) < teamCacheTTL {
+			return cached.team
+		}
+		team := r.codeownersTeam(ctx, repo)
+		r.teams.Add(repo.GetFullName(), cachedTeam{team: team, fetchedAt: time.Now()})
+		return team
+	default:
+		return ""
+	}
+}
+
+// codeownersTeam returns the default owner of the repository, as set by the
+// last `*` rule of its CODEOWNERS file.
+func (r *teamResolver) codeownersTeam(ctx context.Context, repo *github.Repository) string {
+	opts := &github.RepositoryContentGetOptions{Ref: repo.GetDefaultBranch()}
+	for _, path := range codeownersPaths {
+		file, _, _, err := r.ghClient.Repositories.GetContents(ctx, repo.GetOwner().GetLogin(), repo.GetName(), path, opts)
+		if err != nil || file == nil {
+			continue
+		}
+
+		content, err := file.GetContent()
+		if err != nil {
+			r.logger.Warn("Failed to decode CODEOWNERS", zap.String("repo", repo.GetFullName()), zap.String("path", path), zap.Error(err))
+			return ""
+		}
+		return defaultCodeowner(content)
+	}
+
+	r.logger.Debug("No CODEOWNERS found", zap.String("repo", repo.GetFullName()))
+	return ""
+}
+
+// defaultCodeowner returns the first owner of the last `*` rule of a
+// CODEOWNERS file, without the leading @ and organization.
+func defaultCodeowner(content string) string {
+	var owner string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || (fields[0] != "*" && fields[0] != "/*") {
+			continue
+		}
+		owner = fields[1]
+	}
+
+	owner = strings.TrimPrefix(owner, "@")
+	if _, team, ok := strings.Cut(owner, "/"); ok {
+		return team
+	}
+	return owner
+}
@@ -0,0 +1,244 @@
+package githubactionsreceiver
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+func TestEstimateJobUsage(t *testing.T) {
+	started := time.Date(2023, 10, 13, 10, 0, 0, 0, time.UTC)
+	prices := map[string]float64{
+		"ubuntu-latest-16-cores": 0.064,
+		"gpu":                    0.5,
+	}
+
+	private := &github.Repository{Private: github.Ptr(true), Visibility: github.Ptr("private")}
+
+	tests := map[string]struct {
+		status     string
+		duration   time.Duration
+		labels     []string
+		repo       *github.Repository
+		expected   jobUsage
+		expectedOk bool
+	}{
+		"not completed": {
+			status:   "in_progress",
+			duration: time.Minute,
+			labels:   []string{"ubuntu-latest"},
+		},
+		"linux rounds up": {
+			status:     "completed",
+			duration:   61 * time.Second,
+			labels:     []string{"ubuntu-latest"},
+			expected:   jobUsage{minutes: 2, cost: 0.016},
+			expectedOk: true,
+		},
+		"windows multiplier": {
+			status:     "completed",
+			duration:   30 * time.Second,
+			labels:     []string{"windows-2022"},
+			expected:   jobUsage{minutes: 2, cost: 0.016},
+			expectedOk: true,
+		},
+		"macos multiplier": {
+			status:     "completed",
+			duration:   3 * time.Minute,
+			labels:     []string{"macos-14"},
+			expected:   jobUsage{minutes: 30, cost: 0.24},
+			expectedOk: true,
+		},
+		"larger runner": {
+			status:     "completed",
+			duration:   90 * time.Second,
+			labels:     []string{"ubuntu-latest-16-cores"},
+			expected:   jobUsage{minutes: 2, cost: 0.128},
+			expectedOk: true,
+		},
+		"self-hosted with rate": {
+			status:     "completed",
+			duration:   4 * time.Minute,
+			labels:     []string{"self-hosted", "linux", "gpu"},
+			expected:   jobUsage{minutes: 4, cost: 2},
+			expectedOk: true,
+		},
+		"self-hosted without rate": {
+			status:     "completed",
+			duration:   4 * time.Minute,
+			labels:     []string{"self-hosted", "linux"},
+			expectedOk: true,
+		},
+		"public repository": {
+			status:     "completed",
+			duration:   3 * time.Minute,
+			labels:     []string{"macos-14"},
+			repo:       &github.Repository{Private: github.Ptr(false), Visibility: github.Ptr("public")},
+			expectedOk: true,
+		},
+		"public repository without visibility": {
+			status:     "completed",
+			duration:   3 * time.Minute,
+			labels:     []string{"ubuntu-latest"},
+			repo:       &github.Repository{Private: github.Ptr(false)},
+			expectedOk: true,
+		},
+		"public repository larger runner": {
+			status:     "completed",
+			duration:   90 * time.Second,
+			labels:     []string{"ubuntu-latest-16-cores"},
+			repo:       &github.Repository{Private: github.Ptr(false), Visibility: github.Ptr("public")},
+			expected:   jobUsage{minutes: 2, cost: 0.128},
+			expectedOk: true,
+		},
+		"internal repository": {
+			status:     "completed",
+			duration:   61 * time.Second,
+			labels:     []string{"ubuntu-latest"},
+			repo:       &github.Repository{Private: github.Ptr(true), Visibility: github.Ptr("internal")},
+			expected:   jobUsage{minutes: 2, cost: 0.016},
+			expectedOk: true,
+		},
+		"skipped": {
+			status:     "completed",
+			labels:     []string{"ubuntu-latest"},
+			expectedOk: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			job := &github.WorkflowJob{
+				Status:      github.Ptr(test.status),
+				Labels:      test.labels,
+				StartedAt:   &github.Timestamp{Time: started},
+				CompletedAt: &github.Timestamp{Time: started.Add(test.duration)},
+			}
+
+			repo := test.repo
+			if repo == nil {
+				repo = private
+			}
+
+			usage, ok := estimateJobUsage(job, repo, BillingConfig{Prices: prices})
+			require.Equal(t, test.expectedOk, ok)
+			require.Equal(t, test.expected.minutes, usage.minutes)
+			require.InDelta(t, test.expected.cost, usage.cost, 1e-9)
+		})
+	}
+}
+
+func TestDefaultCodeowner(t *testing.T) {
+	tests := map[string]struct {
+		content  string
+		expected string
+	}{
+		"empty": {},
+		"team": {
+			content:  "# Default owners\n* @grafana/platform @grafana/ci\n/docs/ @grafana/docs\n",
+			expected: "platform",
+		},
+		"last rule wins": {
+			content:  "* @grafana/platform\n/*  @grafana/ci\n",
+			expected: "ci",
+		},
+		"user": {
+			content:  "*.go @gopher\n* @octocat\n",
+			expected: "octocat",
+		},
+		"no default rule": {
+			content: "/docs/ @grafana/docs\n",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, defaultCodeowner(test.content))
+		})
+	}
+}
+
+func TestTeamResolver(t *testing.T) {
+	requests := 0
+	ghTestServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/foo/webhook-testing/contents/CODEOWNERS" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		content := base64.StdEncoding.EncodeToString([]byte("* @foo/platform\n"))
+		_, _ = w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "` + content + `"}`))
+	}))
+	t.Cleanup(ghTestServer.Close)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.GitHubAPIConfig.BaseURL = ghTestServer.URL
+	cfg.GitHubAPIConfig.UploadURL = ghTestServer.URL
+
+	repo := &github.Repository{
+		Name:          github.Ptr("webhook-testing"),
+		FullName:      github.Ptr("foo/webhook-testing"),
+		Owner:         &github.User{Login: github.Ptr("foo")},
+		DefaultBranch: github.Ptr("main"),
+		Topics:        []string{"golang", "team-ci"},
+	}
+
+	t.Run("topics", func(t *testing.T) {
+		resolver, err := newTeamResolver(BillingConfig{TeamSource: teamSourceTopics, TopicPrefix: "team-"}, setupTestGitHubClient(cfg), zap.NewNop())
+		require.NoError(t, err)
+		require.Equal(t, "ci", resolver.resolve(t.Context(), repo))
+	})
+
+	t.Run("codeowners", func(t *testing.T) {
+		resolver, err := newTeamResolver(BillingConfig{TeamSource: teamSourceCodeowners}, setupTestGitHubClient(cfg), zap.NewNop())
+		require.NoError(t, err)
+		require.Equal(t, "platform", resolver.resolve(t.Context(), repo))
+		require.Equal(t, "platform", resolver.resolve(t.Context(), repo))
+		require.Equal(t, 1, requests)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		resolver, err := newTeamResolver(BillingConfig{}, setupTestGitHubClient(cfg), zap.NewNop())
+		require.NoError(t, err)
+		require.Empty(t, resolver.resolve(t.Context(), repo))
+	})
+}
+
+func TestJobBillingMetrics(t *testing.T) {
+	e := loadTestJobEvent(t)
+	mh := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), createDefaultConfig().(*Config), zap.NewNop())
+
+	first := mh.jobBillingMetrics(e, jobUsage{minutes: 2, cost: 0.016}, "platform")
+	start := first.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0).StartTimestamp()
+	metrics := mh.jobBillingMetrics(e, jobUsage{minutes: 3, cost: 0.024}, "platform")
+
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 2, ms.Len())
+	for i := range ms.Len() {
+		dp := ms.At(i).Sum().DataPoints().At(0)
+		require.Equal(t, "platform", dp.Attributes().AsRaw()["ci.github.team"])
+		require.Equal(t, start, dp.StartTimestamp())
+		switch ms.At(i).Name() {
+		case "workflow.jobs.billable_minutes":
+			require.Equal(t, int64(5), dp.IntValue())
+		case "workflow.jobs.cost":
+			require.InDelta(t, 0.04, dp.DoubleValue(), 1e-9)
+		default:
+			t.Fatalf("unexpected metric %s", ms.At(i).Name())
+		}
+	}
+	// Evicted series start over with a new start time
+	time.Sleep(time.Millisecond)
+	mh.billingCache.Purge()
+	dp := mh.jobBillingMetrics(e, jobUsage{minutes: 1, cost: 0.008}, "platform").ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, int64(1), dp.IntValue())
+	require.Greater(t, dp.StartTimestamp(), start)
+}
@@ -5,7 +5,11 @@ package githubactionsreceiver // import "github.com/grafana/grafana-ci-otel-coll
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
 	"github.com/grafana/grafana-ci-otel-collector/receiver/githubactionsreceiver/internal/metadata"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/confighttp"
@@ -18,6 +22,17 @@ var errMissingAppID = errors.New("missing app_id")
 var errMissingInstallationID = errors.New("missing installation_id")
 var errMissingPrivateKeyPath = errors.New("missing private_key_path")
 var errBaseURLAndUploadURL = errors.New("both base_url and upload_url must be set if one is set")
+var errCacheInterval = errors.New("storage cache_interval must be positive when caches are enabled")
+var errNegativePrice = errors.New("billing prices must not be negative")
+var errTeamSource = fmt.Errorf("billing team_source must be empty, %q or %q", teamSourceCodeowners, teamSourceTopics)
+var errMultiline = fmt.Errorf("logs multiline must be one of %q, %q or %q", multilineJoin, multilineSplit, multilineJoinUntilBlank)
+
+// Strategies for log lines that do not start with a timestamp
+const (
+	multilineJoin           = "join"             // append to the preceding entry
+	multilineSplit          = "split"            // emit as separate entries sharing the preceding timestamp
+	multilineJoinUntilBlank = "join_until_blank" // append to the preceding entry until a blank line
+)
 
 // GitHubAPIAuthConfig defines authentication configuration for GitHub API
 type GitHubAPIAuthConfig struct {
@@ -34,6 +49,65 @@ type GitHubAPIConfig struct {
 	UploadURL string              `mapstructure:"upload_url"` // github enterprise upload url. Default is empty
 }
 
+// LogsConfig defines how workflow logs are retrieved from the GitHub API
+type LogsConfig struct {
+	logpolicy.Config `mapstructure:",squash"` // sampling, truncation and redaction policies applied to the logs. failed_only applies to jobs
+	FetchJobLogs     bool                     `mapstructure:"fetch_job_logs"` // fetch each job's logs when its workflow_job event completes instead of fetching all logs on workflow_run completion. Default is false
+	Multiline        string                   `mapstructure:"multiline"`      // how lines without a timestamp are handled: join, split or join_until_blank. Default is join
+}
+
+// Validate checks the log policies and the multi-line strategy
+func (cfg *LogsConfig) Validate() error {
+	var errs error
+
+	if err := cfg.Config.Validate(); err != nil {
+		errs = multierr.Append(errs, err)
+	}
+
+	switch cfg.Multiline {
+	case "", multilineJoin, multilineSplit, multilineJoinUntilBlank:
+	default:
+		errs = multierr.Append(errs, errMultiline)
+	}
+
+	return errs
+}
+
+// StorageConfig defines how artifact and cache storage usage is collected
+type StorageConfig struct {
+	Artifacts     bool          `mapstructure:"artifacts"`      // list the artifacts of completed runs. Default is false
+	Caches        bool          `mapstructure:"caches"`         // periodically list the caches of the repositories seen in webhooks. Default is false
+	CacheInterval time.Duration `mapstructure:"cache_interval"` // how often repository caches are listed. Default is 1h
+}
+
+// BillingConfig defines how billable minutes and costs of jobs are estimated
+type BillingConfig struct {
+	Enabled     bool               `mapstructure:"enabled"`      // estimate the billable minutes and cost of completed jobs. Default is false
+	Prices      map[string]float64 `mapstructure:"prices"`       // price per minute by runner label, for larger and self-hosted runners. Default is empty
+	TeamSource  string             `mapstructure:"team_source"`  // where the team owning a repository is read from: codeowners or topics. Default is empty
+	TopicPrefix string             `mapstructure:"topic_prefix"` // prefix of the repository topic naming the owning team. Default is team-
+}
+
+// Validate checks the prices and the team source
+func (cfg *BillingConfig) Validate() error {
+	var errs error
+
+	for _, price := range cfg.Prices {
+		if price < 0 {
+			errs = multierr.Append(errs, errNegativePrice)
+			break
+		}
+	}
+
+	switch cfg.TeamSource {
+	case "", teamSourceCodeowners, teamSourceTopics:
+	default:
+		errs = multierr.Append(errs, errTeamSource)
+	}
+
+	return errs
+}
+
 // Config defines configuration for GitHub Actions receiver
 type Config struct {
 	metadata.MetricsBuilderConfig `mapstructure:",squash"`
@@ -44,6 +118,10 @@ type Config struct {
 	ServiceNamePrefix             string                   `mapstructure:"service_name_prefix"` // service name prefix. Default is empty
 	ServiceNameSuffix             string                   `mapstructure:"service_name_suffix"` // service name suffix. Default is empty
 	GitHubAPIConfig               GitHubAPIConfig          `mapstructure:"gh_api"`              // github api configuration
+	Logs                          LogsConfig               `mapstructure:"logs"`                // logs retrieval configuration
+	Storage                       StorageConfig            `mapstructure:"storage"`             // artifact and cache usage collection
+	Billing                       BillingConfig            `mapstructure:"billing"`             // billable minutes and cost estimation
+	Semconv                       semconv.Config           `mapstructure:"semconv"`             // OpenTelemetry CICD and VCS semantic conventions
 }
 
 var _ component.Config = (*Config)(nil)
@@ -74,5 +152,17 @@ func (cfg *Config) Validate() error {
 		errs = multierr.Append(errs, errBaseURLAndUploadURL)
 	}
 
+	if err := cfg.Logs.Validate(); err != nil {
+		errs = multierr.Append(errs, err)
+	}
+
+	if cfg.Storage.Caches && cfg.Storage.CacheInterval <= 0 {
+		errs = multierr.Append(errs, errCacheInterval)
+	}
+
+	if err := cfg.Billing.Validate(); err != nil {
+		errs = multierr.Append(errs, err)
+	}
+
 	return errs
 }
@@ -6,6 +6,7 @@ package githubactionsreceiver
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana-ci-otel-collector/receiver/githubactionsreceiver/internal/metadata"
 	"github.com/stretchr/testify/require"
@@ -154,6 +155,58 @@ func TestValidateConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:   "Caches without interval",
+			expect: errCacheInterval,
+			conf: Config{
+				ServerConfig: confighttp.ServerConfig{
+					NetAddr: confignet.AddrConfig{
+						Transport: confignet.TransportTypeTCP,
+						Endpoint:  "localhost:8080",
+					},
+				},
+				Storage: StorageConfig{Caches: true},
+			},
+		},
+		{
+			desc:   "Negative billing price",
+			expect: errNegativePrice,
+			conf: Config{
+				ServerConfig: confighttp.ServerConfig{
+					NetAddr: confignet.AddrConfig{
+						Transport: confignet.TransportTypeTCP,
+						Endpoint:  "localhost:8080",
+					},
+				},
+				Billing: BillingConfig{Prices: map[string]float64{"gpu": -1}},
+			},
+		},
+		{
+			desc:   "Unknown team source",
+			expect: errTeamSource,
+			conf: Config{
+				ServerConfig: confighttp.ServerConfig{
+					NetAddr: confignet.AddrConfig{
+						Transport: confignet.TransportTypeTCP,
+						Endpoint:  "localhost:8080",
+					},
+				},
+				Billing: BillingConfig{TeamSource: "ldap"},
+			},
+		},
+		{
+			desc:   "Unknown multi-line strategy",
+			expect: errMultiline,
+			conf: Config{
+				ServerConfig: confighttp.ServerConfig{
+					NetAddr: confignet.AddrConfig{
+						Transport: confignet.TransportTypeTCP,
+						Endpoint:  "localhost:8080",
+					},
+				},
+				Logs: LogsConfig{Multiline: "concat"},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -189,6 +242,12 @@ func TestLoadConfig(t *testing.T) {
 		},
 		Path:   "/ghaevents",
 		Secret: "mysecret",
+		Storage: StorageConfig{
+			CacheInterval: time.Hour,
+		},
+		Billing: BillingConfig{
+			TopicPrefix: "team-",
+		},
 	}
 
 	// create expected config
@@ -4,6 +4,8 @@
 package githubactionsreceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/githubactionsreceiver"
 
 import (
+	"time"
+
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/config/confighttp"
 	"go.opentelemetry.io/collector/config/confignet"
@@ -16,8 +18,10 @@ import (
 // This file implements factory for GitHub Actions receiver.
 
 const (
-	defaultBindEndpoint = "0.0.0.0:19418"
-	defaultPath         = "/ghaevents"
+	defaultBindEndpoint  = "0.0.0.0:19418"
+	defaultPath          = "/ghaevents"
+	defaultCacheInterval = time.Hour
+	defaultTopicPrefix   = "team-"
 )
 
 // NewFactory creates a new GitHub Actions receiver factory
@@ -43,6 +47,12 @@ func createDefaultConfig() component.Config {
 		},
 		Path:   defaultPath,
 		Secret: "",
+		Storage: StorageConfig{
+			CacheInterval: defaultCacheInterval,
+		},
+		Billing: BillingConfig{
+			TopicPrefix: defaultTopicPrefix,
+		},
 	}
 }
 
@@ -28,9 +28,15 @@ func (ms *MetricConfig) Unmarshal(parser *confmap.Conf) error {
 
 // MetricsConfig provides config for githubactions metrics.
 type MetricsConfig struct {
-	BuildInfo         MetricConfig `mapstructure:"build.info"`
-	WorkflowJobsCount MetricConfig `mapstructure:"workflow.jobs.count"`
-	WorkflowRunsCount MetricConfig `mapstructure:"workflow.runs.count"`
+	BuildInfo                   MetricConfig `mapstructure:"build.info"`
+	RepositoryCachesCount       MetricConfig `mapstructure:"repository.caches.count"`
+	RepositoryCachesSize        MetricConfig `mapstructure:"repository.caches.size"`
+	WorkflowArtifactsCount      MetricConfig `mapstructure:"workflow.artifacts.count"`
+	WorkflowArtifactsSize       MetricConfig `mapstructure:"workflow.artifacts.size"`
+	WorkflowJobsBillableMinutes MetricConfig `mapstructure:"workflow.jobs.billable_minutes"`
+	WorkflowJobsCost            MetricConfig `mapstructure:"workflow.jobs.cost"`
+	WorkflowJobsCount           MetricConfig `mapstructure:"workflow.jobs.count"`
+	WorkflowRunsCount           MetricConfig `mapstructure:"workflow.runs.count"`
 }
 
 func DefaultMetricsConfig() MetricsConfig {
@@ -38,6 +44,24 @@ func DefaultMetricsConfig() MetricsConfig {
 		BuildInfo: MetricConfig{
 			Enabled: true,
 		},
+		RepositoryCachesCount: MetricConfig{
+			Enabled: true,
+		},
+		RepositoryCachesSize: MetricConfig{
+			Enabled: true,
+		},
+		WorkflowArtifactsCount: MetricConfig{
+			Enabled: true,
+		},
+		WorkflowArtifactsSize: MetricConfig{
+			Enabled: true,
+		},
+		WorkflowJobsBillableMinutes: MetricConfig{
+			Enabled: true,
+		},
+		WorkflowJobsCost: MetricConfig{
+			Enabled: true,
+		},
 		WorkflowJobsCount: MetricConfig{
 			Enabled: true,
 		},
@@ -30,6 +30,24 @@ func TestMetricsBuilderConfig(t *testing.T) {
 					BuildInfo: MetricConfig{
 						Enabled: true,
 					},
+					RepositoryCachesCount: MetricConfig{
+						Enabled: true,
+					},
+					RepositoryCachesSize: MetricConfig{
+						Enabled: true,
+					},
+					WorkflowArtifactsCount: MetricConfig{
+						Enabled: true,
+					},
+					WorkflowArtifactsSize: MetricConfig{
+						Enabled: true,
+					},
+					WorkflowJobsBillableMinutes: MetricConfig{
+						Enabled: true,
+					},
+					WorkflowJobsCost: MetricConfig{
+						Enabled: true,
+					},
 					WorkflowJobsCount: MetricConfig{
 						Enabled: true,
 					},
@@ -46,6 +64,24 @@ func TestMetricsBuilderConfig(t *testing.T) {
 					BuildInfo: MetricConfig{
 						Enabled: false,
 					},
+					RepositoryCachesCount: MetricConfig{
+						Enabled: false,
+					},
+					RepositoryCachesSize: MetricConfig{
+						Enabled: false,
+					},
+					WorkflowArtifactsCount: MetricConfig{
+						Enabled: false,
+					},
+					WorkflowArtifactsSize: MetricConfig{
+						Enabled: false,
+					},
+					WorkflowJobsBillableMinutes: MetricConfig{
+						Enabled: false,
+					},
+					WorkflowJobsCost: MetricConfig{
+						Enabled: false,
+					},
 					WorkflowJobsCount: MetricConfig{
 						Enabled: false,
 					},
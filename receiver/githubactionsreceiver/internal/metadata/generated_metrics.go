@@ -191,6 +191,24 @@ var MetricsInfo = metricsInfo{
 	BuildInfo: metricInfo{
 		Name: "build.info",
 	},
+	RepositoryCachesCount: metricInfo{
+		Name: "repository.caches.count",
+	},
+	RepositoryCachesSize: metricInfo{
+		Name: "repository.caches.size",
+	},
+	WorkflowArtifactsCount: metricInfo{
+		Name: "workflow.artifacts.count",
+	},
+	WorkflowArtifactsSize: metricInfo{
+		Name: "workflow.artifacts.size",
+	},
+	WorkflowJobsBillableMinutes: metricInfo{
+		Name: "workflow.jobs.billable_minutes",
+	},
+	WorkflowJobsCost: metricInfo{
+		Name: "workflow.jobs.cost",
+	},
 	WorkflowJobsCount: metricInfo{
 		Name: "workflow.jobs.count",
 	},
@@ -200,9 +218,15 @@ var MetricsInfo = metricsInfo{
 }
 
 type metricsInfo struct {
-	BuildInfo         metricInfo
-	WorkflowJobsCount metricInfo
-	WorkflowRunsCount metricInfo
+	BuildInfo                   metricInfo
+	RepositoryCachesCount       metricInfo
+	RepositoryCachesSize        metricInfo
+	WorkflowArtifactsCount      metricInfo
+	WorkflowArtifactsSize       metricInfo
+	WorkflowJobsBillableMinutes metricInfo
+	WorkflowJobsCost            metricInfo
+	WorkflowJobsCount           metricInfo
+	WorkflowRunsCount           metricInfo
 }
 
 type metricInfo struct {
@@ -261,6 +285,328 @@ func newMetricBuildInfo(cfg MetricConfig) metricBuildInfo {
 	return m
 }
 
+type metricRepositoryCachesCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills repository.caches.count metric with initial data.
+func (m *metricRepositoryCachesCount) init() {
+	m.data.SetName("repository.caches.count")
+	m.data.SetDescription("Number of active Actions caches of a repository.")
+	m.data.SetUnit("{cache}")
+	m.data.SetEmptyGauge()
+	m.data.Gauge().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricRepositoryCachesCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Gauge().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("vcs.repository.name", vcsRepositoryNameAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricRepositoryCachesCount) updateCapacity() {
+	if m.data.Gauge().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Gauge().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricRepositoryCachesCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Gauge().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricRepositoryCachesCount(cfg MetricConfig) metricRepositoryCachesCount {
+	m := metricRepositoryCachesCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricRepositoryCachesSize struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills repository.caches.size metric with initial data.
+func (m *metricRepositoryCachesSize) init() {
+	m.data.SetName("repository.caches.size")
+	m.data.SetDescription("Total size of the active Actions caches of a repository.")
+	m.data.SetUnit("By")
+	m.data.SetEmptyGauge()
+	m.data.Gauge().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricRepositoryCachesSize) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Gauge().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("vcs.repository.name", vcsRepositoryNameAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricRepositoryCachesSize) updateCapacity() {
+	if m.data.Gauge().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Gauge().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricRepositoryCachesSize) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Gauge().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricRepositoryCachesSize(cfg MetricConfig) metricRepositoryCachesSize {
+	m := metricRepositoryCachesSize{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricWorkflowArtifactsCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills workflow.artifacts.count metric with initial data.
+func (m *metricWorkflowArtifactsCount) init() {
+	m.data.SetName("workflow.artifacts.count")
+	m.data.SetDescription("Number of unexpired artifacts uploaded by the completed runs of a workflow seen by the receiver.")
+	m.data.SetUnit("{artifact}")
+	m.data.SetEmptyGauge()
+	m.data.Gauge().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricWorkflowArtifactsCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGithubWorkflowNameAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Gauge().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("vcs.repository.name", vcsRepositoryNameAttributeValue)
+	dp.Attributes().PutStr("ci.github.workflow.name", ciGithubWorkflowNameAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricWorkflowArtifactsCount) updateCapacity() {
+	if m.data.Gauge().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Gauge().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricWorkflowArtifactsCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Gauge().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricWorkflowArtifactsCount(cfg MetricConfig) metricWorkflowArtifactsCount {
+	m := metricWorkflowArtifactsCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricWorkflowArtifactsSize struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills workflow.artifacts.size metric with initial data.
+func (m *metricWorkflowArtifactsSize) init() {
+	m.data.SetName("workflow.artifacts.size")
+	m.data.SetDescription("Total size of the unexpired artifacts uploaded by the completed runs of a workflow seen by the receiver.")
+	m.data.SetUnit("By")
+	m.data.SetEmptyGauge()
+	m.data.Gauge().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricWorkflowArtifactsSize) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGithubWorkflowNameAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Gauge().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("vcs.repository.name", vcsRepositoryNameAttributeValue)
+	dp.Attributes().PutStr("ci.github.workflow.name", ciGithubWorkflowNameAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricWorkflowArtifactsSize) updateCapacity() {
+	if m.data.Gauge().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Gauge().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricWorkflowArtifactsSize) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Gauge().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricWorkflowArtifactsSize(cfg MetricConfig) metricWorkflowArtifactsSize {
+	m := metricWorkflowArtifactsSize{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricWorkflowJobsBillableMinutes struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills workflow.jobs.billable_minutes metric with initial data.
+func (m *metricWorkflowJobsBillableMinutes) init() {
+	m.data.SetName("workflow.jobs.billable_minutes")
+	m.data.SetDescription("Estimated billable minutes of completed jobs, rounded up per job and multiplied by the runner OS multiplier.")
+	m.data.SetUnit("min")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricWorkflowJobsBillableMinutes) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGithubWorkflowJobLabelsAttributeValue string, ciGithubTeamAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("vcs.repository.name", vcsRepositoryNameAttributeValue)
+	dp.Attributes().PutStr("ci.github.workflow.job.labels", ciGithubWorkflowJobLabelsAttributeValue)
+	dp.Attributes().PutStr("ci.github.team", ciGithubTeamAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricWorkflowJobsBillableMinutes) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricWorkflowJobsBillableMinutes) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricWorkflowJobsBillableMinutes(cfg MetricConfig) metricWorkflowJobsBillableMinutes {
+	m := metricWorkflowJobsBillableMinutes{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricWorkflowJobsCost struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills workflow.jobs.cost metric with initial data.
+func (m *metricWorkflowJobsCost) init() {
+	m.data.SetName("workflow.jobs.cost")
+	m.data.SetDescription("Estimated cost of completed jobs in US dollars.")
+	m.data.SetUnit("{USD}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricWorkflowJobsCost) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val float64, vcsRepositoryNameAttributeValue string, ciGithubWorkflowJobLabelsAttributeValue string, ciGithubTeamAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetDoubleValue(val)
+	dp.Attributes().PutStr("vcs.repository.name", vcsRepositoryNameAttributeValue)
+	dp.Attributes().PutStr("ci.github.workflow.job.labels", ciGithubWorkflowJobLabelsAttributeValue)
+	dp.Attributes().PutStr("ci.github.team", ciGithubTeamAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricWorkflowJobsCost) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricWorkflowJobsCost) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricWorkflowJobsCost(cfg MetricConfig) metricWorkflowJobsCost {
+	m := metricWorkflowJobsCost{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
 type metricWorkflowJobsCount struct {
 	data     pmetric.Metric // data buffer for generated metric.
 	config   MetricConfig   // metric config provided by user.
@@ -380,14 +726,20 @@ func newMetricWorkflowRunsCount(cfg MetricConfig) metricWorkflowRunsCount {
 // MetricsBuilder provides an interface for scrapers to report metrics while taking care of all the transformations
 // required to produce metric representation defined in metadata and user config.
 type MetricsBuilder struct {
-	config                  MetricsBuilderConfig // config of the metrics builder.
-	startTime               pcommon.Timestamp    // start time that will be applied to all recorded data points.
-	metricsCapacity         int                  // maximum observed number of metrics per resource.
-	metricsBuffer           pmetric.Metrics      // accumulates metrics data before emitting.
-	buildInfo               component.BuildInfo  // contains version information.
-	metricBuildInfo         metricBuildInfo
-	metricWorkflowJobsCount metricWorkflowJobsCount
-	metricWorkflowRunsCount metricWorkflowRunsCount
+	config                            MetricsBuilderConfig // config of the metrics builder.
+	startTime                         pcommon.Timestamp    // start time that will be applied to all recorded data points.
+	metricsCapacity                   int                  // maximum observed number of metrics per resource.
+	metricsBuffer                     pmetric.Metrics      // accumulates metrics data before emitting.
+	buildInfo                         component.BuildInfo  // contains version information.
+	metricBuildInfo                   metricBuildInfo
+	metricRepositoryCachesCount       metricRepositoryCachesCount
+	metricRepositoryCachesSize        metricRepositoryCachesSize
+	metricWorkflowArtifactsCount      metricWorkflowArtifactsCount
+	metricWorkflowArtifactsSize       metricWorkflowArtifactsSize
+	metricWorkflowJobsBillableMinutes metricWorkflowJobsBillableMinutes
+	metricWorkflowJobsCost            metricWorkflowJobsCost
+	metricWorkflowJobsCount           metricWorkflowJobsCount
+	metricWorkflowRunsCount           metricWorkflowRunsCount
 }
 
 // MetricBuilderOption applies changes to default metrics builder.
@@ -409,13 +761,19 @@ func WithStartTime(startTime pcommon.Timestamp) MetricBuilderOption {
 }
 func NewMetricsBuilder(mbc MetricsBuilderConfig, settings receiver.Settings, options ...MetricBuilderOption) *MetricsBuilder {
 	mb := &MetricsBuilder{
-		config:                  mbc,
-		startTime:               pcommon.NewTimestampFromTime(time.Now()),
-		metricsBuffer:           pmetric.NewMetrics(),
-		buildInfo:               settings.BuildInfo,
-		metricBuildInfo:         newMetricBuildInfo(mbc.Metrics.BuildInfo),
-		metricWorkflowJobsCount: newMetricWorkflowJobsCount(mbc.Metrics.WorkflowJobsCount),
-		metricWorkflowRunsCount: newMetricWorkflowRunsCount(mbc.Metrics.WorkflowRunsCount),
+		config:                            mbc,
+		startTime:                         pcommon.NewTimestampFromTime(time.Now()),
+		metricsBuffer:                     pmetric.NewMetrics(),
+		buildInfo:                         settings.BuildInfo,
+		metricBuildInfo:                   newMetricBuildInfo(mbc.Metrics.BuildInfo),
+		metricRepositoryCachesCount:       newMetricRepositoryCachesCount(mbc.Metrics.RepositoryCachesCount),
+		metricRepositoryCachesSize:        newMetricRepositoryCachesSize(mbc.Metrics.RepositoryCachesSize),
+		metricWorkflowArtifactsCount:      newMetricWorkflowArtifactsCount(mbc.Metrics.WorkflowArtifactsCount),
+		metricWorkflowArtifactsSize:       newMetricWorkflowArtifactsSize(mbc.Metrics.WorkflowArtifactsSize),
+		metricWorkflowJobsBillableMinutes: newMetricWorkflowJobsBillableMinutes(mbc.Metrics.WorkflowJobsBillableMinutes),
+		metricWorkflowJobsCost:            newMetricWorkflowJobsCost(mbc.Metrics.WorkflowJobsCost),
+		metricWorkflowJobsCount:           newMetricWorkflowJobsCount(mbc.Metrics.WorkflowJobsCount),
+		metricWorkflowRunsCount:           newMetricWorkflowRunsCount(mbc.Metrics.WorkflowRunsCount),
 	}
 
 	for _, op := range options {
@@ -482,6 +840,12 @@ func (mb *MetricsBuilder) EmitForResource(options ...ResourceMetricsOption) {
 	ils.Scope().SetVersion(mb.buildInfo.Version)
 	ils.Metrics().EnsureCapacity(mb.metricsCapacity)
 	mb.metricBuildInfo.emit(ils.Metrics())
+	mb.metricRepositoryCachesCount.emit(ils.Metrics())
+	mb.metricRepositoryCachesSize.emit(ils.Metrics())
+	mb.metricWorkflowArtifactsCount.emit(ils.Metrics())
+	mb.metricWorkflowArtifactsSize.emit(ils.Metrics())
+	mb.metricWorkflowJobsBillableMinutes.emit(ils.Metrics())
+	mb.metricWorkflowJobsCost.emit(ils.Metrics())
 	mb.metricWorkflowJobsCount.emit(ils.Metrics())
 	mb.metricWorkflowRunsCount.emit(ils.Metrics())
 
@@ -510,6 +874,36 @@ func (mb *MetricsBuilder) RecordBuildInfoDataPoint(ts pcommon.Timestamp, val int
 	mb.metricBuildInfo.recordDataPoint(mb.startTime, ts, val, versionAttributeValue)
 }
 
+// RecordRepositoryCachesCountDataPoint adds a data point to repository.caches.count metric.
+func (mb *MetricsBuilder) RecordRepositoryCachesCountDataPoint(ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string) {
+	mb.metricRepositoryCachesCount.recordDataPoint(mb.startTime, ts, val, vcsRepositoryNameAttributeValue)
+}
+
+// RecordRepositoryCachesSizeDataPoint adds a data point to repository.caches.size metric.
+func (mb *MetricsBuilder) RecordRepositoryCachesSizeDataPoint(ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string) {
+	mb.metricRepositoryCachesSize.recordDataPoint(mb.startTime, ts, val, vcsRepositoryNameAttributeValue)
+}
+
+// RecordWorkflowArtifactsCountDataPoint adds a data point to workflow.artifacts.count metric.
+func (mb *MetricsBuilder) RecordWorkflowArtifactsCountDataPoint(ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGithubWorkflowNameAttributeValue string) {
+	mb.metricWorkflowArtifactsCount.recordDataPoint(mb.startTime, ts, val, vcsRepositoryNameAttributeValue, ciGithubWorkflowNameAttributeValue)
+}
+
+// RecordWorkflowArtifactsSizeDataPoint adds a data point to workflow.artifacts.size metric.
+func (mb *MetricsBuilder) RecordWorkflowArtifactsSizeDataPoint(ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGithubWorkflowNameAttributeValue string) {
+	mb.metricWorkflowArtifactsSize.recordDataPoint(mb.startTime, ts, val, vcsRepositoryNameAttributeValue, ciGithubWorkflowNameAttributeValue)
+}
+
+// RecordWorkflowJobsBillableMinutesDataPoint adds a data point to workflow.jobs.billable_minutes metric.
+func (mb *MetricsBuilder) RecordWorkflowJobsBillableMinutesDataPoint(ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGithubWorkflowJobLabelsAttributeValue string, ciGithubTeamAttributeValue string) {
+	mb.metricWorkflowJobsBillableMinutes.recordDataPoint(mb.startTime, ts, val, vcsRepositoryNameAttributeValue, ciGithubWorkflowJobLabelsAttributeValue, ciGithubTeamAttributeValue)
+}
+
+// RecordWorkflowJobsCostDataPoint adds a data point to workflow.jobs.cost metric.
+func (mb *MetricsBuilder) RecordWorkflowJobsCostDataPoint(ts pcommon.Timestamp, val float64, vcsRepositoryNameAttributeValue string, ciGithubWorkflowJobLabelsAttributeValue string, ciGithubTeamAttributeValue string) {
+	mb.metricWorkflowJobsCost.recordDataPoint(mb.startTime, ts, val, vcsRepositoryNameAttributeValue, ciGithubWorkflowJobLabelsAttributeValue, ciGithubTeamAttributeValue)
+}
+
 // RecordWorkflowJobsCountDataPoint adds a data point to workflow.jobs.count metric.
 func (mb *MetricsBuilder) RecordWorkflowJobsCountDataPoint(ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGithubWorkflowJobLabelsAttributeValue string, ciGithubWorkflowJobStatusAttributeValue AttributeCiGithubWorkflowJobStatus, ciGithubWorkflowJobConclusionAttributeValue AttributeCiGithubWorkflowJobConclusion, ciGithubWorkflowJobHeadBranchIsMainAttributeValue bool) {
 	mb.metricWorkflowJobsCount.recordDataPoint(mb.startTime, ts, val, vcsRepositoryNameAttributeValue, ciGithubWorkflowJobLabelsAttributeValue, ciGithubWorkflowJobStatusAttributeValue.String(), ciGithubWorkflowJobConclusionAttributeValue.String(), ciGithubWorkflowJobHeadBranchIsMainAttributeValue)
@@ -62,6 +62,30 @@ func TestMetricsBuilder(t *testing.T) {
 			allMetricsCount++
 			mb.RecordBuildInfoDataPoint(ts, 1, "version-val")
 
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordRepositoryCachesCountDataPoint(ts, 1, "vcs.repository.name-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordRepositoryCachesSizeDataPoint(ts, 1, "vcs.repository.name-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordWorkflowArtifactsCountDataPoint(ts, 1, "vcs.repository.name-val", "ci.github.workflow.name-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordWorkflowArtifactsSizeDataPoint(ts, 1, "vcs.repository.name-val", "ci.github.workflow.name-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordWorkflowJobsBillableMinutesDataPoint(ts, 1, "vcs.repository.name-val", "ci.github.workflow.job.labels-val", "ci.github.team-val")
+
+			defaultMetricsCount++
+			allMetricsCount++
+			mb.RecordWorkflowJobsCostDataPoint(ts, 1, "vcs.repository.name-val", "ci.github.workflow.job.labels-val", "ci.github.team-val")
+
 			defaultMetricsCount++
 			allMetricsCount++
 			mb.RecordWorkflowJobsCountDataPoint(ts, 1, "vcs.repository.name-val", "ci.github.workflow.job.labels-val", AttributeCiGithubWorkflowJobStatusCompleted, AttributeCiGithubWorkflowJobConclusionSuccess, true)
@@ -113,6 +137,118 @@ func TestMetricsBuilder(t *testing.T) {
 					versionAttrVal, ok := dp.Attributes().Get("version")
 					assert.True(t, ok)
 					assert.Equal(t, "version-val", versionAttrVal.Str())
+				case "repository.caches.count":
+					assert.False(t, validatedMetrics["repository.caches.count"], "Found a duplicate in the metrics slice: repository.caches.count")
+					validatedMetrics["repository.caches.count"] = true
+					assert.Equal(t, pmetric.MetricTypeGauge, mi.Type())
+					assert.Equal(t, 1, mi.Gauge().DataPoints().Len())
+					assert.Equal(t, "Number of active Actions caches of a repository.", mi.Description())
+					assert.Equal(t, "{cache}", mi.Unit())
+					dp := mi.Gauge().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					vcsRepositoryNameAttrVal, ok := dp.Attributes().Get("vcs.repository.name")
+					assert.True(t, ok)
+					assert.Equal(t, "vcs.repository.name-val", vcsRepositoryNameAttrVal.Str())
+				case "repository.caches.size":
+					assert.False(t, validatedMetrics["repository.caches.size"], "Found a duplicate in the metrics slice: repository.caches.size")
+					validatedMetrics["repository.caches.size"] = true
+					assert.Equal(t, pmetric.MetricTypeGauge, mi.Type())
+					assert.Equal(t, 1, mi.Gauge().DataPoints().Len())
+					assert.Equal(t, "Total size of the active Actions caches of a repository.", mi.Description())
+					assert.Equal(t, "By", mi.Unit())
+					dp := mi.Gauge().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					vcsRepositoryNameAttrVal, ok := dp.Attributes().Get("vcs.repository.name")
+					assert.True(t, ok)
+					assert.Equal(t, "vcs.repository.name-val", vcsRepositoryNameAttrVal.Str())
+				case "workflow.artifacts.count":
+					assert.False(t, validatedMetrics["workflow.artifacts.count"], "Found a duplicate in the metrics slice: workflow.artifacts.count")
+					validatedMetrics["workflow.artifacts.count"] = true
+					assert.Equal(t, pmetric.MetricTypeGauge, mi.Type())
+					assert.Equal(t, 1, mi.Gauge().DataPoints().Len())
+					assert.Equal(t, "Number of unexpired artifacts uploaded by the completed runs of a workflow seen by the receiver.", mi.Description())
+					assert.Equal(t, "{artifact}", mi.Unit())
+					dp := mi.Gauge().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					vcsRepositoryNameAttrVal, ok := dp.Attributes().Get("vcs.repository.name")
+					assert.True(t, ok)
+					assert.Equal(t, "vcs.repository.name-val", vcsRepositoryNameAttrVal.Str())
+					ciGithubWorkflowNameAttrVal, ok := dp.Attributes().Get("ci.github.workflow.name")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.github.workflow.name-val", ciGithubWorkflowNameAttrVal.Str())
+				case "workflow.artifacts.size":
+					assert.False(t, validatedMetrics["workflow.artifacts.size"], "Found a duplicate in the metrics slice: workflow.artifacts.size")
+					validatedMetrics["workflow.artifacts.size"] = true
+					assert.Equal(t, pmetric.MetricTypeGauge, mi.Type())
+					assert.Equal(t, 1, mi.Gauge().DataPoints().Len())
+					assert.Equal(t, "Total size of the unexpired artifacts uploaded by the completed runs of a workflow seen by the receiver.", mi.Description())
+					assert.Equal(t, "By", mi.Unit())
+					dp := mi.Gauge().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					vcsRepositoryNameAttrVal, ok := dp.Attributes().Get("vcs.repository.name")
+					assert.True(t, ok)
+					assert.Equal(t, "vcs.repository.name-val", vcsRepositoryNameAttrVal.Str())
+					ciGithubWorkflowNameAttrVal, ok := dp.Attributes().Get("ci.github.workflow.name")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.github.workflow.name-val", ciGithubWorkflowNameAttrVal.Str())
+				case "workflow.jobs.billable_minutes":
+					assert.False(t, validatedMetrics["workflow.jobs.billable_minutes"], "Found a duplicate in the metrics slice: workflow.jobs.billable_minutes")
+					validatedMetrics["workflow.jobs.billable_minutes"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Estimated billable minutes of completed jobs, rounded up per job and multiplied by the runner OS multiplier.", mi.Description())
+					assert.Equal(t, "min", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeInt, dp.ValueType())
+					assert.Equal(t, int64(1), dp.IntValue())
+					vcsRepositoryNameAttrVal, ok := dp.Attributes().Get("vcs.repository.name")
+					assert.True(t, ok)
+					assert.Equal(t, "vcs.repository.name-val", vcsRepositoryNameAttrVal.Str())
+					ciGithubWorkflowJobLabelsAttrVal, ok := dp.Attributes().Get("ci.github.workflow.job.labels")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.github.workflow.job.labels-val", ciGithubWorkflowJobLabelsAttrVal.Str())
+					ciGithubTeamAttrVal, ok := dp.Attributes().Get("ci.github.team")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.github.team-val", ciGithubTeamAttrVal.Str())
+				case "workflow.jobs.cost":
+					assert.False(t, validatedMetrics["workflow.jobs.cost"], "Found a duplicate in the metrics slice: workflow.jobs.cost")
+					validatedMetrics["workflow.jobs.cost"] = true
+					assert.Equal(t, pmetric.MetricTypeSum, mi.Type())
+					assert.Equal(t, 1, mi.Sum().DataPoints().Len())
+					assert.Equal(t, "Estimated cost of completed jobs in US dollars.", mi.Description())
+					assert.Equal(t, "{USD}", mi.Unit())
+					assert.True(t, mi.Sum().IsMonotonic())
+					assert.Equal(t, pmetric.AggregationTemporalityCumulative, mi.Sum().AggregationTemporality())
+					dp := mi.Sum().DataPoints().At(0)
+					assert.Equal(t, start, dp.StartTimestamp())
+					assert.Equal(t, ts, dp.Timestamp())
+					assert.Equal(t, pmetric.NumberDataPointValueTypeDouble, dp.ValueType())
+					assert.InDelta(t, float64(1), dp.DoubleValue(), 0.01)
+					vcsRepositoryNameAttrVal, ok := dp.Attributes().Get("vcs.repository.name")
+					assert.True(t, ok)
+					assert.Equal(t, "vcs.repository.name-val", vcsRepositoryNameAttrVal.Str())
+					ciGithubWorkflowJobLabelsAttrVal, ok := dp.Attributes().Get("ci.github.workflow.job.labels")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.github.workflow.job.labels-val", ciGithubWorkflowJobLabelsAttrVal.Str())
+					ciGithubTeamAttrVal, ok := dp.Attributes().Get("ci.github.team")
+					assert.True(t, ok)
+					assert.Equal(t, "ci.github.team-val", ciGithubTeamAttrVal.Str())
 				case "workflow.jobs.count":
 					assert.False(t, validatedMetrics["workflow.jobs.count"], "Found a duplicate in the metrics slice: workflow.jobs.count")
 					validatedMetrics["workflow.jobs.count"] = true
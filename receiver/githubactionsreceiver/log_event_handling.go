@@ -7,6 +7,7 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"fmt"
 	"io"
@@ -19,36 +20,85 @@ import (
 	"time"
 
 	"github.com/google/go-github/v88/github"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.uber.org/zap"
 )
 
 const (
-	// Logs larger than this will be truncated
-	maxLogEntryBytes = 1 * 1024 * 1024 // 1 MB
+	// Lines and joined entries never grow beyond this, unless the maximum
+	// entry size is larger
+	maxLogBufferBytes = 16 * 1024 * 1024 // 16 MB
+	// logDownloadTimeout bounds the download of the logs of a run or job
+	logDownloadTimeout = 10 * time.Minute
 )
 
+// logsHTTPClient downloads logs from the URLs GitHub redirects to, which
+// must not be sent the credentials of the GitHub client
+var logsHTTPClient = &http.Client{Timeout: logDownloadTimeout}
+
 type logEntryBuilder struct {
+	multiline         string
 	currentBody       strings.Builder
 	currentParsedTime time.Time
 	currentStepNumber int64
+	currentSpanID     pcommon.SpanID
 	hasCurrentEntry   bool
+	// currentLines counts the lines of the entry kept by the log policy
+	currentLines int
 }
 
+// reset clears the entry being built. Its timestamp and step are kept so that
+// following lines without a timestamp can start a new entry with them.
 func (b *logEntryBuilder) reset() {
 	b.currentBody.Reset()
+	b.hasCurrentEntry = false
+	b.currentLines = 0
+}
+
+// clear resets the builder and forgets the timestamp and step of the last entry.
+func (b *logEntryBuilder) clear() {
+	b.reset()
 	b.currentParsedTime = time.Time{}
 	b.currentStepNumber = 0
-	b.hasCurrentEntry = false
+	b.currentSpanID = pcommon.SpanID{}
 }
 
-func eventToLogs(event interface{}, config *Config, ghClient *github.Client, logger *zap.Logger, withTraceInfo bool) (*plog.Logs, error) {
-	e, ok := event.(*github.WorkflowRunEvent)
-	if !ok {
+// continueEntry starts a new entry with the timestamp and step of the last
+// one, reporting whether there was such an entry.
+func (b *logEntryBuilder) continueEntry() bool {
+	if b.currentParsedTime.IsZero() {
+		return false
+	}
+	b.hasCurrentEntry = true
+	return true
+}
+
+// stepLocator resolves the step number and step span ID a log entry
+// starting at the given timestamp belongs to.
+type stepLocator func(ts time.Time) (int64, pcommon.SpanID)
+
+func eventToLogs(ctx context.Context, event interface{}, config *Config, ghClient *github.Client, policy *logpolicy.Policy, logger *zap.Logger, withTraceInfo bool) (*plog.Logs, error) {
+	switch e := event.(type) {
+	case *github.WorkflowRunEvent:
+		if config.Logs.FetchJobLogs {
+			logger.Debug("Job logs are fetched on workflow_job completion, skipping run logs")
+			return nil, nil
+		}
+		return workflowRunEventToLogs(ctx, e, config, ghClient, policy, logger, withTraceInfo)
+	case *github.WorkflowJobEvent:
+		if !config.Logs.FetchJobLogs {
+			return nil, nil
+		}
+		return workflowJobEventToLogs(ctx, e, config, ghClient, policy, logger, withTraceInfo)
+	default:
 		return nil, nil
 	}
+}
 
+func workflowRunEventToLogs(ctx context.Context, e *github.WorkflowRunEvent, config *Config, ghClient *github.Client, policy *logpolicy.Policy, logger *zap.Logger, withTraceInfo bool) (*plog.Logs, error) {
 	log := enrichLogger(logger, e)
 	log.Debug("Processing WorkflowRunEvent for logs",
 		zap.String("status", e.GetWorkflowRun().GetStatus()),
@@ -61,7 +111,17 @@ func eventToLogs(event interface{}, config *Config, ghClient *github.Client, log
 		return nil, nil
 	}
 
-	zipReader, cleanup, err := getWorkflowRunLogsZip(context.Background(), ghClient, e, log)
+	if !policy.KeepLogs(pipelineResult(e.GetWorkflowRun().GetConclusion()).Failed()) {
+		log.Debug("Run did not fail, skipping logs")
+		return nil, nil
+	}
+
+	var jobsByName map[string]*github.WorkflowJob
+	if policy.NeedsOutcome() {
+		jobsByName = listWorkflowRunJobs(ctx, ghClient, e, log)
+	}
+
+	zipReader, cleanup, err := getWorkflowRunLogsZip(ctx, ghClient, e, log)
 	if err != nil {
 		return nil, err
 	}
@@ -74,13 +134,21 @@ func eventToLogs(event interface{}, config *Config, ghClient *github.Client, log
 	traceID, _ := generateTraceID(e.GetWorkflowRun().GetID(), e.GetWorkflowRun().GetRunAttempt())
 	jobs, filesByJob := extractJobsAndFilesFromZip(zipReader, log)
 
+	// Reuse a single logEntryBuilder for all files
+	builder := logEntryBuilder{multiline: config.Logs.Multiline}
+
 	log.Debug("Extracted jobs and files from zip", zap.Int("job_count", len(jobs)))
 	log.Debug("Job names", zap.Any("job_names", jobs))
 
 	for i, jobName := range jobs {
 		log.Debug("Processing job", zap.Int("job_index", i+1), zap.String("job_name", jobName))
+		job := jobsByName[jobName]
+		if job != nil && !policy.KeepLogs(pipelineResult(job.GetConclusion()).Failed()) {
+			log.Debug("Job did not fail, skipping logs", zap.String("job_name", jobName))
+			continue
+		}
 		jobFiles := filesByJob[jobName]
-		processJobLogs(jobName, jobFiles, resourceLogs, traceID, e, withTraceInfo, log)
+		processJobLogs(jobName, job, jobFiles, resourceLogs, traceID, e, policy, withTraceInfo, log, &builder)
 		log.Debug("Completed job", zap.Int("job_index", i+1), zap.String("job_name", jobName))
 	}
 
@@ -106,6 +174,28 @@ func enrichLogger(logger *zap.Logger, e *github.WorkflowRunEvent) *zap.Logger {
 	)
 }
 
+// listWorkflowRunJobs returns the jobs of a run attempt keyed by name. Jobs
+// whose outcome cannot be determined are missing from the map, and their logs
+// are exported regardless of the log policy outcome rules.
+func listWorkflowRunJobs(ctx context.Context, ghClient *github.Client, e *github.WorkflowRunEvent, logger *zap.Logger) map[string]*github.WorkflowJob {
+	jobs := make(map[string]*github.WorkflowJob)
+	for job, err := range ghClient.Actions.ListWorkflowJobsAttemptIter(
+		ctx,
+		e.GetRepo().GetOwner().GetLogin(),
+		e.GetRepo().GetName(),
+		e.GetWorkflowRun().GetID(),
+		int64(e.GetWorkflowRun().GetRunAttempt()),
+		&github.ListOptions{PerPage: 100},
+	) {
+		if err != nil {
+			logger.Warn("Failed to list jobs, log policies depending on job outcome will not be applied", zap.Error(err))
+			return nil
+		}
+		jobs[job.GetName()] = job
+	}
+	return jobs
+}
+
 func getWorkflowRunLogsZip(ctx context.Context, ghClient *github.Client, e *github.WorkflowRunEvent, logger *zap.Logger) (*zip.Reader, func(), error) {
 	url, _, err := ghClient.Actions.GetWorkflowRunAttemptLogs(
 		ctx,
@@ -120,7 +210,7 @@ func getWorkflowRunLogsZip(ctx context.Context, ghClient *github.Client, e *gith
 		return nil, nil, err
 	}
 
-	tmpFile, err := downloadLogsToTempFile(url.String(), logger)
+	tmpFile, err := downloadLogsToTempFile(ctx, url.String(), logger)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -146,14 +236,19 @@ func getWorkflowRunLogsZip(ctx context.Context, ghClient *github.Client, e *gith
 	return &zipReader.Reader, cleanup, nil
 }
 
-func downloadLogsToTempFile(url string, logger *zap.Logger) (*os.File, error) {
+func downloadLogsToTempFile(ctx context.Context, url string, logger *zap.Logger) (*os.File, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	out, err := os.CreateTemp("", "gh-logs-")
 	if err != nil {
 		logger.Error("Failed to create temp file", zap.Error(err))
 		return nil, err
 	}
 
-	resp, err := http.Get(url)
+	resp, err := logsHTTPClient.Do(req)
 	if err != nil {
 		if closeErr := out.Close(); closeErr != nil {
 			logger.Warn("Failed to close temp file after HTTP error", zap.Error(closeErr))
@@ -178,6 +273,151 @@ func downloadLogsToTempFile(url string, logger *zap.Logger) (*os.File, error) {
 	return out, nil
 }
 
+func workflowJobEventToLogs(ctx context.Context, e *github.WorkflowJobEvent, config *Config, ghClient *github.Client, policy *logpolicy.Policy, logger *zap.Logger, withTraceInfo bool) (*plog.Logs, error) {
+	job := e.GetWorkflowJob()
+	log := logger.With(
+		zap.Int64("workflow_run_id", job.GetRunID()),
+		zap.Int64("workflow_run_attempt", job.GetRunAttempt()),
+		zap.Int64("workflow_job_id", job.GetID()),
+		zap.String("workflow_job_name", job.GetName()),
+		zap.String("repo", e.GetRepo().GetFullName()),
+	)
+	log.Debug("Processing WorkflowJobEvent for logs",
+		zap.String("status", job.GetStatus()),
+		zap.String("conclusion", job.GetConclusion()))
+
+	if job.GetStatus() != "completed" {
+		log.Debug("Job not completed, skipping")
+		return nil, nil
+	}
+
+	if !policy.KeepLogs(pipelineResult(job.GetConclusion()).Failed()) {
+		log.Debug("Job did not fail, skipping logs")
+		return nil, nil
+	}
+
+	body, err := getWorkflowJobLogs(ctx, ghClient, e, log)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := body.Close(); err != nil {
+			log.Warn("Failed to close response body", zap.Error(err))
+		}
+	}()
+
+	logs := plog.NewLogs()
+	resourceLogs := logs.ResourceLogs().AppendEmpty()
+	createResourceAttributes(resourceLogs.Resource(), e, config, log)
+	pipeline := workflowJobPipeline(e)
+	cimodel.SetResourceAttributes(resourceLogs.Resource().Attributes(), &pipeline, cimodel.Options{Semconv: config.Semconv})
+
+	traceID, _ := generateTraceID(job.GetRunID(), int(job.GetRunAttempt()))
+
+	jobLogsScope := resourceLogs.ScopeLogs().AppendEmpty()
+	jobLogsScope.Scope().Attributes().PutStr("ci.github.workflow.job.name", job.GetName())
+
+	builder := logEntryBuilder{multiline: config.Logs.Multiline}
+	processLogEntries(body, jobLogsScope, traceID, newJobStepLocator(job, log), policy, withTraceInfo, log, &builder)
+	trimSuccessfulSteps(jobLogsScope.LogRecords(), job, policy)
+
+	log.Debug("Completed job log processing", zap.Int("log_records", jobLogsScope.LogRecords().Len()))
+	return &logs, nil
+}
+
+func getWorkflowJobLogs(ctx context.Context, ghClient *github.Client, e *github.WorkflowJobEvent, logger *zap.Logger) (io.ReadCloser, error) {
+	url, _, err := ghClient.Actions.GetWorkflowJobLogs(
+		ctx,
+		e.GetRepo().GetOwner().GetLogin(),
+		e.GetRepo().GetName(),
+		e.GetWorkflowJob().GetID(),
+		10,
+	)
+	if err != nil {
+		logger.Error("Failed to get job logs", zap.Error(err))
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := logsHTTPClient.Do(req)
+	if err != nil {
+		logger.Error("Failed to download job logs", zap.Error(err))
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if err := resp.Body.Close(); err != nil {
+			logger.Warn("Failed to close response body", zap.Error(err))
+		}
+		return nil, fmt.Errorf("failed to download job logs: unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.Body, nil
+}
+
+// newJobStepLocator maps log entries of a single job log to its steps using
+// the step start and completion times from the webhook payload. Step times
+// only have second precision, so when several steps overlap the one that
+// started last wins.
+func newJobStepLocator(job *github.WorkflowJob, logger *zap.Logger) stepLocator {
+	steps := slices.SortedFunc(slices.Values(job.Steps), func(a, b *github.TaskStep) int {
+		return cmp.Compare(a.GetNumber(), b.GetNumber())
+	})
+
+	spanIDs := make(map[int64]pcommon.SpanID, len(steps))
+	for _, step := range steps {
+		spanID, err := generateStepSpanID(job.GetRunID(), int(job.GetRunAttempt()), job.GetName(), step.GetNumber())
+		if err != nil {
+			logger.Error("Failed to generate span ID", zap.Int64("step_number", step.GetNumber()), zap.Error(err))
+			continue
+		}
+		spanIDs[step.GetNumber()] = spanID
+	}
+
+	return func(ts time.Time) (int64, pcommon.SpanID) {
+		number := findStepNumber(steps, ts)
+		return number, spanIDs[number]
+	}
+}
+
+func findStepNumber(steps []*github.TaskStep, ts time.Time) int64 {
+	if len(steps) == 0 {
+		return 0
+	}
+
+	ts = ts.Truncate(time.Second)
+
+	var match, started *github.TaskStep
+	for _, step := range steps {
+		startedAt := step.GetStartedAt().Time
+		if startedAt.IsZero() || startedAt.After(ts) {
+			continue
+		}
+		if started == nil || !startedAt.Before(started.GetStartedAt().Time) {
+			started = step
+		}
+		completedAt := step.GetCompletedAt().Time
+		if completedAt.IsZero() || !completedAt.Before(ts) {
+			if match == nil || !startedAt.Before(match.GetStartedAt().Time) {
+				match = step
+			}
+		}
+	}
+
+	switch {
+	case match != nil:
+		return match.GetNumber()
+	case started != nil:
+		return started.GetNumber()
+	default:
+		return steps[0].GetNumber()
+	}
+}
+
 func extractJobsAndFilesFromZip(zipReader *zip.Reader, logger *zap.Logger) ([]string, map[string][]*zip.File) {
 	// Pre-allocate maps with reasonable capacity based on typical GitHub Actions workflows
 	estimatedJobs := min(len(zipReader.File)/10, 50) // Estimate ~10 files per job, max 50 jobs
@@ -217,18 +457,19 @@ func extractJobsAndFilesFromZip(zipReader *zip.Reader, logger *zap.Logger) ([]st
 	return jobs, filesByJob
 }
 
-func processJobLogs(jobName string, files []*zip.File, resourceLogs plog.ResourceLogs, traceID pcommon.TraceID, e *github.WorkflowRunEvent, withTraceInfo bool, logger *zap.Logger) {
+func processJobLogs(jobName string, job *github.WorkflowJob, files []*zip.File, resourceLogs plog.ResourceLogs, traceID pcommon.TraceID, e *github.WorkflowRunEvent, policy *logpolicy.Policy, withTraceInfo bool, logger *zap.Logger, builder *logEntryBuilder) {
 	jobLogsScope := resourceLogs.ScopeLogs().AppendEmpty()
 	jobLogsScope.Scope().Attributes().PutStr("ci.github.workflow.job.name", jobName)
 
-	// Reuse a single logEntryBuilder for all files in this job
-	var builder logEntryBuilder
-
 	for _, logFile := range files {
 		logger.Debug("Processing log file",
 			zap.String("job_name", jobName),
 			zap.String("file_name", logFile.Name))
-		processLogFile(logFile, jobName, jobLogsScope, traceID, e, withTraceInfo, logger, &builder)
+		processLogFile(logFile, jobName, jobLogsScope, traceID, e, policy, withTraceInfo, logger, builder)
+	}
+
+	if job != nil {
+		trimSuccessfulSteps(jobLogsScope.LogRecords(), job, policy)
 	}
 
 	logger.Debug("Completed job log processing",
@@ -236,7 +477,7 @@ func processJobLogs(jobName string, files []*zip.File, resourceLogs plog.Resourc
 		zap.Int("log_records", jobLogsScope.LogRecords().Len()))
 }
 
-func processLogFile(logFile *zip.File, jobName string, jobLogsScope plog.ScopeLogs, traceID pcommon.TraceID, e *github.WorkflowRunEvent, withTraceInfo bool, logger *zap.Logger, builder *logEntryBuilder) {
+func processLogFile(logFile *zip.File, jobName string, jobLogsScope plog.ScopeLogs, traceID pcommon.TraceID, e *github.WorkflowRunEvent, policy *logpolicy.Policy, withTraceInfo bool, logger *zap.Logger, builder *logEntryBuilder) {
 	stepNumber, err := extractStepNumberFromFileName(logFile.Name, jobName)
 	if err != nil {
 		if strings.Contains(err.Error(), "skipping system file") {
@@ -253,6 +494,9 @@ func processLogFile(logFile *zip.File, jobName string, jobLogsScope plog.ScopeLo
 		steplog.Error("Failed to generate span ID", zap.Error(err))
 		return
 	}
+	locate := func(time.Time) (int64, pcommon.SpanID) {
+		return int64(stepNumber), spanID
+	}
 
 	fileReader, err := logFile.Open()
 	if err != nil {
@@ -265,7 +509,7 @@ func processLogFile(logFile *zip.File, jobName string, jobLogsScope plog.ScopeLo
 		}
 	}()
 
-	processLogEntries(fileReader, jobLogsScope, spanID, traceID, stepNumber, withTraceInfo, steplog, builder)
+	processLogEntries(fileReader, jobLogsScope, traceID, locate, policy, withTraceInfo, steplog, builder)
 }
 
 func extractStepNumberFromFileName(fileName, jobName string) (int, error) {
@@ -289,48 +533,66 @@ func extractStepNumberFromFileName(fileName, jobName string) (int, error) {
 	return strconv.Atoi(baseName[:underscoreIdx])
 }
 
-func processLogEntries(reader io.Reader, jobLogsScope plog.ScopeLogs, spanID pcommon.SpanID, traceID pcommon.TraceID, stepNumber int, withTraceInfo bool, logger *zap.Logger, builder *logEntryBuilder) {
+func processLogEntries(reader io.Reader, jobLogsScope plog.ScopeLogs, traceID pcommon.TraceID, locate stepLocator, policy *logpolicy.Policy, withTraceInfo bool, logger *zap.Logger, builder *logEntryBuilder) {
 	scanner := bufio.NewScanner(reader)
 
+	bufferLimit := max(maxLogBufferBytes, policy.MaxEntryBytes()+1)
 	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, maxLogEntryBytes)
+	scanner.Buffer(buf, bufferLimit)
+
+	// The log policy applies to each line before it is joined, and entries
+	// are built in full, so that secrets are redacted before the log policy
+	// cuts oversized entries
+	entryLimit := bufferLimit
 
+	builder.clear()
 	for scanner.Scan() {
 		line := scanner.Bytes()
 		if len(line) == 0 {
+			if builder.multiline == multilineJoinUntilBlank && builder.hasCurrentEntry {
+				finalizeLogEntry(builder, jobLogsScope, traceID, policy, withTraceInfo)
+			}
 			continue
 		}
 
 		parsedTime, rest, ok := parseTimestamp(line, logger)
 		if ok {
 			if builder.hasCurrentEntry {
-				finalizeLogEntry(builder, jobLogsScope, spanID, traceID, stepNumber, withTraceInfo)
+				finalizeLogEntry(builder, jobLogsScope, traceID, policy, withTraceInfo)
 			}
 
 			// Reuse the builder
 			builder.currentParsedTime = parsedTime
-			builder.currentStepNumber = int64(stepNumber)
+			builder.currentStepNumber, builder.currentSpanID = locate(parsedTime)
 			builder.hasCurrentEntry = true
 			builder.currentBody.Reset()
-			builder.currentBody.Write(rest)
-		} else {
-			if !builder.hasCurrentEntry {
-				logger.Error("Orphaned log line without preceding timestamp", zap.String("line", string(line)))
-				continue
-			}
+			appendLine(builder, rest, policy, entryLimit)
+			continue
+		}
 
-			if builder.currentBody.Len()+len(line)+1 > maxLogEntryBytes {
-				logger.Warn("Skipping line due to size limit", zap.Int("lineSize", len(line)))
+		if builder.hasCurrentEntry && builder.multiline == multilineSplit {
+			finalizeLogEntry(builder, jobLogsScope, traceID, policy, withTraceInfo)
+		}
+
+		if !builder.hasCurrentEntry {
+			if builder.multiline == "" || builder.multiline == multilineJoin || !builder.continueEntry() {
+				logger.Error("Orphaned log line without preceding timestamp", zap.String("line", string(line)))
 				continue
 			}
+			appendLine(builder, line, policy, entryLimit)
+			continue
+		}
 
-			builder.currentBody.WriteByte('\n')
-			builder.currentBody.Write(line)
+		if builder.currentBody.Len() >= entryLimit {
+			logger.Warn("Skipping line due to size limit", zap.Int("lineSize", len(line)))
+			continue
 		}
+
+		appendLine(builder, line, policy, entryLimit)
 	}
 
 	if builder.hasCurrentEntry {
-		finalizeLogEntry(builder, jobLogsScope, spanID, traceID, stepNumber, withTraceInfo)
+		finalizeLogEntry(builder, jobLogsScope, traceID, policy, withTraceInfo)
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -338,17 +600,98 @@ func processLogEntries(reader io.Reader, jobLogsScope plog.ScopeLogs, spanID pco
 	}
 }
 
-func finalizeLogEntry(builder *logEntryBuilder, jobLogsScope plog.ScopeLogs, spanID pcommon.SpanID, traceID pcommon.TraceID, stepNumber int, withTraceInfo bool) {
-	record := jobLogsScope.LogRecords().AppendEmpty()
-	if withTraceInfo {
-		record.SetSpanID(spanID)
-		record.SetTraceID(traceID)
+// appendLine applies the log policy to a line and adds it to the entry being
+// built, leaving it out if it is dropped.
+func appendLine(builder *logEntryBuilder, line []byte, policy *logpolicy.Policy, limit int) {
+	body, keep := policy.Apply(string(line))
+	if !keep {
+		return
+	}
+	if builder.currentLines > 0 {
+		builder.currentBody.WriteByte('\n')
 	}
-	record.Attributes().PutInt("ci.github.workflow.job.step.number", int64(stepNumber))
-	record.SetTimestamp(pcommon.NewTimestampFromTime(builder.currentParsedTime))
-	record.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Now()))
-	record.Body().SetStr(builder.currentBody.String())
+	appendToEntry(builder, []byte(body), limit)
+	builder.currentLines++
+}
+
+// appendToEntry appends data to the entry being built without growing it
+// beyond limit bytes.
+func appendToEntry(builder *logEntryBuilder, data []byte, limit int) {
+	if room := limit - builder.currentBody.Len(); len(data) > room {
+		data = data[:max(room, 0)]
+	}
+	builder.currentBody.Write(data)
+}
+
+func finalizeLogEntry(builder *logEntryBuilder, jobLogsScope plog.ScopeLogs, traceID pcommon.TraceID, policy *logpolicy.Policy, withTraceInfo bool) {
+	// Entries whose lines were all dropped are left out
+	body, keep := builder.currentBody.String(), builder.currentLines > 0
 	builder.reset()
+	if !keep {
+		return
+	}
+
+	observed := pcommon.NewTimestampFromTime(time.Now())
+	for _, chunk := range policy.Fit(body) {
+		record := jobLogsScope.LogRecords().AppendEmpty()
+		if withTraceInfo {
+			record.SetSpanID(builder.currentSpanID)
+			record.SetTraceID(traceID)
+		}
+		record.Attributes().PutInt("ci.github.workflow.job.step.number", builder.currentStepNumber)
+		record.SetTimestamp(pcommon.NewTimestampFromTime(builder.currentParsedTime))
+		record.SetObservedTimestamp(observed)
+		record.Body().SetStr(chunk)
+	}
+}
+
+// trimSuccessfulSteps removes all but the trailing log lines of the successful
+// steps of a job, as configured by the log policy. The record straddling the
+// tail of a step keeps its trailing lines.
+func trimSuccessfulSteps(records plog.LogRecordSlice, job *github.WorkflowJob, policy *logpolicy.Policy) {
+	tailLines := make(map[int64]int, len(job.Steps))
+	for _, step := range job.Steps {
+		tailLines[step.GetNumber()] = policy.TailLines(pipelineResult(step.GetConclusion()).Failed())
+	}
+
+	remaining := make(map[int64]int, len(tailLines))
+	for i := range records.Len() {
+		remaining[recordStepNumber(records.At(i))] += recordLines(records.At(i))
+	}
+
+	dropped := 0
+	records.RemoveIf(func(record plog.LogRecord) bool {
+		stepNumber := recordStepNumber(record)
+		lines := recordLines(record)
+		remaining[stepNumber] -= lines
+
+		n := tailLines[stepNumber]
+		switch {
+		case n == 0:
+			return false
+		case remaining[stepNumber] >= n:
+			dropped += lines
+			return true
+		case remaining[stepNumber]+lines > n:
+			body := record.Body().Str()
+			for range remaining[stepNumber] + lines - n {
+				body = body[strings.IndexByte(body, '\n')+1:]
+			}
+			record.Body().SetStr(body)
+			dropped += remaining[stepNumber] + lines - n
+		}
+		return false
+	})
+	policy.RecordTailDropped(dropped)
+}
+
+func recordLines(record plog.LogRecord) int {
+	return strings.Count(record.Body().Str(), "\n") + 1
+}
+
+func recordStepNumber(record plog.LogRecord) int64 {
+	v, _ := record.Attributes().Get("ci.github.workflow.job.step.number")
+	return v.Int()
 }
 
 func parseTimestamp(line []byte, logger *zap.Logger) (time.Time, []byte, bool) {
@@ -4,15 +4,12 @@ import (
 	"fmt"
 	"sort"
 	"strings"
-	"time"
 
 	"github.com/google/go-github/v88/github"
-	"go.opentelemetry.io/collector/pdata/pcommon"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 )
 
-var durationBucketBounds = []float64{5, 15, 30, 60, 300, 600, 1800}
-
 func sortedLabels(labels []string) string {
 	if len(labels) == 0 {
 		return "no labels"
@@ -25,60 +22,6 @@ func sortedLabels(labels []string) string {
 	return strings.Join(s, ",")
 }
 
-type histogramState struct {
-	count        uint64
-	sum          float64
-	bucketCounts []uint64
-	lastSeen     time.Time
-}
-
-func (h *histogramState) observe(duration float64, bounds []float64) {
-	h.count++
-	h.sum += duration
-	h.lastSeen = time.Now()
-	for i, b := range bounds {
-		if duration <= b {
-			h.bucketCounts[i]++
-			return
-		}
-	}
-	h.bucketCounts[len(bounds)]++
-}
-
-func newHistogramState(bounds []float64) *histogramState {
-	return &histogramState{
-		bucketCounts: make([]uint64, len(bounds)+1),
-	}
-}
-
-type durationMetricParams struct {
-	name      string
-	strAttrs  map[string]string
-	boolAttrs map[string]bool
-}
-
-func appendDurationMetric(ms pmetric.MetricSlice, p durationMetricParams, state *histogramState) {
-	m := ms.AppendEmpty()
-	m.SetName(p.name)
-	m.SetUnit("s")
-	m.SetEmptyHistogram()
-	m.Histogram().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
-
-	dp := m.Histogram().DataPoints().AppendEmpty()
-	dp.SetTimestamp(pcommon.NewTimestampFromTime(time.Now()))
-	dp.SetCount(state.count)
-	dp.SetSum(state.sum)
-	dp.ExplicitBounds().FromRaw(durationBucketBounds)
-	dp.BucketCounts().FromRaw(state.bucketCounts)
-
-	for k, v := range p.strAttrs {
-		dp.Attributes().PutStr(k, v)
-	}
-	for k, v := range p.boolAttrs {
-		dp.Attributes().PutBool(k, v)
-	}
-}
-
 func (m *metricsHandler) appendJobDurationMetric(ms pmetric.MetricSlice, event *github.WorkflowJobEvent) {
 	if event == nil || event.GetWorkflowJob() == nil || event.GetAction() != "completed" {
 		return
@@ -106,29 +49,24 @@ func (m *metricsHandler) appendJobDurationMetric(ms pmetric.MetricSlice, event *
 	labels := sortedLabels(job.Labels)
 	conclusion := job.GetConclusion()
 
-	cacheKey := fmt.Sprintf("hist:job:%s:%s:%s:%s:%s:%t",
-		repo, job.GetWorkflowName(), job.GetName(), labels, conclusion, isMain)
+	if m.cfg.Semconv.EmitsLegacy() {
+		cacheKey := fmt.Sprintf("hist:job:%s:%s:%s:%s:%s:%t",
+			repo, job.GetWorkflowName(), job.GetName(), labels, conclusion, isMain)
 
-	state, ok := m.histogramCache.Get(cacheKey)
-	if !ok {
-		state = newHistogramState(durationBucketBounds)
-	}
-	state.observe(duration, durationBucketBounds)
-	m.histogramCache.Add(cacheKey, state)
-
-	appendDurationMetric(ms, durationMetricParams{
-		name: "workflow.jobs.duration",
-		strAttrs: map[string]string{
-			"vcs.repository.name":               repo,
-			"ci.github.workflow.name":           job.GetWorkflowName(),
-			"ci.github.workflow.job.name":       job.GetName(),
-			"ci.github.workflow.job.labels":     labels,
-			"ci.github.workflow.job.conclusion": conclusion,
-		},
-		boolAttrs: map[string]bool{
+		cimodel.AppendHistogram(ms, "workflow.jobs.duration", map[string]any{
+			"vcs.repository.name":                        repo,
+			"ci.github.workflow.name":                    job.GetWorkflowName(),
+			"ci.github.workflow.job.name":                job.GetName(),
+			"ci.github.workflow.job.labels":              labels,
+			"ci.github.workflow.job.conclusion":          conclusion,
 			"ci.github.workflow.job.head_branch.is_main": isMain,
-		},
-	}, state)
+		}, m.observeDuration(cacheKey, duration))
+	}
+
+	if m.cfg.Semconv.Enabled {
+		pipeline := workflowJobPipeline(event)
+		m.durations.AppendTask(ms, &pipeline, &pipeline.Tasks[0])
+	}
 }
 
 func (m *metricsHandler) appendRunDurationMetric(ms pmetric.MetricSlice, event *github.WorkflowRunEvent) {
@@ -157,25 +95,31 @@ func (m *metricsHandler) appendRunDurationMetric(ms pmetric.MetricSlice, event *
 	duration := updatedAt.Time.Sub(runStartedAt.Time).Seconds()
 	conclusion := run.GetConclusion()
 
-	cacheKey := fmt.Sprintf("hist:run:%s:%s:%s:%t",
-		repo, run.GetName(), conclusion, isMain)
+	if m.cfg.Semconv.EmitsLegacy() {
+		cacheKey := fmt.Sprintf("hist:run:%s:%s:%s:%t",
+			repo, run.GetName(), conclusion, isMain)
 
-	state, ok := m.histogramCache.Get(cacheKey)
+		cimodel.AppendHistogram(ms, "workflow.runs.duration", map[string]any{
+			"vcs.repository.name":                        repo,
+			"ci.github.workflow.name":                    run.GetName(),
+			"ci.github.workflow.run.conclusion":          conclusion,
+			"ci.github.workflow.run.head_branch.is_main": isMain,
+		}, m.observeDuration(cacheKey, duration))
+	}
+
+	if m.cfg.Semconv.Enabled {
+		pipeline := workflowRunPipeline(event)
+		m.durations.AppendPipeline(ms, &pipeline)
+	}
+}
+
+// observeDuration records a duration in the histogram cached under key.
+func (m *metricsHandler) observeDuration(key string, duration float64) *cimodel.Histogram {
+	h, ok := m.histogramCache.Get(key)
 	if !ok {
-		state = newHistogramState(durationBucketBounds)
+		h = cimodel.NewHistogram()
 	}
-	state.observe(duration, durationBucketBounds)
-	m.histogramCache.Add(cacheKey, state)
-
-	appendDurationMetric(ms, durationMetricParams{
-		name: "workflow.runs.duration",
-		strAttrs: map[string]string{
-			"vcs.repository.name":               repo,
-			"ci.github.workflow.name":           run.GetName(),
-			"ci.github.workflow.run.conclusion": conclusion,
-		},
-		boolAttrs: map[string]bool{
-			"ci.github.workflow.run.head_branch.is_main": isMain,
-		},
-	}, state)
+	h.Observe(duration)
+	m.histogramCache.Add(key, h)
+	return h
 }
@@ -6,60 +6,12 @@ import (
 	"time"
 
 	"github.com/google/go-github/v88/github"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 )
 
-func TestHistogramStateObserve(t *testing.T) {
-	tests := []struct {
-		name     string
-		value    float64
-		expected []uint64
-	}{
-		{
-			name:     "zero value falls in first bucket",
-			value:    0,
-			expected: []uint64{1, 0, 0, 0, 0, 0, 0, 0},
-		},
-		{
-			name:     "on boundary falls in that bucket",
-			value:    5,
-			expected: []uint64{1, 0, 0, 0, 0, 0, 0, 0},
-		},
-		{
-			name:     "between boundaries",
-			value:    10,
-			expected: []uint64{0, 1, 0, 0, 0, 0, 0, 0},
-		},
-		{
-			name:     "on second boundary",
-			value:    15,
-			expected: []uint64{0, 1, 0, 0, 0, 0, 0, 0},
-		},
-		{
-			name:     "between 30 and 60",
-			value:    45,
-			expected: []uint64{0, 0, 0, 1, 0, 0, 0, 0},
-		},
-		{
-			name:     "overflow beyond last boundary",
-			value:    5000,
-			expected: []uint64{0, 0, 0, 0, 0, 0, 0, 1},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			state := newHistogramState(durationBucketBounds)
-			state.observe(tt.value, durationBucketBounds)
-			require.Equal(t, tt.expected, state.bucketCounts)
-			require.Equal(t, uint64(1), state.count)
-			require.Equal(t, tt.value, state.sum)
-		})
-	}
-}
-
 func TestSortedLabels(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -500,3 +452,53 @@ func assertBoolAttr(t *testing.T, attrs pcommon.Map, key string, expected bool)
 	require.True(t, ok, "attribute %q not found", key)
 	require.Equal(t, expected, v.Bool(), "attribute %q", key)
 }
+
+func TestAppendDurationMetric_Semconv(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	jobEvent := makeJobEvent("completed", "timed_out", "main", "main", base, base.Add(10*time.Second))
+	runEvent := makeRunEvent("completed", "cancelled", "main", "main", base, base.Add(45*time.Second))
+
+	tests := map[string]struct {
+		semconv  semconv.Config
+		expected []string
+	}{
+		"legacy": {
+			expected: []string{"workflow.jobs.duration", "workflow.runs.duration"},
+		},
+		"semconv": {
+			semconv:  semconv.Config{Enabled: true},
+			expected: []string{"cicd.pipeline.task.run.duration", "cicd.pipeline.run.duration"},
+		},
+		"semconv with legacy": {
+			semconv: semconv.Config{Enabled: true, EmitLegacy: true},
+			expected: []string{
+				"workflow.jobs.duration", "cicd.pipeline.task.run.duration",
+				"workflow.runs.duration", "cicd.pipeline.run.duration",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := newFullMetricsHandler(t)
+			handler.cfg.Semconv = test.semconv
+
+			ms := pmetric.NewMetricSlice()
+			handler.appendJobDurationMetric(ms, jobEvent)
+			handler.appendRunDurationMetric(ms, runEvent)
+
+			var names []string
+			for i := range ms.Len() {
+				names = append(names, ms.At(i).Name())
+				attrs := ms.At(i).Histogram().DataPoints().At(0).Attributes()
+				switch ms.At(i).Name() {
+				case semconv.MetricCICDPipelineTaskRunDuration:
+					assertStrAttr(t, attrs, semconv.AttributeCICDPipelineTaskRunResult, "timeout")
+				case semconv.MetricCICDPipelineRunDuration:
+					assertStrAttr(t, attrs, semconv.AttributeCICDPipelineResult, "cancellation")
+				}
+			}
+			require.Equal(t, test.expected, names)
+		})
+	}
+}
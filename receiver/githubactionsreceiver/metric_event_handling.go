@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/google/go-github/v88/github"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
 	"github.com/grafana/grafana-ci-otel-collector/receiver/githubactionsreceiver/internal/metadata"
 	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/prometheus/common/version"
@@ -23,7 +24,11 @@ type metricsHandler struct {
 	cfg            *Config
 	logger         *zap.Logger
 	countersCache  *lru.Cache[string, int64]
-	histogramCache *lru.Cache[string, *histogramState]
+	histogramCache *lru.Cache[string, *cimodel.Histogram]
+	durations      *cimodel.Durations
+	billingCache   *lru.Cache[string, billingTotals]
+	artifactRuns   *lru.Cache[int64, *runArtifacts]
+	artifactTotals map[artifactWorkflow]*artifactTotals
 }
 
 const metricsMaxCacheSize = 100000
@@ -50,11 +55,21 @@ func newMetricsHandler(settings receiver.Settings, cfg *Config, logger *zap.Logg
 	// We emit histograms with cumulative temporality (required by Prometheus-compatible
 	// backends), so each emission must include running totals of count/sum/buckets
 	// across all observations — not just the latest event.
-	histCache, err2 := lru.New[string, *histogramState](histogramCacheSize)
+	histCache, err2 := lru.New[string, *cimodel.Histogram](histogramCacheSize)
 	if err2 != nil {
 		panic(fmt.Sprintf("Failed to initialize histogram cache: %v", err2))
 	}
 
+	billingCache, err3 := lru.New[string, billingTotals](billingCacheSize)
+	if err3 != nil {
+		panic(fmt.Sprintf("Failed to initialize billing cache: %v", err3))
+	}
+
+	durations, err4 := cimodel.NewDurations(histogramCacheSize, histogramTTL)
+	if err4 != nil {
+		panic(fmt.Sprintf("Failed to initialize durations cache: %v", err4))
+	}
+
 	mh := &metricsHandler{
 		cfg:            cfg,
 		settings:       settings.TelemetrySettings,
@@ -62,7 +77,16 @@ func newMetricsHandler(settings receiver.Settings, cfg *Config, logger *zap.Logg
 		logger:         logger,
 		countersCache:  countersCache,
 		histogramCache: histCache,
+		billingCache:   billingCache,
+		durations:      durations,
+		artifactTotals: make(map[artifactWorkflow]*artifactTotals),
+	}
+
+	artifactRuns, err5 := lru.NewWithEvict[int64, *runArtifacts](storageMaxRuns, mh.removeRunArtifacts)
+	if err5 != nil {
+		panic(fmt.Sprintf("Failed to initialize artifact runs cache: %v", err5))
 	}
+	mh.artifactRuns = artifactRuns
 
 	return mh
 }
@@ -271,7 +295,7 @@ func (m *metricsHandler) sweepStaleHistograms() {
 	now := time.Now()
 	for _, key := range m.histogramCache.Keys() {
 		state, ok := m.histogramCache.Peek(key)
-		if ok && now.Sub(state.lastSeen) >= histogramTTL {
+		if ok && now.Sub(state.LastSeen) >= histogramTTL {
 			m.histogramCache.Remove(key)
 		}
 	}
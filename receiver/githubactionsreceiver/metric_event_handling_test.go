@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/go-github/v88/github"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
 	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/receiver/receivertest"
@@ -18,7 +19,7 @@ func newTestMetricsHandler(t *testing.T) *metricsHandler {
 	t.Helper()
 	cache, err := lru.New[string, int64](metricsMaxCacheSize)
 	require.NoError(t, err)
-	histCache, err := lru.New[string, *histogramState](histogramCacheSize)
+	histCache, err := lru.New[string, *cimodel.Histogram](histogramCacheSize)
 	require.NoError(t, err)
 	return &metricsHandler{
 		countersCache:  cache,
@@ -398,13 +399,13 @@ func TestSweepStaleHistograms(t *testing.T) {
 	handler := newTestMetricsHandler(t)
 
 	// Add a fresh entry
-	fresh := newHistogramState(durationBucketBounds)
-	fresh.lastSeen = time.Now()
+	fresh := cimodel.NewHistogram()
+	fresh.LastSeen = time.Now()
 	handler.histogramCache.Add("fresh-key", fresh)
 
 	// Add a stale entry (last seen 25h ago, beyond 24h TTL)
-	stale := newHistogramState(durationBucketBounds)
-	stale.lastSeen = time.Now().Add(-25 * time.Hour)
+	stale := cimodel.NewHistogram()
+	stale.LastSeen = time.Now().Add(-25 * time.Hour)
 	handler.histogramCache.Add("stale-key", stale)
 
 	require.Equal(t, 2, handler.histogramCache.Len())
@@ -0,0 +1,95 @@
+package githubactionsreceiver
+
+import (
+	"strconv"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+)
+
+// workflowRunPipeline maps a workflow run to the CI model, without its jobs.
+func workflowRunPipeline(e *github.WorkflowRunEvent) cimodel.Pipeline {
+	run := e.GetWorkflowRun()
+
+	pipeline := cimodel.Pipeline{
+		ID:         strconv.FormatInt(run.GetID(), 10),
+		Name:       run.GetName(),
+		URL:        run.GetHTMLURL(),
+		Result:     pipelineResult(run.GetConclusion()),
+		Status:     run.GetConclusion(),
+		Started:    run.GetRunStartedAt().Time,
+		Finished:   run.GetUpdatedAt().Time,
+		Repository: githubRepository(e.GetRepo()),
+		Ref: cimodel.Ref{
+			Head:     run.GetHeadBranch(),
+			HeadType: semconv.AttributeVCSRefTypeBranch,
+			Revision: run.GetHeadSHA(),
+		},
+	}
+
+	if len(run.PullRequests) > 0 {
+		pr := run.PullRequests[0]
+		pipeline.Ref.ChangeID = strconv.Itoa(pr.GetNumber())
+		pipeline.Ref.Base = pr.GetBase().GetRef()
+	}
+
+	return pipeline
+}
+
+// workflowJobPipeline maps a workflow job to the CI model, as the only task
+// of its run. The run itself is described by workflow_run events.
+func workflowJobPipeline(e *github.WorkflowJobEvent) cimodel.Pipeline {
+	job := e.GetWorkflowJob()
+
+	return cimodel.Pipeline{
+		ID:         strconv.FormatInt(job.GetRunID(), 10),
+		Name:       job.GetWorkflowName(),
+		Repository: githubRepository(e.GetRepo()),
+		Ref: cimodel.Ref{
+			Head:     job.GetHeadBranch(),
+			HeadType: semconv.AttributeVCSRefTypeBranch,
+			Revision: job.GetHeadSHA(),
+		},
+		OmitSpan: true,
+		Tasks: []cimodel.Task{{
+			ID:       strconv.FormatInt(job.GetID(), 10),
+			Name:     job.GetName(),
+			URL:      job.GetHTMLURL(),
+			Worker:   job.GetRunnerName(),
+			Result:   pipelineResult(job.GetConclusion()),
+			Status:   job.GetConclusion(),
+			Started:  job.GetStartedAt().Time,
+			Finished: job.GetCompletedAt().Time,
+		}},
+	}
+}
+
+func githubRepository(repo *github.Repository) cimodel.Repository {
+	return cimodel.Repository{
+		Provider: semconv.AttributeVCSProviderNameGithub,
+		Owner:    repo.GetOwner().GetLogin(),
+		Name:     repo.GetName(),
+		URL:      repo.GetHTMLURL(),
+	}
+}
+
+// pipelineResult maps a GitHub conclusion to a pipeline, job or step result.
+func pipelineResult(conclusion string) cimodel.Result {
+	switch conclusion {
+	case "success":
+		return cimodel.ResultSuccess
+	case "failure":
+		return cimodel.ResultFailure
+	case "startup_failure":
+		return cimodel.ResultError
+	case "timed_out":
+		return cimodel.ResultTimeout
+	case "cancelled":
+		return cimodel.ResultCancellation
+	case "skipped":
+		return cimodel.ResultSkip
+	default:
+		return cimodel.ResultUnknown
+	}
+}
@@ -13,16 +13,25 @@ import (
 
 	"github.com/bradleyfalzon/ghinstallation/v2"
 	"github.com/google/go-github/v88/github"
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
 	"github.com/grafana/grafana-ci-otel-collector/receiver/githubactionsreceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/receiver"
 	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.uber.org/zap"
 )
 
 var errMissingEndpoint = errors.New("missing a receiver endpoint")
 
+// lookupQueueSize bounds the completed events waiting for GitHub API lookups,
+// more are rejected
+const lookupQueueSize = 100
+
 type githubActionsReceiver struct {
 	logsConsumer    consumer.Logs
 	tracesConsumer  consumer.Traces
@@ -31,12 +40,18 @@ type githubActionsReceiver struct {
 	config          *Config
 	server          *http.Server
 	shutdownWG      sync.WaitGroup
+	ctx             context.Context // canceled by Shutdown, bounds the tickers and log downloads
 	cancel          context.CancelFunc
 	createSettings  receiver.Settings
 	logger          *zap.Logger
 	obsrecv         *receiverhelper.ObsReport
 	ghClient        *github.Client
 	ghitr           *ghinstallation.Transport
+	telemetry       *metadata.TelemetryBuilder
+	logPolicy       *logpolicy.Policy
+	repos           *lru.Cache[string, struct{}]
+	teams           *teamResolver
+	events          chan any // completed events waiting for GitHub API lookups
 }
 
 func newReceiver(
@@ -100,6 +115,26 @@ func newReceiver(
 		return nil, err
 	}
 
+	telemetry, err := metadata.NewTelemetryBuilder(params.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+
+	logPolicy, err := logpolicy.New(config.Logs.Config, logPolicyRecorder{telemetry: telemetry})
+	if err != nil {
+		return nil, err
+	}
+
+	repos, err := lru.New[string, struct{}](storageMaxRepos)
+	if err != nil {
+		return nil, err
+	}
+
+	teams, err := newTeamResolver(config.Billing, ghClient, params.Logger.Named("teamResolver"))
+	if err != nil {
+		return nil, err
+	}
+
 	gar := &githubActionsReceiver{
 		config:         config,
 		createSettings: params,
@@ -107,8 +142,14 @@ func newReceiver(
 		obsrecv:        obsrecv,
 		ghClient:       ghClient,
 		ghitr:          itr,
+		telemetry:      telemetry,
+		logPolicy:      logPolicy,
+		repos:          repos,
+		teams:          teams,
+		events:         make(chan any, lookupQueueSize),
 		metricsHandler: *newMetricsHandler(params, config, params.Logger.Named("metricsHandler")),
 	}
+	gar.ctx, gar.cancel = context.WithCancel(context.Background())
 
 	return gar, nil
 }
@@ -185,6 +226,24 @@ func newMetricsReceiver(
 	return r, nil
 }
 
+// logPolicyRecorder reports the log lines affected by the log policy as
+// internal telemetry.
+type logPolicyRecorder struct {
+	telemetry *metadata.TelemetryBuilder
+}
+
+func (r logPolicyRecorder) RecordDroppedLines(n int64, reason string) {
+	r.telemetry.ReceiverLogsDroppedLines.Add(context.Background(), n, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+func (r logPolicyRecorder) RecordRedactedLines(n int64) {
+	r.telemetry.ReceiverLogsRedactedLines.Add(context.Background(), n)
+}
+
+func (r logPolicyRecorder) RecordOversizedEntries(n int64, behaviour string) {
+	r.telemetry.ReceiverLogsOversizedEntries.Add(context.Background(), n, metric.WithAttributes(attribute.String("behaviour", behaviour)))
+}
+
 func (gar *githubActionsReceiver) Start(ctx context.Context, host component.Host) error {
 	endpoint := fmt.Sprintf("%s%s", gar.config.NetAddr.Endpoint, gar.config.Path)
 	gar.logger.Info("Starting GithubActions server", zap.String("endpoint", endpoint))
@@ -203,10 +262,13 @@ func (gar *githubActionsReceiver) Start(ctx context.Context, host component.Host
 		}
 	}()
 
-	if gar.metricsConsumer != nil {
-		var tickCtx context.Context
-		tickCtx, gar.cancel = context.WithCancel(context.Background())
+	gar.shutdownWG.Add(1)
+	go func() {
+		defer gar.shutdownWG.Done()
+		gar.processEvents(gar.ctx)
+	}()
 
+	if gar.metricsConsumer != nil {
 		gar.shutdownWG.Add(1)
 		go func() {
 			defer gar.shutdownWG.Done()
@@ -214,22 +276,52 @@ func (gar *githubActionsReceiver) Start(ctx context.Context, host component.Host
 			defer ticker.Stop()
 
 			// Emit immediately on start, then on each tick.
-			gar.emitBuildInfo(tickCtx)
+			gar.emitBuildInfo(gar.ctx)
 
 			for {
 				select {
 				case <-ticker.C:
-					gar.emitBuildInfo(tickCtx)
-				case <-tickCtx.Done():
+					gar.emitBuildInfo(gar.ctx)
+				case <-gar.ctx.Done():
 					return
 				}
 			}
 		}()
+
+		if gar.config.Storage.Caches {
+			gar.shutdownWG.Add(1)
+			go func() {
+				defer gar.shutdownWG.Done()
+				ticker := time.NewTicker(gar.config.Storage.CacheInterval)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-ticker.C:
+						gar.emitCacheUsage(gar.ctx)
+					case <-gar.ctx.Done():
+						return
+					}
+				}
+			}()
+		}
 	}
 
 	return nil
 }
 
+func (gar *githubActionsReceiver) emitCacheUsage(ctx context.Context) {
+	usages := listCacheUsage(ctx, gar.ghClient, gar.repos.Keys(), gar.logger)
+	if len(usages) == 0 {
+		return
+	}
+
+	md := gar.metricsHandler.cacheUsageMetrics(usages)
+	if err := gar.metricsConsumer.ConsumeMetrics(ctx, md); err != nil {
+		gar.logger.Error("Failed to emit cache usage metrics", zap.Error(err))
+	}
+}
+
 func (gar *githubActionsReceiver) emitBuildInfo(ctx context.Context) {
 	md := gar.metricsHandler.buildInfoMetrics()
 
@@ -238,15 +330,30 @@ func (gar *githubActionsReceiver) emitBuildInfo(ctx context.Context) {
 	}
 }
 
+// Shutdown stops the server, the tickers and the processing of queued
+// events. Events waiting for GitHub API lookups are dropped.
 func (gar *githubActionsReceiver) Shutdown(ctx context.Context) error {
-	if gar.cancel != nil {
-		gar.cancel()
-	}
+	gar.cancel()
 	var err error
 	if gar.server != nil {
 		err = gar.server.Close()
 	}
-	gar.shutdownWG.Wait()
+
+	// The event being processed is still reported unless ctx expires first
+	done := make(chan struct{})
+	go func() {
+		gar.shutdownWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		gar.logger.Warn("Stopped waiting for the event being reported", zap.Error(ctx.Err()))
+	}
+	if n := len(gar.events); n > 0 {
+		gar.logger.Warn("Dropping events waiting for GitHub API lookups", zap.Int("events", n))
+	}
+	gar.telemetry.Shutdown()
 	return err
 }
 
@@ -276,18 +383,29 @@ func (gar *githubActionsReceiver) ServeHTTP(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Events whose reporting calls the GitHub API are reported by
+	// processEvents. Events rejected while the queue is full are not counted
+	// either, so that their redelivery is counted once.
+	queued := gar.needsLookups(event)
+	if queued {
+		select {
+		case gar.events <- event:
+		default:
+			gar.logger.Warn("Too many events waiting for GitHub API lookups, dropping", zap.String("type", eventType))
+			http.Error(w, "too many events waiting for GitHub API lookups", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
 	// Handle events based on specific types and completion status
 	switch e := event.(type) {
 	case *github.WorkflowJobEvent:
-		if gar.metricsConsumer != nil {
-			metrics := gar.metricsHandler.workflowJobEventToMetrics(e)
-			metricsCtx := gar.obsrecv.StartMetricsOp(ctx)
-			err := gar.metricsConsumer.ConsumeMetrics(metricsCtx, metrics)
-			gar.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), metrics.DataPointCount(), err)
+		if gar.config.Storage.Caches {
+			gar.repos.Add(e.GetRepo().GetFullName(), struct{}{})
+		}
 
-			if err != nil {
-				gar.logger.Error("Failed to consume metrics", zap.Error(err))
-			}
+		if gar.metricsConsumer != nil {
+			gar.consumeMetrics(ctx, gar.metricsHandler.workflowJobEventToMetrics(e))
 		}
 
 		if e.GetWorkflowJob().GetStatus() != "completed" {
@@ -296,15 +414,12 @@ func (gar *githubActionsReceiver) ServeHTTP(w http.ResponseWriter, r *http.Reque
 			return
 		}
 	case *github.WorkflowRunEvent:
-		if gar.metricsConsumer != nil && e.GetWorkflowRun().GetEvent() == "push" {
-			metrics := gar.metricsHandler.workflowRunEventToMetrics(e)
-			metricsCtx := gar.obsrecv.StartMetricsOp(ctx)
-			err := gar.metricsConsumer.ConsumeMetrics(metricsCtx, metrics)
-			gar.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), metrics.DataPointCount(), err)
+		if gar.config.Storage.Caches {
+			gar.repos.Add(e.GetRepo().GetFullName(), struct{}{})
+		}
 
-			if err != nil {
-				gar.logger.Error("Failed to consume metrics", zap.Error(err))
-			}
+		if gar.metricsConsumer != nil && e.GetWorkflowRun().GetEvent() == "push" {
+			gar.consumeMetrics(ctx, gar.metricsHandler.workflowRunEventToMetrics(e))
 		}
 
 		if e.GetWorkflowRun().GetStatus() != "completed" {
@@ -319,6 +434,69 @@ func (gar *githubActionsReceiver) ServeHTTP(w http.ResponseWriter, r *http.Reque
 	}
 
 	gar.logger.Debug("Received valid GitHub event", zap.String("type", eventType))
+	if !queued {
+		gar.reportEvent(ctx, event)
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// needsLookups reports whether reporting a completed event lists the
+// artifacts of its run or reads the CODEOWNERS of its repository.
+func (gar *githubActionsReceiver) needsLookups(event any) bool {
+	switch e := event.(type) {
+	case *github.WorkflowJobEvent:
+		return e.GetWorkflowJob().GetStatus() == "completed" &&
+			gar.config.Billing.Enabled && gar.config.Billing.TeamSource == teamSourceCodeowners
+	case *github.WorkflowRunEvent:
+		return e.GetWorkflowRun().GetStatus() == "completed" &&
+			gar.config.Storage.Artifacts && (gar.metricsConsumer != nil || gar.tracesConsumer != nil)
+	default:
+		return false
+	}
+}
+
+// processEvents reports the queued events, until ctx is canceled.
+func (gar *githubActionsReceiver) processEvents(ctx context.Context) {
+	for {
+		select {
+		case event := <-gar.events:
+			gar.reportEvent(ctx, event)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reportEvent reports the billing, artifacts, traces and logs of a completed
+// job or run. Events whose lookups are canceled by shutdown are still reported.
+func (gar *githubActionsReceiver) reportEvent(ctx context.Context, event any) {
+	var artifacts []*github.Artifact
+	var usage jobUsage
+	var hasUsage bool
+	var team string
+	switch e := event.(type) {
+	case *github.WorkflowJobEvent:
+		if gar.config.Billing.Enabled {
+			usage, hasUsage = estimateJobUsage(e.GetWorkflowJob(), e.GetRepo(), gar.config.Billing)
+			if hasUsage {
+				team = gar.teams.resolve(ctx, e.GetRepo())
+			}
+		}
+
+		if hasUsage && gar.metricsConsumer != nil {
+			gar.consumeMetrics(context.WithoutCancel(ctx), gar.metricsHandler.jobBillingMetrics(e, usage, team))
+		}
+	case *github.WorkflowRunEvent:
+		if gar.config.Storage.Artifacts && (gar.metricsConsumer != nil || gar.tracesConsumer != nil) {
+			artifacts = listWorkflowRunArtifacts(ctx, gar.ghClient, e, gar.logger)
+		}
+
+		if gar.config.Storage.Artifacts && gar.metricsConsumer != nil {
+			gar.consumeMetrics(context.WithoutCancel(ctx), gar.metricsHandler.artifactMetrics(e, artifacts))
+		}
+	}
+	ctx = context.WithoutCancel(ctx)
+
 	traceErr := false
 
 	// if a trace consumer is set, process the event into traces
@@ -330,6 +508,13 @@ func (gar *githubActionsReceiver) ServeHTTP(w http.ResponseWriter, r *http.Reque
 		}
 
 		if td != nil {
+			if e, ok := event.(*github.WorkflowRunEvent); ok && len(artifacts) > 0 {
+				appendArtifactEvents(*td, e, artifacts)
+			}
+			if e, ok := event.(*github.WorkflowJobEvent); ok && hasUsage {
+				setJobBillingAttributes(*td, e.GetWorkflowJob(), usage, team)
+			}
+
 			// Pass the traces to the nextConsumer
 			tracesCtx := gar.obsrecv.StartTracesOp(ctx)
 			consumerErr := gar.tracesConsumer.ConsumeTraces(tracesCtx, *td)
@@ -351,7 +536,7 @@ func (gar *githubActionsReceiver) ServeHTTP(w http.ResponseWriter, r *http.Reque
 			gar.logger.Debug("Calling eventToLogs")
 			gar.logger.Debug("Event type being passed to eventToLogs", zap.String("event_type", fmt.Sprintf("%T", event)))
 
-			ld, err := eventToLogs(event, gar.config, gar.ghClient, gar.logger.Named("eventToLogs"), withTraceInfo)
+			ld, err := eventToLogs(gar.ctx, event, gar.config, gar.ghClient, gar.logPolicy, gar.logger.Named("eventToLogs"), withTraceInfo)
 			if err != nil {
 				gar.logger.Error("Failed to process logs", zap.Error(err))
 			}
@@ -366,6 +551,14 @@ func (gar *githubActionsReceiver) ServeHTTP(w http.ResponseWriter, r *http.Reque
 			}
 		}
 	}
+}
 
-	w.WriteHeader(http.StatusAccepted)
+func (gar *githubActionsReceiver) consumeMetrics(ctx context.Context, metrics pmetric.Metrics) {
+	metricsCtx := gar.obsrecv.StartMetricsOp(ctx)
+	err := gar.metricsConsumer.ConsumeMetrics(metricsCtx, metrics)
+	gar.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), metrics.DataPointCount(), err)
+
+	if err != nil {
+		gar.logger.Error("Failed to consume metrics", zap.Error(err))
+	}
 }
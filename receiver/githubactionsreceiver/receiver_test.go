@@ -9,6 +9,7 @@ import (
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"io"
@@ -22,6 +23,7 @@ import (
 	"time"
 
 	"github.com/google/go-github/v88/github"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
 	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
 	"github.com/grafana/grafana-ci-otel-collector/receiver/githubactionsreceiver/internal/metadata"
 	"github.com/stretchr/testify/require"
@@ -268,15 +270,15 @@ func TestProcessSteps(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.desc, func(t *testing.T) {
 			logger := zap.NewNop()
-			traces := ptrace.NewTraces()
-			rs := traces.ResourceSpans().AppendEmpty()
-			ss := rs.ScopeSpans().AppendEmpty()
-
-			traceID, _ := generateTraceID(123, 1)
-			parentSpanID := createParentSpan(ss, tc.givenSteps, &github.WorkflowJob{}, traceID, logger)
+			event := &github.WorkflowJobEvent{
+				WorkflowJob: &github.WorkflowJob{RunID: github.Ptr(int64(123)), RunAttempt: github.Ptr(int64(1)), Steps: tc.givenSteps},
+				Repo:        &github.Repository{DefaultBranch: getPtr("main")},
+			}
 
-			defaultBranch := "main"
-			processSteps(ss, tc.givenSteps, &github.WorkflowJob{}, &defaultBranch, traceID, parentSpanID, logger)
+			pipeline, err := workflowJobEventToPipeline(event, &Config{}, logger)
+			require.NoError(t, err)
+			traces := cimodel.ToTraces(&pipeline, cimodel.Options{})
+			ss := traces.ResourceSpans().At(0).ScopeSpans().At(0)
 
 			startIdx := 1 // Skip the parent span if it's the first one
 			if len(tc.expectedStatuses) == 0 {
@@ -796,6 +798,103 @@ func TestObsReportMetrics(t *testing.T) {
 	}, gotLogs, metricdatatest.IgnoreTimestamp(), metricdatatest.IgnoreExemplars())
 }
 
+func TestServeHTTPLookups(t *testing.T) {
+	testSecret := "testsecret123"
+	validSig := func(payload []byte) string {
+		mac := hmac.New(sha256.New, []byte(testSecret))
+		mac.Write(payload)
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := map[string]struct {
+		eventType   string
+		payloadPath string
+		configure   func(cfg *Config)
+		reported    func(span ptrace.Span) bool
+	}{
+		"artifacts": {
+			eventType:   "workflow_run",
+			payloadPath: "./testdata/completed/8_workflow_run_completed.json",
+			configure: func(cfg *Config) {
+				cfg.Storage.Artifacts = true
+			},
+			reported: func(span ptrace.Span) bool {
+				return span.Events().Len() == 1
+			},
+		},
+		"codeowners": {
+			eventType:   "workflow_job",
+			payloadPath: "./testdata/completed/5_workflow_job_completed.json",
+			configure: func(cfg *Config) {
+				cfg.Billing.Enabled = true
+				cfg.Billing.TeamSource = teamSourceCodeowners
+			},
+			reported: func(span ptrace.Span) bool {
+				team, ok := span.Attributes().Get("ci.github.team")
+				return ok && team.Str() == "platform"
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// GitHub API calls block until the webhook was answered
+			release := make(chan struct{})
+			ghTestServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-release
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case strings.HasSuffix(r.URL.Path, "/artifacts"):
+					_, _ = w.Write([]byte(`{"total_count": 1, "artifacts": [{"id": 1, "name": "dist", "size_in_bytes": 4096}]}`))
+				case strings.HasSuffix(r.URL.Path, "/contents/.github/CODEOWNERS"):
+					content := base64.StdEncoding.EncodeToString([]byte("* @foo/platform\n"))
+					_, _ = w.Write([]byte(`{"type": "file", "encoding": "base64", "content": "` + content + `"}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			t.Cleanup(ghTestServer.Close)
+
+			cfg := createDefaultConfig().(*Config)
+			cfg.Secret = testSecret
+			cfg.NetAddr.Endpoint = "localhost:0"
+			cfg.GitHubAPIConfig.BaseURL = ghTestServer.URL
+			cfg.GitHubAPIConfig.UploadURL = ghTestServer.URL
+			test.configure(cfg)
+
+			tracesSink := new(consumertest.TracesSink)
+			rcvr, err := newReceiver(receivertest.NewNopSettings(metadata.Type), cfg)
+			require.NoError(t, err)
+			rcvr.tracesConsumer = tracesSink
+			require.NoError(t, rcvr.Start(t.Context(), componenttest.NewNopHost()))
+
+			payload, err := os.ReadFile(test.payloadPath)
+			require.NoError(t, err)
+			req := httptest.NewRequest("POST", "/ghaevents", bytes.NewReader(payload))
+			req.Header.Set("X-GitHub-Event", test.eventType)
+			req.Header.Set("X-Hub-Signature-256", validSig(payload))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			rcvr.ServeHTTP(w, req)
+			require.Equal(t, http.StatusAccepted, w.Code)
+			require.Empty(t, tracesSink.AllTraces())
+
+			close(release)
+			require.Eventually(t, func() bool {
+				return len(tracesSink.AllTraces()) == 1
+			}, 5*time.Second, 10*time.Millisecond)
+			require.NoError(t, rcvr.Shutdown(t.Context()))
+
+			var reported bool
+			spans := tracesSink.AllTraces()[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+			for i := range spans.Len() {
+				reported = reported || test.reported(spans.At(i))
+			}
+			require.True(t, reported)
+		})
+	}
+}
+
 func TestBuildInfoPeriodicEmission(t *testing.T) {
 	settings := receiver.Settings{
 		ID:                component.MustNewID("githubactions"),
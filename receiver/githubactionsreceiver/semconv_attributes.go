@@ -0,0 +1,47 @@
+package githubactionsreceiver
+
+import (
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+// legacyJobAttributes are the job resource attributes replaced by the
+// semantic conventions. Attributes without an equivalent keep their names.
+var legacyJobAttributes = []string{
+	"ci.github.workflow.name",
+	"ci.github.workflow.job.conclusion",
+	"ci.github.workflow.job.head_branch",
+	"ci.github.workflow.job.head_sha",
+	"ci.github.workflow.job.html_url",
+	"ci.github.workflow.job.id",
+	"ci.github.workflow.job.name",
+	"ci.github.workflow.job.run_id",
+	"ci.github.workflow.job.runner.name",
+	"scm.git.repo",
+	"scm.git.repo.owner.login",
+}
+
+// legacyRunAttributes are the run resource attributes replaced by the
+// semantic conventions. Attributes without an equivalent keep their names.
+var legacyRunAttributes = []string{
+	"ci.github.workflow.run.conclusion",
+	"ci.github.workflow.run.head_branch",
+	"ci.github.workflow.run.head_sha",
+	"ci.github.workflow.run.html_url",
+	"ci.github.workflow.run.id",
+	"ci.github.workflow.run.name",
+	"scm.git.head_branch",
+	"scm.git.head_sha",
+	"scm.git.repo",
+}
+
+// removeLegacyAttributes removes the legacy attributes replaced by the
+// semantic conventions, unless configured to keep them. The semantic
+// conventions themselves are set by cimodel.
+func removeLegacyAttributes(attrs pcommon.Map, cfg semconv.Config, legacy []string) {
+	if !cfg.EmitsLegacy() {
+		for _, key := range legacy {
+			attrs.Remove(key)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package githubactionsreceiver
+
+import (
+	"testing"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+func TestPipelineResult(t *testing.T) {
+	tests := map[string]string{
+		"success":         "success",
+		"failure":         "failure",
+		"startup_failure": "error",
+		"timed_out":       "timeout",
+		"cancelled":       "cancellation",
+		"skipped":         "skip",
+		"neutral":         "",
+		"":                "",
+	}
+
+	for conclusion, expected := range tests {
+		t.Run(conclusion, func(t *testing.T) {
+			require.Equal(t, expected, string(pipelineResult(conclusion)))
+		})
+	}
+}
+
+func TestRunSpanStatus(t *testing.T) {
+	// Runs that timed out or failed to start are errors, like failed ones
+	tests := map[string]ptrace.StatusCode{
+		"success":         ptrace.StatusCodeOk,
+		"failure":         ptrace.StatusCodeError,
+		"startup_failure": ptrace.StatusCodeError,
+		"timed_out":       ptrace.StatusCodeError,
+		"cancelled":       ptrace.StatusCodeUnset,
+		"skipped":         ptrace.StatusCodeUnset,
+		"neutral":         ptrace.StatusCodeUnset,
+	}
+
+	for conclusion, expected := range tests {
+		t.Run(conclusion, func(t *testing.T) {
+			e := loadTestRunEvent(t)
+			e.WorkflowRun.Conclusion = &conclusion
+
+			traces, err := eventToTraces(e, createDefaultConfig().(*Config), zap.NewNop())
+			require.NoError(t, err)
+			status := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Status()
+			require.Equal(t, expected, status.Code())
+			require.Equal(t, conclusion, status.Message())
+		})
+	}
+}
+
+func TestJobSpanStatus(t *testing.T) {
+	// The result of jobs is their conclusion, whatever the outcome of their steps
+	tests := map[string]ptrace.StatusCode{
+		"success":   ptrace.StatusCodeOk,
+		"failure":   ptrace.StatusCodeError,
+		"timed_out": ptrace.StatusCodeError,
+		"cancelled": ptrace.StatusCodeUnset,
+	}
+
+	for conclusion, expected := range tests {
+		t.Run(conclusion, func(t *testing.T) {
+			e := loadTestJobEvent(t)
+			e.WorkflowJob.Conclusion = &conclusion
+
+			traces, err := eventToTraces(e, createDefaultConfig().(*Config), zap.NewNop())
+			require.NoError(t, err)
+			status := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Status()
+			require.Equal(t, expected, status.Code())
+			require.Equal(t, conclusion, status.Message())
+		})
+	}
+}
+
+func TestJobSemconvAttributes(t *testing.T) {
+	tests := map[string]struct {
+		semconv       semconv.Config
+		expectLegacy  bool
+		expectSemconv bool
+	}{
+		"legacy": {
+			expectLegacy: true,
+		},
+		"semconv": {
+			semconv:       semconv.Config{Enabled: true},
+			expectSemconv: true,
+		},
+		"semconv with legacy": {
+			semconv:       semconv.Config{Enabled: true, EmitLegacy: true},
+			expectLegacy:  true,
+			expectSemconv: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Semconv = test.semconv
+
+			traces, err := eventToTraces(loadTestJobEvent(t), cfg, zap.NewNop())
+			require.NoError(t, err)
+			resourceSpans := traces.ResourceSpans().At(0)
+			attrs := resourceSpans.Resource().Attributes().AsRaw()
+			// The job span comes first, followed by its steps
+			spanAttrs := resourceSpans.ScopeSpans().At(0).Spans().At(0).Attributes().AsRaw()
+
+			require.Equal(t, "foo-webhook-testing", attrs["service.name"])
+			require.Equal(t, "github", attrs["ci.system"])
+
+			_, ok := attrs["ci.github.workflow.job.name"]
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = attrs["scm.git.repo"]
+			require.Equal(t, test.expectLegacy, ok)
+
+			if !test.expectSemconv {
+				require.NotContains(t, attrs, semconv.AttributeVCSRepositoryName)
+				require.NotContains(t, spanAttrs, semconv.AttributeCICDPipelineTaskName)
+				return
+			}
+			require.Equal(t, "foo", attrs[semconv.AttributeVCSOwnerName])
+			require.Equal(t, "webhook-testing", attrs[semconv.AttributeVCSRepositoryName])
+			require.Equal(t, "github", attrs[semconv.AttributeVCSProviderName])
+			require.Equal(t, "Tests", spanAttrs[semconv.AttributeCICDPipelineName])
+			require.Equal(t, "17525183830", spanAttrs[semconv.AttributeCICDPipelineTaskRunID])
+			require.Equal(t, "success", spanAttrs[semconv.AttributeCICDPipelineTaskRunResult])
+			require.Equal(t, "branch", spanAttrs[semconv.AttributeVCSRefHeadType])
+		})
+	}
+}
+
+func TestRunSemconvAttributes(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.Semconv = semconv.Config{Enabled: true}
+
+	traces, err := eventToTraces(loadTestRunEvent(t), cfg, zap.NewNop())
+	require.NoError(t, err)
+	resourceSpans := traces.ResourceSpans().At(0)
+	attrs := resourceSpans.Resource().Attributes().AsRaw()
+	spanAttrs := resourceSpans.ScopeSpans().At(0).Spans().At(0).Attributes().AsRaw()
+
+	require.Equal(t, "webhook-testing", attrs[semconv.AttributeVCSRepositoryName])
+	for _, key := range legacyRunAttributes {
+		require.NotContains(t, attrs, key)
+	}
+	require.Contains(t, attrs, "ci.github.workflow.run.run_attempt")
+
+	require.Equal(t, "Tests", spanAttrs[semconv.AttributeCICDPipelineName])
+	require.Equal(t, "6454805877", spanAttrs[semconv.AttributeCICDPipelineRunID])
+	require.Equal(t, "success", spanAttrs[semconv.AttributeCICDPipelineResult])
+	require.Equal(t, "branch", spanAttrs[semconv.AttributeVCSRefHeadType])
+}
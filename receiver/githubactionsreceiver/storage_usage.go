@@ -0,0 +1,224 @@
+package githubactionsreceiver
+
+import (
+	"container/heap"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v88/github"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+const (
+	// storageMaxRepos bounds the number of repositories whose caches are listed
+	storageMaxRepos = 10000
+	// storageMaxRuns bounds the number of runs whose artifacts are remembered
+	storageMaxRuns = 10000
+)
+
+// artifactWorkflow is the workflow of a repository whose artifacts are counted
+type artifactWorkflow struct {
+	repo, workflow string
+}
+
+// runArtifacts are the artifacts retained by the latest attempt of a run of a
+// workflow
+type runArtifacts struct {
+	workflow    artifactWorkflow
+	count, size int64
+	// removed is set once the run is evicted or replaced by a later attempt
+	removed bool
+}
+
+// artifactTotals are the artifacts retained by the remembered runs of a
+// workflow, kept up to date as runs are added, replaced and evicted
+type artifactTotals struct {
+	runs        int
+	count, size int64
+	// expiring are the counted artifacts that expire, earliest first
+	expiring artifactExpiries
+}
+
+// artifactExpiry is a counted artifact of a run that expires
+type artifactExpiry struct {
+	run       *runArtifacts
+	size      int64
+	expiresAt time.Time
+}
+
+// artifactExpiries is a heap of artifacts ordered by expiry
+type artifactExpiries []artifactExpiry
+
+func (h artifactExpiries) Len() int           { return len(h) }
+func (h artifactExpiries) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h artifactExpiries) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *artifactExpiries) Push(x any)        { *h = append(*h, x.(artifactExpiry)) }
+func (h *artifactExpiries) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// listWorkflowRunArtifacts returns the artifacts uploaded by the run attempt
+// of the event. Errors are logged and yield the artifacts listed so far.
+func listWorkflowRunArtifacts(ctx context.Context, ghClient *github.Client, e *github.WorkflowRunEvent, logger *zap.Logger) []*github.Artifact {
+	var artifacts []*github.Artifact
+	opts := &github.ListOptions{PerPage: 100}
+	for artifact, err := range ghClient.Actions.ListWorkflowRunArtifactsIter(ctx, e.GetRepo().GetOwner().GetLogin(), e.GetRepo().GetName(), e.GetWorkflowRun().GetID(), opts) {
+		if err != nil {
+			logger.Warn("Failed to list workflow run artifacts", zap.Error(err))
+			break
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts
+}
+
+// appendArtifactEvents adds an event per artifact to the root span of the run.
+func appendArtifactEvents(traces ptrace.Traces, e *github.WorkflowRunEvent, artifacts []*github.Artifact) {
+	rootSpanID, err := generateParentSpanID(e.GetWorkflowRun().GetID(), e.GetWorkflowRun().GetRunAttempt())
+	if err != nil {
+		return
+	}
+
+	span, ok := findSpan(traces, rootSpanID)
+	if !ok {
+		return
+	}
+
+	for _, artifact := range artifacts {
+		event := span.Events().AppendEmpty()
+		event.SetName("artifact")
+		event.SetTimestamp(pcommon.NewTimestampFromTime(artifact.GetCreatedAt().Time))
+		event.Attributes().PutInt("ci.github.workflow.run.artifact.id", artifact.GetID())
+		event.Attributes().PutStr("ci.github.workflow.run.artifact.name", artifact.GetName())
+		event.Attributes().PutInt("ci.github.workflow.run.artifact.size", artifact.GetSizeInBytes())
+		if expiresAt := artifact.GetExpiresAt(); !expiresAt.IsZero() {
+			event.Attributes().PutStr("ci.github.workflow.run.artifact.expires_at", expiresAt.Format(time.RFC3339))
+		}
+	}
+}
+
+// artifactMetrics records the number and total size of the artifacts retained
+// by the completed runs of the workflow of a run, for its repository. Artifacts
+// are retained until they expire, the artifacts of a run replacing those of its
+// earlier attempts.
+func (m *metricsHandler) artifactMetrics(e *github.WorkflowRunEvent, artifacts []*github.Artifact) pmetric.Metrics {
+	now := time.Now()
+	workflow := artifactWorkflow{repo: e.GetRepo().GetFullName(), workflow: e.GetWorkflowRun().GetName()}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	totals := m.addRunArtifacts(e.GetWorkflowRun().GetID(), workflow, artifacts, now)
+	totals.expire(now)
+
+	ts := pcommon.NewTimestampFromTime(now)
+	m.mb.RecordWorkflowArtifactsCountDataPoint(ts, totals.count, workflow.repo, workflow.workflow)
+	m.mb.RecordWorkflowArtifactsSizeDataPoint(ts, totals.size, workflow.repo, workflow.workflow)
+	return m.mb.Emit()
+}
+
+// addRunArtifacts counts the unexpired artifacts of a run attempt in the totals
+// of its workflow, in place of those of its earlier attempts. Called under m.mu.
+func (m *metricsHandler) addRunArtifacts(id int64, workflow artifactWorkflow, artifacts []*github.Artifact, now time.Time) *artifactTotals {
+	// Updating a run doesn't evict it
+	if prev, ok := m.artifactRuns.Peek(id); ok {
+		m.removeRunArtifacts(id, prev)
+	}
+
+	totals, ok := m.artifactTotals[workflow]
+	if !ok {
+		totals = &artifactTotals{}
+		m.artifactTotals[workflow] = totals
+	}
+
+	run := &runArtifacts{workflow: workflow}
+	for _, artifact := range artifacts {
+		expiresAt := artifact.GetExpiresAt().Time
+		if artifact.GetExpired() || (!expiresAt.IsZero() && expiresAt.Before(now)) {
+			continue
+		}
+		run.count++
+		run.size += artifact.GetSizeInBytes()
+		if !expiresAt.IsZero() {
+			heap.Push(&totals.expiring, artifactExpiry{run: run, size: artifact.GetSizeInBytes(), expiresAt: expiresAt})
+		}
+	}
+	totals.runs++
+	totals.count += run.count
+	totals.size += run.size
+
+	// May evict the least recently completed run, through removeRunArtifacts
+	m.artifactRuns.Add(id, run)
+	return totals
+}
+
+// removeRunArtifacts stops counting the artifacts of a run, evicted or replaced
+// by a later attempt. Called under m.mu.
+func (m *metricsHandler) removeRunArtifacts(_ int64, run *runArtifacts) {
+	run.removed = true
+	totals := m.artifactTotals[run.workflow]
+	totals.runs--
+	totals.count -= run.count
+	totals.size -= run.size
+	if totals.runs == 0 {
+		delete(m.artifactTotals, run.workflow)
+	}
+}
+
+// expire stops counting the artifacts that expired by now.
+func (t *artifactTotals) expire(now time.Time) {
+	for t.expiring.Len() > 0 && t.expiring[0].expiresAt.Before(now) {
+		expiry := heap.Pop(&t.expiring).(artifactExpiry)
+		if expiry.run.removed {
+			continue
+		}
+		expiry.run.count--
+		expiry.run.size -= expiry.size
+		t.count--
+		t.size -= expiry.size
+	}
+}
+
+// cacheUsageMetrics records the number and total size of the active caches of
+// the given repositories.
+func (m *metricsHandler) cacheUsageMetrics(usages []*github.ActionsCacheUsage) pmetric.Metrics {
+	now := pcommon.NewTimestampFromTime(time.Now())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, usage := range usages {
+		m.mb.RecordRepositoryCachesCountDataPoint(now, int64(usage.ActiveCachesCount), usage.FullName)
+		m.mb.RecordRepositoryCachesSizeDataPoint(now, usage.ActiveCachesSizeInBytes, usage.FullName)
+	}
+	return m.mb.Emit()
+}
+
+// listCacheUsage returns the cache usage of the given repositories, skipping
+// those whose usage cannot be retrieved.
+func listCacheUsage(ctx context.Context, ghClient *github.Client, repos []string, logger *zap.Logger) []*github.ActionsCacheUsage {
+	usages := make([]*github.ActionsCacheUsage, 0, len(repos))
+	for _, repo := range repos {
+		owner, name, ok := strings.Cut(repo, "/")
+		if !ok {
+			continue
+		}
+
+		usage, _, err := ghClient.Actions.GetCacheUsageForRepo(ctx, owner, name)
+		if err != nil {
+			logger.Warn("Failed to get repository cache usage", zap.String("repo", repo), zap.Error(err))
+			continue
+		}
+		if usage.FullName == "" {
+			usage.FullName = repo
+		}
+		usages = append(usages, usage)
+	}
+	return usages
+}
@@ -0,0 +1,179 @@
+package githubactionsreceiver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v88/github"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap"
+)
+
+func newStorageTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ghTestServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v3/repos/foo/webhook-testing/actions/runs/6454805877/artifacts":
+			_, _ = w.Write([]byte(`{"total_count": 2, "artifacts": [
+				{"id": 1, "name": "coverage", "size_in_bytes": 1024, "created_at": "2023-10-13T10:12:00Z", "expires_at": "2099-01-11T10:12:00Z"},
+				{"id": 2, "name": "dist", "size_in_bytes": 4096, "created_at": "2023-10-13T10:13:00Z"}
+			]}`))
+		case "/api/v3/repos/foo/webhook-testing/actions/cache/usage":
+			_, _ = w.Write([]byte(`{"full_name": "foo/webhook-testing", "active_caches_size_in_bytes": 2048, "active_caches_count": 3}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ghTestServer.Close)
+	return ghTestServer
+}
+
+func loadTestRunEvent(t *testing.T) *github.WorkflowRunEvent {
+	t.Helper()
+	payload, err := os.ReadFile("./testdata/completed/8_workflow_run_completed.json")
+	require.NoError(t, err)
+
+	event, err := github.ParseWebHook("workflow_run", payload)
+	require.NoError(t, err)
+	return event.(*github.WorkflowRunEvent)
+}
+
+func TestWorkflowRunArtifacts(t *testing.T) {
+	ghTestServer := newStorageTestServer(t)
+	cfg := createDefaultConfig().(*Config)
+	cfg.GitHubAPIConfig.BaseURL = ghTestServer.URL
+	cfg.GitHubAPIConfig.UploadURL = ghTestServer.URL
+	e := loadTestRunEvent(t)
+
+	artifacts := listWorkflowRunArtifacts(t.Context(), setupTestGitHubClient(cfg), e, zap.NewNop())
+	require.Len(t, artifacts, 2)
+
+	t.Run("span events", func(t *testing.T) {
+		traces, err := eventToTraces(e, cfg, zap.NewNop())
+		require.NoError(t, err)
+		appendArtifactEvents(*traces, e, artifacts)
+
+		rootSpanID, err := generateParentSpanID(e.GetWorkflowRun().GetID(), e.GetWorkflowRun().GetRunAttempt())
+		require.NoError(t, err)
+
+		var found bool
+		scopeSpans := traces.ResourceSpans().At(0).ScopeSpans()
+		for i := range scopeSpans.Len() {
+			spans := scopeSpans.At(i).Spans()
+			for j := range spans.Len() {
+				span := spans.At(j)
+				if span.SpanID() != rootSpanID {
+					continue
+				}
+				found = true
+				require.Equal(t, 2, span.Events().Len())
+				require.Equal(t, map[string]any{
+					"ci.github.workflow.run.artifact.id":         int64(1),
+					"ci.github.workflow.run.artifact.name":       "coverage",
+					"ci.github.workflow.run.artifact.size":       int64(1024),
+					"ci.github.workflow.run.artifact.expires_at": "2099-01-11T10:12:00Z",
+				}, span.Events().At(0).Attributes().AsRaw())
+				require.Equal(t, "dist", span.Events().At(1).Attributes().AsRaw()["ci.github.workflow.run.artifact.name"])
+			}
+		}
+		require.True(t, found, "root span not found")
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		mh := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zap.NewNop())
+		artifactValues := func(metrics pmetric.Metrics) map[string]int64 {
+			values := make(map[string]int64)
+			ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+			for i := range ms.Len() {
+				dp := ms.At(i).Gauge().DataPoints().At(0)
+				require.Equal(t, map[string]any{
+					"vcs.repository.name":     "foo/webhook-testing",
+					"ci.github.workflow.name": "Tests",
+				}, dp.Attributes().AsRaw())
+				values[ms.At(i).Name()] = dp.IntValue()
+			}
+			return values
+		}
+
+		require.Equal(t, map[string]int64{
+			"workflow.artifacts.count": 2,
+			"workflow.artifacts.size":  5120,
+		}, artifactValues(mh.artifactMetrics(e, artifacts)))
+
+		// Artifacts of the other runs of the workflow are retained until they expire
+		other := loadTestRunEvent(t)
+		other.WorkflowRun.ID = github.Ptr(int64(6454805878))
+		expired := github.Timestamp{Time: time.Now().Add(-time.Hour)}
+		otherArtifacts := []*github.Artifact{
+			{ID: github.Ptr(int64(3)), SizeInBytes: github.Ptr(int64(512))},
+			{ID: github.Ptr(int64(4)), SizeInBytes: github.Ptr(int64(256)), ExpiresAt: &expired},
+		}
+		require.Equal(t, map[string]int64{
+			"workflow.artifacts.count": 3,
+			"workflow.artifacts.size":  5632,
+		}, artifactValues(mh.artifactMetrics(other, otherArtifacts)))
+
+		// Re-runs replace the artifacts of their earlier attempts
+		require.Equal(t, map[string]int64{
+			"workflow.artifacts.count": 3,
+			"workflow.artifacts.size":  5632,
+		}, artifactValues(mh.artifactMetrics(e, artifacts)))
+	})
+
+	t.Run("totals", func(t *testing.T) {
+		mh := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zap.NewNop())
+		now := time.Now()
+		workflow := artifactWorkflow{repo: "foo/webhook-testing", workflow: "Tests"}
+		expiresAt := github.Timestamp{Time: now.Add(time.Hour)}
+
+		mh.addRunArtifacts(1, workflow, []*github.Artifact{{SizeInBytes: github.Ptr(int64(1024)), ExpiresAt: &expiresAt}}, now)
+		totals := mh.addRunArtifacts(2, workflow, []*github.Artifact{{SizeInBytes: github.Ptr(int64(512))}}, now)
+		require.Equal(t, int64(2), totals.count)
+		require.Equal(t, int64(1536), totals.size)
+
+		// Expired artifacts are no longer counted
+		totals.expire(now.Add(2 * time.Hour))
+		require.Equal(t, int64(1), totals.count)
+		require.Equal(t, int64(512), totals.size)
+
+		// Nor are those of evicted runs
+		mh.artifactRuns.Remove(2)
+		require.Equal(t, int64(0), totals.count)
+		require.Equal(t, int64(0), totals.size)
+
+		// Totals are forgotten with the last run of their workflow
+		mh.artifactRuns.Remove(1)
+		require.NotContains(t, mh.artifactTotals, workflow)
+	})
+}
+
+func TestCacheUsageMetrics(t *testing.T) {
+	ghTestServer := newStorageTestServer(t)
+	cfg := createDefaultConfig().(*Config)
+	cfg.GitHubAPIConfig.BaseURL = ghTestServer.URL
+	cfg.GitHubAPIConfig.UploadURL = ghTestServer.URL
+
+	usages := listCacheUsage(t.Context(), setupTestGitHubClient(cfg), []string{"foo/webhook-testing", "foo/missing", "invalid"}, zap.NewNop())
+	require.Len(t, usages, 1)
+
+	mh := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zap.NewNop())
+	metrics := mh.cacheUsageMetrics(usages)
+
+	values := make(map[string]int64)
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := range ms.Len() {
+		dp := ms.At(i).Gauge().DataPoints().At(0)
+		require.Equal(t, "foo/webhook-testing", dp.Attributes().AsRaw()["vcs.repository.name"])
+		values[ms.At(i).Name()] = dp.IntValue()
+	}
+	require.Equal(t, map[string]int64{
+		"repository.caches.count": 3,
+		"repository.caches.size":  2048,
+	}, values)
+}
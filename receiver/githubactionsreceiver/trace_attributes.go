@@ -62,6 +62,8 @@ func createResourceAttributes(resource pcommon.Resource, event interface{}, conf
 		attrs.PutStr("scm.git.repo.owner.login", e.GetRepo().GetOwner().GetLogin())
 		attrs.PutStr("scm.git.repo", e.GetRepo().GetFullName())
 
+		removeLegacyAttributes(attrs, config.Semconv, legacyJobAttributes)
+
 	case *github.WorkflowRunEvent:
 		setWorkflowRunEventAttributes(attrs, e, config)
 
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/google/go-github/v88/github"
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.uber.org/zap"
@@ -18,98 +19,108 @@ import (
 
 func eventToTraces(event interface{}, config *Config, logger *zap.Logger) (*ptrace.Traces, error) {
 	logger.Debug("Determining event")
-	traces := ptrace.NewTraces()
-	resourceSpans := traces.ResourceSpans().AppendEmpty()
-	scopeSpans := resourceSpans.ScopeSpans().AppendEmpty()
 
+	var pipeline cimodel.Pipeline
+	var err error
 	switch e := event.(type) {
 	case *github.WorkflowJobEvent:
 		logger.Debug("Processing WorkflowJobEvent", zap.Int64("job_id", e.WorkflowJob.GetID()), zap.String("job_name", e.GetWorkflowJob().GetName()), zap.String("repo", e.GetRepo().GetFullName()))
-		jobResource := resourceSpans.Resource()
-		createResourceAttributes(jobResource, e, config, logger)
-
-		traceID, err := generateTraceID(e.GetWorkflowJob().GetRunID(), int(e.GetWorkflowJob().GetRunAttempt()))
-		if err != nil {
-			logger.Error("Failed to generate trace ID", zap.Error(err))
-			return nil, fmt.Errorf("failed to generate trace ID: %w", err)
-		}
-
-		defaultBranch := e.GetRepo().DefaultBranch
-
-		parentSpanID := createParentSpan(scopeSpans, e.GetWorkflowJob().Steps, e.GetWorkflowJob(), traceID, logger)
-		processSteps(scopeSpans, e.GetWorkflowJob().Steps, e.GetWorkflowJob(), defaultBranch, traceID, parentSpanID, logger)
+		pipeline, err = workflowJobEventToPipeline(e, config, logger)
 
 	case *github.WorkflowRunEvent:
 		logger.Debug("Processing WorkflowRunEvent", zap.Int64("workflow_id", e.GetWorkflowRun().GetID()), zap.String("workflow_name", e.GetWorkflowRun().GetName()), zap.String("repo", e.GetRepo().GetFullName()))
-		runResource := resourceSpans.Resource()
-
-		traceID, err := generateTraceID(e.GetWorkflowRun().GetID(), e.GetWorkflowRun().GetRunAttempt())
-		if err != nil {
-			logger.Error("Failed to generate trace ID", zap.Error(err))
-			return nil, fmt.Errorf("failed to generate trace ID: %w", err)
-		}
-
-		createResourceAttributes(runResource, e, config, logger)
-		// nolint:errcheck
-		createRootSpan(resourceSpans, e, traceID, logger)
+		pipeline, err = workflowRunEventToPipeline(e, config, logger)
 
 	default:
 		logger.Error("unknown event type, dropping payload")
 		return nil, fmt.Errorf("unknown event type")
 	}
+	if err != nil {
+		return nil, err
+	}
 
+	traces := cimodel.ToTraces(&pipeline, cimodel.Options{Semconv: config.Semconv})
 	return &traces, nil
 }
 
-func createParentSpan(scopeSpans ptrace.ScopeSpans, steps []*github.TaskStep, job *github.WorkflowJob, traceID pcommon.TraceID, logger *zap.Logger) pcommon.SpanID {
-	logger.Debug("Creating parent span", zap.String("name", job.GetName()))
-	span := scopeSpans.Spans().AppendEmpty()
-	span.SetTraceID(traceID)
+// workflowJobEventToPipeline maps a job and its steps to the CI model, with
+// the deterministic IDs of the job and step spans.
+func workflowJobEventToPipeline(e *github.WorkflowJobEvent, config *Config, logger *zap.Logger) (cimodel.Pipeline, error) {
+	job := e.GetWorkflowJob()
+
+	traceID, err := generateTraceID(job.GetRunID(), int(job.GetRunAttempt()))
+	if err != nil {
+		logger.Error("Failed to generate trace ID", zap.Error(err))
+		return cimodel.Pipeline{}, fmt.Errorf("failed to generate trace ID: %w", err)
+	}
 
-	parentSpanID, _ := generateParentSpanID(job.GetRunID(), int(job.GetRunAttempt()))
-	span.SetParentSpanID(parentSpanID)
+	pipeline := workflowJobPipeline(e)
+	pipeline.TraceID = traceID
+	pipeline.SpanID, _ = generateParentSpanID(job.GetRunID(), int(job.GetRunAttempt()))
 
-	jobSpanID, _ := generateJobSpanID(job.GetRunID(), int(job.GetRunAttempt()), job.GetName())
+	resource := pcommon.NewResource()
+	createResourceAttributes(resource, e, config, logger)
+	pipeline.ResourceAttributes = resource.Attributes().AsRaw()
+
+	task := &pipeline.Tasks[0]
+	task.SpanID, _ = generateJobSpanID(job.GetRunID(), int(job.GetRunAttempt()), job.GetName())
 	logger.Debug("Generated Job Span ID",
 		zap.Int64("RunID", job.GetRunID()),
 		zap.Int("RunAttempt", int(job.GetRunAttempt())),
 		zap.String("JobName", job.GetName()),
-		zap.String("SpanID", jobSpanID.String()),
+		zap.String("SpanID", task.SpanID.String()),
 	)
-	span.SetSpanID(jobSpanID)
 
-	span.SetName(job.GetName())
-	span.SetKind(ptrace.SpanKindServer)
+	// The job span covers its steps
+	steps := job.Steps
 	if len(steps) > 0 {
-		setSpanTimes(span, steps[0].GetStartedAt().Time, steps[len(steps)-1].GetCompletedAt().Time)
+		task.Started = steps[0].GetStartedAt().Time
+		task.Finished = steps[len(steps)-1].GetCompletedAt().Time
 	} else {
 		logger.Warn("No steps found, defaulting to job times")
-		setSpanTimes(span, job.GetStartedAt().Time, job.GetCompletedAt().Time)
 	}
 
-	allSuccessful := true
-	anyFailure := false
-	for _, step := range steps {
-		if step.GetStatus() != "completed" || step.GetConclusion() != "success" {
-			allSuccessful = false
-		}
-		if step.GetConclusion() == "failure" {
-			anyFailure = true
-			break
-		}
+	isMain := false
+	if defaultBranch := e.GetRepo().DefaultBranch; defaultBranch != nil {
+		isMain = job.GetHeadBranch() == *defaultBranch
+	} else {
+		logger.Debug("Default branch is nil, setting is_main to false")
 	}
 
-	if anyFailure {
-		span.Status().SetCode(ptrace.StatusCodeError)
-	} else if allSuccessful {
-		span.Status().SetCode(ptrace.StatusCodeOk)
-	} else {
-		span.Status().SetCode(ptrace.StatusCodeUnset)
+	for _, step := range steps {
+		task.Steps = append(task.Steps, workflowStepToStep(step, job, isMain, logger))
 	}
 
-	span.Status().SetMessage(job.GetConclusion())
+	return pipeline, nil
+}
+
+func workflowStepToStep(step *github.TaskStep, job *github.WorkflowJob, isMain bool, logger *zap.Logger) cimodel.Step {
+	logger.Debug("Processing span", zap.String("step_name", step.GetName()))
+	spanID, _ := generateStepSpanID(job.GetRunID(), int(job.GetRunAttempt()), job.GetName(), step.GetNumber())
+
+	// Set completed_at to same as started_at if ""
+	// GitHub emits zero values sometimes
+	if step.GetCompletedAt().IsZero() {
+		step.CompletedAt = step.StartedAt
+	}
 
-	return span.SpanID()
+	return cimodel.Step{
+		Name:     step.GetName(),
+		Result:   pipelineResult(step.GetConclusion()),
+		Status:   step.GetConclusion(),
+		Started:  step.GetStartedAt().Time,
+		Finished: step.GetCompletedAt().Time,
+		SpanID:   spanID,
+		Attributes: map[string]any{
+			"ci.github.workflow.job.head_branch.is_main": isMain,
+			"ci.github.workflow.job.step.name":           step.GetName(),
+			"ci.github.workflow.job.step.status":         step.GetStatus(),
+			"ci.github.workflow.job.step.conclusion":     step.GetConclusion(),
+			"ci.github.workflow.job.step.number":         step.GetNumber(),
+			"ci.github.workflow.job.step.started_at":     step.GetStartedAt().Format(time.RFC3339),
+			"ci.github.workflow.job.step.completed_at":   step.GetCompletedAt().Format(time.RFC3339),
+		},
+	}
 }
 
 func convertPRURL(apiURL string) string {
@@ -118,101 +129,64 @@ func convertPRURL(apiURL string) string {
 	return strings.Replace(apiURL, "api.", "", 1)
 }
 
-func createRootSpan(resourceSpans ptrace.ResourceSpans, event *github.WorkflowRunEvent, traceID pcommon.TraceID, logger *zap.Logger) (pcommon.SpanID, error) {
-	logger.Debug("Creating root parent span", zap.String("name", event.GetWorkflowRun().GetName()))
-	scopeSpans := resourceSpans.ScopeSpans().AppendEmpty()
-	span := scopeSpans.Spans().AppendEmpty()
+// workflowRunEventToPipeline maps a run to the CI model, with the
+// deterministic IDs of its root span.
+func workflowRunEventToPipeline(e *github.WorkflowRunEvent, config *Config, logger *zap.Logger) (cimodel.Pipeline, error) {
+	run := e.GetWorkflowRun()
 
-	rootSpanID, err := generateParentSpanID(event.GetWorkflowRun().GetID(), event.GetWorkflowRun().GetRunAttempt())
+	traceID, err := generateTraceID(run.GetID(), run.GetRunAttempt())
 	if err != nil {
-		logger.Error("Failed to generate root span ID", zap.Error(err))
-		return pcommon.SpanID{}, fmt.Errorf("failed to generate root span ID: %w", err)
+		logger.Error("Failed to generate trace ID", zap.Error(err))
+		return cimodel.Pipeline{}, fmt.Errorf("failed to generate trace ID: %w", err)
 	}
 
-	defaultBranch := event.GetRepo().GetDefaultBranch()
-
-	span.Attributes().PutBool("ci.github.workflow.run.head_branch.is_main", event.GetWorkflowRun().GetHeadBranch() == defaultBranch)
-
-	span.SetTraceID(traceID)
-	span.SetSpanID(rootSpanID)
-	span.SetName(event.GetWorkflowRun().GetName())
-	span.SetKind(ptrace.SpanKindServer)
-	setSpanTimes(span, event.GetWorkflowRun().GetRunStartedAt().Time, event.GetWorkflowRun().GetUpdatedAt().Time)
+	logger.Debug("Creating root parent span", zap.String("name", run.GetName()))
+	rootSpanID, err := generateParentSpanID(run.GetID(), run.GetRunAttempt())
+	if err != nil {
+		logger.Error("Failed to generate root span ID", zap.Error(err))
+		return cimodel.Pipeline{}, fmt.Errorf("failed to generate root span ID: %w", err)
+	}
 
-	switch event.WorkflowRun.GetConclusion() {
-	case "success":
-		span.Status().SetCode(ptrace.StatusCodeOk)
-	case "failure":
-		span.Status().SetCode(ptrace.StatusCodeError)
-	default:
-		span.Status().SetCode(ptrace.StatusCodeUnset)
+	pipeline := workflowRunPipeline(e)
+	pipeline.TraceID = traceID
+	pipeline.SpanID = rootSpanID
+	pipeline.Attributes = map[string]any{
+		"ci.github.workflow.run.head_branch.is_main": run.GetHeadBranch() == e.GetRepo().GetDefaultBranch(),
 	}
 
-	span.Status().SetMessage(event.GetWorkflowRun().GetConclusion())
+	resource := pcommon.NewResource()
+	createResourceAttributes(resource, e, config, logger)
+	pipeline.ResourceAttributes = resource.Attributes().AsRaw()
 
 	// Attempt to link to previous trace ID if applicable
-	if event.GetWorkflowRun().GetPreviousAttemptURL() != "" && event.GetWorkflowRun().GetRunAttempt() > 1 {
+	if run.GetPreviousAttemptURL() != "" && run.GetRunAttempt() > 1 {
 		logger.Debug("Linking to previous trace ID for WorkflowRunEvent")
-		previousRunAttempt := event.GetWorkflowRun().GetRunAttempt() - 1
-		previousTraceID, err := generateTraceID(event.GetWorkflowRun().GetID(), previousRunAttempt)
+		previousTraceID, err := generateTraceID(run.GetID(), run.GetRunAttempt()-1)
 		if err != nil {
 			logger.Error("Failed to generate previous trace ID", zap.Error(err))
 		} else {
-			link := span.Links().AppendEmpty()
-			link.SetTraceID(previousTraceID)
+			pipeline.Links = append(pipeline.Links, previousTraceID)
 			logger.Debug("Successfully linked to previous trace ID", zap.String("previousTraceID", previousTraceID.String()))
 		}
 	}
 
-	return rootSpanID, nil
+	return pipeline, nil
 }
 
-func createSpan(scopeSpans ptrace.ScopeSpans, step *github.TaskStep, job *github.WorkflowJob, defaultBranch *string, traceID pcommon.TraceID, parentSpanID pcommon.SpanID, logger *zap.Logger) pcommon.SpanID {
-	logger.Debug("Processing span", zap.String("step_name", step.GetName()))
-	span := scopeSpans.Spans().AppendEmpty()
-	span.SetTraceID(traceID)
-	span.SetParentSpanID(parentSpanID)
-
-	var spanID pcommon.SpanID
-	if defaultBranch != nil {
-		span.Attributes().PutBool("ci.github.workflow.job.head_branch.is_main", job.GetHeadBranch() == *defaultBranch)
-	} else {
-		logger.Debug("Default branch is nil, setting is_main to false")
-		span.Attributes().PutBool("ci.github.workflow.job.head_branch.is_main", false)
-	}
-
-	span.Attributes().PutStr("ci.github.workflow.job.step.name", step.GetName())
-	span.Attributes().PutStr("ci.github.workflow.job.step.status", step.GetStatus())
-	span.Attributes().PutStr("ci.github.workflow.job.step.conclusion", step.GetConclusion())
-	span.Attributes().PutInt("ci.github.workflow.job.step.number", step.GetNumber())
-
-	spanID, _ = generateStepSpanID(job.GetRunID(), int(job.GetRunAttempt()), job.GetName(), step.GetNumber())
-	span.SetSpanID(spanID)
-
-	// Set completed_at to same as started_at if ""
-	// GitHub emits zero values sometimes
-	if step.GetCompletedAt().IsZero() {
-		step.CompletedAt = step.StartedAt
-	}
-	span.Attributes().PutStr("ci.github.workflow.job.step.started_at", step.GetStartedAt().Format(time.RFC3339))
-	span.Attributes().PutStr("ci.github.workflow.job.step.completed_at", step.GetCompletedAt().Format(time.RFC3339))
-	setSpanTimes(span, step.GetStartedAt().Time, step.GetCompletedAt().Time)
-
-	span.SetName(step.GetName())
-	span.SetKind(ptrace.SpanKindServer)
-
-	switch step.GetConclusion() {
-	case "success":
-		span.Status().SetCode(ptrace.StatusCodeOk)
-	case "failure":
-		span.Status().SetCode(ptrace.StatusCodeError)
-	default:
-		span.Status().SetCode(ptrace.StatusCodeUnset)
+// findSpan returns the span with the given ID.
+func findSpan(traces ptrace.Traces, spanID pcommon.SpanID) (ptrace.Span, bool) {
+	for i := range traces.ResourceSpans().Len() {
+		scopeSpans := traces.ResourceSpans().At(i).ScopeSpans()
+		for j := range scopeSpans.Len() {
+			spans := scopeSpans.At(j).Spans()
+			for k := range spans.Len() {
+				if spans.At(k).SpanID() == spanID {
+					return spans.At(k), true
+				}
+			}
+		}
 	}
-
-	span.Status().SetMessage(step.GetConclusion())
-
-	return span.SpanID()
+	return ptrace.Span{}, false
 }
 
 func generateTraceID(runID int64, runAttempt int) (pcommon.TraceID, error) {
@@ -279,17 +253,6 @@ func generateStepSpanID(runID int64, runAttempt int, jobName string, stepNumber
 	return spanID, nil
 }
 
-func processSteps(scopeSpans ptrace.ScopeSpans, steps []*github.TaskStep, job *github.WorkflowJob, defaultBranch *string, traceID pcommon.TraceID, parentSpanID pcommon.SpanID, logger *zap.Logger) {
-	for _, step := range steps {
-		createSpan(scopeSpans, step, job, defaultBranch, traceID, parentSpanID, logger)
-	}
-}
-
-func setSpanTimes(span ptrace.Span, start, end time.Time) {
-	span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
-	span.SetEndTimestamp(pcommon.NewTimestampFromTime(end))
-}
-
 func transformGitHubAPIURL(apiURL string) string {
 	htmlURL := strings.Replace(apiURL, "api.github.com/repos", "github.com", 1)
 	return htmlURL
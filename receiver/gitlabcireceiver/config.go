@@ -0,0 +1,121 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlabcireceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/gitlabcireceiver"
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/gitlabcireceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.uber.org/multierr"
+)
+
+var errMissingEndpointFromConfig = errors.New("missing receiver server endpoint from config")
+var errBaseURL = errors.New("gitlab_api base_url must be an absolute URL")
+
+// GitLabAPIConfig defines configuration for the GitLab API
+type GitLabAPIConfig struct {
+	BaseURL string `mapstructure:"base_url"` // gitlab self-managed instance url. Default is empty, using gitlab.com
+	Token   string `mapstructure:"token"`    // gitlab token with the read_api scope, used to fetch job logs. Default is empty
+}
+
+// LogRetrievalConfig bounds the retrieval of job logs from the API, which
+// happens in the background of the webhooks of finished jobs
+type LogRetrievalConfig struct {
+	Timeout   time.Duration `mapstructure:"timeout"`    // bounds the download of a job log. Default is 30s
+	QueueSize int           `mapstructure:"queue_size"` // finished jobs waiting for their logs, more are rejected. Default is 100
+	MaxBytes  int64         `mapstructure:"max_bytes"`  // bytes of a job log read, the rest is dropped. Default is 64MiB
+}
+
+const (
+	defaultLogTimeout   = 30 * time.Second
+	defaultLogQueueSize = 100
+	defaultLogMaxBytes  = 64 << 20
+)
+
+// timeout returns the bound of the download of a job log
+func (cfg LogRetrievalConfig) timeout() time.Duration {
+	if cfg.Timeout == 0 {
+		return defaultLogTimeout
+	}
+	return cfg.Timeout
+}
+
+// queueSize returns the number of jobs waiting for their logs
+func (cfg LogRetrievalConfig) queueSize() int {
+	if cfg.QueueSize == 0 {
+		return defaultLogQueueSize
+	}
+	return cfg.QueueSize
+}
+
+// maxBytes returns the number of bytes of a job log read
+func (cfg LogRetrievalConfig) maxBytes() int64 {
+	if cfg.MaxBytes == 0 {
+		return defaultLogMaxBytes
+	}
+	return cfg.MaxBytes
+}
+
+// Validate checks the bounds of log retrieval. Zero values are defaults.
+func (cfg LogRetrievalConfig) Validate() error {
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if cfg.QueueSize < 0 {
+		return fmt.Errorf("queue_size must not be negative")
+	}
+	if cfg.MaxBytes < 0 {
+		return fmt.Errorf("max_bytes must not be negative")
+	}
+	return nil
+}
+
+// Config defines configuration for GitLab CI receiver
+type Config struct {
+	metadata.MetricsBuilderConfig `mapstructure:",squash"`
+	confighttp.ServerConfig       `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct
+	Path                          string                   `mapstructure:"path"`                // path for data collection. Default is <host>:<port>/glevents
+	Secret                        string                   `mapstructure:"secret"`              // gitlab webhook secret token. Default is empty
+	CustomServiceName             string                   `mapstructure:"custom_service_name"` // custom service name. Default is empty
+	ServiceNamePrefix             string                   `mapstructure:"service_name_prefix"` // service name prefix. Default is empty
+	ServiceNameSuffix             string                   `mapstructure:"service_name_suffix"` // service name suffix. Default is empty
+	GitLabAPIConfig               GitLabAPIConfig          `mapstructure:"gitlab_api"`          // gitlab api configuration
+	LogRetrieval                  LogRetrievalConfig       `mapstructure:"log_retrieval"`       // bounds of the retrieval of job logs
+	Logs                          logpolicy.Config         `mapstructure:"logs"`                // sampling, truncation and redaction policies applied to job logs. failed_only applies to jobs
+	Semconv                       semconv.Config           `mapstructure:"semconv"`             // OpenTelemetry CICD and VCS semantic conventions
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the receiver configuration is valid
+func (cfg *Config) Validate() error {
+	var errs error
+
+	if cfg.NetAddr.Endpoint == "" {
+		errs = multierr.Append(errs, errMissingEndpointFromConfig)
+	}
+
+	if cfg.GitLabAPIConfig.BaseURL != "" {
+		if u, err := url.Parse(cfg.GitLabAPIConfig.BaseURL); err != nil || !u.IsAbs() {
+			errs = multierr.Append(errs, errBaseURL)
+		}
+	}
+
+	if err := cfg.LogRetrieval.Validate(); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("invalid log_retrieval configuration: %w", err))
+	}
+
+	if err := cfg.Logs.Validate(); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("invalid logs configuration: %w", err))
+	}
+
+	return errs
+}
@@ -0,0 +1,52 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlabcireceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/gitlabcireceiver"
+
+import (
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/confignet"
+	"go.opentelemetry.io/collector/receiver"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/gitlabcireceiver/internal/metadata"
+)
+
+// This file implements factory for GitLab CI receiver.
+
+const (
+	defaultBindEndpoint = "0.0.0.0:19419"
+	defaultPath         = "/glevents"
+)
+
+// NewFactory creates a new GitLab CI receiver factory
+func NewFactory() receiver.Factory {
+	return receiver.NewFactory(
+		metadata.Type,
+		createDefaultConfig,
+		receiver.WithTraces(newTracesReceiver, metadata.TracesStability),
+		receiver.WithLogs(newLogsReceiver, metadata.LogsStability),
+		receiver.WithMetrics(newMetricsReceiver, metadata.MetricsStability),
+	)
+}
+
+// createDefaultConfig creates the default configuration for GitLab CI receiver.
+func createDefaultConfig() component.Config {
+	return &Config{
+		MetricsBuilderConfig: metadata.DefaultMetricsBuilderConfig(),
+		ServerConfig: confighttp.ServerConfig{
+			NetAddr: confignet.AddrConfig{
+				Transport: confignet.TransportTypeTCP,
+				Endpoint:  defaultBindEndpoint,
+			},
+		},
+		Path: defaultPath,
+	}
+}
+
+// This is the map of already created gitlabci receivers for particular configurations.
+// We maintain this map because the Factory is asked trace, log and metric receivers
+// separately but they must not create separate objects, they must use one receiver
+// object per configuration.
+var receivers = sharedcomponent.NewSharedComponents()
@@ -0,0 +1,419 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+)
+
+// AttributeCiGitlabJobStatus specifies the value ci.gitlab.job.status attribute.
+type AttributeCiGitlabJobStatus int
+
+const (
+	_ AttributeCiGitlabJobStatus = iota
+	AttributeCiGitlabJobStatusCreated
+	AttributeCiGitlabJobStatusWaitingForResource
+	AttributeCiGitlabJobStatusPreparing
+	AttributeCiGitlabJobStatusPending
+	AttributeCiGitlabJobStatusRunning
+	AttributeCiGitlabJobStatusSuccess
+	AttributeCiGitlabJobStatusFailed
+	AttributeCiGitlabJobStatusCanceling
+	AttributeCiGitlabJobStatusCanceled
+	AttributeCiGitlabJobStatusSkipped
+	AttributeCiGitlabJobStatusManual
+	AttributeCiGitlabJobStatusScheduled
+)
+
+// String returns the string representation of the AttributeCiGitlabJobStatus.
+func (av AttributeCiGitlabJobStatus) String() string {
+	switch av {
+	case AttributeCiGitlabJobStatusCreated:
+		return "created"
+	case AttributeCiGitlabJobStatusWaitingForResource:
+		return "waiting_for_resource"
+	case AttributeCiGitlabJobStatusPreparing:
+		return "preparing"
+	case AttributeCiGitlabJobStatusPending:
+		return "pending"
+	case AttributeCiGitlabJobStatusRunning:
+		return "running"
+	case AttributeCiGitlabJobStatusSuccess:
+		return "success"
+	case AttributeCiGitlabJobStatusFailed:
+		return "failed"
+	case AttributeCiGitlabJobStatusCanceling:
+		return "canceling"
+	case AttributeCiGitlabJobStatusCanceled:
+		return "canceled"
+	case AttributeCiGitlabJobStatusSkipped:
+		return "skipped"
+	case AttributeCiGitlabJobStatusManual:
+		return "manual"
+	case AttributeCiGitlabJobStatusScheduled:
+		return "scheduled"
+	}
+	return ""
+}
+
+// MapAttributeCiGitlabJobStatus is a helper map of string to AttributeCiGitlabJobStatus attribute value.
+var MapAttributeCiGitlabJobStatus = map[string]AttributeCiGitlabJobStatus{
+	"created":              AttributeCiGitlabJobStatusCreated,
+	"waiting_for_resource": AttributeCiGitlabJobStatusWaitingForResource,
+	"preparing":            AttributeCiGitlabJobStatusPreparing,
+	"pending":              AttributeCiGitlabJobStatusPending,
+	"running":              AttributeCiGitlabJobStatusRunning,
+	"success":              AttributeCiGitlabJobStatusSuccess,
+	"failed":               AttributeCiGitlabJobStatusFailed,
+	"canceling":            AttributeCiGitlabJobStatusCanceling,
+	"canceled":             AttributeCiGitlabJobStatusCanceled,
+	"skipped":              AttributeCiGitlabJobStatusSkipped,
+	"manual":               AttributeCiGitlabJobStatusManual,
+	"scheduled":            AttributeCiGitlabJobStatusScheduled,
+}
+
+// AttributeCiGitlabPipelineStatus specifies the value ci.gitlab.pipeline.status attribute.
+type AttributeCiGitlabPipelineStatus int
+
+const (
+	_ AttributeCiGitlabPipelineStatus = iota
+	AttributeCiGitlabPipelineStatusCreated
+	AttributeCiGitlabPipelineStatusWaitingForResource
+	AttributeCiGitlabPipelineStatusPreparing
+	AttributeCiGitlabPipelineStatusPending
+	AttributeCiGitlabPipelineStatusRunning
+	AttributeCiGitlabPipelineStatusSuccess
+	AttributeCiGitlabPipelineStatusFailed
+	AttributeCiGitlabPipelineStatusCanceling
+	AttributeCiGitlabPipelineStatusCanceled
+	AttributeCiGitlabPipelineStatusSkipped
+	AttributeCiGitlabPipelineStatusManual
+	AttributeCiGitlabPipelineStatusScheduled
+)
+
+// String returns the string representation of the AttributeCiGitlabPipelineStatus.
+func (av AttributeCiGitlabPipelineStatus) String() string {
+	switch av {
+	case AttributeCiGitlabPipelineStatusCreated:
+		return "created"
+	case AttributeCiGitlabPipelineStatusWaitingForResource:
+		return "waiting_for_resource"
+	case AttributeCiGitlabPipelineStatusPreparing:
+		return "preparing"
+	case AttributeCiGitlabPipelineStatusPending:
+		return "pending"
+	case AttributeCiGitlabPipelineStatusRunning:
+		return "running"
+	case AttributeCiGitlabPipelineStatusSuccess:
+		return "success"
+	case AttributeCiGitlabPipelineStatusFailed:
+		return "failed"
+	case AttributeCiGitlabPipelineStatusCanceling:
+		return "canceling"
+	case AttributeCiGitlabPipelineStatusCanceled:
+		return "canceled"
+	case AttributeCiGitlabPipelineStatusSkipped:
+		return "skipped"
+	case AttributeCiGitlabPipelineStatusManual:
+		return "manual"
+	case AttributeCiGitlabPipelineStatusScheduled:
+		return "scheduled"
+	}
+	return ""
+}
+
+// MapAttributeCiGitlabPipelineStatus is a helper map of string to AttributeCiGitlabPipelineStatus attribute value.
+var MapAttributeCiGitlabPipelineStatus = map[string]AttributeCiGitlabPipelineStatus{
+	"created":              AttributeCiGitlabPipelineStatusCreated,
+	"waiting_for_resource": AttributeCiGitlabPipelineStatusWaitingForResource,
+	"preparing":            AttributeCiGitlabPipelineStatusPreparing,
+	"pending":              AttributeCiGitlabPipelineStatusPending,
+	"running":              AttributeCiGitlabPipelineStatusRunning,
+	"success":              AttributeCiGitlabPipelineStatusSuccess,
+	"failed":               AttributeCiGitlabPipelineStatusFailed,
+	"canceling":            AttributeCiGitlabPipelineStatusCanceling,
+	"canceled":             AttributeCiGitlabPipelineStatusCanceled,
+	"skipped":              AttributeCiGitlabPipelineStatusSkipped,
+	"manual":               AttributeCiGitlabPipelineStatusManual,
+	"scheduled":            AttributeCiGitlabPipelineStatusScheduled,
+}
+
+var MetricsInfo = metricsInfo{
+	JobsCount: metricInfo{
+		Name: "jobs.count",
+	},
+	PipelinesCount: metricInfo{
+		Name: "pipelines.count",
+	},
+}
+
+type metricsInfo struct {
+	JobsCount      metricInfo
+	PipelinesCount metricInfo
+}
+
+type metricInfo struct {
+	Name string
+}
+
+type metricJobsCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills jobs.count metric with initial data.
+func (m *metricJobsCount) init() {
+	m.data.SetName("jobs.count")
+	m.data.SetDescription("Number of job events, by status.")
+	m.data.SetUnit("{job}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricJobsCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGitlabJobRunnerTagsAttributeValue string, ciGitlabJobStatusAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("vcs.repository.name", vcsRepositoryNameAttributeValue)
+	dp.Attributes().PutStr("ci.gitlab.job.runner.tags", ciGitlabJobRunnerTagsAttributeValue)
+	dp.Attributes().PutStr("ci.gitlab.job.status", ciGitlabJobStatusAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricJobsCount) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricJobsCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricJobsCount(cfg MetricConfig) metricJobsCount {
+	m := metricJobsCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricPipelinesCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills pipelines.count metric with initial data.
+func (m *metricPipelinesCount) init() {
+	m.data.SetName("pipelines.count")
+	m.data.SetDescription("Number of pipeline events, by status.")
+	m.data.SetUnit("{pipeline}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricPipelinesCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGitlabPipelineSourceAttributeValue string, ciGitlabPipelineStatusAttributeValue string, ciGitlabPipelineRefIsDefaultAttributeValue bool) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("vcs.repository.name", vcsRepositoryNameAttributeValue)
+	dp.Attributes().PutStr("ci.gitlab.pipeline.source", ciGitlabPipelineSourceAttributeValue)
+	dp.Attributes().PutStr("ci.gitlab.pipeline.status", ciGitlabPipelineStatusAttributeValue)
+	dp.Attributes().PutBool("ci.gitlab.pipeline.ref.is_default", ciGitlabPipelineRefIsDefaultAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricPipelinesCount) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricPipelinesCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricPipelinesCount(cfg MetricConfig) metricPipelinesCount {
+	m := metricPipelinesCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+// MetricsBuilder provides an interface for scrapers to report metrics while taking care of all the transformations
+// required to produce metric representation defined in metadata and user config.
+type MetricsBuilder struct {
+	config               MetricsBuilderConfig // config of the metrics builder.
+	startTime            pcommon.Timestamp    // start time that will be applied to all recorded data points.
+	metricsCapacity      int                  // maximum observed number of metrics per resource.
+	metricsBuffer        pmetric.Metrics      // accumulates metrics data before emitting.
+	buildInfo            component.BuildInfo  // contains version information.
+	metricJobsCount      metricJobsCount
+	metricPipelinesCount metricPipelinesCount
+}
+
+// MetricBuilderOption applies changes to default metrics builder.
+type MetricBuilderOption interface {
+	apply(*MetricsBuilder)
+}
+
+type metricBuilderOptionFunc func(mb *MetricsBuilder)
+
+func (mbof metricBuilderOptionFunc) apply(mb *MetricsBuilder) {
+	mbof(mb)
+}
+
+// WithStartTime sets startTime on the metrics builder.
+func WithStartTime(startTime pcommon.Timestamp) MetricBuilderOption {
+	return metricBuilderOptionFunc(func(mb *MetricsBuilder) {
+		mb.startTime = startTime
+	})
+}
+func NewMetricsBuilder(mbc MetricsBuilderConfig, settings receiver.Settings, options ...MetricBuilderOption) *MetricsBuilder {
+	mb := &MetricsBuilder{
+		config:               mbc,
+		startTime:            pcommon.NewTimestampFromTime(time.Now()),
+		metricsBuffer:        pmetric.NewMetrics(),
+		buildInfo:            settings.BuildInfo,
+		metricJobsCount:      newMetricJobsCount(mbc.Metrics.JobsCount),
+		metricPipelinesCount: newMetricPipelinesCount(mbc.Metrics.PipelinesCount),
+	}
+
+	for _, op := range options {
+		op.apply(mb)
+	}
+	return mb
+}
+
+// updateCapacity updates max length of metrics and resource attributes that will be used for the slice capacity.
+func (mb *MetricsBuilder) updateCapacity(rm pmetric.ResourceMetrics) {
+	if mb.metricsCapacity < rm.ScopeMetrics().At(0).Metrics().Len() {
+		mb.metricsCapacity = rm.ScopeMetrics().At(0).Metrics().Len()
+	}
+}
+
+// ResourceMetricsOption applies changes to provided resource metrics.
+type ResourceMetricsOption interface {
+	apply(pmetric.ResourceMetrics)
+}
+
+type resourceMetricsOptionFunc func(pmetric.ResourceMetrics)
+
+func (rmof resourceMetricsOptionFunc) apply(rm pmetric.ResourceMetrics) {
+	rmof(rm)
+}
+
+// WithResource sets the provided resource on the emitted ResourceMetrics.
+// It's recommended to use ResourceBuilder to create the resource.
+func WithResource(res pcommon.Resource) ResourceMetricsOption {
+	return resourceMetricsOptionFunc(func(rm pmetric.ResourceMetrics) {
+		res.CopyTo(rm.Resource())
+	})
+}
+
+// WithStartTimeOverride overrides start time for all the resource metrics data points.
+// This option should be only used if different start time has to be set on metrics coming from different resources.
+func WithStartTimeOverride(start pcommon.Timestamp) ResourceMetricsOption {
+	return resourceMetricsOptionFunc(func(rm pmetric.ResourceMetrics) {
+		var dps pmetric.NumberDataPointSlice
+		metrics := rm.ScopeMetrics().At(0).Metrics()
+		for i := 0; i < metrics.Len(); i++ {
+			switch metrics.At(i).Type() {
+			case pmetric.MetricTypeGauge:
+				dps = metrics.At(i).Gauge().DataPoints()
+			case pmetric.MetricTypeSum:
+				dps = metrics.At(i).Sum().DataPoints()
+			}
+			for j := 0; j < dps.Len(); j++ {
+				dps.At(j).SetStartTimestamp(start)
+			}
+		}
+	})
+}
+
+// EmitForResource saves all the generated metrics under a new resource and updates the internal state to be ready for
+// recording another set of data points as part of another resource. This function can be helpful when one scraper
+// needs to emit metrics from several resources. Otherwise calling this function is not required,
+// just `Emit` function can be called instead.
+// Resource attributes should be provided as ResourceMetricsOption arguments.
+func (mb *MetricsBuilder) EmitForResource(options ...ResourceMetricsOption) {
+	rm := pmetric.NewResourceMetrics()
+	ils := rm.ScopeMetrics().AppendEmpty()
+	ils.Scope().SetName(ScopeName)
+	ils.Scope().SetVersion(mb.buildInfo.Version)
+	ils.Metrics().EnsureCapacity(mb.metricsCapacity)
+	mb.metricJobsCount.emit(ils.Metrics())
+	mb.metricPipelinesCount.emit(ils.Metrics())
+
+	for _, op := range options {
+		op.apply(rm)
+	}
+
+	if ils.Metrics().Len() > 0 {
+		mb.updateCapacity(rm)
+		rm.MoveTo(mb.metricsBuffer.ResourceMetrics().AppendEmpty())
+	}
+}
+
+// Emit returns all the metrics accumulated by the metrics builder and updates the internal state to be ready for
+// recording another set of metrics. This function will be responsible for applying all the transformations required to
+// produce metric representation defined in metadata and user config, e.g. delta or cumulative.
+func (mb *MetricsBuilder) Emit(options ...ResourceMetricsOption) pmetric.Metrics {
+	mb.EmitForResource(options...)
+	metrics := mb.metricsBuffer
+	mb.metricsBuffer = pmetric.NewMetrics()
+	return metrics
+}
+
+// RecordJobsCountDataPoint adds a data point to jobs.count metric.
+func (mb *MetricsBuilder) RecordJobsCountDataPoint(ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGitlabJobRunnerTagsAttributeValue string, ciGitlabJobStatusAttributeValue AttributeCiGitlabJobStatus) {
+	mb.metricJobsCount.recordDataPoint(mb.startTime, ts, val, vcsRepositoryNameAttributeValue, ciGitlabJobRunnerTagsAttributeValue, ciGitlabJobStatusAttributeValue.String())
+}
+
+// RecordPipelinesCountDataPoint adds a data point to pipelines.count metric.
+func (mb *MetricsBuilder) RecordPipelinesCountDataPoint(ts pcommon.Timestamp, val int64, vcsRepositoryNameAttributeValue string, ciGitlabPipelineSourceAttributeValue string, ciGitlabPipelineStatusAttributeValue AttributeCiGitlabPipelineStatus, ciGitlabPipelineRefIsDefaultAttributeValue bool) {
+	mb.metricPipelinesCount.recordDataPoint(mb.startTime, ts, val, vcsRepositoryNameAttributeValue, ciGitlabPipelineSourceAttributeValue, ciGitlabPipelineStatusAttributeValue.String(), ciGitlabPipelineRefIsDefaultAttributeValue)
+}
+
+// Reset resets metrics builder to its initial state. It should be used when external metrics source is restarted,
+// and metrics builder should update its startTime and reset it's internal state accordingly.
+func (mb *MetricsBuilder) Reset(options ...MetricBuilderOption) {
+	mb.startTime = pcommon.NewTimestampFromTime(time.Now())
+	for _, op := range options {
+		op.apply(mb)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlabcireceiver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+var (
+	// ansiEscape matches the colour and erase sequences of job logs.
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	// sectionMarker matches the markers delimiting the sections of job logs,
+	// capturing the Unix time they were written at.
+	sectionMarker = regexp.MustCompile(`section_(?:start|end):(\d+):[^\r\n]*?\r`)
+	// lineTimestamp matches the timestamp and stream prefix of every line
+	// of job logs written by runners with timestamps enabled.
+	lineTimestamp = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?Z) [0-9a-f]{2}[OE]\+? ?`)
+)
+
+// jobEventToLogs fetches the log of a finished job and translates it to
+// log records correlated with the job span.
+func jobEventToLogs(ctx context.Context, e *gitlab.JobEvent, config *Config, glClient *gitlab.Client, policy *logpolicy.Policy, logger *zap.Logger) (*plog.Logs, error) {
+	pipeline := jobEventToPipeline(e, config)
+	job := &pipeline.Tasks[0]
+
+	if !policy.KeepLogs(job.Result.Failed()) {
+		logger.Debug("Skipping logs of job", zap.Int64("job_id", e.BuildID), zap.String("status", e.BuildStatus))
+		return nil, nil
+	}
+
+	trace, err := openJobLog(ctx, glClient, e.ProjectID, e.BuildID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get log of job %d: %w", e.BuildID, err)
+	}
+	defer trace.Close()
+
+	var truncated bool
+	job.Logs, truncated, err = parseJobLog(trace, job.Started, config.LogRetrieval.maxBytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read log of job %d: %w", e.BuildID, err)
+	}
+	if truncated {
+		logger.Warn("Job log exceeds log_retrieval::max_bytes, dropping the rest", zap.Int64("job_id", e.BuildID), zap.Int64("max_bytes", config.LogRetrieval.maxBytes()))
+	}
+	job.LogAttributes = map[string]any{
+		"ci.gitlab.pipeline.id": e.PipelineID,
+		"ci.gitlab.job.id":      e.BuildID,
+		"ci.gitlab.job.name":    e.BuildName,
+		"ci.gitlab.job.stage":   e.BuildStage,
+		"ci.gitlab.job.status":  e.BuildStatus,
+	}
+
+	logger.Debug("Fetched job log", zap.Int64("job_id", e.BuildID), zap.Int("entries", len(job.Logs)))
+
+	logs := cimodel.ToLogs(&pipeline, policy, traceOptions(config))
+	return &logs, nil
+}
+
+// openJobLog streams the log of a job. Jobs.GetTraceFile buffers the whole
+// log instead.
+func openJobLog(ctx context.Context, glClient *gitlab.Client, projectID, jobID int64) (io.ReadCloser, error) {
+	req, err := glClient.NewRequest(http.MethodGet, fmt.Sprintf("projects/%d/jobs/%d/trace", projectID, jobID), nil, []gitlab.RequestOptionFunc{gitlab.WithContext(ctx)})
+	if err != nil {
+		return nil, err
+	}
+
+	// Closing the reader fails the copy of the response body, ending the
+	// request when the log is not read to the end
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := glClient.Do(req, pw)
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// parseJobLog splits the first maxBytes of a job log into entries, reporting
+// whether the rest was dropped. Lines are timestamped by the runner when it
+// has timestamps enabled, and with the time the last section started or
+// ended at otherwise, defaulting to the time the job started.
+func parseJobLog(r io.Reader, started time.Time, maxBytes int64) ([]cimodel.LogEntry, bool, error) {
+	var entries []cimodel.LogEntry
+	timestamp := started
+
+	limited := &io.LimitedReader{R: r, N: maxBytes}
+	reader := bufio.NewReader(limited)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, false, err
+		}
+
+		if body, ts, ok := parseJobLogLine(line); ok {
+			if !ts.IsZero() {
+				timestamp = ts
+			}
+			if body != "" {
+				entries = append(entries, cimodel.LogEntry{Timestamp: timestamp, Body: body})
+			}
+		}
+
+		if err != nil {
+			return entries, limited.N <= 0, nil
+		}
+	}
+}
+
+// parseJobLogLine strips the escape sequences and section markers from a
+// line, returning the time it was written at when known.
+func parseJobLogLine(line string) (string, time.Time, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", time.Time{}, false
+	}
+
+	var timestamp time.Time
+	if m := lineTimestamp.FindStringSubmatch(line); m != nil {
+		timestamp, _ = time.Parse(time.RFC3339Nano, m[1])
+		line = line[len(m[0]):]
+	}
+
+	line = ansiEscape.ReplaceAllString(line, "")
+	for _, m := range sectionMarker.FindAllStringSubmatch(line, -1) {
+		if timestamp.IsZero() {
+			if sec, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+				timestamp = time.Unix(sec, 0).UTC()
+			}
+		}
+	}
+	line = sectionMarker.ReplaceAllString(line, "")
+
+	// Progress output overwrites the line with carriage returns.
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+
+	return strings.TrimRight(line, " "), timestamp, true
+}
@@ -0,0 +1,227 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlabcireceiver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/gitlabcireceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.uber.org/zap"
+)
+
+const metricsMaxCacheSize = 100000
+const histogramCacheSize = 50000
+const histogramTTL = 24 * time.Hour
+
+type metricsHandler struct {
+	mu             sync.Mutex
+	mb             *metadata.MetricsBuilder
+	cfg            *Config
+	logger         *zap.Logger
+	countersCache  *lru.Cache[string, int64]
+	histogramCache *lru.Cache[string, *cimodel.Histogram]
+	durations      *cimodel.Durations
+}
+
+func newMetricsHandler(settings receiver.Settings, cfg *Config, logger *zap.Logger) (*metricsHandler, error) {
+	countersCache, err := lru.New[string, int64](metricsMaxCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize counters cache: %w", err)
+	}
+
+	// histogramCache stores cumulative histogram state per unique dimension set,
+	// as histograms are emitted with cumulative temporality.
+	histogramCache, err := lru.New[string, *cimodel.Histogram](histogramCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize histogram cache: %w", err)
+	}
+
+	durations, err := cimodel.NewDurations(histogramCacheSize, histogramTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize durations cache: %w", err)
+	}
+
+	return &metricsHandler{
+		cfg:            cfg,
+		mb:             metadata.NewMetricsBuilder(cfg.MetricsBuilderConfig, settings),
+		logger:         logger,
+		countersCache:  countersCache,
+		histogramCache: histogramCache,
+		durations:      durations,
+	}, nil
+}
+
+func sortedTags(tags []string) string {
+	if len(tags) == 0 {
+		return "no tags"
+	}
+	s := make([]string, len(tags))
+	for i, t := range tags {
+		s[i] = strings.ToLower(t)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+func (m *metricsHandler) pipelineEventToMetrics(e *gitlab.PipelineEvent) pmetric.Metrics {
+	attrs := e.ObjectAttributes
+	repo := e.Project.PathWithNamespace
+	isDefault := attrs.Ref == e.Project.DefaultBranch
+
+	m.logger.Debug("Processing pipeline event",
+		zap.String("repo", repo),
+		zap.Int64("id", attrs.ID),
+		zap.String("source", attrs.Source),
+		zap.String("status", attrs.Status),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	status, ok := metadata.MapAttributeCiGitlabPipelineStatus[attrs.Status]
+	if ok && repo != "" {
+		dimensions := fmt.Sprintf("pipeline:%s:%s:%t", repo, attrs.Source, isDefault)
+		val, found := m.countersCache.Get(dimensions + ":" + status.String())
+		if !found {
+			// The counters of the other statuses start at zero, so that
+			// their increases are visible from their first event.
+			for _, s := range metadata.MapAttributeCiGitlabPipelineStatus {
+				if s != status && m.seedCounter(dimensions+":"+s.String()) {
+					m.mb.RecordPipelinesCountDataPoint(now, 0, repo, attrs.Source, s, isDefault)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+status.String(), val+1)
+		m.mb.RecordPipelinesCountDataPoint(now, val+1, repo, attrs.Source, status, isDefault)
+	}
+
+	metrics := m.mb.Emit()
+	if !isFinished(attrs.Status) {
+		return metrics
+	}
+
+	ms := scopeMetrics(metrics)
+	pipeline := pipelineEventPipeline(e)
+
+	if m.cfg.Semconv.EmitsLegacy() && !pipeline.Started.IsZero() && !pipeline.Finished.IsZero() {
+		key := fmt.Sprintf("hist:pipeline:%s:%s:%t", repo, attrs.Status, isDefault)
+		cimodel.AppendHistogram(ms, "pipelines.duration", map[string]any{
+			"vcs.repository.name":               repo,
+			"ci.gitlab.pipeline.status":         attrs.Status,
+			"ci.gitlab.pipeline.ref.is_default": isDefault,
+		}, m.observeDuration(key, pipeline.Finished.Sub(pipeline.Started).Seconds()))
+	}
+
+	if m.cfg.Semconv.Enabled {
+		m.durations.AppendPipeline(ms, &pipeline)
+	}
+
+	return metrics
+}
+
+func (m *metricsHandler) jobEventToMetrics(e *gitlab.JobEvent) pmetric.Metrics {
+	pipeline := jobEventPipeline(e)
+	repo := pipeline.Name
+	tags := sortedTags(e.Runner.Tags)
+
+	m.logger.Debug("Processing job event",
+		zap.String("repo", repo),
+		zap.Int64("pipeline_id", e.PipelineID),
+		zap.Int64("id", e.BuildID),
+		zap.String("name", e.BuildName),
+		zap.String("stage", e.BuildStage),
+		zap.String("status", e.BuildStatus),
+		zap.String("tags", tags),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	status, ok := metadata.MapAttributeCiGitlabJobStatus[e.BuildStatus]
+	if ok && repo != "" {
+		dimensions := fmt.Sprintf("job:%s:%s", repo, tags)
+		val, found := m.countersCache.Get(dimensions + ":" + status.String())
+		if !found {
+			for _, s := range metadata.MapAttributeCiGitlabJobStatus {
+				if s != status && m.seedCounter(dimensions+":"+s.String()) {
+					m.mb.RecordJobsCountDataPoint(now, 0, repo, tags, s)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+status.String(), val+1)
+		m.mb.RecordJobsCountDataPoint(now, val+1, repo, tags, status)
+	}
+
+	metrics := m.mb.Emit()
+	if !isFinished(e.BuildStatus) {
+		return metrics
+	}
+
+	ms := scopeMetrics(metrics)
+	job := &pipeline.Tasks[0]
+
+	// Jobs that never ran have no duration.
+	if m.cfg.Semconv.EmitsLegacy() && e.BuildStartedAt != "" && e.BuildFinishedAt != "" {
+		key := fmt.Sprintf("hist:job:%s:%s:%s:%s:%s", repo, e.BuildStage, e.BuildName, tags, e.BuildStatus)
+		cimodel.AppendHistogram(ms, "jobs.duration", map[string]any{
+			"vcs.repository.name":       repo,
+			"ci.gitlab.job.name":        e.BuildName,
+			"ci.gitlab.job.stage":       e.BuildStage,
+			"ci.gitlab.job.runner.tags": tags,
+			"ci.gitlab.job.status":      e.BuildStatus,
+		}, m.observeDuration(key, job.Finished.Sub(job.Started).Seconds()))
+	}
+
+	if m.cfg.Semconv.Enabled && e.BuildStartedAt != "" {
+		m.durations.AppendTask(ms, &pipeline, job)
+	}
+
+	return metrics
+}
+
+// scopeMetrics returns the metrics emitted by the metrics builder, which
+// emits no resource at all when no counter was recorded.
+func scopeMetrics(metrics pmetric.Metrics) pmetric.MetricSlice {
+	if metrics.ResourceMetrics().Len() == 0 {
+		scope := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+		scope.Scope().SetName(metadata.ScopeName)
+		return scope.Metrics()
+	}
+	return metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+}
+
+// seedCounter starts the counter cached under key at zero, reporting
+// whether it was unknown. Called under m.mu.
+func (m *metricsHandler) seedCounter(key string) bool {
+	if m.countersCache.Contains(key) {
+		return false
+	}
+	m.countersCache.Add(key, 0)
+	return true
+}
+
+// observeDuration records a duration in the histogram cached under key.
+// Called under m.mu.
+func (m *metricsHandler) observeDuration(key string, duration float64) *cimodel.Histogram {
+	// Stale histograms start over, the LRU evicts those never observed again
+	h, ok := m.histogramCache.Get(key)
+	if !ok || time.Since(h.LastSeen) >= histogramTTL {
+		h = cimodel.NewHistogram()
+	}
+	h.Observe(duration)
+	m.histogramCache.Add(key, h)
+	return h
+}
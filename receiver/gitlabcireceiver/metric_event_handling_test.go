@@ -0,0 +1,162 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlabcireceiver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/gitlabcireceiver/internal/metadata"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestMetricsHandler(t *testing.T, cfg *Config) *metricsHandler {
+	t.Helper()
+	cfg.MetricsBuilderConfig = metadata.DefaultMetricsBuilderConfig()
+	mh, err := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return mh
+}
+
+// metricNames returns the names of the metrics, with their data point counts.
+func metricNames(metrics pmetric.Metrics) map[string]int {
+	names := map[string]int{}
+	for i := range metrics.ResourceMetrics().Len() {
+		sms := metrics.ResourceMetrics().At(i).ScopeMetrics()
+		for j := range sms.Len() {
+			ms := sms.At(j).Metrics()
+			for k := range ms.Len() {
+				m := ms.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					names[m.Name()] += m.Sum().DataPoints().Len()
+				case pmetric.MetricTypeHistogram:
+					names[m.Name()] += m.Histogram().DataPoints().Len()
+				}
+			}
+		}
+	}
+	return names
+}
+
+func TestPipelineEventToMetrics(t *testing.T) {
+	e := loadEvent(t, gitlab.EventTypePipeline, "pipeline_failed.json").(*gitlab.PipelineEvent)
+	mh := newTestMetricsHandler(t, &Config{})
+
+	running := *e
+	running.ObjectAttributes.Status = "running"
+	running.ObjectAttributes.FinishedAt = ""
+
+	// The first event seeds the counters of the other statuses.
+	metrics := mh.pipelineEventToMetrics(&running)
+	require.Equal(t, map[string]int{
+		"pipelines.count": len(metadata.MapAttributeCiGitlabPipelineStatus),
+	}, metricNames(metrics))
+
+	metrics = mh.pipelineEventToMetrics(e)
+	require.Equal(t, map[string]int{
+		"pipelines.count":    1,
+		"pipelines.duration": 1,
+	}, metricNames(metrics))
+
+	dp := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, int64(1), dp.IntValue())
+	status, _ := dp.Attributes().Get("ci.gitlab.pipeline.status")
+	require.Equal(t, "failed", status.Str())
+
+	// The failed counter keeps counting.
+	metrics = mh.pipelineEventToMetrics(e)
+	dp = metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, int64(2), dp.IntValue())
+}
+
+func TestJobEventToMetrics(t *testing.T) {
+	e := loadEvent(t, gitlab.EventTypeJob, "job_failed.json").(*gitlab.JobEvent)
+
+	tests := []struct {
+		desc          string
+		semconv       semconv.Config
+		expectMetrics []string
+	}{
+		{
+			desc:          "Legacy metrics",
+			expectMetrics: []string{"jobs.count", "jobs.duration"},
+		},
+		{
+			desc:          "Semantic conventions",
+			semconv:       semconv.Config{Enabled: true},
+			expectMetrics: []string{"jobs.count", semconv.MetricCICDPipelineTaskRunDuration},
+		},
+		{
+			desc:          "Semantic conventions with legacy metrics",
+			semconv:       semconv.Config{Enabled: true, EmitLegacy: true},
+			expectMetrics: []string{"jobs.count", "jobs.duration", semconv.MetricCICDPipelineTaskRunDuration},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			mh := newTestMetricsHandler(t, &Config{Semconv: test.semconv})
+
+			names := metricNames(mh.jobEventToMetrics(e))
+			require.Len(t, names, len(test.expectMetrics))
+			for _, name := range test.expectMetrics {
+				require.Contains(t, names, name)
+			}
+			require.Equal(t, len(metadata.MapAttributeCiGitlabJobStatus), names["jobs.count"])
+		})
+	}
+}
+
+func TestJobEventToMetricsNeverStarted(t *testing.T) {
+	e := loadEvent(t, gitlab.EventTypeJob, "job_failed.json").(*gitlab.JobEvent)
+	e.BuildStatus = "skipped"
+	e.BuildStartedAt = ""
+	e.BuildFinishedAt = ""
+
+	mh := newTestMetricsHandler(t, &Config{Semconv: semconv.Config{Enabled: true, EmitLegacy: true}})
+
+	names := metricNames(mh.jobEventToMetrics(e))
+	require.Equal(t, map[string]int{"jobs.count": len(metadata.MapAttributeCiGitlabJobStatus)}, names)
+}
+
+func TestJobEventToMetricsConcurrency(t *testing.T) {
+	e := loadEvent(t, gitlab.EventTypeJob, "job_failed.json").(*gitlab.JobEvent)
+	mh := newTestMetricsHandler(t, &Config{})
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			mh.jobEventToMetrics(e)
+		})
+	}
+	wg.Wait()
+
+	val, ok := mh.countersCache.Get("job:acme/web-app:docker,linux:failed")
+	require.True(t, ok)
+	require.Equal(t, int64(50), val)
+}
+
+func TestObserveDurationStartsOver(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+
+	mh.observeDuration("key", 1)
+	require.Equal(t, uint64(2), mh.observeDuration("key", 1).Count)
+
+	// Histograms not observed within the TTL start over
+	h, _ := mh.histogramCache.Get("key")
+	h.LastSeen = time.Now().Add(-histogramTTL)
+	require.Equal(t, uint64(1), mh.observeDuration("key", 1).Count)
+}
+
+func TestSortedTags(t *testing.T) {
+	require.Equal(t, "no tags", sortedTags(nil))
+	require.Equal(t, "docker,linux", sortedTags([]string{"Linux", "docker"}))
+}
@@ -0,0 +1,298 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlabcireceiver
+
+import (
+	"cmp"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+)
+
+// timeLayouts are the formats of the timestamps of GitLab webhooks, which
+// changed across GitLab versions.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05 -0700",
+	time.RFC3339Nano,
+}
+
+// pipelineEventPipeline maps a pipeline and its jobs to the CI model, with
+// the deterministic IDs of its spans. Jobs are grouped by stage.
+func pipelineEventPipeline(e *gitlab.PipelineEvent) cimodel.Pipeline {
+	attrs := e.ObjectAttributes
+	finished := parseTime(attrs.FinishedAt)
+
+	// The duration of a pipeline excludes the time it was queued for.
+	started := parseTime(attrs.CreatedAt)
+	if attrs.Duration > 0 && !finished.IsZero() {
+		started = finished.Add(-time.Duration(attrs.Duration) * time.Second)
+	}
+
+	pipeline := cimodel.Pipeline{
+		ID:         strconv.FormatInt(attrs.ID, 10),
+		Name:       e.Project.PathWithNamespace,
+		URL:        attrs.URL,
+		Result:     pipelineResult(attrs.Status, ""),
+		Status:     attrs.Status,
+		Started:    started,
+		Finished:   finished,
+		Repository: gitlabRepository(e.Project.PathWithNamespace, e.Project.WebURL),
+		Ref:        gitlabRef(attrs.Ref, attrs.Tag, attrs.SHA),
+		TraceID:    generateTraceID(attrs.ID),
+		SpanID:     generatePipelineSpanID(attrs.ID),
+	}
+
+	if e.MergeRequest.IID != 0 {
+		pipeline.Ref.ChangeID = strconv.FormatInt(e.MergeRequest.IID, 10)
+		pipeline.Ref.Base = e.MergeRequest.TargetBranch
+	}
+
+	builds := slices.Clone(e.Builds)
+	slices.SortFunc(builds, func(a, b gitlab.PipelineEventBuild) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	for _, build := range builds {
+		pipeline.Tasks = append(pipeline.Tasks, pipelineBuildTask(attrs.ID, build, e.Project.WebURL))
+	}
+	pipeline.Stages = pipelineStages(attrs.ID, attrs.Stages, builds, pipeline.Tasks)
+
+	return pipeline
+}
+
+func pipelineBuildTask(pipelineID int64, build gitlab.PipelineEventBuild, projectURL string) cimodel.Task {
+	started, finished := jobTimes(build.CreatedAt, build.StartedAt, build.FinishedAt)
+
+	return cimodel.Task{
+		ID:       strconv.FormatInt(build.ID, 10),
+		Name:     build.Name,
+		URL:      jobURL(projectURL, build.ID),
+		Stage:    build.Stage,
+		Worker:   build.Runner.Description,
+		Result:   pipelineResult(build.Status, build.FailureReason),
+		Status:   build.Status,
+		Started:  started,
+		Finished: finished,
+		SpanID:   generateJobSpanID(pipelineID, build.ID),
+	}
+}
+
+// pipelineStages maps the stages of a pipeline, in the order they are
+// defined in, covering the times of their jobs.
+func pipelineStages(pipelineID int64, names []string, builds []gitlab.PipelineEventBuild, tasks []cimodel.Task) []cimodel.Stage {
+	// Stages are missing from the payloads of older GitLab versions.
+	if len(names) == 0 {
+		for _, build := range builds {
+			if !slices.Contains(names, build.Stage) {
+				names = append(names, build.Stage)
+			}
+		}
+	}
+
+	stages := make([]cimodel.Stage, 0, len(names))
+	for _, name := range names {
+		stage := cimodel.Stage{
+			Name:   name,
+			SpanID: generateStageSpanID(pipelineID, name),
+		}
+
+		var stageBuilds []gitlab.PipelineEventBuild
+		for i, build := range builds {
+			if build.Stage != name {
+				continue
+			}
+			stageBuilds = append(stageBuilds, build)
+
+			task := tasks[i]
+			if stage.Started.IsZero() || task.Started.Before(stage.Started) {
+				stage.Started = task.Started
+			}
+			if task.Finished.After(stage.Finished) {
+				stage.Finished = task.Finished
+			}
+		}
+		if len(stageBuilds) == 0 {
+			continue
+		}
+
+		stage.Status = stageStatus(stageBuilds)
+		stage.Result = pipelineResult(stage.Status, "")
+		stages = append(stages, stage)
+	}
+
+	return stages
+}
+
+// stageStatus derives the status of a stage from its jobs the way GitLab
+// does for the common cases: failed if a job failed without being allowed
+// to, canceled if a job was canceled, skipped if none of the jobs ran and
+// successful otherwise.
+func stageStatus(builds []gitlab.PipelineEventBuild) string {
+	ran := false
+	for _, build := range builds {
+		switch build.Status {
+		case "failed":
+			if !build.AllowFailure {
+				return "failed"
+			}
+		case "canceled":
+			return "canceled"
+		}
+		if build.StartedAt != "" {
+			ran = true
+		}
+	}
+
+	if !ran {
+		return "skipped"
+	}
+	return "success"
+}
+
+// jobEventPipeline maps a job to the CI model, as the only task of its
+// pipeline. The pipeline itself is described by pipeline events.
+func jobEventPipeline(e *gitlab.JobEvent) cimodel.Pipeline {
+	projectPath := jobProjectPath(e)
+	projectURL := ""
+	if e.Repository != nil {
+		projectURL = e.Repository.Homepage
+	}
+	started, finished := jobTimes(e.BuildCreatedAt, e.BuildStartedAt, e.BuildFinishedAt)
+
+	return cimodel.Pipeline{
+		ID:         strconv.FormatInt(e.PipelineID, 10),
+		Name:       projectPath,
+		Repository: gitlabRepository(projectPath, projectURL),
+		Ref:        gitlabRef(e.Ref, e.Tag, e.SHA),
+		TraceID:    generateTraceID(e.PipelineID),
+		SpanID:     generatePipelineSpanID(e.PipelineID),
+		OmitSpan:   true,
+		Tasks: []cimodel.Task{{
+			ID:       strconv.FormatInt(e.BuildID, 10),
+			Name:     e.BuildName,
+			URL:      jobURL(projectURL, e.BuildID),
+			Stage:    e.BuildStage,
+			Worker:   e.Runner.Description,
+			Result:   pipelineResult(e.BuildStatus, e.BuildFailureReason),
+			Status:   e.BuildStatus,
+			Started:  started,
+			Finished: finished,
+			SpanID:   generateJobSpanID(e.PipelineID, e.BuildID),
+		}},
+	}
+}
+
+// jobProjectPath returns the path of the project of a job. Job events only
+// carry the display name of the project, so the path is read from its URL.
+func jobProjectPath(e *gitlab.JobEvent) string {
+	if e.Repository == nil {
+		return strings.ReplaceAll(e.ProjectName, " / ", "/")
+	}
+
+	u, err := url.Parse(e.Repository.Homepage)
+	if err != nil || strings.Trim(u.Path, "/") == "" {
+		return strings.ReplaceAll(e.ProjectName, " / ", "/")
+	}
+	return strings.Trim(u.Path, "/")
+}
+
+func gitlabRepository(projectPath, webURL string) cimodel.Repository {
+	return cimodel.Repository{
+		Provider: semconv.AttributeVCSProviderNameGitlab,
+		Owner:    path.Dir(projectPath),
+		Name:     path.Base(projectPath),
+		URL:      webURL,
+	}
+}
+
+func gitlabRef(ref string, tag bool, sha string) cimodel.Ref {
+	headType := semconv.AttributeVCSRefTypeBranch
+	if tag {
+		headType = semconv.AttributeVCSRefTypeTag
+	}
+
+	return cimodel.Ref{
+		Head:     ref,
+		HeadType: headType,
+		Revision: sha,
+	}
+}
+
+func jobURL(projectURL string, jobID int64) string {
+	if projectURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(projectURL, "/") + "/-/jobs/" + strconv.FormatInt(jobID, 10)
+}
+
+// jobTimes returns the times a job started and finished at. Jobs that never
+// ran start and finish when they were created.
+func jobTimes(createdAt, startedAt, finishedAt string) (time.Time, time.Time) {
+	started := parseTime(startedAt)
+	if started.IsZero() {
+		started = parseTime(createdAt)
+	}
+
+	finished := parseTime(finishedAt)
+	if finished.IsZero() {
+		finished = started
+	}
+
+	return started, finished
+}
+
+// isFinished reports whether a pipeline or job status is final.
+func isFinished(status string) bool {
+	switch status {
+	case "success", "failed", "canceled", "skipped":
+		return true
+	default:
+		return false
+	}
+}
+
+// pipelineResult maps a GitLab status, and the failure reason of failed
+// jobs, to a pipeline, stage or job result.
+func pipelineResult(status, failureReason string) cimodel.Result {
+	switch status {
+	case "success":
+		return cimodel.ResultSuccess
+	case "failed":
+		switch failureReason {
+		case "", "script_failure", "unknown_failure":
+			return cimodel.ResultFailure
+		case "job_execution_timeout", "stuck_or_timeout_failure":
+			return cimodel.ResultTimeout
+		default:
+			return cimodel.ResultError
+		}
+	case "canceled":
+		return cimodel.ResultCancellation
+	case "skipped", "manual":
+		return cimodel.ResultSkip
+	default:
+		return cimodel.ResultUnknown
+	}
+}
+
+func parseTime(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
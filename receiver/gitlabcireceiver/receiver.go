@@ -0,0 +1,397 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlabcireceiver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/gitlabcireceiver/internal/metadata"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+var errMissingEndpoint = errors.New("missing a receiver endpoint")
+
+// tokenHeader carries the secret token of GitLab webhooks.
+const tokenHeader = "X-Gitlab-Token"
+
+type gitlabCIReceiver struct {
+	logsConsumer    consumer.Logs
+	tracesConsumer  consumer.Traces
+	metricsConsumer consumer.Metrics
+	metricsHandler  *metricsHandler
+	config          *Config
+	server          *http.Server
+	shutdownWG      sync.WaitGroup
+	createSettings  receiver.Settings
+	logger          *zap.Logger
+	obsrecv         *receiverhelper.ObsReport
+	glClient        *gitlab.Client
+	telemetry       *metadata.TelemetryBuilder
+	logPolicy       *logpolicy.Policy
+	// events are the finished jobs whose logs are retrieved in the
+	// background, so that webhooks are answered at once
+	events chan *gitlab.JobEvent
+	cancel context.CancelFunc
+}
+
+func newReceiver(
+	params receiver.Settings,
+	config *Config,
+) (*gitlabCIReceiver, error) {
+	if config.NetAddr.Endpoint == "" {
+		return nil, errMissingEndpoint
+	}
+
+	transport := "http"
+	if config.TLS.HasValue() {
+		transport = "https"
+	}
+
+	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             params.ID,
+		Transport:              transport,
+		ReceiverCreateSettings: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Job logs can only be fetched with a token.
+	var glClient *gitlab.Client
+	if config.GitLabAPIConfig.Token != "" {
+		var clientOpts []gitlab.ClientOptionFunc
+		if config.GitLabAPIConfig.BaseURL != "" {
+			clientOpts = append(clientOpts, gitlab.WithBaseURL(config.GitLabAPIConfig.BaseURL))
+		}
+		glClient, err = gitlab.NewClient(config.GitLabAPIConfig.Token, clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GitLab client: %w", err)
+		}
+	}
+
+	telemetry, err := metadata.NewTelemetryBuilder(params.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+
+	logPolicy, err := logpolicy.New(config.Logs, logPolicyRecorder{telemetry: telemetry})
+	if err != nil {
+		return nil, err
+	}
+
+	metricsHandler, err := newMetricsHandler(params, config, params.Logger.Named("metricsHandler"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &gitlabCIReceiver{
+		config:         config,
+		createSettings: params,
+		logger:         params.Logger,
+		obsrecv:        obsrecv,
+		glClient:       glClient,
+		telemetry:      telemetry,
+		logPolicy:      logPolicy,
+		metricsHandler: metricsHandler,
+		events:         make(chan *gitlab.JobEvent, config.LogRetrieval.queueSize()),
+	}, nil
+}
+
+// newTracesReceiver creates a traces receiver based on provided config.
+func newTracesReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Traces,
+) (receiver.Traces, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*gitlabCIReceiver).tracesConsumer = consumer
+
+	return r, nil
+}
+
+// newLogsReceiver creates a logs receiver based on provided config.
+func newLogsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Logs,
+) (receiver.Logs, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*gitlabCIReceiver).logsConsumer = consumer
+
+	return r, nil
+}
+
+// newMetricsReceiver creates a metrics receiver based on provided config.
+func newMetricsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*gitlabCIReceiver).metricsConsumer = consumer
+
+	return r, nil
+}
+
+func getOrAddReceiver(set receiver.Settings, cfg component.Config) (*sharedcomponent.SharedComponent, error) {
+	var err error
+	r := receivers.GetOrAdd(cfg, func() component.Component {
+		var rcv component.Component
+		rcv, err = newReceiver(set, cfg.(*Config))
+		return rcv
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// logPolicyRecorder reports the log lines affected by the log policy as
+// internal telemetry.
+type logPolicyRecorder struct {
+	telemetry *metadata.TelemetryBuilder
+}
+
+func (r logPolicyRecorder) RecordDroppedLines(n int64, reason string) {
+	r.telemetry.ReceiverLogsDroppedLines.Add(context.Background(), n, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+func (r logPolicyRecorder) RecordRedactedLines(n int64) {
+	r.telemetry.ReceiverLogsRedactedLines.Add(context.Background(), n)
+}
+
+func (r logPolicyRecorder) RecordOversizedEntries(n int64, behaviour string) {
+	r.telemetry.ReceiverLogsOversizedEntries.Add(context.Background(), n, metric.WithAttributes(attribute.String("behaviour", behaviour)))
+}
+
+func (glr *gitlabCIReceiver) Start(_ context.Context, _ component.Host) error {
+	endpoint := fmt.Sprintf("%s%s", glr.config.NetAddr.Endpoint, glr.config.Path)
+	glr.logger.Info("Starting GitLab CI server", zap.String("endpoint", endpoint))
+	glr.server = &http.Server{
+		Addr:              glr.config.NetAddr.Endpoint,
+		Handler:           glr,
+		ReadHeaderTimeout: 20 * time.Second,
+	}
+
+	glr.shutdownWG.Add(1)
+	go func() {
+		defer glr.shutdownWG.Done()
+
+		if errHTTP := glr.server.ListenAndServe(); !errors.Is(errHTTP, http.ErrServerClosed) && errHTTP != nil {
+			glr.createSettings.Logger.Error("Server closed with error", zap.Error(errHTTP))
+		}
+	}()
+
+	var ctx context.Context
+	ctx, glr.cancel = context.WithCancel(context.Background())
+	glr.shutdownWG.Add(1)
+	go func() {
+		defer glr.shutdownWG.Done()
+		glr.processEvents(ctx)
+	}()
+
+	return nil
+}
+
+// Shutdown stops the server and the retrieval of logs. Jobs waiting for
+// their logs are dropped.
+func (glr *gitlabCIReceiver) Shutdown(ctx context.Context) error {
+	var err error
+	if glr.server != nil {
+		err = glr.server.Close()
+	}
+	if glr.cancel != nil {
+		glr.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		glr.shutdownWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		glr.logger.Warn("Stopped waiting for the job being reported", zap.Error(ctx.Err()))
+	}
+	if n := len(glr.events); n > 0 {
+		glr.logger.Warn("Dropping jobs waiting for their logs", zap.Int("jobs", n))
+	}
+	glr.telemetry.Shutdown()
+	return err
+}
+
+func (glr *gitlabCIReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Validate request path
+	if r.URL.Path != glr.config.Path {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	// Validate the secret token configured on the webhook
+	if glr.config.Secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(tokenHeader)), []byte(glr.config.Secret)) != 1 {
+		glr.logger.Debug("Webhook token validation failed")
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		glr.logger.Debug("Failed to read payload", zap.Error(err))
+		http.Error(w, "Failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	// Determine the type of GitLab webhook event and ensure it's one we handle
+	eventType := gitlab.HookEventType(r)
+	switch eventType {
+	case gitlab.EventTypePipeline, gitlab.EventTypeJob:
+	default:
+		glr.logger.Debug("Skipping unsupported event type", zap.String("event", string(eventType)))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	event, err := gitlab.ParseWebhook(eventType, payload)
+	if err != nil {
+		glr.logger.Debug("Webhook parsing failed", zap.Error(err))
+		http.Error(w, "Failed to parse webhook", http.StatusBadRequest)
+		return
+	}
+
+	switch e := event.(type) {
+	case *gitlab.PipelineEvent:
+		if glr.metricsConsumer != nil {
+			glr.consumeMetrics(ctx, glr.metricsHandler.pipelineEventToMetrics(e))
+		}
+
+		if !isFinished(e.ObjectAttributes.Status) {
+			glr.logger.Debug("Skipping unfinished PipelineEvent", zap.String("status", e.ObjectAttributes.Status))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if glr.tracesConsumer != nil {
+			td := pipelineEventToTraces(e, glr.config, glr.logger.Named("pipelineEventToTraces"))
+			tracesCtx := glr.obsrecv.StartTracesOp(ctx)
+			err := glr.tracesConsumer.ConsumeTraces(tracesCtx, td)
+			glr.obsrecv.EndTracesOp(tracesCtx, metadata.Type.String(), td.SpanCount(), err)
+			if err != nil {
+				glr.logger.Error("Failed to consume traces", zap.Error(err))
+			}
+		}
+	case *gitlab.JobEvent:
+		finished := isFinished(e.BuildStatus)
+
+		// Jobs rejected while the queue is full are not counted either, so
+		// that their redelivery is counted once
+		if finished && glr.logsConsumer != nil {
+			select {
+			case glr.events <- e:
+			default:
+				glr.logger.Warn("Too many jobs waiting for their logs, dropping",
+					zap.Int64("project_id", e.ProjectID),
+					zap.Int64("job_id", e.BuildID),
+				)
+				http.Error(w, "too many jobs waiting for their logs", http.StatusServiceUnavailable)
+				return
+			}
+		}
+
+		if glr.metricsConsumer != nil {
+			glr.consumeMetrics(ctx, glr.metricsHandler.jobEventToMetrics(e))
+		}
+
+		if !finished {
+			glr.logger.Debug("Skipping unfinished JobEvent", zap.String("status", e.BuildStatus))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (glr *gitlabCIReceiver) consumeMetrics(ctx context.Context, md pmetric.Metrics) {
+	metricsCtx := glr.obsrecv.StartMetricsOp(ctx)
+	err := glr.metricsConsumer.ConsumeMetrics(metricsCtx, md)
+	glr.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), md.DataPointCount(), err)
+	if err != nil {
+		glr.logger.Error("Failed to consume metrics", zap.Error(err))
+	}
+}
+
+// processEvents reports the logs of the queued jobs, until ctx is canceled.
+func (glr *gitlabCIReceiver) processEvents(ctx context.Context) {
+	for {
+		select {
+		case e := <-glr.events:
+			glr.consumeJobLogs(ctx, e)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// consumeJobLogs retrieves the log of a job, within the log retrieval
+// timeout, and reports it.
+func (glr *gitlabCIReceiver) consumeJobLogs(ctx context.Context, e *gitlab.JobEvent) {
+	if glr.glClient == nil {
+		glr.logger.Error("GitLab token not provided, but a logs consumer is set. Logs will not be processed. Please provide a GitLab token.")
+		return
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, glr.config.LogRetrieval.timeout())
+	ld, err := jobEventToLogs(fetchCtx, e, glr.config, glr.glClient, glr.logPolicy, glr.logger.Named("jobEventToLogs"))
+	cancel()
+	if err != nil {
+		glr.logger.Error("Failed to process logs", zap.Error(err))
+		return
+	}
+	if ld == nil {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	logsCtx := glr.obsrecv.StartLogsOp(ctx)
+	err = glr.logsConsumer.ConsumeLogs(logsCtx, *ld)
+	glr.obsrecv.EndLogsOp(logsCtx, metadata.Type.String(), ld.LogRecordCount(), err)
+	if err != nil {
+		glr.logger.Error("Failed to consume logs", zap.Error(err))
+	}
+}
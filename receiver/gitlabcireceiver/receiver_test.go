@@ -0,0 +1,275 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlabcireceiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+func TestNewReceiver(t *testing.T) {
+	defaultConfig := createDefaultConfig().(*Config)
+
+	tests := []struct {
+		desc         string
+		config       Config
+		expectClient bool
+		err          error
+	}{
+		{
+			desc:   "Default config succeeds",
+			config: *defaultConfig,
+		},
+		{
+			desc: "Token creates a GitLab client",
+			config: Config{
+				ServerConfig: defaultConfig.ServerConfig,
+				GitLabAPIConfig: GitLabAPIConfig{
+					BaseURL: "https://gitlab.example.com",
+					Token:   "token",
+				},
+			},
+			expectClient: true,
+		},
+		{
+			desc:   "Missing endpoint fails",
+			config: Config{},
+			err:    errMissingEndpoint,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), &test.config)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectClient, rec.glClient != nil)
+			require.NoError(t, rec.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	pipelinePayload, err := os.ReadFile(filepath.Join("testdata", "pipeline_failed.json"))
+	require.NoError(t, err)
+	jobPayload, err := os.ReadFile(filepath.Join("testdata", "job_failed.json"))
+	require.NoError(t, err)
+	runningPayload := bytes.Replace(pipelinePayload, []byte(`"status": "failed"`), []byte(`"status": "running"`), 1)
+
+	tests := []struct {
+		desc          string
+		path          string
+		token         string
+		eventType     gitlab.EventType
+		payload       []byte
+		expectStatus  int
+		expectSpans   int
+		expectLogs    int
+		expectMetrics bool
+	}{
+		{
+			desc:         "Unknown path",
+			path:         "/other",
+			token:        "mysecret",
+			eventType:    gitlab.EventTypePipeline,
+			payload:      pipelinePayload,
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			desc:         "Invalid token",
+			token:        "wrong",
+			eventType:    gitlab.EventTypePipeline,
+			payload:      pipelinePayload,
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:         "Unsupported event",
+			token:        "mysecret",
+			eventType:    gitlab.EventTypePush,
+			payload:      []byte(`{"object_kind": "push"}`),
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			desc:         "Invalid payload",
+			token:        "mysecret",
+			eventType:    gitlab.EventTypePipeline,
+			payload:      []byte(`{`),
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			desc:          "Running pipeline",
+			token:         "mysecret",
+			eventType:     gitlab.EventTypePipeline,
+			payload:       runningPayload,
+			expectStatus:  http.StatusNoContent,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Finished pipeline",
+			token:         "mysecret",
+			eventType:     gitlab.EventTypePipeline,
+			payload:       pipelinePayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   8,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Finished job",
+			token:         "mysecret",
+			eventType:     gitlab.EventTypeJob,
+			payload:       jobPayload,
+			expectStatus:  http.StatusAccepted,
+			expectLogs:    2,
+			expectMetrics: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Secret = "mysecret"
+
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, rec.Shutdown(context.Background())) })
+
+			tracesSink := new(consumertest.TracesSink)
+			logsSink := new(consumertest.LogsSink)
+			metricsSink := new(consumertest.MetricsSink)
+			rec.tracesConsumer = tracesSink
+			rec.logsConsumer = logsSink
+			rec.metricsConsumer = metricsSink
+			rec.glClient = newJobLogsTestServer(t, "$ make test\nok")
+
+			path := test.path
+			if path == "" {
+				path = cfg.Path
+			}
+			req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(test.payload))
+			req.Header.Set("X-Gitlab-Event", string(test.eventType))
+			req.Header.Set(tokenHeader, test.token)
+			w := httptest.NewRecorder()
+
+			rec.ServeHTTP(w, req)
+			// Logs are retrieved in the background
+			for len(rec.events) > 0 {
+				rec.consumeJobLogs(t.Context(), <-rec.events)
+			}
+
+			require.Equal(t, test.expectStatus, w.Code)
+			require.Equal(t, test.expectSpans, tracesSink.SpanCount())
+			require.Equal(t, test.expectLogs, logsSink.LogRecordCount())
+			require.Equal(t, test.expectMetrics, len(metricsSink.AllMetrics()) > 0)
+		})
+	}
+}
+
+func TestServeHTTPQueue(t *testing.T) {
+	jobPayload, err := os.ReadFile(filepath.Join("testdata", "job_failed.json"))
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.LogRetrieval.QueueSize = 1
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	rec.logsConsumer = new(consumertest.LogsSink)
+	metricsSink := new(consumertest.MetricsSink)
+	rec.metricsConsumer = metricsSink
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, cfg.Path, bytes.NewReader(jobPayload))
+		req.Header.Set("X-Gitlab-Event", string(gitlab.EventTypeJob))
+		w := httptest.NewRecorder()
+		rec.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusAccepted, send())
+	// Jobs are rejected while the queue is full
+	require.Equal(t, http.StatusServiceUnavailable, send())
+	require.Len(t, rec.events, 1)
+
+	// Rejected jobs are counted once redelivered
+	require.Len(t, metricsSink.AllMetrics(), 1)
+	<-rec.events
+	require.Equal(t, http.StatusAccepted, send())
+	require.Len(t, metricsSink.AllMetrics(), 2)
+}
+
+func TestConsumeJobLogsTimeout(t *testing.T) {
+	// The API never answers the log of the job
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.LogRetrieval.Timeout = 100 * time.Millisecond
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	logsSink := new(consumertest.LogsSink)
+	rec.logsConsumer = logsSink
+	rec.glClient, err = gitlab.NewClient("testtoken", gitlab.WithBaseURL(server.URL))
+	require.NoError(t, err)
+
+	e := loadEvent(t, gitlab.EventTypeJob, "job_failed.json").(*gitlab.JobEvent)
+	started := time.Now()
+	rec.consumeJobLogs(t.Context(), e)
+	require.Less(t, time.Since(started), 5*time.Second)
+	require.Zero(t, logsSink.LogRecordCount())
+}
+
+func TestShutdownCancelsLogRetrieval(t *testing.T) {
+	// The API never answers the log of the job
+	requested := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.NetAddr.Endpoint = "127.0.0.1:0"
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	rec.logsConsumer = new(consumertest.LogsSink)
+	rec.glClient, err = gitlab.NewClient("testtoken", gitlab.WithBaseURL(server.URL))
+	require.NoError(t, err)
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	rec.events <- loadEvent(t, gitlab.EventTypeJob, "job_failed.json").(*gitlab.JobEvent)
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "log was not requested")
+	}
+
+	// Shutdown doesn't wait for the timeout of the request
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	started := time.Now()
+	require.NoError(t, rec.Shutdown(ctx))
+	require.Less(t, time.Since(started), time.Second)
+}
@@ -0,0 +1,206 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlabcireceiver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeName    = "gitlabcireceiver"
+	scopeVersion = "0.1.0"
+)
+
+// legacyResourceAttributes, legacyPipelineAttributes and legacyJobAttributes
+// are the attributes replaced by the semantic conventions. Attributes
+// without an equivalent keep their names.
+var legacyResourceAttributes = []string{
+	"ci.gitlab.project.path",
+	"ci.gitlab.project.url",
+}
+
+var legacyPipelineAttributes = []string{
+	"ci.gitlab.merge_request.iid",
+	"ci.gitlab.merge_request.target_branch",
+	"ci.gitlab.pipeline.id",
+	"ci.gitlab.pipeline.ref",
+	"ci.gitlab.pipeline.sha",
+	"ci.gitlab.pipeline.status",
+	"ci.gitlab.pipeline.url",
+}
+
+var legacyJobAttributes = []string{
+	"ci.gitlab.job.id",
+	"ci.gitlab.job.name",
+	"ci.gitlab.job.runner.description",
+	"ci.gitlab.job.status",
+	"ci.gitlab.job.url",
+}
+
+func pipelineEventToTraces(e *gitlab.PipelineEvent, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing PipelineEvent",
+		zap.Int64("pipeline_id", e.ObjectAttributes.ID),
+		zap.String("project", e.Project.PathWithNamespace),
+		zap.String("status", e.ObjectAttributes.Status),
+		zap.Int("jobs", len(e.Builds)),
+	)
+
+	pipeline := pipelineEventToPipeline(e, config)
+	return cimodel.ToTraces(&pipeline, traceOptions(config))
+}
+
+// pipelineEventToPipeline maps a pipeline to the CI model, with the
+// attributes of its resource and spans.
+func pipelineEventToPipeline(e *gitlab.PipelineEvent, config *Config) cimodel.Pipeline {
+	attrs := e.ObjectAttributes
+
+	pipeline := pipelineEventPipeline(e)
+	pipeline.ResourceAttributes = resourceAttributes(config, e.Project.ID, e.Project.PathWithNamespace, e.Project.WebURL)
+	pipeline.Attributes = map[string]any{
+		"ci.gitlab.pipeline.id":              attrs.ID,
+		"ci.gitlab.pipeline.iid":             attrs.IID,
+		"ci.gitlab.pipeline.ref":             attrs.Ref,
+		"ci.gitlab.pipeline.ref.is_default":  attrs.Ref == e.Project.DefaultBranch,
+		"ci.gitlab.pipeline.tag":             attrs.Tag,
+		"ci.gitlab.pipeline.sha":             attrs.SHA,
+		"ci.gitlab.pipeline.source":          attrs.Source,
+		"ci.gitlab.pipeline.status":          attrs.Status,
+		"ci.gitlab.pipeline.detailed_status": attrs.DetailedStatus,
+		"ci.gitlab.pipeline.url":             attrs.URL,
+		"ci.gitlab.pipeline.queued_duration": attrs.QueuedDuration,
+	}
+	if attrs.Name != "" {
+		pipeline.Attributes["ci.gitlab.pipeline.name"] = attrs.Name
+	}
+	if e.User != nil {
+		pipeline.Attributes["ci.gitlab.user.username"] = e.User.Username
+	}
+	if e.MergeRequest.IID != 0 {
+		pipeline.Attributes["ci.gitlab.merge_request.iid"] = e.MergeRequest.IID
+		pipeline.Attributes["ci.gitlab.merge_request.target_branch"] = e.MergeRequest.TargetBranch
+	}
+
+	for i := range pipeline.Stages {
+		stage := &pipeline.Stages[i]
+		stage.Attributes = map[string]any{
+			"ci.gitlab.stage.name":   stage.Name,
+			"ci.gitlab.stage.status": stage.Status,
+		}
+	}
+
+	// Tasks are in the order of the builds sorted by ID.
+	builds := make(map[string]gitlab.PipelineEventBuild, len(e.Builds))
+	for _, build := range e.Builds {
+		builds[strconv.FormatInt(build.ID, 10)] = build
+	}
+	for i := range pipeline.Tasks {
+		task := &pipeline.Tasks[i]
+		build := builds[task.ID]
+		task.Attributes = map[string]any{
+			"ci.gitlab.job.id":                 build.ID,
+			"ci.gitlab.job.name":               build.Name,
+			"ci.gitlab.job.stage":              build.Stage,
+			"ci.gitlab.job.status":             build.Status,
+			"ci.gitlab.job.allow_failure":      build.AllowFailure,
+			"ci.gitlab.job.when":               build.When,
+			"ci.gitlab.job.queued_duration":    build.QueuedDuration,
+			"ci.gitlab.job.url":                task.URL,
+			"ci.gitlab.job.runner.description": build.Runner.Description,
+			"ci.gitlab.job.runner.tags":        sortedTags(build.Runner.Tags),
+		}
+		if build.FailureReason != "" && build.Status == "failed" {
+			task.Attributes["ci.gitlab.job.failure_reason"] = build.FailureReason
+		}
+	}
+
+	removeLegacyAttributes(&pipeline, config.Semconv)
+	return pipeline
+}
+
+// jobEventToPipeline maps a job to the CI model, with the attributes of its
+// resource.
+func jobEventToPipeline(e *gitlab.JobEvent, config *Config) cimodel.Pipeline {
+	pipeline := jobEventPipeline(e)
+	pipeline.ResourceAttributes = resourceAttributes(config, e.ProjectID, pipeline.Name, pipeline.Repository.URL)
+
+	removeLegacyAttributes(&pipeline, config.Semconv)
+	return pipeline
+}
+
+func resourceAttributes(config *Config, projectID int64, projectPath, projectURL string) map[string]any {
+	return map[string]any{
+		"service.name":           generateServiceName(config, projectPath),
+		"ci.gitlab.project.id":   projectID,
+		"ci.gitlab.project.path": projectPath,
+		"ci.gitlab.project.url":  projectURL,
+	}
+}
+
+// removeLegacyAttributes removes the attributes replaced by the semantic
+// conventions, unless the legacy ones are emitted.
+func removeLegacyAttributes(p *cimodel.Pipeline, cfg semconv.Config) {
+	if cfg.EmitsLegacy() {
+		return
+	}
+
+	for _, key := range legacyResourceAttributes {
+		delete(p.ResourceAttributes, key)
+	}
+	for _, key := range legacyPipelineAttributes {
+		delete(p.Attributes, key)
+	}
+	for i := range p.Tasks {
+		for _, key := range legacyJobAttributes {
+			delete(p.Tasks[i].Attributes, key)
+		}
+	}
+}
+
+func traceOptions(config *Config) cimodel.Options {
+	return cimodel.Options{
+		ScopeName:    scopeName,
+		ScopeVersion: scopeVersion,
+		Semconv:      config.Semconv,
+	}
+}
+
+func generateServiceName(config *Config, projectPath string) string {
+	if config.CustomServiceName != "" {
+		return config.CustomServiceName
+	}
+	formattedName := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(projectPath, "/", "-"), "_", "-"))
+	return fmt.Sprintf("%s%s%s", config.ServiceNamePrefix, formattedName, config.ServiceNameSuffix)
+}
+
+func generateTraceID(pipelineID int64) pcommon.TraceID {
+	hash := sha256.Sum256(fmt.Appendf(nil, "%dt", pipelineID))
+	return pcommon.TraceID(hash[:16])
+}
+
+func generatePipelineSpanID(pipelineID int64) pcommon.SpanID {
+	return generateSpanID(fmt.Sprintf("%ds", pipelineID))
+}
+
+func generateStageSpanID(pipelineID int64, stage string) pcommon.SpanID {
+	return generateSpanID(fmt.Sprintf("%ds%s", pipelineID, stage))
+}
+
+func generateJobSpanID(pipelineID, jobID int64) pcommon.SpanID {
+	return generateSpanID(fmt.Sprintf("%dj%d", pipelineID, jobID))
+}
+
+func generateSpanID(input string) pcommon.SpanID {
+	hash := sha256.Sum256([]byte(input))
+	return pcommon.SpanID(hash[:8])
+}
@@ -0,0 +1,246 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package gitlabcireceiver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/stretchr/testify/require"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap/zaptest"
+)
+
+func loadEvent(t *testing.T, eventType gitlab.EventType, name string) any {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	event, err := gitlab.ParseWebhook(eventType, payload)
+	require.NoError(t, err)
+	return event
+}
+
+func spansByName(traces ptrace.Traces) map[string]ptrace.Span {
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	byName := make(map[string]ptrace.Span, spans.Len())
+	for i := 0; i < spans.Len(); i++ {
+		byName[spans.At(i).Name()] = spans.At(i)
+	}
+	return byName
+}
+
+func TestPipelineEventToTraces(t *testing.T) {
+	e := loadEvent(t, gitlab.EventTypePipeline, "pipeline_failed.json").(*gitlab.PipelineEvent)
+
+	traces := pipelineEventToTraces(e, &Config{}, zaptest.NewLogger(t))
+
+	// The pipeline, its 3 stages and its 4 jobs.
+	require.Equal(t, 8, traces.SpanCount())
+
+	resource := traces.ResourceSpans().At(0).Resource().Attributes()
+	serviceName, _ := resource.Get("service.name")
+	require.Equal(t, "acme-web-app", serviceName.Str())
+	projectPath, _ := resource.Get("ci.gitlab.project.path")
+	require.Equal(t, "acme/web-app", projectPath.Str())
+
+	spans := spansByName(traces)
+	pipeline := spans["acme/web-app"]
+	require.Equal(t, "7f3eda5a4d13bb0f2e3f6f68632c43c6", pipeline.TraceID().String())
+	require.True(t, pipeline.ParentSpanID().IsEmpty())
+	require.Equal(t, ptrace.StatusCodeError, pipeline.Status().Code())
+	// The pipeline span excludes the time the pipeline was queued for.
+	require.Equal(t, time.Date(2024, 3, 5, 10, 0, 30, 0, time.UTC), pipeline.StartTimestamp().AsTime())
+	require.Equal(t, time.Date(2024, 3, 5, 10, 5, 0, 0, time.UTC), pipeline.EndTimestamp().AsTime())
+	mrIID, _ := pipeline.Attributes().Get("ci.gitlab.merge_request.iid")
+	require.EqualValues(t, 12, mrIID.Int())
+	isDefault, _ := pipeline.Attributes().Get("ci.gitlab.pipeline.ref.is_default")
+	require.False(t, isDefault.Bool())
+
+	stages := map[string]struct {
+		status string
+		code   ptrace.StatusCode
+	}{
+		"build":  {"success", ptrace.StatusCodeOk},
+		"test":   {"failed", ptrace.StatusCodeError},
+		"deploy": {"skipped", ptrace.StatusCodeUnset},
+	}
+	for name, expected := range stages {
+		stage := spans[name]
+		require.Equal(t, pipeline.SpanID(), stage.ParentSpanID(), name)
+		require.Equal(t, expected.code, stage.Status().Code(), name)
+		status, _ := stage.Attributes().Get("ci.gitlab.stage.status")
+		require.Equal(t, expected.status, status.Str(), name)
+	}
+
+	test := spans["test"]
+	require.Equal(t, time.Date(2024, 3, 5, 10, 2, 0, 0, time.UTC), test.StartTimestamp().AsTime())
+	require.Equal(t, time.Date(2024, 3, 5, 10, 5, 0, 0, time.UTC), test.EndTimestamp().AsTime())
+
+	unit := spans["unit"]
+	require.Equal(t, test.SpanID(), unit.ParentSpanID())
+	require.Equal(t, "0c314179cf1844bd", unit.SpanID().String())
+	require.Equal(t, ptrace.StatusCodeError, unit.Status().Code())
+	failureReason, _ := unit.Attributes().Get("ci.gitlab.job.failure_reason")
+	require.Equal(t, "script_failure", failureReason.Str())
+	tags, _ := unit.Attributes().Get("ci.gitlab.job.runner.tags")
+	require.Equal(t, "docker,linux", tags.Str())
+	url, _ := unit.Attributes().Get("ci.gitlab.job.url")
+	require.Equal(t, "https://gitlab.example.com/acme/web-app/-/jobs/5003", url.Str())
+
+	lint := spans["lint"]
+	allowFailure, _ := lint.Attributes().Get("ci.gitlab.job.allow_failure")
+	require.True(t, allowFailure.Bool())
+	tags, _ = lint.Attributes().Get("ci.gitlab.job.runner.tags")
+	require.Equal(t, "no tags", tags.Str())
+
+	// Jobs that never ran have no duration.
+	staging := spans["staging"]
+	require.Equal(t, staging.StartTimestamp(), staging.EndTimestamp())
+	require.Equal(t, spans["deploy"].SpanID(), staging.ParentSpanID())
+}
+
+func TestPipelineEventToTracesSemconv(t *testing.T) {
+	e := loadEvent(t, gitlab.EventTypePipeline, "pipeline_failed.json").(*gitlab.PipelineEvent)
+
+	tests := []struct {
+		desc         string
+		semconv      semconv.Config
+		expectLegacy bool
+	}{
+		{
+			desc:         "Semantic conventions with legacy attributes",
+			semconv:      semconv.Config{Enabled: true, EmitLegacy: true},
+			expectLegacy: true,
+		},
+		{
+			desc:         "Semantic conventions only",
+			semconv:      semconv.Config{Enabled: true},
+			expectLegacy: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			traces := pipelineEventToTraces(e, &Config{Semconv: test.semconv}, zaptest.NewLogger(t))
+
+			resource := traces.ResourceSpans().At(0).Resource().Attributes()
+			provider, _ := resource.Get(semconv.AttributeVCSProviderName)
+			require.Equal(t, semconv.AttributeVCSProviderNameGitlab, provider.Str())
+			owner, _ := resource.Get(semconv.AttributeVCSOwnerName)
+			require.Equal(t, "acme", owner.Str())
+			_, ok := resource.Get("ci.gitlab.project.path")
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = resource.Get("ci.gitlab.project.id")
+			require.True(t, ok)
+
+			spans := spansByName(traces)
+			pipeline := spans["acme/web-app"].Attributes()
+			changeID, _ := pipeline.Get(semconv.AttributeVCSChangeID)
+			require.Equal(t, "12", changeID.Str())
+			base, _ := pipeline.Get(semconv.AttributeVCSRefBaseName)
+			require.Equal(t, "main", base.Str())
+			result, _ := pipeline.Get(semconv.AttributeCICDPipelineResult)
+			require.Equal(t, "failure", result.Str())
+			_, ok = pipeline.Get("ci.gitlab.pipeline.status")
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = pipeline.Get("ci.gitlab.pipeline.source")
+			require.True(t, ok)
+
+			unit := spans["unit"].Attributes()
+			taskID, _ := unit.Get(semconv.AttributeCICDPipelineTaskRunID)
+			require.Equal(t, "5003", taskID.Str())
+			worker, _ := unit.Get(semconv.AttributeCICDWorkerName)
+			require.Equal(t, "docker-runner-1", worker.Str())
+			_, ok = unit.Get("ci.gitlab.job.name")
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = unit.Get("ci.gitlab.job.stage")
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestJobEventPipeline(t *testing.T) {
+	e := loadEvent(t, gitlab.EventTypeJob, "job_failed.json").(*gitlab.JobEvent)
+	pipelineEvent := loadEvent(t, gitlab.EventTypePipeline, "pipeline_failed.json").(*gitlab.PipelineEvent)
+
+	pipeline := jobEventPipeline(e)
+	require.Equal(t, "acme/web-app", pipeline.Name)
+	require.True(t, pipeline.OmitSpan)
+	require.Len(t, pipeline.Tasks, 1)
+	require.Equal(t, "https://gitlab.example.com/acme/web-app/-/jobs/5003", pipeline.Tasks[0].URL)
+
+	// Job events are correlated with the spans of pipeline events.
+	expected := pipelineEventPipeline(pipelineEvent)
+	require.Equal(t, expected.TraceID, pipeline.TraceID)
+	require.Equal(t, expected.Tasks[2].SpanID, pipeline.Tasks[0].SpanID)
+}
+
+func TestJobProjectPath(t *testing.T) {
+	tests := []struct {
+		desc   string
+		event  gitlab.JobEvent
+		expect string
+	}{
+		{
+			desc: "Homepage",
+			event: gitlab.JobEvent{
+				ProjectName: "Acme / Web App",
+				Repository:  &gitlab.Repository{Homepage: "https://gitlab.example.com/acme/web-app"},
+			},
+			expect: "acme/web-app",
+		},
+		{
+			desc:   "Missing repository",
+			event:  gitlab.JobEvent{ProjectName: "acme / web-app"},
+			expect: "acme/web-app",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			require.Equal(t, test.expect, jobProjectPath(&test.event))
+		})
+	}
+}
+
+func TestPipelineResult(t *testing.T) {
+	tests := map[string]struct {
+		status        string
+		failureReason string
+		expect        string
+	}{
+		"success":        {status: "success", expect: "success"},
+		"script failure": {status: "failed", failureReason: "script_failure", expect: "failure"},
+		"timeout":        {status: "failed", failureReason: "job_execution_timeout", expect: "timeout"},
+		"runner failure": {status: "failed", failureReason: "runner_system_failure", expect: "error"},
+		"canceled":       {status: "canceled", expect: "cancellation"},
+		"manual":         {status: "manual", expect: "skip"},
+		"still running":  {status: "running", expect: ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expect, string(pipelineResult(test.status, test.failureReason)))
+		})
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2024, 3, 5, 10, 2, 0, 0, time.UTC)
+
+	for _, value := range []string{
+		"2024-03-05 10:02:00 UTC",
+		"2024-03-05 11:02:00 +0100",
+		"2024-03-05T10:02:00Z",
+	} {
+		require.True(t, expected.Equal(parseTime(value)), value)
+	}
+	require.True(t, parseTime("").IsZero())
+}
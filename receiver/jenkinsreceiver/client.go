@@ -0,0 +1,185 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// errNotFound is returned for missing resources, such as the wfapi of builds
+// that are not Pipeline builds.
+var errNotFound = errors.New("not found")
+
+// runDescription is a Pipeline build as described by the wfapi of the
+// Pipeline Stage View plugin.
+type runDescription struct {
+	ID                  string             `json:"id"`
+	Name                string             `json:"name"`
+	Status              string             `json:"status"`
+	StartTimeMillis     int64              `json:"startTimeMillis"`
+	EndTimeMillis       int64              `json:"endTimeMillis"`
+	DurationMillis      int64              `json:"durationMillis"`
+	QueueDurationMillis int64              `json:"queueDurationMillis"`
+	PauseDurationMillis int64              `json:"pauseDurationMillis"`
+	Stages              []stageDescription `json:"stages"`
+}
+
+// stageDescription is a stage of a Pipeline build. Nested stages, such as
+// declarative parallel stages, are listed after the stage running them.
+type stageDescription struct {
+	Links               wfLinks `json:"_links"`
+	ID                  string  `json:"id"`
+	Name                string  `json:"name"`
+	ExecNode            string  `json:"execNode"`
+	Status              string  `json:"status"`
+	StartTimeMillis     int64   `json:"startTimeMillis"`
+	DurationMillis      int64   `json:"durationMillis"`
+	PauseDurationMillis int64   `json:"pauseDurationMillis"`
+	// StageFlowNodes are the steps of the stage, only listed when the stage
+	// itself is described.
+	StageFlowNodes []flowNodeDescription `json:"stageFlowNodes"`
+}
+
+// flowNodeDescription is a step of a stage.
+type flowNodeDescription struct {
+	ID                   string   `json:"id"`
+	Name                 string   `json:"name"`
+	ExecNode             string   `json:"execNode"`
+	Status               string   `json:"status"`
+	ParameterDescription string   `json:"parameterDescription"`
+	StartTimeMillis      int64    `json:"startTimeMillis"`
+	DurationMillis       int64    `json:"durationMillis"`
+	PauseDurationMillis  int64    `json:"pauseDurationMillis"`
+	ParentNodes          []string `json:"parentNodes"`
+}
+
+type wfLinks struct {
+	Self struct {
+		Href string `json:"href"`
+	} `json:"self"`
+}
+
+// jenkinsClient reads builds from the Jenkins REST API. Only URLs of the
+// configured controller are requested, as notifications are not
+// authenticated by Jenkins and must not make the receiver send its
+// credentials elsewhere.
+type jenkinsClient struct {
+	baseURL  *url.URL
+	username string
+	token    string
+	client   *http.Client
+}
+
+func newJenkinsClient(cfg JenkinsAPIConfig) (*jenkinsClient, error) {
+	baseURL, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(baseURL.Path, "/") {
+		baseURL.Path += "/"
+	}
+
+	return &jenkinsClient{
+		baseURL:  baseURL,
+		username: cfg.Username,
+		token:    cfg.Token,
+		client:   &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+// describeRun describes a build and its stages, given its URL relative to
+// the root of the controller. It returns errNotFound for builds of other
+// job types than Pipeline.
+func (c *jenkinsClient) describeRun(ctx context.Context, buildURL string) (*runDescription, error) {
+	var run runDescription
+	if err := c.getJSON(ctx, strings.TrimSuffix(buildURL, "/")+"/wfapi/describe", &run); err != nil {
+		return nil, err
+	}
+
+	for i := range run.Stages {
+		stage := &run.Stages[i]
+		if stage.Links.Self.Href == "" {
+			continue
+		}
+
+		var described stageDescription
+		if err := c.getJSON(ctx, stage.Links.Self.Href, &described); err != nil {
+			return nil, fmt.Errorf("failed to describe stage %q: %w", stage.Name, err)
+		}
+		stage.StageFlowNodes = described.StageFlowNodes
+	}
+
+	return &run, nil
+}
+
+// consoleText returns the console output of a build.
+func (c *jenkinsClient) consoleText(ctx context.Context, buildURL string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, strings.TrimSuffix(buildURL, "/")+"/consoleText")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *jenkinsClient) getJSON(ctx context.Context, ref string, v any) error {
+	resp, err := c.get(ctx, ref)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// get requests a URL relative to the root of the controller, or an absolute
+// path on it such as the links of the wfapi.
+func (c *jenkinsClient) get(ctx context.Context, ref string) (*http.Response, error) {
+	u, err := c.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.SetBasicAuth(c.username, c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %w", u.Path, errNotFound)
+	case resp.StatusCode >= http.StatusBadRequest:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: unexpected status %s", u.Path, resp.Status)
+	}
+	return resp, nil
+}
+
+func (c *jenkinsClient) resolve(ref string) (*url.URL, error) {
+	r, err := url.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	u := c.baseURL.ResolveReference(r)
+	if u.Scheme != c.baseURL.Scheme || u.Host != c.baseURL.Host {
+		return nil, fmt.Errorf("refusing to request %q outside of %s", ref, c.baseURL)
+	}
+	return u, nil
+}
@@ -0,0 +1,137 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testBuildPath = "/job/acme/job/web-app/job/PR-12/42/"
+
+// newJenkinsTestServer serves the wfapi and console output of build 42 of
+// acme/web-app/PR-12.
+func newJenkinsTestServer(t *testing.T) *jenkinsClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, token, _ := r.BasicAuth()
+		require.Equal(t, "otel", username)
+		require.Equal(t, "api-token", token)
+
+		var name string
+		switch path := strings.TrimPrefix(r.URL.Path, testBuildPath); {
+		case !strings.HasPrefix(r.URL.Path, testBuildPath):
+		case path == "wfapi/describe":
+			name = "run_42.json"
+		case path == "consoleText":
+			name = "console_42.log"
+		case strings.HasPrefix(path, "execution/node/"):
+			id := strings.TrimSuffix(strings.TrimPrefix(path, "execution/node/"), "/wfapi/describe")
+			name = "stage_" + id + ".json"
+			if _, err := os.Stat(filepath.Join("testdata", name)); err != nil {
+				_, _ = w.Write([]byte(`{"id": "` + id + `", "stageFlowNodes": []}`))
+				return
+			}
+		}
+		if name == "" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, filepath.Join("testdata", name))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := newJenkinsClient(JenkinsAPIConfig{
+		BaseURL:  server.URL,
+		Username: "otel",
+		Token:    "api-token",
+		Timeout:  time.Second,
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestDescribeRun(t *testing.T) {
+	client := newJenkinsTestServer(t)
+
+	run, err := client.describeRun(t.Context(), strings.TrimPrefix(testBuildPath, "/"))
+	require.NoError(t, err)
+	require.Equal(t, int64(5000), run.QueueDurationMillis)
+	require.Len(t, run.Stages, 5)
+
+	// Stages are described with their steps.
+	checkout := run.Stages[0]
+	require.Len(t, checkout.StageFlowNodes, 1)
+	require.Equal(t, "Check out from version control", checkout.StageFlowNodes[0].Name)
+	require.Empty(t, run.Stages[1].StageFlowNodes)
+	require.Equal(t, "make integration", run.Stages[3].StageFlowNodes[0].ParameterDescription)
+
+	_, err = client.describeRun(t.Context(), "job/acme/job/web-app/job/main/1/")
+	require.ErrorIs(t, err, errNotFound)
+}
+
+func TestConsoleText(t *testing.T) {
+	client := newJenkinsTestServer(t)
+
+	body, err := client.consoleText(t.Context(), strings.TrimPrefix(testBuildPath, "/"))
+	require.NoError(t, err)
+	defer body.Close()
+
+	console, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(console), "Started by user admin\n"))
+}
+
+func TestResolve(t *testing.T) {
+	client, err := newJenkinsClient(JenkinsAPIConfig{BaseURL: "https://jenkins.example.com/ci"})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		ref       string
+		expectURL string
+		expectErr bool
+	}{
+		"relative to the controller": {
+			ref:       "job/app/1/wfapi/describe",
+			expectURL: "https://jenkins.example.com/ci/job/app/1/wfapi/describe",
+		},
+		"absolute path": {
+			ref:       "/ci/job/app/1/execution/node/6/wfapi/describe",
+			expectURL: "https://jenkins.example.com/ci/job/app/1/execution/node/6/wfapi/describe",
+		},
+		"other host": {
+			ref:       "https://attacker.example.com/job/app/1/",
+			expectErr: true,
+		},
+		"scheme-relative other host": {
+			ref:       "//attacker.example.com/job/app/1/",
+			expectErr: true,
+		},
+		"other scheme": {
+			ref:       "http://jenkins.example.com/ci/job/app/1/",
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			u, err := client.resolve(test.ref)
+			if test.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectURL, u.String())
+		})
+	}
+}
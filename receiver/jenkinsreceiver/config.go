@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver"
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.uber.org/multierr"
+)
+
+var errMissingEndpointFromConfig = errors.New("missing receiver server endpoint from config")
+var errBaseURL = errors.New("jenkins_api base_url must be an absolute http or https URL")
+var errMissingUsername = errors.New("jenkins_api username is required with a token")
+var errTimeout = errors.New("jenkins_api timeout must not be negative")
+var errQueueSize = errors.New("jenkins_api queue_size must be positive")
+
+// JenkinsAPIConfig defines configuration for the Jenkins REST API
+type JenkinsAPIConfig struct {
+	BaseURL   string        `mapstructure:"base_url"`   // root URL of the Jenkins controller, such as https://jenkins.example.com/. Default is empty, disabling stages, steps and logs
+	Username  string        `mapstructure:"username"`   // jenkins user the API token belongs to. Default is empty
+	Token     string        `mapstructure:"token"`      // jenkins API token. Default is empty, for anonymous read access
+	Timeout   time.Duration `mapstructure:"timeout"`    // timeout of each API request. Default is 30s
+	QueueSize int           `mapstructure:"queue_size"` // finalized builds waiting to be described, more are rejected. Default is 100
+}
+
+// Config defines configuration for Jenkins receiver
+type Config struct {
+	metadata.MetricsBuilderConfig `mapstructure:",squash"`
+	confighttp.ServerConfig       `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct
+	Path                          string                   `mapstructure:"path"`                // path for data collection. Default is <host>:<port>/jenkinsevents
+	Secret                        string                   `mapstructure:"secret"`              // token expected in the token query parameter of notifications. Default is empty
+	CustomServiceName             string                   `mapstructure:"custom_service_name"` // custom service name. Default is empty
+	ServiceNamePrefix             string                   `mapstructure:"service_name_prefix"` // service name prefix. Default is empty
+	ServiceNameSuffix             string                   `mapstructure:"service_name_suffix"` // service name suffix. Default is empty
+	JenkinsAPIConfig              JenkinsAPIConfig         `mapstructure:"jenkins_api"`         // jenkins api configuration
+	Logs                          logpolicy.Config         `mapstructure:"logs"`                // sampling, truncation and redaction policies applied to console output. failed_only applies to builds
+	Semconv                       semconv.Config           `mapstructure:"semconv"`             // OpenTelemetry CICD and VCS semantic conventions
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the receiver configuration is valid
+func (cfg *Config) Validate() error {
+	var errs error
+
+	if cfg.NetAddr.Endpoint == "" {
+		errs = multierr.Append(errs, errMissingEndpointFromConfig)
+	}
+
+	api := cfg.JenkinsAPIConfig
+	if api.BaseURL != "" {
+		if u, err := url.Parse(api.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, errBaseURL)
+		}
+	}
+	if api.Token != "" && api.Username == "" {
+		errs = multierr.Append(errs, errMissingUsername)
+	}
+	if api.Timeout < 0 {
+		errs = multierr.Append(errs, errTimeout)
+	}
+	if api.QueueSize < 1 {
+		errs = multierr.Append(errs, errQueueSize)
+	}
+
+	if err := cfg.Logs.Validate(); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("invalid logs configuration: %w", err))
+	}
+
+	return errs
+}
@@ -0,0 +1,167 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver/internal/metadata"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/confignet"
+	"go.opentelemetry.io/collector/confmap/confmaptest"
+	"go.opentelemetry.io/collector/confmap/xconfmap"
+)
+
+func TestValidateConfig(t *testing.T) {
+	t.Parallel()
+
+	serverConfig := confighttp.ServerConfig{
+		NetAddr: confignet.AddrConfig{
+			Transport: confignet.TransportTypeTCP,
+			Endpoint:  "localhost:8080",
+		},
+	}
+
+	tests := []struct {
+		desc   string
+		expect error
+		conf   Config
+	}{
+		{
+			desc:   "Missing valid endpoint",
+			expect: errMissingEndpointFromConfig,
+			conf: Config{
+				ServerConfig: confighttp.ServerConfig{
+					NetAddr: confignet.AddrConfig{
+						Transport: confignet.TransportTypeTCP,
+						Endpoint:  "",
+					},
+				},
+			},
+		},
+		{
+			desc:   "Valid Secret",
+			expect: nil,
+			conf: Config{
+				ServerConfig:     serverConfig,
+				Secret:           "mysecret",
+				JenkinsAPIConfig: JenkinsAPIConfig{QueueSize: defaultQueueSize},
+			},
+		},
+		{
+			desc:   "Jenkins API",
+			expect: nil,
+			conf: Config{
+				ServerConfig: serverConfig,
+				JenkinsAPIConfig: JenkinsAPIConfig{
+					BaseURL:   "https://jenkins.example.com/",
+					Username:  "otel",
+					Token:     "token",
+					QueueSize: defaultQueueSize,
+				},
+			},
+		},
+		{
+			desc:   "Relative base URL",
+			expect: errBaseURL,
+			conf: Config{
+				ServerConfig: serverConfig,
+				JenkinsAPIConfig: JenkinsAPIConfig{
+					BaseURL: "jenkins.example.com",
+				},
+			},
+		},
+		{
+			desc:   "Token without username",
+			expect: errMissingUsername,
+			conf: Config{
+				ServerConfig: serverConfig,
+				JenkinsAPIConfig: JenkinsAPIConfig{
+					BaseURL: "https://jenkins.example.com/",
+					Token:   "token",
+				},
+			},
+		},
+		{
+			desc:   "Negative timeout",
+			expect: errTimeout,
+			conf: Config{
+				ServerConfig: serverConfig,
+				JenkinsAPIConfig: JenkinsAPIConfig{
+					Timeout: -time.Second,
+				},
+			},
+		},
+		{
+			desc:   "Empty queue",
+			expect: errQueueSize,
+			conf: Config{
+				ServerConfig: serverConfig,
+			},
+		},
+		{
+			desc:   "Invalid logs policy",
+			expect: errors.New("invalid logs configuration"),
+			conf: Config{
+				ServerConfig: serverConfig,
+				Logs:         logpolicy.Config{Oversize: "wrap"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			err := test.conf.Validate()
+			if test.expect == nil {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.expect.Error())
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	cm, err := confmaptest.LoadConf(filepath.Join("testdata", "config.yaml"))
+	require.NoError(t, err)
+	// LoadConf includes the TypeStr which NewFactory does not set
+	id := component.NewIDWithName(metadata.Type, "valid_config")
+	sub, err := cm.Sub(id.String())
+	require.NoError(t, err)
+
+	expect := &Config{
+		MetricsBuilderConfig: metadata.DefaultMetricsBuilderConfig(),
+		ServerConfig: confighttp.ServerConfig{
+			NetAddr: confignet.AddrConfig{
+				Transport: confignet.TransportTypeTCP,
+				Endpoint:  "localhost:8080",
+			},
+		},
+		Path:   "/notifications",
+		Secret: "mysecret",
+		JenkinsAPIConfig: JenkinsAPIConfig{
+			BaseURL:   "https://jenkins.example.com/",
+			Username:  "otel",
+			Token:     "api-token",
+			Timeout:   defaultTimeout,
+			QueueSize: 50,
+		},
+	}
+
+	factory := NewFactory()
+	conf := factory.CreateDefaultConfig()
+	require.NoError(t, sub.Unmarshal(conf))
+	require.NoError(t, xconfmap.Validate(conf))
+
+	require.Equal(t, expect, conf)
+}
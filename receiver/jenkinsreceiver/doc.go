@@ -0,0 +1,6 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:generate ../../.tools/mdatagen metadata.yaml
+
+package jenkinsreceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver"
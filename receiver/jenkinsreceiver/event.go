@@ -0,0 +1,95 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Phases of the builds notified by the Notification plugin.
+const (
+	phaseQueued    = "QUEUED"
+	phaseStarted   = "STARTED"
+	phaseCompleted = "COMPLETED"
+	phaseFinalized = "FINALIZED"
+)
+
+var errMissingBuild = errors.New("notification has no build")
+
+// buildEvent is a build notification in the JSON format of the Jenkins
+// Notification plugin.
+type buildEvent struct {
+	Name        string     `json:"name"`
+	DisplayName string     `json:"display_name"`
+	URL         string     `json:"url"` // URL of the job, relative to the root of the controller
+	Build       buildState `json:"build"`
+}
+
+type buildState struct {
+	FullURL     string       `json:"full_url"`
+	Number      int64        `json:"number"`
+	QueueID     int64        `json:"queue_id"`
+	Timestamp   int64        `json:"timestamp"` // Unix time in milliseconds
+	Duration    int64        `json:"duration"`  // milliseconds
+	Phase       string       `json:"phase"`
+	Status      string       `json:"status"` // result of completed builds
+	URL         string       `json:"url"`    // URL of the build, relative to the root of the controller
+	SCM         scmState     `json:"scm"`
+	Log         string       `json:"log"` // last lines of the console output, if configured on the endpoint
+	TestSummary *testSummary `json:"test_summary"`
+}
+
+type scmState struct {
+	URL    string `json:"url"`
+	Branch string `json:"branch"`
+	Commit string `json:"commit"`
+}
+
+type testSummary struct {
+	Total   int64 `json:"total"`
+	Failed  int64 `json:"failed"`
+	Passed  int64 `json:"passed"`
+	Skipped int64 `json:"skipped"`
+}
+
+func parseBuildEvent(payload []byte) (*buildEvent, error) {
+	var e buildEvent
+	if err := json.Unmarshal(payload, &e); err != nil {
+		return nil, err
+	}
+	if e.Build.Number == 0 || e.Build.Phase == "" {
+		return nil, errMissingBuild
+	}
+	return &e, nil
+}
+
+// jobName returns the full name of the job, including its folders and
+// multibranch project, as in the JOB_NAME environment variable of builds.
+func (e *buildEvent) jobName() string {
+	var segments []string
+	parts := strings.Split(strings.Trim(e.URL, "/"), "/")
+	for i := 0; i+1 < len(parts); i += 2 {
+		if parts[i] != "job" {
+			break
+		}
+		segment, err := url.PathUnescape(parts[i+1])
+		if err != nil {
+			segment = parts[i+1]
+		}
+		segments = append(segments, segment)
+	}
+
+	if len(segments) == 0 {
+		return e.Name
+	}
+	return strings.Join(segments, "/")
+}
+
+// finished reports whether the build has a result.
+func (e *buildEvent) finished() bool {
+	return e.Build.Phase == phaseCompleted || e.Build.Phase == phaseFinalized
+}
@@ -0,0 +1,60 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver"
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/confignet"
+	"go.opentelemetry.io/collector/receiver"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver/internal/metadata"
+)
+
+// This file implements factory for Jenkins receiver.
+
+const (
+	defaultBindEndpoint = "0.0.0.0:19420"
+	defaultPath         = "/jenkinsevents"
+	defaultTimeout      = 30 * time.Second
+	defaultQueueSize    = 100
+)
+
+// NewFactory creates a new Jenkins receiver factory
+func NewFactory() receiver.Factory {
+	return receiver.NewFactory(
+		metadata.Type,
+		createDefaultConfig,
+		receiver.WithTraces(newTracesReceiver, metadata.TracesStability),
+		receiver.WithLogs(newLogsReceiver, metadata.LogsStability),
+		receiver.WithMetrics(newMetricsReceiver, metadata.MetricsStability),
+	)
+}
+
+// createDefaultConfig creates the default configuration for Jenkins receiver.
+func createDefaultConfig() component.Config {
+	return &Config{
+		MetricsBuilderConfig: metadata.DefaultMetricsBuilderConfig(),
+		ServerConfig: confighttp.ServerConfig{
+			NetAddr: confignet.AddrConfig{
+				Transport: confignet.TransportTypeTCP,
+				Endpoint:  defaultBindEndpoint,
+			},
+		},
+		Path: defaultPath,
+		JenkinsAPIConfig: JenkinsAPIConfig{
+			Timeout:   defaultTimeout,
+			QueueSize: defaultQueueSize,
+		},
+	}
+}
+
+// This is the map of already created jenkins receivers for particular configurations.
+// We maintain this map because the Factory is asked trace, log and metric receivers
+// separately but they must not create separate objects, they must use one receiver
+// object per configuration.
+var receivers = sharedcomponent.NewSharedComponents()
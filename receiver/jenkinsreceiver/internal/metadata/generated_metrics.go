@@ -0,0 +1,407 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+)
+
+// AttributeCiJenkinsBuildPhase specifies the value ci.jenkins.build.phase attribute.
+type AttributeCiJenkinsBuildPhase int
+
+const (
+	_ AttributeCiJenkinsBuildPhase = iota
+	AttributeCiJenkinsBuildPhaseQUEUED
+	AttributeCiJenkinsBuildPhaseSTARTED
+	AttributeCiJenkinsBuildPhaseCOMPLETED
+	AttributeCiJenkinsBuildPhaseFINALIZED
+)
+
+// String returns the string representation of the AttributeCiJenkinsBuildPhase.
+func (av AttributeCiJenkinsBuildPhase) String() string {
+	switch av {
+	case AttributeCiJenkinsBuildPhaseQUEUED:
+		return "QUEUED"
+	case AttributeCiJenkinsBuildPhaseSTARTED:
+		return "STARTED"
+	case AttributeCiJenkinsBuildPhaseCOMPLETED:
+		return "COMPLETED"
+	case AttributeCiJenkinsBuildPhaseFINALIZED:
+		return "FINALIZED"
+	}
+	return ""
+}
+
+// MapAttributeCiJenkinsBuildPhase is a helper map of string to AttributeCiJenkinsBuildPhase attribute value.
+var MapAttributeCiJenkinsBuildPhase = map[string]AttributeCiJenkinsBuildPhase{
+	"QUEUED":    AttributeCiJenkinsBuildPhaseQUEUED,
+	"STARTED":   AttributeCiJenkinsBuildPhaseSTARTED,
+	"COMPLETED": AttributeCiJenkinsBuildPhaseCOMPLETED,
+	"FINALIZED": AttributeCiJenkinsBuildPhaseFINALIZED,
+}
+
+// AttributeCiJenkinsBuildResult specifies the value ci.jenkins.build.result attribute.
+type AttributeCiJenkinsBuildResult int
+
+const (
+	_ AttributeCiJenkinsBuildResult = iota
+	AttributeCiJenkinsBuildResultSUCCESS
+	AttributeCiJenkinsBuildResultUNSTABLE
+	AttributeCiJenkinsBuildResultFAILURE
+	AttributeCiJenkinsBuildResultNOTBUILT
+	AttributeCiJenkinsBuildResultABORTED
+	AttributeCiJenkinsBuildResultNull
+)
+
+// String returns the string representation of the AttributeCiJenkinsBuildResult.
+func (av AttributeCiJenkinsBuildResult) String() string {
+	switch av {
+	case AttributeCiJenkinsBuildResultSUCCESS:
+		return "SUCCESS"
+	case AttributeCiJenkinsBuildResultUNSTABLE:
+		return "UNSTABLE"
+	case AttributeCiJenkinsBuildResultFAILURE:
+		return "FAILURE"
+	case AttributeCiJenkinsBuildResultNOTBUILT:
+		return "NOT_BUILT"
+	case AttributeCiJenkinsBuildResultABORTED:
+		return "ABORTED"
+	case AttributeCiJenkinsBuildResultNull:
+		return "null"
+	}
+	return ""
+}
+
+// MapAttributeCiJenkinsBuildResult is a helper map of string to AttributeCiJenkinsBuildResult attribute value.
+var MapAttributeCiJenkinsBuildResult = map[string]AttributeCiJenkinsBuildResult{
+	"SUCCESS":   AttributeCiJenkinsBuildResultSUCCESS,
+	"UNSTABLE":  AttributeCiJenkinsBuildResultUNSTABLE,
+	"FAILURE":   AttributeCiJenkinsBuildResultFAILURE,
+	"NOT_BUILT": AttributeCiJenkinsBuildResultNOTBUILT,
+	"ABORTED":   AttributeCiJenkinsBuildResultABORTED,
+	"null":      AttributeCiJenkinsBuildResultNull,
+}
+
+// AttributeCiJenkinsStageStatus specifies the value ci.jenkins.stage.status attribute.
+type AttributeCiJenkinsStageStatus int
+
+const (
+	_ AttributeCiJenkinsStageStatus = iota
+	AttributeCiJenkinsStageStatusSUCCESS
+	AttributeCiJenkinsStageStatusUNSTABLE
+	AttributeCiJenkinsStageStatusFAILED
+	AttributeCiJenkinsStageStatusABORTED
+	AttributeCiJenkinsStageStatusNOTEXECUTED
+	AttributeCiJenkinsStageStatusINPROGRESS
+	AttributeCiJenkinsStageStatusPAUSEDPENDINGINPUT
+)
+
+// String returns the string representation of the AttributeCiJenkinsStageStatus.
+func (av AttributeCiJenkinsStageStatus) String() string {
+	switch av {
+	case AttributeCiJenkinsStageStatusSUCCESS:
+		return "SUCCESS"
+	case AttributeCiJenkinsStageStatusUNSTABLE:
+		return "UNSTABLE"
+	case AttributeCiJenkinsStageStatusFAILED:
+		return "FAILED"
+	case AttributeCiJenkinsStageStatusABORTED:
+		return "ABORTED"
+	case AttributeCiJenkinsStageStatusNOTEXECUTED:
+		return "NOT_EXECUTED"
+	case AttributeCiJenkinsStageStatusINPROGRESS:
+		return "IN_PROGRESS"
+	case AttributeCiJenkinsStageStatusPAUSEDPENDINGINPUT:
+		return "PAUSED_PENDING_INPUT"
+	}
+	return ""
+}
+
+// MapAttributeCiJenkinsStageStatus is a helper map of string to AttributeCiJenkinsStageStatus attribute value.
+var MapAttributeCiJenkinsStageStatus = map[string]AttributeCiJenkinsStageStatus{
+	"SUCCESS":              AttributeCiJenkinsStageStatusSUCCESS,
+	"UNSTABLE":             AttributeCiJenkinsStageStatusUNSTABLE,
+	"FAILED":               AttributeCiJenkinsStageStatusFAILED,
+	"ABORTED":              AttributeCiJenkinsStageStatusABORTED,
+	"NOT_EXECUTED":         AttributeCiJenkinsStageStatusNOTEXECUTED,
+	"IN_PROGRESS":          AttributeCiJenkinsStageStatusINPROGRESS,
+	"PAUSED_PENDING_INPUT": AttributeCiJenkinsStageStatusPAUSEDPENDINGINPUT,
+}
+
+var MetricsInfo = metricsInfo{
+	BuildsCount: metricInfo{
+		Name: "builds.count",
+	},
+	StagesCount: metricInfo{
+		Name: "stages.count",
+	},
+}
+
+type metricsInfo struct {
+	BuildsCount metricInfo
+	StagesCount metricInfo
+}
+
+type metricInfo struct {
+	Name string
+}
+
+type metricBuildsCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills builds.count metric with initial data.
+func (m *metricBuildsCount) init() {
+	m.data.SetName("builds.count")
+	m.data.SetDescription("Number of build notifications, by phase and result.")
+	m.data.SetUnit("{build}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricBuildsCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciJenkinsJobNameAttributeValue string, ciJenkinsBuildPhaseAttributeValue string, ciJenkinsBuildResultAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.jenkins.job.name", ciJenkinsJobNameAttributeValue)
+	dp.Attributes().PutStr("ci.jenkins.build.phase", ciJenkinsBuildPhaseAttributeValue)
+	dp.Attributes().PutStr("ci.jenkins.build.result", ciJenkinsBuildResultAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricBuildsCount) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricBuildsCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricBuildsCount(cfg MetricConfig) metricBuildsCount {
+	m := metricBuildsCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricStagesCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills stages.count metric with initial data.
+func (m *metricStagesCount) init() {
+	m.data.SetName("stages.count")
+	m.data.SetDescription("Number of stages of finalized builds, by status.")
+	m.data.SetUnit("{stage}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricStagesCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciJenkinsJobNameAttributeValue string, ciJenkinsStageStatusAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.jenkins.job.name", ciJenkinsJobNameAttributeValue)
+	dp.Attributes().PutStr("ci.jenkins.stage.status", ciJenkinsStageStatusAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricStagesCount) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricStagesCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricStagesCount(cfg MetricConfig) metricStagesCount {
+	m := metricStagesCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+// MetricsBuilder provides an interface for scrapers to report metrics while taking care of all the transformations
+// required to produce metric representation defined in metadata and user config.
+type MetricsBuilder struct {
+	config            MetricsBuilderConfig // config of the metrics builder.
+	startTime         pcommon.Timestamp    // start time that will be applied to all recorded data points.
+	metricsCapacity   int                  // maximum observed number of metrics per resource.
+	metricsBuffer     pmetric.Metrics      // accumulates metrics data before emitting.
+	buildInfo         component.BuildInfo  // contains version information.
+	metricBuildsCount metricBuildsCount
+	metricStagesCount metricStagesCount
+}
+
+// MetricBuilderOption applies changes to default metrics builder.
+type MetricBuilderOption interface {
+	apply(*MetricsBuilder)
+}
+
+type metricBuilderOptionFunc func(mb *MetricsBuilder)
+
+func (mbof metricBuilderOptionFunc) apply(mb *MetricsBuilder) {
+	mbof(mb)
+}
+
+// WithStartTime sets startTime on the metrics builder.
+func WithStartTime(startTime pcommon.Timestamp) MetricBuilderOption {
+	return metricBuilderOptionFunc(func(mb *MetricsBuilder) {
+		mb.startTime = startTime
+	})
+}
+func NewMetricsBuilder(mbc MetricsBuilderConfig, settings receiver.Settings, options ...MetricBuilderOption) *MetricsBuilder {
+	mb := &MetricsBuilder{
+		config:            mbc,
+		startTime:         pcommon.NewTimestampFromTime(time.Now()),
+		metricsBuffer:     pmetric.NewMetrics(),
+		buildInfo:         settings.BuildInfo,
+		metricBuildsCount: newMetricBuildsCount(mbc.Metrics.BuildsCount),
+		metricStagesCount: newMetricStagesCount(mbc.Metrics.StagesCount),
+	}
+
+	for _, op := range options {
+		op.apply(mb)
+	}
+	return mb
+}
+
+// updateCapacity updates max length of metrics and resource attributes that will be used for the slice capacity.
+func (mb *MetricsBuilder) updateCapacity(rm pmetric.ResourceMetrics) {
+	if mb.metricsCapacity < rm.ScopeMetrics().At(0).Metrics().Len() {
+		mb.metricsCapacity = rm.ScopeMetrics().At(0).Metrics().Len()
+	}
+}
+
+// ResourceMetricsOption applies changes to provided resource metrics.
+type ResourceMetricsOption interface {
+	apply(pmetric.ResourceMetrics)
+}
+
+type resourceMetricsOptionFunc func(pmetric.ResourceMetrics)
+
+func (rmof resourceMetricsOptionFunc) apply(rm pmetric.ResourceMetrics) {
+	rmof(rm)
+}
+
+// WithResource sets the provided resource on the emitted ResourceMetrics.
+// It's recommended to use ResourceBuilder to create the resource.
+func WithResource(res pcommon.Resource) ResourceMetricsOption {
+	return resourceMetricsOptionFunc(func(rm pmetric.ResourceMetrics) {
+		res.CopyTo(rm.Resource())
+	})
+}
+
+// WithStartTimeOverride overrides start time for all the resource metrics data points.
+// This option should be only used if different start time has to be set on metrics coming from different resources.
+func WithStartTimeOverride(start pcommon.Timestamp) ResourceMetricsOption {
+	return resourceMetricsOptionFunc(func(rm pmetric.ResourceMetrics) {
+		var dps pmetric.NumberDataPointSlice
+		metrics := rm.ScopeMetrics().At(0).Metrics()
+		for i := 0; i < metrics.Len(); i++ {
+			switch metrics.At(i).Type() {
+			case pmetric.MetricTypeGauge:
+				dps = metrics.At(i).Gauge().DataPoints()
+			case pmetric.MetricTypeSum:
+				dps = metrics.At(i).Sum().DataPoints()
+			}
+			for j := 0; j < dps.Len(); j++ {
+				dps.At(j).SetStartTimestamp(start)
+			}
+		}
+	})
+}
+
+// EmitForResource saves all the generated metrics under a new resource and updates the internal state to be ready for
+// recording another set of data points as part of another resource. This function can be helpful when one scraper
+// needs to emit metrics from several resources. Otherwise calling this function is not required,
+// just `Emit` function can be called instead.
+// Resource attributes should be provided as ResourceMetricsOption arguments.
+func (mb *MetricsBuilder) EmitForResource(options ...ResourceMetricsOption) {
+	rm := pmetric.NewResourceMetrics()
+	ils := rm.ScopeMetrics().AppendEmpty()
+	ils.Scope().SetName(ScopeName)
+	ils.Scope().SetVersion(mb.buildInfo.Version)
+	ils.Metrics().EnsureCapacity(mb.metricsCapacity)
+	mb.metricBuildsCount.emit(ils.Metrics())
+	mb.metricStagesCount.emit(ils.Metrics())
+
+	for _, op := range options {
+		op.apply(rm)
+	}
+
+	if ils.Metrics().Len() > 0 {
+		mb.updateCapacity(rm)
+		rm.MoveTo(mb.metricsBuffer.ResourceMetrics().AppendEmpty())
+	}
+}
+
+// Emit returns all the metrics accumulated by the metrics builder and updates the internal state to be ready for
+// recording another set of metrics. This function will be responsible for applying all the transformations required to
+// produce metric representation defined in metadata and user config, e.g. delta or cumulative.
+func (mb *MetricsBuilder) Emit(options ...ResourceMetricsOption) pmetric.Metrics {
+	mb.EmitForResource(options...)
+	metrics := mb.metricsBuffer
+	mb.metricsBuffer = pmetric.NewMetrics()
+	return metrics
+}
+
+// RecordBuildsCountDataPoint adds a data point to builds.count metric.
+func (mb *MetricsBuilder) RecordBuildsCountDataPoint(ts pcommon.Timestamp, val int64, ciJenkinsJobNameAttributeValue string, ciJenkinsBuildPhaseAttributeValue AttributeCiJenkinsBuildPhase, ciJenkinsBuildResultAttributeValue AttributeCiJenkinsBuildResult) {
+	mb.metricBuildsCount.recordDataPoint(mb.startTime, ts, val, ciJenkinsJobNameAttributeValue, ciJenkinsBuildPhaseAttributeValue.String(), ciJenkinsBuildResultAttributeValue.String())
+}
+
+// RecordStagesCountDataPoint adds a data point to stages.count metric.
+func (mb *MetricsBuilder) RecordStagesCountDataPoint(ts pcommon.Timestamp, val int64, ciJenkinsJobNameAttributeValue string, ciJenkinsStageStatusAttributeValue AttributeCiJenkinsStageStatus) {
+	mb.metricStagesCount.recordDataPoint(mb.startTime, ts, val, ciJenkinsJobNameAttributeValue, ciJenkinsStageStatusAttributeValue.String())
+}
+
+// Reset resets metrics builder to its initial state. It should be used when external metrics source is restarted,
+// and metrics builder should update its startTime and reset it's internal state accordingly.
+func (mb *MetricsBuilder) Reset(options ...MetricBuilderOption) {
+	mb.startTime = pcommon.NewTimestampFromTime(time.Now())
+	for _, op := range options {
+		op.apply(mb)
+	}
+}
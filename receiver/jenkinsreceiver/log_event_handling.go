@@ -0,0 +1,154 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+)
+
+var (
+	// ansiEscape matches the colour sequences of the AnsiColor plugin.
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+	// blockStart matches the start of the blocks of Pipeline steps in the
+	// console output, capturing the name of stages and parallel branches.
+	blockStart = regexp.MustCompile(`^\[Pipeline\] \{(?: \((.*)\))?$`)
+)
+
+// branchPrefix prefixes the names of parallel branches in the console output.
+const branchPrefix = "Branch: "
+
+// buildEventToLogs fetches the console output of a finalized build and
+// translates it to log records correlated with the spans of its stages.
+// Without an API client, the last lines included in the notification are
+// used instead.
+func buildEventToLogs(ctx context.Context, e *buildEvent, run *runDescription, config *Config, client *jenkinsClient, policy *logpolicy.Policy, logger *zap.Logger) (*plog.Logs, error) {
+	pipeline := buildEventToPipeline(e, run, config)
+
+	if !policy.KeepLogs(pipeline.Result.Failed()) {
+		logger.Debug("Skipping logs of build", zap.String("job", pipeline.Name), zap.Int64("number", e.Build.Number), zap.String("result", e.Build.Status))
+		return nil, nil
+	}
+
+	var console io.Reader = strings.NewReader(e.Build.Log)
+	if client != nil {
+		body, err := client.consoleText(ctx, e.Build.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get console output of %s #%d: %w", pipeline.Name, e.Build.Number, err)
+		}
+		defer body.Close()
+		console = body
+	}
+
+	if err := parseConsole(console, &pipeline); err != nil {
+		return nil, fmt.Errorf("failed to read console output of %s #%d: %w", pipeline.Name, e.Build.Number, err)
+	}
+
+	pipeline.LogAttributes = map[string]any{
+		"ci.jenkins.job.name":     pipeline.Name,
+		"ci.jenkins.build.number": e.Build.Number,
+	}
+	for i := range pipeline.Tasks {
+		task := &pipeline.Tasks[i]
+		task.LogAttributes = map[string]any{
+			"ci.jenkins.job.name":     pipeline.Name,
+			"ci.jenkins.build.number": e.Build.Number,
+			"ci.jenkins.stage.name":   task.Name,
+		}
+	}
+
+	logs := cimodel.ToLogs(&pipeline, policy, traceOptions(config))
+	return &logs, nil
+}
+
+// parseConsole splits the console output of a build into the logs of its
+// stages, and of the build for the lines outside of any stage. The output of
+// parallel branches is interleaved, so it is attributed to the stage running
+// them. Lines are timestamped with the time their stage started at, as the
+// console output has no timestamps.
+func parseConsole(r io.Reader, p *cimodel.Pipeline) error {
+	stages := make(map[string]*cimodel.Task, len(p.Tasks))
+	for i := range p.Tasks {
+		if _, ok := stages[p.Tasks[i].Name]; !ok {
+			stages[p.Tasks[i].Name] = &p.Tasks[i]
+		}
+	}
+
+	// blocks are the names of the blocks the current line is in, empty for
+	// blocks other than stages and parallel branches.
+	var blocks []string
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+
+		if body, ok := parseConsoleLine(line); ok {
+			switch {
+			case blockStart.MatchString(body):
+				blocks = append(blocks, blockStart.FindStringSubmatch(body)[1])
+			case body == "[Pipeline] }":
+				if len(blocks) > 0 {
+					blocks = blocks[:len(blocks)-1]
+				}
+			case strings.HasPrefix(body, "[Pipeline] "):
+				// Other step markers, such as [Pipeline] sh
+			default:
+				if task := currentStage(blocks, stages); task != nil {
+					task.Logs = append(task.Logs, cimodel.LogEntry{Timestamp: task.Started, Body: body})
+				} else {
+					p.Logs = append(p.Logs, cimodel.LogEntry{Timestamp: p.Started, Body: body})
+				}
+			}
+		}
+
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// currentStage returns the innermost stage of the blocks, outside of
+// parallel branches.
+func currentStage(blocks []string, stages map[string]*cimodel.Task) *cimodel.Task {
+	var current *cimodel.Task
+	for _, name := range blocks {
+		if strings.HasPrefix(name, branchPrefix) {
+			break
+		}
+		if task, ok := stages[name]; ok {
+			current = task
+		}
+	}
+	return current
+}
+
+// parseConsoleLine strips the escape sequences from a line, keeping the text
+// written after the last carriage return of progress output.
+func parseConsoleLine(line string) (string, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", false
+	}
+
+	line = ansiEscape.ReplaceAllString(line, "")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+
+	line = strings.TrimRight(line, " ")
+	return line, line != ""
+}
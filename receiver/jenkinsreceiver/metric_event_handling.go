@@ -0,0 +1,212 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.uber.org/zap"
+)
+
+const metricsMaxCacheSize = 100000
+const histogramCacheSize = 50000
+const histogramTTL = 24 * time.Hour
+
+// resultNull is the result of builds that are not completed yet.
+const resultNull = "null"
+
+type metricsHandler struct {
+	mu             sync.Mutex
+	mb             *metadata.MetricsBuilder
+	cfg            *Config
+	logger         *zap.Logger
+	countersCache  *lru.Cache[string, int64]
+	histogramCache *lru.Cache[string, *cimodel.Histogram]
+	durations      *cimodel.Durations
+}
+
+func newMetricsHandler(settings receiver.Settings, cfg *Config, logger *zap.Logger) (*metricsHandler, error) {
+	countersCache, err := lru.New[string, int64](metricsMaxCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize counters cache: %w", err)
+	}
+
+	// histogramCache stores cumulative histogram state per unique dimension set,
+	// as histograms are emitted with cumulative temporality.
+	histogramCache, err := lru.New[string, *cimodel.Histogram](histogramCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize histogram cache: %w", err)
+	}
+
+	durations, err := cimodel.NewDurations(histogramCacheSize, histogramTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize durations cache: %w", err)
+	}
+
+	return &metricsHandler{
+		cfg:            cfg,
+		mb:             metadata.NewMetricsBuilder(cfg.MetricsBuilderConfig, settings),
+		logger:         logger,
+		countersCache:  countersCache,
+		histogramCache: histogramCache,
+		durations:      durations,
+	}, nil
+}
+
+// buildEventToMetrics counts the builds of every phase, and the stages and
+// durations of finalized builds. The stages of Pipeline builds are described
+// by run, which is nil for other builds.
+func (m *metricsHandler) buildEventToMetrics(e *buildEvent, run *runDescription) pmetric.Metrics {
+	job := e.jobName()
+	result := e.Build.Status
+	if result == "" {
+		result = resultNull
+	}
+
+	m.logger.Debug("Processing build notification",
+		zap.String("job", job),
+		zap.Int64("number", e.Build.Number),
+		zap.String("phase", e.Build.Phase),
+		zap.String("result", result),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	phase, phaseOK := metadata.MapAttributeCiJenkinsBuildPhase[e.Build.Phase]
+	buildResult, resultOK := metadata.MapAttributeCiJenkinsBuildResult[result]
+	if phaseOK && resultOK {
+		key := buildCounterKey(job, phase, buildResult)
+		val, found := m.countersCache.Get(key)
+		if !found {
+			// The counters of the other phases and results start at zero,
+			// so that their increases are visible from their first event.
+			for _, p := range metadata.MapAttributeCiJenkinsBuildPhase {
+				for _, r := range metadata.MapAttributeCiJenkinsBuildResult {
+					if !plausibleBuild(p, r) || (p == phase && r == buildResult) {
+						continue
+					}
+					if m.seedCounter(buildCounterKey(job, p, r)) {
+						m.mb.RecordBuildsCountDataPoint(now, 0, job, p, r)
+					}
+				}
+			}
+		}
+		m.countersCache.Add(key, val+1)
+		m.mb.RecordBuildsCountDataPoint(now, val+1, job, phase, buildResult)
+	}
+
+	if e.Build.Phase != phaseFinalized {
+		return m.mb.Emit()
+	}
+
+	pipeline := buildPipeline(e, run)
+	if run != nil {
+		for _, stage := range run.Stages {
+			status, ok := metadata.MapAttributeCiJenkinsStageStatus[stage.Status]
+			if !ok {
+				continue
+			}
+			key := fmt.Sprintf("stage:%s:%s", job, status.String())
+			val, _ := m.countersCache.Get(key)
+			m.countersCache.Add(key, val+1)
+			m.mb.RecordStagesCountDataPoint(now, val+1, job, status)
+		}
+	}
+
+	metrics := m.mb.Emit()
+	ms := scopeMetrics(metrics)
+
+	if m.cfg.Semconv.EmitsLegacy() {
+		if !pipeline.Started.IsZero() && !pipeline.Finished.IsZero() {
+			key := fmt.Sprintf("hist:build:%s:%s", job, e.Build.Status)
+			cimodel.AppendHistogram(ms, "builds.duration", map[string]any{
+				"ci.jenkins.job.name":     job,
+				"ci.jenkins.build.result": e.Build.Status,
+			}, m.observeDuration(key, pipeline.Finished.Sub(pipeline.Started).Seconds()))
+		}
+
+		for _, task := range pipeline.Tasks {
+			// Stages that were not executed have no duration.
+			if task.Result == cimodel.ResultSkip || task.Started.IsZero() {
+				continue
+			}
+			key := fmt.Sprintf("hist:stage:%s:%s:%s", job, task.Name, task.Status)
+			cimodel.AppendHistogram(ms, "stages.duration", map[string]any{
+				"ci.jenkins.job.name":     job,
+				"ci.jenkins.stage.name":   task.Name,
+				"ci.jenkins.stage.status": task.Status,
+			}, m.observeDuration(key, task.Finished.Sub(task.Started).Seconds()))
+		}
+	}
+
+	if m.cfg.Semconv.Enabled {
+		m.durations.AppendPipeline(ms, &pipeline)
+		for i := range pipeline.Tasks {
+			if pipeline.Tasks[i].Result != cimodel.ResultSkip {
+				m.durations.AppendTask(ms, &pipeline, &pipeline.Tasks[i])
+			}
+		}
+	}
+
+	return metrics
+}
+
+func buildCounterKey(job string, phase metadata.AttributeCiJenkinsBuildPhase, result metadata.AttributeCiJenkinsBuildResult) string {
+	return fmt.Sprintf("build:%s:%s:%s", job, phase.String(), result.String())
+}
+
+// plausibleBuild reports whether builds can be notified with a phase and
+// result: builds only have a result once completed.
+func plausibleBuild(phase metadata.AttributeCiJenkinsBuildPhase, result metadata.AttributeCiJenkinsBuildResult) bool {
+	switch phase {
+	case metadata.AttributeCiJenkinsBuildPhaseQUEUED, metadata.AttributeCiJenkinsBuildPhaseSTARTED:
+		return result == metadata.AttributeCiJenkinsBuildResultNull
+	default:
+		return result != metadata.AttributeCiJenkinsBuildResultNull
+	}
+}
+
+// scopeMetrics returns the metrics emitted by the metrics builder, which
+// emits no resource at all when no counter was recorded.
+func scopeMetrics(metrics pmetric.Metrics) pmetric.MetricSlice {
+	if metrics.ResourceMetrics().Len() == 0 {
+		scope := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+		scope.Scope().SetName(metadata.ScopeName)
+		return scope.Metrics()
+	}
+	return metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+}
+
+// seedCounter starts the counter cached under key at zero, reporting
+// whether it was unknown. Called under m.mu.
+func (m *metricsHandler) seedCounter(key string) bool {
+	if m.countersCache.Contains(key) {
+		return false
+	}
+	m.countersCache.Add(key, 0)
+	return true
+}
+
+// observeDuration records a duration in the histogram cached under key.
+// Called under m.mu.
+func (m *metricsHandler) observeDuration(key string, duration float64) *cimodel.Histogram {
+	// Stale histograms start over, the LRU evicts those never observed again
+	h, ok := m.histogramCache.Get(key)
+	if !ok || time.Since(h.LastSeen) >= histogramTTL {
+		h = cimodel.NewHistogram()
+	}
+	h.Observe(duration)
+	m.histogramCache.Add(key, h)
+	return h
+}
@@ -0,0 +1,163 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver/internal/metadata"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestMetricsHandler(t *testing.T, cfg *Config) *metricsHandler {
+	t.Helper()
+	cfg.MetricsBuilderConfig = metadata.DefaultMetricsBuilderConfig()
+	mh, err := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return mh
+}
+
+// metricNames returns the names of the metrics, with their data point counts.
+func metricNames(metrics pmetric.Metrics) map[string]int {
+	names := map[string]int{}
+	for i := range metrics.ResourceMetrics().Len() {
+		sms := metrics.ResourceMetrics().At(i).ScopeMetrics()
+		for j := range sms.Len() {
+			ms := sms.At(j).Metrics()
+			for k := range ms.Len() {
+				m := ms.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					names[m.Name()] += m.Sum().DataPoints().Len()
+				case pmetric.MetricTypeHistogram:
+					names[m.Name()] += m.Histogram().DataPoints().Len()
+				}
+			}
+		}
+	}
+	return names
+}
+
+func TestBuildEventToMetrics(t *testing.T) {
+	e := loadEvent(t, "build_finalized.json")
+	run := loadRun(t)
+	mh := newTestMetricsHandler(t, &Config{})
+
+	started := *e
+	started.Build.Phase = phaseStarted
+	started.Build.Status = ""
+
+	// The first notification seeds the counters of the other phases and
+	// results: 2 phases without a result, 2 with one of 5 results.
+	metrics := mh.buildEventToMetrics(&started, nil)
+	require.Equal(t, map[string]int{"builds.count": 12}, metricNames(metrics))
+
+	metrics = mh.buildEventToMetrics(e, run)
+	require.Equal(t, map[string]int{
+		"builds.count":    1,
+		"builds.duration": 1,
+		"stages.count":    5,
+		// Stages that were not executed have no duration.
+		"stages.duration": 4,
+	}, metricNames(metrics))
+
+	dp := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, int64(1), dp.IntValue())
+	phase, _ := dp.Attributes().Get("ci.jenkins.build.phase")
+	require.Equal(t, phaseFinalized, phase.Str())
+	result, _ := dp.Attributes().Get("ci.jenkins.build.result")
+	require.Equal(t, "FAILURE", result.Str())
+
+	// The counters keep counting.
+	metrics = mh.buildEventToMetrics(e, run)
+	dp = metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, int64(2), dp.IntValue())
+	val, ok := mh.countersCache.Get("stage:acme/web-app/PR-12:FAILED")
+	require.True(t, ok)
+	require.Equal(t, int64(4), val)
+}
+
+func TestBuildEventToMetricsSemconv(t *testing.T) {
+	e := loadEvent(t, "build_finalized.json")
+	run := loadRun(t)
+
+	tests := []struct {
+		desc          string
+		semconv       semconv.Config
+		expectMetrics []string
+	}{
+		{
+			desc:          "Legacy metrics",
+			expectMetrics: []string{"builds.count", "stages.count", "builds.duration", "stages.duration"},
+		},
+		{
+			desc:          "Semantic conventions",
+			semconv:       semconv.Config{Enabled: true},
+			expectMetrics: []string{"builds.count", "stages.count", semconv.MetricCICDPipelineRunDuration, semconv.MetricCICDPipelineTaskRunDuration},
+		},
+		{
+			desc:    "Semantic conventions with legacy metrics",
+			semconv: semconv.Config{Enabled: true, EmitLegacy: true},
+			expectMetrics: []string{
+				"builds.count", "stages.count", "builds.duration", "stages.duration",
+				semconv.MetricCICDPipelineRunDuration, semconv.MetricCICDPipelineTaskRunDuration,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			mh := newTestMetricsHandler(t, &Config{Semconv: test.semconv})
+
+			names := metricNames(mh.buildEventToMetrics(e, run))
+			require.Len(t, names, len(test.expectMetrics))
+			for _, name := range test.expectMetrics {
+				require.Contains(t, names, name)
+			}
+		})
+	}
+}
+
+func TestBuildEventToMetricsFreestyle(t *testing.T) {
+	e := loadEvent(t, "build_finalized.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	names := metricNames(mh.buildEventToMetrics(e, nil))
+	require.Equal(t, map[string]int{"builds.count": 12, "builds.duration": 1}, names)
+}
+
+func TestBuildEventToMetricsConcurrency(t *testing.T) {
+	e := loadEvent(t, "build_finalized.json")
+	mh := newTestMetricsHandler(t, &Config{})
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			mh.buildEventToMetrics(e, nil)
+		})
+	}
+	wg.Wait()
+
+	val, ok := mh.countersCache.Get("build:acme/web-app/PR-12:FINALIZED:FAILURE")
+	require.True(t, ok)
+	require.Equal(t, int64(50), val)
+}
+
+func TestObserveDurationStartsOver(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+
+	mh.observeDuration("key", 1)
+	require.Equal(t, uint64(2), mh.observeDuration("key", 1).Count)
+
+	// Histograms not observed within the TTL start over
+	h, _ := mh.histogramCache.Get("key")
+	h.LastSeen = time.Now().Add(-histogramTTL)
+	require.Equal(t, uint64(1), mh.observeDuration("key", 1).Count)
+}
@@ -0,0 +1,234 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+)
+
+// changeJob matches the names of the jobs of multibranch projects building
+// pull and merge requests, such as PR-12.
+var changeJob = regexp.MustCompile(`^(?:PR|MR)-(\d+)$`)
+
+// buildPipeline maps a build to the CI model, with the deterministic IDs of
+// its spans. The stages of Pipeline builds are described by run, which is
+// nil for other builds.
+func buildPipeline(e *buildEvent, run *runDescription) cimodel.Pipeline {
+	job := e.jobName()
+	number := e.Build.Number
+
+	started := millis(e.Build.Timestamp)
+	finished := started.Add(time.Duration(e.Build.Duration) * time.Millisecond)
+	if run != nil && run.StartTimeMillis > 0 {
+		started = millis(run.StartTimeMillis)
+		finished = started.Add(time.Duration(run.DurationMillis) * time.Millisecond)
+	}
+
+	pipeline := cimodel.Pipeline{
+		ID:         strconv.FormatInt(number, 10),
+		Name:       job,
+		URL:        e.Build.FullURL,
+		Result:     buildResult(e.Build.Status),
+		Status:     e.Build.Status,
+		Started:    started,
+		Finished:   finished,
+		Repository: scmRepository(e.Build.SCM.URL),
+		Ref:        scmRef(e.Build.SCM),
+		TraceID:    generateTraceID(job, number),
+		SpanID:     generateBuildSpanID(job, number),
+	}
+
+	if m := changeJob.FindStringSubmatch(path.Base(job)); m != nil {
+		pipeline.Ref.ChangeID = m[1]
+	}
+
+	if run != nil {
+		pipeline.Tasks = stageTasks(job, number, e.Build.FullURL, run.Stages)
+	}
+
+	return pipeline
+}
+
+// stageTasks maps the stages of a build to tasks, in the order they are
+// listed in. The wfapi lists nested stages, such as declarative parallel
+// stages, after the stage running them, which they are nested in when they
+// ran within its time span.
+func stageTasks(job string, number int64, buildURL string, stages []stageDescription) []cimodel.Task {
+	tasks := make([]cimodel.Task, 0, len(stages))
+	names := make(map[string]bool, len(stages))
+	var open []int // tasks the following stages may be nested in
+
+	for _, stage := range stages {
+		// Stage names are only unique in declarative pipelines.
+		key := stage.Name
+		if names[key] {
+			key += "#" + stage.ID
+		}
+		names[stage.Name] = true
+
+		task := cimodel.Task{
+			ID:       stage.ID,
+			Name:     stage.Name,
+			URL:      nodeURL(buildURL, stage.ID),
+			Worker:   stage.ExecNode,
+			Result:   stageResult(stage.Status),
+			Status:   stage.Status,
+			Started:  millis(stage.StartTimeMillis),
+			Finished: millis(stage.StartTimeMillis + stage.DurationMillis),
+			SpanID:   generateStageSpanID(job, number, key),
+		}
+
+		for len(open) > 0 && !nestedIn(task, tasks[open[len(open)-1]]) {
+			open = open[:len(open)-1]
+		}
+		if len(open) > 0 {
+			task.Parent = tasks[open[len(open)-1]].ID
+		}
+
+		for _, node := range stage.StageFlowNodes {
+			task.Steps = append(task.Steps, cimodel.Step{
+				Name:     node.Name,
+				Result:   stageResult(node.Status),
+				Status:   node.Status,
+				Started:  millis(node.StartTimeMillis),
+				Finished: millis(node.StartTimeMillis + node.DurationMillis),
+				SpanID:   generateNodeSpanID(job, number, node.ID),
+			})
+		}
+
+		tasks = append(tasks, task)
+		open = append(open, len(tasks)-1)
+	}
+
+	return tasks
+}
+
+// nestedIn reports whether a stage ran within the time span of another.
+func nestedIn(task, parent cimodel.Task) bool {
+	return !task.Started.Before(parent.Started) &&
+		task.Started.Before(parent.Finished) &&
+		!task.Finished.After(parent.Finished)
+}
+
+// scmRepository maps the remote URL of a git repository, in its HTTP, SSH
+// or scp-like form.
+func scmRepository(remote string) cimodel.Repository {
+	if remote == "" {
+		return cimodel.Repository{}
+	}
+
+	var host, repoPath string
+	if u, err := url.Parse(remote); err == nil && u.Host != "" {
+		host, repoPath = u.Hostname(), u.Path
+	} else if at := strings.Index(remote, "@"); at >= 0 {
+		// scp-like syntax, such as git@github.com:grafana/app.git
+		hostPath := remote[at+1:]
+		if colon := strings.Index(hostPath, ":"); colon >= 0 {
+			host, repoPath = hostPath[:colon], hostPath[colon+1:]
+		}
+	}
+
+	repoPath = strings.TrimSuffix(strings.Trim(repoPath, "/"), ".git")
+	if host == "" || repoPath == "" {
+		return cimodel.Repository{URL: remote}
+	}
+
+	return cimodel.Repository{
+		Provider: scmProvider(host),
+		Owner:    path.Dir(repoPath),
+		Name:     path.Base(repoPath),
+		URL:      "https://" + host + "/" + repoPath,
+	}
+}
+
+func scmProvider(host string) string {
+	switch {
+	case strings.Contains(host, "github"):
+		return semconv.AttributeVCSProviderNameGithub
+	case strings.Contains(host, "gitlab"):
+		return semconv.AttributeVCSProviderNameGitlab
+	case strings.Contains(host, "bitbucket"):
+		return semconv.AttributeVCSProviderNameBitbucket
+	case strings.Contains(host, "gitea"):
+		return semconv.AttributeVCSProviderNameGitea
+	default:
+		return ""
+	}
+}
+
+// scmRef maps the revision a build checked out. The Git plugin reports the
+// branch with the name of its remote, such as origin/main.
+func scmRef(scm scmState) cimodel.Ref {
+	ref := cimodel.Ref{Revision: scm.Commit}
+
+	switch branch := scm.Branch; {
+	case branch == "":
+	case strings.HasPrefix(branch, "refs/tags/"):
+		ref.Head = strings.TrimPrefix(branch, "refs/tags/")
+		ref.HeadType = semconv.AttributeVCSRefTypeTag
+	default:
+		branch = strings.TrimPrefix(branch, "refs/heads/")
+		if _, name, ok := strings.Cut(branch, "/"); ok && strings.HasPrefix(branch, "origin/") {
+			branch = name
+		}
+		ref.Head = branch
+		ref.HeadType = semconv.AttributeVCSRefTypeBranch
+	}
+
+	return ref
+}
+
+func nodeURL(buildURL, nodeID string) string {
+	if buildURL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(buildURL, "/") + "/execution/node/" + nodeID + "/"
+}
+
+// buildResult maps the result of a build.
+func buildResult(result string) cimodel.Result {
+	switch result {
+	case "SUCCESS":
+		return cimodel.ResultSuccess
+	case "UNSTABLE", "FAILURE":
+		return cimodel.ResultFailure
+	case "ABORTED":
+		return cimodel.ResultCancellation
+	case "NOT_BUILT":
+		return cimodel.ResultSkip
+	default:
+		return cimodel.ResultUnknown
+	}
+}
+
+// stageResult maps the status of a stage or step.
+func stageResult(status string) cimodel.Result {
+	switch status {
+	case "SUCCESS":
+		return cimodel.ResultSuccess
+	case "UNSTABLE", "FAILED":
+		return cimodel.ResultFailure
+	case "ABORTED":
+		return cimodel.ResultCancellation
+	case "NOT_EXECUTED":
+		return cimodel.ResultSkip
+	default:
+		return cimodel.ResultUnknown
+	}
+}
+
+func millis(ms int64) time.Time {
+	if ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms).UTC()
+}
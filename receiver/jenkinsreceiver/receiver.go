@@ -0,0 +1,406 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/logpolicy"
+	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jenkinsreceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+)
+
+var errMissingEndpoint = errors.New("missing a receiver endpoint")
+
+// tokenParam carries the secret token of notifications, as the Notification
+// plugin does not allow setting headers.
+const tokenParam = "token"
+
+type jenkinsReceiver struct {
+	logsConsumer    consumer.Logs
+	tracesConsumer  consumer.Traces
+	metricsConsumer consumer.Metrics
+	metricsHandler  *metricsHandler
+	config          *Config
+	server          *http.Server
+	shutdownWG      sync.WaitGroup
+	createSettings  receiver.Settings
+	logger          *zap.Logger
+	obsrecv         *receiverhelper.ObsReport
+	client          *jenkinsClient
+	telemetry       *metadata.TelemetryBuilder
+	logPolicy       *logpolicy.Policy
+	// events are the finalized builds reported in the background, once
+	// they are described by the API
+	events chan *buildEvent
+	cancel context.CancelFunc
+}
+
+func newReceiver(
+	params receiver.Settings,
+	config *Config,
+) (*jenkinsReceiver, error) {
+	if config.NetAddr.Endpoint == "" {
+		return nil, errMissingEndpoint
+	}
+
+	transport := "http"
+	if config.TLS.HasValue() {
+		transport = "https"
+	}
+
+	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             params.ID,
+		Transport:              transport,
+		ReceiverCreateSettings: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Stages, steps and console output can only be read from the API.
+	var client *jenkinsClient
+	if config.JenkinsAPIConfig.BaseURL != "" {
+		client, err = newJenkinsClient(config.JenkinsAPIConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Jenkins client: %w", err)
+		}
+	}
+
+	telemetry, err := metadata.NewTelemetryBuilder(params.TelemetrySettings)
+	if err != nil {
+		return nil, err
+	}
+
+	logPolicy, err := logpolicy.New(config.Logs, logPolicyRecorder{telemetry: telemetry})
+	if err != nil {
+		return nil, err
+	}
+
+	metricsHandler, err := newMetricsHandler(params, config, params.Logger.Named("metricsHandler"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &jenkinsReceiver{
+		config:         config,
+		createSettings: params,
+		logger:         params.Logger,
+		obsrecv:        obsrecv,
+		client:         client,
+		telemetry:      telemetry,
+		logPolicy:      logPolicy,
+		metricsHandler: metricsHandler,
+		events:         make(chan *buildEvent, config.JenkinsAPIConfig.QueueSize),
+	}, nil
+}
+
+// newTracesReceiver creates a traces receiver based on provided config.
+func newTracesReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Traces,
+) (receiver.Traces, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*jenkinsReceiver).tracesConsumer = consumer
+
+	return r, nil
+}
+
+// newLogsReceiver creates a logs receiver based on provided config.
+func newLogsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Logs,
+) (receiver.Logs, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*jenkinsReceiver).logsConsumer = consumer
+
+	return r, nil
+}
+
+// newMetricsReceiver creates a metrics receiver based on provided config.
+func newMetricsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*jenkinsReceiver).metricsConsumer = consumer
+
+	return r, nil
+}
+
+func getOrAddReceiver(set receiver.Settings, cfg component.Config) (*sharedcomponent.SharedComponent, error) {
+	var err error
+	r := receivers.GetOrAdd(cfg, func() component.Component {
+		var rcv component.Component
+		rcv, err = newReceiver(set, cfg.(*Config))
+		return rcv
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// logPolicyRecorder reports the log lines affected by the log policy as
+// internal telemetry.
+type logPolicyRecorder struct {
+	telemetry *metadata.TelemetryBuilder
+}
+
+func (r logPolicyRecorder) RecordDroppedLines(n int64, reason string) {
+	r.telemetry.ReceiverLogsDroppedLines.Add(context.Background(), n, metric.WithAttributes(attribute.String("reason", reason)))
+}
+
+func (r logPolicyRecorder) RecordRedactedLines(n int64) {
+	r.telemetry.ReceiverLogsRedactedLines.Add(context.Background(), n)
+}
+
+func (r logPolicyRecorder) RecordOversizedEntries(n int64, behaviour string) {
+	r.telemetry.ReceiverLogsOversizedEntries.Add(context.Background(), n, metric.WithAttributes(attribute.String("behaviour", behaviour)))
+}
+
+func (jr *jenkinsReceiver) Start(_ context.Context, _ component.Host) error {
+	endpoint := fmt.Sprintf("%s%s", jr.config.NetAddr.Endpoint, jr.config.Path)
+	jr.logger.Info("Starting Jenkins server", zap.String("endpoint", endpoint))
+	jr.server = &http.Server{
+		Addr:              jr.config.NetAddr.Endpoint,
+		Handler:           jr,
+		ReadHeaderTimeout: 20 * time.Second,
+	}
+
+	jr.shutdownWG.Add(1)
+	go func() {
+		defer jr.shutdownWG.Done()
+
+		if errHTTP := jr.server.ListenAndServe(); !errors.Is(errHTTP, http.ErrServerClosed) && errHTTP != nil {
+			jr.createSettings.Logger.Error("Server closed with error", zap.Error(errHTTP))
+		}
+	}()
+
+	var ctx context.Context
+	ctx, jr.cancel = context.WithCancel(context.Background())
+	jr.shutdownWG.Add(1)
+	go func() {
+		defer jr.shutdownWG.Done()
+		jr.processEvents(ctx)
+	}()
+
+	return nil
+}
+
+// Shutdown stops the server and the requests to the API. Builds waiting to
+// be described are dropped.
+func (jr *jenkinsReceiver) Shutdown(ctx context.Context) error {
+	var err error
+	if jr.server != nil {
+		err = jr.server.Close()
+	}
+	if jr.cancel != nil {
+		jr.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		jr.shutdownWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		jr.logger.Warn("Stopped waiting for the build being reported", zap.Error(ctx.Err()))
+	}
+	if n := len(jr.events); n > 0 {
+		jr.logger.Warn("Dropping builds waiting to be described", zap.Int("builds", n))
+	}
+	jr.telemetry.Shutdown()
+	return err
+}
+
+func (jr *jenkinsReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Validate request path
+	if r.URL.Path != jr.config.Path {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	// Validate the secret token configured on the notification endpoint
+	if jr.config.Secret != "" && subtle.ConstantTimeCompare([]byte(r.URL.Query().Get(tokenParam)), []byte(jr.config.Secret)) != 1 {
+		jr.logger.Debug("Notification token validation failed")
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		jr.logger.Debug("Failed to read payload", zap.Error(err))
+		http.Error(w, "Failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	e, err := parseBuildEvent(payload)
+	if err != nil {
+		jr.logger.Debug("Notification parsing failed", zap.Error(err))
+		http.Error(w, "Failed to parse notification", http.StatusBadRequest)
+		return
+	}
+
+	// Builds are only described once finalized, as their logs and test
+	// results are complete then.
+	if e.Build.Phase != phaseFinalized {
+		if jr.metricsConsumer != nil {
+			jr.consumeMetrics(ctx, jr.metricsHandler.buildEventToMetrics(e, nil))
+		}
+		jr.logger.Debug("Skipping unfinalized build", zap.String("phase", e.Build.Phase))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// The Notification plugin doesn't retry rejected notifications, so
+	// their builds are still counted, without their stages
+	select {
+	case jr.events <- e:
+	default:
+		jr.logger.Warn("Too many builds waiting to be described, dropping",
+			zap.String("job", e.jobName()),
+			zap.Int64("number", e.Build.Number),
+		)
+		if jr.metricsConsumer != nil {
+			jr.consumeMetrics(ctx, jr.metricsHandler.buildEventToMetrics(e, nil))
+		}
+		http.Error(w, "too many builds waiting to be described", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// processEvents reports the queued builds, until ctx is canceled.
+func (jr *jenkinsReceiver) processEvents(ctx context.Context) {
+	for {
+		select {
+		case e := <-jr.events:
+			jr.processEvent(ctx, e)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// processEvent describes a finalized build with the API and reports its
+// metrics, traces and logs. Builds whose requests are canceled by shutdown
+// are still reported with what was read.
+func (jr *jenkinsReceiver) processEvent(ctx context.Context, e *buildEvent) {
+	run := jr.describeRun(ctx, e)
+	consumeCtx := context.WithoutCancel(ctx)
+
+	if jr.metricsConsumer != nil {
+		jr.consumeMetrics(consumeCtx, jr.metricsHandler.buildEventToMetrics(e, run))
+	}
+
+	if jr.tracesConsumer != nil {
+		td := buildEventToTraces(e, run, jr.config, jr.logger.Named("buildEventToTraces"))
+		tracesCtx := jr.obsrecv.StartTracesOp(consumeCtx)
+		err := jr.tracesConsumer.ConsumeTraces(tracesCtx, td)
+		jr.obsrecv.EndTracesOp(tracesCtx, metadata.Type.String(), td.SpanCount(), err)
+		if err != nil {
+			jr.logger.Error("Failed to consume traces", zap.Error(err))
+		}
+	}
+
+	if jr.logsConsumer != nil {
+		jr.consumeBuildLogs(ctx, e, run)
+	}
+}
+
+// describeRun describes the stages of Pipeline builds, returning nil for
+// other builds or when the API is not configured.
+func (jr *jenkinsReceiver) describeRun(ctx context.Context, e *buildEvent) *runDescription {
+	if jr.client == nil || e.Build.URL == "" {
+		return nil
+	}
+
+	run, err := jr.client.describeRun(ctx, e.Build.URL)
+	switch {
+	case errors.Is(err, errNotFound):
+		jr.logger.Debug("Build is not a Pipeline build", zap.String("job", e.jobName()), zap.Int64("number", e.Build.Number))
+		return nil
+	case err != nil:
+		jr.logger.Error("Failed to describe build", zap.String("job", e.jobName()), zap.Int64("number", e.Build.Number), zap.Error(err))
+		return nil
+	}
+	return run
+}
+
+func (jr *jenkinsReceiver) consumeMetrics(ctx context.Context, md pmetric.Metrics) {
+	metricsCtx := jr.obsrecv.StartMetricsOp(ctx)
+	err := jr.metricsConsumer.ConsumeMetrics(metricsCtx, md)
+	jr.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), md.DataPointCount(), err)
+	if err != nil {
+		jr.logger.Error("Failed to consume metrics", zap.Error(err))
+	}
+}
+
+func (jr *jenkinsReceiver) consumeBuildLogs(ctx context.Context, e *buildEvent, run *runDescription) {
+	// Without the API, only the lines included in notifications are known.
+	client := jr.client
+	if client == nil && e.Build.Log == "" {
+		jr.logger.Debug("Jenkins API not configured and no log in notification, logs will not be processed.")
+		return
+	}
+	if client != nil && e.Build.URL == "" {
+		client = nil
+	}
+
+	ld, err := buildEventToLogs(ctx, e, run, jr.config, client, jr.logPolicy, jr.logger.Named("buildEventToLogs"))
+	if err != nil {
+		jr.logger.Error("Failed to process logs", zap.Error(err))
+		return
+	}
+	if ld == nil {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	logsCtx := jr.obsrecv.StartLogsOp(ctx)
+	err = jr.logsConsumer.ConsumeLogs(logsCtx, *ld)
+	jr.obsrecv.EndLogsOp(logsCtx, metadata.Type.String(), ld.LogRecordCount(), err)
+	if err != nil {
+		jr.logger.Error("Failed to consume logs", zap.Error(err))
+	}
+}
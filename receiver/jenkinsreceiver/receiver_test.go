@@ -0,0 +1,244 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+func TestNewReceiver(t *testing.T) {
+	defaultConfig := createDefaultConfig().(*Config)
+
+	tests := []struct {
+		desc         string
+		config       Config
+		expectClient bool
+		err          error
+	}{
+		{
+			desc:   "Default config succeeds",
+			config: *defaultConfig,
+		},
+		{
+			desc: "Base URL creates a Jenkins client",
+			config: Config{
+				ServerConfig: defaultConfig.ServerConfig,
+				JenkinsAPIConfig: JenkinsAPIConfig{
+					BaseURL: "https://jenkins.example.com/",
+				},
+			},
+			expectClient: true,
+		},
+		{
+			desc:   "Missing endpoint fails",
+			config: Config{},
+			err:    errMissingEndpoint,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), &test.config)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expectClient, rec.client != nil)
+			require.NoError(t, rec.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	finalizedPayload, err := os.ReadFile(filepath.Join("testdata", "build_finalized.json"))
+	require.NoError(t, err)
+	startedPayload := bytes.Replace(finalizedPayload, []byte(`"phase": "FINALIZED"`), []byte(`"phase": "STARTED"`), 1)
+	missingPayload := bytes.Replace(finalizedPayload, []byte(`"url": "job/acme/job/web-app/job/PR-12/42/"`), []byte(`"url": "job/acme/job/web-app/job/PR-12/41/"`), 1)
+
+	tests := []struct {
+		desc          string
+		path          string
+		token         string
+		payload       []byte
+		expectStatus  int
+		expectSpans   int
+		expectLogs    int
+		expectMetrics bool
+	}{
+		{
+			desc:         "Unknown path",
+			path:         "/other",
+			token:        "mysecret",
+			payload:      finalizedPayload,
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			desc:         "Invalid token",
+			token:        "wrong",
+			payload:      finalizedPayload,
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:         "Invalid payload",
+			token:        "mysecret",
+			payload:      []byte(`{`),
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			desc:         "Not a build notification",
+			token:        "mysecret",
+			payload:      []byte(`{"name": "app"}`),
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			desc:          "Started build",
+			token:         "mysecret",
+			payload:       startedPayload,
+			expectStatus:  http.StatusNoContent,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Finalized build",
+			token:         "mysecret",
+			payload:       finalizedPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   8,
+			expectLogs:    12,
+			expectMetrics: true,
+		},
+		{
+			// Builds that cannot be described are still reported, without
+			// their stages and console output.
+			desc:          "Finalized build missing from the API",
+			token:         "mysecret",
+			payload:       missingPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   1,
+			expectMetrics: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Secret = "mysecret"
+
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, rec.Shutdown(context.Background())) })
+
+			tracesSink := new(consumertest.TracesSink)
+			logsSink := new(consumertest.LogsSink)
+			metricsSink := new(consumertest.MetricsSink)
+			rec.tracesConsumer = tracesSink
+			rec.logsConsumer = logsSink
+			rec.metricsConsumer = metricsSink
+			rec.client = newJenkinsTestServer(t)
+
+			path := test.path
+			if path == "" {
+				path = cfg.Path
+			}
+			req := httptest.NewRequest(http.MethodPost, path+"?"+tokenParam+"="+test.token, bytes.NewReader(test.payload))
+			w := httptest.NewRecorder()
+
+			rec.ServeHTTP(w, req)
+			// Finalized builds are reported in the background
+			for len(rec.events) > 0 {
+				rec.processEvent(t.Context(), <-rec.events)
+			}
+
+			require.Equal(t, test.expectStatus, w.Code)
+			require.Equal(t, test.expectSpans, tracesSink.SpanCount())
+			require.Equal(t, test.expectLogs, logsSink.LogRecordCount())
+			require.Equal(t, test.expectMetrics, len(metricsSink.AllMetrics()) > 0)
+		})
+	}
+}
+
+func TestServeHTTPQueue(t *testing.T) {
+	payload, err := os.ReadFile(filepath.Join("testdata", "build_finalized.json"))
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.JenkinsAPIConfig.QueueSize = 1
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	metricsSink := new(consumertest.MetricsSink)
+	rec.metricsConsumer = metricsSink
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, cfg.Path, bytes.NewReader(payload))
+		w := httptest.NewRecorder()
+		rec.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusAccepted, send())
+	require.Empty(t, metricsSink.AllMetrics())
+	// Builds are rejected while the queue is full, but still counted as the
+	// Notification plugin doesn't retry them
+	require.Equal(t, http.StatusServiceUnavailable, send())
+	require.Len(t, rec.events, 1)
+	require.Len(t, metricsSink.AllMetrics(), 1)
+}
+
+func TestShutdownCancelsAPIRequests(t *testing.T) {
+	// The API never answers
+	requested := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		select {
+		case requested <- struct{}{}:
+		default:
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+
+	payload, err := os.ReadFile(filepath.Join("testdata", "build_finalized.json"))
+	require.NoError(t, err)
+	e, err := parseBuildEvent(payload)
+	require.NoError(t, err)
+
+	cfg := createDefaultConfig().(*Config)
+	cfg.NetAddr.Endpoint = "127.0.0.1:0"
+	cfg.JenkinsAPIConfig.BaseURL = server.URL
+
+	rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+	require.NoError(t, err)
+	tracesSink := new(consumertest.TracesSink)
+	rec.tracesConsumer = tracesSink
+	require.NoError(t, rec.Start(context.Background(), componenttest.NewNopHost()))
+
+	rec.events <- e
+	select {
+	case <-requested:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "build was not described")
+	}
+
+	// Shutdown doesn't wait for the timeout of the request
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	started := time.Now()
+	require.NoError(t, rec.Shutdown(ctx))
+	require.Less(t, time.Since(started), time.Second)
+
+	// The build is reported without its stages
+	require.Equal(t, 1, tracesSink.SpanCount())
+}
@@ -0,0 +1,188 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeName    = "jenkinsreceiver"
+	scopeVersion = "0.1.0"
+)
+
+// legacyResourceAttributes, legacyBuildAttributes and legacyStageAttributes
+// are the attributes replaced by the semantic conventions. Attributes
+// without an equivalent keep their names.
+var legacyResourceAttributes = []string{
+	"ci.jenkins.job.name",
+	"ci.jenkins.scm.url",
+}
+
+var legacyBuildAttributes = []string{
+	"ci.jenkins.build.number",
+	"ci.jenkins.build.result",
+	"ci.jenkins.build.url",
+	"ci.jenkins.scm.branch",
+	"ci.jenkins.scm.commit",
+}
+
+var legacyStageAttributes = []string{
+	"ci.jenkins.stage.id",
+	"ci.jenkins.stage.name",
+	"ci.jenkins.stage.node",
+	"ci.jenkins.stage.status",
+}
+
+func buildEventToTraces(e *buildEvent, run *runDescription, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing build",
+		zap.String("job", e.jobName()),
+		zap.Int64("number", e.Build.Number),
+		zap.String("result", e.Build.Status),
+		zap.Bool("pipeline", run != nil),
+	)
+
+	pipeline := buildEventToPipeline(e, run, config)
+	return cimodel.ToTraces(&pipeline, traceOptions(config))
+}
+
+// buildEventToPipeline maps a build to the CI model, with the attributes of
+// its resource and spans.
+func buildEventToPipeline(e *buildEvent, run *runDescription, config *Config) cimodel.Pipeline {
+	build := e.Build
+
+	pipeline := buildPipeline(e, run)
+	pipeline.ResourceAttributes = map[string]any{
+		"service.name":        generateServiceName(config, pipeline.Name),
+		"ci.jenkins.job.name": pipeline.Name,
+	}
+	if build.SCM.URL != "" {
+		pipeline.ResourceAttributes["ci.jenkins.scm.url"] = build.SCM.URL
+	}
+
+	pipeline.Attributes = map[string]any{
+		"ci.jenkins.build.number":   build.Number,
+		"ci.jenkins.build.queue_id": build.QueueID,
+		"ci.jenkins.build.result":   build.Status,
+		"ci.jenkins.build.url":      build.FullURL,
+	}
+	if build.SCM.Branch != "" {
+		pipeline.Attributes["ci.jenkins.scm.branch"] = build.SCM.Branch
+	}
+	if build.SCM.Commit != "" {
+		pipeline.Attributes["ci.jenkins.scm.commit"] = build.SCM.Commit
+	}
+	if run != nil {
+		pipeline.Attributes["ci.jenkins.build.queued_duration"] = float64(run.QueueDurationMillis) / 1000
+		pipeline.Attributes["ci.jenkins.build.paused_duration"] = float64(run.PauseDurationMillis) / 1000
+	}
+	if tests := build.TestSummary; tests != nil {
+		pipeline.Attributes["ci.jenkins.build.tests.total"] = tests.Total
+		pipeline.Attributes["ci.jenkins.build.tests.failed"] = tests.Failed
+		pipeline.Attributes["ci.jenkins.build.tests.passed"] = tests.Passed
+		pipeline.Attributes["ci.jenkins.build.tests.skipped"] = tests.Skipped
+	}
+
+	// Tasks are in the order of the stages.
+	for i := range pipeline.Tasks {
+		stage := run.Stages[i]
+		task := &pipeline.Tasks[i]
+		task.Attributes = map[string]any{
+			"ci.jenkins.stage.id":              stage.ID,
+			"ci.jenkins.stage.name":            stage.Name,
+			"ci.jenkins.stage.status":          stage.Status,
+			"ci.jenkins.stage.paused_duration": float64(stage.PauseDurationMillis) / 1000,
+		}
+		if stage.ExecNode != "" {
+			task.Attributes["ci.jenkins.stage.node"] = stage.ExecNode
+		}
+
+		for j := range task.Steps {
+			node := stage.StageFlowNodes[j]
+			task.Steps[j].Attributes = map[string]any{
+				"ci.jenkins.step.id":     node.ID,
+				"ci.jenkins.step.name":   node.Name,
+				"ci.jenkins.step.status": node.Status,
+			}
+			if node.ParameterDescription != "" {
+				task.Steps[j].Attributes["ci.jenkins.step.description"] = node.ParameterDescription
+			}
+		}
+	}
+
+	removeLegacyAttributes(&pipeline, config.Semconv)
+	return pipeline
+}
+
+// removeLegacyAttributes removes the attributes replaced by the semantic
+// conventions, unless the legacy ones are emitted.
+func removeLegacyAttributes(p *cimodel.Pipeline, cfg semconv.Config) {
+	if cfg.EmitsLegacy() {
+		return
+	}
+
+	for _, key := range legacyResourceAttributes {
+		delete(p.ResourceAttributes, key)
+	}
+	for _, key := range legacyBuildAttributes {
+		delete(p.Attributes, key)
+	}
+	for i := range p.Tasks {
+		for _, key := range legacyStageAttributes {
+			delete(p.Tasks[i].Attributes, key)
+		}
+	}
+}
+
+func traceOptions(config *Config) cimodel.Options {
+	return cimodel.Options{
+		ScopeName:    scopeName,
+		ScopeVersion: scopeVersion,
+		Semconv:      config.Semconv,
+	}
+}
+
+func generateServiceName(config *Config, jobName string) string {
+	if config.CustomServiceName != "" {
+		return config.CustomServiceName
+	}
+	formattedName := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(jobName, "/", "-"), "_", "-"))
+	return fmt.Sprintf("%s%s%s", config.ServiceNamePrefix, formattedName, config.ServiceNameSuffix)
+}
+
+// buildKey identifies a build as the JOB_NAME and BUILD_NUMBER environment
+// variables of its steps do.
+func buildKey(jobName string, number int64) string {
+	return fmt.Sprintf("%s#%d", jobName, number)
+}
+
+func generateTraceID(jobName string, number int64) pcommon.TraceID {
+	hash := sha256.Sum256([]byte(buildKey(jobName, number) + "t"))
+	return pcommon.TraceID(hash[:16])
+}
+
+func generateBuildSpanID(jobName string, number int64) pcommon.SpanID {
+	return generateSpanID(buildKey(jobName, number) + "s")
+}
+
+func generateStageSpanID(jobName string, number int64, stage string) pcommon.SpanID {
+	return generateSpanID(buildKey(jobName, number) + "s" + stage)
+}
+
+func generateNodeSpanID(jobName string, number int64, nodeID string) pcommon.SpanID {
+	return generateSpanID(buildKey(jobName, number) + "n" + nodeID)
+}
+
+func generateSpanID(input string) pcommon.SpanID {
+	hash := sha256.Sum256([]byte(input))
+	return pcommon.SpanID(hash[:8])
+}
@@ -0,0 +1,266 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jenkinsreceiver
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap/zaptest"
+)
+
+func loadEvent(t *testing.T, name string) *buildEvent {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	e, err := parseBuildEvent(payload)
+	require.NoError(t, err)
+	return e
+}
+
+// loadRun describes build 42 as the wfapi does.
+func loadRun(t *testing.T) *runDescription {
+	t.Helper()
+
+	run, err := newJenkinsTestServer(t).describeRun(t.Context(), strings.TrimPrefix(testBuildPath, "/"))
+	require.NoError(t, err)
+	return run
+}
+
+func spansByName(traces ptrace.Traces) map[string]ptrace.Span {
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	byName := make(map[string]ptrace.Span, spans.Len())
+	for i := 0; i < spans.Len(); i++ {
+		byName[spans.At(i).Name()] = spans.At(i)
+	}
+	return byName
+}
+
+func TestBuildEventToTraces(t *testing.T) {
+	e := loadEvent(t, "build_finalized.json")
+
+	traces := buildEventToTraces(e, loadRun(t), &Config{}, zaptest.NewLogger(t))
+
+	// The build, its 5 stages and the 2 steps of Checkout and Integration.
+	require.Equal(t, 8, traces.SpanCount())
+
+	resource := traces.ResourceSpans().At(0).Resource().Attributes()
+	serviceName, _ := resource.Get("service.name")
+	require.Equal(t, "acme-web-app-pr-12", serviceName.Str())
+	jobName, _ := resource.Get("ci.jenkins.job.name")
+	require.Equal(t, "acme/web-app/PR-12", jobName.Str())
+
+	spans := spansByName(traces)
+	build := spans["acme/web-app/PR-12"]
+	require.Equal(t, "7f385b94680172f91bc2bdf0222b95ef", build.TraceID().String())
+	require.Equal(t, "40f22a3e2e8521c9", build.SpanID().String())
+	require.True(t, build.ParentSpanID().IsEmpty())
+	require.Equal(t, ptrace.StatusCodeError, build.Status().Code())
+	// The build span excludes the time the build was queued for.
+	require.Equal(t, time.Date(2024, 3, 5, 10, 0, 5, 0, time.UTC), build.StartTimestamp().AsTime())
+	require.Equal(t, time.Date(2024, 3, 5, 10, 1, 15, 0, time.UTC), build.EndTimestamp().AsTime())
+	queued, _ := build.Attributes().Get("ci.jenkins.build.queued_duration")
+	require.Equal(t, 5.0, queued.Double())
+	failedTests, _ := build.Attributes().Get("ci.jenkins.build.tests.failed")
+	require.EqualValues(t, 1, failedTests.Int())
+
+	stages := map[string]struct {
+		parent string
+		code   ptrace.StatusCode
+	}{
+		"Checkout":    {"acme/web-app/PR-12", ptrace.StatusCodeOk},
+		"Test":        {"acme/web-app/PR-12", ptrace.StatusCodeError},
+		"Unit":        {"Test", ptrace.StatusCodeOk},
+		"Integration": {"Test", ptrace.StatusCodeError},
+		"Deploy":      {"acme/web-app/PR-12", ptrace.StatusCodeUnset},
+	}
+	for name, expected := range stages {
+		stage := spans[name]
+		require.Equal(t, spans[expected.parent].SpanID(), stage.ParentSpanID(), name)
+		require.Equal(t, expected.code, stage.Status().Code(), name)
+	}
+
+	integration := spans["Integration"]
+	require.Equal(t, "857d7ab7604c5c1e", integration.SpanID().String())
+	node, _ := integration.Attributes().Get("ci.jenkins.stage.node")
+	require.Equal(t, "agent-2", node.Str())
+
+	step := spans["Shell Script"]
+	require.Equal(t, integration.SpanID(), step.ParentSpanID())
+	require.Equal(t, ptrace.StatusCodeError, step.Status().Code())
+	description, _ := step.Attributes().Get("ci.jenkins.step.description")
+	require.Equal(t, "make integration", description.Str())
+}
+
+func TestBuildEventToTracesFreestyle(t *testing.T) {
+	e := loadEvent(t, "build_finalized.json")
+
+	// Builds of other job types than Pipeline have no stages.
+	traces := buildEventToTraces(e, nil, &Config{}, zaptest.NewLogger(t))
+	require.Equal(t, 1, traces.SpanCount())
+
+	build := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+	require.Equal(t, time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC), build.StartTimestamp().AsTime())
+	require.Equal(t, time.Date(2024, 3, 5, 10, 1, 15, 0, time.UTC), build.EndTimestamp().AsTime())
+	_, ok := build.Attributes().Get("ci.jenkins.build.queued_duration")
+	require.False(t, ok)
+}
+
+func TestBuildEventToTracesSemconv(t *testing.T) {
+	e := loadEvent(t, "build_finalized.json")
+	run := loadRun(t)
+
+	tests := []struct {
+		desc         string
+		semconv      semconv.Config
+		expectLegacy bool
+	}{
+		{
+			desc:         "Semantic conventions with legacy attributes",
+			semconv:      semconv.Config{Enabled: true, EmitLegacy: true},
+			expectLegacy: true,
+		},
+		{
+			desc:         "Semantic conventions only",
+			semconv:      semconv.Config{Enabled: true},
+			expectLegacy: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			traces := buildEventToTraces(e, run, &Config{Semconv: test.semconv}, zaptest.NewLogger(t))
+
+			resource := traces.ResourceSpans().At(0).Resource().Attributes()
+			provider, _ := resource.Get(semconv.AttributeVCSProviderName)
+			require.Equal(t, semconv.AttributeVCSProviderNameGithub, provider.Str())
+			repoURL, _ := resource.Get(semconv.AttributeVCSRepositoryURLFull)
+			require.Equal(t, "https://github.com/acme/web-app", repoURL.Str())
+			_, ok := resource.Get("ci.jenkins.scm.url")
+			require.Equal(t, test.expectLegacy, ok)
+
+			spans := spansByName(traces)
+			build := spans["acme/web-app/PR-12"].Attributes()
+			changeID, _ := build.Get(semconv.AttributeVCSChangeID)
+			require.Equal(t, "12", changeID.Str())
+			head, _ := build.Get(semconv.AttributeVCSRefHeadName)
+			require.Equal(t, "PR-12", head.Str())
+			result, _ := build.Get(semconv.AttributeCICDPipelineResult)
+			require.Equal(t, "failure", result.Str())
+			_, ok = build.Get("ci.jenkins.build.result")
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = build.Get("ci.jenkins.build.queue_id")
+			require.True(t, ok)
+
+			unit := spans["Unit"].Attributes()
+			taskID, _ := unit.Get(semconv.AttributeCICDPipelineTaskRunID)
+			require.Equal(t, "17", taskID.Str())
+			taskURL, _ := unit.Get(semconv.AttributeCICDPipelineTaskRunURLFull)
+			require.Equal(t, "https://jenkins.example.com/job/acme/job/web-app/job/PR-12/42/execution/node/17/", taskURL.Str())
+			worker, _ := unit.Get(semconv.AttributeCICDWorkerName)
+			require.Equal(t, "agent-1", worker.Str())
+			_, ok = unit.Get("ci.jenkins.stage.name")
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = unit.Get("ci.jenkins.stage.paused_duration")
+			require.True(t, ok)
+		})
+	}
+}
+
+func TestStageTasksRepeatedNames(t *testing.T) {
+	stages := []stageDescription{
+		{ID: "6", Name: "Build", StartTimeMillis: 1000, DurationMillis: 1000},
+		{ID: "12", Name: "Build", StartTimeMillis: 3000, DurationMillis: 1000},
+	}
+
+	tasks := stageTasks("app", 1, "", stages)
+	require.Len(t, tasks, 2)
+	require.NotEqual(t, tasks[0].SpanID, tasks[1].SpanID)
+	require.Equal(t, generateStageSpanID("app", 1, "Build"), tasks[0].SpanID)
+	require.Equal(t, generateStageSpanID("app", 1, "Build#12"), tasks[1].SpanID)
+	require.Empty(t, tasks[1].Parent)
+	require.Empty(t, tasks[0].URL)
+}
+
+func TestJobName(t *testing.T) {
+	tests := map[string]struct {
+		event  buildEvent
+		expect string
+	}{
+		"top level job": {
+			event:  buildEvent{Name: "app", URL: "job/app/"},
+			expect: "app",
+		},
+		"folders and branches": {
+			event:  buildEvent{Name: "feature/login", URL: "job/acme/job/app/job/feature%2Flogin/"},
+			expect: "acme/app/feature/login",
+		},
+		"missing URL": {
+			event:  buildEvent{Name: "app"},
+			expect: "app",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expect, test.event.jobName())
+		})
+	}
+}
+
+func TestScmRepository(t *testing.T) {
+	tests := map[string]struct {
+		remote   string
+		expect   string
+		owner    string
+		name     string
+		provider string
+	}{
+		"https":      {remote: "https://github.com/acme/web-app.git", expect: "https://github.com/acme/web-app", owner: "acme", name: "web-app", provider: "github"},
+		"ssh":        {remote: "ssh://git@gitlab.example.com:2222/acme/group/web-app.git", expect: "https://gitlab.example.com/acme/group/web-app", owner: "acme/group", name: "web-app", provider: "gitlab"},
+		"scp-like":   {remote: "git@bitbucket.org:acme/web-app.git", expect: "https://bitbucket.org/acme/web-app", owner: "acme", name: "web-app", provider: "bitbucket"},
+		"gitea":      {remote: "https://gitea.example.com/acme/web-app.git", expect: "https://gitea.example.com/acme/web-app", owner: "acme", name: "web-app", provider: "gitea"},
+		"local path": {remote: "/srv/git/web-app", expect: "/srv/git/web-app"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo := scmRepository(test.remote)
+			require.Equal(t, test.expect, repo.URL)
+			require.Equal(t, test.owner, repo.Owner)
+			require.Equal(t, test.name, repo.Name)
+			require.Equal(t, test.provider, repo.Provider)
+		})
+	}
+}
+
+func TestScmRef(t *testing.T) {
+	tests := map[string]struct {
+		branch     string
+		expectHead string
+		expectType string
+	}{
+		"remote branch": {branch: "origin/feature/login", expectHead: "feature/login", expectType: semconv.AttributeVCSRefTypeBranch},
+		"full ref":      {branch: "refs/heads/main", expectHead: "main", expectType: semconv.AttributeVCSRefTypeBranch},
+		"local branch":  {branch: "release/1.2", expectHead: "release/1.2", expectType: semconv.AttributeVCSRefTypeBranch},
+		"tag":           {branch: "refs/tags/v1.2.0", expectHead: "v1.2.0", expectType: semconv.AttributeVCSRefTypeTag},
+		"no branch":     {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ref := scmRef(scmState{Branch: test.branch})
+			require.Equal(t, test.expectHead, ref.Head)
+			require.Equal(t, test.expectType, ref.HeadType)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver // import "github.com/grafana/grafana-ci-otel-collector/receiver/jsonwebhookreceiver"
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jsonwebhookreceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.uber.org/multierr"
+)
+
+var (
+	errMissingEndpointFromConfig = errors.New("missing receiver server endpoint from config")
+	errMissingMappings           = errors.New("missing mappings from config")
+	errMissingRunID              = errors.New("missing run.id expression")
+	errMissingTaskItems          = errors.New("missing tasks.items expression")
+	errMissingTaskID             = errors.New("missing tasks.id expression")
+)
+
+// Config defines configuration for JSON webhook receiver
+type Config struct {
+	metadata.MetricsBuilderConfig `mapstructure:",squash"`
+	confighttp.ServerConfig       `mapstructure:",squash"` // squash ensures fields are correctly decoded in embedded struct
+	Path                          string                   `mapstructure:"path"`                // path for data collection. Default is <host>:<port>/webhookevents
+	Secret                        string                   `mapstructure:"secret"`              // bearer token of the requests. Default is empty
+	CustomServiceName             string                   `mapstructure:"custom_service_name"` // custom service name. Default is empty
+	ServiceNamePrefix             string                   `mapstructure:"service_name_prefix"` // service name prefix. Default is empty
+	ServiceNameSuffix             string                   `mapstructure:"service_name_suffix"` // service name suffix. Default is empty
+	Semconv                       semconv.Config           `mapstructure:"semconv"`             // OpenTelemetry CICD and VCS semantic conventions
+	Mappings                      []MappingConfig          `mapstructure:"mappings"`            // mappings of the payloads, the first one whose condition holds is used
+}
+
+// MappingConfig maps a kind of payload to a run and its tasks, with
+// expressions selecting their fields in the payload
+type MappingConfig struct {
+	Name       string              `mapstructure:"name"`        // name of the mapping, used in logs. Default is its index
+	When       string              `mapstructure:"when"`        // condition selecting the payloads of the mapping, such as $.event == "build.finished". Default is every payload
+	System     string              `mapstructure:"system"`      // name of the CI system, reported as ci.system. Default is webhook
+	TimeFormat string              `mapstructure:"time_format"` // rfc3339, unix, unix_ms or a Go time layout. Default is rfc3339
+	Results    map[string][]string `mapstructure:"results"`     // statuses of each result, replacing its default statuses
+	Run        RunMappingConfig    `mapstructure:"run"`
+	Tasks      *TaskMappingConfig  `mapstructure:"tasks"` // tasks of the run. Default is none
+}
+
+// RunMappingConfig selects the fields of a run. Only ID is required.
+type RunMappingConfig struct {
+	ID                 string            `mapstructure:"id"`                  // run ID, the trace ID is derived from it
+	Attempt            string            `mapstructure:"attempt"`             // attempt of the run. Default is 1
+	Name               string            `mapstructure:"name"`                // pipeline name. Default is the run ID
+	URL                string            `mapstructure:"url"`                 // URL of the run
+	Parent             string            `mapstructure:"parent"`              // ID of the run that triggered this one, linked to its trace
+	ParentAttempt      string            `mapstructure:"parent_attempt"`      // attempt of the parent run. Default is 1
+	Started            string            `mapstructure:"started"`             // start of the run. Default is its end
+	Finished           string            `mapstructure:"finished"`            // end of the run. Default is its start, or the receipt of the payload
+	Status             string            `mapstructure:"status"`              // status of the run, mapped to a result
+	RepositoryURL      string            `mapstructure:"repository_url"`      // URL of the repository
+	Ref                string            `mapstructure:"ref"`                 // branch the run is for
+	Revision           string            `mapstructure:"revision"`            // commit the run is for
+	OmitSpan           bool              `mapstructure:"omit_span"`           // report the tasks only, for payloads describing some tasks of a run
+	Attributes         map[string]string `mapstructure:"attributes"`          // span attributes
+	ResourceAttributes map[string]string `mapstructure:"resource_attributes"` // resource attributes
+}
+
+// TaskMappingConfig selects the tasks of a run, and their fields. Task
+// expressions select fields of the current item with @ paths.
+type TaskMappingConfig struct {
+	Items      string            `mapstructure:"items"`    // tasks of the run, such as $.jobs[*]
+	ID         string            `mapstructure:"id"`       // task ID, unique within the run
+	Name       string            `mapstructure:"name"`     // task name. Default is the task ID
+	Parent     string            `mapstructure:"parent"`   // ID of the task this one runs in
+	Worker     string            `mapstructure:"worker"`   // runner or machine the task ran on
+	URL        string            `mapstructure:"url"`      // URL of the task
+	Started    string            `mapstructure:"started"`  // start of the task. Default is the start of the run
+	Finished   string            `mapstructure:"finished"` // end of the task. Default is its start
+	Status     string            `mapstructure:"status"`   // status of the task, mapped to a result
+	Attributes map[string]string `mapstructure:"attributes"`
+}
+
+var _ component.Config = (*Config)(nil)
+
+// Validate checks the receiver configuration is valid
+func (cfg *Config) Validate() error {
+	var errs error
+
+	if cfg.NetAddr.Endpoint == "" {
+		errs = multierr.Append(errs, errMissingEndpointFromConfig)
+	}
+
+	if len(cfg.Mappings) == 0 {
+		errs = multierr.Append(errs, errMissingMappings)
+	}
+	for i := range cfg.Mappings {
+		if _, err := compileMapping(&cfg.Mappings[i]); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("mapping %s: %w", mappingName(&cfg.Mappings[i], i), err))
+		}
+	}
+
+	return errs
+}
+
+// mappingName returns the name of a mapping, or its index when unnamed.
+func mappingName(cfg *MappingConfig, index int) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	return fmt.Sprint(index)
+}
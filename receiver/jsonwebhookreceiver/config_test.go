@@ -0,0 +1,144 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jsonwebhookreceiver/internal/metadata"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/config/confignet"
+	"go.opentelemetry.io/collector/confmap/confmaptest"
+	"go.opentelemetry.io/collector/confmap/xconfmap"
+)
+
+// loadTestConfig loads the valid configuration of testdata/config.yaml.
+func loadTestConfig(t *testing.T) *Config {
+	t.Helper()
+
+	cm, err := confmaptest.LoadConf(filepath.Join("testdata", "config.yaml"))
+	require.NoError(t, err)
+	sub, err := cm.Sub(component.NewIDWithName(metadata.Type, "valid_config").String())
+	require.NoError(t, err)
+
+	cfg := NewFactory().CreateDefaultConfig().(*Config)
+	require.NoError(t, sub.Unmarshal(cfg))
+	return cfg
+}
+
+func TestValidateConfig(t *testing.T) {
+	t.Parallel()
+
+	serverConfig := confighttp.ServerConfig{
+		NetAddr: confignet.AddrConfig{
+			Transport: confignet.TransportTypeTCP,
+			Endpoint:  "localhost:8080",
+		},
+	}
+	runMapping := RunMappingConfig{ID: "$.id"}
+
+	tests := []struct {
+		desc   string
+		expect string
+		conf   Config
+	}{
+		{
+			desc:   "Missing valid endpoint",
+			expect: errMissingEndpointFromConfig.Error(),
+			conf:   Config{Mappings: []MappingConfig{{Run: runMapping}}},
+		},
+		{
+			desc:   "Missing mappings",
+			expect: errMissingMappings.Error(),
+			conf:   Config{ServerConfig: serverConfig},
+		},
+		{
+			desc:   "Missing run ID",
+			expect: "mapping 0: " + errMissingRunID.Error(),
+			conf:   Config{ServerConfig: serverConfig, Mappings: []MappingConfig{{}}},
+		},
+		{
+			desc:   "Invalid condition",
+			expect: `mapping builds: when: invalid condition "$.event = 'build'"`,
+			conf:   Config{ServerConfig: serverConfig, Mappings: []MappingConfig{{Name: "builds", When: "$.event = 'build'", Run: runMapping}}},
+		},
+		{
+			desc:   "Invalid expression",
+			expect: `run.name: invalid expression "$.build["`,
+			conf:   Config{ServerConfig: serverConfig, Mappings: []MappingConfig{{Run: RunMappingConfig{ID: "$.id", Name: "$.build["}}}},
+		},
+		{
+			desc:   "Task item outside of tasks",
+			expect: `run.name: "@.name" uses @ outside of tasks`,
+			conf:   Config{ServerConfig: serverConfig, Mappings: []MappingConfig{{Run: RunMappingConfig{ID: "$.id", Name: "@.name"}}}},
+		},
+		{
+			desc:   "Several run IDs",
+			expect: `run.id: "$.ids[*]" selects several values`,
+			conf:   Config{ServerConfig: serverConfig, Mappings: []MappingConfig{{Run: RunMappingConfig{ID: "$.ids[*]"}}}},
+		},
+		{
+			desc:   "Missing task ID",
+			expect: errMissingTaskID.Error(),
+			conf:   Config{ServerConfig: serverConfig, Mappings: []MappingConfig{{Run: runMapping, Tasks: &TaskMappingConfig{Items: "$.jobs[*]"}}}},
+		},
+		{
+			desc:   "Unknown result",
+			expect: `results: unknown result "broken"`,
+			conf:   Config{ServerConfig: serverConfig, Mappings: []MappingConfig{{Run: runMapping, Results: map[string][]string{"broken": {"red"}}}}},
+		},
+		{
+			desc: "Valid mapping",
+			conf: Config{ServerConfig: serverConfig, Secret: "mysecret", Mappings: []MappingConfig{{Run: runMapping}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			err := test.conf.Validate()
+			if test.expect == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), test.expect)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	conf := loadTestConfig(t)
+	require.NoError(t, xconfmap.Validate(conf))
+
+	require.Equal(t, "localhost:8080", conf.NetAddr.Endpoint)
+	require.Equal(t, "/events", conf.Path)
+	require.Equal(t, "mysecret", conf.Secret)
+	require.Len(t, conf.Mappings, 2)
+
+	builds := conf.Mappings[0]
+	require.Equal(t, `$.event == "build.finished"`, builds.When)
+	require.Equal(t, "$.build.id", builds.Run.ID)
+	require.Equal(t, "$.build.labels.team", builds.Run.Attributes["ci.acme.team"])
+	require.Equal(t, &TaskMappingConfig{
+		Items:      "$.jobs[*]",
+		ID:         "@.id",
+		Name:       "@.name",
+		Parent:     "@.parent",
+		Worker:     "@.agent",
+		Started:    "@.started_at",
+		Finished:   "@.finished_at",
+		Status:     "@.state",
+		Attributes: map[string]string{"ci.acme.job.exit_code": "@.exit_code"},
+	}, builds.Tasks)
+
+	deployments := conf.Mappings[1]
+	require.Equal(t, "unix", deployments.TimeFormat)
+	require.Equal(t, map[string][]string{"cancellation": {"withdrawn"}}, deployments.Results)
+	require.Nil(t, deployments.Tasks)
+}
@@ -0,0 +1,43 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	errInvalidToken     = errors.New("invalid bearer token")
+	errTrailingJSONData = errors.New("unexpected data after the JSON value")
+)
+
+// decodePayload decodes a JSON payload, keeping numbers as json.Number so
+// that large IDs keep their digits.
+func decodePayload(payload []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errTrailingJSONData
+	}
+	return decoded, nil
+}
+
+func validateToken(r *http.Request, secret string) error {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return errInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePayload(t *testing.T) {
+	decoded, err := decodePayload([]byte(` {"id": 9007199254740993} `))
+	require.NoError(t, err)
+	// Large IDs keep their digits.
+	require.Equal(t, map[string]any{"id": json.Number("9007199254740993")}, decoded)
+
+	_, err = decodePayload([]byte(`{"id": 1} {"id": 2}`))
+	require.ErrorIs(t, err, errTrailingJSONData)
+	_, err = decodePayload([]byte(`{"id": `))
+	require.Error(t, err)
+}
+
+func TestValidateToken(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		err    error
+	}{
+		"valid":        {header: "Bearer mysecret"},
+		"wrong token":  {header: "Bearer wrong", err: errInvalidToken},
+		"wrong scheme": {header: "Basic mysecret", err: errInvalidToken},
+		"missing":      {err: errInvalidToken},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhookevents", nil)
+			if test.header != "" {
+				req.Header.Set("Authorization", test.header)
+			}
+			require.ErrorIs(t, validateToken(req, "mysecret"), test.err)
+		})
+	}
+}
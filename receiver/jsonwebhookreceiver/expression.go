@@ -0,0 +1,414 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expressions select values of a payload with JSONPath-like paths:
+//
+//	$.build.id                the id field of the build object of the payload
+//	$.jobs[0].name            the name of the first job
+//	$['build.info'].name      fields whose names are not identifiers
+//	$.jobs[*]                 every job, in tasks.items only
+//	@.name                    the name field of the current task item
+//	"pipeline"                a string literal
+//	$.run.title ?? $.run.id   the first path resolving to a non-null value
+//
+// Conditions compare the string values of two expressions with == or !=, or
+// hold when a single expression resolves to a value that is not null, false
+// or empty.
+
+var errUnexpectedEnd = errors.New("unexpected end of expression")
+
+// expression is a compiled expression: the first of its terms resolving to
+// a non-null value.
+type expression struct {
+	source string
+	terms  []term
+}
+
+// term is a string literal, or a path from the payload ($) or from the
+// current task item (@).
+type term struct {
+	literal  string
+	root     byte
+	segments []segment
+}
+
+type segment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// condition is a compiled when condition
+type condition struct {
+	left, right *expression
+	op          string
+}
+
+// compileExpression compiles an expression. Empty expressions compile to
+// nil, which resolves to nothing.
+func compileExpression(source string) (*expression, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, nil
+	}
+
+	p := &parser{input: source}
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+	if p.skipSpaces(); p.pos < len(p.input) {
+		return nil, fmt.Errorf("invalid expression %q: unexpected %q at offset %d", source, p.input[p.pos], p.pos)
+	}
+	return expr, nil
+}
+
+// compileCondition compiles a condition. Empty conditions compile to nil,
+// which always holds.
+func compileCondition(source string) (*condition, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, nil
+	}
+
+	p := &parser{input: source}
+	left, err := p.parseExpression()
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition %q: %w", source, err)
+	}
+	cond := &condition{left: left}
+
+	p.skipSpaces()
+	if op := p.input[p.pos:min(p.pos+2, len(p.input))]; op == "==" || op == "!=" {
+		p.pos += 2
+		cond.op = op
+		if cond.right, err = p.parseExpression(); err != nil {
+			return nil, fmt.Errorf("invalid condition %q: %w", source, err)
+		}
+		p.skipSpaces()
+	}
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("invalid condition %q: unexpected %q at offset %d", source, p.input[p.pos], p.pos)
+	}
+	return cond, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) skipSpaces() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) parseExpression() (*expression, error) {
+	start := p.pos
+	expr := &expression{}
+	for {
+		p.skipSpaces()
+		t, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		expr.terms = append(expr.terms, t)
+
+		p.skipSpaces()
+		if !strings.HasPrefix(p.input[p.pos:], "??") {
+			break
+		}
+		p.pos += 2
+	}
+	expr.source = strings.TrimSpace(p.input[start:p.pos])
+	return expr, nil
+}
+
+func (p *parser) parseTerm() (term, error) {
+	if p.pos >= len(p.input) {
+		return term{}, errUnexpectedEnd
+	}
+
+	switch c := p.input[p.pos]; c {
+	case '"', '\'':
+		s, err := p.parseQuoted()
+		return term{literal: s}, err
+	case '$', '@':
+		p.pos++
+		segments, err := p.parseSegments()
+		return term{root: c, segments: segments}, err
+	default:
+		return term{}, fmt.Errorf("unexpected %q at offset %d, expected a path or a quoted string", c, p.pos)
+	}
+}
+
+// parseQuoted parses a string quoted with single or double quotes, in which
+// backslashes escape the next character.
+func (p *parser) parseQuoted() (string, error) {
+	quote := p.input[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return sb.String(), nil
+		case c == '\\' && p.pos < len(p.input):
+			sb.WriteByte(p.input[p.pos])
+			p.pos++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", errUnexpectedEnd
+}
+
+func (p *parser) parseSegments() ([]segment, error) {
+	var segments []segment
+	for p.pos < len(p.input) {
+		switch p.input[p.pos] {
+		case '.':
+			p.pos++
+			start := p.pos
+			for p.pos < len(p.input) && isIdentifierChar(p.input[p.pos]) {
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, fmt.Errorf("missing field name at offset %d", start)
+			}
+			segments = append(segments, segment{key: p.input[start:p.pos]})
+		case '[':
+			p.pos++
+			seg, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			segments = append(segments, seg)
+		default:
+			return segments, nil
+		}
+	}
+	return segments, nil
+}
+
+// parseBracket parses a quoted field name, an index or a wildcard, and its
+// closing bracket.
+func (p *parser) parseBracket() (segment, error) {
+	if p.pos >= len(p.input) {
+		return segment{}, errUnexpectedEnd
+	}
+
+	var seg segment
+	switch c := p.input[p.pos]; {
+	case c == '*':
+		p.pos++
+		seg.wildcard = true
+	case c == '"' || c == '\'':
+		key, err := p.parseQuoted()
+		if err != nil {
+			return segment{}, err
+		}
+		seg.key = key
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+			p.pos++
+		}
+		index, err := strconv.Atoi(p.input[start:p.pos])
+		if err != nil {
+			return segment{}, err
+		}
+		seg.index, seg.isIndex = index, true
+	default:
+		return segment{}, fmt.Errorf("unexpected %q at offset %d, expected an index, a quoted field name or *", c, p.pos)
+	}
+
+	if p.pos >= len(p.input) || p.input[p.pos] != ']' {
+		return segment{}, fmt.Errorf("missing ] at offset %d", p.pos)
+	}
+	p.pos++
+	return seg, nil
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// hasWildcard reports whether any path of the expression selects several
+// values.
+func (e *expression) hasWildcard() bool {
+	for _, t := range e.terms {
+		for _, seg := range t.segments {
+			if seg.wildcard {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// usesItem reports whether any path of the expression starts from the
+// current task item.
+func (e *expression) usesItem() bool {
+	for _, t := range e.terms {
+		if t.root == '@' {
+			return true
+		}
+	}
+	return false
+}
+
+// eval returns the value of the expression in the payload, and the current
+// task item if any. Values are decoded JSON values, and nil when nothing
+// resolved.
+func (e *expression) eval(payload, item any) any {
+	if e == nil {
+		return nil
+	}
+
+	for _, t := range e.terms {
+		var value any
+		switch t.root {
+		case '$':
+			value = evalPath(payload, t.segments)
+		case '@':
+			value = evalPath(item, t.segments)
+		default:
+			value = t.literal
+		}
+		if value != nil {
+			return value
+		}
+	}
+	return nil
+}
+
+// evalString returns the value of the expression as a string, empty when
+// nothing resolved.
+func (e *expression) evalString(payload, item any) string {
+	return stringValue(e.eval(payload, item))
+}
+
+func evalPath(value any, segments []segment) any {
+	for i, seg := range segments {
+		switch {
+		case seg.wildcard:
+			arr, _ := value.([]any)
+			var values []any
+			for _, elem := range arr {
+				if elem = evalPath(elem, segments[i+1:]); elem != nil {
+					values = append(values, elem)
+				}
+			}
+			if values == nil {
+				return nil
+			}
+			return values
+		case seg.isIndex:
+			arr, ok := value.([]any)
+			if !ok || seg.index >= len(arr) {
+				return nil
+			}
+			value = arr[seg.index]
+		default:
+			obj, ok := value.(map[string]any)
+			if !ok {
+				return nil
+			}
+			value = obj[seg.key]
+		}
+		if value == nil {
+			return nil
+		}
+	}
+	return value
+}
+
+// holds reports whether the condition holds for the payload. Nil
+// conditions always hold.
+func (c *condition) holds(payload any) bool {
+	if c == nil {
+		return true
+	}
+
+	left := c.left.eval(payload, nil)
+	switch c.op {
+	case "==":
+		return left != nil && stringValue(left) == c.right.evalString(payload, nil)
+	case "!=":
+		return left == nil || stringValue(left) != c.right.evalString(payload, nil)
+	default:
+		switch v := left.(type) {
+		case nil:
+			return false
+		case bool:
+			return v
+		case string:
+			return v != ""
+		default:
+			return true
+		}
+	}
+}
+
+// stringValue formats a decoded JSON value as a string. Objects and arrays
+// are formatted as JSON.
+func stringValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+// attributeValue converts a decoded JSON value to a value accepted by
+// pcommon.Map.FromRaw. Integers are kept as integers.
+func attributeValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case []any:
+		values := make([]any, len(v))
+		for i, elem := range v {
+			values[i] = attributeValue(elem)
+		}
+		return values
+	case map[string]any:
+		values := make(map[string]any, len(v))
+		for key, elem := range v {
+			values[key] = attributeValue(elem)
+		}
+		return values
+	default:
+		return v
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecode(t *testing.T, payload string) any {
+	t.Helper()
+	decoded, err := decodePayload([]byte(payload))
+	require.NoError(t, err)
+	return decoded
+}
+
+func TestExpressionEval(t *testing.T) {
+	payload := mustDecode(t, `{
+		"build": {"id": 12345678901234567, "title": null, "ok": true},
+		"jobs": [{"name": "lint"}, {"name": "test"}, {}],
+		"build.info": {"os": "linux"}
+	}`)
+	item := mustDecode(t, `{"name": "lint", "exit": 1.5}`)
+
+	tests := map[string]struct {
+		expr   string
+		expect any
+	}{
+		"field":               {expr: "$.build.id", expect: json.Number("12345678901234567")},
+		"missing field":       {expr: "$.build.number"},
+		"field of a scalar":   {expr: "$.build.id.value"},
+		"null":                {expr: "$.build.title"},
+		"index":               {expr: "$.jobs[1].name", expect: "test"},
+		"index out of range":  {expr: "$.jobs[5].name"},
+		"quoted field":        {expr: `$['build.info'].os`, expect: "linux"},
+		"wildcard":            {expr: "$.jobs[*].name", expect: []any{"lint", "test"}},
+		"wildcard of nothing": {expr: "$.build[*]"},
+		"item":                {expr: "@.exit", expect: json.Number("1.5")},
+		"literal":             {expr: `"it's \"quoted\""`, expect: `it's "quoted"`},
+		"single quoted":       {expr: `'build'`, expect: "build"},
+		"coalescing":          {expr: `$.build.title ?? $.build.name ?? "untitled"`, expect: "untitled"},
+		"first value":         {expr: `$.build.ok ?? "never"`, expect: true},
+		"whole payload":       {expr: "$['build.info']", expect: map[string]any{"os": "linux"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			expr, err := compileExpression(test.expr)
+			require.NoError(t, err)
+			require.Equal(t, test.expect, expr.eval(payload, item))
+		})
+	}
+}
+
+func TestCompileExpressionErrors(t *testing.T) {
+	tests := map[string]string{
+		"unquoted literal":    "build",
+		"missing field name":  "$.build.",
+		"unclosed bracket":    "$.jobs[0",
+		"invalid bracket":     "$.jobs[name]",
+		"unterminated string": `"build`,
+		"dangling coalescing": "$.build ??",
+		"trailing data":       "$.build $.jobs",
+	}
+
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := compileExpression(source)
+			require.Error(t, err)
+		})
+	}
+
+	expr, err := compileExpression("  ")
+	require.NoError(t, err)
+	require.Nil(t, expr)
+	require.Nil(t, expr.eval(nil, nil))
+}
+
+func TestConditionHolds(t *testing.T) {
+	payload := mustDecode(t, `{"event": "build.finished", "attempt": 2, "draft": false, "tags": [], "empty": ""}`)
+
+	tests := map[string]struct {
+		cond   string
+		expect bool
+	}{
+		"always":            {cond: "", expect: true},
+		"equal":             {cond: `$.event == "build.finished"`, expect: true},
+		"not equal":         {cond: `$.event == 'build.started'`},
+		"number":            {cond: `$.attempt == "2"`, expect: true},
+		"different":         {cond: `$.event != "build.started"`, expect: true},
+		"missing different": {cond: `$.missing != "build.started"`, expect: true},
+		"missing equal":     {cond: `$.missing == ""`},
+		"exists":            {cond: "$.event", expect: true},
+		"missing":           {cond: "$.missing"},
+		"false":             {cond: "$.draft"},
+		"empty string":      {cond: "$.empty"},
+		"empty array":       {cond: "$.tags", expect: true},
+		"paths":             {cond: "$.event == $.event", expect: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			cond, err := compileCondition(test.cond)
+			require.NoError(t, err)
+			require.Equal(t, test.expect, cond.holds(payload))
+		})
+	}
+
+	_, err := compileCondition(`$.event = "build"`)
+	require.ErrorContains(t, err, `unexpected '='`)
+	_, err = compileCondition(`$.event ==`)
+	require.ErrorContains(t, err, errUnexpectedEnd.Error())
+}
+
+func TestAttributeValue(t *testing.T) {
+	require.Equal(t, int64(12345678901234567), attributeValue(json.Number("12345678901234567")))
+	require.Equal(t, 1.5, attributeValue(json.Number("1.5")))
+	require.Equal(t, []any{int64(1), "a", true}, attributeValue([]any{json.Number("1"), "a", true}))
+	require.Equal(t, map[string]any{"n": int64(2)}, attributeValue(map[string]any{"n": json.Number("2")}))
+}
+
+func TestStringValue(t *testing.T) {
+	require.Empty(t, stringValue(nil))
+	require.Equal(t, "90210", stringValue(json.Number("90210")))
+	require.Equal(t, "false", stringValue(false))
+	require.Equal(t, `{"a":[1]}`, stringValue(map[string]any{"a": []any{json.Number("1")}}))
+}
@@ -0,0 +1,329 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+)
+
+// AttributeCiRunResult specifies the value ci.run.result attribute.
+type AttributeCiRunResult int
+
+const (
+	_ AttributeCiRunResult = iota
+	AttributeCiRunResultSuccess
+	AttributeCiRunResultFailure
+	AttributeCiRunResultError
+	AttributeCiRunResultTimeout
+	AttributeCiRunResultCancellation
+	AttributeCiRunResultSkip
+)
+
+// String returns the string representation of the AttributeCiRunResult.
+func (av AttributeCiRunResult) String() string {
+	switch av {
+	case AttributeCiRunResultSuccess:
+		return "success"
+	case AttributeCiRunResultFailure:
+		return "failure"
+	case AttributeCiRunResultError:
+		return "error"
+	case AttributeCiRunResultTimeout:
+		return "timeout"
+	case AttributeCiRunResultCancellation:
+		return "cancellation"
+	case AttributeCiRunResultSkip:
+		return "skip"
+	}
+	return ""
+}
+
+// MapAttributeCiRunResult is a helper map of string to AttributeCiRunResult attribute value.
+var MapAttributeCiRunResult = map[string]AttributeCiRunResult{
+	"success":      AttributeCiRunResultSuccess,
+	"failure":      AttributeCiRunResultFailure,
+	"error":        AttributeCiRunResultError,
+	"timeout":      AttributeCiRunResultTimeout,
+	"cancellation": AttributeCiRunResultCancellation,
+	"skip":         AttributeCiRunResultSkip,
+}
+
+var MetricsInfo = metricsInfo{
+	RunsCount: metricInfo{
+		Name: "runs.count",
+	},
+	TasksCount: metricInfo{
+		Name: "tasks.count",
+	},
+}
+
+type metricsInfo struct {
+	RunsCount  metricInfo
+	TasksCount metricInfo
+}
+
+type metricInfo struct {
+	Name string
+}
+
+type metricRunsCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills runs.count metric with initial data.
+func (m *metricRunsCount) init() {
+	m.data.SetName("runs.count")
+	m.data.SetDescription("Number of runs mapped from webhooks, by result.")
+	m.data.SetUnit("{run}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricRunsCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciSystemAttributeValue string, ciPipelineNameAttributeValue string, ciRunResultAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.system", ciSystemAttributeValue)
+	dp.Attributes().PutStr("ci.pipeline.name", ciPipelineNameAttributeValue)
+	dp.Attributes().PutStr("ci.run.result", ciRunResultAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricRunsCount) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricRunsCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricRunsCount(cfg MetricConfig) metricRunsCount {
+	m := metricRunsCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+type metricTasksCount struct {
+	data     pmetric.Metric // data buffer for generated metric.
+	config   MetricConfig   // metric config provided by user.
+	capacity int            // max observed number of data points added to the metric.
+}
+
+// init fills tasks.count metric with initial data.
+func (m *metricTasksCount) init() {
+	m.data.SetName("tasks.count")
+	m.data.SetDescription("Number of tasks mapped from webhooks, by result.")
+	m.data.SetUnit("{task}")
+	m.data.SetEmptySum()
+	m.data.Sum().SetIsMonotonic(true)
+	m.data.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	m.data.Sum().DataPoints().EnsureCapacity(m.capacity)
+}
+
+func (m *metricTasksCount) recordDataPoint(start pcommon.Timestamp, ts pcommon.Timestamp, val int64, ciSystemAttributeValue string, ciPipelineNameAttributeValue string, ciTaskNameAttributeValue string, ciRunResultAttributeValue string) {
+	if !m.config.Enabled {
+		return
+	}
+	dp := m.data.Sum().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(val)
+	dp.Attributes().PutStr("ci.system", ciSystemAttributeValue)
+	dp.Attributes().PutStr("ci.pipeline.name", ciPipelineNameAttributeValue)
+	dp.Attributes().PutStr("ci.task.name", ciTaskNameAttributeValue)
+	dp.Attributes().PutStr("ci.run.result", ciRunResultAttributeValue)
+}
+
+// updateCapacity saves max length of data point slices that will be used for the slice capacity.
+func (m *metricTasksCount) updateCapacity() {
+	if m.data.Sum().DataPoints().Len() > m.capacity {
+		m.capacity = m.data.Sum().DataPoints().Len()
+	}
+}
+
+// emit appends recorded metric data to a metrics slice and prepares it for recording another set of data points.
+func (m *metricTasksCount) emit(metrics pmetric.MetricSlice) {
+	if m.config.Enabled && m.data.Sum().DataPoints().Len() > 0 {
+		m.updateCapacity()
+		m.data.MoveTo(metrics.AppendEmpty())
+		m.init()
+	}
+}
+
+func newMetricTasksCount(cfg MetricConfig) metricTasksCount {
+	m := metricTasksCount{config: cfg}
+
+	if cfg.Enabled {
+		m.data = pmetric.NewMetric()
+		m.init()
+	}
+	return m
+}
+
+// MetricsBuilder provides an interface for scrapers to report metrics while taking care of all the transformations
+// required to produce metric representation defined in metadata and user config.
+type MetricsBuilder struct {
+	config           MetricsBuilderConfig // config of the metrics builder.
+	startTime        pcommon.Timestamp    // start time that will be applied to all recorded data points.
+	metricsCapacity  int                  // maximum observed number of metrics per resource.
+	metricsBuffer    pmetric.Metrics      // accumulates metrics data before emitting.
+	buildInfo        component.BuildInfo  // contains version information.
+	metricRunsCount  metricRunsCount
+	metricTasksCount metricTasksCount
+}
+
+// MetricBuilderOption applies changes to default metrics builder.
+type MetricBuilderOption interface {
+	apply(*MetricsBuilder)
+}
+
+type metricBuilderOptionFunc func(mb *MetricsBuilder)
+
+func (mbof metricBuilderOptionFunc) apply(mb *MetricsBuilder) {
+	mbof(mb)
+}
+
+// WithStartTime sets startTime on the metrics builder.
+func WithStartTime(startTime pcommon.Timestamp) MetricBuilderOption {
+	return metricBuilderOptionFunc(func(mb *MetricsBuilder) {
+		mb.startTime = startTime
+	})
+}
+func NewMetricsBuilder(mbc MetricsBuilderConfig, settings receiver.Settings, options ...MetricBuilderOption) *MetricsBuilder {
+	mb := &MetricsBuilder{
+		config:           mbc,
+		startTime:        pcommon.NewTimestampFromTime(time.Now()),
+		metricsBuffer:    pmetric.NewMetrics(),
+		buildInfo:        settings.BuildInfo,
+		metricRunsCount:  newMetricRunsCount(mbc.Metrics.RunsCount),
+		metricTasksCount: newMetricTasksCount(mbc.Metrics.TasksCount),
+	}
+
+	for _, op := range options {
+		op.apply(mb)
+	}
+	return mb
+}
+
+// updateCapacity updates max length of metrics and resource attributes that will be used for the slice capacity.
+func (mb *MetricsBuilder) updateCapacity(rm pmetric.ResourceMetrics) {
+	if mb.metricsCapacity < rm.ScopeMetrics().At(0).Metrics().Len() {
+		mb.metricsCapacity = rm.ScopeMetrics().At(0).Metrics().Len()
+	}
+}
+
+// ResourceMetricsOption applies changes to provided resource metrics.
+type ResourceMetricsOption interface {
+	apply(pmetric.ResourceMetrics)
+}
+
+type resourceMetricsOptionFunc func(pmetric.ResourceMetrics)
+
+func (rmof resourceMetricsOptionFunc) apply(rm pmetric.ResourceMetrics) {
+	rmof(rm)
+}
+
+// WithResource sets the provided resource on the emitted ResourceMetrics.
+// It's recommended to use ResourceBuilder to create the resource.
+func WithResource(res pcommon.Resource) ResourceMetricsOption {
+	return resourceMetricsOptionFunc(func(rm pmetric.ResourceMetrics) {
+		res.CopyTo(rm.Resource())
+	})
+}
+
+// WithStartTimeOverride overrides start time for all the resource metrics data points.
+// This option should be only used if different start time has to be set on metrics coming from different resources.
+func WithStartTimeOverride(start pcommon.Timestamp) ResourceMetricsOption {
+	return resourceMetricsOptionFunc(func(rm pmetric.ResourceMetrics) {
+		var dps pmetric.NumberDataPointSlice
+		metrics := rm.ScopeMetrics().At(0).Metrics()
+		for i := 0; i < metrics.Len(); i++ {
+			switch metrics.At(i).Type() {
+			case pmetric.MetricTypeGauge:
+				dps = metrics.At(i).Gauge().DataPoints()
+			case pmetric.MetricTypeSum:
+				dps = metrics.At(i).Sum().DataPoints()
+			}
+			for j := 0; j < dps.Len(); j++ {
+				dps.At(j).SetStartTimestamp(start)
+			}
+		}
+	})
+}
+
+// EmitForResource saves all the generated metrics under a new resource and updates the internal state to be ready for
+// recording another set of data points as part of another resource. This function can be helpful when one scraper
+// needs to emit metrics from several resources. Otherwise calling this function is not required,
+// just `Emit` function can be called instead.
+// Resource attributes should be provided as ResourceMetricsOption arguments.
+func (mb *MetricsBuilder) EmitForResource(options ...ResourceMetricsOption) {
+	rm := pmetric.NewResourceMetrics()
+	ils := rm.ScopeMetrics().AppendEmpty()
+	ils.Scope().SetName(ScopeName)
+	ils.Scope().SetVersion(mb.buildInfo.Version)
+	ils.Metrics().EnsureCapacity(mb.metricsCapacity)
+	mb.metricRunsCount.emit(ils.Metrics())
+	mb.metricTasksCount.emit(ils.Metrics())
+
+	for _, op := range options {
+		op.apply(rm)
+	}
+
+	if ils.Metrics().Len() > 0 {
+		mb.updateCapacity(rm)
+		rm.MoveTo(mb.metricsBuffer.ResourceMetrics().AppendEmpty())
+	}
+}
+
+// Emit returns all the metrics accumulated by the metrics builder and updates the internal state to be ready for
+// recording another set of metrics. This function will be responsible for applying all the transformations required to
+// produce metric representation defined in metadata and user config, e.g. delta or cumulative.
+func (mb *MetricsBuilder) Emit(options ...ResourceMetricsOption) pmetric.Metrics {
+	mb.EmitForResource(options...)
+	metrics := mb.metricsBuffer
+	mb.metricsBuffer = pmetric.NewMetrics()
+	return metrics
+}
+
+// RecordRunsCountDataPoint adds a data point to runs.count metric.
+func (mb *MetricsBuilder) RecordRunsCountDataPoint(ts pcommon.Timestamp, val int64, ciSystemAttributeValue string, ciPipelineNameAttributeValue string, ciRunResultAttributeValue AttributeCiRunResult) {
+	mb.metricRunsCount.recordDataPoint(mb.startTime, ts, val, ciSystemAttributeValue, ciPipelineNameAttributeValue, ciRunResultAttributeValue.String())
+}
+
+// RecordTasksCountDataPoint adds a data point to tasks.count metric.
+func (mb *MetricsBuilder) RecordTasksCountDataPoint(ts pcommon.Timestamp, val int64, ciSystemAttributeValue string, ciPipelineNameAttributeValue string, ciTaskNameAttributeValue string, ciRunResultAttributeValue AttributeCiRunResult) {
+	mb.metricTasksCount.recordDataPoint(mb.startTime, ts, val, ciSystemAttributeValue, ciPipelineNameAttributeValue, ciTaskNameAttributeValue, ciRunResultAttributeValue.String())
+}
+
+// Reset resets metrics builder to its initial state. It should be used when external metrics source is restarted,
+// and metrics builder should update its startTime and reset it's internal state accordingly.
+func (mb *MetricsBuilder) Reset(options ...MetricBuilderOption) {
+	mb.startTime = pcommon.NewTimestampFromTime(time.Now())
+	for _, op := range options {
+		op.apply(mb)
+	}
+}
@@ -0,0 +1,17 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"go.opentelemetry.io/collector/component"
+)
+
+var (
+	Type      = component.MustNewType("jsonwebhook")
+	ScopeName = "github.com/grafana/grafana-ci-otel-collector/receiver/jsonwebhookreceiver"
+)
+
+const (
+	TracesStability  = component.StabilityLevelAlpha
+	MetricsStability = component.StabilityLevelAlpha
+)
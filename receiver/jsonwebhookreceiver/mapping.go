@@ -0,0 +1,416 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"go.uber.org/multierr"
+)
+
+const (
+	defaultSystem = "webhook"
+
+	timeFormatRFC3339 = "rfc3339"
+	timeFormatUnix    = "unix"
+	timeFormatUnixMs  = "unix_ms"
+)
+
+var (
+	errNoRunID  = errors.New("run ID resolved to nothing")
+	errNoTaskID = errors.New("task ID resolved to nothing")
+)
+
+// defaultResults are the statuses of each result, compared case-insensitively
+var defaultResults = map[cimodel.Result][]string{
+	cimodel.ResultSuccess:      {"success", "succeeded", "successful", "passed", "ok"},
+	cimodel.ResultFailure:      {"failure", "failed", "fail", "broken"},
+	cimodel.ResultError:        {"error", "errored"},
+	cimodel.ResultTimeout:      {"timeout", "timed_out", "timedout"},
+	cimodel.ResultCancellation: {"cancellation", "canceled", "cancelled", "aborted", "stopped"},
+	cimodel.ResultSkip:         {"skip", "skipped", "not_run"},
+}
+
+// mapping is a compiled mapping configuration
+type mapping struct {
+	name       string
+	system     string
+	timeFormat string
+	omitSpan   bool
+	when       *condition
+	results    map[string]cimodel.Result
+
+	run   runMapping
+	tasks *taskMapping
+}
+
+type runMapping struct {
+	id, attempt, name, url         *expression
+	parent, parentAttempt          *expression
+	started, finished, status      *expression
+	repositoryURL, ref, revision   *expression
+	attributes, resourceAttributes map[string]*expression
+}
+
+type taskMapping struct {
+	items, id, name, parent, worker, url *expression
+	started, finished, status            *expression
+	attributes                           map[string]*expression
+}
+
+// compiler compiles the expressions of a mapping, collecting their errors.
+type compiler struct {
+	errs error
+}
+
+// expression compiles the expression of a field. Paths from the current
+// task item are only allowed in task fields, and paths selecting several
+// values in attributes.
+func (c *compiler) expression(field, source string, inTask, multiple bool) *expression {
+	expr, err := compileExpression(source)
+	switch {
+	case err != nil:
+		c.errs = multierr.Append(c.errs, fmt.Errorf("%s: %w", field, err))
+	case expr == nil:
+	case !inTask && expr.usesItem():
+		c.errs = multierr.Append(c.errs, fmt.Errorf("%s: %q uses @ outside of tasks", field, source))
+	case !multiple && expr.hasWildcard():
+		c.errs = multierr.Append(c.errs, fmt.Errorf("%s: %q selects several values", field, source))
+	}
+	return expr
+}
+
+func (c *compiler) attributes(field string, sources map[string]string, inTask bool) map[string]*expression {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	attrs := make(map[string]*expression, len(sources))
+	for key, source := range sources {
+		attrs[key] = c.expression(field+"."+key, source, inTask, true)
+	}
+	return attrs
+}
+
+// compileMapping compiles the condition and expressions of a mapping.
+func compileMapping(cfg *MappingConfig) (*mapping, error) {
+	var c compiler
+
+	m := &mapping{
+		name:       cfg.Name,
+		system:     cfg.System,
+		timeFormat: cfg.TimeFormat,
+		omitSpan:   cfg.Run.OmitSpan,
+	}
+	if m.system == "" {
+		m.system = defaultSystem
+	}
+	if m.timeFormat == "" {
+		m.timeFormat = timeFormatRFC3339
+	}
+
+	when, err := compileCondition(cfg.When)
+	if err != nil {
+		c.errs = multierr.Append(c.errs, fmt.Errorf("when: %w", err))
+	}
+	m.when = when
+
+	results, err := compileResults(cfg.Results)
+	if err != nil {
+		c.errs = multierr.Append(c.errs, err)
+	}
+	m.results = results
+
+	run := &cfg.Run
+	if strings.TrimSpace(run.ID) == "" {
+		c.errs = multierr.Append(c.errs, errMissingRunID)
+	}
+	m.run = runMapping{
+		id:                 c.expression("run.id", run.ID, false, false),
+		attempt:            c.expression("run.attempt", run.Attempt, false, false),
+		name:               c.expression("run.name", run.Name, false, false),
+		url:                c.expression("run.url", run.URL, false, false),
+		parent:             c.expression("run.parent", run.Parent, false, false),
+		parentAttempt:      c.expression("run.parent_attempt", run.ParentAttempt, false, false),
+		started:            c.expression("run.started", run.Started, false, false),
+		finished:           c.expression("run.finished", run.Finished, false, false),
+		status:             c.expression("run.status", run.Status, false, false),
+		repositoryURL:      c.expression("run.repository_url", run.RepositoryURL, false, false),
+		ref:                c.expression("run.ref", run.Ref, false, false),
+		revision:           c.expression("run.revision", run.Revision, false, false),
+		attributes:         c.attributes("run.attributes", run.Attributes, false),
+		resourceAttributes: c.attributes("run.resource_attributes", run.ResourceAttributes, false),
+	}
+
+	if tasks := cfg.Tasks; tasks != nil {
+		if strings.TrimSpace(tasks.Items) == "" {
+			c.errs = multierr.Append(c.errs, errMissingTaskItems)
+		}
+		if strings.TrimSpace(tasks.ID) == "" {
+			c.errs = multierr.Append(c.errs, errMissingTaskID)
+		}
+		m.tasks = &taskMapping{
+			items:      c.expression("tasks.items", tasks.Items, false, true),
+			id:         c.expression("tasks.id", tasks.ID, true, false),
+			name:       c.expression("tasks.name", tasks.Name, true, false),
+			parent:     c.expression("tasks.parent", tasks.Parent, true, false),
+			worker:     c.expression("tasks.worker", tasks.Worker, true, false),
+			url:        c.expression("tasks.url", tasks.URL, true, false),
+			started:    c.expression("tasks.started", tasks.Started, true, false),
+			finished:   c.expression("tasks.finished", tasks.Finished, true, false),
+			status:     c.expression("tasks.status", tasks.Status, true, false),
+			attributes: c.attributes("tasks.attributes", tasks.Attributes, true),
+		}
+	}
+
+	if c.errs != nil {
+		return nil, c.errs
+	}
+	return m, nil
+}
+
+// compileResults returns the results of the statuses, the configured
+// statuses of a result replacing its default ones.
+func compileResults(configured map[string][]string) (map[string]cimodel.Result, error) {
+	var errs error
+	overrides := make(map[cimodel.Result][]string, len(configured))
+	for name, statuses := range configured {
+		result := cimodel.Result(strings.ToLower(name))
+		if _, ok := defaultResults[result]; !ok {
+			errs = multierr.Append(errs, fmt.Errorf("results: unknown result %q", name))
+			continue
+		}
+		overrides[result] = statuses
+	}
+
+	// Configured statuses are added last, so that they take precedence
+	// over the default statuses of other results.
+	results := map[string]cimodel.Result{}
+	for result, statuses := range defaultResults {
+		if _, ok := overrides[result]; !ok {
+			addStatuses(results, result, statuses)
+		}
+	}
+	for result, statuses := range overrides {
+		addStatuses(results, result, statuses)
+	}
+	return results, errs
+}
+
+func addStatuses(results map[string]cimodel.Result, result cimodel.Result, statuses []string) {
+	for _, status := range statuses {
+		results[strings.ToLower(status)] = result
+	}
+}
+
+// matches reports whether the mapping applies to the payload.
+func (m *mapping) matches(payload any) bool {
+	return m.when.holds(payload)
+}
+
+// result returns the result of a status, unknown for unmapped statuses.
+func (m *mapping) result(status string) cimodel.Result {
+	return m.results[strings.ToLower(status)]
+}
+
+// pipeline maps a payload to a run and its tasks. Runs without timestamps
+// end when the payload was received.
+func (m *mapping) pipeline(payload any, received time.Time) (*cimodel.Pipeline, error) {
+	run := &m.run
+
+	id := run.id.evalString(payload, nil)
+	if id == "" {
+		return nil, errNoRunID
+	}
+	attempt := run.attempt.evalString(payload, nil)
+	if attempt == "" {
+		attempt = "1"
+	}
+
+	started, finished, err := m.times(run.started, run.finished, payload, nil, received, received)
+	if err != nil {
+		return nil, fmt.Errorf("run: %w", err)
+	}
+
+	status := run.status.evalString(payload, nil)
+	p := &cimodel.Pipeline{
+		ID:       id,
+		Name:     run.name.evalString(payload, nil),
+		URL:      run.url.evalString(payload, nil),
+		Result:   m.result(status),
+		Status:   status,
+		Started:  started,
+		Finished: finished,
+		Repository: cimodel.Repository{
+			URL: run.repositoryURL.evalString(payload, nil),
+		},
+		Ref: cimodel.Ref{
+			Head:     run.ref.evalString(payload, nil),
+			Revision: run.revision.evalString(payload, nil),
+		},
+		TraceID:            generateTraceID(id, attempt),
+		SpanID:             generateRunSpanID(id, attempt),
+		OmitSpan:           m.omitSpan,
+		Attributes:         evalAttributes(run.attributes, payload, nil),
+		ResourceAttributes: evalAttributes(run.resourceAttributes, payload, nil),
+	}
+	if p.Name == "" {
+		p.Name = id
+	}
+	if p.Ref.Head != "" {
+		p.Ref.HeadType = semconv.AttributeVCSRefTypeBranch
+	}
+	if p.Attributes == nil {
+		p.Attributes = map[string]any{}
+	}
+	p.Attributes["ci.run.id"] = id
+	p.Attributes["ci.run.attempt"] = attempt
+
+	// Retries link to the trace of the previous attempt, and triggered runs
+	// to the trace of the run that triggered them.
+	if n, err := strconv.Atoi(attempt); err == nil && n > 1 {
+		p.Links = append(p.Links, generateTraceID(id, strconv.Itoa(n-1)))
+	}
+	if parent := run.parent.evalString(payload, nil); parent != "" {
+		parentAttempt := run.parentAttempt.evalString(payload, nil)
+		if parentAttempt == "" {
+			parentAttempt = "1"
+		}
+		p.Links = append(p.Links, generateTraceID(parent, parentAttempt))
+	}
+
+	if m.tasks != nil {
+		if p.Tasks, err = m.pipelineTasks(p, attempt, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// pipelineTasks maps the task items of a payload. Tasks without timestamps
+// span their run.
+func (m *mapping) pipelineTasks(p *cimodel.Pipeline, attempt string, payload any) ([]cimodel.Task, error) {
+	t := m.tasks
+
+	var items []any
+	switch v := t.items.eval(payload, nil).(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		items = v
+	default:
+		items = []any{v}
+	}
+
+	tasks := make([]cimodel.Task, 0, len(items))
+	for i, item := range items {
+		id := t.id.evalString(payload, item)
+		if id == "" {
+			return nil, fmt.Errorf("task %d: %w", i, errNoTaskID)
+		}
+
+		started, finished, err := m.times(t.started, t.finished, payload, item, p.Started, p.Finished)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: %w", id, err)
+		}
+
+		status := t.status.evalString(payload, item)
+		task := cimodel.Task{
+			ID:         id,
+			Name:       t.name.evalString(payload, item),
+			URL:        t.url.evalString(payload, item),
+			Parent:     t.parent.evalString(payload, item),
+			Worker:     t.worker.evalString(payload, item),
+			Result:     m.result(status),
+			Status:     status,
+			Started:    started,
+			Finished:   finished,
+			SpanID:     generateTaskSpanID(p.ID, attempt, id),
+			Attributes: evalAttributes(t.attributes, payload, item),
+		}
+		if task.Name == "" {
+			task.Name = id
+		}
+		if task.Attributes == nil {
+			task.Attributes = map[string]any{}
+		}
+		task.Attributes["ci.task.id"] = id
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// times returns the start and end selected by the given expressions. A
+// missing start is the end, and a missing or earlier end is the start. When
+// both are missing, they are the fallback ones.
+func (m *mapping) times(startedExpr, finishedExpr *expression, payload, item any, fallbackStart, fallbackEnd time.Time) (time.Time, time.Time, error) {
+	started, err := parseTime(startedExpr.eval(payload, item), m.timeFormat)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("started: %w", err)
+	}
+	finished, err := parseTime(finishedExpr.eval(payload, item), m.timeFormat)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("finished: %w", err)
+	}
+
+	switch {
+	case started.IsZero() && finished.IsZero():
+		started, finished = fallbackStart, fallbackEnd
+	case started.IsZero():
+		started = finished
+	case finished.IsZero() || finished.Before(started):
+		finished = started
+	}
+	return started, finished, nil
+}
+
+// parseTime parses a timestamp in the given format. Missing timestamps
+// parse to the zero time.
+func parseTime(value any, format string) (time.Time, error) {
+	s := stringValue(value)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	switch format {
+	case timeFormatRFC3339:
+		return time.Parse(time.RFC3339Nano, s)
+	case timeFormatUnix, timeFormatUnixMs:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if format == timeFormatUnixMs {
+			f /= 1e3
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC(), nil
+	default:
+		return time.Parse(format, s)
+	}
+}
+
+// evalAttributes returns the values of attribute expressions, without the
+// attributes resolving to nothing.
+func evalAttributes(exprs map[string]*expression, payload, item any) map[string]any {
+	if len(exprs) == 0 {
+		return nil
+	}
+
+	attrs := make(map[string]any, len(exprs))
+	for key, expr := range exprs {
+		if value := expr.eval(payload, item); value != nil {
+			attrs[key] = attributeValue(value)
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,183 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/stretchr/testify/require"
+)
+
+func loadPayload(t *testing.T, name string) any {
+	t.Helper()
+	payload, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return mustDecode(t, string(payload))
+}
+
+// testMappings returns the compiled mappings of testdata/config.yaml.
+func testMappings(t *testing.T) (builds, deployments *mapping) {
+	t.Helper()
+	cfg := loadTestConfig(t)
+	builds, err := compileMapping(&cfg.Mappings[0])
+	require.NoError(t, err)
+	deployments, err = compileMapping(&cfg.Mappings[1])
+	require.NoError(t, err)
+	return builds, deployments
+}
+
+func at(minute, second int) time.Time {
+	return time.Date(2024, 3, 5, 10, minute, second, 0, time.UTC)
+}
+
+func TestMappingMatches(t *testing.T) {
+	builds, deployments := testMappings(t)
+	build := loadPayload(t, "build_finished.json")
+	deployment := loadPayload(t, "deploy_finished.json")
+
+	require.True(t, builds.matches(build))
+	require.False(t, builds.matches(deployment))
+	require.True(t, deployments.matches(deployment))
+	require.False(t, deployments.matches(build))
+}
+
+func TestMappingPipeline(t *testing.T) {
+	builds, _ := testMappings(t)
+
+	p, err := builds.pipeline(loadPayload(t, "build_finished.json"), time.Now())
+	require.NoError(t, err)
+
+	require.Equal(t, "90210", p.ID)
+	require.Equal(t, "acme/web-app", p.Name)
+	require.Equal(t, "https://ci.acme.io/builds/90210", p.URL)
+	require.Equal(t, cimodel.ResultFailure, p.Result)
+	require.Equal(t, "FAILED", p.Status)
+	require.Equal(t, at(0, 0), p.Started)
+	require.Equal(t, at(5, 0), p.Finished)
+	require.Equal(t, "https://git.acme.io/acme/web-app", p.Repository.URL)
+	require.Equal(t, "main", p.Ref.Head)
+	require.Equal(t, "9b2e6c1f4d7a8e3b5c0f1a2d3e4f5a6b7c8d9e0f", p.Ref.Revision)
+	require.Equal(t, generateTraceID("90210", "2"), p.TraceID)
+	require.Equal(t, generateRunSpanID("90210", "2"), p.SpanID)
+	require.Equal(t, map[string]any{
+		"ci.run.id":        "90210",
+		"ci.run.attempt":   "2",
+		"ci.acme.team":     "web",
+		"ci.acme.priority": int64(1),
+		"ci.acme.agents":   []any{"runner-1", "runner-2", "runner-2"},
+	}, p.Attributes)
+
+	// The run links to its previous attempt, and to the run that triggered it.
+	require.Equal(t, []string{generateTraceID("90210", "1").String(), generateTraceID("90200", "1").String()},
+		[]string{p.Links[0].String(), p.Links[1].String()})
+
+	require.Len(t, p.Tasks, 4)
+	test := p.Tasks[1]
+	require.Equal(t, "test", test.ID)
+	require.Equal(t, "Test", test.Name)
+	require.Equal(t, "runner-2", test.Worker)
+	require.Equal(t, cimodel.ResultFailure, test.Result)
+	require.Equal(t, at(1, 0), test.Started)
+	require.Equal(t, at(5, 0), test.Finished)
+	require.Equal(t, generateTaskSpanID("90210", "2", "test"), test.SpanID)
+	require.Equal(t, map[string]any{"ci.task.id": "test", "ci.acme.job.exit_code": int64(1)}, test.Attributes)
+
+	require.Equal(t, "test", p.Tasks[2].Parent)
+
+	// Tasks without timestamps span their run.
+	deploy := p.Tasks[3]
+	require.Equal(t, cimodel.ResultSkip, deploy.Result)
+	require.Equal(t, p.Started, deploy.Started)
+	require.Equal(t, p.Finished, deploy.Finished)
+}
+
+func TestMappingPipelineDefaults(t *testing.T) {
+	_, deployments := testMappings(t)
+
+	p, err := deployments.pipeline(loadPayload(t, "deploy_finished.json"), time.Now())
+	require.NoError(t, err)
+
+	require.Equal(t, "web-app", p.Name)
+	// Configured statuses are mapped case-insensitively.
+	require.Equal(t, cimodel.ResultCancellation, p.Result)
+	require.Equal(t, time.Unix(1709633100, 0).UTC(), p.Started)
+	require.Equal(t, time.Unix(1709633160, 5e8).UTC(), p.Finished)
+	require.Equal(t, generateTraceID("d-5521", "1"), p.TraceID)
+	require.Empty(t, p.Links)
+	require.Empty(t, p.Tasks)
+	require.Equal(t, map[string]any{"deployment.environment.name": "production"}, p.ResourceAttributes)
+
+	// Names fall back to literals, and runs without timestamps end when
+	// they are received.
+	received := at(30, 0)
+	p, err = deployments.pipeline(mustDecode(t, `{"deployment": {"uid": "d-1", "result": "Rolled back"}}`), received)
+	require.NoError(t, err)
+	require.Equal(t, "deployment", p.Name)
+	require.Equal(t, cimodel.ResultUnknown, p.Result)
+	require.Equal(t, "Rolled back", p.Status)
+	require.Equal(t, received, p.Started)
+	require.Equal(t, received, p.Finished)
+}
+
+func TestMappingPipelineErrors(t *testing.T) {
+	builds, _ := testMappings(t)
+
+	_, err := builds.pipeline(mustDecode(t, `{"build": {}}`), time.Now())
+	require.ErrorIs(t, err, errNoRunID)
+
+	_, err = builds.pipeline(mustDecode(t, `{"build": {"id": 1}, "jobs": [{"name": "lint"}]}`), time.Now())
+	require.ErrorIs(t, err, errNoTaskID)
+
+	_, err = builds.pipeline(mustDecode(t, `{"build": {"id": 1, "started_at": "yesterday"}}`), time.Now())
+	require.ErrorContains(t, err, "run: started:")
+}
+
+func TestCompileResults(t *testing.T) {
+	results, err := compileResults(map[string][]string{"Success": {"OK", "green"}, "skip": {"stopped"}})
+	require.NoError(t, err)
+
+	require.Equal(t, cimodel.ResultSuccess, results["green"])
+	require.Equal(t, cimodel.ResultSuccess, results["ok"])
+	// Configured statuses replace the defaults of their result, and take
+	// precedence over the defaults of other results.
+	require.NotContains(t, results, "passed")
+	require.Equal(t, cimodel.ResultSkip, results["stopped"])
+	require.NotContains(t, results, "skipped")
+	require.Equal(t, cimodel.ResultCancellation, results["aborted"])
+}
+
+func TestParseTime(t *testing.T) {
+	tests := map[string]struct {
+		value  any
+		format string
+		expect time.Time
+		err    bool
+	}{
+		"missing":       {format: timeFormatRFC3339},
+		"rfc3339":       {value: "2024-03-05T11:01:02+01:00", format: timeFormatRFC3339, expect: at(1, 2)},
+		"rfc3339 nanos": {value: "2024-03-05T10:01:02.5Z", format: timeFormatRFC3339, expect: at(1, 2).Add(500 * time.Millisecond)},
+		"unix string":   {value: "1709632862", format: timeFormatUnix, expect: at(1, 2)},
+		"unix number":   {value: mustDecode(t, "1709632862.25"), format: timeFormatUnix, expect: at(1, 2).Add(250 * time.Millisecond)},
+		"unix_ms":       {value: mustDecode(t, "1709632862250"), format: timeFormatUnixMs, expect: at(1, 2).Add(250 * time.Millisecond)},
+		"layout":        {value: "05/03/2024 10:01:02", format: "02/01/2006 15:04:05", expect: at(1, 2)},
+		"invalid unix":  {value: "now", format: timeFormatUnix, err: true},
+		"invalid":       {value: "1709632862", format: timeFormatRFC3339, err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parsed, err := parseTime(test.value, test.format)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, test.expect.Equal(parsed), "expected %s, got %s", test.expect, parsed)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jsonwebhookreceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.uber.org/zap"
+)
+
+const metricsMaxCacheSize = 100000
+const histogramCacheSize = 50000
+const histogramTTL = 24 * time.Hour
+
+type metricsHandler struct {
+	mu             sync.Mutex
+	mb             *metadata.MetricsBuilder
+	cfg            *Config
+	logger         *zap.Logger
+	countersCache  *lru.Cache[string, int64]
+	histogramCache *lru.Cache[string, *cimodel.Histogram]
+	durations      *cimodel.Durations
+}
+
+func newMetricsHandler(settings receiver.Settings, cfg *Config, logger *zap.Logger) (*metricsHandler, error) {
+	countersCache, err := lru.New[string, int64](metricsMaxCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize counters cache: %w", err)
+	}
+
+	// histogramCache stores cumulative histogram state per unique dimension set,
+	// as histograms are emitted with cumulative temporality.
+	histogramCache, err := lru.New[string, *cimodel.Histogram](histogramCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize histogram cache: %w", err)
+	}
+
+	durations, err := cimodel.NewDurations(histogramCacheSize, histogramTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize durations cache: %w", err)
+	}
+
+	return &metricsHandler{
+		cfg:            cfg,
+		mb:             metadata.NewMetricsBuilder(cfg.MetricsBuilderConfig, settings),
+		logger:         logger,
+		countersCache:  countersCache,
+		histogramCache: histogramCache,
+		durations:      durations,
+	}, nil
+}
+
+// pipelineToMetrics counts a run, unless its span is omitted, and its
+// tasks by result, and reports their durations. Runs and tasks whose status
+// maps to no result are not counted.
+func (m *metricsHandler) pipelineToMetrics(p *cimodel.Pipeline, system string) pmetric.Metrics {
+	m.logger.Debug("Processing run",
+		zap.String("system", system),
+		zap.String("pipeline", p.Name),
+		zap.String("status", p.Status),
+		zap.Int("tasks", len(p.Tasks)),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	if !p.OmitSpan {
+		if result, ok := metadata.MapAttributeCiRunResult[string(p.Result)]; ok {
+			dimensions := fmt.Sprintf("run:%s:%s", system, p.Name)
+			val, found := m.countersCache.Get(dimensions + ":" + result.String())
+			if !found {
+				// The counters of the other results start at zero, so that
+				// their increases are visible from their first run.
+				for _, r := range metadata.MapAttributeCiRunResult {
+					if r != result && m.seedCounter(dimensions+":"+r.String()) {
+						m.mb.RecordRunsCountDataPoint(now, 0, system, p.Name, r)
+					}
+				}
+			}
+			m.countersCache.Add(dimensions+":"+result.String(), val+1)
+			m.mb.RecordRunsCountDataPoint(now, val+1, system, p.Name, result)
+		}
+	}
+
+	for _, task := range p.Tasks {
+		result, ok := metadata.MapAttributeCiRunResult[string(task.Result)]
+		if !ok {
+			continue
+		}
+		dimensions := fmt.Sprintf("task:%s:%s:%s", system, p.Name, task.Name)
+		val, found := m.countersCache.Get(dimensions + ":" + result.String())
+		if !found {
+			for _, r := range metadata.MapAttributeCiRunResult {
+				if r != result && m.seedCounter(dimensions+":"+r.String()) {
+					m.mb.RecordTasksCountDataPoint(now, 0, system, p.Name, task.Name, r)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+result.String(), val+1)
+		m.mb.RecordTasksCountDataPoint(now, val+1, system, p.Name, task.Name, result)
+	}
+
+	metrics := m.mb.Emit()
+	ms := scopeMetrics(metrics)
+
+	if !p.OmitSpan && p.Result != cimodel.ResultUnknown {
+		if m.cfg.Semconv.EmitsLegacy() {
+			key := fmt.Sprintf("hist:run:%s:%s:%s", system, p.Name, p.Result)
+			cimodel.AppendHistogram(ms, "runs.duration", map[string]any{
+				"ci.system":        system,
+				"ci.pipeline.name": p.Name,
+				"ci.run.result":    string(p.Result),
+			}, m.observeDuration(key, p.Finished.Sub(p.Started).Seconds()))
+		}
+
+		if m.cfg.Semconv.Enabled {
+			m.durations.AppendPipeline(ms, p)
+		}
+	}
+
+	for i := range p.Tasks {
+		task := &p.Tasks[i]
+		// Tasks that never ran have no duration.
+		if task.Result == cimodel.ResultUnknown || task.Result == cimodel.ResultSkip {
+			continue
+		}
+
+		if m.cfg.Semconv.EmitsLegacy() {
+			key := fmt.Sprintf("hist:task:%s:%s:%s:%s", system, p.Name, task.Name, task.Result)
+			cimodel.AppendHistogram(ms, "tasks.duration", map[string]any{
+				"ci.system":        system,
+				"ci.pipeline.name": p.Name,
+				"ci.task.name":     task.Name,
+				"ci.run.result":    string(task.Result),
+			}, m.observeDuration(key, task.Finished.Sub(task.Started).Seconds()))
+		}
+
+		if m.cfg.Semconv.Enabled {
+			m.durations.AppendTask(ms, p, task)
+		}
+	}
+
+	return metrics
+}
+
+// scopeMetrics returns the metrics emitted by the metrics builder, which
+// emits no resource at all when no counter was recorded.
+func scopeMetrics(metrics pmetric.Metrics) pmetric.MetricSlice {
+	if metrics.ResourceMetrics().Len() == 0 {
+		scope := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+		scope.Scope().SetName(metadata.ScopeName)
+		return scope.Metrics()
+	}
+	return metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+}
+
+// seedCounter starts the counter cached under key at zero, reporting
+// whether it was unknown. Called under m.mu.
+func (m *metricsHandler) seedCounter(key string) bool {
+	if m.countersCache.Contains(key) {
+		return false
+	}
+	m.countersCache.Add(key, 0)
+	return true
+}
+
+// observeDuration records a duration in the histogram cached under key.
+// Called under m.mu.
+func (m *metricsHandler) observeDuration(key string, duration float64) *cimodel.Histogram {
+	// Stale histograms start over, the LRU evicts those never observed again
+	h, ok := m.histogramCache.Get(key)
+	if !ok || time.Since(h.LastSeen) >= histogramTTL {
+		h = cimodel.NewHistogram()
+	}
+	h.Observe(duration)
+	m.histogramCache.Add(key, h)
+	return h
+}
@@ -0,0 +1,166 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jsonwebhookreceiver/internal/metadata"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestMetricsHandler(t *testing.T, cfg *Config) *metricsHandler {
+	t.Helper()
+	cfg.MetricsBuilderConfig = metadata.DefaultMetricsBuilderConfig()
+	mh, err := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return mh
+}
+
+// metricNames returns the names of the metrics, with their data point counts.
+func metricNames(metrics pmetric.Metrics) map[string]int {
+	names := map[string]int{}
+	for i := range metrics.ResourceMetrics().Len() {
+		sms := metrics.ResourceMetrics().At(i).ScopeMetrics()
+		for j := range sms.Len() {
+			ms := sms.At(j).Metrics()
+			for k := range ms.Len() {
+				m := ms.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					names[m.Name()] += m.Sum().DataPoints().Len()
+				case pmetric.MetricTypeHistogram:
+					names[m.Name()] += m.Histogram().DataPoints().Len()
+				}
+			}
+		}
+	}
+	return names
+}
+
+func testPipeline(t *testing.T) *cimodel.Pipeline {
+	t.Helper()
+	builds, _ := testMappings(t)
+	p, err := builds.pipeline(loadPayload(t, "build_finished.json"), time.Now())
+	require.NoError(t, err)
+	return p
+}
+
+func TestPipelineToMetrics(t *testing.T) {
+	p := testPipeline(t)
+	mh := newTestMetricsHandler(t, &Config{})
+
+	// The first run seeds the counters of the other results. The skipped
+	// task has no duration.
+	results := len(metadata.MapAttributeCiRunResult)
+	require.Equal(t, map[string]int{
+		"runs.count":     results,
+		"tasks.count":    4 * results,
+		"runs.duration":  1,
+		"tasks.duration": 3,
+	}, metricNames(mh.pipelineToMetrics(p, "acme-ci")))
+
+	// The counters keep counting.
+	metrics := mh.pipelineToMetrics(p, "acme-ci")
+	dp := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, int64(2), dp.IntValue())
+	require.Equal(t, map[string]any{
+		"ci.system":        "acme-ci",
+		"ci.pipeline.name": "acme/web-app",
+		"ci.run.result":    "failure",
+	}, dp.Attributes().AsRaw())
+
+	val, ok := mh.countersCache.Get("task:acme-ci:acme/web-app:Test:failure")
+	require.True(t, ok)
+	require.Equal(t, int64(2), val)
+}
+
+func TestPipelineToMetricsUnknownResult(t *testing.T) {
+	p := testPipeline(t)
+	p.OmitSpan = true
+	for i := range p.Tasks {
+		p.Tasks[i].Result = cimodel.ResultUnknown
+	}
+	mh := newTestMetricsHandler(t, &Config{})
+
+	// Runs whose span is omitted and statuses mapping to no result are not
+	// counted.
+	require.Zero(t, mh.pipelineToMetrics(p, "acme-ci").DataPointCount())
+}
+
+func TestPipelineToMetricsSemconv(t *testing.T) {
+	p := testPipeline(t)
+
+	tests := []struct {
+		desc          string
+		semconv       semconv.Config
+		expectMetrics []string
+	}{
+		{
+			desc:          "Legacy metrics",
+			expectMetrics: []string{"runs.count", "tasks.count", "runs.duration", "tasks.duration"},
+		},
+		{
+			desc:          "Semantic conventions",
+			semconv:       semconv.Config{Enabled: true},
+			expectMetrics: []string{"runs.count", "tasks.count", semconv.MetricCICDPipelineRunDuration, semconv.MetricCICDPipelineTaskRunDuration},
+		},
+		{
+			desc:    "Semantic conventions with legacy metrics",
+			semconv: semconv.Config{Enabled: true, EmitLegacy: true},
+			expectMetrics: []string{
+				"runs.count", "tasks.count", "runs.duration", "tasks.duration",
+				semconv.MetricCICDPipelineRunDuration, semconv.MetricCICDPipelineTaskRunDuration,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			mh := newTestMetricsHandler(t, &Config{Semconv: test.semconv})
+
+			names := metricNames(mh.pipelineToMetrics(p, "acme-ci"))
+			require.Len(t, names, len(test.expectMetrics))
+			for _, name := range test.expectMetrics {
+				require.Contains(t, names, name)
+			}
+		})
+	}
+}
+
+func TestPipelineToMetricsConcurrency(t *testing.T) {
+	p := testPipeline(t)
+	mh := newTestMetricsHandler(t, &Config{})
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Go(func() {
+			mh.pipelineToMetrics(p, "acme-ci")
+		})
+	}
+	wg.Wait()
+
+	val, ok := mh.countersCache.Get("run:acme-ci:acme/web-app:failure")
+	require.True(t, ok)
+	require.Equal(t, int64(50), val)
+}
+
+func TestObserveDurationStartsOver(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+
+	mh.observeDuration("key", 1)
+	require.Equal(t, uint64(2), mh.observeDuration("key", 1).Count)
+
+	// Histograms not observed within the TTL start over
+	h, _ := mh.histogramCache.Get("key")
+	h.LastSeen = time.Now().Add(-histogramTTL)
+	require.Equal(t, uint64(1), mh.observeDuration("key", 1).Count)
+}
@@ -0,0 +1,252 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/jsonwebhookreceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.uber.org/zap"
+)
+
+var errMissingEndpoint = errors.New("missing a receiver endpoint")
+
+type jsonWebhookReceiver struct {
+	tracesConsumer  consumer.Traces
+	metricsConsumer consumer.Metrics
+	metricsHandler  *metricsHandler
+	mappings        []*mapping
+	config          *Config
+	server          *http.Server
+	shutdownWG      sync.WaitGroup
+	createSettings  receiver.Settings
+	logger          *zap.Logger
+	obsrecv         *receiverhelper.ObsReport
+}
+
+func newReceiver(
+	params receiver.Settings,
+	config *Config,
+) (*jsonWebhookReceiver, error) {
+	if config.NetAddr.Endpoint == "" {
+		return nil, errMissingEndpoint
+	}
+
+	transport := "http"
+	if config.TLS.HasValue() {
+		transport = "https"
+	}
+
+	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             params.ID,
+		Transport:              transport,
+		ReceiverCreateSettings: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metricsHandler, err := newMetricsHandler(params, config, params.Logger.Named("metricsHandler"))
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := make([]*mapping, 0, len(config.Mappings))
+	for i := range config.Mappings {
+		m, err := compileMapping(&config.Mappings[i])
+		if err != nil {
+			return nil, fmt.Errorf("mapping %s: %w", mappingName(&config.Mappings[i], i), err)
+		}
+		if m.name == "" {
+			m.name = mappingName(&config.Mappings[i], i)
+		}
+		mappings = append(mappings, m)
+	}
+
+	return &jsonWebhookReceiver{
+		config:         config,
+		createSettings: params,
+		logger:         params.Logger,
+		obsrecv:        obsrecv,
+		metricsHandler: metricsHandler,
+		mappings:       mappings,
+	}, nil
+}
+
+// newTracesReceiver creates a traces receiver based on provided config.
+func newTracesReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Traces,
+) (receiver.Traces, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*jsonWebhookReceiver).tracesConsumer = consumer
+
+	return r, nil
+}
+
+// newMetricsReceiver creates a metrics receiver based on provided config.
+func newMetricsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*jsonWebhookReceiver).metricsConsumer = consumer
+
+	return r, nil
+}
+
+func getOrAddReceiver(set receiver.Settings, cfg component.Config) (*sharedcomponent.SharedComponent, error) {
+	var err error
+	r := receivers.GetOrAdd(cfg, func() component.Component {
+		var rcv component.Component
+		rcv, err = newReceiver(set, cfg.(*Config))
+		return rcv
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (jr *jsonWebhookReceiver) Start(_ context.Context, _ component.Host) error {
+	endpoint := fmt.Sprintf("%s%s", jr.config.NetAddr.Endpoint, jr.config.Path)
+	jr.logger.Info("Starting JSON webhook server", zap.String("endpoint", endpoint))
+	jr.server = &http.Server{
+		Addr:              jr.config.NetAddr.Endpoint,
+		Handler:           jr,
+		ReadHeaderTimeout: 20 * time.Second,
+	}
+
+	jr.shutdownWG.Add(1)
+	go func() {
+		defer jr.shutdownWG.Done()
+
+		if errHTTP := jr.server.ListenAndServe(); !errors.Is(errHTTP, http.ErrServerClosed) && errHTTP != nil {
+			jr.createSettings.Logger.Error("Server closed with error", zap.Error(errHTTP))
+		}
+	}()
+
+	return nil
+}
+
+func (jr *jsonWebhookReceiver) Shutdown(_ context.Context) error {
+	var err error
+	if jr.server != nil {
+		err = jr.server.Close()
+	}
+	jr.shutdownWG.Wait()
+	return err
+}
+
+func (jr *jsonWebhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Validate request path
+	if r.URL.Path != jr.config.Path {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if jr.config.Secret != "" {
+		if err := validateToken(r, jr.config.Secret); err != nil {
+			jr.logger.Debug("Token validation failed", zap.Error(err))
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		jr.logger.Debug("Failed to read payload", zap.Error(err))
+		http.Error(w, "Failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	decoded, err := decodePayload(payload)
+	if err != nil {
+		jr.logger.Debug("Payload parsing failed", zap.Error(err))
+		http.Error(w, "Failed to parse payload", http.StatusBadRequest)
+		return
+	}
+
+	m := jr.mapping(decoded)
+	if m == nil {
+		jr.logger.Debug("Skipping payload matching no mapping")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	p, err := m.pipeline(decoded, time.Now())
+	if err != nil {
+		jr.logger.Debug("Payload mapping failed", zap.String("mapping", m.name), zap.Error(err))
+		http.Error(w, "Failed to map payload", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if jr.tracesConsumer != nil {
+		jr.consumeTraces(ctx, pipelineToTraces(p, m, jr.config, jr.logger.Named("pipelineToTraces")))
+	}
+	if jr.metricsConsumer != nil {
+		jr.consumeMetrics(ctx, jr.metricsHandler.pipelineToMetrics(p, m.system))
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// mapping returns the first mapping applying to the payload, if any.
+func (jr *jsonWebhookReceiver) mapping(payload any) *mapping {
+	for _, m := range jr.mappings {
+		if m.matches(payload) {
+			return m
+		}
+	}
+	return nil
+}
+
+func (jr *jsonWebhookReceiver) consumeTraces(ctx context.Context, td ptrace.Traces) {
+	tracesCtx := jr.obsrecv.StartTracesOp(ctx)
+	err := jr.tracesConsumer.ConsumeTraces(tracesCtx, td)
+	jr.obsrecv.EndTracesOp(tracesCtx, metadata.Type.String(), td.SpanCount(), err)
+	if err != nil {
+		jr.logger.Error("Failed to consume traces", zap.Error(err))
+	}
+}
+
+func (jr *jsonWebhookReceiver) consumeMetrics(ctx context.Context, md pmetric.Metrics) {
+	if md.DataPointCount() == 0 {
+		return
+	}
+
+	metricsCtx := jr.obsrecv.StartMetricsOp(ctx)
+	err := jr.metricsConsumer.ConsumeMetrics(metricsCtx, md)
+	jr.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), md.DataPointCount(), err)
+	if err != nil {
+		jr.logger.Error("Failed to consume metrics", zap.Error(err))
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+func TestNewReceiver(t *testing.T) {
+	defaultConfig := createDefaultConfig().(*Config)
+	invalidMapping := createDefaultConfig().(*Config)
+	invalidMapping.Mappings = []MappingConfig{{Name: "builds", Run: RunMappingConfig{ID: "$.build["}}}
+
+	tests := []struct {
+		desc      string
+		config    Config
+		err       error
+		errString string
+	}{
+		{
+			desc:   "Default config succeeds",
+			config: *defaultConfig,
+		},
+		{
+			desc:   "Missing endpoint fails",
+			config: Config{},
+			err:    errMissingEndpoint,
+		},
+		{
+			desc:      "Invalid mapping fails",
+			config:    *invalidMapping,
+			errString: "mapping builds: run.id: invalid expression",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), &test.config)
+			switch {
+			case test.err != nil:
+				require.ErrorIs(t, err, test.err)
+				return
+			case test.errString != "":
+				require.ErrorContains(t, err, test.errString)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, rec.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	buildPayload, err := os.ReadFile(filepath.Join("testdata", "build_finished.json"))
+	require.NoError(t, err)
+	deployPayload, err := os.ReadFile(filepath.Join("testdata", "deploy_finished.json"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc          string
+		path          string
+		token         string
+		payload       []byte
+		expectStatus  int
+		expectSpans   int
+		expectMetrics bool
+	}{
+		{
+			desc:         "Unknown path",
+			path:         "/other",
+			token:        "mysecret",
+			payload:      buildPayload,
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			desc:         "Invalid token",
+			token:        "wrong",
+			payload:      buildPayload,
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:         "Invalid payload",
+			token:        "mysecret",
+			payload:      []byte(`{"event": "build.finished"} {}`),
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			desc:         "Unmapped payload",
+			token:        "mysecret",
+			payload:      []byte(`{"event": "build.started", "build": {"id": 1}}`),
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			desc:         "Payload without run ID",
+			token:        "mysecret",
+			payload:      []byte(`{"event": "build.finished", "build": {}}`),
+			expectStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			desc:          "Finished build",
+			token:         "mysecret",
+			payload:       buildPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   5,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Finished deployment",
+			token:         "mysecret",
+			payload:       deployPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   1,
+			expectMetrics: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			cfg := loadTestConfig(t)
+
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, rec.Shutdown(context.Background())) })
+
+			tracesSink := new(consumertest.TracesSink)
+			metricsSink := new(consumertest.MetricsSink)
+			rec.tracesConsumer = tracesSink
+			rec.metricsConsumer = metricsSink
+
+			path := test.path
+			if path == "" {
+				path = cfg.Path
+			}
+			req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(test.payload))
+			req.Header.Set("Authorization", "Bearer "+test.token)
+			w := httptest.NewRecorder()
+
+			rec.ServeHTTP(w, req)
+
+			require.Equal(t, test.expectStatus, w.Code)
+			require.Equal(t, test.expectSpans, tracesSink.SpanCount())
+			require.Equal(t, test.expectMetrics, len(metricsSink.AllMetrics()) > 0)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeName    = "jsonwebhookreceiver"
+	scopeVersion = "0.1.0"
+)
+
+func pipelineToTraces(p *cimodel.Pipeline, m *mapping, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing run",
+		zap.String("mapping", m.name),
+		zap.String("id", p.ID),
+		zap.String("name", p.Name),
+		zap.Int("tasks", len(p.Tasks)),
+	)
+
+	finishPipeline(p, m, config)
+	return cimodel.ToTraces(p, cimodel.Options{
+		ScopeName:    scopeName,
+		ScopeVersion: scopeVersion,
+		Semconv:      config.Semconv,
+	})
+}
+
+// finishPipeline sets the resource attributes of a run. Mapped resource
+// attributes take precedence.
+func finishPipeline(p *cimodel.Pipeline, m *mapping, config *Config) {
+	attrs := map[string]any{
+		"service.name": generateServiceName(config, p.Name),
+		"ci.system":    m.system,
+	}
+	for key, value := range p.ResourceAttributes {
+		attrs[key] = value
+	}
+	p.ResourceAttributes = attrs
+}
+
+func generateServiceName(config *Config, name string) string {
+	if config.CustomServiceName != "" {
+		return config.CustomServiceName
+	}
+	formattedName := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(name, "/", "-"), "_", "-"))
+	return fmt.Sprintf("%s%s%s", config.ServiceNamePrefix, formattedName, config.ServiceNameSuffix)
+}
+
+// The IDs follow the scheme of the GitHub Actions receiver, so that tools
+// reporting runs with the same IDs to both receivers share their traces.
+
+// generateTraceID returns the trace ID of an attempt of a run.
+func generateTraceID(runID, attempt string) pcommon.TraceID {
+	hash := sha256.Sum256([]byte(runID + attempt + "t"))
+	return pcommon.TraceID(hash[:16])
+}
+
+func generateRunSpanID(runID, attempt string) pcommon.SpanID {
+	return generateSpanID(runID + attempt + "s")
+}
+
+func generateTaskSpanID(runID, attempt, taskID string) pcommon.SpanID {
+	return generateSpanID(runID + attempt + taskID)
+}
+
+func generateSpanID(input string) pcommon.SpanID {
+	hash := sha256.Sum256([]byte(input))
+	return pcommon.SpanID(hash[8:16])
+}
@@ -0,0 +1,132 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package jsonwebhookreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap/zaptest"
+)
+
+func spansByName(traces ptrace.Traces) map[string]ptrace.Span {
+	byName := map[string]ptrace.Span{}
+	for i := range traces.ResourceSpans().Len() {
+		spans := traces.ResourceSpans().At(i).ScopeSpans().At(0).Spans()
+		for j := range spans.Len() {
+			byName[spans.At(j).Name()] = spans.At(j)
+		}
+	}
+	return byName
+}
+
+func TestPipelineToTraces(t *testing.T) {
+	builds, _ := testMappings(t)
+	p, err := builds.pipeline(loadPayload(t, "build_finished.json"), time.Now())
+	require.NoError(t, err)
+
+	traces := pipelineToTraces(p, builds, &Config{}, zaptest.NewLogger(t))
+	require.Equal(t, 5, traces.SpanCount())
+
+	resource := traces.ResourceSpans().At(0).Resource().Attributes()
+	serviceName, _ := resource.Get("service.name")
+	require.Equal(t, "acme-web-app", serviceName.Str())
+	system, _ := resource.Get("ci.system")
+	require.Equal(t, "acme-ci", system.Str())
+
+	spans := spansByName(traces)
+	// The IDs are those the GitHub Actions receiver would give run 90210.
+	run := spans["acme/web-app"]
+	require.Equal(t, "7c71a3f84991f570d156d08459b714ab", run.TraceID().String())
+	require.Equal(t, "575fcecc62816447", run.SpanID().String())
+	require.True(t, run.ParentSpanID().IsEmpty())
+	require.Equal(t, ptrace.StatusCodeError, run.Status().Code())
+	require.Equal(t, "FAILED", run.Status().Message())
+	require.Equal(t, 2, run.Links().Len())
+	require.Equal(t, "62d5f7d43a9a924484ed957d77d3ea3f", run.Links().At(0).TraceID().String())
+	team, _ := run.Attributes().Get("ci.acme.team")
+	require.Equal(t, "web", team.Str())
+	agents, _ := run.Attributes().Get("ci.acme.agents")
+	require.Equal(t, 3, agents.Slice().Len())
+
+	test := spans["Test"]
+	require.Equal(t, "244d6cbd2ef6f66c", test.SpanID().String())
+	require.Equal(t, run.SpanID(), test.ParentSpanID())
+	require.Equal(t, at(1, 0), test.StartTimestamp().AsTime())
+	exitCode, _ := test.Attributes().Get("ci.acme.job.exit_code")
+	require.EqualValues(t, 1, exitCode.Int())
+
+	require.Equal(t, test.SpanID(), spans["Test shard 1"].ParentSpanID())
+	require.Equal(t, ptrace.StatusCodeOk, spans["Lint"].Status().Code())
+	require.Equal(t, ptrace.StatusCodeUnset, spans["Deploy"].Status().Code())
+}
+
+func TestPipelineToTracesOmitSpan(t *testing.T) {
+	cfg := loadTestConfig(t)
+	cfg.Mappings[0].Run.OmitSpan = true
+	builds, err := compileMapping(&cfg.Mappings[0])
+	require.NoError(t, err)
+	p, err := builds.pipeline(loadPayload(t, "build_finished.json"), time.Now())
+	require.NoError(t, err)
+
+	traces := pipelineToTraces(p, builds, &Config{}, zaptest.NewLogger(t))
+	require.Equal(t, 4, traces.SpanCount())
+	require.Equal(t, "575fcecc62816447", spansByName(traces)["Lint"].ParentSpanID().String())
+}
+
+func TestPipelineToTracesSemconv(t *testing.T) {
+	builds, deployments := testMappings(t)
+	p, err := builds.pipeline(loadPayload(t, "build_finished.json"), time.Now())
+	require.NoError(t, err)
+
+	traces := pipelineToTraces(p, builds, &Config{Semconv: semconv.Config{Enabled: true}}, zaptest.NewLogger(t))
+
+	resource := traces.ResourceSpans().At(0).Resource().Attributes()
+	repoURL, _ := resource.Get(semconv.AttributeVCSRepositoryURLFull)
+	require.Equal(t, "https://git.acme.io/acme/web-app", repoURL.Str())
+
+	spans := spansByName(traces)
+	run := spans["acme/web-app"].Attributes()
+	runID, _ := run.Get(semconv.AttributeCICDPipelineRunID)
+	require.Equal(t, "90210", runID.Str())
+	result, _ := run.Get(semconv.AttributeCICDPipelineResult)
+	require.Equal(t, "failure", result.Str())
+	branch, _ := run.Get(semconv.AttributeVCSRefHeadName)
+	require.Equal(t, "main", branch.Str())
+
+	test := spans["Test"].Attributes()
+	worker, _ := test.Get(semconv.AttributeCICDWorkerName)
+	require.Equal(t, "runner-2", worker.Str())
+
+	// Mapped resource attributes are added to the defaults.
+	p, err = deployments.pipeline(loadPayload(t, "deploy_finished.json"), time.Now())
+	require.NoError(t, err)
+	resource = pipelineToTraces(p, deployments, &Config{}, zaptest.NewLogger(t)).ResourceSpans().At(0).Resource().Attributes()
+	require.Equal(t, map[string]any{
+		"service.name":                "web-app",
+		"ci.system":                   "acme-deploy",
+		"deployment.environment.name": "production",
+	}, resource.AsRaw())
+}
+
+func TestGenerateServiceName(t *testing.T) {
+	tests := map[string]struct {
+		config *Config
+		name   string
+		expect string
+	}{
+		"name":    {config: &Config{}, name: "acme/web_app", expect: "acme-web-app"},
+		"custom":  {config: &Config{CustomServiceName: "ci"}, name: "acme/web-app", expect: "ci"},
+		"affixes": {config: &Config{ServiceNamePrefix: "foo-", ServiceNameSuffix: "-bar"}, name: "web-app", expect: "foo-web-app-bar"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expect, generateServiceName(test.config, test.name))
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package tektonargoreceiver
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+)
+
+const labelWorkflowTemplate = "workflows.argoproj.io/workflow-template"
+
+// Phases of workflows and their nodes.
+const (
+	phaseSucceeded = "Succeeded"
+	phaseFailed    = "Failed"
+	phaseError     = "Error"
+	phaseSkipped   = "Skipped"
+	phaseOmitted   = "Omitted"
+)
+
+// Types of workflow nodes. Step and task groups only group the nodes of a
+// step or DAG template, and have no span.
+const (
+	nodeTypePod       = "Pod"
+	nodeTypeRetry     = "Retry"
+	nodeTypeStepGroup = "StepGroup"
+	nodeTypeTaskGroup = "TaskGroup"
+)
+
+type workflow struct {
+	Kind     string     `json:"kind"`
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		WorkflowTemplateRef *struct {
+			Name string `json:"name"`
+		} `json:"workflowTemplateRef"`
+	} `json:"spec"`
+	Status struct {
+		Phase      string                  `json:"phase"`
+		Message    string                  `json:"message"`
+		StartedAt  string                  `json:"startedAt"`
+		FinishedAt string                  `json:"finishedAt"`
+		Nodes      map[string]workflowNode `json:"nodes"`
+	} `json:"status"`
+}
+
+// workflowNode is a node of a workflow: a pod, a retried node, or a step or
+// DAG template grouping the nodes with its ID as boundary.
+type workflowNode struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	DisplayName  string   `json:"displayName"`
+	Type         string   `json:"type"`
+	TemplateName string   `json:"templateName"`
+	Phase        string   `json:"phase"`
+	BoundaryID   string   `json:"boundaryID"`
+	Message      string   `json:"message"`
+	StartedAt    string   `json:"startedAt"`
+	FinishedAt   string   `json:"finishedAt"`
+	HostNodeName string   `json:"hostNodeName"`
+	Children     []string `json:"children"`
+	TemplateRef  *struct {
+		Name     string `json:"name"`
+		Template string `json:"template"`
+	} `json:"templateRef"`
+}
+
+// finished reports whether the workflow completed.
+func (w *workflow) finished() bool {
+	switch w.Status.Phase {
+	case phaseSucceeded, phaseFailed, phaseError:
+		return true
+	default:
+		return false
+	}
+}
+
+// templateName returns the name of the workflow template of a workflow, or
+// the name the workflow was generated from, if any.
+func (w *workflow) templateName() string {
+	if name := w.Metadata.Labels[labelWorkflowTemplate]; name != "" {
+		return name
+	}
+	if ref := w.Spec.WorkflowTemplateRef; ref != nil && ref.Name != "" {
+		return ref.Name
+	}
+	if name := strings.TrimSuffix(w.Metadata.GenerateName, "-"); name != "" {
+		return name
+	}
+	return w.Metadata.Name
+}
+
+// templateName returns the name of the template of a node.
+func (n *workflowNode) templateName() string {
+	if n.TemplateRef != nil {
+		return n.TemplateRef.Name + "/" + n.TemplateRef.Template
+	}
+	return n.TemplateName
+}
+
+// phaseResult maps the phase of a completed workflow or node.
+func phaseResult(phase, message string) cimodel.Result {
+	switch phase {
+	case phaseSucceeded:
+		return cimodel.ResultSuccess
+	case phaseSkipped, phaseOmitted:
+		return cimodel.ResultSkip
+	case phaseFailed:
+		switch {
+		case strings.Contains(message, "exceeded its deadline"):
+			return cimodel.ResultTimeout
+		case strings.HasPrefix(message, "Stopped with strategy"):
+			return cimodel.ResultCancellation
+		default:
+			return cimodel.ResultFailure
+		}
+	case phaseError:
+		return cimodel.ResultError
+	default:
+		return cimodel.ResultUnknown
+	}
+}
+
+// workflowPipeline maps a workflow to the CI model, with a task per node.
+// Nodes are the children of the template node they run in, or of the retry
+// node of their attempts.
+func workflowPipeline(w *workflow) cimodel.Pipeline {
+	started, finished := runTimes(w.Metadata.CreationTimestamp, w.Status.StartedAt, w.Status.FinishedAt)
+
+	pipeline := cimodel.Pipeline{
+		ID:       w.Metadata.Name,
+		Name:     w.templateName(),
+		Result:   phaseResult(w.Status.Phase, w.Status.Message),
+		Status:   w.Status.Phase,
+		Started:  started,
+		Finished: finished,
+		TraceID:  generateTraceID(w.Metadata.UID),
+		SpanID:   generateRunSpanID(w.Metadata.UID),
+		Attributes: map[string]any{
+			"ci.argo.workflow.name":  w.Metadata.Name,
+			"ci.argo.workflow.uid":   w.Metadata.UID,
+			"ci.argo.workflow.phase": w.Status.Phase,
+		},
+	}
+	if w.Status.Message != "" {
+		pipeline.Attributes["ci.argo.workflow.message"] = w.Status.Message
+	}
+	if name := w.templateName(); name != w.Metadata.Name {
+		pipeline.Attributes["ci.argo.workflow_template.name"] = name
+	}
+
+	// The attempts of retried nodes share the boundary of the retry node
+	parents := map[string]string{}
+	for id, node := range w.Status.Nodes {
+		if node.Type == nodeTypeRetry {
+			for _, child := range node.Children {
+				parents[child] = id
+			}
+		}
+	}
+
+	// Nodes are sorted by start time, so that the trace is stable
+	ids := make([]string, 0, len(w.Status.Nodes))
+	for id, node := range w.Status.Nodes {
+		// The root node is the workflow itself
+		if id == w.Metadata.Name || node.Type == nodeTypeStepGroup || node.Type == nodeTypeTaskGroup {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		if c := strings.Compare(w.Status.Nodes[a].StartedAt, w.Status.Nodes[b].StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+
+	for _, id := range ids {
+		node := w.Status.Nodes[id]
+		task := nodeTask(w, &node, finished)
+		if parent, ok := parents[id]; ok {
+			task.Parent = parent
+		}
+		pipeline.Tasks = append(pipeline.Tasks, task)
+	}
+
+	return pipeline
+}
+
+// nodeTask maps a workflow node. Nodes that never ran start and finish when
+// the workflow finished.
+func nodeTask(w *workflow, node *workflowNode, workflowFinished time.Time) cimodel.Task {
+	started, finished := runTimes("", node.StartedAt, node.FinishedAt)
+	if started.IsZero() {
+		started, finished = workflowFinished, workflowFinished
+	}
+
+	task := cimodel.Task{
+		ID:       node.ID,
+		Name:     node.DisplayName,
+		Worker:   node.HostNodeName,
+		Result:   phaseResult(node.Phase, node.Message),
+		Status:   node.Phase,
+		Started:  started,
+		Finished: finished,
+		SpanID:   generateTaskSpanID(w.Metadata.UID, node.ID),
+		Attributes: map[string]any{
+			"ci.argo.node.id":       node.ID,
+			"ci.argo.node.name":     node.DisplayName,
+			"ci.argo.node.type":     node.Type,
+			"ci.argo.node.template": node.templateName(),
+			"ci.argo.node.phase":    node.Phase,
+		},
+	}
+	if node.BoundaryID != w.Metadata.Name {
+		task.Parent = node.BoundaryID
+	}
+	if node.HostNodeName != "" {
+		task.Attributes["ci.argo.node.host"] = node.HostNodeName
+	}
+	if node.Message != "" {
+		task.Attributes["ci.argo.node.message"] = node.Message
+	}
+
+	return task
+}
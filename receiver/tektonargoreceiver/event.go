@@ -0,0 +1,187 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package tektonargoreceiver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// contentTypeStructured is the content type of CloudEvents sent in
+	// structured content mode, with their attributes in the body.
+	contentTypeStructured = "application/cloudevents+json"
+	// tektonEventPrefix prefixes the types of the events of Tekton.
+	tektonEventPrefix = "dev.tekton.event."
+)
+
+// Types of the Tekton events handled by the receiver. Started, running and
+// unknown events are ignored.
+const (
+	eventPipelineRunSuccessful = "dev.tekton.event.pipelinerun.successful.v1"
+	eventPipelineRunFailed     = "dev.tekton.event.pipelinerun.failed.v1"
+	eventTaskRunSuccessful     = "dev.tekton.event.taskrun.successful.v1"
+	eventTaskRunFailed         = "dev.tekton.event.taskrun.failed.v1"
+)
+
+const kindWorkflow = "Workflow"
+
+var (
+	errMissingType     = errors.New("cloudevent has no type")
+	errMissingData     = errors.New("cloudevent has no data")
+	errMissingRun      = errors.New("event has no pipelinerun or taskrun")
+	errUnsupportedData = errors.New("cloudevent data is not a Tekton run or an Argo workflow")
+	errInvalidToken    = errors.New("invalid bearer token")
+)
+
+// cloudEvent is a CloudEvent, in binary or structured content mode.
+type cloudEvent struct {
+	ID          string          `json:"id"`
+	Source      string          `json:"source"`
+	SpecVersion string          `json:"specversion"`
+	Type        string          `json:"type"`
+	Subject     string          `json:"subject"`
+	Time        string          `json:"time"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// tektonEvent is the data of Tekton events, carrying the run the event is
+// about.
+type tektonEvent struct {
+	PipelineRun *pipelineRun `json:"pipelineRun"`
+	TaskRun     *taskRun     `json:"taskRun"`
+}
+
+// objectMeta is the metadata of Kubernetes resources.
+type objectMeta struct {
+	Name              string            `json:"name"`
+	GenerateName      string            `json:"generateName"`
+	Namespace         string            `json:"namespace"`
+	UID               string            `json:"uid"`
+	Labels            map[string]string `json:"labels"`
+	CreationTimestamp string            `json:"creationTimestamp"`
+	OwnerReferences   []ownerReference  `json:"ownerReferences"`
+}
+
+type ownerReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	UID  string `json:"uid"`
+}
+
+// condition is a Knative condition, of which Tekton runs report the
+// Succeeded one.
+type condition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// parseCloudEvent parses the CloudEvent of a request, sent in binary
+// content mode, with its attributes in Ce- headers, or in structured
+// content mode.
+func parseCloudEvent(r *http.Request, payload []byte) (*cloudEvent, error) {
+	var e cloudEvent
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == contentTypeStructured {
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return nil, err
+		}
+	} else {
+		e = cloudEvent{
+			ID:          r.Header.Get("Ce-Id"),
+			Source:      r.Header.Get("Ce-Source"),
+			SpecVersion: r.Header.Get("Ce-Specversion"),
+			Type:        r.Header.Get("Ce-Type"),
+			Subject:     r.Header.Get("Ce-Subject"),
+			Time:        r.Header.Get("Ce-Time"),
+			Data:        payload,
+		}
+	}
+
+	if e.Type == "" {
+		return nil, errMissingType
+	}
+	if len(e.Data) == 0 || string(e.Data) == "null" {
+		return nil, errMissingData
+	}
+	return &e, nil
+}
+
+// isTekton reports whether the event was sent by Tekton.
+func (e *cloudEvent) isTekton() bool {
+	return strings.HasPrefix(e.Type, tektonEventPrefix)
+}
+
+// tektonRun decodes the run of a Tekton event.
+func (e *cloudEvent) tektonRun() (*tektonEvent, error) {
+	var data tektonEvent
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return nil, err
+	}
+	if data.PipelineRun == nil && data.TaskRun == nil {
+		return nil, errMissingRun
+	}
+	return &data, nil
+}
+
+// argoWorkflow decodes the Argo workflow of an event. Workflows are either
+// the data of the event, or its body, as sent by the resource event source
+// of Argo Events.
+func (e *cloudEvent) argoWorkflow() (*workflow, error) {
+	var data struct {
+		workflow
+		Body *workflow `json:"body"`
+	}
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case data.Kind == kindWorkflow:
+		return &data.workflow, nil
+	case data.Body != nil && data.Body.Kind == kindWorkflow:
+		return data.Body, nil
+	default:
+		return nil, errUnsupportedData
+	}
+}
+
+// validateToken checks the bearer token of a request.
+func validateToken(r *http.Request, secret string) error {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return errInvalidToken
+	}
+	return nil
+}
+
+// ownerUID returns the UID of the owner of a resource of the given kind,
+// if any.
+func (m *objectMeta) ownerUID(kind string) string {
+	for _, owner := range m.OwnerReferences {
+		if owner.Kind == kind {
+			return owner.UID
+		}
+	}
+	return ""
+}
+
+func parseTime(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
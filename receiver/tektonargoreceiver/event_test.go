@@ -0,0 +1,154 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package tektonargoreceiver
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func loadPayload(t *testing.T, name string) []byte {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	return payload
+}
+
+// newEventRequest returns a request sending a CloudEvent in structured
+// content mode, or in binary content mode when eventType is set.
+func newEventRequest(path, eventType string, payload []byte) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	if eventType == "" {
+		req.Header.Set("Content-Type", contentTypeStructured+"; charset=utf-8")
+		return req
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Ce-Id", "a3f9b5c1-6d2e-4f8a-9b0c-1d2e3f4a5b6c")
+	req.Header.Set("Ce-Source", "/apis///namespaces/ci/taskruns/build-and-test-r8x2k-test")
+	req.Header.Set("Ce-Specversion", "1.0")
+	req.Header.Set("Ce-Type", eventType)
+	return req
+}
+
+func loadTektonEvent(t *testing.T, name, eventType string) *tektonEvent {
+	t.Helper()
+
+	payload := loadPayload(t, name)
+	e, err := parseCloudEvent(newEventRequest("/cloudevents", eventType, payload), payload)
+	require.NoError(t, err)
+	data, err := e.tektonRun()
+	require.NoError(t, err)
+	return data
+}
+
+func loadWorkflow(t *testing.T, name string) *workflow {
+	t.Helper()
+
+	payload := loadPayload(t, name)
+	e, err := parseCloudEvent(newEventRequest("/cloudevents", "", payload), payload)
+	require.NoError(t, err)
+	wf, err := e.argoWorkflow()
+	require.NoError(t, err)
+	return wf
+}
+
+func TestParseCloudEvent(t *testing.T) {
+	tests := map[string]struct {
+		eventType string
+		payload   string
+		expType   string
+		err       error
+	}{
+		"structured": {
+			payload: `{"specversion": "1.0", "type": "dev.tekton.event.taskrun.started.v1", "data": {"taskRun": {}}}`,
+			expType: "dev.tekton.event.taskrun.started.v1",
+		},
+		"structured without type": {
+			payload: `{"specversion": "1.0", "data": {"taskRun": {}}}`,
+			err:     errMissingType,
+		},
+		"structured without data": {
+			payload: `{"specversion": "1.0", "type": "dev.tekton.event.taskrun.started.v1"}`,
+			err:     errMissingData,
+		},
+		"binary": {
+			eventType: eventTaskRunFailed,
+			payload:   `{"taskRun": {}}`,
+			expType:   eventTaskRunFailed,
+		},
+		"binary without data": {
+			eventType: eventTaskRunFailed,
+			err:       errMissingData,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			payload := []byte(test.payload)
+			e, err := parseCloudEvent(newEventRequest("/cloudevents", test.eventType, payload), payload)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expType, e.Type)
+		})
+	}
+}
+
+func TestArgoWorkflow(t *testing.T) {
+	tests := map[string]struct {
+		data    string
+		expName string
+		err     error
+	}{
+		"workflow":             {data: `{"kind": "Workflow", "metadata": {"name": "ci-x7k2p"}}`, expName: "ci-x7k2p"},
+		"argo events resource": {data: `{"type": "UPDATE", "body": {"kind": "Workflow", "metadata": {"name": "ci-x7k2p"}}}`, expName: "ci-x7k2p"},
+		"other resource":       {data: `{"type": "UPDATE", "body": {"kind": "Pod"}}`, err: errUnsupportedData},
+		"other data":           {data: `{"message": "hello"}`, err: errUnsupportedData},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := &cloudEvent{Type: "resource", Data: []byte(test.data)}
+			wf, err := e.argoWorkflow()
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expName, wf.Metadata.Name)
+		})
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		err    error
+	}{
+		"valid token":   {header: "Bearer mysecret"},
+		"invalid token": {header: "Bearer wrong", err: errInvalidToken},
+		"basic auth":    {header: "Basic bXlzZWNyZXQ=", err: errInvalidToken},
+		"missing token": {err: errInvalidToken},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/cloudevents", nil)
+			if test.header != "" {
+				req.Header.Set("Authorization", test.header)
+			}
+			require.ErrorIs(t, validateToken(req, "mysecret"), test.err)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package tektonargoreceiver
+
+import (
+	"go.uber.org/goleak"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
@@ -0,0 +1,17 @@
+// Code generated by mdatagen. DO NOT EDIT.
+
+package metadata
+
+import (
+	"go.opentelemetry.io/collector/component"
+)
+
+var (
+	Type      = component.MustNewType("tektonargo")
+	ScopeName = "github.com/grafana/grafana-ci-otel-collector/receiver/tektonargoreceiver"
+)
+
+const (
+	TracesStability  = component.StabilityLevelAlpha
+	MetricsStability = component.StabilityLevelAlpha
+)
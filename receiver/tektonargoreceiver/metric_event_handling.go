@@ -0,0 +1,229 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package tektonargoreceiver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/tektonargoreceiver/internal/metadata"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver"
+	"go.uber.org/zap"
+)
+
+const metricsMaxCacheSize = 100000
+const histogramCacheSize = 50000
+const histogramTTL = 24 * time.Hour
+
+type metricsHandler struct {
+	mu             sync.Mutex
+	mb             *metadata.MetricsBuilder
+	cfg            *Config
+	logger         *zap.Logger
+	countersCache  *lru.Cache[string, int64]
+	histogramCache *lru.Cache[string, *cimodel.Histogram]
+	durations      *cimodel.Durations
+}
+
+func newMetricsHandler(settings receiver.Settings, cfg *Config, logger *zap.Logger) (*metricsHandler, error) {
+	countersCache, err := lru.New[string, int64](metricsMaxCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize counters cache: %w", err)
+	}
+
+	// histogramCache stores cumulative histogram state per unique dimension set,
+	// as histograms are emitted with cumulative temporality.
+	histogramCache, err := lru.New[string, *cimodel.Histogram](histogramCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize histogram cache: %w", err)
+	}
+
+	durations, err := cimodel.NewDurations(histogramCacheSize, histogramTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize durations cache: %w", err)
+	}
+
+	return &metricsHandler{
+		cfg:            cfg,
+		mb:             metadata.NewMetricsBuilder(cfg.MetricsBuilderConfig, settings),
+		logger:         logger,
+		countersCache:  countersCache,
+		histogramCache: histogramCache,
+		durations:      durations,
+	}, nil
+}
+
+// pipelineRunToMetrics counts a finished PipelineRun by result, and reports
+// its duration.
+func (m *metricsHandler) pipelineRunToMetrics(pr *pipelineRun) pmetric.Metrics {
+	pipeline := pipelineRunPipeline(pr)
+	return m.runToMetrics(metadata.AttributeCiSystemTekton, pr.Metadata.Namespace, &pipeline, true, nil)
+}
+
+// taskRunToMetrics counts a finished TaskRun by result, and reports its
+// duration. Standalone TaskRuns have no pipeline name.
+func (m *metricsHandler) taskRunToMetrics(tr *taskRun) pmetric.Metrics {
+	pipeline := taskRunPipeline(tr)
+	if pipeline.OmitSpan {
+		return m.runToMetrics(metadata.AttributeCiSystemTekton, tr.Metadata.Namespace, &pipeline, false, pipeline.Tasks)
+	}
+
+	task := cimodel.Task{
+		ID:       pipeline.ID,
+		Name:     pipeline.Name,
+		Result:   pipeline.Result,
+		Started:  pipeline.Started,
+		Finished: pipeline.Finished,
+	}
+	return m.runToMetrics(metadata.AttributeCiSystemTekton, tr.Metadata.Namespace, &cimodel.Pipeline{}, false, []cimodel.Task{task})
+}
+
+// workflowToMetrics counts a finished Workflow and its pods by result, and
+// reports their durations.
+func (m *metricsHandler) workflowToMetrics(w *workflow) pmetric.Metrics {
+	pipeline := workflowPipeline(w)
+
+	var pods []cimodel.Task
+	for _, task := range pipeline.Tasks {
+		if w.Status.Nodes[task.ID].Type == nodeTypePod {
+			pods = append(pods, task)
+		}
+	}
+	return m.runToMetrics(metadata.AttributeCiSystemArgo, w.Metadata.Namespace, &pipeline, true, pods)
+}
+
+// runToMetrics counts the run of a pipeline, when countRun is set, and the
+// given tasks of the run by result, and reports their durations.
+func (m *metricsHandler) runToMetrics(system metadata.AttributeCiSystem, namespace string, p *cimodel.Pipeline, countRun bool, tasks []cimodel.Task) pmetric.Metrics {
+	m.logger.Debug("Processing run",
+		zap.String("system", system.String()),
+		zap.String("namespace", namespace),
+		zap.String("pipeline", p.Name),
+		zap.Bool("run", countRun),
+		zap.Int("tasks", len(tasks)),
+	)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := pcommon.NewTimestampFromTime(time.Now())
+	if countRun {
+		if result, ok := metadata.MapAttributeCiRunResult[string(p.Result)]; ok {
+			dimensions := fmt.Sprintf("pipelinerun:%s:%s:%s", system, namespace, p.Name)
+			val, found := m.countersCache.Get(dimensions + ":" + result.String())
+			if !found {
+				// The counters of the other results start at zero, so that
+				// their increases are visible from their first run.
+				for _, r := range metadata.MapAttributeCiRunResult {
+					if r != result && m.seedCounter(dimensions+":"+r.String()) {
+						m.mb.RecordPipelinerunsCountDataPoint(now, 0, system, namespace, p.Name, r)
+					}
+				}
+			}
+			m.countersCache.Add(dimensions+":"+result.String(), val+1)
+			m.mb.RecordPipelinerunsCountDataPoint(now, val+1, system, namespace, p.Name, result)
+		}
+	}
+
+	for _, task := range tasks {
+		result, ok := metadata.MapAttributeCiRunResult[string(task.Result)]
+		if !ok {
+			continue
+		}
+		dimensions := fmt.Sprintf("taskrun:%s:%s:%s:%s", system, namespace, p.Name, task.Name)
+		val, found := m.countersCache.Get(dimensions + ":" + result.String())
+		if !found {
+			for _, r := range metadata.MapAttributeCiRunResult {
+				if r != result && m.seedCounter(dimensions+":"+r.String()) {
+					m.mb.RecordTaskrunsCountDataPoint(now, 0, system, namespace, p.Name, task.Name, r)
+				}
+			}
+		}
+		m.countersCache.Add(dimensions+":"+result.String(), val+1)
+		m.mb.RecordTaskrunsCountDataPoint(now, val+1, system, namespace, p.Name, task.Name, result)
+	}
+
+	metrics := m.mb.Emit()
+	ms := scopeMetrics(metrics)
+
+	if countRun {
+		if m.cfg.Semconv.EmitsLegacy() {
+			key := fmt.Sprintf("hist:pipelinerun:%s:%s:%s:%s", system, namespace, p.Name, p.Result)
+			cimodel.AppendHistogram(ms, "pipelineruns.duration", map[string]any{
+				"ci.system":          system.String(),
+				"k8s.namespace.name": namespace,
+				"ci.pipeline.name":   p.Name,
+				"ci.run.result":      string(p.Result),
+			}, m.observeDuration(key, p.Finished.Sub(p.Started).Seconds()))
+		}
+
+		if m.cfg.Semconv.Enabled {
+			m.durations.AppendPipeline(ms, p)
+		}
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		// Tasks that never ran have no duration.
+		if task.Result == cimodel.ResultSkip {
+			continue
+		}
+
+		if m.cfg.Semconv.EmitsLegacy() {
+			key := fmt.Sprintf("hist:taskrun:%s:%s:%s:%s:%s", system, namespace, p.Name, task.Name, task.Result)
+			cimodel.AppendHistogram(ms, "taskruns.duration", map[string]any{
+				"ci.system":          system.String(),
+				"k8s.namespace.name": namespace,
+				"ci.pipeline.name":   p.Name,
+				"ci.task.name":       task.Name,
+				"ci.run.result":      string(task.Result),
+			}, m.observeDuration(key, task.Finished.Sub(task.Started).Seconds()))
+		}
+
+		if m.cfg.Semconv.Enabled {
+			m.durations.AppendTask(ms, p, task)
+		}
+	}
+
+	return metrics
+}
+
+// scopeMetrics returns the metrics emitted by the metrics builder, which
+// emits no resource at all when no counter was recorded.
+func scopeMetrics(metrics pmetric.Metrics) pmetric.MetricSlice {
+	if metrics.ResourceMetrics().Len() == 0 {
+		scope := metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+		scope.Scope().SetName(metadata.ScopeName)
+		return scope.Metrics()
+	}
+	return metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+}
+
+// seedCounter starts the counter cached under key at zero, reporting
+// whether it was unknown. Called under m.mu.
+func (m *metricsHandler) seedCounter(key string) bool {
+	if m.countersCache.Contains(key) {
+		return false
+	}
+	m.countersCache.Add(key, 0)
+	return true
+}
+
+// observeDuration records a duration in the histogram cached under key.
+// Called under m.mu.
+func (m *metricsHandler) observeDuration(key string, duration float64) *cimodel.Histogram {
+	// Stale histograms start over, the LRU evicts those never observed again
+	h, ok := m.histogramCache.Get(key)
+	if !ok || time.Since(h.LastSeen) >= histogramTTL {
+		h = cimodel.NewHistogram()
+	}
+	h.Observe(duration)
+	m.histogramCache.Add(key, h)
+	return h
+}
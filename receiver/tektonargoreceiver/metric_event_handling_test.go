@@ -0,0 +1,124 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package tektonargoreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/tektonargoreceiver/internal/metadata"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestMetricsHandler(t *testing.T, cfg *Config) *metricsHandler {
+	t.Helper()
+	cfg.MetricsBuilderConfig = metadata.DefaultMetricsBuilderConfig()
+	mh, err := newMetricsHandler(receivertest.NewNopSettings(receivertest.NopType), cfg, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	return mh
+}
+
+// metricNames returns the names of the metrics, with their data point counts.
+func metricNames(metrics pmetric.Metrics) map[string]int {
+	names := map[string]int{}
+	for i := range metrics.ResourceMetrics().Len() {
+		sms := metrics.ResourceMetrics().At(i).ScopeMetrics()
+		for j := range sms.Len() {
+			ms := sms.At(j).Metrics()
+			for k := range ms.Len() {
+				m := ms.At(k)
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					names[m.Name()] += m.Sum().DataPoints().Len()
+				case pmetric.MetricTypeHistogram:
+					names[m.Name()] += m.Histogram().DataPoints().Len()
+				}
+			}
+		}
+	}
+	return names
+}
+
+// counterValues returns the values of a counter, by result.
+func counterValues(metrics pmetric.Metrics, name string) map[string]int64 {
+	values := map[string]int64{}
+	ms := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := range ms.Len() {
+		if ms.At(i).Name() != name {
+			continue
+		}
+		dps := ms.At(i).Sum().DataPoints()
+		for j := range dps.Len() {
+			result, _ := dps.At(j).Attributes().Get("ci.run.result")
+			values[result.Str()] += dps.At(j).IntValue()
+		}
+	}
+	return values
+}
+
+func TestPipelineRunToMetrics(t *testing.T) {
+	pr := loadTektonEvent(t, "pipelinerun_successful.json", "").PipelineRun
+	mh := newTestMetricsHandler(t, &Config{})
+
+	// The first run seeds the counters of the other results.
+	metrics := mh.pipelineRunToMetrics(pr)
+	results := len(metadata.MapAttributeCiRunResult)
+	require.Equal(t, map[string]int{"pipelineruns.count": results, "pipelineruns.duration": 1}, metricNames(metrics))
+	require.Equal(t, int64(1), counterValues(metrics, "pipelineruns.count")["success"])
+
+	metrics = mh.pipelineRunToMetrics(pr)
+	require.Equal(t, map[string]int{"pipelineruns.count": 1, "pipelineruns.duration": 1}, metricNames(metrics))
+	require.Equal(t, map[string]int64{"success": 2}, counterValues(metrics, "pipelineruns.count"))
+}
+
+func TestTaskRunToMetrics(t *testing.T) {
+	tr := loadTektonEvent(t, "taskrun_failed.json", eventTaskRunFailed).TaskRun
+	mh := newTestMetricsHandler(t, &Config{})
+
+	metrics := mh.taskRunToMetrics(tr)
+	results := len(metadata.MapAttributeCiRunResult)
+	require.Equal(t, map[string]int{"taskruns.count": results, "taskruns.duration": 1}, metricNames(metrics))
+	require.Equal(t, int64(1), counterValues(metrics, "taskruns.count")["failure"])
+
+	dp := metrics.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	require.Equal(t, "build-and-test", dp.Attributes().AsRaw()["ci.pipeline.name"])
+	require.Equal(t, "test", dp.Attributes().AsRaw()["ci.task.name"])
+}
+
+func TestWorkflowToMetrics(t *testing.T) {
+	wf := loadWorkflow(t, "workflow_failed.json")
+	mh := newTestMetricsHandler(t, &Config{Semconv: semconv.Config{Enabled: true}})
+
+	metrics := mh.workflowToMetrics(wf)
+
+	// Pods are counted, retry and skipped nodes are not. Without legacy
+	// metrics, durations are reported by the semantic conventions only.
+	results := len(metadata.MapAttributeCiRunResult)
+	require.Equal(t, map[string]int{
+		"pipelineruns.count":                      results,
+		"taskruns.count":                          2 * results,
+		semconv.MetricCICDPipelineRunDuration:     1,
+		semconv.MetricCICDPipelineTaskRunDuration: 2,
+	}, metricNames(metrics))
+	require.Equal(t, int64(1), counterValues(metrics, "pipelineruns.count")["failure"])
+	taskRuns := counterValues(metrics, "taskruns.count")
+	require.Equal(t, int64(1), taskRuns["success"])
+	require.Equal(t, int64(1), taskRuns["failure"])
+}
+
+func TestObserveDurationStartsOver(t *testing.T) {
+	mh := newTestMetricsHandler(t, &Config{})
+
+	mh.observeDuration("key", 1)
+	require.Equal(t, uint64(2), mh.observeDuration("key", 1).Count)
+
+	// Histograms not observed within the TTL start over
+	h, _ := mh.histogramCache.Get("key")
+	h.LastSeen = time.Now().Add(-histogramTTL)
+	require.Equal(t, uint64(1), mh.observeDuration("key", 1).Count)
+}
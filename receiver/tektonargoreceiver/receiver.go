@@ -0,0 +1,276 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package tektonargoreceiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/sharedcomponent"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/tektonargoreceiver/internal/metadata"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/receiver"
+	"go.opentelemetry.io/collector/receiver/receiverhelper"
+	"go.uber.org/zap"
+)
+
+var errMissingEndpoint = errors.New("missing a receiver endpoint")
+
+type tektonArgoReceiver struct {
+	tracesConsumer  consumer.Traces
+	metricsConsumer consumer.Metrics
+	metricsHandler  *metricsHandler
+	config          *Config
+	server          *http.Server
+	shutdownWG      sync.WaitGroup
+	createSettings  receiver.Settings
+	logger          *zap.Logger
+	obsrecv         *receiverhelper.ObsReport
+}
+
+func newReceiver(
+	params receiver.Settings,
+	config *Config,
+) (*tektonArgoReceiver, error) {
+	if config.NetAddr.Endpoint == "" {
+		return nil, errMissingEndpoint
+	}
+
+	transport := "http"
+	if config.TLS.HasValue() {
+		transport = "https"
+	}
+
+	obsrecv, err := receiverhelper.NewObsReport(receiverhelper.ObsReportSettings{
+		ReceiverID:             params.ID,
+		Transport:              transport,
+		ReceiverCreateSettings: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	metricsHandler, err := newMetricsHandler(params, config, params.Logger.Named("metricsHandler"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &tektonArgoReceiver{
+		config:         config,
+		createSettings: params,
+		logger:         params.Logger,
+		obsrecv:        obsrecv,
+		metricsHandler: metricsHandler,
+	}, nil
+}
+
+// newTracesReceiver creates a traces receiver based on provided config.
+func newTracesReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Traces,
+) (receiver.Traces, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*tektonArgoReceiver).tracesConsumer = consumer
+
+	return r, nil
+}
+
+// newMetricsReceiver creates a metrics receiver based on provided config.
+func newMetricsReceiver(
+	_ context.Context,
+	set receiver.Settings,
+	cfg component.Config,
+	consumer consumer.Metrics,
+) (receiver.Metrics, error) {
+	r, err := getOrAddReceiver(set, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Unwrap().(*tektonArgoReceiver).metricsConsumer = consumer
+
+	return r, nil
+}
+
+func getOrAddReceiver(set receiver.Settings, cfg component.Config) (*sharedcomponent.SharedComponent, error) {
+	var err error
+	r := receivers.GetOrAdd(cfg, func() component.Component {
+		var rcv component.Component
+		rcv, err = newReceiver(set, cfg.(*Config))
+		return rcv
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (tar *tektonArgoReceiver) Start(_ context.Context, _ component.Host) error {
+	endpoint := fmt.Sprintf("%s%s", tar.config.NetAddr.Endpoint, tar.config.Path)
+	tar.logger.Info("Starting Tekton and Argo Workflows server", zap.String("endpoint", endpoint))
+	tar.server = &http.Server{
+		Addr:              tar.config.NetAddr.Endpoint,
+		Handler:           tar,
+		ReadHeaderTimeout: 20 * time.Second,
+	}
+
+	tar.shutdownWG.Add(1)
+	go func() {
+		defer tar.shutdownWG.Done()
+
+		if errHTTP := tar.server.ListenAndServe(); !errors.Is(errHTTP, http.ErrServerClosed) && errHTTP != nil {
+			tar.createSettings.Logger.Error("Server closed with error", zap.Error(errHTTP))
+		}
+	}()
+
+	return nil
+}
+
+func (tar *tektonArgoReceiver) Shutdown(_ context.Context) error {
+	var err error
+	if tar.server != nil {
+		err = tar.server.Close()
+	}
+	tar.shutdownWG.Wait()
+	return err
+}
+
+func (tar *tektonArgoReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Validate request path
+	if r.URL.Path != tar.config.Path {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if tar.config.Secret != "" {
+		if err := validateToken(r, tar.config.Secret); err != nil {
+			tar.logger.Debug("Token validation failed", zap.Error(err))
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		tar.logger.Debug("Failed to read payload", zap.Error(err))
+		http.Error(w, "Failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	e, err := parseCloudEvent(r, payload)
+	if err != nil {
+		tar.logger.Debug("CloudEvent parsing failed", zap.Error(err))
+		http.Error(w, "Failed to parse CloudEvent", http.StatusBadRequest)
+		return
+	}
+
+	if e.isTekton() {
+		tar.handleTektonEvent(ctx, w, e)
+		return
+	}
+	tar.handleArgoEvent(ctx, w, e)
+}
+
+// handleTektonEvent handles the events of finished PipelineRuns and TaskRuns.
+func (tar *tektonArgoReceiver) handleTektonEvent(ctx context.Context, w http.ResponseWriter, e *cloudEvent) {
+	switch e.Type {
+	case eventPipelineRunSuccessful, eventPipelineRunFailed, eventTaskRunSuccessful, eventTaskRunFailed:
+	default:
+		tar.logger.Debug("Skipping unsupported event type", zap.String("type", e.Type))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	data, err := e.tektonRun()
+	if err != nil {
+		tar.logger.Debug("Tekton event parsing failed", zap.Error(err))
+		http.Error(w, "Failed to parse Tekton event", http.StatusBadRequest)
+		return
+	}
+
+	if pr := data.PipelineRun; pr != nil {
+		if tar.tracesConsumer != nil {
+			tar.consumeTraces(ctx, pipelineRunToTraces(pr, tar.config, tar.logger.Named("pipelineRunToTraces")))
+		}
+		if tar.metricsConsumer != nil {
+			tar.consumeMetrics(ctx, tar.metricsHandler.pipelineRunToMetrics(pr))
+		}
+	} else {
+		if tar.tracesConsumer != nil {
+			tar.consumeTraces(ctx, taskRunToTraces(data.TaskRun, tar.config, tar.logger.Named("taskRunToTraces")))
+		}
+		if tar.metricsConsumer != nil {
+			tar.consumeMetrics(ctx, tar.metricsHandler.taskRunToMetrics(data.TaskRun))
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleArgoEvent handles the events of finished Argo workflows. Events
+// about other resources and unfinished workflows are ignored.
+func (tar *tektonArgoReceiver) handleArgoEvent(ctx context.Context, w http.ResponseWriter, e *cloudEvent) {
+	wf, err := e.argoWorkflow()
+	switch {
+	case errors.Is(err, errUnsupportedData):
+		tar.logger.Debug("Skipping unsupported event", zap.String("type", e.Type), zap.String("source", e.Source))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case err != nil:
+		tar.logger.Debug("Argo event parsing failed", zap.Error(err))
+		http.Error(w, "Failed to parse Argo event", http.StatusBadRequest)
+		return
+	case !wf.finished():
+		tar.logger.Debug("Skipping unfinished workflow", zap.String("name", wf.Metadata.Name), zap.String("phase", wf.Status.Phase))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if tar.tracesConsumer != nil {
+		tar.consumeTraces(ctx, workflowToTraces(wf, tar.config, tar.logger.Named("workflowToTraces")))
+	}
+	if tar.metricsConsumer != nil {
+		tar.consumeMetrics(ctx, tar.metricsHandler.workflowToMetrics(wf))
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (tar *tektonArgoReceiver) consumeTraces(ctx context.Context, td ptrace.Traces) {
+	tracesCtx := tar.obsrecv.StartTracesOp(ctx)
+	err := tar.tracesConsumer.ConsumeTraces(tracesCtx, td)
+	tar.obsrecv.EndTracesOp(tracesCtx, metadata.Type.String(), td.SpanCount(), err)
+	if err != nil {
+		tar.logger.Error("Failed to consume traces", zap.Error(err))
+	}
+}
+
+func (tar *tektonArgoReceiver) consumeMetrics(ctx context.Context, md pmetric.Metrics) {
+	if md.DataPointCount() == 0 {
+		return
+	}
+
+	metricsCtx := tar.obsrecv.StartMetricsOp(ctx)
+	err := tar.metricsConsumer.ConsumeMetrics(metricsCtx, md)
+	tar.obsrecv.EndMetricsOp(metricsCtx, metadata.Type.String(), md.DataPointCount(), err)
+	if err != nil {
+		tar.logger.Error("Failed to consume metrics", zap.Error(err))
+	}
+}
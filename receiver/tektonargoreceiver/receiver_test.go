@@ -0,0 +1,153 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package tektonargoreceiver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/receiver/receivertest"
+)
+
+func TestNewReceiver(t *testing.T) {
+	defaultConfig := createDefaultConfig().(*Config)
+
+	tests := []struct {
+		desc   string
+		config Config
+		err    error
+	}{
+		{
+			desc:   "Default config succeeds",
+			config: *defaultConfig,
+		},
+		{
+			desc:   "Missing endpoint fails",
+			config: Config{},
+			err:    errMissingEndpoint,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), &test.config)
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, rec.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	pipelineRunPayload := loadPayload(t, "pipelinerun_successful.json")
+	taskRunPayload := loadPayload(t, "taskrun_failed.json")
+	workflowPayload := loadPayload(t, "workflow_failed.json")
+	runningWorkflowPayload := []byte(`{"specversion": "1.0", "type": "resource", "data": {"body": {"kind": "Workflow", "status": {"phase": "Running"}}}}`)
+
+	tests := []struct {
+		desc          string
+		path          string
+		eventType     string
+		token         string
+		payload       []byte
+		expectStatus  int
+		expectSpans   int
+		expectMetrics bool
+	}{
+		{
+			desc:         "Unknown path",
+			path:         "/other",
+			token:        "mysecret",
+			payload:      pipelineRunPayload,
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			desc:         "Invalid token",
+			token:        "wrong",
+			payload:      pipelineRunPayload,
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:         "Invalid payload",
+			token:        "mysecret",
+			payload:      []byte(`{`),
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			desc:         "Started TaskRun",
+			eventType:    "dev.tekton.event.taskrun.started.v1",
+			token:        "mysecret",
+			payload:      taskRunPayload,
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			desc:          "Finished PipelineRun",
+			token:         "mysecret",
+			payload:       pipelineRunPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   1,
+			expectMetrics: true,
+		},
+		{
+			desc:          "Failed TaskRun",
+			eventType:     eventTaskRunFailed,
+			token:         "mysecret",
+			payload:       taskRunPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   4,
+			expectMetrics: true,
+		},
+		{
+			desc:         "Running workflow",
+			token:        "mysecret",
+			payload:      runningWorkflowPayload,
+			expectStatus: http.StatusNoContent,
+		},
+		{
+			desc:          "Failed workflow",
+			token:         "mysecret",
+			payload:       workflowPayload,
+			expectStatus:  http.StatusAccepted,
+			expectSpans:   5,
+			expectMetrics: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			cfg := createDefaultConfig().(*Config)
+			cfg.Secret = "mysecret"
+
+			rec, err := newReceiver(receivertest.NewNopSettings(receivertest.NopType), cfg)
+			require.NoError(t, err)
+			t.Cleanup(func() { require.NoError(t, rec.Shutdown(context.Background())) })
+
+			tracesSink := new(consumertest.TracesSink)
+			metricsSink := new(consumertest.MetricsSink)
+			rec.tracesConsumer = tracesSink
+			rec.metricsConsumer = metricsSink
+
+			path := test.path
+			if path == "" {
+				path = cfg.Path
+			}
+			req := newEventRequest(path, test.eventType, test.payload)
+			req.Header.Set("Authorization", "Bearer "+test.token)
+			w := httptest.NewRecorder()
+
+			rec.ServeHTTP(w, req)
+
+			require.Equal(t, test.expectStatus, w.Code)
+			require.Equal(t, test.expectSpans, tracesSink.SpanCount())
+			require.Equal(t, test.expectMetrics, len(metricsSink.AllMetrics()) > 0)
+		})
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package tektonargoreceiver
+
+import (
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+)
+
+// Labels Tekton sets on the runs of pipelines and tasks.
+const (
+	labelPipeline     = "tekton.dev/pipeline"
+	labelPipelineRun  = "tekton.dev/pipelineRun"
+	labelPipelineTask = "tekton.dev/pipelineTask"
+	labelTask         = "tekton.dev/task"
+)
+
+const kindPipelineRun = "PipelineRun"
+
+type pipelineRun struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		PipelineRef *struct {
+			Name string `json:"name"`
+		} `json:"pipelineRef"`
+	} `json:"spec"`
+	Status struct {
+		Conditions      []condition      `json:"conditions"`
+		StartTime       string           `json:"startTime"`
+		CompletionTime  string           `json:"completionTime"`
+		ChildReferences []childReference `json:"childReferences"`
+	} `json:"status"`
+}
+
+type childReference struct {
+	Kind             string `json:"kind"`
+	Name             string `json:"name"`
+	PipelineTaskName string `json:"pipelineTaskName"`
+}
+
+type taskRun struct {
+	Metadata objectMeta `json:"metadata"`
+	Spec     struct {
+		TaskRef *struct {
+			Name string `json:"name"`
+		} `json:"taskRef"`
+	} `json:"spec"`
+	Status struct {
+		Conditions     []condition `json:"conditions"`
+		StartTime      string      `json:"startTime"`
+		CompletionTime string      `json:"completionTime"`
+		PodName        string      `json:"podName"`
+		Steps          []stepState `json:"steps"`
+	} `json:"status"`
+}
+
+// stepState is the state of the container of a step.
+type stepState struct {
+	Name       string `json:"name"`
+	Container  string `json:"container"`
+	Terminated *struct {
+		ExitCode   int    `json:"exitCode"`
+		Reason     string `json:"reason"`
+		StartedAt  string `json:"startedAt"`
+		FinishedAt string `json:"finishedAt"`
+	} `json:"terminated"`
+}
+
+// pipelineName returns the name of the pipeline of a run, or the name of
+// the run when it has an embedded pipeline spec.
+func (pr *pipelineRun) pipelineName() string {
+	if name := pr.Metadata.Labels[labelPipeline]; name != "" {
+		return name
+	}
+	if pr.Spec.PipelineRef != nil && pr.Spec.PipelineRef.Name != "" {
+		return pr.Spec.PipelineRef.Name
+	}
+	return pr.Metadata.Name
+}
+
+// succeeded returns the Succeeded condition of a run.
+func succeeded(conditions []condition) condition {
+	for _, c := range conditions {
+		if c.Type == "Succeeded" {
+			return c
+		}
+	}
+	return condition{Status: "Unknown"}
+}
+
+// finished reports whether the run of a condition finished.
+func (c condition) finished() bool {
+	return c.Status == "True" || c.Status == "False"
+}
+
+// result maps the Succeeded condition of a finished run.
+func (c condition) result() cimodel.Result {
+	switch {
+	case c.Status == "True":
+		return cimodel.ResultSuccess
+	case c.Status != "False":
+		return cimodel.ResultUnknown
+	case strings.Contains(c.Reason, "Timeout") || strings.Contains(c.Reason, "TimedOut"):
+		return cimodel.ResultTimeout
+	case strings.Contains(c.Reason, "Cancelled"):
+		return cimodel.ResultCancellation
+	case c.Reason == "Failed":
+		return cimodel.ResultFailure
+	default:
+		// Validation, resolution and scheduling failures
+		return cimodel.ResultError
+	}
+}
+
+// pipelineName returns the name of the pipeline of the PipelineRun owning a
+// TaskRun, if any.
+func (tr *taskRun) pipelineName() string {
+	return tr.Metadata.Labels[labelPipeline]
+}
+
+// taskName returns the name of the pipeline task of a run, or the name of
+// its task for runs outside of a pipeline.
+func (tr *taskRun) taskName() string {
+	if name := tr.Metadata.Labels[labelPipelineTask]; name != "" {
+		return name
+	}
+	if name := tr.Metadata.Labels[labelTask]; name != "" {
+		return name
+	}
+	if tr.Spec.TaskRef != nil && tr.Spec.TaskRef.Name != "" {
+		return tr.Spec.TaskRef.Name
+	}
+	return tr.Metadata.Name
+}
+
+// pipelineRunPipeline maps a PipelineRun to the CI model. Its TaskRuns are
+// reported by their own events, and keep the PipelineRun span as parent.
+func pipelineRunPipeline(pr *pipelineRun) cimodel.Pipeline {
+	c := succeeded(pr.Status.Conditions)
+	started, finished := runTimes(pr.Metadata.CreationTimestamp, pr.Status.StartTime, pr.Status.CompletionTime)
+
+	return cimodel.Pipeline{
+		ID:       pr.Metadata.Name,
+		Name:     pr.pipelineName(),
+		Result:   c.result(),
+		Status:   c.Reason,
+		Started:  started,
+		Finished: finished,
+		TraceID:  generateTraceID(pr.Metadata.UID),
+		SpanID:   generateRunSpanID(pr.Metadata.UID),
+		Attributes: map[string]any{
+			"ci.tekton.pipeline.name":      pr.pipelineName(),
+			"ci.tekton.pipelinerun.name":   pr.Metadata.Name,
+			"ci.tekton.pipelinerun.uid":    pr.Metadata.UID,
+			"ci.tekton.pipelinerun.reason": c.Reason,
+			"ci.tekton.pipelinerun.tasks":  int64(len(pr.Status.ChildReferences)),
+		},
+	}
+}
+
+// taskRunPipeline maps a TaskRun to the CI model. TaskRuns of a PipelineRun
+// are a task of the trace of the PipelineRun, whose span is omitted.
+// TaskRuns run on their own are the run of the trace, with a task per step.
+func taskRunPipeline(tr *taskRun) cimodel.Pipeline {
+	ownerUID := tr.Metadata.ownerUID(kindPipelineRun)
+	if ownerUID == "" {
+		return standaloneTaskRunPipeline(tr)
+	}
+
+	c := succeeded(tr.Status.Conditions)
+	started, finished := runTimes(tr.Metadata.CreationTimestamp, tr.Status.StartTime, tr.Status.CompletionTime)
+
+	task := cimodel.Task{
+		ID:         tr.Metadata.Name,
+		Name:       tr.taskName(),
+		Result:     c.result(),
+		Status:     c.Reason,
+		Started:    started,
+		Finished:   finished,
+		SpanID:     generateTaskSpanID(ownerUID, tr.Metadata.UID),
+		Attributes: taskRunAttributes(tr, c),
+	}
+	for _, step := range tr.Status.Steps {
+		task.Steps = append(task.Steps, stepStep(tr.Metadata.UID, step, finished))
+	}
+
+	pipelineName := tr.pipelineName()
+	if pipelineName == "" {
+		pipelineName = tr.Metadata.Labels[labelPipelineRun]
+	}
+
+	return cimodel.Pipeline{
+		ID:       tr.Metadata.Labels[labelPipelineRun],
+		Name:     pipelineName,
+		OmitSpan: true,
+		TraceID:  generateTraceID(ownerUID),
+		SpanID:   generateRunSpanID(ownerUID),
+		Tasks:    []cimodel.Task{task},
+	}
+}
+
+func standaloneTaskRunPipeline(tr *taskRun) cimodel.Pipeline {
+	c := succeeded(tr.Status.Conditions)
+	started, finished := runTimes(tr.Metadata.CreationTimestamp, tr.Status.StartTime, tr.Status.CompletionTime)
+
+	pipeline := cimodel.Pipeline{
+		ID:         tr.Metadata.Name,
+		Name:       tr.taskName(),
+		Result:     c.result(),
+		Status:     c.Reason,
+		Started:    started,
+		Finished:   finished,
+		TraceID:    generateTraceID(tr.Metadata.UID),
+		SpanID:     generateRunSpanID(tr.Metadata.UID),
+		Attributes: taskRunAttributes(tr, c),
+	}
+	for _, state := range tr.Status.Steps {
+		step := stepStep(tr.Metadata.UID, state, finished)
+		pipeline.Tasks = append(pipeline.Tasks, cimodel.Task{
+			ID:         state.Container,
+			Name:       step.Name,
+			Result:     step.Result,
+			Status:     step.Status,
+			Started:    step.Started,
+			Finished:   step.Finished,
+			SpanID:     step.SpanID,
+			Attributes: step.Attributes,
+		})
+	}
+	return pipeline
+}
+
+func taskRunAttributes(tr *taskRun, c condition) map[string]any {
+	attrs := map[string]any{
+		"ci.tekton.taskrun.name":   tr.Metadata.Name,
+		"ci.tekton.taskrun.uid":    tr.Metadata.UID,
+		"ci.tekton.taskrun.reason": c.Reason,
+	}
+	if name := tr.Metadata.Labels[labelPipelineTask]; name != "" {
+		attrs["ci.tekton.pipeline_task.name"] = name
+	}
+	if name := tr.Metadata.Labels[labelTask]; name != "" {
+		attrs["ci.tekton.task.name"] = name
+	}
+	if tr.Status.PodName != "" {
+		attrs["ci.tekton.taskrun.pod"] = tr.Status.PodName
+	}
+	return attrs
+}
+
+// stepStep maps the state of a step. Steps that never ran are skipped, and
+// start and finish when their task finished.
+func stepStep(taskRunUID string, state stepState, taskFinished time.Time) cimodel.Step {
+	step := cimodel.Step{
+		Name:     state.Name,
+		Result:   cimodel.ResultSkip,
+		Started:  taskFinished,
+		Finished: taskFinished,
+		SpanID:   generateStepSpanID(taskRunUID, state.Name),
+		Attributes: map[string]any{
+			"ci.tekton.step.name":      state.Name,
+			"ci.tekton.step.container": state.Container,
+		},
+	}
+
+	if t := state.Terminated; t != nil {
+		step.Status = t.Reason
+		step.Started, step.Finished = runTimes("", t.StartedAt, t.FinishedAt)
+		step.Attributes["ci.tekton.step.exit_code"] = int64(t.ExitCode)
+		step.Attributes["ci.tekton.step.reason"] = t.Reason
+		switch {
+		case t.ExitCode == 0 && t.Reason == "Completed":
+			step.Result = cimodel.ResultSuccess
+		case t.Reason == "TaskRunCancelled":
+			step.Result = cimodel.ResultCancellation
+		case t.Reason == "TaskRunTimeout":
+			step.Result = cimodel.ResultTimeout
+		default:
+			step.Result = cimodel.ResultFailure
+		}
+	}
+
+	return step
+}
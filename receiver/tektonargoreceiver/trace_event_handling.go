@@ -0,0 +1,163 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package tektonargoreceiver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/cimodel"
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/grafana/grafana-ci-otel-collector/receiver/tektonargoreceiver/internal/metadata"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeName    = "tektonargoreceiver"
+	scopeVersion = "0.1.0"
+)
+
+// legacyRunAttributes and legacyTaskAttributes are the attributes replaced
+// by the semantic conventions. Attributes without an equivalent keep their
+// names.
+var legacyRunAttributes = []string{
+	"ci.tekton.pipeline.name",
+	"ci.tekton.pipelinerun.name",
+	"ci.tekton.taskrun.name",
+	"ci.argo.workflow.name",
+	"ci.argo.workflow_template.name",
+}
+
+var legacyTaskAttributes = []string{
+	"ci.tekton.taskrun.name",
+	"ci.tekton.pipeline_task.name",
+	"ci.argo.node.id",
+	"ci.argo.node.name",
+	"ci.argo.node.host",
+}
+
+func pipelineRunToTraces(pr *pipelineRun, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing PipelineRun",
+		zap.String("namespace", pr.Metadata.Namespace),
+		zap.String("name", pr.Metadata.Name),
+		zap.String("pipeline", pr.pipelineName()),
+	)
+
+	pipeline := pipelineRunPipeline(pr)
+	finishPipeline(&pipeline, config, metadata.AttributeCiSystemTekton, pr.Metadata.Namespace)
+	return cimodel.ToTraces(&pipeline, traceOptions(config))
+}
+
+func taskRunToTraces(tr *taskRun, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing TaskRun",
+		zap.String("namespace", tr.Metadata.Namespace),
+		zap.String("name", tr.Metadata.Name),
+		zap.String("pipelinerun", tr.Metadata.Labels[labelPipelineRun]),
+		zap.Int("steps", len(tr.Status.Steps)),
+	)
+
+	pipeline := taskRunPipeline(tr)
+	finishPipeline(&pipeline, config, metadata.AttributeCiSystemTekton, tr.Metadata.Namespace)
+	return cimodel.ToTraces(&pipeline, traceOptions(config))
+}
+
+func workflowToTraces(w *workflow, config *Config, logger *zap.Logger) ptrace.Traces {
+	logger.Debug("Processing Workflow",
+		zap.String("namespace", w.Metadata.Namespace),
+		zap.String("name", w.Metadata.Name),
+		zap.String("phase", w.Status.Phase),
+		zap.Int("nodes", len(w.Status.Nodes)),
+	)
+
+	pipeline := workflowPipeline(w)
+	finishPipeline(&pipeline, config, metadata.AttributeCiSystemArgo, w.Metadata.Namespace)
+	return cimodel.ToTraces(&pipeline, traceOptions(config))
+}
+
+// finishPipeline sets the resource attributes of a run, and removes its
+// legacy attributes.
+func finishPipeline(p *cimodel.Pipeline, config *Config, system metadata.AttributeCiSystem, namespace string) {
+	p.ResourceAttributes = map[string]any{
+		"service.name":       generateServiceName(config, p.Name),
+		"ci.system":          system.String(),
+		"k8s.namespace.name": namespace,
+	}
+	removeLegacyAttributes(p, config.Semconv)
+}
+
+// removeLegacyAttributes removes the attributes replaced by the semantic
+// conventions, unless the legacy ones are emitted.
+func removeLegacyAttributes(p *cimodel.Pipeline, cfg semconv.Config) {
+	if cfg.EmitsLegacy() {
+		return
+	}
+
+	for _, key := range legacyRunAttributes {
+		delete(p.Attributes, key)
+	}
+	for i := range p.Tasks {
+		for _, key := range legacyTaskAttributes {
+			delete(p.Tasks[i].Attributes, key)
+		}
+	}
+}
+
+func traceOptions(config *Config) cimodel.Options {
+	return cimodel.Options{
+		ScopeName:    scopeName,
+		ScopeVersion: scopeVersion,
+		Semconv:      config.Semconv,
+	}
+}
+
+// runTimes returns the start and end of a run. Runs that never started
+// start when they were created, and runs that never finished end when they
+// started.
+func runTimes(created, started, finished string) (time.Time, time.Time) {
+	start := parseTime(started)
+	if start.IsZero() {
+		start = parseTime(created)
+	}
+	end := parseTime(finished)
+	if end.IsZero() || end.Before(start) {
+		end = start
+	}
+	return start, end
+}
+
+func generateServiceName(config *Config, name string) string {
+	if config.CustomServiceName != "" {
+		return config.CustomServiceName
+	}
+	formattedName := strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(name, "/", "-"), "_", "-"))
+	return fmt.Sprintf("%s%s%s", config.ServiceNamePrefix, formattedName, config.ServiceNameSuffix)
+}
+
+// generateTraceID returns the trace ID of the run of the given UID: the
+// PipelineRun, Workflow or standalone TaskRun at the root of the trace.
+func generateTraceID(runUID string) pcommon.TraceID {
+	hash := sha256.Sum256([]byte(runUID + "t"))
+	return pcommon.TraceID(hash[:16])
+}
+
+func generateRunSpanID(runUID string) pcommon.SpanID {
+	return generateSpanID(runUID + "s")
+}
+
+func generateTaskSpanID(runUID, taskID string) pcommon.SpanID {
+	return generateSpanID(runUID + "j" + taskID)
+}
+
+func generateStepSpanID(taskRunUID, stepName string) pcommon.SpanID {
+	return generateSpanID(taskRunUID + "p" + stepName)
+}
+
+func generateSpanID(input string) pcommon.SpanID {
+	hash := sha256.Sum256([]byte(input))
+	return pcommon.SpanID(hash[:8])
+}
@@ -0,0 +1,203 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package tektonargoreceiver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-ci-otel-collector/internal/semconv"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap/zaptest"
+)
+
+func spansByName(traces ptrace.Traces) map[string]ptrace.Span {
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	byName := make(map[string]ptrace.Span, spans.Len())
+	for i := 0; i < spans.Len(); i++ {
+		byName[spans.At(i).Name()] = spans.At(i)
+	}
+	return byName
+}
+
+func at(hour, minute, second int) time.Time {
+	return time.Date(2024, 3, 5, hour, minute, second, 0, time.UTC)
+}
+
+func TestPipelineRunToTraces(t *testing.T) {
+	data := loadTektonEvent(t, "pipelinerun_successful.json", "")
+
+	traces := pipelineRunToTraces(data.PipelineRun, &Config{}, zaptest.NewLogger(t))
+	require.Equal(t, 1, traces.SpanCount())
+
+	resource := traces.ResourceSpans().At(0).Resource().Attributes().AsRaw()
+	require.Equal(t, "build-and-test", resource["service.name"])
+	require.Equal(t, "tekton", resource["ci.system"])
+	require.Equal(t, "ci", resource["k8s.namespace.name"])
+
+	run := spansByName(traces)["build-and-test"]
+	require.Equal(t, generateTraceID("0b6f0a52-3c1e-4d2b-8f4e-6a7b8c9d0e1f"), run.TraceID())
+	require.True(t, run.ParentSpanID().IsEmpty())
+	require.Equal(t, ptrace.StatusCodeOk, run.Status().Code())
+	require.Equal(t, at(10, 0, 1), run.StartTimestamp().AsTime())
+	require.Equal(t, at(10, 4, 12), run.EndTimestamp().AsTime())
+	require.Equal(t, int64(2), run.Attributes().AsRaw()["ci.tekton.pipelinerun.tasks"])
+}
+
+func TestTaskRunToTraces(t *testing.T) {
+	data := loadTektonEvent(t, "taskrun_failed.json", eventTaskRunFailed)
+	pipelineRun := loadTektonEvent(t, "pipelinerun_successful.json", "").PipelineRun
+	runTraces := pipelineRunToTraces(pipelineRun, &Config{}, zaptest.NewLogger(t))
+	run := runTraces.ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0)
+
+	traces := taskRunToTraces(data.TaskRun, &Config{}, zaptest.NewLogger(t))
+
+	// The TaskRun and its 3 steps, without the span of the PipelineRun.
+	require.Equal(t, 4, traces.SpanCount())
+	spans := spansByName(traces)
+
+	// The task and its step share their name
+	task, ok := spanByAttribute(traces, "ci.tekton.taskrun.uid")
+	require.True(t, ok)
+	require.Equal(t, run.TraceID(), task.TraceID())
+	require.Equal(t, run.SpanID(), task.ParentSpanID())
+	require.Equal(t, ptrace.StatusCodeError, task.Status().Code())
+	require.Equal(t, "Failed", task.Status().Message())
+	require.Equal(t, "go-test", task.Attributes().AsRaw()["ci.tekton.task.name"])
+
+	steps := map[string]struct {
+		start, end time.Time
+		code       ptrace.StatusCode
+	}{
+		"vet":      {at(10, 2, 10), at(10, 2, 40), ptrace.StatusCodeOk},
+		"test":     {at(10, 2, 40), at(10, 4, 9), ptrace.StatusCodeError},
+		"coverage": {at(10, 4, 9), at(10, 4, 9), ptrace.StatusCodeError},
+	}
+	for name, expected := range steps {
+		step, ok := spans[name]
+		require.True(t, ok, name)
+		require.Equal(t, task.SpanID(), step.ParentSpanID(), name)
+		require.Equal(t, expected.start, step.StartTimestamp().AsTime(), name)
+		require.Equal(t, expected.end, step.EndTimestamp().AsTime(), name)
+		require.Equal(t, expected.code, step.Status().Code(), name)
+	}
+}
+
+// spanByAttribute returns the first span with the given attribute.
+func spanByAttribute(traces ptrace.Traces, key string) (ptrace.Span, bool) {
+	spans := traces.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	for i := 0; i < spans.Len(); i++ {
+		if _, ok := spans.At(i).Attributes().Get(key); ok {
+			return spans.At(i), true
+		}
+	}
+	return ptrace.Span{}, false
+}
+
+func TestStandaloneTaskRunToTraces(t *testing.T) {
+	tr := loadTektonEvent(t, "taskrun_failed.json", eventTaskRunFailed).TaskRun
+	tr.Metadata.OwnerReferences = nil
+	delete(tr.Metadata.Labels, labelPipeline)
+	delete(tr.Metadata.Labels, labelPipelineRun)
+	delete(tr.Metadata.Labels, labelPipelineTask)
+
+	traces := taskRunToTraces(tr, &Config{}, zaptest.NewLogger(t))
+
+	// The TaskRun is the root of its trace, with a span per step.
+	require.Equal(t, 4, traces.SpanCount())
+	spans := spansByName(traces)
+	run := spans["go-test"]
+	require.Equal(t, generateTraceID("5d4c3b2a-1f0e-4d9c-8b7a-6f5e4d3c2b1a"), run.TraceID())
+	require.True(t, run.ParentSpanID().IsEmpty())
+	for _, name := range []string{"vet", "test", "coverage"} {
+		require.Equal(t, run.SpanID(), spans[name].ParentSpanID(), name)
+	}
+}
+
+func TestWorkflowToTraces(t *testing.T) {
+	wf := loadWorkflow(t, "workflow_failed.json")
+
+	traces := workflowToTraces(wf, &Config{}, zaptest.NewLogger(t))
+
+	// The workflow and its nodes, without the DAG node of the workflow.
+	require.Equal(t, 5, traces.SpanCount())
+	resource := traces.ResourceSpans().At(0).Resource().Attributes().AsRaw()
+	require.Equal(t, "release", resource["service.name"])
+	require.Equal(t, "argo", resource["ci.system"])
+	require.Equal(t, "argo", resource["k8s.namespace.name"])
+
+	spans := spansByName(traces)
+	run := spans["release"]
+	require.True(t, run.ParentSpanID().IsEmpty())
+	require.Equal(t, ptrace.StatusCodeError, run.Status().Code())
+	require.Equal(t, at(11, 0, 1), run.StartTimestamp().AsTime())
+	require.Equal(t, at(11, 3, 30), run.EndTimestamp().AsTime())
+
+	require.Equal(t, run.SpanID(), spans["build"].ParentSpanID())
+	require.Equal(t, ptrace.StatusCodeOk, spans["build"].Status().Code())
+	require.Equal(t, "worker-1", spans["build"].Attributes().AsRaw()["ci.argo.node.host"])
+	require.Equal(t, run.SpanID(), spans["publish"].ParentSpanID())
+	// Attempts of retried nodes are children of the retry node.
+	require.Equal(t, spans["publish"].SpanID(), spans["publish(0)"].ParentSpanID())
+	require.Equal(t, ptrace.StatusCodeError, spans["publish(0)"].Status().Code())
+
+	// Omitted nodes never ran, and end with the workflow.
+	notify := spans["notify"]
+	require.Equal(t, run.SpanID(), notify.ParentSpanID())
+	require.Equal(t, at(11, 3, 30), notify.StartTimestamp().AsTime())
+	require.Equal(t, at(11, 3, 30), notify.EndTimestamp().AsTime())
+}
+
+func TestWorkflowPhaseResult(t *testing.T) {
+	tests := map[string]struct {
+		phase, message string
+		expected       string
+	}{
+		"succeeded": {phase: phaseSucceeded, expected: "success"},
+		"failed":    {phase: phaseFailed, message: "child 'ci-x7k2p-1' failed", expected: "failure"},
+		"deadline":  {phase: phaseFailed, message: "Step exceeded its deadline", expected: "timeout"},
+		"stopped":   {phase: phaseFailed, message: "Stopped with strategy 'Terminate'", expected: "cancellation"},
+		"error":     {phase: phaseError, expected: "error"},
+		"omitted":   {phase: phaseOmitted, expected: "skip"},
+		"running":   {phase: "Running", expected: ""},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.expected, string(phaseResult(test.phase, test.message)))
+		})
+	}
+}
+
+func TestLegacyAttributes(t *testing.T) {
+	wf := loadWorkflow(t, "workflow_failed.json")
+
+	tests := map[string]struct {
+		semconv      semconv.Config
+		expectLegacy bool
+	}{
+		"legacy only":        {semconv: semconv.Config{}, expectLegacy: true},
+		"semconv and legacy": {semconv: semconv.Config{Enabled: true, EmitLegacy: true}, expectLegacy: true},
+		"semconv only":       {semconv: semconv.Config{Enabled: true}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			traces := workflowToTraces(wf, &Config{Semconv: test.semconv}, zaptest.NewLogger(t))
+			spans := spansByName(traces)
+
+			_, ok := spans["release"].Attributes().Get("ci.argo.workflow.name")
+			require.Equal(t, test.expectLegacy, ok)
+			_, ok = spans["build"].Attributes().Get("ci.argo.node.name")
+			require.Equal(t, test.expectLegacy, ok)
+			// Attributes without semantic conventions are kept
+			_, ok = spans["release"].Attributes().Get("ci.argo.workflow.phase")
+			require.True(t, ok)
+
+			_, ok = spans["build"].Attributes().Get(semconv.AttributeCICDPipelineTaskName)
+			require.Equal(t, test.semconv.Enabled, ok)
+		})
+	}
+}